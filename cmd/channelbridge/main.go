@@ -527,7 +527,7 @@ func (b *bridge) processSlackEventsPayload(payload map[string]any) (map[string]a
 		if !ok {
 			return map[string]any{"ok": true}, nil
 		}
-		if err := b.forwardSlackInbound(in.senderID, in.channelID, in.threadID, in.messageID, in.text, in.isGroup, in.wasMentioned); err != nil {
+		if err := b.forwardSlackInbound(in.senderID, in.channelID, in.threadID, in.messageID, in.text, in.isGroup, in.wasMentioned, in.audio...); err != nil {
 			return nil, err
 		}
 		return map[string]any{"ok": true}, nil
@@ -542,6 +542,7 @@ type slackInbound struct {
 	threadID     string
 	messageID    string
 	text         string
+	audio        []slackAudioFile
 	isGroup      bool
 	wasMentioned bool
 }
@@ -561,6 +562,7 @@ func normalizeSlackInboundEvent(event map[string]any, botUserID string) (slackIn
 			threadID:     strings.TrimSpace(asString(event["thread_ts"])),
 			messageID:    firstNonEmpty(asString(event["ts"]), asString(event["event_ts"])),
 			text:         text,
+			audio:        slackAudioFilesFromEvent(event["files"]),
 			isGroup:      true,
 			wasMentioned: true,
 		}, true
@@ -598,6 +600,7 @@ func normalizeSlackInboundEvent(event map[string]any, botUserID string) (slackIn
 	}
 
 	text := strings.TrimSpace(asString(msg["text"]))
+	audio := slackAudioFilesFromEvent(msg["files"])
 	switch subtype {
 	case "message_deleted":
 		if text == "" {
@@ -607,6 +610,9 @@ func normalizeSlackInboundEvent(event map[string]any, botUserID string) (slackIn
 			text = "[message deleted]"
 		}
 	case "file_share":
+		if text == "" && len(audio) > 0 {
+			text = "[Audio Message]"
+		}
 		if text == "" {
 			text = "[file shared]"
 		}
@@ -640,12 +646,151 @@ func normalizeSlackInboundEvent(event map[string]any, botUserID string) (slackIn
 		threadID:     threadID,
 		messageID:    messageID,
 		text:         text,
+		audio:        audio,
 		isGroup:      isGroup,
 		wasMentioned: wasMentioned,
 	}, true
 }
 
-func (b *bridge) forwardSlackInbound(senderID, channelID, threadID, messageID, text string, isGroup, wasMentioned bool) error {
+// slackAudioFile is a voice clip or audio upload on an inbound message. The
+// bridge downloads it with the bot token and forwards it inline, because the
+// gateway has no Slack credentials to fetch url_private itself.
+type slackAudioFile struct {
+	url  string
+	name string
+}
+
+// inboundAudioMimeExts maps audio MIME types to the extension the gateway's
+// voice pipeline recognizes.
+var inboundAudioMimeExts = map[string]string{
+	"audio/webm": ".webm", "audio/mp4": ".m4a", "audio/x-m4a": ".m4a", "audio/m4a": ".m4a",
+	"audio/mpeg": ".mp3", "audio/mp3": ".mp3", "audio/ogg": ".ogg", "audio/opus": ".opus",
+	"audio/wav": ".wav", "audio/x-wav": ".wav", "audio/wave": ".wav", "audio/flac": ".flac",
+	"audio/aac": ".aac", "audio/amr": ".amr",
+}
+
+// inboundAudioName returns a file name with an audio extension for an
+// attachment, or false when it is not audio.
+func inboundAudioName(name, mimetype string) (string, bool) {
+	mimetype = strings.ToLower(strings.TrimSpace(mimetype))
+	if i := strings.IndexByte(mimetype, ';'); i >= 0 {
+		mimetype = strings.TrimSpace(mimetype[:i])
+	}
+	name = path.Base(strings.TrimSpace(name))
+	ext := strings.ToLower(path.Ext(name))
+	known := false
+	for _, e := range inboundAudioMimeExts {
+		if e == ext {
+			known = true
+			break
+		}
+	}
+	if !known {
+		mimeExt, ok := inboundAudioMimeExts[mimetype]
+		if !ok {
+			return "", false
+		}
+		ext = mimeExt
+	} else if mimetype != "" && !strings.HasPrefix(mimetype, "audio/") {
+		return "", false
+	}
+	base := strings.TrimSuffix(name, path.Ext(name))
+	if base == "" || base == "." || base == "/" {
+		base = "audio"
+	}
+	return base + ext, true
+}
+
+func slackAudioFilesFromEvent(raw any) []slackAudioFile {
+	files, _ := raw.([]any)
+	var out []slackAudioFile
+	for _, f := range files {
+		file, _ := f.(map[string]any)
+		if af, ok := newSlackAudioFile(asString(file["name"]), asString(file["mimetype"]), asString(file["url_private_download"])); ok {
+			out = append(out, af)
+		}
+	}
+	return out
+}
+
+func slackAudioFilesFromAPI(files []slack.File) []slackAudioFile {
+	var out []slackAudioFile
+	for _, f := range files {
+		if af, ok := newSlackAudioFile(f.Name, f.Mimetype, f.URLPrivateDownload); ok {
+			out = append(out, af)
+		}
+	}
+	return out
+}
+
+func newSlackAudioFile(name, mimetype, rawURL string) (slackAudioFile, bool) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" || !strings.HasPrefix(strings.ToLower(strings.TrimSpace(mimetype)), "audio/") {
+		return slackAudioFile{}, false
+	}
+	name, ok := inboundAudioName(name, mimetype)
+	if !ok {
+		return slackAudioFile{}, false
+	}
+	return slackAudioFile{url: rawURL, name: name}, true
+}
+
+// slackInboundAudio downloads voice clips for forwarding; failures are
+// logged and the message is forwarded without them.
+func (b *bridge) slackInboundAudio(files []slackAudioFile) []mediaFile {
+	token := strings.TrimSpace(b.cfg.SlackBotToken)
+	if token == "" || len(files) == 0 {
+		return nil
+	}
+	var out []mediaFile
+	for _, f := range files {
+		u, err := validateMediaDownloadURL(f.url)
+		if err != nil {
+			log.Printf("slack audio skipped: %v", err)
+			continue
+		}
+		data, err := b.fetchInboundAudio(u.String(), token)
+		if err != nil {
+			log.Printf("slack audio download failed: %v", err)
+			continue
+		}
+		out = append(out, mediaFile{Filename: f.name, ContentBase64: base64.StdEncoding.EncodeToString(data)})
+	}
+	return out
+}
+
+// fetchInboundAudio downloads an inbound attachment, sending token as a
+// bearer credential when set.
+func (b *bridge) fetchInboundAudio(rawURL, token string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("audio fetch status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxInlineMediaBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxInlineMediaBytes {
+		return nil, errors.New("audio file too large")
+	}
+	if len(data) == 0 {
+		return nil, errors.New("empty audio file")
+	}
+	return data, nil
+}
+
+func (b *bridge) forwardSlackInbound(senderID, channelID, threadID, messageID, text string, isGroup, wasMentioned bool, audio ...slackAudioFile) error {
 	channelID = strings.TrimSpace(channelID)
 	senderID = strings.TrimSpace(senderID)
 	if channelID == "" || senderID == "" {
@@ -655,7 +800,7 @@ func (b *bridge) forwardSlackInbound(senderID, channelID, threadID, messageID, t
 		b.noteInboundDeduped(true)
 		return nil
 	}
-	err := b.postInbound("/api/v1/channels/slack/inbound", b.cfg.KafclawSlackInboundToken, map[string]any{
+	payload := map[string]any{
 		"account_id":       strings.TrimSpace(b.cfg.SlackAccountID),
 		"sender_id":        senderID,
 		"chat_id":          channelID,
@@ -666,7 +811,11 @@ func (b *bridge) forwardSlackInbound(senderID, channelID, threadID, messageID, t
 		"was_mentioned":    wasMentioned,
 		"history_limit":    b.cfg.SlackHistoryLimit,
 		"dm_history_limit": b.cfg.SlackDMHistoryLimit,
-	})
+	}
+	if files := b.slackInboundAudio(audio); len(files) > 0 {
+		payload["media_files"] = files
+	}
+	err := b.postInbound("/api/v1/channels/slack/inbound", b.cfg.KafclawSlackInboundToken, payload)
 	if err != nil {
 		b.noteInboundForward(false, err)
 		log.Printf("slack inbound forward failed: %v", err)
@@ -684,6 +833,9 @@ func (b *bridge) forwardSlackSlashCommand(cmd slack.SlashCommand) error {
 	return b.forwardSlackInbound(cmd.UserID, cmd.ChannelID, "", cmd.TriggerID, content, isGroup, true)
 }
 
+// slackApprovalActionPrefix prefixes the action_id of KafClaw approval buttons.
+const slackApprovalActionPrefix = "kafclaw_approval_"
+
 func (b *bridge) forwardSlackInteraction(cb slack.InteractionCallback) error {
 	channelID := strings.TrimSpace(cb.Channel.ID)
 	if channelID == "" {
@@ -701,6 +853,10 @@ func (b *bridge) forwardSlackInteraction(cb slack.InteractionCallback) error {
 		}
 	}
 	content := strings.TrimSpace("interactive " + actionID + " " + actionVal)
+	if strings.HasPrefix(actionID, slackApprovalActionPrefix) && actionVal != "" {
+		// Approval buttons carry "approve:<id>" / "deny:<id>" as their value.
+		content = actionVal
+	}
 	if content == "interactive" {
 		content = "interactive " + strings.TrimSpace(string(cb.Type))
 	}
@@ -747,7 +903,11 @@ func (b *bridge) runSlackSocketMode(client *socketmode.Client) {
 					if botID := strings.TrimSpace(b.cfg.SlackBotUserID); botID != "" {
 						wasMentioned = strings.Contains(in.Text, "<@"+botID+">")
 					}
-					_ = b.forwardSlackInbound(in.User, in.Channel, in.ThreadTimeStamp, in.TimeStamp, in.Text, in.ChannelType != "im", wasMentioned)
+					var audio []slackAudioFile
+					if in.Message != nil {
+						audio = slackAudioFilesFromAPI(in.Message.Files)
+					}
+					_ = b.forwardSlackInbound(in.User, in.Channel, in.ThreadTimeStamp, in.TimeStamp, in.Text, in.ChannelType != "im", wasMentioned, audio...)
 				case *slackevents.AppMentionEvent:
 					if in == nil {
 						continue
@@ -791,6 +951,7 @@ func (b *bridge) handleSlackOutbound(w http.ResponseWriter, r *http.Request) {
 		StreamChunkChars  int            `json:"stream_chunk_chars"`
 		Content           string         `json:"content"`
 		MediaURLs         []string       `json:"media_urls"`
+		MediaFiles        []mediaFile    `json:"media_files"`
 		Card              map[string]any `json:"card"`
 		Action            string         `json:"action"`
 		ActionParams      map[string]any `json:"action_params"`
@@ -806,8 +967,8 @@ func (b *bridge) handleSlackOutbound(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "chat_id required", http.StatusBadRequest)
 		return
 	}
-	if strings.TrimSpace(req.Content) == "" && len(req.MediaURLs) == 0 && len(req.Card) == 0 && strings.TrimSpace(req.Action) == "" {
-		http.Error(w, "content, media_urls, card or action required", http.StatusBadRequest)
+	if strings.TrimSpace(req.Content) == "" && len(req.MediaURLs) == 0 && len(req.MediaFiles) == 0 && len(req.Card) == 0 && strings.TrimSpace(req.Action) == "" {
+		http.Error(w, "content, media_urls, media_files, card or action required", http.StatusBadRequest)
 		return
 	}
 	accountID := strings.TrimSpace(req.AccountID)
@@ -827,7 +988,9 @@ func (b *bridge) handleSlackOutbound(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	threadID := b.resolveReplyThread("slack", accountID, req.ChatID, req.ThreadID, req.ReplyMode, defaultReplyMode)
-	if act := strings.TrimSpace(strings.ToLower(req.Action)); act != "" {
+	// Approval prompts are posted like any message (their card carries the
+	// buttons); every other action is a Slack API operation.
+	if act := strings.TrimSpace(strings.ToLower(req.Action)); act != "" && act != "approval_request" {
 		result, err := b.slackHandleAction(act, channelID, strings.TrimSpace(threadID), req.Content, req.ActionParams)
 		if err != nil {
 			b.noteOutbound(false, true, err)
@@ -845,6 +1008,18 @@ func (b *bridge) handleSlackOutbound(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	for _, f := range req.MediaFiles {
+		name, data, err := f.decode()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := b.slackUploadFile(channelID, threadID, name, data, ""); err != nil {
+			b.noteOutbound(false, true, err)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
 	streamMode := normalizeSlackStreamMode(firstNonEmpty(req.StreamMode, b.cfg.SlackStreamMode))
 	nativeStreaming := b.cfg.SlackNativeStreaming
 	if req.NativeStreaming != nil {
@@ -1508,15 +1683,49 @@ func (b *bridge) slackRetryDecision(err error) (bool, error) {
 	return false, err
 }
 
+// mediaFile is an attachment sent inline by the gateway, e.g. a synthesized
+// voice reply that has no public URL.
+type mediaFile struct {
+	Filename      string `json:"filename"`
+	ContentBase64 string `json:"content_base64"`
+}
+
+const maxInlineMediaBytes = 10 << 20
+
+func (f mediaFile) decode() (string, []byte, error) {
+	name := path.Base(strings.TrimSpace(f.Filename))
+	if name == "." || name == "/" || name == "" {
+		name = "upload.bin"
+	}
+	if base64.StdEncoding.DecodedLen(len(f.ContentBase64)) > maxInlineMediaBytes {
+		return "", nil, errors.New("media file too large")
+	}
+	data, err := base64.StdEncoding.DecodeString(f.ContentBase64)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid media file content: %w", err)
+	}
+	if len(data) == 0 {
+		return "", nil, errors.New("empty media file")
+	}
+	return name, data, nil
+}
+
 func (b *bridge) slackUploadMedia(channelID, threadID, mediaURL, caption string) error {
-	token := strings.TrimSpace(b.cfg.SlackBotToken)
-	if token == "" {
+	if strings.TrimSpace(b.cfg.SlackBotToken) == "" {
 		return errors.New("missing SLACK_BOT_TOKEN")
 	}
 	data, filename, err := b.downloadMedia(mediaURL)
 	if err != nil {
 		return err
 	}
+	return b.slackUploadFile(channelID, threadID, filename, data, caption)
+}
+
+func (b *bridge) slackUploadFile(channelID, threadID, filename string, data []byte, caption string) error {
+	token := strings.TrimSpace(b.cfg.SlackBotToken)
+	if token == "" {
+		return errors.New("missing SLACK_BOT_TOKEN")
+	}
 	return withRetry(3, 200*time.Millisecond, func() (bool, error) {
 		var body bytes.Buffer
 		w := multipart.NewWriter(&body)
@@ -1643,7 +1852,7 @@ func (b *bridge) handleTeamsMessages(w http.ResponseWriter, r *http.Request) {
 	b.teamsMu.Unlock()
 	_ = b.saveState()
 
-	err = b.postInbound("/api/v1/channels/msteams/inbound", b.cfg.KafclawMSTeamsInboundToken, map[string]any{
+	payload := map[string]any{
 		"account_id":         strings.TrimSpace(b.cfg.MSTeamsAccountID),
 		"sender_id":          inbound.senderID,
 		"user_id":            inbound.userID,
@@ -1662,7 +1871,11 @@ func (b *bridge) handleTeamsMessages(w http.ResponseWriter, r *http.Request) {
 		"tenant_id":          inbound.tenantID,
 		"service_url":        inbound.serviceURL,
 		"service_url_domain": inbound.serviceDomain,
-	})
+	}
+	if files := b.teamsInboundAudio(inbound.audio); len(files) > 0 {
+		payload["media_files"] = files
+	}
+	err = b.postInbound("/api/v1/channels/msteams/inbound", b.cfg.KafclawMSTeamsInboundToken, payload)
 	if err != nil {
 		b.noteInboundForward(false, err)
 		log.Printf("teams inbound forward failed: %v", err)
@@ -1689,10 +1902,82 @@ type teamsInbound struct {
 	channelID        string
 	tenantID         string
 	mediaURLs        []string
+	audio            []teamsAudioAttachment
 	isGroup          bool
 	wasMentioned     bool
 }
 
+// teamsAudioAttachment is a voice message or audio file on an inbound
+// activity. Attachments served by the Bot Framework (same host as the
+// activity's serviceUrl) need the bot token; file download URLs are
+// pre-authorized.
+type teamsAudioAttachment struct {
+	url  string
+	name string
+	auth bool
+}
+
+func extractTeamsInboundAudio(activity map[string]any, allowHosts []string, serviceURL string) []teamsAudioAttachment {
+	atts, _ := activity["attachments"].([]any)
+	serviceHost := hostOnly(serviceURL)
+	var out []teamsAudioAttachment
+	for _, raw := range atts {
+		att, _ := raw.(map[string]any)
+		if att == nil {
+			continue
+		}
+		contentType := strings.ToLower(strings.TrimSpace(asString(att["contentType"])))
+		content, _ := att["content"].(map[string]any)
+		var rawURL, name string
+		var ok bool
+		switch {
+		case strings.HasPrefix(contentType, "audio/"):
+			rawURL = strings.TrimSpace(asString(att["contentUrl"]))
+			name, ok = inboundAudioName(asString(att["name"]), contentType)
+		case contentType == "application/vnd.microsoft.teams.file.download.info" && content != nil:
+			rawURL = strings.TrimSpace(asString(content["downloadUrl"]))
+			fileName := asString(att["name"])
+			if path.Ext(fileName) == "" {
+				fileName += "." + strings.TrimSpace(asString(content["fileType"]))
+			}
+			name, ok = inboundAudioName(fileName, "")
+		}
+		if !ok || rawURL == "" || !isURLAllowed(rawURL, allowHosts) {
+			continue
+		}
+		out = append(out, teamsAudioAttachment{
+			url:  rawURL,
+			name: name,
+			auth: serviceHost != "" && hostOnly(rawURL) == serviceHost,
+		})
+	}
+	return out
+}
+
+// teamsInboundAudio downloads voice messages for forwarding; failures are
+// logged and the message is forwarded without them.
+func (b *bridge) teamsInboundAudio(atts []teamsAudioAttachment) []mediaFile {
+	var out []mediaFile
+	for _, a := range atts {
+		token := ""
+		if a.auth {
+			t, err := b.getTeamsAccessToken()
+			if err != nil {
+				log.Printf("teams audio download skipped: %v", err)
+				continue
+			}
+			token = t
+		}
+		data, err := b.fetchInboundAudio(a.url, token)
+		if err != nil {
+			log.Printf("teams audio download failed: %v", err)
+			continue
+		}
+		out = append(out, mediaFile{Filename: a.name, ContentBase64: base64.StdEncoding.EncodeToString(data)})
+	}
+	return out
+}
+
 func normalizeTeamsInbound(activity map[string]any, mediaAllowHosts []string) teamsInbound {
 	from, _ := activity["from"].(map[string]any)
 	conv, _ := activity["conversation"].(map[string]any)
@@ -1717,6 +2002,7 @@ func normalizeTeamsInbound(activity map[string]any, mediaAllowHosts []string) te
 		channelID:        strings.TrimSpace(asString(channel["id"])),
 		tenantID:         strings.TrimSpace(asString(tenant["id"])),
 		mediaURLs:        mediaURLs,
+		audio:            extractTeamsInboundAudio(activity, mediaAllowHosts, asString(activity["serviceUrl"])),
 	}
 	if out.userID == "" {
 		out.userID = out.senderID
@@ -1865,6 +2151,10 @@ func isURLAllowed(rawURL string, allowHosts []string) bool {
 }
 
 func teamsWasMentioned(activity map[string]any, botID, botName string) bool {
+	// Approval card submits are addressed to the bot by construction.
+	if val, ok := activity["value"].(map[string]any); ok && strings.TrimSpace(asString(val["kafclaw_approval"])) != "" {
+		return true
+	}
 	botID = strings.TrimSpace(botID)
 	botName = strings.TrimSpace(strings.ToLower(botName))
 	if ents, ok := activity["entities"].([]any); ok {
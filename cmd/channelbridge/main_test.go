@@ -11,6 +11,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
@@ -215,6 +216,74 @@ func TestSlackEventsFileShareSubtypeForwardsFallbackText(t *testing.T) {
 	}
 }
 
+func TestSlackEventsVoiceClipForwardsInlineAudio(t *testing.T) {
+	var got map[string]any
+	var auth string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/channels/slack/inbound":
+			defer r.Body.Close()
+			_ = json.NewDecoder(r.Body).Decode(&got)
+		case "/files-pri/T1-F1/download/audio_message.webm":
+			auth = r.Header.Get("Authorization")
+			_, _ = w.Write([]byte("webm-bytes"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	b.cfg.SlackBotToken = "xoxb-test"
+	apiBase, _ := url.Parse(api.URL)
+	b.client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.EqualFold(req.URL.Hostname(), "files.slack.com") {
+			clone := req.Clone(req.Context())
+			clone.URL.Scheme = apiBase.Scheme
+			clone.URL.Host = apiBase.Host
+			return http.DefaultTransport.RoundTrip(clone)
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+	payload := map[string]any{
+		"type":     "event_callback",
+		"event_id": "EvVoice",
+		"event": map[string]any{
+			"type":         "message",
+			"subtype":      "file_share",
+			"channel":      "D777",
+			"channel_type": "im",
+			"user":         "U777",
+			"ts":           "171.400",
+			"files": []any{
+				map[string]any{"name": "audio_message.webm", "mimetype": "audio/webm", "url_private_download": "https://files.slack.com/files-pri/T1-F1/download/audio_message.webm"},
+				map[string]any{"name": "report.pdf", "mimetype": "application/pdf", "url_private_download": "https://files.slack.com/files-pri/T1-F2/download/report.pdf"},
+			},
+		},
+	}
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest(http.MethodPost, "/slack/events", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	b.handleSlackEvents(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if auth != "Bearer xoxb-test" {
+		t.Fatalf("voice clip fetched with auth %q", auth)
+	}
+	if asString(got["text"]) != "[Audio Message]" {
+		t.Fatalf("expected audio placeholder text, got %#v", got["text"])
+	}
+	files, _ := got["media_files"].([]any)
+	if len(files) != 1 {
+		t.Fatalf("expected one inline audio file, got %#v", got["media_files"])
+	}
+	file, _ := files[0].(map[string]any)
+	if asString(file["filename"]) != "audio_message.webm" || asString(file["content_base64"]) != base64.StdEncoding.EncodeToString([]byte("webm-bytes")) {
+		t.Fatalf("unexpected inline audio: %#v", file)
+	}
+}
+
 func TestSlackEventsMessageDeletedForwardsTombstone(t *testing.T) {
 	var got map[string]any
 	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -463,6 +532,57 @@ func TestSlackInteractionsForwardInbound(t *testing.T) {
 	}
 }
 
+func TestSlackApprovalInteractionForwardsVerdict(t *testing.T) {
+	var got map[string]any
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/channels/slack/inbound" {
+			defer r.Body.Close()
+			_ = json.NewDecoder(r.Body).Decode(&got)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	payload, _ := json.Marshal(map[string]any{
+		"type":      "block_actions",
+		"user":      map[string]any{"id": "U22"},
+		"channel":   map[string]any{"id": "C22"},
+		"action_ts": "171.224",
+		"actions": []map[string]any{
+			{"block_id": "kafclaw_approval:ap-7", "action_id": "kafclaw_approval_deny", "value": "deny:ap-7"},
+		},
+	})
+	form := url.Values{}
+	form.Set("payload", string(payload))
+	req := httptest.NewRequest(http.MethodPost, "/slack/interactions", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	b.handleSlackInteractions(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if asString(got["text"]) != "deny:ap-7" || asString(got["sender_id"]) != "U22" {
+		t.Fatalf("expected approval verdict as text, got %#v", got)
+	}
+}
+
+func TestTeamsApprovalSubmitCountsAsMention(t *testing.T) {
+	activity := map[string]any{
+		"type":  "message",
+		"value": map[string]any{"text": "approve:ap-8", "kafclaw_approval": "ap-8"},
+	}
+	if got := extractTeamsInboundText(activity); got != "approve:ap-8" {
+		t.Fatalf("expected submit text, got %q", got)
+	}
+	if !teamsWasMentioned(activity, "bot-id", "KafClaw") {
+		t.Fatal("expected approval submit to count as addressed to the bot")
+	}
+	if teamsWasMentioned(map[string]any{"type": "message", "value": map[string]any{"poll_id": "p1"}}, "bot-id", "KafClaw") {
+		t.Fatal("expected other submits to keep mention gating")
+	}
+}
+
 func TestTeamsInboundRequiresBearerWhenConfigured(t *testing.T) {
 	var forwards int32
 	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -847,6 +967,63 @@ func TestTeamsInboundNormalizationExtractsAttachmentMediaAndFallbackText(t *test
 	}
 }
 
+func TestTeamsInboundVoiceMessageForwardsInlineAudio(t *testing.T) {
+	var got map[string]any
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/channels/msteams/inbound":
+			defer r.Body.Close()
+			_ = json.NewDecoder(r.Body).Decode(&got)
+		case "/download/voice":
+			if r.Header.Get("Authorization") != "" {
+				t.Errorf("pre-authorized download url must not receive the bot token")
+			}
+			_, _ = w.Write([]byte("m4a-bytes"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	payload := map[string]any{
+		"type":         "message",
+		"id":           "activity-voice-1",
+		"from":         map[string]any{"id": "29:user-3"},
+		"conversation": map[string]any{"id": "a:personal-3", "conversationType": "personal"},
+		"serviceUrl":   "https://smba.trafficmanager.net/emea",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.teams.file.download.info",
+			"name":        "Voice message",
+			"content":     map[string]any{"downloadUrl": api.URL + "/download/voice", "fileType": "m4a"},
+		}},
+	}
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest(http.MethodPost, "/teams/messages", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	b.handleTeamsMessages(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	files, _ := got["media_files"].([]any)
+	if len(files) != 1 {
+		t.Fatalf("expected one inline audio file, got %#v", got["media_files"])
+	}
+	file, _ := files[0].(map[string]any)
+	if asString(file["filename"]) != "Voice message.m4a" || asString(file["content_base64"]) != base64.StdEncoding.EncodeToString([]byte("m4a-bytes")) {
+		t.Fatalf("unexpected inline audio: %#v", file)
+	}
+
+	atts := extractTeamsInboundAudio(map[string]any{"attachments": []any{
+		map[string]any{"contentType": "audio/ogg", "contentUrl": "https://smba.trafficmanager.net/emea/v3/attachments/a1/views/original"},
+		map[string]any{"contentType": "audio/ogg", "contentUrl": "https://evil.example.com/a.ogg"},
+		map[string]any{"contentType": "image/png", "contentUrl": "https://smba.trafficmanager.net/emea/v3/attachments/a2/views/original"},
+	}}, []string{"smba.trafficmanager.net"}, "https://smba.trafficmanager.net/emea")
+	if len(atts) != 1 || !atts[0].auth || atts[0].name != "audio.ogg" {
+		t.Fatalf("unexpected audio attachments: %+v", atts)
+	}
+}
+
 func TestTeamsInboundAttachmentAllowlistFiltersMediaHosts(t *testing.T) {
 	var got map[string]any
 	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1056,6 +1233,59 @@ func TestSlackOutboundMediaUpload(t *testing.T) {
 	}
 }
 
+func TestSlackOutboundInlineMediaFiles(t *testing.T) {
+	var uploadedName string
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files.uploadV2":
+			_ = r.ParseMultipartForm(2 << 20)
+			if f, hdr, err := r.FormFile("file"); err == nil {
+				data, _ := io.ReadAll(f)
+				if string(data) != "voice-reply" {
+					t.Fatalf("unexpected upload body %q", data)
+				}
+				uploadedName = hdr.Filename
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+		case "/chat.postMessage":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "ts": "1"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer slackAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.SlackAPIBase = slackAPI.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+
+	reqBody, _ := json.Marshal(map[string]any{
+		"chat_id": "C123",
+		"media_files": []map[string]string{{
+			"filename":       "../../reply.mp3",
+			"content_base64": base64.StdEncoding.EncodeToString([]byte("voice-reply")),
+		}},
+	})
+	w := httptest.NewRecorder()
+	b.handleSlackOutbound(w, httptest.NewRequest(http.MethodPost, "/slack/outbound", bytes.NewReader(reqBody)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if uploadedName != "reply.mp3" {
+		t.Fatalf("expected sanitized upload filename, got %q", uploadedName)
+	}
+
+	bad, _ := json.Marshal(map[string]any{
+		"chat_id":     "C123",
+		"media_files": []map[string]string{{"filename": "x.mp3", "content_base64": "!!"}},
+	})
+	w = httptest.NewRecorder()
+	b.handleSlackOutbound(w, httptest.NewRequest(http.MethodPost, "/slack/outbound", bytes.NewReader(bad)))
+	if w.Code == http.StatusOK {
+		t.Fatal("expected invalid base64 media file to be rejected")
+	}
+}
+
 type roundTripFunc func(*http.Request) (*http.Response, error)
 
 func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -1308,6 +1538,51 @@ func TestSlackOutboundActionReact(t *testing.T) {
 	}
 }
 
+func TestSlackOutboundApprovalRequestPostsCard(t *testing.T) {
+	var posted int32
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chat.postMessage" {
+			_ = r.ParseForm()
+			if strings.Contains(r.FormValue("blocks"), "kafclaw_approval_approve") {
+				atomic.AddInt32(&posted, 1)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "ts": "1"})
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer slackAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.SlackAPIBase = slackAPI.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+
+	body, _ := json.Marshal(map[string]any{
+		"chat_id":       "C111",
+		"content":       "approve?",
+		"action":        "approval_request",
+		"action_params": map[string]any{"approval_id": "ap-9"},
+		"card": map[string]any{
+			"blocks": []map[string]any{{
+				"type": "actions",
+				"elements": []map[string]any{{
+					"type": "button", "action_id": "kafclaw_approval_approve", "value": "approve:ap-9",
+					"text": map[string]any{"type": "plain_text", "text": "Approve"},
+				}},
+			}},
+		},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/slack/outbound", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	b.handleSlackOutbound(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if atomic.LoadInt32(&posted) != 1 {
+		t.Fatal("expected approval card to be posted with its buttons")
+	}
+}
+
 func TestSlackOutboundCardBlocks(t *testing.T) {
 	var sawBlocks bool
 	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -5,9 +5,12 @@ import (
 	"os"
 
 	"github.com/KafClaw/KafClaw/internal/cli"
+	"github.com/KafClaw/KafClaw/internal/sandbox"
 )
 
 func main() {
+	// Becomes the sandbox helper when re-executed for an isolated command.
+	sandbox.Init()
 	if err := cli.Execute(); err != nil {
 		os.Exit(1)
 	}
@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/approval"
+	"github.com/KafClaw/KafClaw/internal/bus"
+)
+
+// approvalConsoleChatID is the chat the web dashboard uses to inject
+// approval decisions of admins; they count as operator decisions by the
+// sender.
+const approvalConsoleChatID = "approval"
+
+// approvalPrompt renders the chat prompt for a pending approval. note,
+// when set, is prepended (e.g. the escalation notice).
+func approvalPrompt(req *approval.ApprovalRequest, note string) string {
+	var b strings.Builder
+	if note != "" {
+		b.WriteString(note + "\n")
+	}
+	fmt.Fprintf(&b, "Tool \"%s\" (tier %d) requires approval.\nArgs: %s\n", req.Tool, req.Tier, formatArgsPreview(req.Arguments))
+	if req.Routed {
+		need := "Needs approval"
+		if req.Quorum > 1 {
+			need = fmt.Sprintf("Needs %d approvals", req.Quorum)
+		}
+		if approvers := req.EligibleApprovers(); len(approvers) > 0 {
+			need += " from " + strings.Join(approvers, ", ")
+		}
+		if !req.AllowRequester {
+			need += " (not the requester)"
+		}
+		b.WriteString(need + ".\n")
+	}
+	fmt.Fprintf(&b, "Reply approve:%s or deny:%s", req.ApprovalID, req.ApprovalID)
+	fmt.Fprintf(&b, "\nTo remember the approval, add session, a duration or always (e.g. approve:%s 1h).", req.ApprovalID)
+	return b.String()
+}
+
+// approvalGrantText returns what follows the ID in an "approve:<id> ..."
+// reply: the grant scope and optional argument pattern.
+func approvalGrantText(content string) string {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(content), "approve:")
+	if !ok {
+		return ""
+	}
+	rest = strings.TrimLeft(rest, " \t")
+	if i := strings.IndexAny(rest, " \t\n"); i >= 0 {
+		return strings.TrimSpace(rest[i:])
+	}
+	return ""
+}
+
+// approvalActionParams describes a pending approval for channels that
+// render native approve/deny controls.
+func approvalActionParams(req *approval.ApprovalRequest) map[string]any {
+	params := map[string]any{
+		"approval_id": req.ApprovalID,
+		"tool":        req.Tool,
+		"tier":        req.Tier,
+		"quorum":      req.Quorum,
+	}
+	if approvers := req.EligibleApprovers(); len(approvers) > 0 {
+		params["approvers"] = approvers
+	}
+	if req.Escalated {
+		params["escalated"] = true
+	}
+	return params
+}
+
+// handleApprovalResponse applies an approve:/deny: reply and returns the
+// confirmation for the chat. grantText, when set on an approval, asks to
+// remember it (see approval.ParseGrantSpec).
+func (l *Loop) handleApprovalResponse(msg *bus.InboundMessage, id string, approved bool, grantText string) string {
+	by := approval.Responder{Channel: msg.Channel, ID: msg.SenderID}
+	if msg.Channel == "webui" && msg.ChatID == approvalConsoleChatID && msg.MessageType() == bus.MessageTypeInternal {
+		by = approval.Responder{Channel: approval.Operator.Channel, ID: msg.SenderID}
+	}
+	var out approval.Outcome
+	var err error
+	switch {
+	case approved && grantText != "":
+		spec, perr := approval.ParseGrantSpec(grantText)
+		if perr != nil {
+			return fmt.Sprintf("Approval %s not applied: %v.", id, perr)
+		}
+		out, err = l.approvalMgr.ApproveWithGrant(id, by, spec)
+	default:
+		out, err = l.approvalMgr.RespondAs(id, by, approved)
+	}
+	switch {
+	case errors.Is(err, approval.ErrNotApprover):
+		slog.Warn("Approval response rejected", "id", id, "channel", msg.Channel, "sender", msg.SenderID, "error", err)
+		return fmt.Sprintf("You are not an eligible approver for %s.", id)
+	case err != nil:
+		slog.Warn("Approval response failed", "id", id, "error", err)
+		return fmt.Sprintf("No pending approval found for ID %s.", id)
+	case !out.Decided:
+		return fmt.Sprintf("Approval %s: approval recorded (%d/%d).", id, out.Approvals, out.Quorum)
+	case out.Grant != nil:
+		return fmt.Sprintf("Approval %s: approved. Remembered as grant %s: %s.", id, out.Grant.GrantID, approval.DescribeGrant(*out.Grant))
+	case approved && grantText != "" && out.Quorum > 1:
+		return fmt.Sprintf("Approval %s: approved (not remembered: every approver must ask for the same grant).", id)
+	case approved && grantText != "" && l.timeline == nil:
+		return fmt.Sprintf("Approval %s: approved (not remembered: no timeline database).", id)
+	case approved && grantText != "":
+		return fmt.Sprintf("Approval %s: approved (not remembered: storing the grant failed).", id)
+	}
+	return fmt.Sprintf("Approval %s: %s.", id, approvalVerdict(approved))
+}
+
+// publishApprovalEscalation re-sends an escalated prompt to the originating
+// chat and to every escalation target of the form "<channel>:<chatID>".
+func (l *Loop) publishApprovalEscalation(req approval.ApprovalRequest) {
+	if l.bus == nil {
+		return
+	}
+	note := fmt.Sprintf("Escalated: no decision on approval %s yet.", req.ApprovalID)
+	send := func(channel, chatID, threadID string) {
+		l.bus.PublishOutbound(&bus.OutboundMessage{
+			Channel:      channel,
+			ChatID:       chatID,
+			ThreadID:     threadID,
+			TraceID:      req.TraceID,
+			Content:      approvalPrompt(&req, note),
+			Action:       "approval_request",
+			ActionParams: approvalActionParams(&req),
+		})
+	}
+	if req.Channel != "" && req.ChatID != "" {
+		send(req.Channel, req.ChatID, req.ThreadID)
+	}
+	for _, target := range req.EscalateTo {
+		channel, chatID, ok := strings.Cut(target, ":")
+		if !ok || channel == "person" || chatID == "" || (channel == req.Channel && chatID == req.ChatID) {
+			continue
+		}
+		send(channel, chatID, "")
+	}
+}
+
+func approvalVerdict(approved bool) string {
+	if approved {
+		return "approved"
+	}
+	return "denied"
+}
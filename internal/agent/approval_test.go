@@ -10,6 +10,7 @@ import (
 
 	"github.com/KafClaw/KafClaw/internal/approval"
 	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
 	"github.com/KafClaw/KafClaw/internal/policy"
 	"github.com/KafClaw/KafClaw/internal/provider"
 )
@@ -391,6 +392,8 @@ func TestParseApprovalResponse(t *testing.T) {
 		{"  approve:def456  ", "def456", true, true},
 		{"  deny:def456  ", "def456", true, false},
 		{"hello world", "", false, false},
+		{"approve:abc123 1h", "abc123", true, true},
+		{"approve:abc123 always git push *", "abc123", true, true},
 		{"approve:", "", false, false},
 		{"deny:", "", false, false},
 		{"", "", false, false},
@@ -485,3 +488,298 @@ func TestApprovalRunInterception(t *testing.T) {
 	}
 	t.Logf("Run() interception test passed for approval ID=%s", id)
 }
+
+// TestProcessDirectToolProgressAndRespondApproval covers the hooks used by the
+// interactive CLI: approval prompts on the session's channel answered through
+// RespondApproval, and ToolProgress around the approved call.
+func TestProcessDirectToolProgressAndRespondApproval(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	tmpDir := t.TempDir()
+	mock := &mockProvider{
+		responses: []provider.ChatResponse{
+			{ToolCalls: []provider.ToolCall{{ID: "call_1", Name: "exec", Arguments: map[string]any{"command": "echo hello"}}}},
+			{Content: "done"},
+		},
+	}
+	policyEngine := policy.NewDefaultEngine()
+
+	var mu sync.Mutex
+	var progress []ToolProgress
+	loop := NewLoop(LoopOptions{
+		Bus:           msgBus,
+		Provider:      mock,
+		Policy:        policyEngine,
+		Workspace:     tmpDir,
+		WorkRepo:      tmpDir,
+		Model:         "mock-model",
+		MaxIterations: 5,
+		ToolProgress: func(p ToolProgress) {
+			mu.Lock()
+			progress = append(progress, p)
+			mu.Unlock()
+		},
+	})
+
+	var outbound outboundCapture
+	msgBus.Subscribe("tui", outbound.add)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go msgBus.DispatchOutbound(ctx)
+
+	done := make(chan string, 1)
+	go func() {
+		resp, _ := loop.ProcessDirectWithTrace(ctx, "run it", "tui:default", "trace-tui-1")
+		done <- resp
+	}()
+
+	approvalID := waitForApprovalPrompt(t, &outbound, 5*time.Second)
+	if err := loop.RespondApproval(approvalID, true); err != nil {
+		t.Fatalf("respond: %v", err)
+	}
+	select {
+	case resp := <-done:
+		if resp != "done" {
+			t.Fatalf("unexpected response %q", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ProcessDirect did not complete after approval")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(progress) != 2 || progress[0].Done || !progress[1].Done {
+		t.Fatalf("expected start and done progress, got %+v", progress)
+	}
+	if progress[1].Tool != "exec" || progress[1].TraceID != "trace-tui-1" || progress[1].ResultLen == 0 {
+		t.Fatalf("unexpected progress %+v", progress[1])
+	}
+	if err := loop.RespondApproval("missing", true); err == nil {
+		t.Fatal("expected error for unknown approval")
+	}
+}
+
+// TestApprovalResponseRoutingAndQuorum checks that chat replies are routed
+// through the configured approvers and quorum, while the web console acts
+// as operator.
+func TestApprovalResponseRoutingAndQuorum(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Tools.Approvals.Rules = []config.ToolApprovalRuleConfig{{
+		Tool:      "exec",
+		MinTier:   2,
+		Approvers: []string{"slack:U1", "slack:U2", "slack:U3"},
+		Quorum:    2,
+	}}
+	loop := NewLoop(LoopOptions{
+		Bus:       bus.NewMessageBus(),
+		Provider:  &mockProvider{},
+		Timeline:  newTestTimeline(t),
+		Policy:    policy.NewDefaultEngine(),
+		Workspace: t.TempDir(),
+		Model:     "mock-model",
+		Config:    cfg,
+	})
+
+	req := &approval.ApprovalRequest{Tool: "exec", Tier: 2, Arguments: map[string]any{"command": "rm -rf build"}, Sender: "U1", Channel: "slack"}
+	id := loop.approvalMgr.Create(req)
+	prompt := approvalPrompt(req, "")
+	for _, want := range []string{"Needs 2 approvals from slack:U1, slack:U2, slack:U3 (not the requester).", "approve:" + id} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("expected %q in prompt:\n%s", want, prompt)
+		}
+	}
+	if params := approvalActionParams(req); params["quorum"] != 2 || len(params["approvers"].([]string)) != 3 {
+		t.Fatalf("unexpected action params: %v", params)
+	}
+
+	reply := func(sender string, approved bool) string {
+		return loop.handleApprovalResponse(&bus.InboundMessage{Channel: "slack", SenderID: sender, ChatID: "C1"}, id, approved, "")
+	}
+	if got := reply("U1", true); !strings.Contains(got, "not an eligible approver") {
+		t.Fatalf("expected requester rejected, got %q", got)
+	}
+	if got := reply("U9", true); !strings.Contains(got, "not an eligible approver") {
+		t.Fatalf("expected unlisted sender rejected, got %q", got)
+	}
+	if got := reply("U2", true); !strings.Contains(got, "approval recorded (1/2)") {
+		t.Fatalf("expected partial approval, got %q", got)
+	}
+	if got := reply("U3", true); got != fmt.Sprintf("Approval %s: approved.", id) {
+		t.Fatalf("expected quorum approval, got %q", got)
+	}
+
+	// Other web principals answer under the approvers list.
+	other := loop.approvalMgr.Create(&approval.ApprovalRequest{Tool: "exec", Tier: 2, Sender: "U1", Channel: "slack"})
+	internal := map[string]any{bus.MetaKeyMessageType: bus.MessageTypeInternal}
+	web := &bus.InboundMessage{Channel: "webui", SenderID: "bob", ChatID: "approval:bob", Metadata: internal}
+	if got := loop.handleApprovalResponse(web, other, true, ""); !strings.Contains(got, "not an eligible approver") {
+		t.Fatalf("expected web principal rejected, got %q", got)
+	}
+
+	// The web console answers as operator, bypassing the approvers list,
+	// and the audit trail names the admin.
+	console := &bus.InboundMessage{Channel: "webui", SenderID: "alice", ChatID: "approval", Metadata: internal}
+	if got := loop.handleApprovalResponse(console, other, false, ""); got != fmt.Sprintf("Approval %s: denied.", other) {
+		t.Fatalf("expected operator denial, got %q", got)
+	}
+	if got := reply("U2", true); !strings.Contains(got, "No pending approval") {
+		t.Fatalf("expected decided approval to be gone, got %q", got)
+	}
+	events, _ := loop.timeline.ListApprovalEvents(other)
+	actors := map[string]string{}
+	for _, e := range events {
+		actors[e.Action] = e.Actor
+	}
+	if actors["rejected"] != "webui:bob" || actors["override"] != "operator:alice" {
+		t.Fatalf("unexpected audit actors %v", actors)
+	}
+}
+
+// TestApprovalEscalationRepublishesPrompt checks that an escalated request
+// is re-sent to the originating chat and to channel-addressed targets.
+func TestApprovalEscalationRepublishesPrompt(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	loop := NewLoop(LoopOptions{
+		Bus:       msgBus,
+		Provider:  &mockProvider{},
+		Policy:    policy.NewDefaultEngine(),
+		Workspace: t.TempDir(),
+		Model:     "mock-model",
+	})
+	var outbound outboundCapture
+	for _, ch := range []string{"slack", "whatsapp"} {
+		msgBus.Subscribe(ch, outbound.add)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go msgBus.DispatchOutbound(ctx)
+
+	loop.publishApprovalEscalation(approval.ApprovalRequest{
+		ApprovalID: "ap-esc",
+		Tool:       "exec",
+		Tier:       2,
+		Channel:    "slack",
+		ChatID:     "C1",
+		ThreadID:   "171.1",
+		Routed:     true,
+		Quorum:     1,
+		Approvers:  []string{"slack:U1"},
+		EscalateTo: []string{"whatsapp:4917@s.whatsapp.net", "person:p-1"},
+		Escalated:  true,
+	})
+
+	deadline := time.Now().Add(time.Second)
+	for len(outbound.snapshot()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	msgs := outbound.snapshot()
+	if len(msgs) != 2 {
+		t.Fatalf("expected prompts to origin and escalation target, got %+v", msgs)
+	}
+	targets := map[string]bool{}
+	for _, m := range msgs {
+		targets[m.Channel+"|"+m.ChatID] = true
+		if m.Action != "approval_request" || m.ActionParams["escalated"] != true {
+			t.Fatalf("expected escalated approval prompt, got %+v", m)
+		}
+		if !strings.Contains(m.Content, "Escalated") || !strings.Contains(m.Content, "whatsapp:4917@s.whatsapp.net") {
+			t.Fatalf("unexpected escalation prompt: %q", m.Content)
+		}
+	}
+	if !targets["slack|C1"] || !targets["whatsapp|4917@s.whatsapp.net"] {
+		t.Fatalf("unexpected escalation targets: %v", targets)
+	}
+}
+
+// TestApprovalGrantSkipsLaterPrompts remembers an approval for the session
+// and checks that the same call then runs without a prompt, logged with
+// the grant ID.
+func TestApprovalGrantSkipsLaterPrompts(t *testing.T) {
+	if got := approvalGrantText("approve:abc  for 1h git push * "); got != "for 1h git push *" {
+		t.Fatalf("unexpected grant text %q", got)
+	}
+	if got := approvalGrantText("approve:abc"); got != "" {
+		t.Fatalf("expected no grant text, got %q", got)
+	}
+
+	tl := newTestTimeline(t)
+	msgBus := bus.NewMessageBus()
+	tmpDir := t.TempDir()
+	execCall := provider.ChatResponse{ToolCalls: []provider.ToolCall{{
+		ID: "call_exec", Name: "exec", Arguments: map[string]any{"command": "echo hello"},
+	}}}
+	mock := &mockProvider{responses: []provider.ChatResponse{
+		execCall, {Content: "first done"},
+		execCall, {Content: "second done"},
+	}}
+	policyEngine := policy.NewDefaultEngine()
+	policyEngine.MaxAutoTier = 1
+	loop := NewLoop(LoopOptions{
+		Bus:           msgBus,
+		Provider:      mock,
+		Timeline:      tl,
+		Policy:        policyEngine,
+		Workspace:     tmpDir,
+		WorkRepo:      tmpDir,
+		Model:         "mock-model",
+		MaxIterations: 5,
+	})
+	var outbound outboundCapture
+	msgBus.Subscribe("whatsapp", outbound.add)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go msgBus.DispatchOutbound(ctx)
+
+	inbound := func(traceID string) *bus.InboundMessage {
+		return &bus.InboundMessage{
+			Channel:   "whatsapp",
+			SenderID:  "owner@s.whatsapp.net",
+			ChatID:    "owner@s.whatsapp.net",
+			TraceID:   traceID,
+			Content:   "Run echo hello",
+			Timestamp: time.Now(),
+			Metadata:  map[string]any{bus.MetaKeyMessageType: bus.MessageTypeInternal},
+		}
+	}
+
+	done := make(chan string, 1)
+	go func() {
+		response, _, _ := loop.processMessage(ctx, inbound("trace-grant-1"))
+		done <- response
+	}()
+	approvalID := waitForApprovalPrompt(t, &outbound, 5*time.Second)
+	reply := loop.handleApprovalResponse(inbound("trace-grant-reply"), approvalID, true, "session")
+	if !strings.Contains(reply, "Remembered as grant") || !strings.Contains(reply, "this session") {
+		t.Fatalf("unexpected approval reply %q", reply)
+	}
+	select {
+	case response := <-done:
+		if response != "first done" {
+			t.Fatalf("unexpected first response %q", response)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("first message did not complete after approval")
+	}
+
+	response, _, err := loop.processMessage(ctx, inbound("trace-grant-2"))
+	if err != nil || response != "second done" {
+		t.Fatalf("expected second call to run under the grant, got %q err=%v", response, err)
+	}
+	prompts := 0
+	for _, o := range outbound.snapshot() {
+		if strings.Contains(o.Content, "requires approval") {
+			prompts++
+		}
+	}
+	if prompts != 1 {
+		t.Fatalf("expected a single approval prompt, got %d", prompts)
+	}
+
+	decisions, err := tl.ListPolicyDecisions("trace-grant-2")
+	if err != nil || len(decisions) != 1 {
+		t.Fatalf("decisions: %+v err=%v", decisions, err)
+	}
+	d := decisions[0]
+	if !d.Allowed || d.GrantID == "" || d.Reason != "approval_grant:"+d.GrantID {
+		t.Fatalf("expected decision allowed under a grant, got %+v", d)
+	}
+}
@@ -138,9 +138,6 @@ func TestInternalExternalClassificationE2E(t *testing.T) {
 	// ---------------------------------------------------------------
 	t.Log("━━━ Scenario 2: EXTERNAL message (authorized user) ━━━")
 
-	// Reset activeMessageType so the next message starts clean
-	loop.activeMessageType = ""
-
 	externalMsg := &bus.InboundMessage{
 		Channel:        "whatsapp",
 		SenderID:       "friend@s.whatsapp.net",
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+func TestRunCompletesInboundMessages(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	loop := NewLoop(LoopOptions{
+		Bus:           msgBus,
+		Provider:      &mockProvider{responses: []provider.ChatResponse{{Content: "done"}}},
+		Timeline:      newTestTimeline(t),
+		Workspace:     t.TempDir(),
+		Model:         "mock-model",
+		MaxIterations: 2,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go loop.Run(ctx)
+
+	done := make(chan error, 1)
+	msgBus.PublishInbound(&bus.InboundMessage{
+		Channel:  "scheduler",
+		SenderID: "scheduler",
+		ChatID:   "scheduler:report",
+		Content:  "run the report",
+		Metadata: map[string]any{bus.MetaKeyMessageType: bus.MessageTypeInternal},
+		Done:     func(err error) { done <- err },
+	})
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected successful completion, got %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("message was never completed")
+	}
+}
+
+func TestRunStopsMessagesAtDeadline(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	loop := NewLoop(LoopOptions{
+		Bus:           msgBus,
+		Provider:      &slowProvider{},
+		Timeline:      newTestTimeline(t),
+		Workspace:     t.TempDir(),
+		Model:         "slow-model",
+		MaxIterations: 2,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go loop.Run(ctx)
+
+	done := make(chan error, 1)
+	start := time.Now()
+	msgBus.PublishInbound(&bus.InboundMessage{
+		Channel:  "scheduler",
+		SenderID: "scheduler",
+		ChatID:   "scheduler:slow",
+		Content:  "take your time",
+		Metadata: map[string]any{bus.MetaKeyMessageType: bus.MessageTypeInternal},
+		Deadline: time.Now().Add(100 * time.Millisecond),
+		Done:     func(err error) { done <- err },
+	})
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected the deadline to fail the message")
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("deadline not enforced, took %s", elapsed)
+		}
+	case <-ctx.Done():
+		t.Fatal("message was never completed")
+	}
+}
@@ -3,7 +3,6 @@ package agent
 import (
 	"context"
 	"log/slog"
-	"math"
 	"time"
 
 	"github.com/KafClaw/KafClaw/internal/bus"
@@ -73,14 +72,3 @@ func (w *DeliveryWorker) poll() {
 		slog.Info("Delivery worker dispatched", "task_id", task.TaskID, "channel", task.Channel)
 	}
 }
-
-// DeliveryBackoff calculates the next retry time using exponential backoff.
-// Returns min(30s * 2^attempts, 5min).
-func DeliveryBackoff(attempts int) time.Time {
-	delay := time.Duration(30*math.Pow(2, float64(attempts))) * time.Second
-	maxDelay := 5 * time.Minute
-	if delay > maxDelay {
-		delay = maxDelay
-	}
-	return time.Now().Add(delay)
-}
@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -21,13 +22,17 @@ import (
 	"github.com/KafClaw/KafClaw/internal/approval"
 	"github.com/KafClaw/KafClaw/internal/bus"
 	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/knowledge"
 	"github.com/KafClaw/KafClaw/internal/memory"
 	"github.com/KafClaw/KafClaw/internal/policy"
 	"github.com/KafClaw/KafClaw/internal/provider"
 	"github.com/KafClaw/KafClaw/internal/provider/middleware"
+	"github.com/KafClaw/KafClaw/internal/scheduler"
 	"github.com/KafClaw/KafClaw/internal/session"
+	"github.com/KafClaw/KafClaw/internal/skills"
 	"github.com/KafClaw/KafClaw/internal/timeline"
 	"github.com/KafClaw/KafClaw/internal/tools"
+	"github.com/KafClaw/KafClaw/internal/voice"
 )
 
 // GroupTracePublisher can publish trace and audit data to a group.
@@ -46,7 +51,9 @@ const (
 	maxMemoryLaneTopK                 = 20
 	workingMemorySectionCapChars      = 1200
 	observationsSectionCapChars       = 1200
+	personPreferencesSectionCapChars  = 600
 	ragSectionCapChars                = 1200
+	knowledgeLaneCandidateFacts       = 500
 	subagentParentContextMsgLimit     = 8
 	subagentParentContextCharLimit    = 1800
 	subagentHandoffCharLimit          = 2400
@@ -82,40 +89,62 @@ type LoopOptions struct {
 	SubagentToolsAllow      []string
 	SubagentToolsDeny       []string
 	Config                  *config.Config // for middleware chain setup
+	// ToolProgress, when set, is called before and after each tool call.
+	ToolProgress func(ToolProgress)
+	// IdentityLinker, when set, verifies "link <code>" messages and links
+	// the sender to a person. It returns the person's display name.
+	IdentityLinker func(channel, senderID, code string) (string, error)
+	// Voice, when set, transcribes inbound audio media and attaches voice
+	// replies according to the chat's reply mode.
+	Voice *voice.Pipeline
+	// ToolCache, when set, is shared instead of building one from
+	// Config.Tools.Cache (subagents reuse their parent's cache).
+	ToolCache *tools.ResultCache
+	// ToolParallelism caps concurrent parallel-safe tool calls; 0 uses
+	// Config.Tools.Parallel.MaxConcurrent.
+	ToolParallelism int
+	// KnowledgePublisher, when set, shares facts and proposals made with
+	// knowledge_propose with the group.
+	KnowledgePublisher func(env knowledge.Envelope) error
+}
+
+// ToolProgress reports a tool call starting (Done=false) or finishing.
+type ToolProgress struct {
+	TraceID   string
+	Tool      string
+	Arguments map[string]any
+	Done      bool
+	Duration  time.Duration
+	ResultLen int
+	Err       string
 }
 
 // Loop is the core agent processing engine.
 type Loop struct {
-	bus              *bus.MessageBus
-	provider         provider.LLMProvider
-	timeline         *timeline.TimelineService
-	policy           policy.Engine
-	memoryService    *memory.MemoryService
-	autoIndexer      *memory.AutoIndexer
-	expertiseTracker *memory.ExpertiseTracker
-	workingMemory    *memory.WorkingMemoryStore
-	observer         *memory.Observer
-	groupPublisher   GroupTracePublisher
-	approvalMgr      *approval.Manager
-	registry         *tools.Registry
-	sessions         *session.Manager
-	contextBuilder   *ContextBuilder
-	workspace        string
-	workRepo         string
-	systemRepo       string
-	workRepoGetter   func() string
-	model            string
-	maxIterations    int
-	running          atomic.Bool
-	// activeTaskID tracks the current task being processed (for token accounting).
-	activeTaskID string
-	// activeSender tracks the sender of the current message (for policy checks).
-	activeSender            string
-	activeChannel           string
-	activeChatID            string
-	activeThreadID          string
-	activeTraceID           string
-	activeMessageType       string
+	bus                     *bus.MessageBus
+	provider                provider.LLMProvider
+	timeline                *timeline.TimelineService
+	policy                  policy.Engine
+	memoryService           *memory.MemoryService
+	autoIndexer             *memory.AutoIndexer
+	expertiseTracker        *memory.ExpertiseTracker
+	workingMemory           *memory.WorkingMemoryStore
+	observer                *memory.Observer
+	groupPublisher          GroupTracePublisher
+	approvalMgr             *approval.Manager
+	registry                *tools.Registry
+	sessions                *session.Manager
+	processes               *tools.ProcessManager
+	codeRun                 *tools.CodeRunTool
+	contextBuilder          *ContextBuilder
+	workspace               string
+	workRepo                string
+	systemRepo              string
+	workRepoGetter          func() string
+	model                   string
+	modelMu                 sync.RWMutex
+	maxIterations           int
+	running                 atomic.Bool
 	chain                   *middleware.Chain
 	cfg                     *config.Config
 	subagents               *subagentManager
@@ -129,6 +158,15 @@ type Loop struct {
 	announceSent            map[string]time.Time
 	retryWorkerMu           sync.Mutex
 	retryWorkerOn           bool
+	toolProgress            func(ToolProgress)
+	identityLinker          func(channel, senderID, code string) (string, error)
+	voice                   *voice.Pipeline
+	toolCache               *tools.ResultCache
+	// parentCacheScope makes a subagent share its parent's cache entries.
+	parentCacheScope string
+	toolParallelism  int
+	// knowledgePublisher shares knowledge_propose envelopes with the group.
+	knowledgePublisher func(env knowledge.Envelope) error
 }
 
 // NewLoop creates a new agent loop.
@@ -157,6 +195,7 @@ func NewLoop(opts LoopOptions) *Loop {
 		approvalMgr:      approval.NewManager(opts.Timeline),
 		registry:         registry,
 		sessions:         session.NewManager(opts.Workspace),
+		processes:        tools.NewProcessManager(),
 		contextBuilder:   ctxBuilder,
 		workspace:        opts.Workspace,
 		workRepo:         opts.WorkRepo,
@@ -195,6 +234,26 @@ func NewLoop(opts LoopOptions) *Loop {
 	}
 
 	loop.cfg = opts.Config
+	loop.toolProgress = opts.ToolProgress
+	loop.identityLinker = opts.IdentityLinker
+	loop.voice = opts.Voice
+	loop.toolCache = opts.ToolCache
+	if loop.toolCache == nil && opts.Config != nil && opts.Config.Tools.Cache.Enabled {
+		loop.toolCache = tools.NewResultCache(opts.Config.Tools.Cache)
+	}
+	registry.SetCache(loop.toolCache)
+	loop.toolParallelism = opts.ToolParallelism
+	loop.knowledgePublisher = opts.KnowledgePublisher
+	if opts.Config != nil {
+		loop.approvalMgr.SetRules(approval.RulesFromConfig(opts.Config.Tools.Approvals))
+	}
+	loop.approvalMgr.SetEscalationHandler(loop.publishApprovalEscalation)
+	if loop.toolParallelism == 0 && opts.Config != nil {
+		loop.toolParallelism = opts.Config.Tools.Parallel.MaxConcurrent
+	}
+	if loop.toolParallelism <= 0 {
+		loop.toolParallelism = defaultToolParallelism
+	}
 
 	// Build middleware chain.
 	loop.chain = middleware.NewChain(opts.Provider)
@@ -220,16 +279,66 @@ func NewLoop(opts LoopOptions) *Loop {
 }
 
 func (l *Loop) registerDefaultTools() {
-	l.registry.Register(tools.NewReadFileTool())
 	repoGetter := l.workRepoGetter
 	if repoGetter == nil {
 		repoGetter = func() string { return l.workRepo }
 	}
+	var readCfg config.ReadToolConfig
+	if l.cfg != nil {
+		readCfg = l.cfg.Tools.Read
+	}
+	readPolicy := tools.NewReadPolicy(readCfg, func() []string { return []string{l.workspace, repoGetter()} })
+	l.registry.Register(tools.NewReadFileToolWithPolicy(readPolicy))
 	l.registry.Register(tools.NewWriteFileTool(repoGetter))
 	l.registry.Register(tools.NewEditFileTool(repoGetter))
-	l.registry.Register(tools.NewListDirTool())
+	l.registry.Register(tools.NewListDirToolWithPolicy(readPolicy))
+	l.registry.Register(tools.NewGrepToolWithPolicy(repoGetter, readPolicy))
+	l.registry.Register(tools.NewGlobToolWithPolicy(repoGetter, readPolicy))
+	l.registry.Register(tools.NewApplyPatchTool(repoGetter))
 	l.registry.Register(tools.NewResolvePathTool(repoGetter))
-	l.registry.Register(tools.NewExecTool(0, true, l.workspace, repoGetter))
+	execTool := tools.NewExecTool(0, true, l.workspace, repoGetter)
+	if l.cfg != nil {
+		execTool.Isolation = l.cfg.Tools.Exec.RuntimeIsolation
+		execTool.AllowNetwork = l.cfg.Tools.Exec.AllowNetwork
+		execTool.StrictSandbox = l.cfg.Tools.Exec.StrictSandbox
+	}
+	l.registry.Register(execTool)
+	l.registry.Register(tools.NewProcessStartTool(l.processes, execTool, l.currentSessionKey))
+	l.registry.Register(tools.NewProcessOutputTool(l.processes, l.currentSessionKey))
+	l.registry.Register(tools.NewProcessWriteTool(l.processes, l.currentSessionKey))
+	l.registry.Register(tools.NewProcessKillTool(l.processes, l.currentSessionKey))
+	l.registry.Register(tools.NewProcessListTool(l.processes, l.currentSessionKey))
+	if l.cfg != nil && l.cfg.Tools.CodeRun.Enabled {
+		l.codeRun = tools.NewCodeRunTool(l.cfg, repoGetter, l.currentSessionKey, l.addTurnMedia)
+		l.registry.Register(l.codeRun)
+	}
+	if l.cfg != nil && l.cfg.Scheduler.Enabled && l.timeline != nil {
+		loc, err := scheduler.Location(l.cfg.Scheduler.Timezone)
+		if err != nil {
+			slog.Warn("Invalid scheduler timezone, using host timezone", "error", err)
+			loc = time.Local
+		}
+		l.registry.Register(tools.NewScheduleTool(l.timeline, loc, l.scheduleTarget))
+	}
+	if l.cfg != nil && l.cfg.Knowledge.Enabled && l.timeline != nil {
+		kc := l.cfg.Knowledge
+		l.registry.Register(tools.NewKnowledgeQueryTool(l.timeline, kc.Group))
+		l.registry.Register(tools.NewKnowledgeProposeTool(l.timeline, tools.KnowledgeProposeOptions{
+			Group:      kc.Group,
+			Governance: kc.GovernanceEnabled && kc.ShareMode != "direct",
+			ClawID:     strings.TrimSpace(l.cfg.Node.ClawID),
+			InstanceID: strings.TrimSpace(l.cfg.Node.InstanceID),
+			Publish:    l.knowledgePublisher,
+		}))
+	}
+	l.registry.Register(tools.NewGitStatusTool(repoGetter))
+	l.registry.Register(tools.NewGitDiffTool(repoGetter))
+	l.registry.Register(tools.NewGitLogTool(repoGetter))
+	l.registry.Register(tools.NewGitBlameTool(repoGetter))
+	l.registry.Register(tools.NewGitBranchTool(repoGetter))
+	l.registry.Register(tools.NewGitCommitTool(repoGetter, l.recordGitCommit))
+	l.registry.Register(tools.NewGitPushTool(repoGetter))
+	l.registry.Register(tools.NewGitPRTool(repoGetter))
 
 	// Register memory tools only when memory service is available.
 	if l.memoryService != nil {
@@ -242,6 +351,9 @@ func (l *Loop) registerDefaultTools() {
 	l.registry.Register(tools.NewAgentsListTool(l.listSubagentAgentsForTool))
 	l.registry.Register(tools.NewGoogleWorkspaceReadTool())
 	l.registry.Register(tools.NewM365ReadTool())
+	if l.cfg != nil && len(l.cfg.Tools.HTTP.AllowedHosts) > 0 {
+		l.registry.Register(tools.NewHTTPRequestTool(l.cfg.Tools.HTTP))
+	}
 
 	// er1-brain leg C(b): live knowledge-graph query tool (no embedder needed).
 	if l.cfg != nil && l.cfg.KafGraph.URL != "" {
@@ -249,6 +361,47 @@ func (l *Loop) registerDefaultTools() {
 			l.registry.Register(gt)
 		}
 	}
+
+	// Typed tools declared by enabled skills (SKILL-TOOLS.json).
+	if l.cfg != nil && l.cfg.Skills.Enabled {
+		l.registerSkillTools()
+	}
+}
+
+// registerSkillTools adds the tools declared by enabled skills. A skill tool
+// never replaces a built-in tool of the same name.
+func (l *Loop) registerSkillTools() {
+	defs, errs := skills.LoadSkillTools(l.cfg)
+	for _, err := range errs {
+		slog.Warn("Skill tool manifest skipped", "error", err)
+	}
+	for _, def := range defs {
+		if _, exists := l.registry.Get(def.Spec.Name); exists {
+			slog.Warn("Skill tool shadows a registered tool; skipped", "tool", def.Spec.Name, "skill", def.Skill)
+			continue
+		}
+		l.registry.Register(tools.NewSkillTool(l.cfg, def))
+	}
+}
+
+// recordGitCommit links a commit made by the git_commit tool to the active
+// trace so a task's code changes can be found from its conversation.
+func (l *Loop) recordGitCommit(ctx context.Context, rec tools.GitCommitRecord) {
+	if l.timeline == nil {
+		return
+	}
+	s := l.scope(ctx)
+	if err := l.timeline.RecordRepoCommit(&timeline.RepoCommitRecord{
+		TraceID: s.traceID,
+		TaskID:  s.taskID,
+		Repo:    rec.Repo,
+		SHA:     rec.SHA,
+		Branch:  rec.Branch,
+		Message: rec.Message,
+		Files:   rec.Files,
+	}); err != nil {
+		slog.Warn("Failed to record commit in timeline", "sha", rec.SHA, "error", err)
+	}
 }
 
 // Run starts the agent loop, processing messages from the bus.
@@ -269,32 +422,64 @@ func (l *Loop) Run(ctx context.Context) error {
 
 		// Intercept approval responses (approve:<id> / deny:<id>)
 		if id, approved, ok := parseApprovalResponse(msg.Content); ok && l.approvalMgr != nil {
-			if err := l.approvalMgr.Respond(id, approved); err != nil {
-				slog.Warn("Approval response failed", "id", id, "error", err)
-				l.bus.PublishOutbound(&bus.OutboundMessage{
-					Channel:  msg.Channel,
-					ChatID:   msg.ChatID,
-					ThreadID: msg.ThreadID,
-					TraceID:  msg.TraceID,
-					Content:  fmt.Sprintf("No pending approval found for ID %s.", id),
-				})
+			l.bus.PublishOutbound(&bus.OutboundMessage{
+				Channel:  msg.Channel,
+				ChatID:   msg.ChatID,
+				ThreadID: msg.ThreadID,
+				TraceID:  msg.TraceID,
+				Content:  l.handleApprovalResponse(msg, id, approved, approvalGrantText(msg.Content)),
+			})
+			msg.Complete(nil)
+			continue
+		}
+
+		// Intercept identity link codes (link <code>)
+		if code, ok := parseIdentityLinkCommand(msg.Content); ok && l.identityLinker != nil {
+			content := ""
+			if name, err := l.identityLinker(msg.Channel, msg.SenderID, code); err != nil {
+				slog.Warn("Identity link failed", "channel", msg.Channel, "sender", msg.SenderID, "error", err)
+				content = "Link code not recognized or expired."
 			} else {
-				action := "denied"
-				if approved {
-					action = "approved"
-				}
-				l.bus.PublishOutbound(&bus.OutboundMessage{
-					Channel:  msg.Channel,
-					ChatID:   msg.ChatID,
-					ThreadID: msg.ThreadID,
-					TraceID:  msg.TraceID,
-					Content:  fmt.Sprintf("Approval %s: %s.", id, action),
-				})
+				content = fmt.Sprintf("Linked this %s identity to %s.", msg.Channel, name)
+			}
+			l.bus.PublishOutbound(&bus.OutboundMessage{
+				Channel:  msg.Channel,
+				ChatID:   msg.ChatID,
+				ThreadID: msg.ThreadID,
+				TraceID:  msg.TraceID,
+				Content:  content,
+			})
+			msg.Complete(nil)
+			continue
+		}
+
+		// Intercept voice reply preference (/voice on|off|auto|default)
+		if mode, ok := parseVoiceCommand(msg.Content); ok && l.voice != nil {
+			content := fmt.Sprintf("Voice replies for this chat: %s.", describeVoiceMode(mode))
+			if !voice.SupportsReplies(msg.Channel) {
+				content = fmt.Sprintf("Voice replies are not supported on %s.", msg.Channel)
+			} else if err := l.voice.SetReplyMode(msg.Channel, msg.ChatID, mode); err != nil {
+				slog.Warn("Voice preference update failed", "channel", msg.Channel, "chat", msg.ChatID, "error", err)
+				content = "Could not update the voice reply preference."
 			}
+			l.bus.PublishOutbound(&bus.OutboundMessage{
+				Channel:  msg.Channel,
+				ChatID:   msg.ChatID,
+				ThreadID: msg.ThreadID,
+				TraceID:  msg.TraceID,
+				Content:  content,
+			})
+			msg.Complete(nil)
 			continue
 		}
 
-		response, taskID, err := l.processMessage(ctx, msg)
+		procCtx, cancel := ctx, context.CancelFunc(func() {})
+		if !msg.Deadline.IsZero() {
+			procCtx, cancel = context.WithDeadline(ctx, msg.Deadline)
+		}
+		media := &turnMedia{}
+		response, taskID, err := l.processMessage(withTurnScope(procCtx, &turnScope{media: media}), msg)
+		cancel()
 		if err != nil {
 			slog.Error("Failed to process message", "error", err)
 			response = fmt.Sprintf("Error: %v", err)
@@ -302,26 +487,63 @@ func (l *Loop) Run(ctx context.Context) error {
 
 		if response != "" {
 			l.bus.PublishOutbound(&bus.OutboundMessage{
-				Channel:  msg.Channel,
-				ChatID:   msg.ChatID,
-				ThreadID: msg.ThreadID,
-				TraceID:  msg.TraceID,
-				TaskID:   taskID,
-				Content:  response,
+				Channel:   msg.Channel,
+				ChatID:    msg.ChatID,
+				ThreadID:  msg.ThreadID,
+				TraceID:   msg.TraceID,
+				TaskID:    taskID,
+				Content:   response,
+				MediaURLs: append(media.take(), l.voiceReply(ctx, msg, response, err == nil)...),
 			})
 			// Optimistic delivery mark
 			if l.timeline != nil && taskID != "" {
 				_ = l.timeline.UpdateTaskDelivery(taskID, timeline.DeliverySent, nil)
 			}
 		}
+		msg.Complete(err)
 	}
 
 	return nil
 }
 
-// Stop signals the agent loop to stop.
+// Stop signals the agent loop to stop and terminates its background
+// processes.
 func (l *Loop) Stop() {
 	l.running.Store(false)
+	l.processes.KillAll()
+}
+
+// RespondApproval resolves a pending tool approval created by this loop.
+func (l *Loop) RespondApproval(id string, approved bool) error {
+	return l.approvalMgr.Respond(id, approved)
+}
+
+// Model returns the model name used for LLM calls.
+func (l *Loop) Model() string {
+	l.modelMu.RLock()
+	defer l.modelMu.RUnlock()
+	return l.model
+}
+
+// SetModel changes the model for subsequent turns. A turn keeps the model it
+// started with.
+func (l *Loop) SetModel(name string) {
+	if name = strings.TrimSpace(name); name != "" {
+		l.modelMu.Lock()
+		l.model = name
+		l.modelMu.Unlock()
+	}
+}
+
+// Sessions returns the loop's session manager.
+func (l *Loop) Sessions() *session.Manager { return l.sessions }
+
+// Processes returns the manager of background processes started by tools.
+func (l *Loop) Processes() *tools.ProcessManager { return l.processes }
+
+// Subagents lists subagent runs under the given session's root.
+func (l *Loop) Subagents(sessionKey string) []tools.SubagentRunView {
+	return subagentRunViews(l.subagents.listByController(sessionKey))
 }
 
 // ProcessDirect processes a message directly (for CLI usage).
@@ -340,25 +562,27 @@ func (l *Loop) ProcessDirectWithTrace(ctx context.Context, content, sessionKey,
 	if traceID == "" {
 		traceID = fmt.Sprintf("trace-%d", time.Now().UnixNano())
 	}
-	prevChannel := l.activeChannel
-	prevChatID := l.activeChatID
-	prevThreadID := l.activeThreadID
-	prevTrace := l.activeTraceID
-	l.activeChannel = channel
-	l.activeChatID = chatID
-	l.activeThreadID = ""
-	l.activeTraceID = traceID
-	defer func() {
-		l.activeChannel = prevChannel
-		l.activeChatID = prevChatID
-		l.activeThreadID = prevThreadID
-		l.activeTraceID = prevTrace
-	}()
+	var requestedAgent string
+	if turn := turnFrom(ctx); turn != nil {
+		requestedAgent = turn.AgentID
+	}
+	agent, err := l.resolveAgent(requestedAgent)
+	if err != nil {
+		return "", err
+	}
 
-	// CLI direct calls are always internal (owner). Bus-routed messages
-	// have activeMessageType set by processMessage before calling here.
-	if l.activeMessageType == "" {
-		l.activeMessageType = bus.MessageTypeInternal
+	// Bus-routed messages arrive with a scope filled by processMessage;
+	// direct calls get their own so they never see another turn's origin.
+	s := turnScopeFrom(ctx)
+	if s == nil || (s.session != "" && s.session != sessionKey) {
+		s = &turnScope{channel: channel, chatID: chatID}
+		ctx = withTurnScope(ctx, s)
+	}
+	s.session = sessionKey
+	s.traceID = traceID
+	// CLI direct calls are always internal (owner).
+	if s.messageType == "" {
+		s.messageType = bus.MessageTypeInternal
 	}
 
 	// Get or create session
@@ -381,17 +605,17 @@ func (l *Loop) ProcessDirectWithTrace(ctx context.Context, content, sessionKey,
 	// Task-type model routing: assess the message and swap provider if routing matches.
 	if l.cfg != nil && len(l.cfg.Model.TaskRouting) > 0 {
 		assessment := AssessTask(content)
-		if routed, err := provider.ResolveWithTaskType(l.cfg, l.agentID, assessment.Category); err == nil && routed != l.provider {
-			slog.Info("Task-type routing applied", "category", assessment.Category, "agent", l.agentID)
-			if l.timeline != nil && l.activeTraceID != "" {
+		if routed, err := provider.ResolveWithTaskType(l.cfg, agent.id, assessment.Category); err == nil && routed != l.provider {
+			slog.Info("Task-type routing applied", "category", assessment.Category, "agent", agent.id)
+			if l.timeline != nil && s.traceID != "" {
 				routeMeta, _ := json.Marshal(map[string]string{
 					"category":       assessment.Category,
 					"cognitive_mode": assessment.CognitiveMode,
-					"agent_id":       l.agentID,
+					"agent_id":       agent.id,
 				})
 				_ = l.timeline.AddEvent(&timeline.TimelineEvent{
-					EventID:        fmt.Sprintf("ROUTE_%s_%d", l.activeTraceID, time.Now().UnixNano()),
-					TraceID:        l.activeTraceID,
+					EventID:        fmt.Sprintf("ROUTE_%s_%d", s.traceID, time.Now().UnixNano()),
+					TraceID:        s.traceID,
 					Timestamp:      time.Now(),
 					SenderID:       "AGENT",
 					SenderName:     "TaskRouter",
@@ -402,26 +626,34 @@ func (l *Loop) ProcessDirectWithTrace(ctx context.Context, content, sessionKey,
 					Metadata:       string(routeMeta),
 				})
 			}
-			origProvider := l.chain.Provider
-			l.chain.Provider = routed
-			defer func() { l.chain.Provider = origProvider }()
+			agent.provider = routed
 		}
 	}
+	s.agent = agent
 
 	// Build messages using the context builder
-	messages := l.contextBuilder.BuildMessages(sess, content, channel, chatID, l.activeMessageType)
+	messages := l.contextBuilder.BuildMessages(sess, content, channel, chatID, s.messageType)
 
 	remainingMemoryBudget := l.memoryInjectionBudgetChars()
 
-	// Inject working memory (scoped per user/thread)
-	messages, remainingMemoryBudget = l.injectWorkingMemory(messages, chatID, sessionKey, remainingMemoryBudget)
+	// Inject working memory (scoped per person when the sender is linked,
+	// otherwise per chat) and the person's preferences.
+	resourceID := chatID
+	if s.personID != "" {
+		resourceID = timeline.PersonResourceID(s.personID)
+	}
+	messages, remainingMemoryBudget = l.injectWorkingMemory(ctx, messages, resourceID, sessionKey, remainingMemoryBudget)
+	messages, remainingMemoryBudget = l.injectPersonPreferences(ctx, messages, remainingMemoryBudget)
 
 	// Inject observations (compressed session history)
-	messages, remainingMemoryBudget = l.injectObservations(messages, sessionKey, remainingMemoryBudget)
+	messages, remainingMemoryBudget = l.injectObservations(ctx, messages, sessionKey, remainingMemoryBudget)
 
 	// Inject RAG context from semantic memory
 	messages, _ = l.injectRAGContext(ctx, messages, content, remainingMemoryBudget)
 
+	// Inject group knowledge facts (separate budget)
+	messages = l.injectKnowledgeFacts(ctx, messages, content)
+
 	// Run the agentic loop
 	response, err := l.runAgentLoop(ctx, messages)
 	if err != nil {
@@ -1030,13 +1262,52 @@ func (l *Loop) injectRAGContext(ctx context.Context, messages []provider.Message
 	truncated := sectionWouldOverflow(section, ragSectionCapChars, budgetChars)
 	updated, remaining := appendSectionWithBudget(messages, section, ragSectionCapChars, budgetChars)
 	if truncated {
-		l.recordMemoryOverflow("rag")
+		l.recordMemoryOverflow(ctx, "rag")
 	}
 	return updated, remaining
 }
 
+// injectKnowledgeFacts appends the group facts most relevant to the user
+// query to the system prompt. The lane has its own budget
+// (knowledge.contextBudgetChars) instead of sharing the memory budget.
+func (l *Loop) injectKnowledgeFacts(ctx context.Context, messages []provider.Message, userQuery string) []provider.Message {
+	if l.cfg == nil || !l.cfg.Knowledge.Enabled || l.timeline == nil || len(messages) == 0 {
+		return messages
+	}
+	maxFacts, budgetChars := l.cfg.Knowledge.ContextMaxFacts, l.cfg.Knowledge.ContextBudgetChars
+	if maxFacts <= 0 || budgetChars <= 0 {
+		return messages
+	}
+	facts, err := l.timeline.SearchKnowledgeFacts(timeline.KnowledgeFactQuery{
+		Group: l.cfg.Knowledge.Group,
+		Limit: knowledgeLaneCandidateFacts,
+	})
+	if err != nil {
+		slog.Warn("Knowledge facts lookup failed", "error", err)
+		return messages
+	}
+	ranked := knowledge.RankFacts(facts, userQuery, maxFacts)
+	if len(ranked) == 0 {
+		return messages
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n\n---\n\n# Group Knowledge\n\nFacts agreed by the agent group (subject | predicate | object):\n\n")
+	for _, f := range ranked {
+		sb.WriteString(fmt.Sprintf("- %s | %s | %s (v%d)\n", f.Subject, f.Predicate, f.Object, f.Version))
+	}
+
+	section := sb.String()
+	truncated := sectionWouldOverflow(section, 0, budgetChars)
+	updated, _ := appendSectionWithBudget(messages, section, 0, budgetChars)
+	if truncated {
+		l.recordMemoryOverflow(ctx, "facts")
+	}
+	return updated
+}
+
 // injectWorkingMemory loads scoped working memory and appends it to the system prompt.
-func (l *Loop) injectWorkingMemory(messages []provider.Message, resourceID, threadID string, budgetChars int) ([]provider.Message, int) {
+func (l *Loop) injectWorkingMemory(ctx context.Context, messages []provider.Message, resourceID, threadID string, budgetChars int) ([]provider.Message, int) {
 	if l.workingMemory == nil || len(messages) == 0 {
 		return messages, budgetChars
 	}
@@ -1069,13 +1340,49 @@ func (l *Loop) injectWorkingMemory(messages []provider.Message, resourceID, thre
 	truncated := sectionWouldOverflow(section, workingMemorySectionCapChars, budgetChars)
 	updated, remaining := appendSectionWithBudget(messages, section, workingMemorySectionCapChars, budgetChars)
 	if truncated {
-		l.recordMemoryOverflow("working")
+		l.recordMemoryOverflow(ctx, "working")
+	}
+	return updated, remaining
+}
+
+// injectPersonPreferences appends the linked person's preferences to the system prompt.
+func (l *Loop) injectPersonPreferences(ctx context.Context, messages []provider.Message, budgetChars int) ([]provider.Message, int) {
+	s := l.scope(ctx)
+	if l.timeline == nil || s.personID == "" || len(messages) == 0 {
+		return messages, budgetChars
+	}
+	person, err := l.timeline.GetPerson(s.personID)
+	if err != nil {
+		slog.Warn("Person preferences load failed", "person", s.personID, "error", err)
+		return messages, budgetChars
+	}
+	if len(person.Preferences) == 0 {
+		return messages, budgetChars
+	}
+	keys := make([]string, 0, len(person.Preferences))
+	for k := range person.Preferences {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString("\n\n---\n\n# User Preferences\n\n")
+	fmt.Fprintf(&sb, "The user is %s.\n", person.DisplayName)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "- %s: %s\n", k, person.Preferences[k])
+	}
+
+	section := sb.String()
+	truncated := sectionWouldOverflow(section, personPreferencesSectionCapChars, budgetChars)
+	updated, remaining := appendSectionWithBudget(messages, section, personPreferencesSectionCapChars, budgetChars)
+	if truncated {
+		l.recordMemoryOverflow(ctx, "preferences")
 	}
 	return updated, remaining
 }
 
 // injectObservations loads compressed observation notes and appends them to the system prompt.
-func (l *Loop) injectObservations(messages []provider.Message, sessionID string, budgetChars int) ([]provider.Message, int) {
+func (l *Loop) injectObservations(ctx context.Context, messages []provider.Message, sessionID string, budgetChars int) ([]provider.Message, int) {
 	if l.observer == nil || len(messages) == 0 {
 		return messages, budgetChars
 	}
@@ -1096,7 +1403,7 @@ func (l *Loop) injectObservations(messages []provider.Message, sessionID string,
 	truncated := sectionWouldOverflow(section, observationsSectionCapChars, budgetChars)
 	updated, remaining := appendSectionWithBudget(messages, section, observationsSectionCapChars, budgetChars)
 	if truncated {
-		l.recordMemoryOverflow("observation")
+		l.recordMemoryOverflow(ctx, "observation")
 	}
 	return updated, remaining
 }
@@ -1170,7 +1477,7 @@ func sectionWouldOverflow(section string, sectionCapChars, budgetChars int) bool
 	return len(section) > capChars
 }
 
-func (l *Loop) recordMemoryOverflow(lane string) {
+func (l *Loop) recordMemoryOverflow(ctx context.Context, lane string) {
 	if l == nil || l.timeline == nil {
 		return
 	}
@@ -1181,10 +1488,10 @@ func (l *Loop) recordMemoryOverflow(lane string) {
 	incrementSettingCounter(l.timeline, "memory_overflow_events_total")
 	incrementSettingCounter(l.timeline, "memory_overflow_events_"+lane)
 
-	if l.activeTraceID != "" {
+	if s := l.scope(ctx); s.traceID != "" {
 		_ = l.timeline.AddEvent(&timeline.TimelineEvent{
 			EventID:        fmt.Sprintf("MEMORY_OVERFLOW_%d", time.Now().UnixNano()),
-			TraceID:        l.activeTraceID,
+			TraceID:        s.traceID,
 			Timestamp:      time.Now(),
 			SenderID:       "system",
 			SenderName:     "KafClaw",
@@ -1266,6 +1573,9 @@ func (l *Loop) processMessage(ctx context.Context, msg *bus.InboundMessage) (res
 		}
 	}
 
+	// TRANSCRIBE inbound audio media (any channel)
+	l.transcribeInboundAudio(ctx, msg)
+
 	// CREATE TASK (H-004)
 	if l.timeline != nil {
 		task, createErr := l.timeline.CreateTask(&timeline.AgentTask{
@@ -1285,14 +1595,27 @@ func (l *Loop) processMessage(ctx context.Context, msg *bus.InboundMessage) (res
 		}
 	}
 
-	// Set active context for policy checks and token tracking
-	l.activeTaskID = taskID
-	l.activeSender = msg.SenderID
-	l.activeChannel = msg.Channel
-	l.activeChatID = msg.ChatID
-	l.activeThreadID = msg.ThreadID
-	l.activeTraceID = msg.TraceID
-	l.activeMessageType = msg.MessageType()
+	// Scope the turn to the message for policy checks and token tracking
+	s := turnScopeFrom(ctx)
+	if s == nil {
+		s = &turnScope{}
+		ctx = withTurnScope(ctx, s)
+	}
+	s.session = sessionKey
+	s.taskID = taskID
+	s.sender = msg.SenderID
+	s.channel = msg.Channel
+	s.chatID = msg.ChatID
+	s.threadID = msg.ThreadID
+	s.traceID = msg.TraceID
+	s.messageType = msg.MessageType()
+	if l.timeline != nil {
+		if personID, ok, err := l.timeline.ResolvePersonID(msg.Channel, msg.SenderID); err != nil {
+			slog.Warn("Person lookup failed", "channel", msg.Channel, "sender", msg.SenderID, "error", err)
+		} else if ok {
+			s.personID = personID
+		}
+	}
 
 	// PROCESS
 	response, err = l.ProcessDirectWithTrace(ctx, msg.Content, sessionKey, msg.TraceID)
@@ -1325,6 +1648,10 @@ func (l *Loop) processMessage(ctx context.Context, msg *bus.InboundMessage) (res
 
 func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (string, error) {
 	toolDefs := l.buildToolDefinitions()
+	// Tool cache hits and deduplicated characters since the last LLM call.
+	cacheHits, cacheSaved := 0, 0
+	s := l.scope(ctx)
+	agent := l.turnAgentFrom(ctx)
 
 	for i := 0; i < l.maxIterations; i++ {
 		// QUOTA CHECK (H-014): check daily token limit before LLM call
@@ -1337,14 +1664,17 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 		chatReq := &provider.ChatRequest{
 			Messages:    messages,
 			Tools:       toolDefs,
-			Model:       l.model,
+			Model:       agent.model,
 			MaxTokens:   4096,
 			Temperature: 0.7,
 		}
-		meta := middleware.NewRequestMeta("", l.model)
-		meta.SenderID = l.activeSender
-		meta.Channel = l.activeChannel
-		meta.MessageType = l.activeMessageType
+		meta := middleware.NewRequestMeta("", agent.model)
+		meta.ProviderOverride = agent.provider
+		meta.SenderID = s.sender
+		meta.Channel = s.channel
+		meta.MessageType = s.messageType
+		meta.ToolCacheHits, meta.ToolCacheSaved = cacheHits, cacheSaved
+		cacheHits, cacheSaved = 0, 0
 		resp, err := l.chain.Process(ctx, chatReq, meta)
 		llmDuration := time.Since(llmStart)
 		if err != nil {
@@ -1352,10 +1682,11 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 		}
 
 		// TOKEN TRACKING (H-013): record usage
-		l.trackTokens(resp.Usage)
+		l.trackTokens(ctx, resp.Usage)
+		turnFrom(ctx).addUsage(resp.Usage)
 
 		// Log middleware security events to timeline
-		l.logMiddlewareEvents(meta, i)
+		l.logMiddlewareEvents(ctx, meta, i)
 
 		// Build LLM span summary
 		toolCallSummary := ""
@@ -1366,13 +1697,13 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 			}
 			toolCallSummary = fmt.Sprintf(" → tools: %s", strings.Join(names, ", "))
 		}
-		llmContent := fmt.Sprintf("model=%s tokens=%d duration=%dms%s", l.model, resp.Usage.TotalTokens, llmDuration.Milliseconds(), toolCallSummary)
+		llmContent := fmt.Sprintf("model=%s tokens=%d duration=%dms%s", agent.model, resp.Usage.TotalTokens, llmDuration.Milliseconds(), toolCallSummary)
 
 		// Log LLM span to timeline for end-to-end trace visibility
-		if l.timeline != nil && l.activeTraceID != "" {
+		if l.timeline != nil && s.traceID != "" {
 			// Build rich metadata for LLM span
 			llmMeta := map[string]any{
-				"model":             l.model,
+				"model":             agent.model,
 				"temperature":       0.7,
 				"max_tokens":        4096,
 				"duration_ms":       llmDuration.Milliseconds(),
@@ -1383,6 +1714,10 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 				"response_text":     truncateStr(resp.Content, 10240),
 				"message_count":     len(messages),
 			}
+			if meta.ToolCacheHits > 0 {
+				llmMeta["tool_cache_hits"] = meta.ToolCacheHits
+				llmMeta["tool_cache_saved_chars"] = meta.ToolCacheSaved
+			}
 			// System prompt preview (first message if role=system)
 			if len(messages) > 0 && messages[0].Role == "system" {
 				llmMeta["system_prompt"] = truncateStr(messages[0].Content, 2048)
@@ -1408,8 +1743,8 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 			llmMetaJSON, _ := json.Marshal(llmMeta)
 
 			_ = l.timeline.AddEvent(&timeline.TimelineEvent{
-				EventID:        fmt.Sprintf("LLM_%s_%d_%d", l.activeTraceID, i, time.Now().UnixNano()),
-				TraceID:        l.activeTraceID,
+				EventID:        fmt.Sprintf("LLM_%s_%d_%d", s.traceID, i, time.Now().UnixNano()),
+				TraceID:        s.traceID,
 				Timestamp:      llmStart,
 				SenderID:       "AGENT",
 				SenderName:     "LLM",
@@ -1421,7 +1756,7 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 			})
 		}
 		// Publish LLM span to group traces topic
-		if l.groupPublisher != nil && l.groupPublisher.Active() && l.activeTraceID != "" {
+		if l.groupPublisher != nil && l.groupPublisher.Active() && s.traceID != "" {
 			go func(traceID, content string, dur time.Duration) {
 				pubCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 				defer cancel()
@@ -1429,13 +1764,13 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 				_ = l.groupPublisher.PublishTrace(pubCtx, map[string]string{
 					"trace_id":    traceID,
 					"span_type":   "LLM",
-					"title":       fmt.Sprintf("LLM call: %s", l.model),
+					"title":       fmt.Sprintf("LLM call: %s", agent.model),
 					"content":     content,
 					"started_at":  now.Add(-dur).Format(time.RFC3339),
 					"ended_at":    now.Format(time.RFC3339),
 					"duration_ms": fmt.Sprintf("%d", dur.Milliseconds()),
 				})
-			}(l.activeTraceID, llmContent, llmDuration)
+			}(s.traceID, llmContent, llmDuration)
 		}
 
 		// Check for tool calls
@@ -1451,98 +1786,239 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 			ToolCalls: resp.ToolCalls,
 		})
 
-		// Execute each tool call
-		for _, tc := range resp.ToolCalls {
-			// POLICY CHECK (H-011): evaluate before tool execution
-			if denied, reason := l.checkToolPolicy(ctx, tc.Name, tc.Arguments); denied {
-				slog.Warn("Tool denied by policy", "tool", tc.Name, "reason", reason)
+		// Execute tool calls in batches: consecutive parallel-safe calls run
+		// concurrently, every other call runs alone. Results are fed back in
+		// call order.
+		for start := 0; start < len(resp.ToolCalls); {
+			batch := resp.ToolCalls[start:l.toolBatchEnd(resp.ToolCalls, start)]
+			start += len(batch)
+
+			// POLICY CHECK (H-011): evaluate before tool execution, in call
+			// order so approval prompts are asked one at a time.
+			outcomes := make([]toolOutcome, len(batch))
+			for i, tc := range batch {
+				if denied, reason := l.checkToolPolicy(ctx, tc.Name, tc.Arguments); denied {
+					slog.Warn("Tool denied by policy", "tool", tc.Name, "reason", reason)
+					outcomes[i] = toolOutcome{denied: true, result: fmt.Sprintf("Policy denied: %s", reason)}
+				}
+			}
+			l.runToolBatch(ctx, batch, outcomes)
+
+			for i, tc := range batch {
+				out := outcomes[i]
+				if strings.Contains(out.result, "Ey, du spinnst wohl? Hä?") {
+					return "Ey, du spinnst wohl? Hä? 💣 👮‍♂️ 🔒", nil
+				}
+
+				// Add tool result; a cached result already in the conversation
+				// is replaced by a reference to save tokens.
+				content := out.result
+				if out.cached {
+					cacheHits++
+					if prev := previousToolResult(messages, out.result); prev != "" && len(out.result) > dedupMinChars {
+						content = fmt.Sprintf("(unchanged: identical to the result of tool call %s above)", prev)
+						cacheSaved += len(out.result)
+					}
+				}
 				messages = append(messages, provider.Message{
 					Role:       "tool",
-					Content:    fmt.Sprintf("Policy denied: %s", reason),
+					Content:    content,
 					ToolCallID: tc.ID,
 				})
-				continue
 			}
+		}
+	}
 
-			toolStart := time.Now()
-			result, err := l.registry.Execute(ctx, tc.Name, tc.Arguments)
-			toolDuration := time.Since(toolStart)
-			if err != nil {
-				result = fmt.Sprintf("Error: %v", err)
-			}
+	return "Max iterations reached. Please try a simpler request.", nil
+}
 
-			// Log tool span to timeline for end-to-end trace visibility
-			toolContent := fmt.Sprintf("tool=%s duration=%dms result_len=%d", tc.Name, toolDuration.Milliseconds(), len(result))
-			if l.timeline != nil && l.activeTraceID != "" {
-				// Build rich metadata for TOOL span
-				toolMeta := map[string]any{
-					"tool_name":    tc.Name,
-					"tool_call_id": tc.ID,
-					"arguments":    tc.Arguments,
-					"duration_ms":  toolDuration.Milliseconds(),
-					"result":       truncateStr(result, 10240),
-				}
-				if err != nil {
-					toolMeta["error"] = err.Error()
-				}
-				toolMetaJSON, _ := json.Marshal(toolMeta)
+// defaultToolParallelism caps concurrent tool calls when tools.parallel is
+// not configured.
+const defaultToolParallelism = 4
 
-				_ = l.timeline.AddEvent(&timeline.TimelineEvent{
-					EventID:        fmt.Sprintf("TOOL_%s_%s_%d", l.activeTraceID, tc.Name, time.Now().UnixNano()),
-					TraceID:        l.activeTraceID,
-					Timestamp:      toolStart,
-					SenderID:       "AGENT",
-					SenderName:     "Tool",
-					EventType:      "SYSTEM",
-					ContentText:    toolContent,
-					Classification: "TOOL",
-					Authorized:     true,
-					Metadata:       string(toolMetaJSON),
-				})
-			}
-			// Publish tool span to group traces topic
-			if l.groupPublisher != nil && l.groupPublisher.Active() && l.activeTraceID != "" {
-				go func(traceID, toolN, content string, dur time.Duration) {
-					pubCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-					defer cancel()
-					now := time.Now()
-					_ = l.groupPublisher.PublishTrace(pubCtx, map[string]string{
-						"trace_id":    traceID,
-						"span_type":   "TOOL",
-						"title":       fmt.Sprintf("Tool: %s", toolN),
-						"content":     content,
-						"started_at":  now.Add(-dur).Format(time.RFC3339),
-						"ended_at":    now.Format(time.RFC3339),
-						"duration_ms": fmt.Sprintf("%d", dur.Milliseconds()),
-					})
-				}(l.activeTraceID, tc.Name, toolContent, toolDuration)
-			}
+// toolOutcome is the result of one tool call of a batch.
+type toolOutcome struct {
+	result string
+	cached bool
+	denied bool
+}
 
-			if strings.Contains(result, "Ey, du spinnst wohl? Hä?") {
-				return "Ey, du spinnst wohl? Hä? 💣 👮‍♂️ 🔒", nil
-			}
+// toolBatchEnd returns the end of the batch starting at start: the run of
+// consecutive parallel-safe calls, or the single call at start.
+func (l *Loop) toolBatchEnd(calls []provider.ToolCall, start int) int {
+	if l.toolParallelism <= 1 || !l.toolCallParallelSafe(calls[start]) {
+		return start + 1
+	}
+	end := start + 1
+	for end < len(calls) && l.toolCallParallelSafe(calls[end]) {
+		end++
+	}
+	return end
+}
 
-			// Auto-index substantive tool results
-			if l.autoIndexer != nil && err == nil && len(result) > 200 {
-				item := memory.FormatToolResult(tc.Name, tc.Arguments, result)
-				l.autoIndexer.Enqueue(item)
-			}
+func (l *Loop) toolCallParallelSafe(tc provider.ToolCall) bool {
+	t, ok := l.registry.Get(tc.Name)
+	return ok && tools.ParallelSafe(t, tc.Arguments)
+}
+
+// runToolBatch executes the calls of a batch not denied by policy, at most
+// toolParallelism at a time, and stores each result at its call's index.
+func (l *Loop) runToolBatch(ctx context.Context, batch []provider.ToolCall, outcomes []toolOutcome) {
+	if len(batch) == 1 {
+		if !outcomes[0].denied {
+			outcomes[0] = l.executeToolCall(ctx, batch[0])
+		}
+		return
+	}
+	sem := make(chan struct{}, l.toolParallelism)
+	var wg sync.WaitGroup
+	for i, tc := range batch {
+		if outcomes[i].denied {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, tc provider.ToolCall) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			outcomes[i] = l.executeToolCall(ctx, tc)
+		}(i, tc)
+	}
+	wg.Wait()
+}
+
+// executeToolCall runs one tool call and records its progress, timeline
+// span, group trace, auto-index entry and expertise. It may run concurrently
+// with other calls of the same batch.
+func (l *Loop) executeToolCall(ctx context.Context, tc provider.ToolCall) toolOutcome {
+	s := l.scope(ctx)
+	if l.toolProgress != nil {
+		l.toolProgress(ToolProgress{TraceID: s.traceID, Tool: tc.Name, Arguments: tc.Arguments})
+	}
+	turn := turnFrom(ctx)
+	turn.emit(TurnEvent{Type: TurnEventToolCall, Tool: tc.Name, Arguments: tc.Arguments})
+	toolStart := time.Now()
+	toolCtx, cacheCall := l.withToolCache(ctx)
+	result, err := l.registry.Execute(toolCtx, tc.Name, tc.Arguments)
+	toolDuration := time.Since(toolStart)
+	cached := cacheCall != nil && cacheCall.Hit
+	if err != nil {
+		result = fmt.Sprintf("Error: %v", err)
+	}
+	if l.toolProgress != nil {
+		done := ToolProgress{TraceID: s.traceID, Tool: tc.Name, Arguments: tc.Arguments, Done: true, Duration: toolDuration, ResultLen: len(result)}
+		if err != nil {
+			done.Err = err.Error()
+		}
+		l.toolProgress(done)
+	}
+	if err != nil {
+		turn.emit(TurnEvent{Type: TurnEventToolResult, Tool: tc.Name, Error: err.Error()})
+	} else {
+		turn.emit(TurnEvent{Type: TurnEventToolResult, Tool: tc.Name})
+	}
 
-			// Track tool expertise
-			l.expertiseTracker.RecordToolUse(tc.Name, l.activeTaskID, toolDuration.Milliseconds(), err == nil)
+	// Log tool span to timeline for end-to-end trace visibility
+	toolContent := fmt.Sprintf("tool=%s duration=%dms result_len=%d", tc.Name, toolDuration.Milliseconds(), len(result))
+	if cached {
+		toolContent += " cached"
+	}
+	if l.timeline != nil && s.traceID != "" {
+		// Build rich metadata for TOOL span
+		toolMeta := map[string]any{
+			"tool_name":    tc.Name,
+			"tool_call_id": tc.ID,
+			"arguments":    tc.Arguments,
+			"duration_ms":  toolDuration.Milliseconds(),
+			"result":       truncateStr(result, 10240),
+		}
+		if err != nil {
+			toolMeta["error"] = err.Error()
+		}
+		if cached {
+			toolMeta["cached"] = true
+			toolMeta["cache_age_ms"] = cacheCall.Age.Milliseconds()
+		}
+		toolMetaJSON, _ := json.Marshal(toolMeta)
 
-			// Add tool result
-			messages = append(messages, provider.Message{
-				Role:       "tool",
-				Content:    result,
-				ToolCallID: tc.ID,
+		_ = l.timeline.AddEvent(&timeline.TimelineEvent{
+			EventID:        fmt.Sprintf("TOOL_%s_%s_%s_%d", s.traceID, tc.Name, tc.ID, time.Now().UnixNano()),
+			TraceID:        s.traceID,
+			Timestamp:      toolStart,
+			SenderID:       "AGENT",
+			SenderName:     "Tool",
+			EventType:      "SYSTEM",
+			ContentText:    toolContent,
+			Classification: "TOOL",
+			Authorized:     true,
+			Metadata:       string(toolMetaJSON),
+		})
+	}
+	// Publish tool span to group traces topic
+	if l.groupPublisher != nil && l.groupPublisher.Active() && s.traceID != "" {
+		go func(traceID, toolN, content string, dur time.Duration) {
+			pubCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			now := time.Now()
+			_ = l.groupPublisher.PublishTrace(pubCtx, map[string]string{
+				"trace_id":    traceID,
+				"span_type":   "TOOL",
+				"title":       fmt.Sprintf("Tool: %s", toolN),
+				"content":     content,
+				"started_at":  now.Add(-dur).Format(time.RFC3339),
+				"ended_at":    now.Format(time.RFC3339),
+				"duration_ms": fmt.Sprintf("%d", dur.Milliseconds()),
 			})
+		}(s.traceID, tc.Name, toolContent, toolDuration)
+	}
 
-			slog.Debug("Tool executed", "name", tc.Name, "result_length", len(result))
-		}
+	// Auto-index substantive tool results
+	if l.autoIndexer != nil && err == nil && len(result) > 200 {
+		item := memory.FormatToolResult(tc.Name, tc.Arguments, result)
+		l.autoIndexer.Enqueue(item)
 	}
 
-	return "Max iterations reached. Please try a simpler request.", nil
+	// Track tool expertise
+	l.expertiseTracker.RecordToolUse(tc.Name, s.taskID, toolDuration.Milliseconds(), err == nil)
+
+	slog.Debug("Tool executed", "name", tc.Name, "result_length", len(result))
+	return toolOutcome{result: result, cached: cached}
+}
+
+// dedupMinChars is the smallest cached result worth replacing by a reference.
+const dedupMinChars = 200
+
+// withToolCache scopes tool calls for the result cache: the active trace,
+// shared with subagents, or the session when tools.cache.scope is
+// "session". Without a cache ctx is returned unchanged.
+func (l *Loop) withToolCache(ctx context.Context) (context.Context, *tools.CacheCall) {
+	if l.toolCache == nil {
+		return ctx, nil
+	}
+	call := &tools.CacheCall{Scope: l.toolCacheScope(ctx)}
+	return tools.WithCacheCall(ctx, call), call
+}
+
+func (l *Loop) toolCacheScope(ctx context.Context) string {
+	s := l.scope(ctx)
+	if l.parentCacheScope != "" {
+		return l.parentCacheScope
+	}
+	if (l.cfg != nil && l.cfg.Tools.Cache.Scope == "session") || s.traceID == "" {
+		return "session:" + l.currentSessionKey(ctx)
+	}
+	return "trace:" + s.traceID
+}
+
+// previousToolResult returns the call ID of an earlier tool message with the
+// same content, if any.
+func previousToolResult(messages []provider.Message, result string) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "tool" && messages[i].Content == result {
+			return messages[i].ToolCallID
+		}
+	}
+	return ""
 }
 
 // truncateStr returns s trimmed to maxLen characters.
@@ -1556,95 +2032,133 @@ func truncateStr(s string, maxLen int) string {
 // checkToolPolicy evaluates whether a tool call should proceed.
 // Returns (denied bool, reason string).
 func (l *Loop) checkToolPolicy(ctx context.Context, toolName string, args map[string]any) (bool, string) {
-	if l.policy == nil {
-		return false, ""
-	}
-
+	s := l.scope(ctx)
 	tier := tools.TierReadOnly
+	var blocked error
 	if t, ok := l.registry.Get(toolName); ok {
-		tier = tools.ToolTier(t)
+		tier = tools.ToolCallTier(t, args)
+		blocked = tools.CheckToolCall(t, args)
+	}
+	if l.policy == nil && blocked == nil {
+		return false, ""
 	}
 
 	policyCtx := policy.Context{
-		Sender:      l.activeSender,
-		Channel:     l.activeChannel,
+		Sender:      s.sender,
+		Channel:     s.channel,
 		Tool:        toolName,
 		Tier:        tier,
 		Arguments:   args,
-		TraceID:     l.activeTraceID,
-		MessageType: l.activeMessageType,
+		TraceID:     s.traceID,
+		MessageType: s.messageType,
+	}
+
+	var decision policy.Decision
+	if blocked != nil {
+		// Refused by the tool itself (e.g. a read of a secret file); no
+		// approval can override it.
+		decision = policy.Decision{
+			Reason:  "call_blocked: " + blocked.Error(),
+			Tier:    tier,
+			Ts:      time.Now(),
+			TraceID: s.traceID,
+		}
+	} else {
+		decision = l.policy.Evaluate(policyCtx)
 	}
 
-	decision := l.policy.Evaluate(policyCtx)
+	// A remembered approval (grant) answers the prompt in advance.
+	grantID := ""
+	if !decision.Allow && decision.RequiresApproval && l.approvalMgr != nil {
+		if g := l.approvalMgr.MatchGrant(toolName, tier, args, l.currentSessionKey(ctx)); g != nil {
+			grantID = g.GrantID
+			decision.Allow, decision.RequiresApproval = true, false
+			decision.Reason = "approval_grant:" + grantID
+		}
+	}
 
 	// Log policy decision (H-015)
 	if l.timeline != nil {
 		_ = l.timeline.LogPolicyDecision(&timeline.PolicyDecisionRecord{
-			TraceID: l.activeTraceID,
-			TaskID:  l.activeTaskID,
+			TraceID: s.traceID,
+			TaskID:  s.taskID,
 			Tool:    toolName,
 			Tier:    tier,
-			Sender:  l.activeSender,
-			Channel: l.activeChannel,
+			Sender:  s.sender,
+			Channel: s.channel,
 			Allowed: decision.Allow,
 			Reason:  decision.Reason,
+			GrantID: grantID,
 		})
 	}
 	// Publish policy decision as audit event to group
-	if l.groupPublisher != nil && l.groupPublisher.Active() && l.activeTraceID != "" {
+	if l.groupPublisher != nil && l.groupPublisher.Active() && s.traceID != "" {
 		action := "ALLOW"
 		if !decision.Allow {
 			action = "DENY"
 		}
-		detail := fmt.Sprintf("tool=%s tier=%d sender=%s action=%s reason=%s", toolName, tier, l.activeSender, action, decision.Reason)
+		detail := fmt.Sprintf("tool=%s tier=%d sender=%s action=%s reason=%s", toolName, tier, s.sender, action, decision.Reason)
 		go func(traceID, det string) {
 			pubCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
 			_ = l.groupPublisher.PublishAudit(pubCtx, "policy_decision", traceID, det)
-		}(l.activeTraceID, detail)
+		}(s.traceID, detail)
 	}
 
 	if !decision.Allow {
 		// Interactive approval gate for tier 2+ internal messages
 		if decision.RequiresApproval && l.approvalMgr != nil && l.bus != nil {
 			req := &approval.ApprovalRequest{
-				Tool:      toolName,
-				Tier:      tier,
-				Arguments: args,
-				Sender:    l.activeSender,
-				Channel:   l.activeChannel,
-				TraceID:   l.activeTraceID,
-				TaskID:    l.activeTaskID,
+				Tool:       toolName,
+				Tier:       tier,
+				Arguments:  args,
+				Sender:     s.sender,
+				Channel:    s.channel,
+				ChatID:     s.chatID,
+				ThreadID:   s.threadID,
+				SessionKey: l.currentSessionKey(ctx),
+				TraceID:    s.traceID,
+				TaskID:     s.taskID,
 			}
 			approvalID := l.approvalMgr.Create(req)
+			prompt := approvalPrompt(req, "")
+			turn := turnFrom(ctx)
+			turn.emit(TurnEvent{Type: TurnEventApprovalRequired, Tool: toolName, Arguments: args, ApprovalID: approvalID, Tier: tier, Prompt: prompt})
 
 			// Format and send prompt to user
-			argsPreview := formatArgsPreview(args)
-			prompt := fmt.Sprintf("Tool \"%s\" (tier %d) requires approval.\nArgs: %s\nReply approve:%s or deny:%s",
-				toolName, tier, argsPreview, approvalID, approvalID)
-
 			l.bus.PublishOutbound(&bus.OutboundMessage{
-				Channel:  l.activeChannel,
-				ChatID:   l.activeChatID,
-				ThreadID: l.activeThreadID,
-				TraceID:  l.activeTraceID,
-				TaskID:   l.activeTaskID,
+				Channel:  s.channel,
+				ChatID:   s.chatID,
+				ThreadID: s.threadID,
+				TraceID:  s.traceID,
+				TaskID:   s.taskID,
 				Content:  prompt,
+				// Lets channels render native approve/deny affordances
+				// (Slack buttons, Teams cards, reactions) for the same ID.
+				Action:       "approval_request",
+				ActionParams: approvalActionParams(req),
 			})
 
-			// Block with configurable timeout (default 60s)
+			// Block with configurable timeout (default 60s); escalated
+			// requests stay open for another timeout after escalating.
 			timeout := l.approvalTimeout()
+			if req.EscalateAfter > 0 && len(req.EscalateTo) > 0 {
+				timeout += req.EscalateAfter
+			}
 			waitCtx, waitCancel := context.WithTimeout(ctx, timeout)
 			defer waitCancel()
 
 			approved, err := l.approvalMgr.Wait(waitCtx, approvalID)
 			if err != nil {
 				slog.Warn("Approval wait failed", "id", approvalID, "error", err)
+				turn.emit(TurnEvent{Type: TurnEventApprovalResolved, Tool: toolName, ApprovalID: approvalID, Reason: "timeout"})
 				return true, "approval_timeout"
 			}
 			if approved {
+				turn.emit(TurnEvent{Type: TurnEventApprovalResolved, Tool: toolName, ApprovalID: approvalID, Approved: true, Reason: "approved"})
 				return false, "" // Allow execution
 			}
+			turn.emit(TurnEvent{Type: TurnEventApprovalResolved, Tool: toolName, ApprovalID: approvalID, Reason: "denied"})
 			return true, "approval_denied"
 		}
 		return true, decision.Reason
@@ -1654,23 +2168,139 @@ func (l *Loop) checkToolPolicy(ctx context.Context, toolName string, args map[st
 
 // parseApprovalResponse checks if a message is an approval response.
 // Returns (id, approved, ok).
+// Text after the ID ("approve:<id> 1h") is read by approvalGrantText.
 func parseApprovalResponse(content string) (string, bool, bool) {
 	trimmed := strings.TrimSpace(content)
 	if strings.HasPrefix(trimmed, "approve:") {
-		id := strings.TrimSpace(strings.TrimPrefix(trimmed, "approve:"))
-		if id != "" {
-			return id, true, true
+		if fields := strings.Fields(strings.TrimPrefix(trimmed, "approve:")); len(fields) > 0 {
+			return fields[0], true, true
 		}
 	}
 	if strings.HasPrefix(trimmed, "deny:") {
-		id := strings.TrimSpace(strings.TrimPrefix(trimmed, "deny:"))
-		if id != "" {
-			return id, false, true
+		if fields := strings.Fields(strings.TrimPrefix(trimmed, "deny:")); len(fields) > 0 {
+			return fields[0], false, true
 		}
 	}
 	return "", false, false
 }
 
+// transcribeInboundAudio replaces or extends the content of messages carrying
+// audio media with their transcript and marks them as voice notes.
+func (l *Loop) transcribeInboundAudio(ctx context.Context, msg *bus.InboundMessage) {
+	if l.voice == nil {
+		return
+	}
+	var transcripts []string
+	for _, media := range msg.Media {
+		if !voice.IsAudio(media) {
+			continue
+		}
+		text, err := l.voice.Transcribe(ctx, media)
+		if err != nil {
+			slog.Warn("Audio transcription failed", "channel", msg.Channel, "media", media, "error", err)
+			continue
+		}
+		if text != "" {
+			transcripts = append(transcripts, text)
+		}
+	}
+	if len(transcripts) == 0 {
+		return
+	}
+	transcript := "[Audio Transcript]: " + strings.Join(transcripts, "\n")
+	content := strings.TrimSpace(msg.Content)
+	if content == "" || content == "[Audio Message]" {
+		msg.Content = transcript
+	} else {
+		msg.Content = content + "\n" + transcript
+	}
+	if msg.Metadata == nil {
+		msg.Metadata = map[string]any{}
+	}
+	msg.Metadata[bus.MetaKeyVoiceNote] = true
+}
+
+// maxTurnMedia caps the artifacts attached to one reply.
+const maxTurnMedia = 10
+
+// addTurnMedia queues artifact paths to be attached to the reply of the
+// message carried by ctx.
+func (l *Loop) addTurnMedia(ctx context.Context, paths []string) {
+	l.scope(ctx).media.add(paths)
+}
+
+// voiceReply synthesizes a voice attachment for the reply when the chat's
+// reply mode asks for one. Failures fall back to a text-only reply.
+func (l *Loop) voiceReply(ctx context.Context, msg *bus.InboundMessage, response string, ok bool) []string {
+	if l.voice == nil || !ok || !voice.SupportsReplies(msg.Channel) {
+		return nil
+	}
+	inboundVoice, _ := msg.Metadata[bus.MetaKeyVoiceNote].(bool)
+	if !l.voice.ShouldReply(msg.Channel, msg.ChatID, inboundVoice) {
+		return nil
+	}
+	path, err := l.voice.Synthesize(ctx, msg.Channel, response)
+	if err != nil {
+		slog.Warn("Voice reply synthesis failed", "channel", msg.Channel, "error", err)
+		return nil
+	}
+	return []string{path}
+}
+
+// parseVoiceCommand checks if a message sets the chat's voice reply mode
+// ("/voice on|off|auto|default"). Returns (mode, ok); mode "" restores the
+// configured default.
+func parseVoiceCommand(content string) (string, bool) {
+	fields := strings.Fields(strings.ToLower(strings.TrimSpace(content)))
+	if len(fields) != 2 || fields[0] != "/voice" {
+		return "", false
+	}
+	switch fields[1] {
+	case "on", "always":
+		return config.VoiceReplyAlways, true
+	case "off":
+		return config.VoiceReplyOff, true
+	case "auto", "inbound":
+		return config.VoiceReplyInbound, true
+	case "default", "reset":
+		return "", true
+	}
+	return "", false
+}
+
+func describeVoiceMode(mode string) string {
+	switch mode {
+	case config.VoiceReplyAlways:
+		return "always"
+	case config.VoiceReplyOff:
+		return "off"
+	case config.VoiceReplyInbound:
+		return "when you send a voice note"
+	}
+	return "default"
+}
+
+// parseIdentityLinkCommand checks if a message is an identity link code
+// ("link <code>" or "/link <code>"). Returns (code, ok).
+func parseIdentityLinkCommand(content string) (string, bool) {
+	fields := strings.Fields(strings.TrimSpace(content))
+	if len(fields) != 2 {
+		return "", false
+	}
+	cmd := strings.ToLower(strings.TrimPrefix(fields[0], "/"))
+	code := strings.ToUpper(fields[1])
+	if cmd != "link" || len(code) != 8 {
+		return "", false
+	}
+	// Same alphabet as channel pairing codes (no 0/O or 1/I).
+	for _, r := range code {
+		if !strings.ContainsRune("ABCDEFGHJKLMNPQRSTUVWXYZ23456789", r) {
+			return "", false
+		}
+	}
+	return code, true
+}
+
 // formatArgsPreview returns a truncated JSON representation of tool arguments.
 func formatArgsPreview(args map[string]any) string {
 	if len(args) == 0 {
@@ -1705,8 +2335,9 @@ func (l *Loop) approvalTimeout() time.Duration {
 }
 
 // logMiddlewareEvents logs security-relevant middleware actions to timeline.
-func (l *Loop) logMiddlewareEvents(meta *middleware.RequestMeta, iteration int) {
-	if meta == nil || l.timeline == nil || l.activeTraceID == "" {
+func (l *Loop) logMiddlewareEvents(ctx context.Context, meta *middleware.RequestMeta, iteration int) {
+	s := l.scope(ctx)
+	if meta == nil || l.timeline == nil || s.traceID == "" {
 		return
 	}
 
@@ -1720,8 +2351,8 @@ func (l *Loop) logMiddlewareEvents(meta *middleware.RequestMeta, iteration int)
 			"message_type": meta.MessageType,
 		})
 		_ = l.timeline.AddEvent(&timeline.TimelineEvent{
-			EventID:        fmt.Sprintf("GUARD_%s_%d_%d", l.activeTraceID, iteration, time.Now().UnixNano()),
-			TraceID:        l.activeTraceID,
+			EventID:        fmt.Sprintf("GUARD_%s_%d_%d", s.traceID, iteration, time.Now().UnixNano()),
+			TraceID:        s.traceID,
 			Timestamp:      time.Now(),
 			SenderID:       meta.SenderID,
 			SenderName:     "PromptGuard",
@@ -1739,8 +2370,8 @@ func (l *Loop) logMiddlewareEvents(meta *middleware.RequestMeta, iteration int)
 		slog.Info("Prompt guard triggered", "mode", mode, "sender", meta.SenderID)
 		eventMeta, _ := json.Marshal(meta.Tags)
 		_ = l.timeline.AddEvent(&timeline.TimelineEvent{
-			EventID:        fmt.Sprintf("GUARD_%s_%d_%d", l.activeTraceID, iteration, time.Now().UnixNano()),
-			TraceID:        l.activeTraceID,
+			EventID:        fmt.Sprintf("GUARD_%s_%d_%d", s.traceID, iteration, time.Now().UnixNano()),
+			TraceID:        s.traceID,
 			Timestamp:      time.Now(),
 			SenderID:       meta.SenderID,
 			SenderName:     "PromptGuard",
@@ -1760,8 +2391,8 @@ func (l *Loop) logMiddlewareEvents(meta *middleware.RequestMeta, iteration int)
 			"channel": meta.Channel,
 		})
 		_ = l.timeline.AddEvent(&timeline.TimelineEvent{
-			EventID:        fmt.Sprintf("SANITIZE_%s_%d_%d", l.activeTraceID, iteration, time.Now().UnixNano()),
-			TraceID:        l.activeTraceID,
+			EventID:        fmt.Sprintf("SANITIZE_%s_%d_%d", s.traceID, iteration, time.Now().UnixNano()),
+			TraceID:        s.traceID,
 			Timestamp:      time.Now(),
 			SenderID:       "AGENT",
 			SenderName:     "OutputSanitizer",
@@ -1775,12 +2406,13 @@ func (l *Loop) logMiddlewareEvents(meta *middleware.RequestMeta, iteration int)
 }
 
 // trackTokens persists token usage for the active task.
-func (l *Loop) trackTokens(usage provider.Usage) {
-	if l.timeline == nil || l.activeTaskID == "" {
+func (l *Loop) trackTokens(ctx context.Context, usage provider.Usage) {
+	s := l.scope(ctx)
+	if l.timeline == nil || s.taskID == "" {
 		return
 	}
 	if usage.TotalTokens > 0 {
-		_ = l.timeline.UpdateTaskTokens(l.activeTaskID, usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens)
+		_ = l.timeline.UpdateTaskTokens(s.taskID, usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens)
 	}
 }
 
@@ -1830,19 +2462,33 @@ func SessionKey(channel, chatID string) string {
 	return strings.Join([]string{channel, chatID}, ":")
 }
 
-func (l *Loop) currentSessionKey() string {
-	channel := strings.TrimSpace(l.activeChannel)
-	chatID := strings.TrimSpace(l.activeChatID)
+func (l *Loop) currentSessionKey(ctx context.Context) string {
+	s := l.scope(ctx)
+	channel := strings.TrimSpace(s.channel)
+	chatID := strings.TrimSpace(s.chatID)
 	if channel == "" || chatID == "" {
 		return "cli:default"
 	}
 	return SessionKey(channel, chatID)
 }
 
-func (l *Loop) subagentPolicy() policy.Engine {
+// scheduleTarget is the conversation jobs created by the schedule tool run
+// in and reply to.
+func (l *Loop) scheduleTarget(ctx context.Context) scheduler.Target {
+	s := l.scope(ctx)
+	return scheduler.Target{
+		Channel:     s.channel,
+		ChatID:      s.chatID,
+		ThreadID:    s.threadID,
+		SenderID:    s.sender,
+		MessageType: s.messageType,
+	}
+}
+
+func (l *Loop) subagentPolicy(ctx context.Context) policy.Engine {
 	return &subagentPolicy{
 		base:      l.policy,
-		session:   l.currentSessionKey(),
+		session:   l.currentSessionKey(ctx),
 		manager:   l.subagents,
 		allowList: append([]string{}, l.subagentTools.Allow...),
 		denyList:  append([]string{}, l.subagentTools.Deny...),
@@ -1850,12 +2496,13 @@ func (l *Loop) subagentPolicy() policy.Engine {
 }
 
 func (l *Loop) spawnSubagentFromTool(ctx context.Context, req tools.SpawnRequest) (tools.SpawnResult, error) {
-	parentSession := l.currentSessionKey()
+	s := l.scope(ctx)
+	parentSession := l.currentSessionKey(ctx)
 	depth, err := l.subagents.canSpawn(parentSession)
 	if err != nil {
 		return tools.SpawnResult{}, err
 	}
-	targetAgentID, err := l.resolveRequestedSubagentAgentID(req.AgentID)
+	targetAgentID, err := l.resolveRequestedSubagentAgentID(l.turnAgentFrom(ctx).id, req.AgentID)
 	if err != nil {
 		return tools.SpawnResult{}, err
 	}
@@ -1865,7 +2512,7 @@ func (l *Loop) spawnSubagentFromTool(ctx context.Context, req tools.SpawnRequest
 		childModel = l.subagentModel
 	}
 	if childModel == "" {
-		childModel = l.model
+		childModel = l.turnAgentFrom(ctx).model
 	}
 	childThinking := strings.TrimSpace(req.Thinking)
 	if childThinking == "" {
@@ -1888,9 +2535,9 @@ func (l *Loop) spawnSubagentFromTool(ctx context.Context, req tools.SpawnRequest
 	run := l.subagents.register(
 		parentSession,
 		parentSession,
-		parentChannelOrDefault(l.activeChannel),
-		strings.TrimSpace(l.activeChatID),
-		strings.TrimSpace(l.activeTraceID),
+		parentChannelOrDefault(s.channel),
+		strings.TrimSpace(s.chatID),
+		strings.TrimSpace(s.traceID),
 		req.Task,
 		req.Label,
 		childModel,
@@ -1900,9 +2547,13 @@ func (l *Loop) spawnSubagentFromTool(ctx context.Context, req tools.SpawnRequest
 		depth,
 		cancel,
 	)
-	parentChannel := l.activeChannel
-	parentChatID := l.activeChatID
-	parentTraceID := l.activeTraceID
+	parentChannel := s.channel
+	parentChatID := s.chatID
+	parentTraceID := s.traceID
+	parentCacheScope := ""
+	if l.toolCache != nil {
+		parentCacheScope = l.toolCacheScope(ctx)
+	}
 
 	childTrace := parentTraceID
 	if childTrace == "" {
@@ -1916,7 +2567,7 @@ func (l *Loop) spawnSubagentFromTool(ctx context.Context, req tools.SpawnRequest
 		childLoop := NewLoop(LoopOptions{
 			Provider:                l.provider,
 			Timeline:                l.timeline,
-			Policy:                  l.subagentPolicy(),
+			Policy:                  l.subagentPolicy(ctx),
 			MemoryService:           l.memoryService,
 			AutoIndexer:             l.autoIndexer,
 			ExpertiseTracker:        l.expertiseTracker,
@@ -1937,7 +2588,10 @@ func (l *Loop) spawnSubagentFromTool(ctx context.Context, req tools.SpawnRequest
 			SubagentMemoryShareMode: l.subagentMemoryShareMode,
 			SubagentToolsAllow:      append([]string{}, l.subagentTools.Allow...),
 			SubagentToolsDeny:       append([]string{}, l.subagentTools.Deny...),
+			ToolCache:               l.toolCache,
+			ToolParallelism:         l.toolParallelism,
 		})
+		childLoop.parentCacheScope = parentCacheScope
 		if l.subagentMemoryShareMode == "inherit-readonly" {
 			l.seedChildReadonlyParentContext(childLoop, parentSession, childSessionKey)
 		}
@@ -1977,7 +2631,7 @@ func (l *Loop) spawnSubagentFromTool(ctx context.Context, req tools.SpawnRequest
 		}
 	}(run.RunID, run.ChildSessionKey, run.ParentSession, req.Task, childModel, childThinking)
 
-	l.addSubagentAuditEvent("spawn_accepted", map[string]any{
+	l.addSubagentAuditEvent(ctx, "spawn_accepted", map[string]any{
 		"run_id":            run.RunID,
 		"parent_session":    run.ParentSession,
 		"child_session_key": run.ChildSessionKey,
@@ -2095,9 +2749,11 @@ func resolveSubagentStatePath(workspace string) string {
 	return ""
 }
 
-func (l *Loop) listSubagentsForTool() []tools.SubagentRunView {
-	parentSession := l.currentSessionKey()
-	runs := l.subagents.listByController(parentSession)
+func (l *Loop) listSubagentsForTool(ctx context.Context) []tools.SubagentRunView {
+	return subagentRunViews(l.subagents.listByController(l.currentSessionKey(ctx)))
+}
+
+func subagentRunViews(runs []subagentRun) []tools.SubagentRunView {
 	out := make([]tools.SubagentRunView, 0, len(runs))
 	for _, run := range runs {
 		out = append(out, tools.SubagentRunView{
@@ -2123,8 +2779,8 @@ func (l *Loop) listSubagentsForTool() []tools.SubagentRunView {
 	return out
 }
 
-func (l *Loop) listSubagentAgentsForTool() tools.AgentDiscovery {
-	current := strings.TrimSpace(l.agentID)
+func (l *Loop) listSubagentAgentsForTool(ctx context.Context) tools.AgentDiscovery {
+	current := strings.TrimSpace(l.turnAgentFrom(ctx).id)
 	if current == "" {
 		current = "default"
 	}
@@ -2276,7 +2932,7 @@ func (l *Loop) normalizeSubagentAnnounceWithRetry(ctx context.Context, candidate
 	for attempt := 0; attempt < attempts; attempt++ {
 		reqCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
 		resp, err := l.provider.Chat(reqCtx, &provider.ChatRequest{
-			Model:       l.model,
+			Model:       l.Model(),
 			MaxTokens:   220,
 			Temperature: 0.1,
 			Messages: []provider.Message{
@@ -2371,7 +3027,7 @@ func splitSessionKey(sessionKey string) (string, string, bool) {
 	return channel, chatID, true
 }
 
-func (l *Loop) resolveSubagentAnnounceRoute(run *subagentRun, defaultChannel, defaultChatID, defaultTrace string) (channel, chatID, traceID string, ok bool) {
+func (l *Loop) resolveSubagentAnnounceRoute(ctx context.Context, run *subagentRun, defaultChannel, defaultChatID, defaultTrace string) (channel, chatID, traceID string, ok bool) {
 	if run == nil {
 		return "", "", "", false
 	}
@@ -2382,7 +3038,7 @@ func (l *Loop) resolveSubagentAnnounceRoute(run *subagentRun, defaultChannel, de
 		traceID = strings.TrimSpace(defaultTrace)
 	}
 	if traceID == "" {
-		traceID = strings.TrimSpace(l.activeTraceID)
+		traceID = strings.TrimSpace(l.scope(ctx).traceID)
 	}
 	if channel != "" && chatID != "" {
 		return channel, chatID, traceID, true
@@ -2398,7 +3054,7 @@ func (l *Loop) resolveSubagentAnnounceRoute(run *subagentRun, defaultChannel, de
 		run.RequestedBy,
 		run.RootSession,
 		run.ParentSession,
-		l.currentSessionKey(),
+		l.currentSessionKey(ctx),
 		"cli:default",
 	}
 	for _, key := range candidates {
@@ -2436,7 +3092,7 @@ func (l *Loop) publishSubagentAnnounceWithRetry(
 	}
 	l.announceMu.Unlock()
 
-	channel, chatID, traceID, routeOK := l.resolveSubagentAnnounceRoute(run, defaultChannel, defaultChatID, defaultTrace)
+	channel, chatID, traceID, routeOK := l.resolveSubagentAnnounceRoute(ctx, run, defaultChannel, defaultChatID, defaultTrace)
 	if l.bus == nil || !routeOK {
 		l.subagents.markAnnounceAttempt(run.RunID, false)
 		return false
@@ -2488,6 +3144,10 @@ func (l *Loop) deleteSessionWithRetry(sessionKey string) {
 	if sessionKey == "" || l.sessions == nil {
 		return
 	}
+	l.processes.KillSession(sessionKey)
+	if l.codeRun != nil {
+		_ = l.codeRun.ResetSession(sessionKey)
+	}
 	for i := 0; i < 8; i++ {
 		if l.sessions.Delete(sessionKey) || !l.sessionExists(sessionKey) {
 			return
@@ -2553,8 +3213,8 @@ func (l *Loop) startSubagentRetryWorker(ctx context.Context) {
 	}()
 }
 
-func (l *Loop) resolveRequestedSubagentAgentID(requested string) (string, error) {
-	current := strings.TrimSpace(l.agentID)
+func (l *Loop) resolveRequestedSubagentAgentID(current, requested string) (string, error) {
+	current = strings.TrimSpace(current)
 	if current == "" {
 		current = "default"
 	}
@@ -2588,14 +3248,14 @@ func containsAllowAgent(allow []string, target string) bool {
 	return false
 }
 
-func (l *Loop) killSubagentForTool(runID string) (bool, error) {
-	parentSession := l.currentSessionKey()
+func (l *Loop) killSubagentForTool(ctx context.Context, runID string) (bool, error) {
+	parentSession := l.currentSessionKey(ctx)
 	target := strings.TrimSpace(runID)
 	killed, err := l.subagents.killByRunID(parentSession, target)
 	if err != nil {
 		return false, err
 	}
-	l.addSubagentAuditEvent("kill", map[string]any{
+	l.addSubagentAuditEvent(ctx, "kill", map[string]any{
 		"run_id":         target,
 		"parent_session": parentSession,
 		"killed":         killed,
@@ -2603,8 +3263,8 @@ func (l *Loop) killSubagentForTool(runID string) (bool, error) {
 	return killed, nil
 }
 
-func (l *Loop) steerSubagentForTool(runID, input string) (tools.SpawnResult, error) {
-	parentSession := l.currentSessionKey()
+func (l *Loop) steerSubagentForTool(ctx context.Context, runID, input string) (tools.SpawnResult, error) {
+	parentSession := l.currentSessionKey(ctx)
 	target := strings.TrimSpace(runID)
 	steerInput := strings.TrimSpace(input)
 	if target == "" {
@@ -2619,7 +3279,7 @@ func (l *Loop) steerSubagentForTool(runID, input string) (tools.SpawnResult, err
 		return tools.SpawnResult{}, err
 	}
 
-	if _, killErr := l.killSubagentForTool(target); killErr != nil {
+	if _, killErr := l.killSubagentForTool(ctx, target); killErr != nil {
 		return tools.SpawnResult{}, killErr
 	}
 
@@ -2628,7 +3288,7 @@ func (l *Loop) steerSubagentForTool(runID, input string) (tools.SpawnResult, err
 	if label == "" {
 		label = "steered"
 	}
-	res, spawnErr := l.spawnSubagentFromTool(context.Background(), tools.SpawnRequest{
+	res, spawnErr := l.spawnSubagentFromTool(ctx, tools.SpawnRequest{
 		Task:     task,
 		Label:    fmt.Sprintf("%s-steer", label),
 		Model:    targetRun.Model,
@@ -2638,7 +3298,7 @@ func (l *Loop) steerSubagentForTool(runID, input string) (tools.SpawnResult, err
 	if spawnErr != nil {
 		return tools.SpawnResult{}, spawnErr
 	}
-	l.addSubagentAuditEvent("steer", map[string]any{
+	l.addSubagentAuditEvent(ctx, "steer", map[string]any{
 		"target_run_id":   target,
 		"new_run_id":      res.RunID,
 		"parent_session":  parentSession,
@@ -2648,14 +3308,15 @@ func (l *Loop) steerSubagentForTool(runID, input string) (tools.SpawnResult, err
 	return res, nil
 }
 
-func (l *Loop) addSubagentAuditEvent(action string, details map[string]any) {
-	if l.timeline == nil || l.activeTraceID == "" {
+func (l *Loop) addSubagentAuditEvent(ctx context.Context, action string, details map[string]any) {
+	s := l.scope(ctx)
+	if l.timeline == nil || s.traceID == "" {
 		return
 	}
 	meta, _ := json.Marshal(details)
 	_ = l.timeline.AddEvent(&timeline.TimelineEvent{
 		EventID:        fmt.Sprintf("SUBAGENT_%s_%d", action, time.Now().UnixNano()),
-		TraceID:        l.activeTraceID,
+		TraceID:        s.traceID,
 		Timestamp:      time.Now(),
 		SenderID:       "AGENT",
 		SenderName:     "SubagentController",
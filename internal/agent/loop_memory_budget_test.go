@@ -1,7 +1,9 @@
 package agent
 
 import (
+	"context"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/KafClaw/KafClaw/internal/config"
@@ -158,8 +160,8 @@ func TestRecordMemoryOverflowIncrementsCounters(t *testing.T) {
 	defer tl.Close()
 
 	l := &Loop{timeline: tl}
-	l.recordMemoryOverflow("rag")
-	l.recordMemoryOverflow("rag")
+	l.recordMemoryOverflow(context.Background(), "rag")
+	l.recordMemoryOverflow(context.Background(), "rag")
 
 	total, err := tl.GetSetting("memory_overflow_events_total")
 	if err != nil {
@@ -184,8 +186,8 @@ func TestRecordMemoryOverflowWithTraceAddsEvent(t *testing.T) {
 	}
 	defer tl.Close()
 
-	l := &Loop{timeline: tl, activeTraceID: "trace-1"}
-	l.recordMemoryOverflow("observation")
+	l := &Loop{timeline: tl}
+	l.recordMemoryOverflow(withTurnScope(context.Background(), &turnScope{traceID: "trace-1"}), "observation")
 
 	var count int
 	if err := tl.DB().QueryRow(`SELECT COUNT(*) FROM timeline WHERE trace_id = ? AND classification = 'MEMORY_CONTEXT_OVERFLOW'`, "trace-1").Scan(&count); err != nil {
@@ -216,3 +218,52 @@ func TestIncrementSettingCounterHandlesInvalidCurrentValue(t *testing.T) {
 		t.Fatalf("expected invalid value reset to 1, got %s", v)
 	}
 }
+
+func TestInjectKnowledgeFactsUsesOwnBudget(t *testing.T) {
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	defer tl.Close()
+	for _, rec := range []timeline.KnowledgeFactRecord{
+		{FactID: "f1", GroupName: "kafclaw", Subject: "payments-api", Predicate: "owned_by", Object: "team-billing", Version: 2, Source: "s", Tags: "[]"},
+		{FactID: "f2", GroupName: "kafclaw", Subject: "search", Predicate: "owned_by", Object: "team-discovery", Version: 1, Source: "s", Tags: "[]"},
+		{FactID: "f3", GroupName: "other", Subject: "payments-api", Predicate: "owned_by", Object: "team-x", Version: 1, Source: "s", Tags: "[]"},
+	} {
+		rec := rec
+		if err := tl.UpsertKnowledgeFactLatest(&rec); err != nil {
+			t.Fatalf("upsert fact: %v", err)
+		}
+	}
+
+	cfg := config.DefaultConfig()
+	l := &Loop{cfg: cfg, timeline: tl}
+	msgs := []provider.Message{{Role: "system", Content: "base"}}
+	if got := l.injectKnowledgeFacts(context.Background(), msgs, "who owns payments api?"); got[0].Content != "base" {
+		t.Fatalf("expected no facts while knowledge is disabled, got %q", got[0].Content)
+	}
+
+	cfg.Knowledge.Enabled = true
+	got := l.injectKnowledgeFacts(context.Background(), []provider.Message{{Role: "system", Content: "base"}}, "who owns payments api?")
+	content := got[0].Content
+	if !strings.Contains(content, "# Group Knowledge") || !strings.Contains(content, "payments-api | owned_by | team-billing (v2)") {
+		t.Fatalf("expected relevant fact in prompt, got %q", content)
+	}
+	if strings.Contains(content, "team-discovery") || strings.Contains(content, "team-x") {
+		t.Fatalf("expected only relevant facts of the configured group, got %q", content)
+	}
+
+	cfg.Knowledge.ContextBudgetChars = 40
+	got = l.injectKnowledgeFacts(context.Background(), []provider.Message{{Role: "system", Content: "base"}}, "who owns payments api?")
+	if len(got[0].Content) != len("base")+40 {
+		t.Fatalf("expected facts section capped at 40 chars, got %q", got[0].Content)
+	}
+	if n, _ := tl.GetSetting("memory_overflow_events_facts"); n != "1" {
+		t.Fatalf("expected one facts overflow event, got %q", n)
+	}
+
+	cfg.Knowledge.ContextMaxFacts = 0
+	if got := l.injectKnowledgeFacts(context.Background(), []provider.Message{{Role: "system", Content: "base"}}, "payments"); got[0].Content != "base" {
+		t.Fatalf("expected lane disabled with contextMaxFacts=0, got %q", got[0].Content)
+	}
+}
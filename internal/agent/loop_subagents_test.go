@@ -77,29 +77,33 @@ func (s *staticPolicy) Evaluate(_ policy.Context) policy.Decision {
 
 func TestLoopCurrentSessionKey(t *testing.T) {
 	loop := NewLoop(LoopOptions{Workspace: t.TempDir(), WorkRepo: t.TempDir()})
-	if got := loop.currentSessionKey(); got != "cli:default" {
+	turn := &turnScope{}
+	ctx := withTurnScope(context.Background(), turn)
+	if got := loop.currentSessionKey(ctx); got != "cli:default" {
 		t.Fatalf("expected default session key, got %s", got)
 	}
 
-	loop.activeChannel = "whatsapp"
-	loop.activeChatID = "abc123"
-	if got := loop.currentSessionKey(); got != "whatsapp:abc123" {
+	turn.channel = "whatsapp"
+	turn.chatID = "abc123"
+	if got := loop.currentSessionKey(ctx); got != "whatsapp:abc123" {
 		t.Fatalf("expected whatsapp:abc123, got %s", got)
 	}
 }
 
 func TestLoopCurrentSessionKeyIsolationAcrossChannels(t *testing.T) {
 	loop := NewLoop(LoopOptions{Workspace: t.TempDir(), WorkRepo: t.TempDir()})
-	loop.activeChatID = "shared-room"
+	turn := &turnScope{}
+	ctx := withTurnScope(context.Background(), turn)
+	turn.chatID = "shared-room"
 
-	loop.activeChannel = "whatsapp"
-	wa := loop.currentSessionKey()
+	turn.channel = "whatsapp"
+	wa := loop.currentSessionKey(ctx)
 	if wa != "whatsapp:shared-room" {
 		t.Fatalf("unexpected whatsapp key: %s", wa)
 	}
 
-	loop.activeChannel = "slack"
-	slack := loop.currentSessionKey()
+	turn.channel = "slack"
+	slack := loop.currentSessionKey(ctx)
 	if slack != "slack:shared-room" {
 		t.Fatalf("unexpected slack key: %s", slack)
 	}
@@ -107,8 +111,8 @@ func TestLoopCurrentSessionKeyIsolationAcrossChannels(t *testing.T) {
 		t.Fatalf("expected channel-isolated keys, got same key %q", slack)
 	}
 
-	loop.activeChannel = "msteams"
-	teams := loop.currentSessionKey()
+	turn.channel = "msteams"
+	teams := loop.currentSessionKey(ctx)
 	if teams != "msteams:shared-room" {
 		t.Fatalf("unexpected teams key: %s", teams)
 	}
@@ -123,10 +127,12 @@ func TestLoopSubagentPolicy(t *testing.T) {
 		WorkRepo:              t.TempDir(),
 		MaxSubagentSpawnDepth: 1,
 	})
-	loop.activeChannel = "subagent"
-	loop.activeChatID = "child"
+	turn := &turnScope{}
+	ctx := withTurnScope(context.Background(), turn)
+	turn.channel = "subagent"
+	turn.chatID = "child"
 	loop.subagents.sessionDepth["subagent:child"] = 1
-	p := loop.subagentPolicy()
+	p := loop.subagentPolicy(ctx)
 
 	denied := p.Evaluate(policy.Context{Tool: "sessions_spawn", Tier: tools.TierWrite, TraceID: "trace-1"})
 	if denied.Allow {
@@ -139,7 +145,7 @@ func TestLoopSubagentPolicy(t *testing.T) {
 	loop.policy = &staticPolicy{
 		decision: policy.Decision{Allow: true, Reason: "base_ok", Tier: tools.TierReadOnly},
 	}
-	allowed := loop.subagentPolicy().Evaluate(policy.Context{Tool: "read_file", Tier: tools.TierReadOnly})
+	allowed := loop.subagentPolicy(ctx).Evaluate(policy.Context{Tool: "read_file", Tier: tools.TierReadOnly})
 	if !allowed.Allow || allowed.Reason != "base_ok" {
 		t.Fatalf("expected base policy decision passthrough, got %+v", allowed)
 	}
@@ -154,17 +160,17 @@ func TestLoopSubagentPolicy_AllowDenyLists(t *testing.T) {
 	})
 	loop.policy = &staticPolicy{decision: policy.Decision{Allow: true, Reason: "base_ok"}}
 
-	read := loop.subagentPolicy().Evaluate(policy.Context{Tool: "read_file", Tier: tools.TierReadOnly})
+	read := loop.subagentPolicy(context.Background()).Evaluate(policy.Context{Tool: "read_file", Tier: tools.TierReadOnly})
 	if !read.Allow {
 		t.Fatalf("expected read_file allowed, got %+v", read)
 	}
 
-	exec := loop.subagentPolicy().Evaluate(policy.Context{Tool: "exec", Tier: tools.TierHighRisk})
+	exec := loop.subagentPolicy(context.Background()).Evaluate(policy.Context{Tool: "exec", Tier: tools.TierHighRisk})
 	if exec.Allow || exec.Reason != "subagent_tool_denied_by_policy" {
 		t.Fatalf("expected exec denied by policy, got %+v", exec)
 	}
 
-	write := loop.subagentPolicy().Evaluate(policy.Context{Tool: "write_file", Tier: tools.TierWrite})
+	write := loop.subagentPolicy(context.Background()).Evaluate(policy.Context{Tool: "write_file", Tier: tools.TierWrite})
 	if write.Allow || write.Reason != "subagent_tool_denied_by_policy" {
 		t.Fatalf("expected allowlist miss denied, got %+v", write)
 	}
@@ -177,13 +183,15 @@ func TestLoopSubagentListAndKill(t *testing.T) {
 		MaxSubagentSpawnDepth: 2,
 		MaxSubagentChildren:   3,
 	})
-	loop.activeChannel = "cli"
-	loop.activeChatID = "default"
+	turn := &turnScope{}
+	ctx := withTurnScope(context.Background(), turn)
+	turn.channel = "cli"
+	turn.chatID = "default"
 
 	run := loop.subagents.register("cli:default", "cli:default", "", "", "", "work", "worker", "", "", "", "keep", 1, func() {})
 	loop.subagents.markRunning(run.RunID)
 
-	list := loop.listSubagentsForTool()
+	list := loop.listSubagentsForTool(ctx)
 	if len(list) != 1 {
 		t.Fatalf("expected 1 run, got %d", len(list))
 	}
@@ -191,7 +199,7 @@ func TestLoopSubagentListAndKill(t *testing.T) {
 		t.Fatalf("unexpected list entry: %+v", list[0])
 	}
 
-	killed, err := loop.killSubagentForTool("  " + run.RunID + "  ")
+	killed, err := loop.killSubagentForTool(ctx, "  "+run.RunID+"  ")
 	if err != nil {
 		t.Fatalf("kill err: %v", err)
 	}
@@ -227,11 +235,13 @@ func TestLoopSpawnSubagentFromTool_Success(t *testing.T) {
 		MaxSubagentSpawnDepth: 1,
 		MaxSubagentChildren:   2,
 	})
-	loop.activeChannel = "whatsapp"
-	loop.activeChatID = "owner@s.whatsapp.net"
-	loop.activeTraceID = "trace-parent"
+	turn := &turnScope{}
+	turnCtx := withTurnScope(context.Background(), turn)
+	turn.channel = "whatsapp"
+	turn.chatID = "owner@s.whatsapp.net"
+	turn.traceID = "trace-parent"
 
-	res, err := loop.spawnSubagentFromTool(context.Background(), tools.SpawnRequest{
+	res, err := loop.spawnSubagentFromTool(turnCtx, tools.SpawnRequest{
 		Task:  "say hello",
 		Label: "worker-1",
 	})
@@ -245,7 +255,7 @@ func TestLoopSpawnSubagentFromTool_Success(t *testing.T) {
 	var gotCompleted bool
 	deadline := time.Now().Add(3 * time.Second)
 	for time.Now().Before(deadline) {
-		list := loop.listSubagentsForTool()
+		list := loop.listSubagentsForTool(turnCtx)
 		if len(list) == 1 && list[0].Status == "completed" {
 			gotCompleted = true
 			break
@@ -299,15 +309,17 @@ func TestLoopSpawnSubagentFromTool_IsolatedModeSkipsParentHandoff(t *testing.T)
 		MaxSubagentChildren:     2,
 		SubagentMemoryShareMode: "isolated",
 	})
-	loop.activeChannel = "cli"
-	loop.activeChatID = "default"
-	res, err := loop.spawnSubagentFromTool(context.Background(), tools.SpawnRequest{Task: "isolated child"})
+	turn := &turnScope{}
+	ctx := withTurnScope(context.Background(), turn)
+	turn.channel = "cli"
+	turn.chatID = "default"
+	res, err := loop.spawnSubagentFromTool(ctx, tools.SpawnRequest{Task: "isolated child"})
 	if err != nil {
 		t.Fatalf("spawn err: %v", err)
 	}
 	deadline := time.Now().Add(2 * time.Second)
 	for time.Now().Before(deadline) {
-		list := loop.listSubagentsForTool()
+		list := loop.listSubagentsForTool(ctx)
 		if len(list) == 1 && list[0].Status == "completed" {
 			break
 		}
@@ -333,14 +345,16 @@ func TestLoopSpawnSubagentFromTool_InheritReadonlySeedsChildContext(t *testing.T
 		MaxSubagentChildren:     2,
 		SubagentMemoryShareMode: "inherit-readonly",
 	})
-	loop.activeChannel = "cli"
-	loop.activeChatID = "default"
+	turn := &turnScope{}
+	ctx := withTurnScope(context.Background(), turn)
+	turn.channel = "cli"
+	turn.chatID = "default"
 	parent := loop.sessions.GetOrCreate("cli:default")
 	parent.AddMessage("user", "Parent context: bug reproduced in parser")
 	if err := loop.sessions.Save(parent); err != nil {
 		t.Fatalf("save parent session err: %v", err)
 	}
-	_, err := loop.spawnSubagentFromTool(context.Background(), tools.SpawnRequest{Task: "fix parser bug"})
+	_, err := loop.spawnSubagentFromTool(ctx, tools.SpawnRequest{Task: "fix parser bug"})
 	if err != nil {
 		t.Fatalf("spawn err: %v", err)
 	}
@@ -365,11 +379,13 @@ func TestLoopSpawnSubagentFromTool_DepthDenied(t *testing.T) {
 		MaxSubagentSpawnDepth: 1,
 		MaxSubagentChildren:   2,
 	})
-	loop.activeChannel = "subagent"
-	loop.activeChatID = "child-1"
+	turn := &turnScope{}
+	ctx := withTurnScope(context.Background(), turn)
+	turn.channel = "subagent"
+	turn.chatID = "child-1"
 	loop.subagents.sessionDepth["subagent:child-1"] = 1
 
-	_, err := loop.spawnSubagentFromTool(context.Background(), tools.SpawnRequest{Task: "nested"})
+	_, err := loop.spawnSubagentFromTool(ctx, tools.SpawnRequest{Task: "nested"})
 	if err == nil {
 		t.Fatal("expected depth deny error")
 	}
@@ -390,17 +406,19 @@ func TestLoopSpawnSubagentFromTool_AgentAllowlist(t *testing.T) {
 		AgentID:               "agent-main",
 		SubagentAllowAgents:   []string{"agent-main", "agent-research"},
 	})
-	loop.activeChannel = "cli"
-	loop.activeChatID = "default"
+	turn := &turnScope{}
+	ctx := withTurnScope(context.Background(), turn)
+	turn.channel = "cli"
+	turn.chatID = "default"
 
-	if _, err := loop.spawnSubagentFromTool(context.Background(), tools.SpawnRequest{
+	if _, err := loop.spawnSubagentFromTool(ctx, tools.SpawnRequest{
 		Task:    "allowed",
 		AgentID: "agent-research",
 	}); err != nil {
 		t.Fatalf("expected allowed agentId, got err: %v", err)
 	}
 
-	if _, err := loop.spawnSubagentFromTool(context.Background(), tools.SpawnRequest{
+	if _, err := loop.spawnSubagentFromTool(ctx, tools.SpawnRequest{
 		Task:    "denied",
 		AgentID: "agent-other",
 	}); err == nil || !strings.Contains(err.Error(), "not allowed") {
@@ -419,16 +437,18 @@ func TestLoopSpawnSubagentFromTool_DefaultAllowCurrentOnly(t *testing.T) {
 		MaxSubagentChildren:   2,
 		AgentID:               "agent-main",
 	})
-	loop.activeChannel = "cli"
-	loop.activeChatID = "default"
+	turn := &turnScope{}
+	ctx := withTurnScope(context.Background(), turn)
+	turn.channel = "cli"
+	turn.chatID = "default"
 
-	if _, err := loop.spawnSubagentFromTool(context.Background(), tools.SpawnRequest{
+	if _, err := loop.spawnSubagentFromTool(ctx, tools.SpawnRequest{
 		Task:    "same",
 		AgentID: "agent-main",
 	}); err != nil {
 		t.Fatalf("expected current agent allowed, got err: %v", err)
 	}
-	if _, err := loop.spawnSubagentFromTool(context.Background(), tools.SpawnRequest{
+	if _, err := loop.spawnSubagentFromTool(ctx, tools.SpawnRequest{
 		Task:    "other",
 		AgentID: "agent-other",
 	}); err == nil || !strings.Contains(err.Error(), "default allows only current agent") {
@@ -452,24 +472,26 @@ func TestLoopSteerSubagentForTool(t *testing.T) {
 		MaxSubagentSpawnDepth: 1,
 		MaxSubagentChildren:   3,
 	})
-	loop.activeChannel = "cli"
-	loop.activeChatID = "default"
+	turn := &turnScope{}
+	ctx := withTurnScope(context.Background(), turn)
+	turn.channel = "cli"
+	turn.chatID = "default"
 
-	first, err := loop.spawnSubagentFromTool(context.Background(), tools.SpawnRequest{Task: "base task", Label: "worker"})
+	first, err := loop.spawnSubagentFromTool(ctx, tools.SpawnRequest{Task: "base task", Label: "worker"})
 	if err != nil {
 		t.Fatalf("spawn err: %v", err)
 	}
 
 	deadline := time.Now().Add(3 * time.Second)
 	for time.Now().Before(deadline) {
-		list := loop.listSubagentsForTool()
+		list := loop.listSubagentsForTool(ctx)
 		if len(list) > 0 && list[0].RunID == first.RunID && list[0].Status == "completed" {
 			break
 		}
 		time.Sleep(20 * time.Millisecond)
 	}
 
-	steered, err := loop.steerSubagentForTool(first.RunID, "do this differently")
+	steered, err := loop.steerSubagentForTool(ctx, first.RunID, "do this differently")
 	if err != nil {
 		t.Fatalf("steer err: %v", err)
 	}
@@ -499,15 +521,17 @@ func TestLoopSubagentAuditEvents(t *testing.T) {
 		MaxSubagentSpawnDepth: 1,
 		MaxSubagentChildren:   3,
 	})
-	loop.activeChannel = "cli"
-	loop.activeChatID = "default"
-	loop.activeTraceID = "trace-subagent-audit"
+	turn := &turnScope{}
+	ctx := withTurnScope(context.Background(), turn)
+	turn.channel = "cli"
+	turn.chatID = "default"
+	turn.traceID = "trace-subagent-audit"
 
-	spawned, err := loop.spawnSubagentFromTool(context.Background(), tools.SpawnRequest{Task: "task", Label: "l1"})
+	spawned, err := loop.spawnSubagentFromTool(ctx, tools.SpawnRequest{Task: "task", Label: "l1"})
 	if err != nil {
 		t.Fatalf("spawn err: %v", err)
 	}
-	if _, err := loop.steerSubagentForTool(spawned.RunID, "retask"); err != nil {
+	if _, err := loop.steerSubagentForTool(ctx, spawned.RunID, "retask"); err != nil {
 		t.Fatalf("steer err: %v", err)
 	}
 
@@ -537,16 +561,18 @@ func TestLoopSteerSubagentForTool_Validation(t *testing.T) {
 		MaxSubagentSpawnDepth: 1,
 		MaxSubagentChildren:   2,
 	})
-	loop.activeChannel = "cli"
-	loop.activeChatID = "default"
+	turn := &turnScope{}
+	ctx := withTurnScope(context.Background(), turn)
+	turn.channel = "cli"
+	turn.chatID = "default"
 
-	if _, err := loop.steerSubagentForTool("", "x"); err == nil {
+	if _, err := loop.steerSubagentForTool(ctx, "", "x"); err == nil {
 		t.Fatal("expected empty target validation error")
 	}
-	if _, err := loop.steerSubagentForTool("run-1", ""); err == nil {
+	if _, err := loop.steerSubagentForTool(ctx, "run-1", ""); err == nil {
 		t.Fatal("expected empty input validation error")
 	}
-	if _, err := loop.steerSubagentForTool("missing", "x"); err == nil || !strings.Contains(err.Error(), "unknown subagent run") {
+	if _, err := loop.steerSubagentForTool(ctx, "missing", "x"); err == nil || !strings.Contains(err.Error(), "unknown subagent run") {
 		t.Fatalf("unexpected missing run error: %v", err)
 	}
 
@@ -562,10 +588,12 @@ func TestLoopSpawnSubagentFromTool_Timeout(t *testing.T) {
 		MaxSubagentSpawnDepth: 1,
 		MaxSubagentChildren:   2,
 	})
-	loop.activeChannel = "cli"
-	loop.activeChatID = "default"
+	turn := &turnScope{}
+	ctx := withTurnScope(context.Background(), turn)
+	turn.channel = "cli"
+	turn.chatID = "default"
 
-	spawned, err := loop.spawnSubagentFromTool(context.Background(), tools.SpawnRequest{
+	spawned, err := loop.spawnSubagentFromTool(ctx, tools.SpawnRequest{
 		Task:              "timeout me",
 		RunTimeoutSeconds: 1,
 	})
@@ -575,7 +603,7 @@ func TestLoopSpawnSubagentFromTool_Timeout(t *testing.T) {
 
 	deadline := time.Now().Add(3 * time.Second)
 	for time.Now().Before(deadline) {
-		list := loop.listSubagentsForTool()
+		list := loop.listSubagentsForTool(ctx)
 		for _, run := range list {
 			if run.RunID == spawned.RunID && run.Status == "timeout" {
 				return
@@ -643,7 +671,7 @@ func TestLoopListSubagentAgentsForTool(t *testing.T) {
 		AgentID:             "agent-main",
 		SubagentAllowAgents: []string{"agent-main", "agent-research", "*"},
 	})
-	got := loop.listSubagentAgentsForTool()
+	got := loop.listSubagentAgentsForTool(context.Background())
 	if got.CurrentAgentID != "agent-main" {
 		t.Fatalf("unexpected current agent: %+v", got)
 	}
@@ -865,15 +893,17 @@ func TestLoopResolveSubagentAnnounceRoute_Fallbacks(t *testing.T) {
 		Workspace: t.TempDir(),
 		WorkRepo:  t.TempDir(),
 	})
-	loop.activeChannel = "webui"
-	loop.activeChatID = "active-chat"
-	loop.activeTraceID = "trace-active"
+	turn := &turnScope{}
+	ctx := withTurnScope(context.Background(), turn)
+	turn.channel = "webui"
+	turn.chatID = "active-chat"
+	turn.traceID = "trace-active"
 
 	run := &subagentRun{
 		RequestedBy: "whatsapp:owner@s.whatsapp.net",
 		RootSession: "cli:default",
 	}
-	channel, chatID, traceID, ok := loop.resolveSubagentAnnounceRoute(run, "", "", "")
+	channel, chatID, traceID, ok := loop.resolveSubagentAnnounceRoute(ctx, run, "", "", "")
 	if !ok || channel != "whatsapp" || chatID != "owner@s.whatsapp.net" {
 		t.Fatalf("expected requestedBy fallback route, got ok=%v channel=%q chat=%q", ok, channel, chatID)
 	}
@@ -886,13 +916,13 @@ func TestLoopResolveSubagentAnnounceRoute_Fallbacks(t *testing.T) {
 		RequesterChatID: "12345",
 		RequesterTrace:  "trace-run",
 	}
-	channel, chatID, traceID, ok = loop.resolveSubagentAnnounceRoute(run, "whatsapp", "fallback", "trace-default")
+	channel, chatID, traceID, ok = loop.resolveSubagentAnnounceRoute(ctx, run, "whatsapp", "fallback", "trace-default")
 	if !ok || channel != "telegram" || chatID != "12345" || traceID != "trace-run" {
 		t.Fatalf("expected explicit requester route, got ok=%v channel=%q chat=%q trace=%q", ok, channel, chatID, traceID)
 	}
 
 	run = &subagentRun{}
-	channel, chatID, _, ok = loop.resolveSubagentAnnounceRoute(run, "", "", "")
+	channel, chatID, _, ok = loop.resolveSubagentAnnounceRoute(ctx, run, "", "", "")
 	if !ok || channel != "webui" || chatID != "active-chat" {
 		t.Fatalf("expected active session fallback, got ok=%v channel=%q chat=%q", ok, channel, chatID)
 	}
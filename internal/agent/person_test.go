@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/memory"
+	"github.com/KafClaw/KafClaw/internal/policy"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestParseIdentityLinkCommand(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{"link ABCD2345", "ABCD2345", true},
+		{"  /link abcd2345 ", "ABCD2345", true},
+		{"link tutorial", "", false}, // I is not in the code alphabet
+		{"link ABC", "", false},
+		{"please link ABCD2345", "", false},
+		{"hello", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseIdentityLinkCommand(tt.input)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseIdentityLinkCommand(%q) = %q, %v", tt.input, got, ok)
+		}
+	}
+}
+
+func TestProcessMessageScopesWorkingMemoryToLinkedPerson(t *testing.T) {
+	tl := newTestTimeline(t)
+	prov := &capturingProvider{}
+	wm := memory.NewWorkingMemoryStore(tl.DB())
+	loop := NewLoop(LoopOptions{
+		Bus:           bus.NewMessageBus(),
+		Provider:      prov,
+		Timeline:      tl,
+		Policy:        policy.NewDefaultEngine(),
+		WorkingMemory: wm,
+		Workspace:     t.TempDir(),
+		Model:         "capture-model",
+		MaxIterations: 2,
+	})
+
+	person, err := tl.CreatePerson("Dana")
+	if err != nil {
+		t.Fatalf("create person: %v", err)
+	}
+	_ = tl.LinkPersonIdentity(person.PersonID, "slack", "U7")
+	_ = tl.LinkPersonIdentity(person.PersonID, "whatsapp", "497@s.whatsapp.net")
+	_ = tl.SetPersonPreference(person.PersonID, "language", "German")
+	if err := wm.Save(timeline.PersonResourceID(person.PersonID), "", "Dana is planning a trip to Lisbon."); err != nil {
+		t.Fatalf("save working memory: %v", err)
+	}
+
+	for _, msg := range []*bus.InboundMessage{
+		{Channel: "slack", SenderID: "U7", ChatID: "D1", Content: "hi from slack"},
+		{Channel: "whatsapp", SenderID: "497@s.whatsapp.net", ChatID: "497@s.whatsapp.net", Content: "hi from whatsapp"},
+	} {
+		if _, _, err := loop.processMessage(context.Background(), msg); err != nil {
+			t.Fatalf("process: %v", err)
+		}
+		system := prov.LastRequest().Messages[0].Content
+		if !strings.Contains(system, "trip to Lisbon") || !strings.Contains(system, "- language: German") {
+			t.Fatalf("%s: expected person-scoped memory and preferences in system prompt:\n%s", msg.Channel, system)
+		}
+	}
+
+	if _, _, err := loop.processMessage(context.Background(), &bus.InboundMessage{Channel: "slack", SenderID: "U8", ChatID: "D2", Content: "hi"}); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if strings.Contains(prov.LastRequest().Messages[0].Content, "trip to Lisbon") {
+		t.Fatal("unlinked sender must not see the person's working memory")
+	}
+}
+
+func TestRunInterceptsIdentityLinkCode(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	var linked []string
+	loop := NewLoop(LoopOptions{
+		Bus:           msgBus,
+		Provider:      &mockProvider{},
+		Timeline:      newTestTimeline(t),
+		Policy:        policy.NewDefaultEngine(),
+		Workspace:     t.TempDir(),
+		Model:         "mock-model",
+		MaxIterations: 2,
+		IdentityLinker: func(channel, senderID, code string) (string, error) {
+			if code != "ABCD2345" {
+				return "", fmt.Errorf("unknown code")
+			}
+			linked = append(linked, channel+"|"+senderID)
+			return "Erin", nil
+		},
+	})
+
+	var outbound outboundCapture
+	msgBus.Subscribe("slack", outbound.add)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go msgBus.DispatchOutbound(ctx)
+	go loop.Run(ctx)
+
+	msgBus.PublishInbound(&bus.InboundMessage{Channel: "slack", SenderID: "U9", ChatID: "D9", Content: "link abcd2345"})
+	msgBus.PublishInbound(&bus.InboundMessage{Channel: "slack", SenderID: "U9", ChatID: "D9", Content: "link ZZZZ2345"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(outbound.snapshot()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	msgs := outbound.snapshot()
+	if len(msgs) < 2 {
+		t.Fatalf("expected two replies, got %+v", msgs)
+	}
+	if msgs[0].Content != "Linked this slack identity to Erin." || !strings.Contains(msgs[1].Content, "not recognized") {
+		t.Fatalf("unexpected replies %+v", msgs)
+	}
+	if len(linked) != 1 || linked[0] != "slack|U9" {
+		t.Fatalf("unexpected link calls %v", linked)
+	}
+}
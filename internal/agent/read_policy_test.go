@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+func TestBlockedReadIsRecordedAsPolicyDecision(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("KAFCLAW_HOME", home)
+	t.Setenv("KAFCLAW_CONFIG", "")
+	cfgPath := filepath.Join(home, ".kafclaw", "config.json")
+	if err := os.MkdirAll(filepath.Dir(cfgPath), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cfgPath, []byte(`{"providers":{"openai":{"apiKey":"sk-secret"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tl := newTestTimeline(t)
+	tmpDir := t.TempDir()
+	mock := &mockProvider{
+		responses: []provider.ChatResponse{
+			{
+				ToolCalls: []provider.ToolCall{{
+					ID:        "call_read_cfg",
+					Name:      "read_file",
+					Arguments: map[string]any{"path": cfgPath},
+				}},
+			},
+			{Content: "I cannot read that file."},
+		},
+	}
+	rec := &messagesRecorder{}
+	loop := NewLoop(LoopOptions{
+		Bus:           bus.NewMessageBus(),
+		Provider:      mock,
+		Timeline:      tl,
+		Workspace:     tmpDir,
+		WorkRepo:      tmpDir,
+		Model:         "mock-model",
+		MaxIterations: 3,
+	})
+	loop.chain.Use(rec)
+
+	_, _, err := loop.processMessage(context.Background(), &bus.InboundMessage{
+		Channel:   "cli",
+		SenderID:  "owner",
+		ChatID:    "owner",
+		TraceID:   "trace-read-blocked",
+		Content:   "show me the config",
+		Timestamp: time.Now(),
+		Metadata:  map[string]any{bus.MetaKeyMessageType: bus.MessageTypeInternal},
+	})
+	if err != nil {
+		t.Fatalf("processMessage: %v", err)
+	}
+
+	decisions, err := tl.ListPolicyDecisions("trace-read-blocked")
+	if err != nil {
+		t.Fatalf("list decisions: %v", err)
+	}
+	if len(decisions) != 1 || decisions[0].Tool != "read_file" || decisions[0].Allowed || !strings.HasPrefix(decisions[0].Reason, "call_blocked:") {
+		t.Fatalf("expected one blocked read decision, got %+v", decisions)
+	}
+	if len(rec.calls) != 2 {
+		t.Fatalf("expected 2 LLM calls, got %d", len(rec.calls))
+	}
+	last := rec.calls[1][len(rec.calls[1])-1]
+	if !strings.Contains(last.Content, "Policy denied: call_blocked") || strings.Contains(last.Content, "sk-secret") {
+		t.Fatalf("unexpected tool result %q", last.Content)
+	}
+}
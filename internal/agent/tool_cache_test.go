@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/provider/middleware"
+)
+
+// metaRecorder keeps the request meta and last message of each LLM call.
+type metaRecorder struct {
+	metas []middleware.RequestMeta
+	last  []provider.Message
+}
+
+func (m *metaRecorder) Name() string { return "meta-recorder" }
+func (m *metaRecorder) ProcessRequest(_ context.Context, req *provider.ChatRequest, meta *middleware.RequestMeta) error {
+	m.metas = append(m.metas, *meta)
+	m.last = append(m.last, req.Messages[len(req.Messages)-1])
+	return nil
+}
+func (m *metaRecorder) ProcessResponse(context.Context, *provider.ChatRequest, *provider.ChatResponse, *middleware.RequestMeta) error {
+	return nil
+}
+
+func TestToolCacheDeduplicatesRepeatedReads(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.txt")
+	content := strings.Repeat("line of text\n", 30)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	read := func(id string) provider.ChatResponse {
+		return provider.ChatResponse{ToolCalls: []provider.ToolCall{{ID: id, Name: "read_file", Arguments: map[string]any{"path": path}}}}
+	}
+	mock := &mockProvider{responses: []provider.ChatResponse{read("call_1"), read("call_2"), {Content: "done"}}}
+	cfg := config.DefaultConfig()
+	cfg.Tools.Cache.Enabled = true
+	loop := NewLoop(LoopOptions{
+		Provider:      mock,
+		Workspace:     dir,
+		WorkRepo:      dir,
+		Model:         "mock-model",
+		MaxIterations: 5,
+		Config:        cfg,
+	})
+	rec := &metaRecorder{}
+	loop.chain.Use(rec)
+
+	resp, err := loop.ProcessDirectWithTrace(context.Background(), "read it twice", "cli:default", "trace-cache-1")
+	if err != nil || resp != "done" {
+		t.Fatalf("unexpected result %q, %v", resp, err)
+	}
+	if len(rec.metas) != 3 {
+		t.Fatalf("expected 3 LLM calls, got %d", len(rec.metas))
+	}
+	if rec.last[1].Content != content {
+		t.Fatalf("first read should be sent in full, got %q", rec.last[1].Content)
+	}
+	if !strings.Contains(rec.last[2].Content, "identical to the result of tool call call_1") {
+		t.Fatalf("second read should be deduplicated, got %q", rec.last[2].Content)
+	}
+	if rec.metas[1].ToolCacheHits != 0 || rec.metas[2].ToolCacheHits != 1 || rec.metas[2].ToolCacheSaved != len(content) {
+		t.Fatalf("unexpected cache meta: %+v / %+v", rec.metas[1], rec.metas[2])
+	}
+}
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/provider/middleware"
+)
+
+// probeState is shared by probe tools to observe concurrent calls.
+type probeState struct {
+	mu      sync.Mutex
+	active  int
+	maxSeen int
+	overlap bool // a write call started while another call was running
+}
+
+// probeTool records how many calls run at the same time.
+type probeTool struct {
+	name  string
+	tier  int
+	delay time.Duration
+	state *probeState
+}
+
+func (p *probeTool) Name() string               { return p.name }
+func (p *probeTool) Tier() int                  { return p.tier }
+func (p *probeTool) Description() string        { return "probe" }
+func (p *probeTool) Parameters() map[string]any { return map[string]any{"type": "object"} }
+func (p *probeTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	s := p.state
+	s.mu.Lock()
+	if s.active > 0 && p.tier > 0 {
+		s.overlap = true
+	}
+	s.active++
+	s.maxSeen = max(s.maxSeen, s.active)
+	s.mu.Unlock()
+	time.Sleep(p.delay)
+	s.mu.Lock()
+	s.active--
+	s.mu.Unlock()
+	return fmt.Sprintf("%s:%v", p.name, params["n"]), nil
+}
+
+// messagesRecorder keeps the full message list of each LLM call.
+type messagesRecorder struct {
+	calls [][]provider.Message
+}
+
+func (m *messagesRecorder) Name() string { return "messages-recorder" }
+func (m *messagesRecorder) ProcessRequest(_ context.Context, req *provider.ChatRequest, _ *middleware.RequestMeta) error {
+	m.calls = append(m.calls, append([]provider.Message(nil), req.Messages...))
+	return nil
+}
+func (m *messagesRecorder) ProcessResponse(context.Context, *provider.ChatRequest, *provider.ChatResponse, *middleware.RequestMeta) error {
+	return nil
+}
+
+func runParallelProbe(t *testing.T, maxConcurrent int) (maxSeen int, overlap bool, results []provider.Message) {
+	t.Helper()
+	dir := t.TempDir()
+	call := func(id, name string, n int) provider.ToolCall {
+		return provider.ToolCall{ID: id, Name: name, Arguments: map[string]any{"n": n}}
+	}
+	mock := &mockProvider{responses: []provider.ChatResponse{
+		{ToolCalls: []provider.ToolCall{
+			call("c1", "probe_read", 1),
+			call("c2", "probe_read", 2),
+			call("c3", "probe_read", 3),
+			call("c4", "probe_write", 4),
+			call("c5", "probe_read", 5),
+		}},
+		{Content: "done"},
+	}}
+	cfg := config.DefaultConfig()
+	cfg.Tools.Parallel.MaxConcurrent = maxConcurrent
+	loop := NewLoop(LoopOptions{
+		Provider:      mock,
+		Workspace:     dir,
+		WorkRepo:      dir,
+		Model:         "mock-model",
+		MaxIterations: 5,
+		Config:        cfg,
+	})
+	state := &probeState{}
+	loop.registry.Register(&probeTool{name: "probe_read", tier: 0, delay: 50 * time.Millisecond, state: state})
+	loop.registry.Register(&probeTool{name: "probe_write", tier: 1, delay: 10 * time.Millisecond, state: state})
+	rec := &messagesRecorder{}
+	loop.chain.Use(rec)
+
+	resp, err := loop.ProcessDirectWithTrace(context.Background(), "probe", "cli:default", "trace-parallel")
+	if err != nil || resp != "done" {
+		t.Fatalf("unexpected result %q, %v", resp, err)
+	}
+	if len(rec.calls) != 2 {
+		t.Fatalf("expected 2 LLM calls, got %d", len(rec.calls))
+	}
+	for _, m := range rec.calls[1] {
+		if m.Role == "tool" {
+			results = append(results, m)
+		}
+	}
+	return state.maxSeen, state.overlap, results
+}
+
+func TestParallelToolCallsKeepOrder(t *testing.T) {
+	maxSeen, overlap, results := runParallelProbe(t, 2)
+	if maxSeen != 2 {
+		t.Fatalf("expected two concurrent reads with cap 2, saw %d", maxSeen)
+	}
+	if overlap {
+		t.Fatal("write call ran concurrently with another call")
+	}
+	want := []string{"probe_read:1", "probe_read:2", "probe_read:3", "probe_write:4", "probe_read:5"}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d tool results, got %d", len(want), len(results))
+	}
+	for i, m := range results {
+		if m.ToolCallID != fmt.Sprintf("c%d", i+1) || m.Content != want[i] {
+			t.Fatalf("result %d out of order: %s=%q", i, m.ToolCallID, m.Content)
+		}
+	}
+}
+
+func TestParallelToolCallsDisabled(t *testing.T) {
+	maxSeen, _, results := runParallelProbe(t, 1)
+	if maxSeen != 1 {
+		t.Fatalf("expected sequential execution, saw %d concurrent calls", maxSeen)
+	}
+	if len(results) != 5 {
+		t.Fatalf("expected 5 tool results, got %d", len(results))
+	}
+}
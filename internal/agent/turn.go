@@ -0,0 +1,206 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+// Turn event types.
+const (
+	TurnEventToolCall         = "tool_call"
+	TurnEventToolResult       = "tool_result"
+	TurnEventApprovalRequired = "approval_required"
+	TurnEventApprovalResolved = "approval_resolved"
+)
+
+// TurnEvent reports progress of a turn to Turn.OnEvent.
+type TurnEvent struct {
+	Type       string         `json:"type"`
+	Tool       string         `json:"tool,omitempty"`
+	Arguments  map[string]any `json:"arguments,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	ApprovalID string         `json:"approval_id,omitempty"`
+	Tier       int            `json:"tier,omitempty"`
+	Prompt     string         `json:"prompt,omitempty"`
+	Approved   bool           `json:"approved,omitempty"`
+	Reason     string         `json:"reason,omitempty"` // approval_resolved: approved, denied or timeout
+}
+
+// Turn observes one direct call. Attach it with WithTurn: the loop adds the
+// usage of every LLM call of the turn and reports tool calls and approval
+// prompts to OnEvent.
+type Turn struct {
+	// AgentID, when set, processes the call as that agents.list entry
+	// (its model, fallbacks and subagent settings).
+	AgentID string
+	// OnEvent may be called concurrently for parallel tool calls.
+	OnEvent func(TurnEvent)
+
+	mu       sync.Mutex
+	usage    provider.Usage
+	llmCalls int
+}
+
+type turnKey struct{}
+
+// WithTurn returns ctx carrying t.
+func WithTurn(ctx context.Context, t *Turn) context.Context {
+	return context.WithValue(ctx, turnKey{}, t)
+}
+
+func turnFrom(ctx context.Context) *Turn {
+	t, _ := ctx.Value(turnKey{}).(*Turn)
+	return t
+}
+
+// Usage returns the token usage summed over the turn's LLM calls.
+func (t *Turn) Usage() provider.Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.usage
+}
+
+// LLMCalls returns the number of LLM calls of the turn.
+func (t *Turn) LLMCalls() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.llmCalls
+}
+
+func (t *Turn) addUsage(u provider.Usage) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.usage.PromptTokens += u.PromptTokens
+	t.usage.CompletionTokens += u.CompletionTokens
+	t.usage.TotalTokens += u.TotalTokens
+	t.llmCalls++
+	t.mu.Unlock()
+}
+
+func (t *Turn) emit(ev TurnEvent) {
+	if t == nil || t.OnEvent == nil {
+		return
+	}
+	t.OnEvent(ev)
+}
+
+// turnAgent is the agent a call runs as: its agents.list entry, model and
+// provider. A nil provider means the loop's chain provider.
+type turnAgent struct {
+	id       string
+	model    string
+	provider provider.LLMProvider
+}
+
+// turnScope is the message one call of the loop works on: where it came
+// from, who sent it and the agent answering it. It travels on the context
+// so concurrent calls (bus messages, API requests, subagents) never see each
+// other's origin.
+type turnScope struct {
+	agent turnAgent
+	// session is the session the scope was opened for; a nested call on
+	// another session gets a scope of its own.
+	session     string
+	taskID      string
+	sender      string
+	channel     string
+	chatID      string
+	threadID    string
+	traceID     string
+	messageType string
+	personID    string
+	// media collects artifacts of the message for the reply.
+	media *turnMedia
+}
+
+type turnScopeKey struct{}
+
+func withTurnScope(ctx context.Context, s *turnScope) context.Context {
+	return context.WithValue(ctx, turnScopeKey{}, s)
+}
+
+func turnScopeFrom(ctx context.Context) *turnScope {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(turnScopeKey{}).(*turnScope)
+	return s
+}
+
+// scope returns the scope carried by ctx, or an empty scope running as the
+// loop's own agent when ctx carries none.
+func (l *Loop) scope(ctx context.Context) *turnScope {
+	if s := turnScopeFrom(ctx); s != nil {
+		return s
+	}
+	return &turnScope{agent: turnAgent{id: l.agentID, model: l.Model()}}
+}
+
+// turnAgentFrom returns the agent of the call carried by ctx, or the loop's
+// own agent and model.
+func (l *Loop) turnAgentFrom(ctx context.Context) turnAgent {
+	if s := turnScopeFrom(ctx); s != nil && s.agent.id != "" {
+		return s.agent
+	}
+	return turnAgent{id: l.agentID, model: l.Model()}
+}
+
+// turnMedia collects the artifacts of one message for its reply.
+type turnMedia struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (m *turnMedia) add(paths []string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range paths {
+		if len(m.paths) >= maxTurnMedia {
+			return
+		}
+		m.paths = append(m.paths, p)
+	}
+}
+
+func (m *turnMedia) take() []string {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	paths := m.paths
+	m.paths = nil
+	return paths
+}
+
+// resolveAgent resolves agentID (an agents.list entry) for one call without
+// touching the loop, so concurrent calls may run as different agents.
+func (l *Loop) resolveAgent(agentID string) (turnAgent, error) {
+	a := turnAgent{id: l.agentID, model: l.Model()}
+	agentID = strings.TrimSpace(agentID)
+	if agentID == "" || agentID == l.agentID || l.cfg == nil {
+		return a, nil
+	}
+	prov, err := provider.Resolve(l.cfg, agentID)
+	if err != nil {
+		return turnAgent{}, fmt.Errorf("agent %s: %w", agentID, err)
+	}
+	a.id, a.provider = agentID, prov
+	if l.cfg.Agents != nil {
+		for _, entry := range l.cfg.Agents.List {
+			if strings.TrimSpace(entry.ID) == agentID && entry.Model != nil && entry.Model.Primary != "" {
+				_, a.model = provider.ParseModelString(entry.Model.Primary)
+			}
+		}
+	}
+	return a, nil
+}
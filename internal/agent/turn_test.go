@@ -0,0 +1,232 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/policy"
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+type turnEvents struct {
+	mu     sync.Mutex
+	events []TurnEvent
+}
+
+func (e *turnEvents) add(ev TurnEvent) {
+	e.mu.Lock()
+	e.events = append(e.events, ev)
+	e.mu.Unlock()
+}
+
+func (e *turnEvents) types() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]string, len(e.events))
+	for i, ev := range e.events {
+		out[i] = ev.Type
+	}
+	return out
+}
+
+func TestTurnAggregatesUsageAndReportsTools(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	mock := &mockProvider{responses: []provider.ChatResponse{
+		{
+			ToolCalls: []provider.ToolCall{{ID: "call_read", Name: "read_file", Arguments: map[string]any{"path": filepath.Join(tmpDir, "notes.txt")}}},
+			Usage:     provider.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120},
+		},
+		{Content: "it says hello", Usage: provider.Usage{PromptTokens: 150, CompletionTokens: 5, TotalTokens: 155}},
+	}}
+	loop := NewLoop(LoopOptions{
+		Bus:           bus.NewMessageBus(),
+		Provider:      mock,
+		Timeline:      newTestTimeline(t),
+		Policy:        policy.NewDefaultEngine(),
+		Workspace:     tmpDir,
+		WorkRepo:      tmpDir,
+		Model:         "mock-model",
+		MaxIterations: 5,
+	})
+
+	var events turnEvents
+	turn := &Turn{OnEvent: events.add}
+	resp, err := loop.ProcessDirect(WithTurn(context.Background(), turn), "What is in notes.txt?", "openai:test")
+	if err != nil || resp != "it says hello" {
+		t.Fatalf("unexpected response %q err=%v", resp, err)
+	}
+	if got := turn.Usage(); got.PromptTokens != 250 || got.CompletionTokens != 25 || got.TotalTokens != 275 || turn.LLMCalls() != 2 {
+		t.Fatalf("expected usage summed over both calls, got %+v calls=%d", got, turn.LLMCalls())
+	}
+	if got := events.types(); len(got) != 2 || got[0] != TurnEventToolCall || got[1] != TurnEventToolResult {
+		t.Fatalf("unexpected events %v", got)
+	}
+}
+
+func TestTurnReportsApprovals(t *testing.T) {
+	tmpDir := t.TempDir()
+	mock := &mockProvider{responses: []provider.ChatResponse{
+		{ToolCalls: []provider.ToolCall{{ID: "call_exec", Name: "exec", Arguments: map[string]any{"command": "echo hi"}}}},
+		{Content: "done"},
+	}}
+	policyEngine := policy.NewDefaultEngine()
+	policyEngine.MaxAutoTier = 1
+	loop := NewLoop(LoopOptions{
+		Bus:           bus.NewMessageBus(),
+		Provider:      mock,
+		Timeline:      newTestTimeline(t),
+		Policy:        policyEngine,
+		Workspace:     tmpDir,
+		WorkRepo:      tmpDir,
+		Model:         "mock-model",
+		MaxIterations: 5,
+	})
+
+	var events turnEvents
+	turn := &Turn{OnEvent: func(ev TurnEvent) {
+		events.add(ev)
+		if ev.Type == TurnEventApprovalRequired {
+			go func() { _ = loop.RespondApproval(ev.ApprovalID, false) }()
+		}
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := loop.ProcessDirect(WithTurn(ctx, turn), "run echo hi", "openai:test"); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	if len(events.events) != 2 {
+		t.Fatalf("expected approval required and resolved, got %+v", events.events)
+	}
+	req, res := events.events[0], events.events[1]
+	if req.Type != TurnEventApprovalRequired || req.ApprovalID == "" || req.Tool != "exec" || req.Prompt == "" {
+		t.Fatalf("unexpected approval event %+v", req)
+	}
+	if res.Type != TurnEventApprovalResolved || res.ApprovalID != req.ApprovalID || res.Approved || res.Reason != "denied" {
+		t.Fatalf("unexpected resolution event %+v", res)
+	}
+}
+
+// modelEcho answers with the requested model once every concurrent call has
+// reached it, so the calls of a test overlap.
+type modelEcho struct {
+	arrived *sync.WaitGroup
+}
+
+func (m *modelEcho) Chat(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	m.arrived.Done()
+	m.arrived.Wait()
+	return &provider.ChatResponse{Content: "main:" + req.Model}, nil
+}
+func (m *modelEcho) Transcribe(context.Context, *provider.AudioRequest) (*provider.AudioResponse, error) {
+	return &provider.AudioResponse{}, nil
+}
+func (m *modelEcho) Speak(context.Context, *provider.TTSRequest) (*provider.TTSResponse, error) {
+	return &provider.TTSResponse{}, nil
+}
+func (m *modelEcho) DefaultModel() string { return "mock-model" }
+
+func TestConcurrentTurnsKeepTheirOwnAgent(t *testing.T) {
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	writer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		arrived.Done()
+		arrived.Wait()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]any{"role": "assistant", "content": "writer:" + body.Model}, "finish_reason": "stop"}},
+		})
+	}))
+	defer writer.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Providers.OpenAI.APIKey = "sk-test"
+	cfg.Providers.OpenAI.APIBase = writer.URL
+	cfg.Agents = &config.AgentsConfig{List: []config.AgentListEntry{
+		{ID: "writer", Model: &config.AgentModelSpec{Primary: "openai/gpt-4o-mini"}},
+	}}
+	loop := NewLoop(LoopOptions{
+		Bus:           bus.NewMessageBus(),
+		Provider:      &modelEcho{arrived: &arrived},
+		Timeline:      newTestTimeline(t),
+		Policy:        policy.NewDefaultEngine(),
+		Workspace:     t.TempDir(),
+		Model:         "mock-model",
+		AgentID:       "main",
+		Config:        cfg,
+		MaxIterations: 2,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	got := make([]string, 2)
+	for i, agentID := range []string{"writer", ""} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := loop.ProcessDirect(WithTurn(ctx, &Turn{AgentID: agentID}), "hello", "openai:"+strconv.Itoa(i))
+			if err != nil {
+				resp = err.Error()
+			}
+			got[i] = resp
+		}()
+	}
+	wg.Wait()
+	if got[0] != "writer:gpt-4o-mini" || got[1] != "main:mock-model" {
+		t.Fatalf("expected each call on its own agent, got writer=%q main=%q", got[0], got[1])
+	}
+	if loop.agentID != "main" || loop.Model() != "mock-model" || loop.chain.Provider != loop.provider {
+		t.Fatalf("loop agent changed: agent=%s model=%s", loop.agentID, loop.Model())
+	}
+}
+
+func TestSetModelDuringTurnResolution(t *testing.T) {
+	loop := NewLoop(LoopOptions{
+		Bus:       bus.NewMessageBus(),
+		Provider:  &modelEcho{},
+		Timeline:  newTestTimeline(t),
+		Policy:    policy.NewDefaultEngine(),
+		Workspace: t.TempDir(),
+		Model:     "mock-model",
+	})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			loop.SetModel("model-" + strconv.Itoa(i))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if _, err := loop.resolveAgent(""); err != nil {
+				t.Errorf("resolve agent: %v", err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	if got := loop.Model(); got != "model-99" {
+		t.Fatalf("model = %q, want model-99", got)
+	}
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/policy"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/voice"
+)
+
+type stubVoiceProvider struct{}
+
+func (stubVoiceProvider) Transcribe(_ context.Context, req *provider.AudioRequest) (*provider.AudioResponse, error) {
+	return &provider.AudioResponse{Text: "what is on my calendar"}, nil
+}
+
+func (stubVoiceProvider) Speak(_ context.Context, req *provider.TTSRequest) (*provider.TTSResponse, error) {
+	return &provider.TTSResponse{AudioData: []byte("OggS" + req.Text), Format: "opus"}, nil
+}
+
+func TestParseVoiceCommand(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{"/voice on", config.VoiceReplyAlways, true},
+		{" /VOICE off ", config.VoiceReplyOff, true},
+		{"/voice auto", config.VoiceReplyInbound, true},
+		{"/voice default", "", true},
+		{"/voice loud", "", false},
+		{"voice on", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseVoiceCommand(tt.input)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseVoiceCommand(%q) = %q, %v", tt.input, got, ok)
+		}
+	}
+}
+
+func TestRunTranscribesVoiceNoteAndRepliesWithAudio(t *testing.T) {
+	tl := newTestTimeline(t)
+	msgBus := bus.NewMessageBus()
+	prov := &capturingProvider{response: "**Two** meetings today."}
+	workspace := t.TempDir()
+	pipeline := voice.NewPipeline(config.VoiceConfig{ReplyMode: config.VoiceReplyInbound, MaxReplyChars: 500},
+		stubVoiceProvider{}, nil, stubVoiceProvider{}, tl, filepath.Join(workspace, "media"))
+	loop := NewLoop(LoopOptions{
+		Bus:           msgBus,
+		Provider:      prov,
+		Timeline:      tl,
+		Policy:        policy.NewDefaultEngine(),
+		Workspace:     workspace,
+		Model:         "capture-model",
+		MaxIterations: 2,
+		Voice:         pipeline,
+	})
+
+	audio := filepath.Join(t.TempDir(), "note.ogg")
+	if err := os.WriteFile(audio, []byte("opus-bytes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var outbound outboundCapture
+	msgBus.Subscribe("whatsapp", outbound.add)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go msgBus.DispatchOutbound(ctx)
+	go loop.Run(ctx)
+
+	chat := "491@s.whatsapp.net"
+	msgBus.PublishInbound(&bus.InboundMessage{Channel: "whatsapp", SenderID: chat, ChatID: chat, Content: "[Audio Message]", Media: []string{audio}})
+	waitForOutbound(t, &outbound, 1)
+
+	reply := outbound.snapshot()[0]
+	if reply.Content != "**Two** meetings today." {
+		t.Fatalf("expected text reply alongside audio, got %q", reply.Content)
+	}
+	if len(reply.MediaURLs) != 1 || filepath.Ext(reply.MediaURLs[0]) != ".ogg" {
+		t.Fatalf("expected one ogg voice reply, got %v", reply.MediaURLs)
+	}
+	last := prov.LastRequest().Messages
+	if got := last[len(last)-1].Content; !strings.Contains(got, "[Audio Transcript]: what is on my calendar") || strings.Contains(got, "[Audio Message]") {
+		t.Fatalf("expected transcript to replace placeholder, got %q", got)
+	}
+
+	// Text messages get text-only replies in inbound mode, until /voice on.
+	msgBus.PublishInbound(&bus.InboundMessage{Channel: "whatsapp", SenderID: chat, ChatID: chat, Content: "and tomorrow?"})
+	waitForOutbound(t, &outbound, 2)
+	if got := outbound.snapshot()[1]; len(got.MediaURLs) != 0 {
+		t.Fatalf("expected text-only reply, got %v", got.MediaURLs)
+	}
+
+	msgBus.PublishInbound(&bus.InboundMessage{Channel: "whatsapp", SenderID: chat, ChatID: chat, Content: "/voice on"})
+	waitForOutbound(t, &outbound, 3)
+	if got := outbound.snapshot()[2].Content; got != "Voice replies for this chat: always." {
+		t.Fatalf("unexpected /voice reply %q", got)
+	}
+	msgBus.PublishInbound(&bus.InboundMessage{Channel: "whatsapp", SenderID: chat, ChatID: chat, Content: "and friday?"})
+	waitForOutbound(t, &outbound, 4)
+	if got := outbound.snapshot()[3]; len(got.MediaURLs) != 1 {
+		t.Fatalf("expected voice reply after /voice on, got %v", got.MediaURLs)
+	}
+}
+
+func waitForOutbound(t *testing.T, c *outboundCapture, n int) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for len(c.snapshot()) < n && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := len(c.snapshot()); got < n {
+		t.Fatalf("expected %d outbound messages, got %d: %+v", n, got, c.snapshot())
+	}
+}
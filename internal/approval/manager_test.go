@@ -3,11 +3,13 @@ package approval
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"io"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/KafClaw/KafClaw/internal/config"
 	"github.com/KafClaw/KafClaw/internal/timeline"
 )
 
@@ -230,6 +232,210 @@ func TestApprovalLifecyclePersistsTimelineStatuses(t *testing.T) {
 	}
 }
 
+func TestRulesFromConfigAndMatching(t *testing.T) {
+	rules := RulesFromConfig(config.ToolApprovalsConfig{Rules: []config.ToolApprovalRuleConfig{
+		{Tool: "exec", MinTier: 2, Approvers: []string{" slack:U1 ", ""}, Quorum: 2, EscalateAfterSeconds: 30, EscalateTo: []string{"slack:U9"}},
+		{Tool: "git_*"},
+	}})
+	if len(rules) != 2 || len(rules[0].Approvers) != 1 || rules[0].Approvers[0] != "slack:U1" {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+	if rules[0].EscalateAfter != 30*time.Second {
+		t.Fatalf("expected escalate after 30s, got %s", rules[0].EscalateAfter)
+	}
+	if !rules[0].Matches("exec", 2) || rules[0].Matches("exec", 1) || rules[0].Matches("write_file", 2) {
+		t.Fatal("exec rule matched wrongly")
+	}
+	if !rules[1].Matches("git_push", 0) || rules[1].Matches("exec", 3) {
+		t.Fatal("glob rule matched wrongly")
+	}
+}
+
+func TestBareApproverIDsMatchNobody(t *testing.T) {
+	m := NewManager(nil)
+	m.SetRules([]Rule{{Tool: "exec", Approvers: []string{"U1"}}})
+	id := m.Create(&ApprovalRequest{Tool: "exec", Tier: 2, Sender: "U2", Channel: "slack"})
+	for _, by := range []Responder{{Channel: "slack", ID: "U1"}, {Channel: "webhook", ID: "U1"}} {
+		if _, err := m.RespondAs(id, by, true); !errors.Is(err, ErrNotApprover) {
+			t.Fatalf("expected bare approver entry not to match %s, got %v", by, err)
+		}
+	}
+	if !matchesApprover("person:alice", Responder{Channel: "slack", ID: "U1"}, "alice") || matchesApprover("person:", Responder{}, "") {
+		t.Fatal("unexpected person approver matching")
+	}
+}
+
+func TestRespondAsEnforcesApproversAndRequester(t *testing.T) {
+	m := NewManager(nil)
+	m.SetRules([]Rule{{Tool: "exec", MinTier: 2, Approvers: []string{"slack:U1", "slack:U2"}}})
+	req := &ApprovalRequest{Tool: "exec", Tier: 2, Sender: "U2", Channel: "slack"}
+	id := m.Create(req)
+	if !req.Routed || req.Quorum != 1 {
+		t.Fatalf("expected routed request with quorum 1, got %+v", req)
+	}
+
+	if _, err := m.RespondAs(id, Responder{Channel: "slack", ID: "U3"}, true); !errors.Is(err, ErrNotApprover) {
+		t.Fatalf("expected ErrNotApprover for unlisted responder, got %v", err)
+	}
+	// U2 is listed but is the requester.
+	if _, err := m.RespondAs(id, Responder{Channel: "slack", ID: "U2"}, true); !errors.Is(err, ErrNotApprover) {
+		t.Fatalf("expected ErrNotApprover for requester, got %v", err)
+	}
+	out, err := m.RespondAs(id, Responder{Channel: "slack", ID: "U1"}, true)
+	if err != nil || !out.Decided || !out.Approved {
+		t.Fatalf("expected approval by U1, got %+v err=%v", out, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if ok, err := m.Wait(ctx, id); err != nil || !ok {
+		t.Fatalf("wait: ok=%v err=%v", ok, err)
+	}
+
+	// Without a matching rule anyone may answer, including the requester.
+	open := m.Create(&ApprovalRequest{Tool: "write_file", Tier: 2, Sender: "U2", Channel: "slack"})
+	if out, err := m.RespondAs(open, Responder{Channel: "slack", ID: "U2"}, false); err != nil || !out.Decided {
+		t.Fatalf("expected unrouted request to accept requester: %+v err=%v", out, err)
+	}
+}
+
+func TestRespondAsQuorumCountsDistinctPersons(t *testing.T) {
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("new timeline: %v", err)
+	}
+	t.Cleanup(func() { _ = tl.Close() })
+	alice, err := tl.CreatePerson("Alice")
+	if err != nil {
+		t.Fatalf("create person: %v", err)
+	}
+	_ = tl.LinkPersonIdentity(alice.PersonID, "slack", "UA")
+	_ = tl.LinkPersonIdentity(alice.PersonID, "whatsapp", "4911")
+
+	m := NewManager(tl)
+	m.SetRules([]Rule{{Tool: "exec", Quorum: 2}})
+	id := m.Create(&ApprovalRequest{Tool: "exec", Tier: 2, Sender: "UR", Channel: "slack", TraceID: "trace-quorum"})
+
+	out, err := m.RespondAs(id, Responder{Channel: "slack", ID: "UA"}, true)
+	if err != nil || out.Decided || out.Approvals != 1 || out.Quorum != 2 {
+		t.Fatalf("expected 1/2 pending, got %+v err=%v", out, err)
+	}
+	// Same person on another channel does not count twice.
+	out, _ = m.RespondAs(id, Responder{Channel: "whatsapp", ID: "4911"}, true)
+	if out.Decided || out.Approvals != 1 {
+		t.Fatalf("expected duplicate person ignored, got %+v", out)
+	}
+	out, _ = m.RespondAs(id, Responder{Channel: "slack", ID: "UB"}, true)
+	if !out.Decided || !out.Approved || out.Approvals != 2 {
+		t.Fatalf("expected quorum reached, got %+v", out)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if ok, err := m.Wait(ctx, id); err != nil || !ok {
+		t.Fatalf("wait: ok=%v err=%v", ok, err)
+	}
+
+	records, err := tl.GetApprovalsByTraceID("trace-quorum")
+	if err != nil || len(records) != 1 {
+		t.Fatalf("records: %+v err=%v", records, err)
+	}
+	if records[0].Status != "approved" || records[0].Quorum != 2 || records[0].DecidedBy != "slack:UA,slack:UB" {
+		t.Fatalf("unexpected record: %+v", records[0])
+	}
+	events, err := tl.ListApprovalEvents(id)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	var actions []string
+	for _, e := range events {
+		actions = append(actions, e.Action)
+	}
+	want := []string{"created", "approve", "approve", "approve", "approved"}
+	if len(actions) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, actions)
+	}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Fatalf("expected events %v, got %v", want, actions)
+		}
+	}
+}
+
+func TestRespondAsDenyDecidesImmediately(t *testing.T) {
+	m := NewManager(nil)
+	m.SetRules([]Rule{{Quorum: 3}})
+	id := m.Create(&ApprovalRequest{Tool: "exec", Tier: 2})
+	out, err := m.RespondAs(id, Responder{Channel: "slack", ID: "U1"}, false)
+	if err != nil || !out.Decided || out.Approved {
+		t.Fatalf("expected immediate denial, got %+v err=%v", out, err)
+	}
+}
+
+func TestEscalationOpensRequestToSecondaryApprovers(t *testing.T) {
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("new timeline: %v", err)
+	}
+	t.Cleanup(func() { _ = tl.Close() })
+
+	m := NewManager(tl)
+	m.SetRules([]Rule{{Approvers: []string{"slack:U1"}, EscalateAfter: 20 * time.Millisecond, EscalateTo: []string{"slack:U9"}}})
+	escalated := make(chan ApprovalRequest, 1)
+	m.SetEscalationHandler(func(req ApprovalRequest) { escalated <- req })
+	id := m.Create(&ApprovalRequest{Tool: "exec", Tier: 2, Sender: "U5", Channel: "slack"})
+
+	if _, err := m.RespondAs(id, Responder{Channel: "slack", ID: "U9"}, true); !errors.Is(err, ErrNotApprover) {
+		t.Fatalf("expected secondary approver rejected before escalation, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result := make(chan bool, 1)
+	go func() {
+		ok, _ := m.Wait(ctx, id)
+		result <- ok
+	}()
+
+	select {
+	case req := <-escalated:
+		if !req.Escalated || len(req.EligibleApprovers()) != 2 {
+			t.Fatalf("unexpected escalated request: %+v", req)
+		}
+	case <-ctx.Done():
+		t.Fatal("expected escalation")
+	}
+	if _, err := m.RespondAs(id, Responder{Channel: "slack", ID: "U9"}, true); err != nil {
+		t.Fatalf("secondary approver after escalation: %v", err)
+	}
+	if ok := <-result; !ok {
+		t.Fatal("expected approval by secondary approver")
+	}
+
+	events, _ := tl.ListApprovalEvents(id)
+	seen := map[string]bool{}
+	for _, e := range events {
+		seen[e.Action] = true
+	}
+	for _, action := range []string{"created", "rejected", "escalated", "approve", "approved"} {
+		if !seen[action] {
+			t.Fatalf("expected %q in audit trail, got %+v", action, events)
+		}
+	}
+}
+
+func TestOperatorRespondOverridesRouting(t *testing.T) {
+	m := NewManager(nil)
+	m.SetRules([]Rule{{Approvers: []string{"slack:U1"}, Quorum: 2}})
+	id := m.Create(&ApprovalRequest{Tool: "exec", Tier: 2})
+	if err := m.Respond(id, true); err != nil {
+		t.Fatalf("operator respond: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if ok, err := m.Wait(ctx, id); err != nil || !ok {
+		t.Fatalf("wait: ok=%v err=%v", ok, err)
+	}
+}
+
 type failingReader struct{}
 
 func (failingReader) Read(_ []byte) (int, error) {
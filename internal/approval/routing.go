@@ -0,0 +1,134 @@
+package approval
+
+import (
+	"errors"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+var (
+	// ErrNotFound is returned when no pending approval has the given ID.
+	ErrNotFound = errors.New("no pending approval")
+	// ErrNotApprover is returned when the responder may not decide the
+	// approval (not listed, or the requester answering their own request).
+	ErrNotApprover = errors.New("not an eligible approver")
+)
+
+// Rule routes approvals of matching tool calls to a set of approvers.
+type Rule struct {
+	// Tool is a glob on the tool name; empty matches any tool.
+	Tool    string
+	MinTier int
+	// Approvers are "<channel>:<senderID>", "person:<id>" or "*". Empty
+	// allows anyone except (by default) the requester. Other entries match
+	// nobody.
+	Approvers      []string
+	Quorum         int
+	AllowRequester bool
+	EscalateAfter  time.Duration
+	EscalateTo     []string
+}
+
+// Matches reports whether the rule applies to a call of tool at tier.
+func (r Rule) Matches(tool string, tier int) bool {
+	if tier < r.MinTier {
+		return false
+	}
+	pattern := strings.TrimSpace(r.Tool)
+	if pattern == "" || pattern == "*" {
+		return true
+	}
+	ok, err := path.Match(pattern, tool)
+	return err == nil && ok
+}
+
+// RulesFromConfig converts tools.approvals.rules into routing rules. Bare
+// sender IDs are rejected with a warning: some channels take the sender ID
+// from the caller, so an ID only names a person together with its channel.
+// They stay in the rule and match nobody, so the rule does not fall open.
+func RulesFromConfig(cfg config.ToolApprovalsConfig) []Rule {
+	rules := make([]Rule, 0, len(cfg.Rules))
+	for _, rc := range cfg.Rules {
+		for _, e := range append(normalizeApprovers(rc.Approvers), normalizeApprovers(rc.EscalateTo)...) {
+			if !qualifiedApprover(e) {
+				slog.Warn("Approval rule approver needs a channel (<channel>:<id> or person:<id>); it matches nobody", "tool", rc.Tool, "approver", e)
+			}
+		}
+		rules = append(rules, Rule{
+			Tool:           strings.TrimSpace(rc.Tool),
+			MinTier:        rc.MinTier,
+			Approvers:      normalizeApprovers(rc.Approvers),
+			Quorum:         rc.Quorum,
+			AllowRequester: rc.AllowRequester,
+			EscalateAfter:  time.Duration(rc.EscalateAfterSeconds) * time.Second,
+			EscalateTo:     normalizeApprovers(rc.EscalateTo),
+		})
+	}
+	return rules
+}
+
+// Responder identifies who answered an approval prompt.
+type Responder struct {
+	Channel string
+	ID      string
+}
+
+// Operator is the local console (TUI, web dashboard). Its decisions
+// override routing rules and quorum.
+var Operator = Responder{Channel: "operator"}
+
+// IsOperator reports whether r answers from the operator console. An ID,
+// when set, names the operator in the audit trail.
+func (r Responder) IsOperator() bool {
+	return r.Channel == Operator.Channel
+}
+
+func (r Responder) String() string {
+	switch {
+	case r.ID == "":
+		return r.Channel
+	case r.Channel == "":
+		return r.ID
+	default:
+		return r.Channel + ":" + r.ID
+	}
+}
+
+// matchesApprover reports whether entry names the responder. personID is
+// the responder's linked person, if any.
+func matchesApprover(entry string, by Responder, personID string) bool {
+	switch {
+	case entry == "*":
+		return true
+	case strings.HasPrefix(entry, "person:"):
+		return personID != "" && strings.TrimPrefix(entry, "person:") == personID
+	case !qualifiedApprover(entry):
+		return false
+	default:
+		return entry == by.String()
+	}
+}
+
+// qualifiedApprover reports whether entry is "*", "person:<id>" or
+// "<channel>:<id>".
+func qualifiedApprover(entry string) bool {
+	if entry == "*" {
+		return true
+	}
+	channel, id, ok := strings.Cut(entry, ":")
+	return ok && channel != "" && id != ""
+}
+
+func normalizeApprovers(entries []string) []string {
+	var out []string
+	for _, e := range entries {
+		if e = strings.TrimSpace(e); e != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
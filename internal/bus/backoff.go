@@ -0,0 +1,17 @@
+package bus
+
+import (
+	"math"
+	"time"
+)
+
+// DeliveryBackoff calculates the next retry time of an outbound delivery
+// using exponential backoff. Returns min(30s * 2^attempts, 5min).
+func DeliveryBackoff(attempts int) time.Time {
+	delay := time.Duration(30*math.Pow(2, float64(attempts))) * time.Second
+	maxDelay := 5 * time.Minute
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return time.Now().Add(delay)
+}
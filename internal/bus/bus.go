@@ -13,6 +13,7 @@ const (
 	MetaKeyIsFromMe       = "is_from_me"
 	MetaKeySessionScope   = "session_scope"
 	MetaKeyChannelAccount = "channel_account"
+	MetaKeyVoiceNote      = "voice_note"
 	MessageTypeInternal   = "internal"
 	MessageTypeExternal   = "external"
 )
@@ -31,6 +32,23 @@ type InboundMessage struct {
 	Media          []string       `json:"media,omitempty"`
 	Metadata       map[string]any `json:"metadata,omitempty"`
 	Timestamp      time.Time      `json:"timestamp"`
+
+	// Deadline, when set, bounds how long the agent may work on the message.
+	Deadline time.Time `json:"-"`
+	// Done, when set, is called once the agent has finished the message,
+	// with the processing error if any. Use Complete to call it.
+	Done func(err error) `json:"-"`
+}
+
+// Complete reports that the message has been handled. Only the first call
+// reaches Done.
+func (m *InboundMessage) Complete(err error) {
+	if m.Done == nil {
+		return
+	}
+	done := m.Done
+	m.Done = nil
+	done(err)
 }
 
 // MessageType returns the message type from metadata, defaulting to external.
@@ -81,3 +81,25 @@ func TestConsumeInboundCanceled(t *testing.T) {
 		t.Fatal("expected cancellation error")
 	}
 }
+
+func TestDeliveryBackoff(t *testing.T) {
+	before := time.Now()
+	next := DeliveryBackoff(0)
+	// 30s * 2^0 = 30s
+	if next.Before(before.Add(29 * time.Second)) {
+		t.Fatal("backoff(0) should be ~30s")
+	}
+
+	next = DeliveryBackoff(3)
+	// 30s * 2^3 = 240s = 4min
+	if next.Before(before.Add(239 * time.Second)) {
+		t.Fatal("backoff(3) should be ~240s")
+	}
+
+	// Large attempt should cap at 5min
+	next = DeliveryBackoff(10)
+	maxDelay := 5*time.Minute + 1*time.Second
+	if next.After(before.Add(maxDelay)) {
+		t.Fatal("backoff should cap at 5min")
+	}
+}
@@ -0,0 +1,98 @@
+package channels
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+)
+
+// The agent loop publishes approval prompts with this action and the
+// approval ID in ActionParams["approval_id"]. Channels render native
+// approve/deny controls for them and turn a click, button tap or reaction
+// back into an "approve:<id>" / "deny:<id>" inbound message.
+const approvalRequestAction = "approval_request"
+
+// approvalReactionHint is appended to prompts answered by reactions.
+const approvalReactionHint = "React with ✅ to approve or ❌ to deny."
+
+// approvalPromptTTL bounds how long a sent prompt accepts reactions.
+const approvalPromptTTL = 24 * time.Hour
+
+// Reaction keys accepted on approval prompts. Variation selectors are
+// stripped before matching.
+var (
+	approveReactions = map[string]bool{"✅": true, "👍": true, "✔": true, "☑": true, "+1": true}
+	denyReactions    = map[string]bool{"❌": true, "👎": true, "🚫": true, "✖": true, "-1": true}
+)
+
+// approvalPromptID returns the approval ID carried by an approval prompt,
+// or "" for other messages.
+func approvalPromptID(msg *bus.OutboundMessage) string {
+	if msg == nil || msg.Action != approvalRequestAction {
+		return ""
+	}
+	id, _ := msg.ActionParams["approval_id"].(string)
+	return strings.TrimSpace(id)
+}
+
+// approvalReactionVerdict maps a reaction key to "approve", "deny" or "".
+func approvalReactionVerdict(key string) string {
+	switch k := strings.TrimSpace(strings.ReplaceAll(key, "\ufe0f", "")); {
+	case approveReactions[k]:
+		return "approve"
+	case denyReactions[k]:
+		return "deny"
+	default:
+		return ""
+	}
+}
+
+// isApprovalReply reports whether text is an "approve:<id>" or "deny:<id>"
+// reply, e.g. the value of an approval button.
+func isApprovalReply(text string) bool {
+	verdict, id, ok := strings.Cut(strings.TrimSpace(text), ":")
+	return ok && (verdict == "approve" || verdict == "deny") && strings.TrimSpace(id) != ""
+}
+
+// approvalPromptIndex remembers which sent message carries which approval
+// so reactions on it can be routed back. Entries stay valid until they
+// expire: with a quorum, several approvers react to the same prompt.
+type approvalPromptIndex struct {
+	mu      sync.Mutex
+	entries map[string]approvalPromptEntry
+}
+
+type approvalPromptEntry struct {
+	approvalID string
+	sentAt     time.Time
+}
+
+func (x *approvalPromptIndex) remember(messageID, approvalID string) {
+	if messageID == "" || approvalID == "" {
+		return
+	}
+	now := time.Now()
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.entries == nil {
+		x.entries = make(map[string]approvalPromptEntry)
+	}
+	for k, e := range x.entries {
+		if now.Sub(e.sentAt) > approvalPromptTTL {
+			delete(x.entries, k)
+		}
+	}
+	x.entries[messageID] = approvalPromptEntry{approvalID: approvalID, sentAt: now}
+}
+
+func (x *approvalPromptIndex) lookup(messageID string) (string, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	e, ok := x.entries[messageID]
+	if !ok || time.Since(e.sentAt) > approvalPromptTTL {
+		return "", false
+	}
+	return e.approvalID, true
+}
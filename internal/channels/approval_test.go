@@ -0,0 +1,91 @@
+package channels
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestApprovalPromptHelpers(t *testing.T) {
+	if id := approvalPromptID(&bus.OutboundMessage{Action: "approval_request", ActionParams: map[string]any{"approval_id": " ap-1 "}}); id != "ap-1" {
+		t.Fatalf("expected approval id, got %q", id)
+	}
+	if id := approvalPromptID(&bus.OutboundMessage{Action: "react", ActionParams: map[string]any{"approval_id": "ap-1"}}); id != "" {
+		t.Fatalf("expected no approval id for other actions, got %q", id)
+	}
+	for key, want := range map[string]string{"✅": "approve", "👍️": "approve", "❌": "deny", "-1": "deny", "🎉": ""} {
+		if got := approvalReactionVerdict(key); got != want {
+			t.Fatalf("reaction %q: got %q want %q", key, got, want)
+		}
+	}
+	for text, want := range map[string]bool{"approve:ap-1": true, " deny:ap-1 ": true, "approve:": false, "maybe:ap-1": false} {
+		if got := isApprovalReply(text); got != want {
+			t.Fatalf("isApprovalReply(%q) = %v", text, got)
+		}
+	}
+}
+
+func TestApprovalPromptIndexKeepsEntriesForQuorum(t *testing.T) {
+	var idx approvalPromptIndex
+	idx.remember("msg-1", "ap-1")
+	idx.remember("", "ap-2")
+	for i := 0; i < 2; i++ {
+		if id, ok := idx.lookup("msg-1"); !ok || id != "ap-1" {
+			t.Fatalf("lookup %d: id=%q ok=%v", i, id, ok)
+		}
+	}
+	idx.entries["old"] = approvalPromptEntry{approvalID: "ap-old", sentAt: time.Now().Add(-2 * approvalPromptTTL)}
+	if _, ok := idx.lookup("old"); ok {
+		t.Fatal("expected expired prompt to be ignored")
+	}
+	idx.remember("msg-2", "ap-2")
+	if _, ok := idx.entries["old"]; ok {
+		t.Fatal("expected expired prompt to be pruned")
+	}
+}
+
+func TestWhatsAppApprovalPromptAndReplies(t *testing.T) {
+	if got := whatsappOutboundMessage("hello", "", true).GetConversation(); got != "hello" {
+		t.Fatalf("expected plain conversation, got %q", got)
+	}
+	if got := whatsappOutboundMessage("approve?", "ap-1", false).GetConversation(); got != "approve?\n"+approvalReactionHint {
+		t.Fatalf("expected reaction hint, got %q", got)
+	}
+	buttons := whatsappOutboundMessage("approve?", "ap-1", true).GetButtonsMessage()
+	if buttons.GetContentText() != "approve?" || len(buttons.GetButtons()) != 2 ||
+		buttons.GetButtons()[0].GetButtonID() != "approve:ap-1" || buttons.GetButtons()[1].GetButtonID() != "deny:ap-1" {
+		t.Fatalf("unexpected buttons message: %v", buttons)
+	}
+
+	c := &WhatsAppChannel{}
+	c.approvals.remember("PROMPT1", "ap-1")
+	tap := &waE2E.Message{ButtonsResponseMessage: &waE2E.ButtonsResponseMessage{
+		Response:         &waE2E.ButtonsResponseMessage_SelectedDisplayText{SelectedDisplayText: "Deny"},
+		SelectedButtonID: proto.String("deny:ap-1"),
+	}}
+	if reply, ok := c.approvalReply(tap); !ok || reply != "deny:ap-1" {
+		t.Fatalf("button tap: reply=%q ok=%v", reply, ok)
+	}
+	reaction := func(target, key string) *waE2E.Message {
+		return &waE2E.Message{ReactionMessage: &waE2E.ReactionMessage{
+			Key:  &waCommon.MessageKey{ID: proto.String(target)},
+			Text: proto.String(key),
+		}}
+	}
+	if reply, ok := c.approvalReply(reaction("PROMPT1", "👍")); !ok || reply != "approve:ap-1" {
+		t.Fatalf("reaction: reply=%q ok=%v", reply, ok)
+	}
+	if _, ok := c.approvalReply(reaction("OTHER", "👍")); ok {
+		t.Fatal("expected reaction on untracked message to be ignored")
+	}
+	if _, ok := c.approvalReply(reaction("PROMPT1", "🎉")); ok {
+		t.Fatal("expected unrelated reaction to be ignored")
+	}
+	if _, ok := c.approvalReply(&waE2E.Message{Conversation: proto.String("approve:ap-1")}); ok {
+		t.Fatal("expected plain text to be left to the loop")
+	}
+}
@@ -0,0 +1,176 @@
+package channels
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+const identityLinkPendingKey = "identity_link_pending_v1"
+
+const (
+	defaultIdentityLinkTTL  = 15 * time.Minute
+	maxPendingIdentityLinks = 20
+)
+
+// PendingIdentityLink is a link code issued for a person. Sending
+// "link <code>" on Channel links the sender to the person.
+type PendingIdentityLink struct {
+	PersonID  string    `json:"person_id"`
+	Channel   string    `json:"channel"`
+	Code      string    `json:"code"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type personStore interface {
+	GetPerson(personID string) (*timeline.Person, error)
+	LinkPersonIdentity(personID, channel, senderID string) error
+}
+
+// CreateIdentityLinkCode issues a one-time code that links the channel
+// identity which sends it back to the given person.
+func (s *PairingService) CreateIdentityLinkCode(personID, channel string, ttl time.Duration) (*PendingIdentityLink, error) {
+	if s.persons == nil {
+		return nil, fmt.Errorf("person registry unavailable")
+	}
+	personID = strings.TrimSpace(personID)
+	channel = normalizeChannel(channel)
+	if personID == "" || channel == "" {
+		return nil, fmt.Errorf("person_id and channel are required")
+	}
+	if _, err := s.persons.GetPerson(personID); err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		ttl = defaultIdentityLinkTTL
+	}
+
+	items, err := s.loadIdentityLinks()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	items = filterActiveIdentityLinks(items, now)
+	for _, it := range items {
+		if it.PersonID == personID && it.Channel == channel {
+			return &it, s.saveIdentityLinks(items)
+		}
+	}
+
+	code, err := randomPairingCode()
+	if err != nil {
+		return nil, err
+	}
+	entry := PendingIdentityLink{
+		PersonID:  personID,
+		Channel:   channel,
+		Code:      code,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	items = append(items, entry)
+	if len(items) > maxPendingIdentityLinks {
+		slices.SortFunc(items, func(a, b PendingIdentityLink) int {
+			return a.CreatedAt.Compare(b.CreatedAt)
+		})
+		items = items[len(items)-maxPendingIdentityLinks:]
+	}
+	if err := s.saveIdentityLinks(items); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListIdentityLinkCodes returns the unexpired link codes.
+func (s *PairingService) ListIdentityLinkCodes() ([]PendingIdentityLink, error) {
+	items, err := s.loadIdentityLinks()
+	if err != nil {
+		return nil, err
+	}
+	items = filterActiveIdentityLinks(items, time.Now().UTC())
+	if err := s.saveIdentityLinks(items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// VerifyIdentityLink consumes a link code received from senderID on channel
+// and links that identity to the code's person. Codes are single-use and
+// only valid on the channel they were issued for.
+func (s *PairingService) VerifyIdentityLink(channel, senderID, code string) (*timeline.Person, error) {
+	if s.persons == nil {
+		return nil, fmt.Errorf("person registry unavailable")
+	}
+	channel = normalizeChannel(channel)
+	senderID = strings.TrimSpace(senderID)
+	code = normalizeCode(code)
+	if channel == "" || senderID == "" || code == "" {
+		return nil, fmt.Errorf("channel, sender_id and code are required")
+	}
+	items, err := s.loadIdentityLinks()
+	if err != nil {
+		return nil, err
+	}
+	items = filterActiveIdentityLinks(items, time.Now().UTC())
+	remaining := make([]PendingIdentityLink, 0, len(items))
+	var hit *PendingIdentityLink
+	for _, it := range items {
+		if hit == nil && it.Channel == channel && it.Code == code {
+			tmp := it
+			hit = &tmp
+			continue
+		}
+		remaining = append(remaining, it)
+	}
+	if hit == nil {
+		return nil, fmt.Errorf("link code not found or expired for channel %q", channel)
+	}
+	if err := s.persons.LinkPersonIdentity(hit.PersonID, channel, senderID); err != nil {
+		return nil, err
+	}
+	if err := s.saveIdentityLinks(remaining); err != nil {
+		return nil, err
+	}
+	return s.persons.GetPerson(hit.PersonID)
+}
+
+func (s *PairingService) loadIdentityLinks() ([]PendingIdentityLink, error) {
+	raw, err := s.store.GetSetting(identityLinkPendingKey)
+	if err != nil || strings.TrimSpace(raw) == "" {
+		return []PendingIdentityLink{}, nil
+	}
+	var items []PendingIdentityLink
+	if err := json.Unmarshal([]byte(raw), &items); err != nil {
+		return nil, fmt.Errorf("parse pending identity links: %w", err)
+	}
+	return items, nil
+}
+
+func (s *PairingService) saveIdentityLinks(items []PendingIdentityLink) error {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return s.store.SetSetting(identityLinkPendingKey, string(data))
+}
+
+func filterActiveIdentityLinks(items []PendingIdentityLink, now time.Time) []PendingIdentityLink {
+	out := make([]PendingIdentityLink, 0, len(items))
+	for _, it := range items {
+		if !it.ExpiresAt.IsZero() && !it.ExpiresAt.After(now) {
+			continue
+		}
+		it.Channel = normalizeChannel(it.Channel)
+		it.Code = normalizeCode(it.Code)
+		it.PersonID = strings.TrimSpace(it.PersonID)
+		if it.Channel != "" && it.Code != "" && it.PersonID != "" {
+			out = append(out, it)
+		}
+	}
+	return out
+}
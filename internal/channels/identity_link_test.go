@@ -0,0 +1,70 @@
+package channels
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestIdentityLinkCodeVerifiesOnIssuedChannelOnce(t *testing.T) {
+	timeSvc, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("new timeline: %v", err)
+	}
+	defer timeSvc.Close()
+
+	person, err := timeSvc.CreatePerson("Carol")
+	if err != nil {
+		t.Fatalf("create person: %v", err)
+	}
+	svc := NewPairingService(timeSvc)
+	if _, err := svc.CreateIdentityLinkCode("p-unknown", "slack", 0); err == nil {
+		t.Fatal("expected unknown person to be rejected")
+	}
+	link, err := svc.CreateIdentityLinkCode(person.PersonID, "teams", time.Minute)
+	if err != nil {
+		t.Fatalf("create link code: %v", err)
+	}
+	if link.Channel != "msteams" || len(link.Code) != pairingCodeLength {
+		t.Fatalf("unexpected link %+v", link)
+	}
+	again, err := svc.CreateIdentityLinkCode(person.PersonID, "msteams", time.Minute)
+	if err != nil || again.Code != link.Code {
+		t.Fatalf("expected pending code to be reused, got %+v %v", again, err)
+	}
+
+	if _, err := svc.VerifyIdentityLink("slack", "U1", link.Code); err == nil {
+		t.Fatal("code must only verify on the channel it was issued for")
+	}
+	got, err := svc.VerifyIdentityLink("msteams", "aad-42", " "+link.Code+" ")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if got.PersonID != person.PersonID || len(got.Identities) != 1 || got.Identities[0].SenderID != "aad-42" {
+		t.Fatalf("unexpected linked person %+v", got)
+	}
+	if _, err := svc.VerifyIdentityLink("msteams", "aad-43", link.Code); err == nil {
+		t.Fatal("link codes must be single-use")
+	}
+	pending, err := svc.ListIdentityLinkCodes()
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("expected no pending codes, got %+v %v", pending, err)
+	}
+}
+
+func TestIdentityLinkCodeExpires(t *testing.T) {
+	store := &mockPairingStore{raw: `[{"person_id":"p-1","channel":"slack","code":"ABCDEFGH","expires_at":"2000-01-01T00:00:00Z"}]`}
+	svc := &PairingService{store: store, persons: nil}
+	items, err := svc.ListIdentityLinkCodes()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(items) != 0 {
+		t.Fatalf("expected expired code to be dropped, got %+v", items)
+	}
+	if _, err := svc.VerifyIdentityLink("slack", "U1", "ABCDEFGH"); err == nil {
+		t.Fatal("expected verify without registry to fail")
+	}
+}
@@ -0,0 +1,605 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+const matrixSyncTokenKey = "matrix_sync_next_batch"
+
+// MatrixChannel talks to a Matrix homeserver over the Client-Server API, with
+// Olm/Megolm end-to-end encryption for encrypted rooms.
+type MatrixChannel struct {
+	BaseChannel
+	config   config.MatrixConfig
+	timeline *timeline.TimelineService
+	client   *matrixClient
+	crypto   *matrixCrypto
+
+	mu          sync.Mutex
+	userID      string
+	deviceID    string
+	encrypted   map[string]bool
+	memberCount map[string]int
+	approvals   approvalPromptIndex // prompt event ID -> approval ID
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+func NewMatrixChannel(cfg config.MatrixConfig, messageBus *bus.MessageBus, tl *timeline.TimelineService) *MatrixChannel {
+	return &MatrixChannel{
+		BaseChannel: BaseChannel{Bus: messageBus},
+		config:      cfg,
+		timeline:    tl,
+		client:      newMatrixClient(cfg.Homeserver, cfg.AccessToken),
+		userID:      strings.TrimSpace(cfg.UserID),
+		deviceID:    strings.TrimSpace(cfg.DeviceID),
+		encrypted:   map[string]bool{},
+		memberCount: map[string]int{},
+	}
+}
+
+func (c *MatrixChannel) Name() string { return "matrix" }
+
+func (c *MatrixChannel) Start(ctx context.Context) error {
+	if !c.config.Enabled {
+		return nil
+	}
+	if strings.TrimSpace(c.config.Homeserver) == "" || strings.TrimSpace(c.config.AccessToken) == "" {
+		return fmt.Errorf("matrix: homeserver and accessToken are required")
+	}
+	who, err := c.client.whoAmI(ctx)
+	if err != nil {
+		return fmt.Errorf("matrix whoami: %w", err)
+	}
+	c.mu.Lock()
+	c.userID = who.UserID
+	if strings.TrimSpace(who.DeviceID) != "" {
+		c.deviceID = who.DeviceID
+	}
+	c.mu.Unlock()
+
+	if c.config.Encryption {
+		if c.deviceID == "" {
+			return fmt.Errorf("matrix: encryption requires a device ID (set deviceId or use a device-bound access token)")
+		}
+		path := strings.TrimSpace(c.config.CryptoStorePath)
+		if path == "" {
+			path, err = defaultMatrixCryptoStorePath(c.userID, c.deviceID)
+			if err != nil {
+				return err
+			}
+		}
+		c.crypto, err = openMatrixCrypto(path, c.client, c.userID, c.deviceID)
+		if err != nil {
+			return err
+		}
+		if err := c.crypto.ensureKeys(ctx, -1); err != nil {
+			return fmt.Errorf("matrix key upload: %w", err)
+		}
+	}
+
+	c.Bus.Subscribe(c.Name(), func(msg *bus.OutboundMessage) {
+		if err := c.Send(ctx, msg); err != nil {
+			if c.timeline != nil && strings.TrimSpace(msg.TaskID) != "" {
+				reason, cls := classifyDeliveryError(err)
+				if cls == deliveryTransient {
+					next := time.Now().Add(30 * time.Second)
+					_ = c.timeline.UpdateTaskDeliveryWithReason(msg.TaskID, timeline.DeliveryPending, &next, reason)
+				} else {
+					_ = c.timeline.UpdateTaskDeliveryWithReason(msg.TaskID, timeline.DeliveryFailed, nil, reason)
+				}
+			}
+			return
+		}
+		if c.timeline != nil && strings.TrimSpace(msg.TaskID) != "" {
+			_ = c.timeline.UpdateTaskDeliveryWithReason(msg.TaskID, timeline.DeliverySent, nil, "")
+		}
+	})
+
+	syncCtx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	go c.syncLoop(syncCtx)
+	return nil
+}
+
+func (c *MatrixChannel) Stop() error {
+	if c.cancel != nil {
+		c.cancel()
+		<-c.done
+		c.cancel = nil
+	}
+	return nil
+}
+
+func (c *MatrixChannel) Send(ctx context.Context, msg *bus.OutboundMessage) error {
+	_, roomID := parseAccountChat(strings.TrimSpace(msg.ChatID))
+	if roomID == "" {
+		return nil
+	}
+	body := msg.Content
+	for _, u := range msg.MediaURLs {
+		if strings.TrimSpace(u) != "" {
+			body += "\n" + strings.TrimSpace(u)
+		}
+	}
+	approvalID := approvalPromptID(msg)
+	if approvalID != "" {
+		body += "\n" + approvalReactionHint
+	}
+	content := map[string]any{"msgtype": "m.text", "body": body}
+	if thread := strings.TrimSpace(msg.ThreadID); thread != "" {
+		content["m.relates_to"] = map[string]any{
+			"rel_type":        "m.thread",
+			"event_id":        thread,
+			"is_falling_back": true,
+			"m.in_reply_to":   map[string]any{"event_id": thread},
+		}
+	}
+	eventType := "m.room.message"
+	encrypted, err := c.roomEncrypted(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	var payload any = content
+	if encrypted {
+		if c.crypto == nil {
+			return fmt.Errorf("matrix room %s is encrypted but encryption is disabled", roomID)
+		}
+		sealed, err := c.crypto.encryptRoomEvent(ctx, roomID, eventType, content)
+		if err != nil {
+			return err
+		}
+		eventType, payload = "m.room.encrypted", sealed
+	}
+	eventID, err := c.client.sendEvent(ctx, roomID, eventType, payload)
+	if err != nil {
+		return err
+	}
+	c.approvals.remember(eventID, approvalID)
+	return nil
+}
+
+// HandleInbound applies access policy to one Matrix message and publishes it
+// to the bus. chatID is the room ID; threadID is the thread root event ID.
+func (c *MatrixChannel) HandleInbound(senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool) error {
+	return c.handleInbound(senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, nil)
+}
+
+// handleInbound is HandleInbound with an optional media fetch, run only once
+// the sender passed the access check.
+func (c *MatrixChannel) handleInbound(senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool, media func() []string) error {
+	decision := EvaluateAccess(AccessContext{
+		SenderID:     senderID,
+		IsGroup:      isGroup,
+		WasMentioned: wasMentioned,
+	}, c.accessConfig(isGroup))
+	if decision.RequiresPairing {
+		if c.timeline == nil {
+			return nil
+		}
+		svc := NewPairingService(c.timeline)
+		pending, err := svc.CreateOrGetPending(c.Name(), senderID, 0)
+		if err != nil {
+			return err
+		}
+		c.Bus.PublishOutbound(&bus.OutboundMessage{
+			Channel: c.Name(),
+			ChatID:  chatID,
+			Content: BuildPairingReply(c.Name(), fmt.Sprintf("Matrix user: %s", strings.TrimSpace(senderID)), pending.Code),
+		})
+		return nil
+	}
+	if !decision.Allowed {
+		return nil
+	}
+	var paths []string
+	if media != nil {
+		paths = media()
+	}
+	c.Bus.PublishInbound(&bus.InboundMessage{
+		Channel:        c.Name(),
+		SenderID:       strings.TrimSpace(senderID),
+		ChatID:         strings.TrimSpace(chatID),
+		ThreadID:       strings.TrimSpace(threadID),
+		MessageID:      strings.TrimSpace(messageID),
+		IdempotencyKey: matrixIdempotencyKey(messageID),
+		Content:        text,
+		Media:          paths,
+		Metadata: map[string]any{
+			bus.MetaKeyMessageType:    bus.MessageTypeExternal,
+			bus.MetaKeySessionScope:   buildSessionScope(c.Name(), "default", chatID, threadID, senderID, c.config.SessionScope),
+			bus.MetaKeyChannelAccount: "default",
+		},
+	})
+	return nil
+}
+
+// HandleReaction turns a reaction on a pending approval prompt into an
+// approve:/deny: reply for the agent loop. Other reactions are ignored.
+func (c *MatrixChannel) HandleReaction(senderID, chatID, targetEventID, key string, isGroup bool) bool {
+	approvalID, ok := c.approvals.lookup(targetEventID)
+	if !ok {
+		return false
+	}
+	verdict := approvalReactionVerdict(key)
+	if verdict == "" {
+		return false
+	}
+	// Approvers must be allowed senders; mentions are not required here.
+	acc := c.accessConfig(isGroup)
+	acc.RequireMention = false
+	if !EvaluateAccess(AccessContext{SenderID: senderID, IsGroup: isGroup}, acc).Allowed {
+		return false
+	}
+	c.Bus.PublishInbound(&bus.InboundMessage{
+		Channel:  c.Name(),
+		SenderID: strings.TrimSpace(senderID),
+		ChatID:   strings.TrimSpace(chatID),
+		Content:  verdict + ":" + approvalID,
+		Metadata: map[string]any{
+			bus.MetaKeyMessageType:    bus.MessageTypeExternal,
+			bus.MetaKeySessionScope:   buildSessionScope(c.Name(), "default", chatID, "", senderID, c.config.SessionScope),
+			bus.MetaKeyChannelAccount: "default",
+		},
+	})
+	return true
+}
+
+func (c *MatrixChannel) accessConfig(isGroup bool) AccessConfig {
+	return AccessConfig{
+		Channel:        c.Name(),
+		AllowFrom:      c.config.AllowFrom,
+		GroupAllowFrom: c.config.GroupAllowFrom,
+		DmPolicy:       c.config.DmPolicy,
+		GroupPolicy:    c.config.GroupPolicy,
+		RequireMention: c.config.RequireMention && isGroup,
+	}
+}
+
+func matrixIdempotencyKey(eventID string) string {
+	if id := strings.TrimSpace(eventID); id != "" {
+		return "matrix:" + id
+	}
+	return ""
+}
+
+// evaluateMatrixInvite decides whether to join a room we were invited to.
+// Direct invites follow the DM policy (pairing still needs a joined room to
+// reply in); room invites follow the group policy.
+func evaluateMatrixInvite(cfg config.MatrixConfig, inviter, roomID string, isDirect bool) (bool, string) {
+	if isDirect {
+		switch cfg.DmPolicy {
+		case config.DmPolicyDisabled:
+			return false, "dm_policy_disabled"
+		case config.DmPolicyAllowlist:
+			if isAllowedSender("matrix", cfg.AllowFrom, inviter) {
+				return true, "dm_allowlist_match"
+			}
+			return false, "dm_allowlist_block"
+		case "", config.DmPolicyPairing, config.DmPolicyOpen:
+			return true, "dm_invite_accepted"
+		default:
+			return false, "invalid_dm_policy"
+		}
+	}
+	switch cfg.GroupPolicy {
+	case config.GroupPolicyDisabled:
+		return false, "group_policy_disabled"
+	case config.GroupPolicyOpen:
+		return true, "group_policy_open"
+	case "", config.GroupPolicyAllowlist:
+		allow := cfg.GroupAllowFrom
+		if len(allow) == 0 {
+			allow = cfg.AllowFrom
+		}
+		if isAllowedSender("matrix", allow, inviter) || isAllowedSender("matrix", allow, roomID) {
+			return true, "group_allowlist_match"
+		}
+		return false, "group_allowlist_block"
+	default:
+		return false, "invalid_group_policy"
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Sync loop
+// ---------------------------------------------------------------------------
+
+func (c *MatrixChannel) syncLoop(ctx context.Context) {
+	defer close(c.done)
+	since := c.loadSyncToken()
+	timeout := time.Duration(c.config.SyncTimeoutSec) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	backoff := time.Second
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		// The first sync of a fresh device only catches up on state; history
+		// from before the bot joined is not replayed to the agent.
+		initial := since == ""
+		wait := timeout
+		if initial {
+			wait = 0
+		}
+		resp, err := c.client.sync(ctx, since, wait)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("Matrix sync failed", "error", err, "retry_in", backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, time.Minute)
+			continue
+		}
+		backoff = time.Second
+		c.processSync(ctx, resp, initial)
+		since = resp.NextBatch
+		c.saveSyncToken(since)
+	}
+}
+
+func (c *MatrixChannel) loadSyncToken() string {
+	if c.timeline == nil {
+		return ""
+	}
+	v, _ := c.timeline.GetSetting(matrixSyncTokenKey)
+	return strings.TrimSpace(v)
+}
+
+func (c *MatrixChannel) saveSyncToken(token string) {
+	if c.timeline == nil || token == "" {
+		return
+	}
+	_ = c.timeline.SetSetting(matrixSyncTokenKey, token)
+}
+
+func (c *MatrixChannel) processSync(ctx context.Context, resp *matrixSyncResponse, initial bool) {
+	if c.crypto != nil {
+		if len(resp.DeviceLists.Changed) > 0 {
+			c.crypto.markUsersStale(resp.DeviceLists.Changed)
+		}
+		for _, ev := range resp.ToDevice.Events {
+			if err := c.crypto.handleToDevice(ev); err != nil {
+				slog.Warn("Matrix to-device decrypt failed", "sender", ev.Sender, "error", err)
+			}
+		}
+		if count, ok := resp.DeviceOneTimeKeysCount["signed_curve25519"]; ok {
+			if err := c.crypto.ensureKeys(ctx, count); err != nil {
+				slog.Warn("Matrix one-time key upload failed", "error", err)
+			}
+		}
+	}
+	for roomID, inv := range resp.Rooms.Invite {
+		c.handleInvite(ctx, roomID, inv)
+	}
+	for roomID, room := range resp.Rooms.Join {
+		if n := room.Summary.JoinedMemberCount; n != nil {
+			c.mu.Lock()
+			c.memberCount[roomID] = *n
+			c.mu.Unlock()
+		}
+		for _, ev := range room.State.Events {
+			c.trackRoomState(roomID, ev)
+		}
+		for _, ev := range room.Timeline.Events {
+			c.trackRoomState(roomID, ev)
+			if initial || ev.StateKey != nil {
+				continue
+			}
+			c.handleRoomEvent(ctx, roomID, ev)
+		}
+	}
+}
+
+func (c *MatrixChannel) trackRoomState(roomID string, ev matrixEvent) {
+	if ev.StateKey == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch ev.Type {
+	case "m.room.encryption":
+		c.encrypted[roomID] = true
+	case "m.room.member":
+		// Force a recount on the next message.
+		delete(c.memberCount, roomID)
+	}
+}
+
+func (c *MatrixChannel) handleInvite(ctx context.Context, roomID string, inv matrixInvitedRoom) {
+	inviter, isDirect := "", false
+	for _, ev := range inv.InviteState.Events {
+		if ev.Type == "m.room.member" && ev.StateKey != nil && *ev.StateKey == c.userID {
+			if m, _ := ev.Content["membership"].(string); m == "invite" {
+				inviter = ev.Sender
+				isDirect, _ = ev.Content["is_direct"].(bool)
+			}
+		}
+	}
+	accept, reason := evaluateMatrixInvite(c.config, inviter, roomID, isDirect)
+	var err error
+	if accept {
+		err = c.client.joinRoom(ctx, roomID)
+	} else {
+		err = c.client.leaveRoom(ctx, roomID)
+	}
+	slog.Info("Matrix invite handled", "room", roomID, "inviter", inviter, "direct", isDirect, "joined", accept, "reason", reason, "error", err)
+}
+
+func (c *MatrixChannel) handleRoomEvent(ctx context.Context, roomID string, ev matrixEvent) {
+	if ev.Sender == "" || ev.Sender == c.userID {
+		return
+	}
+	if ev.Type == "m.room.encrypted" {
+		if c.crypto == nil {
+			return
+		}
+		dec, err := c.crypto.decryptRoomEvent(roomID, ev)
+		if err != nil {
+			slog.Warn("Matrix room decrypt failed", "room", roomID, "event", ev.EventID, "error", err)
+			return
+		}
+		ev = dec
+	}
+	switch ev.Type {
+	case "m.room.message":
+		c.handleMessageEvent(ctx, roomID, ev)
+	case "m.reaction":
+		rel, _ := ev.Content["m.relates_to"].(map[string]any)
+		if relType, _ := rel["rel_type"].(string); relType != "m.annotation" {
+			return
+		}
+		target, _ := rel["event_id"].(string)
+		key, _ := rel["key"].(string)
+		c.HandleReaction(ev.Sender, roomID, target, key, c.isGroupRoom(ctx, roomID))
+	}
+}
+
+func (c *MatrixChannel) handleMessageEvent(ctx context.Context, roomID string, ev matrixEvent) {
+	msgType, _ := ev.Content["msgtype"].(string)
+	if msgType != "m.text" && msgType != "m.emote" && msgType != "m.audio" {
+		return
+	}
+	body, _ := ev.Content["body"].(string)
+	var media func() []string
+	if msgType == "m.audio" {
+		name := body
+		content := ev.Content
+		media = func() []string {
+			audio, err := c.downloadAudio(ctx, name, content)
+			if err != nil {
+				slog.Warn("Matrix audio download failed", "room", roomID, "event", ev.EventID, "error", err)
+				return nil
+			}
+			return saveInboundAudio(c.Name(), ev.EventID, []InboundAudio{audio})
+		}
+		body = "[Audio Message]"
+	}
+	threadID := ""
+	rel, _ := ev.Content["m.relates_to"].(map[string]any)
+	if relType, _ := rel["rel_type"].(string); relType == "m.thread" {
+		threadID, _ = rel["event_id"].(string)
+	}
+	if _, isReply := rel["m.in_reply_to"]; isReply {
+		body = stripMatrixReplyFallback(body)
+	}
+	if strings.TrimSpace(body) == "" {
+		return
+	}
+	if err := c.handleInbound(ev.Sender, roomID, threadID, ev.EventID, body, c.isGroupRoom(ctx, roomID), c.wasMentioned(ev.Content, body), media); err != nil {
+		slog.Warn("Matrix inbound failed", "room", roomID, "event", ev.EventID, "error", err)
+	}
+}
+
+// downloadAudio fetches an m.audio attachment. Attachments in encrypted
+// rooms carry an EncryptedFile ("file") and are decrypted locally.
+func (c *MatrixChannel) downloadAudio(ctx context.Context, body string, content map[string]any) (InboundAudio, error) {
+	info, _ := content["info"].(map[string]any)
+	mimetype, _ := info["mimetype"].(string)
+	name := inboundAudioFilename(body, mimetype)
+	if file, ok := content["file"].(map[string]any); ok {
+		mxc, _ := file["url"].(string)
+		data, err := c.client.downloadMedia(ctx, mxc, maxInboundAudioBytes)
+		if err != nil {
+			return InboundAudio{}, err
+		}
+		plain, err := decryptMatrixAttachment(file, data)
+		if err != nil {
+			return InboundAudio{}, err
+		}
+		return InboundAudio{Filename: name, Data: plain}, nil
+	}
+	mxc, _ := content["url"].(string)
+	data, err := c.client.downloadMedia(ctx, mxc, maxInboundAudioBytes)
+	if err != nil {
+		return InboundAudio{}, err
+	}
+	return InboundAudio{Filename: name, Data: data}, nil
+}
+
+func (c *MatrixChannel) wasMentioned(content map[string]any, body string) bool {
+	if mentions, ok := content["m.mentions"].(map[string]any); ok {
+		ids, _ := mentions["user_ids"].([]any)
+		for _, id := range ids {
+			if s, _ := id.(string); s == c.userID {
+				return true
+			}
+		}
+	}
+	if c.userID == "" {
+		return false
+	}
+	if strings.Contains(body, c.userID) {
+		return true
+	}
+	local, _, _ := strings.Cut(strings.TrimPrefix(c.userID, "@"), ":")
+	return local != "" && strings.Contains(strings.ToLower(body), strings.ToLower(local))
+}
+
+// isGroupRoom treats rooms with more than two joined members as groups.
+func (c *MatrixChannel) isGroupRoom(ctx context.Context, roomID string) bool {
+	c.mu.Lock()
+	n, ok := c.memberCount[roomID]
+	c.mu.Unlock()
+	if !ok {
+		members, err := c.client.joinedMembers(ctx, roomID)
+		if err != nil {
+			return true
+		}
+		n = len(members)
+		c.mu.Lock()
+		c.memberCount[roomID] = n
+		c.mu.Unlock()
+	}
+	return n > 2
+}
+
+func (c *MatrixChannel) roomEncrypted(ctx context.Context, roomID string) (bool, error) {
+	c.mu.Lock()
+	enc, ok := c.encrypted[roomID]
+	c.mu.Unlock()
+	if ok {
+		return enc, nil
+	}
+	enc, err := c.client.roomEncrypted(ctx, roomID)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	c.encrypted[roomID] = enc
+	c.mu.Unlock()
+	return enc, nil
+}
+
+// stripMatrixReplyFallback drops the "> quoted" prefix clients add to replies.
+func stripMatrixReplyFallback(body string) string {
+	lines := strings.Split(body, "\n")
+	i := 0
+	for i < len(lines) && strings.HasPrefix(lines[i], "> ") {
+		i++
+	}
+	if i == 0 {
+		return body
+	}
+	if i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	return strings.Join(lines[i:], "\n")
+}
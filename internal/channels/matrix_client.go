@@ -0,0 +1,332 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// matrixClient is a minimal Matrix Client-Server API (v3) client covering the
+// endpoints the channel needs: sync, membership, sending and the E2EE key APIs.
+type matrixClient struct {
+	homeserver  string
+	accessToken string
+	http        *http.Client
+	txnPrefix   string
+	txnCounter  atomic.Uint64
+}
+
+// matrixAPIError is a non-2xx response. The message keeps the "status: N" form
+// so classifyDeliveryError can tell transient and terminal failures apart.
+type matrixAPIError struct {
+	Status  int
+	ErrCode string
+	Message string
+}
+
+func (e *matrixAPIError) Error() string {
+	if e.ErrCode != "" {
+		return fmt.Sprintf("matrix api status: %d %s: %s", e.Status, e.ErrCode, e.Message)
+	}
+	return fmt.Sprintf("matrix api status: %d", e.Status)
+}
+
+func newMatrixClient(homeserver, accessToken string) *matrixClient {
+	return &matrixClient{
+		homeserver:  strings.TrimRight(strings.TrimSpace(homeserver), "/"),
+		accessToken: strings.TrimSpace(accessToken),
+		http:        &http.Client{Timeout: 90 * time.Second},
+		txnPrefix:   strconv.FormatInt(time.Now().UnixNano(), 36),
+	}
+}
+
+func (c *matrixClient) nextTxnID() string {
+	return c.txnPrefix + "." + strconv.FormatUint(c.txnCounter.Add(1), 10)
+}
+
+func (c *matrixClient) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := c.homeserver + "/_matrix/client/v3" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		apiErr := &matrixAPIError{Status: resp.StatusCode}
+		var payload struct {
+			ErrCode string `json:"errcode"`
+			Error   string `json:"error"`
+		}
+		if json.Unmarshal(raw, &payload) == nil {
+			apiErr.ErrCode = payload.ErrCode
+			apiErr.Message = payload.Error
+		}
+		return apiErr
+	}
+	if out == nil || len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// downloadMedia fetches an mxc:// URI, trying the authenticated media API
+// first and the legacy unauthenticated one for older homeservers.
+func (c *matrixClient) downloadMedia(ctx context.Context, mxc string, limit int64) ([]byte, error) {
+	server, mediaID, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(mxc), "mxc://"), "/")
+	if !strings.HasPrefix(strings.TrimSpace(mxc), "mxc://") || !ok || server == "" || mediaID == "" {
+		return nil, fmt.Errorf("invalid mxc uri %q", mxc)
+	}
+	var lastErr error
+	for _, prefix := range []string{"/_matrix/client/v1/media/download", "/_matrix/media/v3/download"} {
+		data, err := c.fetchMedia(ctx, c.homeserver+prefix+matrixPath(server, mediaID), limit)
+		if err == nil {
+			return data, nil
+		}
+		lastErr = err
+		var apiErr *matrixAPIError
+		if !errors.As(err, &apiErr) || (apiErr.Status != http.StatusNotFound && apiErr.Status != http.StatusMethodNotAllowed) {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+func (c *matrixClient) fetchMedia(ctx context.Context, u string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, &matrixAPIError{Status: resp.StatusCode}
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errors.New("matrix media too large")
+	}
+	return data, nil
+}
+
+func matrixPath(parts ...string) string {
+	var b strings.Builder
+	for _, p := range parts {
+		b.WriteString("/")
+		b.WriteString(url.PathEscape(p))
+	}
+	return b.String()
+}
+
+type matrixWhoAmI struct {
+	UserID   string `json:"user_id"`
+	DeviceID string `json:"device_id"`
+}
+
+func (c *matrixClient) whoAmI(ctx context.Context) (*matrixWhoAmI, error) {
+	var out matrixWhoAmI
+	if err := c.do(ctx, http.MethodGet, "/account/whoami", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+type matrixEvent struct {
+	Type           string         `json:"type"`
+	EventID        string         `json:"event_id,omitempty"`
+	Sender         string         `json:"sender"`
+	StateKey       *string        `json:"state_key,omitempty"`
+	OriginServerTS int64          `json:"origin_server_ts,omitempty"`
+	Content        map[string]any `json:"content"`
+}
+
+type matrixEventList struct {
+	Events []matrixEvent `json:"events"`
+}
+
+type matrixJoinedRoom struct {
+	Summary struct {
+		JoinedMemberCount *int `json:"m.joined_member_count,omitempty"`
+	} `json:"summary"`
+	State    matrixEventList `json:"state"`
+	Timeline matrixEventList `json:"timeline"`
+}
+
+type matrixInvitedRoom struct {
+	InviteState matrixEventList `json:"invite_state"`
+}
+
+type matrixSyncResponse struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join   map[string]matrixJoinedRoom  `json:"join"`
+		Invite map[string]matrixInvitedRoom `json:"invite"`
+	} `json:"rooms"`
+	ToDevice               matrixEventList `json:"to_device"`
+	DeviceOneTimeKeysCount map[string]int  `json:"device_one_time_keys_count"`
+	DeviceLists            struct {
+		Changed []string `json:"changed"`
+	} `json:"device_lists"`
+}
+
+func (c *matrixClient) sync(ctx context.Context, since string, timeout time.Duration) (*matrixSyncResponse, error) {
+	q := url.Values{}
+	if since != "" {
+		q.Set("since", since)
+	}
+	q.Set("timeout", strconv.FormatInt(timeout.Milliseconds(), 10))
+	var out matrixSyncResponse
+	if err := c.do(ctx, http.MethodGet, "/sync", q, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *matrixClient) joinRoom(ctx context.Context, roomID string) error {
+	return c.do(ctx, http.MethodPost, "/join"+matrixPath(roomID), nil, map[string]any{}, nil)
+}
+
+func (c *matrixClient) leaveRoom(ctx context.Context, roomID string) error {
+	return c.do(ctx, http.MethodPost, "/rooms"+matrixPath(roomID, "leave"), nil, map[string]any{}, nil)
+}
+
+func (c *matrixClient) sendEvent(ctx context.Context, roomID, eventType string, content any) (string, error) {
+	var out struct {
+		EventID string `json:"event_id"`
+	}
+	path := "/rooms" + matrixPath(roomID, "send", eventType, c.nextTxnID())
+	if err := c.do(ctx, http.MethodPut, path, nil, content, &out); err != nil {
+		return "", err
+	}
+	return out.EventID, nil
+}
+
+func (c *matrixClient) joinedMembers(ctx context.Context, roomID string) ([]string, error) {
+	var out struct {
+		Joined map[string]any `json:"joined"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rooms"+matrixPath(roomID, "joined_members"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	members := make([]string, 0, len(out.Joined))
+	for id := range out.Joined {
+		members = append(members, id)
+	}
+	return members, nil
+}
+
+// roomEncrypted reports whether the room has an m.room.encryption state event.
+func (c *matrixClient) roomEncrypted(ctx context.Context, roomID string) (bool, error) {
+	var out map[string]any
+	err := c.do(ctx, http.MethodGet, "/rooms"+matrixPath(roomID, "state", "m.room.encryption", ""), nil, nil, &out)
+	if err != nil {
+		var apiErr *matrixAPIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return out["algorithm"] != nil, nil
+}
+
+func (c *matrixClient) uploadKeys(ctx context.Context, deviceKeys map[string]any, oneTimeKeys map[string]any) (map[string]int, error) {
+	body := map[string]any{}
+	if deviceKeys != nil {
+		body["device_keys"] = deviceKeys
+	}
+	if len(oneTimeKeys) > 0 {
+		body["one_time_keys"] = oneTimeKeys
+	}
+	var out struct {
+		OneTimeKeyCounts map[string]int `json:"one_time_key_counts"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/keys/upload", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out.OneTimeKeyCounts, nil
+}
+
+// matrixDeviceKeys is the device_keys object published by each device.
+type matrixDeviceKeys map[string]any
+
+func (c *matrixClient) queryKeys(ctx context.Context, users []string) (map[string]map[string]matrixDeviceKeys, error) {
+	req := map[string]any{}
+	for _, u := range users {
+		req[u] = []string{}
+	}
+	var out struct {
+		DeviceKeys map[string]map[string]matrixDeviceKeys `json:"device_keys"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/keys/query", nil, map[string]any{"device_keys": req, "timeout": 10000}, &out); err != nil {
+		return nil, err
+	}
+	return out.DeviceKeys, nil
+}
+
+// claimKeys claims one signed_curve25519 key per requested user/device.
+func (c *matrixClient) claimKeys(ctx context.Context, devices map[string][]string) (map[string]map[string]map[string]any, error) {
+	req := map[string]map[string]string{}
+	for user, ids := range devices {
+		req[user] = map[string]string{}
+		for _, id := range ids {
+			req[user][id] = "signed_curve25519"
+		}
+	}
+	var out struct {
+		OneTimeKeys map[string]map[string]map[string]any `json:"one_time_keys"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/keys/claim", nil, map[string]any{"one_time_keys": req, "timeout": 10000}, &out); err != nil {
+		return nil, err
+	}
+	return out.OneTimeKeys, nil
+}
+
+func (c *matrixClient) sendToDevice(ctx context.Context, eventType string, messages map[string]map[string]any) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	path := "/sendToDevice" + matrixPath(eventType, c.nextTxnID())
+	return c.do(ctx, http.MethodPut, path, nil, map[string]any{"messages": messages}, nil)
+}
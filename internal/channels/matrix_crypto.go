@@ -0,0 +1,707 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/secrets"
+)
+
+const (
+	matrixCryptoStateVersion = 1
+	// Keep roughly this many signed one-time keys published on the server.
+	matrixTargetOneTimeKeys = 50
+)
+
+var errMatrixNoSession = errors.New("matrix: no session for encrypted message")
+
+// matrixDevice is a verified device key entry from /keys/query.
+type matrixDevice struct {
+	UserID     string `json:"user_id"`
+	DeviceID   string `json:"device_id"`
+	Curve25519 string `json:"curve25519"`
+	Ed25519    string `json:"ed25519"`
+}
+
+// matrixCryptoState is the persisted E2EE state. It is sealed with
+// secrets.EncryptBlob before it is written to disk.
+type matrixCryptoState struct {
+	Version            int                                `json:"version"`
+	UserID             string                             `json:"user_id"`
+	DeviceID           string                             `json:"device_id"`
+	Account            *olmAccount                        `json:"account"`
+	DeviceKeysUploaded bool                               `json:"device_keys_uploaded"`
+	Sessions           map[string][]*olmSession           `json:"sessions,omitempty"`
+	Inbound            map[string]*megolmInbound          `json:"inbound,omitempty"`
+	Outbound           map[string]*megolmOutbound         `json:"outbound,omitempty"`
+	Devices            map[string]map[string]matrixDevice `json:"devices,omitempty"`
+	SeenIndexes        map[string]string                  `json:"seen_indexes,omitempty"`
+}
+
+// matrixCrypto implements Olm/Megolm for one Matrix device.
+type matrixCrypto struct {
+	mu         sync.Mutex
+	path       string
+	client     *matrixClient
+	state      *matrixCryptoState
+	staleUsers map[string]bool
+}
+
+var matrixStoreNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// defaultMatrixCryptoStorePath places the sealed store next to the other
+// credential blobs: <configDir>/skills/tools/auth/channels/matrix/<user>_<device>.json.
+func defaultMatrixCryptoStorePath(userID, deviceID string) (string, error) {
+	cfgPath, err := config.ConfigPath()
+	if err != nil {
+		return "", err
+	}
+	name := matrixStoreNameRe.ReplaceAllString(strings.TrimPrefix(userID, "@")+"_"+deviceID, "_")
+	return filepath.Join(filepath.Dir(cfgPath), "skills", "tools", "auth", "channels", "matrix", name+".json"), nil
+}
+
+// openMatrixCrypto loads the sealed store or creates a fresh device account.
+func openMatrixCrypto(path string, client *matrixClient, userID, deviceID string) (*matrixCrypto, error) {
+	m := &matrixCrypto{path: path, client: client, staleUsers: map[string]bool{}}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		plain, err := secrets.DecryptBlob(data)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt matrix crypto store: %w", err)
+		}
+		var st matrixCryptoState
+		if err := json.Unmarshal(plain, &st); err != nil {
+			return nil, fmt.Errorf("parse matrix crypto store: %w", err)
+		}
+		if st.UserID != userID || st.DeviceID != deviceID {
+			return nil, fmt.Errorf("matrix crypto store belongs to %s/%s, not %s/%s", st.UserID, st.DeviceID, userID, deviceID)
+		}
+		m.state = &st
+	case os.IsNotExist(err):
+		acct, err := newOlmAccount()
+		if err != nil {
+			return nil, err
+		}
+		m.state = &matrixCryptoState{Version: matrixCryptoStateVersion, UserID: userID, DeviceID: deviceID, Account: acct}
+	default:
+		return nil, fmt.Errorf("load matrix crypto store: %w", err)
+	}
+	if m.state.Sessions == nil {
+		m.state.Sessions = map[string][]*olmSession{}
+	}
+	if m.state.Inbound == nil {
+		m.state.Inbound = map[string]*megolmInbound{}
+	}
+	if m.state.Outbound == nil {
+		m.state.Outbound = map[string]*megolmOutbound{}
+	}
+	if m.state.Devices == nil {
+		m.state.Devices = map[string]map[string]matrixDevice{}
+	}
+	if m.state.SeenIndexes == nil {
+		m.state.SeenIndexes = map[string]string{}
+	}
+	return m, m.saveLocked()
+}
+
+func (m *matrixCrypto) saveLocked() error {
+	plain, err := json.Marshal(m.state)
+	if err != nil {
+		return err
+	}
+	sealed, err := secrets.EncryptBlob(plain)
+	if err != nil {
+		return fmt.Errorf("encrypt matrix crypto store: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o700); err != nil {
+		return err
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, sealed, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
+
+func (m *matrixCrypto) identityKeys() (ed, curve string) {
+	return m.state.Account.ed25519Key(), m.state.Account.curve25519Key()
+}
+
+// ---------------------------------------------------------------------------
+// Canonical JSON + signatures
+// ---------------------------------------------------------------------------
+
+func matrixCanonicalJSON(v any) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(generic); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+func matrixSigningPayload(obj map[string]any) ([]byte, error) {
+	clean := make(map[string]any, len(obj))
+	for k, v := range obj {
+		if k == "signatures" || k == "unsigned" {
+			continue
+		}
+		clean[k] = v
+	}
+	return matrixCanonicalJSON(clean)
+}
+
+func (m *matrixCrypto) signObject(obj map[string]any) error {
+	payload, err := matrixSigningPayload(obj)
+	if err != nil {
+		return err
+	}
+	obj["signatures"] = map[string]any{
+		m.state.UserID: map[string]any{"ed25519:" + m.state.DeviceID: m.state.Account.sign(payload)},
+	}
+	return nil
+}
+
+func verifyMatrixSignature(obj map[string]any, userID, deviceID, ed25519Key string) bool {
+	sigs, _ := obj["signatures"].(map[string]any)
+	userSigs, _ := sigs[userID].(map[string]any)
+	sig, _ := userSigs["ed25519:"+deviceID].(string)
+	if sig == "" {
+		return false
+	}
+	pub, err := matrixUnB64(ed25519Key)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return false
+	}
+	raw, err := matrixUnB64(sig)
+	if err != nil {
+		return false
+	}
+	payload, err := matrixSigningPayload(obj)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(ed25519.PublicKey(pub), payload, raw)
+}
+
+// ---------------------------------------------------------------------------
+// Key upload
+// ---------------------------------------------------------------------------
+
+func (m *matrixCrypto) deviceKeysLocked() (map[string]any, error) {
+	ed, curve := m.identityKeys()
+	obj := map[string]any{
+		"user_id":    m.state.UserID,
+		"device_id":  m.state.DeviceID,
+		"algorithms": []string{olmAlgorithm, megolmAlgorithm},
+		"keys": map[string]any{
+			"curve25519:" + m.state.DeviceID: curve,
+			"ed25519:" + m.state.DeviceID:    ed,
+		},
+	}
+	return obj, m.signObject(obj)
+}
+
+// ensureKeys publishes the device keys once and tops up one-time keys when
+// the server-side count drops below half the target. serverCount < 0 means
+// the count is unknown.
+func (m *matrixCrypto) ensureKeys(ctx context.Context, serverCount int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.state.DeviceKeysUploaded && serverCount >= 0 && serverCount >= matrixTargetOneTimeKeys/2 {
+		return nil
+	}
+	var deviceKeys map[string]any
+	if !m.state.DeviceKeysUploaded {
+		dk, err := m.deviceKeysLocked()
+		if err != nil {
+			return err
+		}
+		deviceKeys = dk
+	}
+	if serverCount < 0 {
+		serverCount = 0
+	}
+	if missing := matrixTargetOneTimeKeys - serverCount - len(m.state.Account.unpublishedOneTimeKeys()); missing > 0 {
+		if err := m.state.Account.generateOneTimeKeys(missing); err != nil {
+			return err
+		}
+	}
+	otks := map[string]any{}
+	for _, k := range m.state.Account.unpublishedOneTimeKeys() {
+		obj := map[string]any{"key": matrixB64(k.Public)}
+		if err := m.signObject(obj); err != nil {
+			return err
+		}
+		otks["signed_curve25519:"+k.ID] = obj
+	}
+	if deviceKeys == nil && len(otks) == 0 {
+		return nil
+	}
+	// Persist generated keys before publishing so a crash cannot strand
+	// one-time keys the server already advertises.
+	if err := m.saveLocked(); err != nil {
+		return err
+	}
+	if _, err := m.client.uploadKeys(ctx, deviceKeys, otks); err != nil {
+		return err
+	}
+	m.state.DeviceKeysUploaded = true
+	m.state.Account.markOneTimeKeysPublished()
+	return m.saveLocked()
+}
+
+// markUsersStale forces a device re-query for users whose device list changed.
+func (m *matrixCrypto) markUsersStale(users []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, u := range users {
+		m.staleUsers[u] = true
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Olm to-device decryption
+// ---------------------------------------------------------------------------
+
+// handleToDevice processes an encrypted to-device event and imports any room
+// key it carries. Unrelated events are ignored.
+func (m *matrixCrypto) handleToDevice(ev matrixEvent) error {
+	if ev.Type != "m.room.encrypted" {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	plain, senderKey, err := m.decryptOlmLocked(ev)
+	if err != nil {
+		return err
+	}
+	var payload struct {
+		Type          string            `json:"type"`
+		Sender        string            `json:"sender"`
+		Recipient     string            `json:"recipient"`
+		RecipientKeys map[string]string `json:"recipient_keys"`
+		Keys          map[string]string `json:"keys"`
+		Content       map[string]any    `json:"content"`
+	}
+	if err := json.Unmarshal(plain, &payload); err != nil {
+		return err
+	}
+	ourEd, _ := m.identityKeys()
+	if payload.Sender != ev.Sender || payload.Recipient != m.state.UserID || payload.RecipientKeys["ed25519"] != ourEd {
+		return fmt.Errorf("matrix olm payload addressing mismatch")
+	}
+	if payload.Type != "m.room_key" {
+		return m.saveLocked()
+	}
+	if alg, _ := payload.Content["algorithm"].(string); alg != megolmAlgorithm {
+		return m.saveLocked()
+	}
+	roomID, _ := payload.Content["room_id"].(string)
+	sessionKey, _ := payload.Content["session_key"].(string)
+	in, err := newMegolmInbound(roomID, senderKey, sessionKey)
+	if err != nil {
+		return err
+	}
+	key := roomID + "|" + senderKey + "|" + in.sessionID()
+	if prev, ok := m.state.Inbound[key]; !ok || in.Initial.Counter < prev.Initial.Counter {
+		m.state.Inbound[key] = in
+	}
+	return m.saveLocked()
+}
+
+func (m *matrixCrypto) decryptOlmLocked(ev matrixEvent) ([]byte, string, error) {
+	if alg, _ := ev.Content["algorithm"].(string); alg != olmAlgorithm {
+		return nil, "", fmt.Errorf("unsupported to-device algorithm %q", alg)
+	}
+	senderKey, _ := ev.Content["sender_key"].(string)
+	_, ourCurve := m.identityKeys()
+	ciphertexts, _ := ev.Content["ciphertext"].(map[string]any)
+	entry, _ := ciphertexts[ourCurve].(map[string]any)
+	if entry == nil {
+		return nil, "", fmt.Errorf("olm message not addressed to this device")
+	}
+	msgType, _ := entry["type"].(float64)
+	bodyStr, _ := entry["body"].(string)
+	body, err := matrixUnB64(bodyStr)
+	if err != nil {
+		return nil, "", err
+	}
+	sessions := m.state.Sessions[senderKey]
+	if int(msgType) == olmMessageTypePreKey {
+		pre, err := parseOlmPreKeyMessage(body)
+		if err != nil {
+			return nil, "", err
+		}
+		if matrixB64(pre.IdentityKey) != senderKey {
+			return nil, "", fmt.Errorf("olm pre-key identity mismatch")
+		}
+		for _, s := range sessions {
+			if s.matchesPreKey(pre) {
+				plain, err := s.decrypt(pre.Message)
+				return plain, senderKey, err
+			}
+		}
+		s, err := newInboundOlmSession(m.state.Account, pre)
+		if err != nil {
+			return nil, "", err
+		}
+		plain, err := s.decrypt(pre.Message)
+		if err != nil {
+			return nil, "", err
+		}
+		m.state.Account.removeOneTimeKey(pre.OneTimeKey)
+		m.state.Sessions[senderKey] = append([]*olmSession{s}, sessions...)
+		return plain, senderKey, nil
+	}
+	for _, s := range sessions {
+		if plain, err := s.decrypt(body); err == nil {
+			return plain, senderKey, nil
+		}
+	}
+	return nil, "", errMatrixNoSession
+}
+
+// ---------------------------------------------------------------------------
+// Megolm room decryption
+// ---------------------------------------------------------------------------
+
+// decryptRoomEvent returns the cleartext event for an m.room.encrypted event.
+func (m *matrixCrypto) decryptRoomEvent(roomID string, ev matrixEvent) (matrixEvent, error) {
+	if alg, _ := ev.Content["algorithm"].(string); alg != megolmAlgorithm {
+		return ev, fmt.Errorf("unsupported room algorithm %q", alg)
+	}
+	senderKey, _ := ev.Content["sender_key"].(string)
+	sessionID, _ := ev.Content["session_id"].(string)
+	ct, _ := ev.Content["ciphertext"].(string)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	in := m.state.Inbound[roomID+"|"+senderKey+"|"+sessionID]
+	if in == nil {
+		return ev, errMatrixNoSession
+	}
+	body, err := matrixUnB64(ct)
+	if err != nil {
+		return ev, err
+	}
+	plain, index, err := in.decrypt(body)
+	if err != nil {
+		return ev, err
+	}
+	// Reject a replayed message index under a different event ID.
+	seenKey := fmt.Sprintf("%s|%s|%d", roomID, sessionID, index)
+	if prev, ok := m.state.SeenIndexes[seenKey]; ok && prev != ev.EventID {
+		return ev, fmt.Errorf("megolm message index %d replayed", index)
+	}
+	if ev.EventID != "" {
+		m.state.SeenIndexes[seenKey] = ev.EventID
+		_ = m.saveLocked()
+	}
+	var payload struct {
+		Type    string         `json:"type"`
+		Content map[string]any `json:"content"`
+		RoomID  string         `json:"room_id"`
+	}
+	if err := json.Unmarshal(plain, &payload); err != nil {
+		return ev, err
+	}
+	if payload.RoomID != roomID {
+		return ev, fmt.Errorf("megolm payload room mismatch")
+	}
+	out := ev
+	out.Type = payload.Type
+	out.Content = payload.Content
+	if out.Content == nil {
+		out.Content = map[string]any{}
+	}
+	// Relations stay in cleartext on the encrypted wrapper.
+	if rel, ok := ev.Content["m.relates_to"]; ok {
+		if _, inner := out.Content["m.relates_to"]; !inner {
+			out.Content["m.relates_to"] = rel
+		}
+	}
+	return out, nil
+}
+
+// ---------------------------------------------------------------------------
+// Megolm room encryption
+// ---------------------------------------------------------------------------
+
+// encryptRoomEvent shares the room key with every joined device that lacks
+// it and returns the m.room.encrypted content to send.
+func (m *matrixCrypto) encryptRoomEvent(ctx context.Context, roomID, eventType string, content map[string]any) (map[string]any, error) {
+	members, err := m.client.joinedMembers(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := m.state.Outbound[roomID]
+	if out != nil && (out.expired(time.Now()) || membersLeft(out.SharedWith, members)) {
+		out = nil
+	}
+	if out == nil {
+		out, err = newMegolmOutbound()
+		if err != nil {
+			return nil, err
+		}
+		m.state.Outbound[roomID] = out
+	}
+	devices, err := m.devicesLocked(ctx, members)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.shareRoomKeyLocked(ctx, roomID, out, devices); err != nil {
+		return nil, err
+	}
+
+	inner := make(map[string]any, len(content))
+	var relatesTo any
+	for k, v := range content {
+		if k == "m.relates_to" {
+			relatesTo = v
+			continue
+		}
+		inner[k] = v
+	}
+	plain, err := json.Marshal(map[string]any{"type": eventType, "content": inner, "room_id": roomID})
+	if err != nil {
+		return nil, err
+	}
+	ct, err := out.encrypt(plain)
+	if err != nil {
+		return nil, err
+	}
+	_, ourCurve := m.identityKeys()
+	encrypted := map[string]any{
+		"algorithm":  megolmAlgorithm,
+		"sender_key": ourCurve,
+		"ciphertext": matrixB64(ct),
+		"session_id": out.sessionID(),
+		"device_id":  m.state.DeviceID,
+	}
+	if relatesTo != nil {
+		encrypted["m.relates_to"] = relatesTo
+	}
+	return encrypted, m.saveLocked()
+}
+
+func membersLeft(sharedWith map[string]bool, members []string) bool {
+	joined := make(map[string]bool, len(members))
+	for _, u := range members {
+		joined[u] = true
+	}
+	for key := range sharedWith {
+		user, _, _ := strings.Cut(key, "|")
+		if !joined[user] {
+			return true
+		}
+	}
+	return false
+}
+
+// devicesLocked returns verified devices for the given users, querying the
+// server for users that are unknown or flagged stale.
+func (m *matrixCrypto) devicesLocked(ctx context.Context, users []string) ([]matrixDevice, error) {
+	var query []string
+	for _, u := range users {
+		if _, ok := m.state.Devices[u]; !ok || m.staleUsers[u] {
+			query = append(query, u)
+		}
+	}
+	if len(query) > 0 {
+		res, err := m.client.queryKeys(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range query {
+			known := map[string]matrixDevice{}
+			for deviceID, raw := range res[u] {
+				d, ok := parseMatrixDevice(u, deviceID, raw)
+				if !ok {
+					continue
+				}
+				// Pin the ed25519 key: a device that changes keys is ignored.
+				if prev, ok := m.state.Devices[u][deviceID]; ok && prev.Ed25519 != d.Ed25519 {
+					continue
+				}
+				known[deviceID] = d
+			}
+			m.state.Devices[u] = known
+			delete(m.staleUsers, u)
+		}
+	}
+	var out []matrixDevice
+	for _, u := range users {
+		ids := make([]string, 0, len(m.state.Devices[u]))
+		for id := range m.state.Devices[u] {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			if u == m.state.UserID && id == m.state.DeviceID {
+				continue
+			}
+			out = append(out, m.state.Devices[u][id])
+		}
+	}
+	return out, nil
+}
+
+func parseMatrixDevice(userID, deviceID string, raw matrixDeviceKeys) (matrixDevice, bool) {
+	obj := map[string]any(raw)
+	if obj["user_id"] != userID || obj["device_id"] != deviceID {
+		return matrixDevice{}, false
+	}
+	keys, _ := obj["keys"].(map[string]any)
+	curve, _ := keys["curve25519:"+deviceID].(string)
+	ed, _ := keys["ed25519:"+deviceID].(string)
+	if curve == "" || ed == "" || !verifyMatrixSignature(obj, userID, deviceID, ed) {
+		return matrixDevice{}, false
+	}
+	return matrixDevice{UserID: userID, DeviceID: deviceID, Curve25519: curve, Ed25519: ed}, true
+}
+
+func (m *matrixCrypto) shareRoomKeyLocked(ctx context.Context, roomID string, out *megolmOutbound, devices []matrixDevice) error {
+	var pending []matrixDevice
+	for _, d := range devices {
+		if !out.SharedWith[d.UserID+"|"+d.DeviceID] {
+			pending = append(pending, d)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	if err := m.ensureOlmSessionsLocked(ctx, pending); err != nil {
+		return err
+	}
+	ourEd, ourCurve := m.identityKeys()
+	roomKey := map[string]any{
+		"algorithm":   megolmAlgorithm,
+		"room_id":     roomID,
+		"session_id":  out.sessionID(),
+		"session_key": out.sessionKey(),
+	}
+	messages := map[string]map[string]any{}
+	var delivered []string
+	for _, d := range pending {
+		sessions := m.state.Sessions[d.Curve25519]
+		if len(sessions) == 0 {
+			continue // device had no one-time keys left; it will get the key next time
+		}
+		plain, err := json.Marshal(map[string]any{
+			"type":           "m.room_key",
+			"content":        roomKey,
+			"sender":         m.state.UserID,
+			"sender_device":  m.state.DeviceID,
+			"keys":           map[string]string{"ed25519": ourEd},
+			"recipient":      d.UserID,
+			"recipient_keys": map[string]string{"ed25519": d.Ed25519},
+		})
+		if err != nil {
+			return err
+		}
+		msgType, body, err := sessions[0].encrypt(plain)
+		if err != nil {
+			return err
+		}
+		if messages[d.UserID] == nil {
+			messages[d.UserID] = map[string]any{}
+		}
+		messages[d.UserID][d.DeviceID] = map[string]any{
+			"algorithm":  olmAlgorithm,
+			"sender_key": ourCurve,
+			"ciphertext": map[string]any{
+				d.Curve25519: map[string]any{"type": msgType, "body": matrixB64(body)},
+			},
+		}
+		delivered = append(delivered, d.UserID+"|"+d.DeviceID)
+	}
+	if err := m.saveLocked(); err != nil {
+		return err
+	}
+	if err := m.client.sendToDevice(ctx, "m.room.encrypted", messages); err != nil {
+		return err
+	}
+	for _, key := range delivered {
+		out.SharedWith[key] = true
+	}
+	return nil
+}
+
+func (m *matrixCrypto) ensureOlmSessionsLocked(ctx context.Context, devices []matrixDevice) error {
+	claim := map[string][]string{}
+	byKey := map[string]matrixDevice{}
+	for _, d := range devices {
+		if len(m.state.Sessions[d.Curve25519]) > 0 {
+			continue
+		}
+		claim[d.UserID] = append(claim[d.UserID], d.DeviceID)
+		byKey[d.UserID+"|"+d.DeviceID] = d
+	}
+	if len(claim) == 0 {
+		return nil
+	}
+	res, err := m.client.claimKeys(ctx, claim)
+	if err != nil {
+		return err
+	}
+	for user, perDevice := range res {
+		for deviceID, keys := range perDevice {
+			d, ok := byKey[user+"|"+deviceID]
+			if !ok {
+				continue
+			}
+			for keyID, raw := range keys {
+				obj, _ := raw.(map[string]any)
+				if !strings.HasPrefix(keyID, "signed_curve25519:") || obj == nil {
+					continue
+				}
+				if !verifyMatrixSignature(obj, user, deviceID, d.Ed25519) {
+					continue
+				}
+				otkStr, _ := obj["key"].(string)
+				otk, err := matrixUnB64(otkStr)
+				if err != nil || len(otk) != 32 {
+					continue
+				}
+				identity, err := matrixUnB64(d.Curve25519)
+				if err != nil || len(identity) != 32 {
+					continue
+				}
+				s, err := newOutboundOlmSession(m.state.Account, identity, otk)
+				if err != nil {
+					return err
+				}
+				m.state.Sessions[d.Curve25519] = append([]*olmSession{s}, m.state.Sessions[d.Curve25519]...)
+				break
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package channels
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestOlmSessionRoundTrip(t *testing.T) {
+	alice, err := newOlmAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := newOlmAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bob.generateOneTimeKeys(1); err != nil {
+		t.Fatal(err)
+	}
+	bobIdentity, _ := curve25519Public(bob.Curve25519)
+	out, err := newOutboundOlmSession(alice, bobIdentity, bob.OneTimeKeys[0].Public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two pre-key messages before Bob replies; the second arrives first.
+	_, first, err := out.encrypt([]byte("hello bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ, second, err := out.encrypt([]byte("are you there"))
+	if err != nil || typ != olmMessageTypePreKey {
+		t.Fatalf("expected pre-key message, got type %d err %v", typ, err)
+	}
+	pre2, err := parseOlmPreKeyMessage(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, err := newInboundOlmSession(bob, pre2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.ID != out.ID {
+		t.Fatalf("session ids differ: %s vs %s", in.ID, out.ID)
+	}
+	if got, err := in.decrypt(pre2.Message); err != nil || string(got) != "are you there" {
+		t.Fatalf("decrypt second: %q %v", got, err)
+	}
+	pre1, _ := parseOlmPreKeyMessage(first)
+	if !in.matchesPreKey(pre1) {
+		t.Fatal("expected first pre-key message to match the session")
+	}
+	if got, err := in.decrypt(pre1.Message); err != nil || string(got) != "hello bob" {
+		t.Fatalf("decrypt skipped first: %q %v", got, err)
+	}
+	if _, err := in.decrypt(pre1.Message); err == nil {
+		t.Fatal("expected replayed message to fail")
+	}
+
+	// Ratchet back and forth a few times.
+	for i := 0; i < 3; i++ {
+		typ, reply, err := in.encrypt([]byte(fmt.Sprintf("bob %d", i)))
+		if err != nil || typ != olmMessageTypeNormal {
+			t.Fatalf("bob encrypt: type %d err %v", typ, err)
+		}
+		if got, err := out.decrypt(reply); err != nil || string(got) != fmt.Sprintf("bob %d", i) {
+			t.Fatalf("alice decrypt: %q %v", got, err)
+		}
+		typ, msg, err := out.encrypt([]byte(fmt.Sprintf("alice %d", i)))
+		if err != nil || typ != olmMessageTypeNormal {
+			t.Fatalf("alice encrypt after reply: type %d err %v", typ, err)
+		}
+		if got, err := in.decrypt(msg); err != nil || string(got) != fmt.Sprintf("alice %d", i) {
+			t.Fatalf("bob decrypt: %q %v", got, err)
+		}
+	}
+
+	// Tampering is rejected without corrupting state.
+	_, msg, _ := out.encrypt([]byte("intact"))
+	bad := append([]byte(nil), msg...)
+	bad[len(bad)-1] ^= 0xff
+	if _, err := in.decrypt(bad); err == nil {
+		t.Fatal("expected mac failure")
+	}
+	if got, err := in.decrypt(msg); err != nil || string(got) != "intact" {
+		t.Fatalf("decrypt after tamper: %q %v", got, err)
+	}
+}
+
+func TestMegolmAdvanceToMatchesSequential(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x42}, megolmRatchetSize)
+	for _, target := range []uint32{1, 255, 256, 257, 1000, 65536 + 3} {
+		seq := megolmRatchet{Data: append([]byte(nil), seed...)}
+		for seq.Counter < target {
+			seq.advance()
+		}
+		fast := megolmRatchet{Data: append([]byte(nil), seed...)}
+		fast.advanceTo(target)
+		if fast.Counter != target || !bytes.Equal(fast.Data, seq.Data) {
+			t.Fatalf("advanceTo(%d) diverged from sequential advance", target)
+		}
+	}
+}
+
+func TestMegolmSessionShareAndDecrypt(t *testing.T) {
+	out, err := newMegolmOutbound()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs [][]byte
+	for i := 0; i < 3; i++ {
+		ct, err := out.encrypt([]byte(fmt.Sprintf("msg %d", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, ct)
+	}
+	// A key shared now only decrypts messages from index 3 onwards.
+	in, err := newMegolmInbound("!r:x", "sender", out.sessionKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.sessionID() != out.sessionID() {
+		t.Fatal("session id mismatch")
+	}
+	if _, _, err := in.decrypt(msgs[0]); err == nil {
+		t.Fatal("expected earlier index to be undecryptable")
+	}
+	late, _ := out.encrypt([]byte("late"))
+	if got, idx, err := in.decrypt(late); err != nil || string(got) != "late" || idx != 3 {
+		t.Fatalf("decrypt late: %q idx=%d err=%v", got, idx, err)
+	}
+	late[len(late)-1] ^= 1
+	if _, _, err := in.decrypt(late); err == nil {
+		t.Fatal("expected signature failure")
+	}
+}
+
+func TestMatrixCryptoStoreIsSealedAndReloads(t *testing.T) {
+	setMatrixTestMasterKey(t)
+	path := t.TempDir() + "/store.json"
+	m, err := openMatrixCrypto(path, nil, "@bot:x", "DEV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed, curve := m.identityKeys()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, m.state.Account.Ed25519Seed) || bytes.Contains(raw, []byte("ed25519_seed")) {
+		t.Fatal("crypto store must not contain cleartext key material")
+	}
+	again, err := openMatrixCrypto(path, nil, "@bot:x", "DEV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ed2, curve2 := again.identityKeys(); ed2 != ed || curve2 != curve {
+		t.Fatal("identity keys changed across reload")
+	}
+	if _, err := openMatrixCrypto(path, nil, "@bot:x", "OTHER"); err == nil {
+		t.Fatal("expected device mismatch error")
+	}
+}
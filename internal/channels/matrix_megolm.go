@@ -0,0 +1,216 @@
+package channels
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// Megolm group ratchet for Matrix room encryption (m.megolm.v1.aes-sha2).
+
+const (
+	megolmRatchetParts    = 4
+	megolmRatchetPartSize = 32
+	megolmRatchetSize     = megolmRatchetParts * megolmRatchetPartSize
+	megolmSessionKeyVer   = 0x02
+	megolmSignatureLength = ed25519.SignatureSize
+	megolmKeysInfo        = "MEGOLM_KEYS"
+	megolmAlgorithm       = "m.megolm.v1.aes-sha2"
+	olmAlgorithm          = "m.olm.v1.curve25519-aes-sha2"
+
+	// Rotate outbound sessions the way Element does by default.
+	megolmRotateMessages = 100
+	megolmRotatePeriod   = 7 * 24 * time.Hour
+)
+
+type megolmRatchet struct {
+	Data    []byte `json:"data"`
+	Counter uint32 `json:"counter"`
+}
+
+func (r *megolmRatchet) clone() megolmRatchet {
+	return megolmRatchet{Data: append([]byte{}, r.Data...), Counter: r.Counter}
+}
+
+func (r *megolmRatchet) part(i int) []byte {
+	return r.Data[i*megolmRatchetPartSize : (i+1)*megolmRatchetPartSize]
+}
+
+func (r *megolmRatchet) rehash(from, to int) {
+	copy(r.part(to), hmacSHA256(r.part(from), []byte{byte(to)}))
+}
+
+func (r *megolmRatchet) advance() {
+	mask := uint32(0x00FFFFFF)
+	h := 0
+	r.Counter++
+	for h < megolmRatchetParts {
+		if r.Counter&mask == 0 {
+			break
+		}
+		h++
+		mask >>= 8
+	}
+	for i := megolmRatchetParts - 1; i >= h; i-- {
+		r.rehash(h, i)
+	}
+}
+
+// advanceTo moves the ratchet forward to the target index in O(log n) steps.
+func (r *megolmRatchet) advanceTo(target uint32) {
+	for j := 0; j < megolmRatchetParts; j++ {
+		shift := uint((megolmRatchetParts - j - 1) * 8)
+		mask := ^uint32(0) << shift
+		steps := ((target >> shift) - (r.Counter >> shift)) & 0xff
+		if steps == 0 {
+			if target < r.Counter {
+				steps = 0x100
+			} else {
+				continue
+			}
+		}
+		for ; steps > 1; steps-- {
+			r.rehash(j, j)
+		}
+		for k := megolmRatchetParts - 1; k >= j; k-- {
+			r.rehash(j, k)
+		}
+		r.Counter = target & mask
+	}
+}
+
+// megolmOutbound is our sending session for one room.
+type megolmOutbound struct {
+	Ratchet      megolmRatchet   `json:"ratchet"`
+	SigningSeed  []byte          `json:"signing_seed"`
+	CreatedAt    time.Time       `json:"created_at"`
+	MessageCount int             `json:"message_count"`
+	SharedWith   map[string]bool `json:"shared_with,omitempty"`
+}
+
+func newMegolmOutbound() (*megolmOutbound, error) {
+	data := make([]byte, megolmRatchetSize)
+	if _, err := rand.Read(data); err != nil {
+		return nil, err
+	}
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	return &megolmOutbound{
+		Ratchet:     megolmRatchet{Data: data},
+		SigningSeed: seed,
+		CreatedAt:   time.Now().UTC(),
+		SharedWith:  map[string]bool{},
+	}, nil
+}
+
+func (s *megolmOutbound) signingKey() ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(s.SigningSeed)
+}
+
+func (s *megolmOutbound) sessionID() string {
+	return matrixB64(s.signingKey().Public().(ed25519.PublicKey))
+}
+
+func (s *megolmOutbound) expired(now time.Time) bool {
+	return s.MessageCount >= megolmRotateMessages || now.Sub(s.CreatedAt) >= megolmRotatePeriod
+}
+
+// sessionKey exports the current ratchet state for an m.room_key event.
+func (s *megolmOutbound) sessionKey() string {
+	out := []byte{megolmSessionKeyVer}
+	out = binary.BigEndian.AppendUint32(out, s.Ratchet.Counter)
+	out = append(out, s.Ratchet.Data...)
+	out = append(out, s.signingKey().Public().(ed25519.PublicKey)...)
+	out = append(out, ed25519.Sign(s.signingKey(), out)...)
+	return matrixB64(out)
+}
+
+func (s *megolmOutbound) encrypt(plain []byte) ([]byte, error) {
+	aesKey, macKey, iv, err := aesSHA2Keys(s.Ratchet.Data, megolmKeysInfo)
+	if err != nil {
+		return nil, err
+	}
+	ct, err := aesCBCEncrypt(aesKey, iv, plain)
+	if err != nil {
+		return nil, err
+	}
+	m := []byte{olmProtocolVersion}
+	m = appendVarintField(m, 0x08, uint64(s.Ratchet.Counter))
+	m = appendBytesField(m, 0x12, ct)
+	m = append(m, hmacSHA256(macKey, m)[:olmMACLength]...)
+	m = append(m, ed25519.Sign(s.signingKey(), m)...)
+	s.Ratchet.advance()
+	s.MessageCount++
+	return m, nil
+}
+
+// megolmInbound is a receiving session imported from an m.room_key event.
+type megolmInbound struct {
+	RoomID     string        `json:"room_id"`
+	SenderKey  string        `json:"sender_key"`
+	SigningKey []byte        `json:"signing_key"`
+	Initial    megolmRatchet `json:"initial"`
+}
+
+func newMegolmInbound(roomID, senderKey, sessionKey string) (*megolmInbound, error) {
+	raw, err := matrixUnB64(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	const want = 1 + 4 + megolmRatchetSize + ed25519.PublicKeySize + megolmSignatureLength
+	if len(raw) != want || raw[0] != megolmSessionKeyVer {
+		return nil, fmt.Errorf("unsupported megolm session key")
+	}
+	signed := raw[:len(raw)-megolmSignatureLength]
+	pub := ed25519.PublicKey(raw[1+4+megolmRatchetSize : len(raw)-megolmSignatureLength])
+	if !ed25519.Verify(pub, signed, raw[len(raw)-megolmSignatureLength:]) {
+		return nil, fmt.Errorf("megolm session key signature mismatch")
+	}
+	return &megolmInbound{
+		RoomID:     roomID,
+		SenderKey:  senderKey,
+		SigningKey: append([]byte{}, pub...),
+		Initial: megolmRatchet{
+			Data:    append([]byte{}, raw[5:5+megolmRatchetSize]...),
+			Counter: binary.BigEndian.Uint32(raw[1:5]),
+		},
+	}, nil
+}
+
+func (s *megolmInbound) sessionID() string { return matrixB64(s.SigningKey) }
+
+func (s *megolmInbound) decrypt(body []byte) ([]byte, uint32, error) {
+	if len(body) < 1+olmMACLength+megolmSignatureLength || body[0] != olmProtocolVersion {
+		return nil, 0, fmt.Errorf("unsupported megolm message")
+	}
+	sigStart := len(body) - megolmSignatureLength
+	if !ed25519.Verify(ed25519.PublicKey(s.SigningKey), body[:sigStart], body[sigStart:]) {
+		return nil, 0, fmt.Errorf("megolm message signature mismatch")
+	}
+	macStart := sigStart - olmMACLength
+	f, err := parseOlmFields(body[1:macStart])
+	if err != nil {
+		return nil, 0, err
+	}
+	index := uint32(f.varints[0x08])
+	ct := f.bytes[0x12]
+	if index < s.Initial.Counter {
+		return nil, index, fmt.Errorf("megolm index %d precedes known session start", index)
+	}
+	r := s.Initial.clone()
+	r.advanceTo(index)
+	aesKey, macKey, iv, err := aesSHA2Keys(r.Data, megolmKeysInfo)
+	if err != nil {
+		return nil, index, err
+	}
+	if !hmac.Equal(hmacSHA256(macKey, body[:macStart])[:olmMACLength], body[macStart:sigStart]) {
+		return nil, index, fmt.Errorf("megolm message mac mismatch")
+	}
+	plain, err := aesCBCDecrypt(aesKey, iv, ct)
+	return plain, index, err
+}
@@ -0,0 +1,657 @@
+package channels
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Olm double-ratchet primitives for Matrix to-device encryption. The wire
+// format follows the matrix.org Olm specification (version 3 messages) so
+// sessions interoperate with libolm/vodozemac peers.
+
+const (
+	olmProtocolVersion   = 0x03
+	olmMACLength         = 8
+	olmMaxSkippedKeys    = 40
+	olmMaxReceiverChains = 5
+	olmMaxMessageGap     = 2000
+	olmMaxOneTimeKeys    = 100
+
+	olmMessageTypePreKey = 0
+	olmMessageTypeNormal = 1
+)
+
+var (
+	olmRootInfo    = "OLM_ROOT"
+	olmRatchetInfo = "OLM_RATCHET"
+	olmKeysInfo    = "OLM_KEYS"
+)
+
+func matrixB64(b []byte) string { return base64.RawStdEncoding.EncodeToString(b) }
+
+func matrixUnB64(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(s), "="))
+}
+
+func hmacSHA256(key, msg []byte) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write(msg)
+	return m.Sum(nil)
+}
+
+func hkdfSHA256(salt, secret []byte, info string, n int) ([]byte, error) {
+	return hkdf.Key(sha256.New, secret, salt, info, n)
+}
+
+func x25519(priv, pub []byte) ([]byte, error) {
+	sk, err := ecdh.X25519().NewPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := ecdh.X25519().NewPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return sk.ECDH(pk)
+}
+
+func newCurve25519Pair() (priv, pub []byte, err error) {
+	sk, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sk.Bytes(), sk.PublicKey().Bytes(), nil
+}
+
+func curve25519Public(priv []byte) ([]byte, error) {
+	sk, err := ecdh.X25519().NewPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return sk.PublicKey().Bytes(), nil
+}
+
+// aesSHA2Keys expands a message key into the AES-256 key, HMAC key and IV
+// used by both Olm ("OLM_KEYS") and Megolm ("MEGOLM_KEYS").
+func aesSHA2Keys(secret []byte, info string) (aesKey, macKey, iv []byte, err error) {
+	out, err := hkdfSHA256(nil, secret, info, 80)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return out[:32], out[32:64], out[64:80], nil
+}
+
+func aesCBCEncrypt(key, iv, plain []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	buf := make([]byte, len(plain)+pad)
+	copy(buf, plain)
+	for i := len(plain); i < len(buf); i++ {
+		buf[i] = byte(pad)
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(buf, buf)
+	return buf, nil
+}
+
+func aesCBCDecrypt(key, iv, ct []byte) ([]byte, error) {
+	if len(ct) == 0 || len(ct)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("invalid ciphertext length")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, len(ct))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(buf, ct)
+	pad := int(buf[len(buf)-1])
+	if pad == 0 || pad > aes.BlockSize || pad > len(buf) {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	for _, b := range buf[len(buf)-pad:] {
+		if int(b) != pad {
+			return nil, fmt.Errorf("invalid padding")
+		}
+	}
+	return buf[:len(buf)-pad], nil
+}
+
+// ---------------------------------------------------------------------------
+// Protobuf-style field encoding shared by Olm and Megolm messages.
+// ---------------------------------------------------------------------------
+
+func appendBytesField(b []byte, tag byte, v []byte) []byte {
+	b = append(b, tag)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendVarintField(b []byte, tag byte, v uint64) []byte {
+	b = append(b, tag)
+	return binary.AppendUvarint(b, v)
+}
+
+type olmFields struct {
+	bytes   map[byte][]byte
+	varints map[byte]uint64
+}
+
+// parseOlmFields decodes the body after the version byte. Unknown fields are
+// skipped as the spec requires.
+func parseOlmFields(b []byte) (olmFields, error) {
+	f := olmFields{bytes: map[byte][]byte{}, varints: map[byte]uint64{}}
+	for len(b) > 0 {
+		tag := b[0]
+		b = b[1:]
+		switch tag & 0x07 {
+		case 0:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return f, fmt.Errorf("truncated varint")
+			}
+			f.varints[tag] = v
+			b = b[n:]
+		case 2:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return f, fmt.Errorf("truncated field")
+			}
+			f.bytes[tag] = b[n : n+int(l)]
+			b = b[n+int(l):]
+		default:
+			return f, fmt.Errorf("unsupported wire type %d", tag&0x07)
+		}
+	}
+	return f, nil
+}
+
+// ---------------------------------------------------------------------------
+// Account
+// ---------------------------------------------------------------------------
+
+type olmOneTimeKey struct {
+	ID        string `json:"id"`
+	Private   []byte `json:"private"`
+	Public    []byte `json:"public"`
+	Published bool   `json:"published"`
+}
+
+// olmAccount holds the long-lived device identity keys.
+type olmAccount struct {
+	Ed25519Seed []byte          `json:"ed25519_seed"`
+	Curve25519  []byte          `json:"curve25519"`
+	OneTimeKeys []olmOneTimeKey `json:"one_time_keys,omitempty"`
+	NextKeyID   uint32          `json:"next_key_id"`
+}
+
+func newOlmAccount() (*olmAccount, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	curve, _, err := newCurve25519Pair()
+	if err != nil {
+		return nil, err
+	}
+	return &olmAccount{Ed25519Seed: seed, Curve25519: curve, NextKeyID: 1}, nil
+}
+
+func (a *olmAccount) signingKey() ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(a.Ed25519Seed)
+}
+
+func (a *olmAccount) ed25519Key() string {
+	return matrixB64(a.signingKey().Public().(ed25519.PublicKey))
+}
+
+func (a *olmAccount) curve25519Key() string {
+	pub, _ := curve25519Public(a.Curve25519)
+	return matrixB64(pub)
+}
+
+func (a *olmAccount) sign(msg []byte) string {
+	return matrixB64(ed25519.Sign(a.signingKey(), msg))
+}
+
+// generateOneTimeKeys adds n fresh keys, dropping the oldest when the account
+// would exceed the libolm limit.
+func (a *olmAccount) generateOneTimeKeys(n int) error {
+	for i := 0; i < n; i++ {
+		priv, pub, err := newCurve25519Pair()
+		if err != nil {
+			return err
+		}
+		id := make([]byte, 4)
+		binary.BigEndian.PutUint32(id, a.NextKeyID)
+		a.NextKeyID++
+		a.OneTimeKeys = append(a.OneTimeKeys, olmOneTimeKey{ID: matrixB64(id), Private: priv, Public: pub})
+	}
+	if over := len(a.OneTimeKeys) - olmMaxOneTimeKeys; over > 0 {
+		a.OneTimeKeys = a.OneTimeKeys[over:]
+	}
+	return nil
+}
+
+func (a *olmAccount) unpublishedOneTimeKeys() []olmOneTimeKey {
+	var out []olmOneTimeKey
+	for _, k := range a.OneTimeKeys {
+		if !k.Published {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func (a *olmAccount) markOneTimeKeysPublished() {
+	for i := range a.OneTimeKeys {
+		a.OneTimeKeys[i].Published = true
+	}
+}
+
+func (a *olmAccount) findOneTimeKey(pub []byte) *olmOneTimeKey {
+	for i := range a.OneTimeKeys {
+		if bytes.Equal(a.OneTimeKeys[i].Public, pub) {
+			return &a.OneTimeKeys[i]
+		}
+	}
+	return nil
+}
+
+func (a *olmAccount) removeOneTimeKey(pub []byte) {
+	out := a.OneTimeKeys[:0]
+	for _, k := range a.OneTimeKeys {
+		if !bytes.Equal(k.Public, pub) {
+			out = append(out, k)
+		}
+	}
+	a.OneTimeKeys = out
+}
+
+// ---------------------------------------------------------------------------
+// Session
+// ---------------------------------------------------------------------------
+
+type olmChain struct {
+	RatchetPub  []byte `json:"ratchet_pub"`
+	RatchetPriv []byte `json:"ratchet_priv,omitempty"`
+	ChainKey    []byte `json:"chain_key"`
+	Index       uint32 `json:"index"`
+}
+
+type olmSkippedKey struct {
+	RatchetPub []byte `json:"ratchet_pub"`
+	Index      uint32 `json:"index"`
+	MessageKey []byte `json:"message_key"`
+}
+
+// olmSession is one double-ratchet session with a peer device.
+type olmSession struct {
+	ID               string          `json:"id"`
+	TheirIdentityKey []byte          `json:"their_identity_key"`
+	RootKey          []byte          `json:"root_key"`
+	Sender           *olmChain       `json:"sender,omitempty"`
+	Receivers        []olmChain      `json:"receivers,omitempty"`
+	Skipped          []olmSkippedKey `json:"skipped,omitempty"`
+	// Outbound sessions keep wrapping messages as pre-key messages until the
+	// peer has replied once.
+	PreKeyOneTime  []byte    `json:"prekey_one_time,omitempty"`
+	PreKeyBase     []byte    `json:"prekey_base,omitempty"`
+	OurIdentityKey []byte    `json:"our_identity_key,omitempty"`
+	Received       bool      `json:"received"`
+	LastUsed       time.Time `json:"last_used"`
+}
+
+type olmPreKeyMessage struct {
+	OneTimeKey  []byte
+	BaseKey     []byte
+	IdentityKey []byte
+	Message     []byte
+}
+
+func olmSessionID(aliceIdentity, aliceBase, bobOneTime []byte) string {
+	h := sha256.New()
+	h.Write(aliceIdentity)
+	h.Write(aliceBase)
+	h.Write(bobOneTime)
+	return matrixB64(h.Sum(nil))
+}
+
+func olmRootFromSecret(s1, s2, s3 []byte) (root, chain []byte, err error) {
+	secret := append(append(append([]byte{}, s1...), s2...), s3...)
+	keys, err := hkdfSHA256(nil, secret, olmRootInfo, 64)
+	if err != nil {
+		return nil, nil, err
+	}
+	return keys[:32], keys[32:], nil
+}
+
+// newOutboundOlmSession starts a session as "Alice" using the peer's identity
+// key and one of its claimed one-time keys.
+func newOutboundOlmSession(acct *olmAccount, theirIdentity, theirOneTime []byte) (*olmSession, error) {
+	basePriv, basePub, err := newCurve25519Pair()
+	if err != nil {
+		return nil, err
+	}
+	ratchetPriv, ratchetPub, err := newCurve25519Pair()
+	if err != nil {
+		return nil, err
+	}
+	s1, err := x25519(acct.Curve25519, theirOneTime)
+	if err != nil {
+		return nil, err
+	}
+	s2, err := x25519(basePriv, theirIdentity)
+	if err != nil {
+		return nil, err
+	}
+	s3, err := x25519(basePriv, theirOneTime)
+	if err != nil {
+		return nil, err
+	}
+	root, chain, err := olmRootFromSecret(s1, s2, s3)
+	if err != nil {
+		return nil, err
+	}
+	ourIdentity, err := curve25519Public(acct.Curve25519)
+	if err != nil {
+		return nil, err
+	}
+	return &olmSession{
+		ID:               olmSessionID(ourIdentity, basePub, theirOneTime),
+		TheirIdentityKey: append([]byte{}, theirIdentity...),
+		RootKey:          root,
+		Sender:           &olmChain{RatchetPub: ratchetPub, RatchetPriv: ratchetPriv, ChainKey: chain},
+		PreKeyOneTime:    append([]byte{}, theirOneTime...),
+		PreKeyBase:       basePub,
+		OurIdentityKey:   ourIdentity,
+		LastUsed:         time.Now().UTC(),
+	}, nil
+}
+
+// newInboundOlmSession creates a session as "Bob" from a received pre-key
+// message. The one-time key is only removed from the account by the caller
+// after the first message decrypts.
+func newInboundOlmSession(acct *olmAccount, pre olmPreKeyMessage) (*olmSession, error) {
+	otk := acct.findOneTimeKey(pre.OneTimeKey)
+	if otk == nil {
+		return nil, fmt.Errorf("unknown one-time key")
+	}
+	inner, err := parseOlmMessage(pre.Message)
+	if err != nil {
+		return nil, err
+	}
+	s1, err := x25519(otk.Private, pre.IdentityKey)
+	if err != nil {
+		return nil, err
+	}
+	s2, err := x25519(acct.Curve25519, pre.BaseKey)
+	if err != nil {
+		return nil, err
+	}
+	s3, err := x25519(otk.Private, pre.BaseKey)
+	if err != nil {
+		return nil, err
+	}
+	root, chain, err := olmRootFromSecret(s1, s2, s3)
+	if err != nil {
+		return nil, err
+	}
+	return &olmSession{
+		ID:               olmSessionID(pre.IdentityKey, pre.BaseKey, pre.OneTimeKey),
+		TheirIdentityKey: append([]byte{}, pre.IdentityKey...),
+		RootKey:          root,
+		Receivers:        []olmChain{{RatchetPub: inner.RatchetKey, ChainKey: chain}},
+		Received:         true,
+		LastUsed:         time.Now().UTC(),
+	}, nil
+}
+
+func (s *olmSession) clone() *olmSession {
+	raw, _ := json.Marshal(s)
+	var out olmSession
+	_ = json.Unmarshal(raw, &out)
+	return &out
+}
+
+// matchesPreKey reports whether a pre-key message belongs to this session.
+func (s *olmSession) matchesPreKey(pre olmPreKeyMessage) bool {
+	return s.ID == olmSessionID(pre.IdentityKey, pre.BaseKey, pre.OneTimeKey)
+}
+
+func advanceChainKey(chain []byte) (messageKey, next []byte) {
+	return hmacSHA256(chain, []byte{0x01}), hmacSHA256(chain, []byte{0x02})
+}
+
+// encrypt returns the Olm message type and the encoded body.
+func (s *olmSession) encrypt(plain []byte) (int, []byte, error) {
+	if s.Sender == nil {
+		if len(s.Receivers) == 0 {
+			return 0, nil, fmt.Errorf("olm session has no receiver chain")
+		}
+		priv, pub, err := newCurve25519Pair()
+		if err != nil {
+			return 0, nil, err
+		}
+		dh, err := x25519(priv, s.Receivers[0].RatchetPub)
+		if err != nil {
+			return 0, nil, err
+		}
+		keys, err := hkdfSHA256(s.RootKey, dh, olmRatchetInfo, 64)
+		if err != nil {
+			return 0, nil, err
+		}
+		s.RootKey = keys[:32]
+		s.Sender = &olmChain{RatchetPub: pub, RatchetPriv: priv, ChainKey: keys[32:]}
+	}
+	mk, next := advanceChainKey(s.Sender.ChainKey)
+	counter := s.Sender.Index
+	s.Sender.ChainKey = next
+	s.Sender.Index++
+	body, err := encodeOlmMessage(s.Sender.RatchetPub, counter, mk, plain)
+	if err != nil {
+		return 0, nil, err
+	}
+	s.LastUsed = time.Now().UTC()
+	if s.Received {
+		return olmMessageTypeNormal, body, nil
+	}
+	pre := []byte{olmProtocolVersion}
+	pre = appendBytesField(pre, 0x0A, s.PreKeyOneTime)
+	pre = appendBytesField(pre, 0x12, s.PreKeyBase)
+	pre = appendBytesField(pre, 0x1A, s.OurIdentityKey)
+	pre = appendBytesField(pre, 0x22, body)
+	return olmMessageTypePreKey, pre, nil
+}
+
+func encodeOlmMessage(ratchetPub []byte, counter uint32, messageKey, plain []byte) ([]byte, error) {
+	aesKey, macKey, iv, err := aesSHA2Keys(messageKey, olmKeysInfo)
+	if err != nil {
+		return nil, err
+	}
+	ct, err := aesCBCEncrypt(aesKey, iv, plain)
+	if err != nil {
+		return nil, err
+	}
+	m := []byte{olmProtocolVersion}
+	m = appendBytesField(m, 0x0A, ratchetPub)
+	m = appendVarintField(m, 0x10, uint64(counter))
+	m = appendBytesField(m, 0x22, ct)
+	return append(m, hmacSHA256(macKey, m)[:olmMACLength]...), nil
+}
+
+type olmMessage struct {
+	RatchetKey []byte
+	Counter    uint32
+	Ciphertext []byte
+	Signed     []byte
+	MAC        []byte
+}
+
+func parseOlmMessage(b []byte) (olmMessage, error) {
+	if len(b) < 1+olmMACLength || b[0] != olmProtocolVersion {
+		return olmMessage{}, fmt.Errorf("unsupported olm message")
+	}
+	signed := b[:len(b)-olmMACLength]
+	f, err := parseOlmFields(signed[1:])
+	if err != nil {
+		return olmMessage{}, err
+	}
+	m := olmMessage{
+		RatchetKey: f.bytes[0x0A],
+		Counter:    uint32(f.varints[0x10]),
+		Ciphertext: f.bytes[0x22],
+		Signed:     signed,
+		MAC:        b[len(b)-olmMACLength:],
+	}
+	if len(m.RatchetKey) != 32 || len(m.Ciphertext) == 0 {
+		return olmMessage{}, fmt.Errorf("malformed olm message")
+	}
+	return m, nil
+}
+
+func parseOlmPreKeyMessage(b []byte) (olmPreKeyMessage, error) {
+	if len(b) < 1 || b[0] != olmProtocolVersion {
+		return olmPreKeyMessage{}, fmt.Errorf("unsupported olm pre-key message")
+	}
+	f, err := parseOlmFields(b[1:])
+	if err != nil {
+		return olmPreKeyMessage{}, err
+	}
+	p := olmPreKeyMessage{
+		OneTimeKey:  f.bytes[0x0A],
+		BaseKey:     f.bytes[0x12],
+		IdentityKey: f.bytes[0x1A],
+		Message:     f.bytes[0x22],
+	}
+	if len(p.OneTimeKey) != 32 || len(p.BaseKey) != 32 || len(p.IdentityKey) != 32 || len(p.Message) == 0 {
+		return olmPreKeyMessage{}, fmt.Errorf("malformed olm pre-key message")
+	}
+	return p, nil
+}
+
+// decrypt decrypts a normal-message body. State changes are only committed
+// when the MAC verifies.
+func (s *olmSession) decrypt(body []byte) ([]byte, error) {
+	msg, err := parseOlmMessage(body)
+	if err != nil {
+		return nil, err
+	}
+	next := s.clone()
+	idx := -1
+	for i := range next.Receivers {
+		if bytes.Equal(next.Receivers[i].RatchetPub, msg.RatchetKey) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		if next.Sender == nil {
+			return nil, fmt.Errorf("olm session cannot ratchet without a sender chain")
+		}
+		dh, err := x25519(next.Sender.RatchetPriv, msg.RatchetKey)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := hkdfSHA256(next.RootKey, dh, olmRatchetInfo, 64)
+		if err != nil {
+			return nil, err
+		}
+		next.RootKey = keys[:32]
+		next.Receivers = append([]olmChain{{RatchetPub: msg.RatchetKey, ChainKey: keys[32:]}}, next.Receivers...)
+		if len(next.Receivers) > olmMaxReceiverChains {
+			next.Receivers = next.Receivers[:olmMaxReceiverChains]
+		}
+		next.Sender = nil
+		idx = 0
+	}
+	chain := &next.Receivers[idx]
+	var mk []byte
+	if msg.Counter < chain.Index {
+		for i, sk := range next.Skipped {
+			if sk.Index == msg.Counter && bytes.Equal(sk.RatchetPub, msg.RatchetKey) {
+				mk = sk.MessageKey
+				next.Skipped = append(next.Skipped[:i], next.Skipped[i+1:]...)
+				break
+			}
+		}
+		if mk == nil {
+			return nil, fmt.Errorf("olm message key already used")
+		}
+	} else {
+		if msg.Counter-chain.Index > olmMaxMessageGap {
+			return nil, fmt.Errorf("olm message gap too large")
+		}
+		for chain.Index < msg.Counter {
+			skipped, ck := advanceChainKey(chain.ChainKey)
+			next.Skipped = append(next.Skipped, olmSkippedKey{RatchetPub: chain.RatchetPub, Index: chain.Index, MessageKey: skipped})
+			chain.ChainKey = ck
+			chain.Index++
+		}
+		if over := len(next.Skipped) - olmMaxSkippedKeys; over > 0 {
+			next.Skipped = next.Skipped[over:]
+		}
+		var ck []byte
+		mk, ck = advanceChainKey(chain.ChainKey)
+		chain.ChainKey = ck
+		chain.Index++
+	}
+	aesKey, macKey, iv, err := aesSHA2Keys(mk, olmKeysInfo)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(hmacSHA256(macKey, msg.Signed)[:olmMACLength], msg.MAC) {
+		return nil, fmt.Errorf("olm message mac mismatch")
+	}
+	plain, err := aesCBCDecrypt(aesKey, iv, msg.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	next.Received = true
+	next.LastUsed = time.Now().UTC()
+	*s = *next
+	return plain, nil
+}
+
+// decryptMatrixAttachment decrypts an EncryptedFile (AES-256-CTR with a JWK
+// key, SHA-256 of the ciphertext) as used for attachments in encrypted rooms.
+func decryptMatrixAttachment(file map[string]any, ciphertext []byte) ([]byte, error) {
+	jwk, _ := file["key"].(map[string]any)
+	k, _ := jwk["k"].(string)
+	key, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(k), "="))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("matrix attachment: invalid key")
+	}
+	ivStr, _ := file["iv"].(string)
+	iv, err := matrixUnB64(ivStr)
+	if err != nil || len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("matrix attachment: invalid iv")
+	}
+	hashes, _ := file["hashes"].(map[string]any)
+	want, _ := hashes["sha256"].(string)
+	sum := sha256.Sum256(ciphertext)
+	if want == "" || !hmac.Equal([]byte(matrixB64(sum[:])), []byte(strings.TrimRight(want, "="))) {
+		return nil, fmt.Errorf("matrix attachment: hash mismatch")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(ciphertext))
+	cipher.NewCTR(block, iv).XORKeyStream(plain, ciphertext)
+	return plain, nil
+}
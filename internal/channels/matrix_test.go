@@ -0,0 +1,562 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// fakeHomeserver is a recorded, in-memory stand-in for the Client-Server API
+// endpoints MatrixChannel uses. Access tokens map to user/device identities so
+// one server can host the bot and its peers.
+type fakeHomeserver struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mu             sync.Mutex
+	identities     map[string][2]string // token -> user, device
+	deviceKeys     map[string]map[string]map[string]any
+	oneTimeKeys    map[string]map[string]any // user|device -> key id -> key
+	toDevice       map[string][]matrixEvent  // user|device -> events
+	members        map[string][]string
+	encryptedRooms map[string]bool
+	media          map[string][]byte // server/id -> content
+	syncQueue      []matrixSyncResponse
+	sent           []fakeSentEvent
+	joined         []string
+	left           []string
+	nextEvent      int
+}
+
+type fakeSentEvent struct {
+	Sender  string
+	RoomID  string
+	Type    string
+	EventID string
+	Content map[string]any
+}
+
+func newFakeHomeserver(t *testing.T) *fakeHomeserver {
+	t.Helper()
+	f := &fakeHomeserver{
+		t:              t,
+		identities:     map[string][2]string{},
+		deviceKeys:     map[string]map[string]map[string]any{},
+		oneTimeKeys:    map[string]map[string]any{},
+		toDevice:       map[string][]matrixEvent{},
+		members:        map[string][]string{},
+		encryptedRooms: map[string]bool{},
+		media:          map[string][]byte{},
+	}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeHomeserver) addUser(token, userID, deviceID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.identities[token] = [2]string{userID, deviceID}
+}
+
+func (f *fakeHomeserver) queueSync(resp matrixSyncResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.syncQueue = append(f.syncQueue, resp)
+}
+
+func (f *fakeHomeserver) sentEvents() []fakeSentEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeSentEvent(nil), f.sent...)
+}
+
+func (f *fakeHomeserver) takeToDevice(userID, deviceID string) []matrixEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := userID + "|" + deviceID
+	out := f.toDevice[key]
+	delete(f.toDevice, key)
+	return out
+}
+
+func (f *fakeHomeserver) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeHomeserver) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	id, ok := f.identities[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	f.mu.Unlock()
+	if !ok {
+		f.writeJSON(w, http.StatusUnauthorized, map[string]string{"errcode": "M_UNKNOWN_TOKEN", "error": "unknown token"})
+		return
+	}
+	user, device := id[0], id[1]
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/_matrix/client/v3")
+	var parts []string
+	for _, p := range strings.Split(strings.Trim(path, "/"), "/") {
+		u, _ := url.PathUnescape(p)
+		parts = append(parts, u)
+	}
+	raw, _ := io.ReadAll(r.Body)
+	var body map[string]any
+	_ = json.Unmarshal(raw, &body)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case len(parts) == 7 && strings.HasPrefix(path, "/_matrix/client/v1/media/download/"):
+		data, ok := f.media[parts[5]+"/"+parts[6]]
+		if !ok {
+			f.writeJSON(w, 404, map[string]string{"errcode": "M_NOT_FOUND"})
+			return
+		}
+		_, _ = w.Write(data)
+	case path == "/account/whoami":
+		f.writeJSON(w, 200, map[string]string{"user_id": user, "device_id": device})
+	case path == "/sync":
+		var resp matrixSyncResponse
+		if len(f.syncQueue) > 0 {
+			resp = f.syncQueue[0]
+			f.syncQueue = f.syncQueue[1:]
+		} else {
+			f.mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			f.mu.Lock()
+		}
+		key := user + "|" + device
+		resp.ToDevice.Events = append(resp.ToDevice.Events, f.toDevice[key]...)
+		delete(f.toDevice, key)
+		resp.NextBatch = "s" + r.URL.Query().Get("since") + "x"
+		f.writeJSON(w, 200, resp)
+	case path == "/keys/upload":
+		if dk, ok := body["device_keys"].(map[string]any); ok {
+			if f.deviceKeys[user] == nil {
+				f.deviceKeys[user] = map[string]map[string]any{}
+			}
+			f.deviceKeys[user][device] = dk
+		}
+		if otks, ok := body["one_time_keys"].(map[string]any); ok {
+			if f.oneTimeKeys[user+"|"+device] == nil {
+				f.oneTimeKeys[user+"|"+device] = map[string]any{}
+			}
+			for k, v := range otks {
+				f.oneTimeKeys[user+"|"+device][k] = v
+			}
+		}
+		f.writeJSON(w, 200, map[string]any{"one_time_key_counts": map[string]int{"signed_curve25519": len(f.oneTimeKeys[user+"|"+device])}})
+	case path == "/keys/query":
+		req, _ := body["device_keys"].(map[string]any)
+		out := map[string]any{}
+		for u := range req {
+			devs := map[string]any{}
+			for d, keys := range f.deviceKeys[u] {
+				devs[d] = keys
+			}
+			out[u] = devs
+		}
+		f.writeJSON(w, 200, map[string]any{"device_keys": out})
+	case path == "/keys/claim":
+		req, _ := body["one_time_keys"].(map[string]any)
+		out := map[string]any{}
+		for u, devs := range req {
+			perUser := map[string]any{}
+			for d := range devs.(map[string]any) {
+				for keyID, key := range f.oneTimeKeys[u+"|"+d] {
+					perUser[d] = map[string]any{keyID: key}
+					delete(f.oneTimeKeys[u+"|"+d], keyID)
+					break
+				}
+			}
+			out[u] = perUser
+		}
+		f.writeJSON(w, 200, map[string]any{"one_time_keys": out})
+	case len(parts) == 3 && parts[0] == "sendToDevice":
+		msgs, _ := body["messages"].(map[string]any)
+		for u, devs := range msgs {
+			for d, content := range devs.(map[string]any) {
+				f.toDevice[u+"|"+d] = append(f.toDevice[u+"|"+d], matrixEvent{Type: parts[1], Sender: user, Content: content.(map[string]any)})
+			}
+		}
+		f.writeJSON(w, 200, map[string]any{})
+	case len(parts) == 2 && parts[0] == "join":
+		f.joined = append(f.joined, parts[1])
+		f.writeJSON(w, 200, map[string]any{"room_id": parts[1]})
+	case len(parts) == 3 && parts[0] == "rooms" && parts[2] == "leave":
+		f.left = append(f.left, parts[1])
+		f.writeJSON(w, 200, map[string]any{})
+	case len(parts) == 3 && parts[0] == "rooms" && parts[2] == "joined_members":
+		joined := map[string]any{}
+		for _, m := range f.members[parts[1]] {
+			joined[m] = map[string]any{}
+		}
+		f.writeJSON(w, 200, map[string]any{"joined": joined})
+	case len(parts) >= 4 && parts[0] == "rooms" && parts[2] == "state" && parts[3] == "m.room.encryption":
+		if f.encryptedRooms[parts[1]] {
+			f.writeJSON(w, 200, map[string]any{"algorithm": megolmAlgorithm})
+			return
+		}
+		f.writeJSON(w, 404, map[string]string{"errcode": "M_NOT_FOUND", "error": "not found"})
+	case len(parts) == 5 && parts[0] == "rooms" && parts[2] == "send":
+		f.nextEvent++
+		eventID := "$ev" + strconv.Itoa(f.nextEvent)
+		f.sent = append(f.sent, fakeSentEvent{Sender: user, RoomID: parts[1], Type: parts[3], EventID: eventID, Content: body})
+		f.writeJSON(w, 200, map[string]string{"event_id": eventID})
+	default:
+		f.t.Logf("fake homeserver: unhandled %s %s", r.Method, path)
+		f.writeJSON(w, 404, map[string]string{"errcode": "M_UNRECOGNIZED"})
+	}
+}
+
+func setMatrixTestMasterKey(t *testing.T) {
+	t.Helper()
+	t.Setenv("KAFCLAW_OAUTH_MASTER_KEY", base64.RawStdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+}
+
+func strPtr(s string) *string { return &s }
+
+func waitInbound(t *testing.T, msgBus *bus.MessageBus) *bus.InboundMessage {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(t.Context(), 3*time.Second)
+	defer cancel()
+	msg, err := msgBus.ConsumeInbound(ctx)
+	if err != nil {
+		t.Fatalf("expected inbound message: %v", err)
+	}
+	return msg
+}
+
+func TestEvaluateMatrixInvite(t *testing.T) {
+	cfg := config.MatrixConfig{
+		AllowFrom:   []string{"@alice:example.org"},
+		DmPolicy:    config.DmPolicyAllowlist,
+		GroupPolicy: config.GroupPolicyAllowlist,
+	}
+	cases := []struct {
+		name     string
+		mutate   func(*config.MatrixConfig)
+		inviter  string
+		room     string
+		direct   bool
+		accepted bool
+	}{
+		{name: "dm allowlisted", inviter: "@alice:example.org", direct: true, accepted: true},
+		{name: "dm stranger", inviter: "@mallory:example.org", direct: true, accepted: false},
+		{name: "dm pairing accepts", mutate: func(c *config.MatrixConfig) { c.DmPolicy = config.DmPolicyPairing }, inviter: "@mallory:example.org", direct: true, accepted: true},
+		{name: "group allowlisted inviter", inviter: "@alice:example.org", room: "!r:example.org", accepted: true},
+		{name: "group stranger", inviter: "@mallory:example.org", room: "!r:example.org", accepted: false},
+		{name: "group allowlisted room", mutate: func(c *config.MatrixConfig) { c.GroupAllowFrom = []string{"!ops:example.org"} }, inviter: "@mallory:example.org", room: "!ops:example.org", accepted: true},
+		{name: "group disabled", mutate: func(c *config.MatrixConfig) { c.GroupPolicy = config.GroupPolicyDisabled }, inviter: "@alice:example.org", room: "!r:example.org", accepted: false},
+		{name: "group open", mutate: func(c *config.MatrixConfig) { c.GroupPolicy = config.GroupPolicyOpen }, inviter: "@mallory:example.org", room: "!r:example.org", accepted: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := cfg
+			if tc.mutate != nil {
+				tc.mutate(&c)
+			}
+			got, reason := evaluateMatrixInvite(c, tc.inviter, tc.room, tc.direct)
+			if got != tc.accepted {
+				t.Fatalf("accepted=%v want %v (reason=%s)", got, tc.accepted, reason)
+			}
+		})
+	}
+}
+
+func TestStripMatrixReplyFallback(t *testing.T) {
+	got := stripMatrixReplyFallback("> <@a:x> original\n> more\n\nactual reply")
+	if got != "actual reply" {
+		t.Fatalf("unexpected body: %q", got)
+	}
+	if got := stripMatrixReplyFallback("plain"); got != "plain" {
+		t.Fatalf("unexpected body: %q", got)
+	}
+}
+
+func TestMatrixChannelSyncInvitesMessagesAndReactionApprovals(t *testing.T) {
+	setMatrixTestMasterKey(t)
+	hs := newFakeHomeserver(t)
+	hs.addUser("bot-token", "@bot:example.org", "BOTDEV")
+	hs.members["!ops:example.org"] = []string{"@bot:example.org", "@alice:example.org", "@bob:example.org"}
+
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	defer tl.Close()
+
+	// Initial sync: state only plus two invites. History must not be replayed.
+	initial := matrixSyncResponse{}
+	initial.Rooms.Invite = map[string]matrixInvitedRoom{
+		"!ops:example.org": {InviteState: matrixEventList{Events: []matrixEvent{{
+			Type: "m.room.member", Sender: "@alice:example.org", StateKey: strPtr("@bot:example.org"),
+			Content: map[string]any{"membership": "invite"},
+		}}}},
+		"!spam:example.org": {InviteState: matrixEventList{Events: []matrixEvent{{
+			Type: "m.room.member", Sender: "@mallory:example.org", StateKey: strPtr("@bot:example.org"),
+			Content: map[string]any{"membership": "invite"},
+		}}}},
+	}
+	initial.Rooms.Join = map[string]matrixJoinedRoom{
+		"!ops:example.org": {Timeline: matrixEventList{Events: []matrixEvent{{
+			Type: "m.room.message", EventID: "$old", Sender: "@alice:example.org",
+			Content: map[string]any{"msgtype": "m.text", "body": "old history @bot:example.org"},
+		}}}},
+	}
+	hs.queueSync(initial)
+
+	live := matrixSyncResponse{}
+	live.Rooms.Join = map[string]matrixJoinedRoom{
+		"!ops:example.org": {Timeline: matrixEventList{Events: []matrixEvent{{
+			Type: "m.room.message", EventID: "$m1", Sender: "@alice:example.org",
+			Content: map[string]any{
+				"msgtype":      "m.text",
+				"body":         "bot: deploy please",
+				"m.mentions":   map[string]any{"user_ids": []any{"@bot:example.org"}},
+				"m.relates_to": map[string]any{"rel_type": "m.thread", "event_id": "$root"},
+			},
+		}}}},
+	}
+	hs.queueSync(live)
+
+	msgBus := bus.NewMessageBus()
+	ch := NewMatrixChannel(config.MatrixConfig{
+		Enabled:         true,
+		Homeserver:      hs.srv.URL,
+		AccessToken:     "bot-token",
+		Encryption:      true,
+		CryptoStorePath: filepath.Join(t.TempDir(), "crypto.json"),
+		SyncTimeoutSec:  1,
+		SessionScope:    "thread",
+		AllowFrom:       []string{"@alice:example.org"},
+		DmPolicy:        config.DmPolicyPairing,
+		GroupPolicy:     config.GroupPolicyAllowlist,
+		RequireMention:  true,
+	}, msgBus, tl)
+	if err := ch.Start(t.Context()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer ch.Stop()
+
+	msg := waitInbound(t, msgBus)
+	if msg.Content != "bot: deploy please" || msg.ChatID != "!ops:example.org" || msg.ThreadID != "$root" {
+		t.Fatalf("unexpected inbound: %+v", msg)
+	}
+	if msg.IdempotencyKey != "matrix:$m1" {
+		t.Fatalf("unexpected idempotency key: %q", msg.IdempotencyKey)
+	}
+	if got := msg.Metadata[bus.MetaKeySessionScope]; got != "matrix:default:!ops:example.org:$root" {
+		t.Fatalf("unexpected session scope: %v", got)
+	}
+	hs.mu.Lock()
+	joined, left := append([]string(nil), hs.joined...), append([]string(nil), hs.left...)
+	deviceKeys := hs.deviceKeys["@bot:example.org"]["BOTDEV"]
+	hs.mu.Unlock()
+	if len(joined) != 1 || joined[0] != "!ops:example.org" {
+		t.Fatalf("expected join of allowlisted invite only, got %v", joined)
+	}
+	if len(left) != 1 || left[0] != "!spam:example.org" {
+		t.Fatalf("expected rejected invite, got %v", left)
+	}
+	if deviceKeys == nil {
+		t.Fatal("expected device keys to be uploaded")
+	}
+
+	// Approval prompt, then a reaction on it from an allowed sender.
+	if err := ch.Send(t.Context(), &bus.OutboundMessage{
+		Channel:      "matrix",
+		ChatID:       "!ops:example.org",
+		ThreadID:     "$root",
+		Content:      "Tool \"exec\" requires approval.",
+		Action:       "approval_request",
+		ActionParams: map[string]any{"approval_id": "appr-1"},
+	}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	sent := hs.sentEvents()
+	if len(sent) != 1 || sent[0].Type != "m.room.message" {
+		t.Fatalf("expected one cleartext message, got %+v", sent)
+	}
+	if body, _ := sent[0].Content["body"].(string); !strings.Contains(body, "React with") {
+		t.Fatalf("expected reaction hint in body: %q", body)
+	}
+	rel, _ := sent[0].Content["m.relates_to"].(map[string]any)
+	if rel["rel_type"] != "m.thread" || rel["event_id"] != "$root" {
+		t.Fatalf("expected thread relation, got %v", rel)
+	}
+
+	reactions := matrixSyncResponse{}
+	reactions.Rooms.Join = map[string]matrixJoinedRoom{
+		"!ops:example.org": {Timeline: matrixEventList{Events: []matrixEvent{
+			{Type: "m.reaction", EventID: "$r0", Sender: "@mallory:example.org", Content: map[string]any{
+				"m.relates_to": map[string]any{"rel_type": "m.annotation", "event_id": sent[0].EventID, "key": "✅"},
+			}},
+			{Type: "m.reaction", EventID: "$r1", Sender: "@alice:example.org", Content: map[string]any{
+				"m.relates_to": map[string]any{"rel_type": "m.annotation", "event_id": sent[0].EventID, "key": "👍️"},
+			}},
+		}}},
+	}
+	hs.queueSync(reactions)
+	got := waitInbound(t, msgBus)
+	if got.Content != "approve:appr-1" || got.SenderID != "@alice:example.org" {
+		t.Fatalf("unexpected approval inbound: %+v", got)
+	}
+}
+
+func TestMatrixAudioMessagesAreDownloaded(t *testing.T) {
+	dir := useTempInboundAudioDir(t)
+	hs := newFakeHomeserver(t)
+	hs.addUser("bot-token", "@bot:example.org", "BOTDEV")
+	hs.members["!dm:example.org"] = []string{"@bot:example.org", "@alice:example.org"}
+	hs.media["example.org/plain"] = []byte("plain-voice")
+
+	key := bytes.Repeat([]byte{3}, 32)
+	iv := append(bytes.Repeat([]byte{9}, 8), make([]byte, 8)...)
+	block, _ := aes.NewCipher(key)
+	ciphertext := make([]byte, len("secret-voice"))
+	cipher.NewCTR(block, iv).XORKeyStream(ciphertext, []byte("secret-voice"))
+	sum := sha256.Sum256(ciphertext)
+	hs.media["example.org/enc"] = ciphertext
+
+	msgBus := bus.NewMessageBus()
+	ch := NewMatrixChannel(config.MatrixConfig{
+		Enabled:     true,
+		Homeserver:  hs.srv.URL,
+		AccessToken: "bot-token",
+		UserID:      "@bot:example.org",
+		AllowFrom:   []string{"@alice:example.org"},
+		DmPolicy:    config.DmPolicyAllowlist,
+	}, msgBus, nil)
+
+	ch.handleMessageEvent(t.Context(), "!dm:example.org", matrixEvent{
+		Type: "m.room.message", EventID: "$a1", Sender: "@alice:example.org",
+		Content: map[string]any{"msgtype": "m.audio", "body": "Voice message", "url": "mxc://example.org/plain", "info": map[string]any{"mimetype": "audio/ogg"}},
+	})
+	msg := waitInbound(t, msgBus)
+	if msg.Content != "[Audio Message]" || len(msg.Media) != 1 || filepath.Dir(msg.Media[0]) != dir || filepath.Ext(msg.Media[0]) != ".ogg" {
+		t.Fatalf("unexpected audio inbound: %+v", msg)
+	}
+	if data, _ := os.ReadFile(msg.Media[0]); string(data) != "plain-voice" {
+		t.Fatalf("stored audio = %q", data)
+	}
+
+	ch.handleMessageEvent(t.Context(), "!dm:example.org", matrixEvent{
+		Type: "m.room.message", EventID: "$a2", Sender: "@alice:example.org",
+		Content: map[string]any{"msgtype": "m.audio", "body": "note.m4a", "file": map[string]any{
+			"url":    "mxc://example.org/enc",
+			"key":    map[string]any{"kty": "oct", "alg": "A256CTR", "k": base64.RawURLEncoding.EncodeToString(key)},
+			"iv":     matrixB64(iv),
+			"hashes": map[string]any{"sha256": matrixB64(sum[:])},
+		}},
+	})
+	msg = waitInbound(t, msgBus)
+	if len(msg.Media) != 1 {
+		t.Fatalf("expected decrypted voice note, got %+v", msg)
+	}
+	if data, _ := os.ReadFile(msg.Media[0]); string(data) != "secret-voice" {
+		t.Fatalf("decrypted audio = %q", data)
+	}
+}
+
+func TestMatrixChannelEncryptedRoomRoundTrip(t *testing.T) {
+	setMatrixTestMasterKey(t)
+	hs := newFakeHomeserver(t)
+	hs.addUser("bot-token", "@bot:example.org", "BOTDEV")
+	hs.addUser("alice-token", "@alice:example.org", "ALICEDEV")
+	room := "!secret:example.org"
+	hs.members[room] = []string{"@bot:example.org", "@alice:example.org"}
+	hs.encryptedRooms[room] = true
+
+	// Alice's client, backed by the same crypto implementation.
+	aliceClient := newMatrixClient(hs.srv.URL, "alice-token")
+	alice, err := openMatrixCrypto(filepath.Join(t.TempDir(), "alice.json"), aliceClient, "@alice:example.org", "ALICEDEV")
+	if err != nil {
+		t.Fatalf("alice crypto: %v", err)
+	}
+	if err := alice.ensureKeys(t.Context(), -1); err != nil {
+		t.Fatalf("alice keys: %v", err)
+	}
+
+	msgBus := bus.NewMessageBus()
+	ch := NewMatrixChannel(config.MatrixConfig{
+		Enabled:         true,
+		Homeserver:      hs.srv.URL,
+		AccessToken:     "bot-token",
+		Encryption:      true,
+		CryptoStorePath: filepath.Join(t.TempDir(), "bot.json"),
+		SyncTimeoutSec:  1,
+		AllowFrom:       []string{"@alice:example.org"},
+		DmPolicy:        config.DmPolicyAllowlist,
+		GroupPolicy:     config.GroupPolicyAllowlist,
+	}, msgBus, nil)
+	hs.queueSync(matrixSyncResponse{}) // initial catch-up
+	if err := ch.Start(t.Context()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer ch.Stop()
+
+	// Alice -> bot: shares her room key via to-device, then sends a message.
+	sealed, err := alice.encryptRoomEvent(t.Context(), room, "m.room.message", map[string]any{"msgtype": "m.text", "body": "top secret"})
+	if err != nil {
+		t.Fatalf("alice encrypt: %v", err)
+	}
+	incoming := matrixSyncResponse{}
+	incoming.Rooms.Join = map[string]matrixJoinedRoom{
+		room: {
+			State:    matrixEventList{Events: []matrixEvent{{Type: "m.room.encryption", StateKey: strPtr(""), Content: map[string]any{"algorithm": megolmAlgorithm}}}},
+			Timeline: matrixEventList{Events: []matrixEvent{{Type: "m.room.encrypted", EventID: "$enc1", Sender: "@alice:example.org", Content: sealed}}},
+		},
+	}
+	hs.queueSync(incoming)
+	msg := waitInbound(t, msgBus)
+	if msg.Content != "top secret" {
+		t.Fatalf("unexpected decrypted content: %q", msg.Content)
+	}
+
+	// Bot -> Alice: reply is Megolm-encrypted and decryptable by Alice.
+	if err := ch.Send(t.Context(), &bus.OutboundMessage{Channel: "matrix", ChatID: room, Content: "ack"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	sent := hs.sentEvents()
+	if len(sent) == 0 || sent[len(sent)-1].Type != "m.room.encrypted" {
+		t.Fatalf("expected encrypted send, got %+v", sent)
+	}
+	reply := sent[len(sent)-1]
+	if _, leaked := reply.Content["body"]; leaked {
+		t.Fatal("cleartext body leaked into encrypted event")
+	}
+	for _, ev := range hs.takeToDevice("@alice:example.org", "ALICEDEV") {
+		if err := alice.handleToDevice(ev); err != nil {
+			t.Fatalf("alice to-device: %v", err)
+		}
+	}
+	dec, err := alice.decryptRoomEvent(room, matrixEvent{Type: reply.Type, EventID: reply.EventID, Sender: "@bot:example.org", Content: reply.Content})
+	if err != nil {
+		t.Fatalf("alice decrypt: %v", err)
+	}
+	if dec.Type != "m.room.message" || dec.Content["body"] != "ack" {
+		t.Fatalf("unexpected decrypted reply: %+v", dec)
+	}
+}
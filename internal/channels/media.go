@@ -0,0 +1,170 @@
+package channels
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/voice"
+)
+
+// maxInlineMediaBytes bounds local files sent inline to a bridge.
+const maxInlineMediaBytes = 10 << 20
+
+// isLocalAudioFile reports whether a media entry is an audio file on disk
+// (as produced by the voice pipeline) rather than a remote URL.
+func isLocalAudioFile(media string) bool {
+	media = strings.TrimSpace(media)
+	if media == "" || strings.Contains(media, "://") {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(media)) {
+	case ".ogg", ".oga", ".opus", ".mp3", ".m4a", ".wav":
+	default:
+		return false
+	}
+	return isLocalMediaFile(media)
+}
+
+// isLocalMediaFile reports whether a media entry is a regular file on disk,
+// such as a voice reply or a code_run artifact, small enough to send inline.
+func isLocalMediaFile(media string) bool {
+	media = strings.TrimSpace(media)
+	if media == "" || strings.Contains(media, "://") {
+		return false
+	}
+	info, err := os.Stat(media)
+	return err == nil && info.Mode().IsRegular() && info.Size() <= maxInlineMediaBytes
+}
+
+// splitLocalMedia separates remote media URLs, which bridges download
+// themselves, from local files, which are sent inline as base64
+// "media_files" entries.
+func splitLocalMedia(media []string) (remote []string, files []map[string]string) {
+	for _, m := range media {
+		if !isLocalMediaFile(m) {
+			if strings.TrimSpace(m) != "" {
+				remote = append(remote, m)
+			}
+			continue
+		}
+		data, err := os.ReadFile(m)
+		if err != nil {
+			continue
+		}
+		files = append(files, map[string]string{
+			"filename":       filepath.Base(m),
+			"content_base64": base64.StdEncoding.EncodeToString(data),
+		})
+	}
+	return remote, files
+}
+
+// maxInboundAudioBytes bounds a single inbound voice note (Whisper's upload
+// limit).
+const maxInboundAudioBytes = 25 << 20
+
+// InboundAudio is a voice note delivered with an inbound message. Channels
+// store it only after the sender passed the access check.
+type InboundAudio struct {
+	Filename string
+	Data     []byte
+}
+
+// inboundAudioDir is where inbound voice notes are stored, next to the
+// WhatsApp downloads, so the agent's voice pipeline can transcribe them.
+var inboundAudioDir = func() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kafclaw", "workspace", "media", "audio")
+}
+
+// saveInboundAudio writes voice notes to the media directory and returns
+// their paths. Entries that are not audio, are empty or too large are skipped.
+func saveInboundAudio(channel, messageID string, audio []InboundAudio) []string {
+	if len(audio) == 0 {
+		return nil
+	}
+	dir := inboundAudioDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		slog.Warn("inbound audio dir unavailable", "channel", channel, "error", err)
+		return nil
+	}
+	base := sanitizeMediaName(messageID)
+	if base == "" {
+		base = fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	var paths []string
+	for i, a := range audio {
+		ext := strings.ToLower(filepath.Ext(strings.TrimSpace(a.Filename)))
+		if !voice.IsAudio("x"+ext) || len(a.Data) == 0 || len(a.Data) > maxInboundAudioBytes {
+			continue
+		}
+		path := filepath.Join(dir, fmt.Sprintf("%s-%s-%d%s", channel, base, i, ext))
+		if err := os.WriteFile(path, a.Data, 0o644); err != nil {
+			slog.Warn("inbound audio not saved", "channel", channel, "error", err)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// inboundAudioMimeExts maps audio MIME types to a file extension the voice
+// pipeline recognizes.
+var inboundAudioMimeExts = map[string]string{
+	"audio/ogg": ".ogg", "audio/opus": ".opus", "audio/mpeg": ".mp3", "audio/mp3": ".mp3",
+	"audio/mp4": ".m4a", "audio/x-m4a": ".m4a", "audio/m4a": ".m4a", "audio/webm": ".webm",
+	"audio/wav": ".wav", "audio/x-wav": ".wav", "audio/wave": ".wav", "audio/flac": ".flac",
+	"audio/aac": ".aac", "audio/amr": ".amr",
+}
+
+// inboundAudioFilename keeps name when it already has an audio extension and
+// otherwise derives one from mimetype.
+func inboundAudioFilename(name, mimetype string) string {
+	name = filepath.Base(strings.TrimSpace(name))
+	if name == "." || name == string(filepath.Separator) {
+		name = ""
+	}
+	if voice.IsAudio(name) {
+		return name
+	}
+	mimetype = strings.ToLower(strings.TrimSpace(mimetype))
+	if i := strings.IndexByte(mimetype, ';'); i >= 0 {
+		mimetype = strings.TrimSpace(mimetype[:i])
+	}
+	if name == "" {
+		name = "audio"
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name)) + inboundAudioMimeExts[mimetype]
+}
+
+// sanitizeMediaName keeps a message ID usable as a file name.
+func sanitizeMediaName(s string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() > 64 {
+		return b.String()[:64]
+	}
+	return b.String()
+}
+
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -63,6 +63,10 @@ func (c *MSTeamsChannel) Send(ctx context.Context, msg *bus.OutboundMessage) err
 	if strings.TrimSpace(ac.OutboundURL) == "" {
 		return nil
 	}
+	card := msg.Card
+	if id := approvalPromptID(msg); id != "" && len(card) == 0 {
+		card = teamsApprovalCard(msg.ActionParams, id)
+	}
 	body, _ := json.Marshal(map[string]any{
 		"channel":             "msteams",
 		"account_id":          accountID,
@@ -70,7 +74,7 @@ func (c *MSTeamsChannel) Send(ctx context.Context, msg *bus.OutboundMessage) err
 		"thread_id":           strings.TrimSpace(msg.ThreadID),
 		"content":             msg.Content,
 		"media_urls":          msg.MediaURLs,
-		"card":                msg.Card,
+		"card":                card,
 		"action":              strings.TrimSpace(msg.Action),
 		"action_params":       msg.ActionParams,
 		"poll_question":       strings.TrimSpace(msg.PollQuestion),
@@ -97,19 +101,54 @@ func (c *MSTeamsChannel) Send(ctx context.Context, msg *bus.OutboundMessage) err
 	return nil
 }
 
+// teamsApprovalCard renders Approve/Deny buttons for an approval prompt; the
+// prompt text travels as the message text. Action.Submit posts its data as
+// the activity value, whose "text" the bridge forwards as the message.
+func teamsApprovalCard(params map[string]any, approvalID string) map[string]any {
+	facts := []map[string]any{{"title": "Approval", "value": approvalID}}
+	if tool, _ := params["tool"].(string); tool != "" {
+		facts = append(facts, map[string]any{"title": "Tool", "value": tool})
+	}
+	if tier, ok := params["tier"]; ok {
+		facts = append(facts, map[string]any{"title": "Tier", "value": fmt.Sprint(tier)})
+	}
+	submit := func(verdict, title, style string) map[string]any {
+		return map[string]any{
+			"type":  "Action.Submit",
+			"title": title,
+			"style": style,
+			"data":  map[string]any{"text": verdict + ":" + approvalID, "kafclaw_approval": approvalID},
+		}
+	}
+	return map[string]any{
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body": []map[string]any{
+			{"type": "TextBlock", "text": "Approval required", "weight": "Bolder", "wrap": true},
+			{"type": "FactSet", "facts": facts},
+		},
+		"actions": []map[string]any{
+			submit("approve", "Approve", "positive"),
+			submit("deny", "Deny", "destructive"),
+		},
+	}
+}
+
 func (c *MSTeamsChannel) HandleInbound(senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool) error {
-	return c.HandleInboundWithContextAndHints("default", senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, "", "", 0, 0)
+	return c.HandleInboundWithContextAndHints("default", senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, "", "", 0, 0, nil)
 }
 
 func (c *MSTeamsChannel) HandleInboundWithAccount(accountID, senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool) error {
-	return c.HandleInboundWithContextAndHints(accountID, senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, "", "", 0, 0)
+	return c.HandleInboundWithContextAndHints(accountID, senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, "", "", 0, 0, nil)
 }
 
 func (c *MSTeamsChannel) HandleInboundWithContext(accountID, senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool, groupID, channelID string) error {
-	return c.HandleInboundWithContextAndHints(accountID, senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, groupID, channelID, 0, 0)
+	return c.HandleInboundWithContextAndHints(accountID, senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, groupID, channelID, 0, 0, nil)
 }
 
-func (c *MSTeamsChannel) HandleInboundWithContextAndHints(accountID, senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool, groupID, channelID string, historyLimit, dmHistoryLimit int) error {
+// HandleInboundWithContextAndHints publishes an inbound Teams message. Voice
+// notes forwarded by the bridge are stored locally for transcription.
+func (c *MSTeamsChannel) HandleInboundWithContextAndHints(accountID, senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool, groupID, channelID string, historyLimit, dmHistoryLimit int, audio []InboundAudio) error {
 	ac := c.teamsAccountConfig(accountID)
 	targetAllowlistMode := isGroup && (ac.GroupPolicy == config.GroupPolicyAllowlist || strings.TrimSpace(string(ac.GroupPolicy)) == "") && hasTeamsGroupTargetEntries(ac.GroupAllowFrom)
 	groupAllowFrom := ac.GroupAllowFrom
@@ -176,6 +215,7 @@ func (c *MSTeamsChannel) HandleInboundWithContextAndHints(accountID, senderID, c
 		ThreadID:  strings.TrimSpace(threadID),
 		MessageID: strings.TrimSpace(messageID),
 		Content:   text,
+		Media:     saveInboundAudio(c.Name(), messageID, audio),
 		Metadata:  metadata,
 	})
 	return nil
@@ -33,11 +33,12 @@ type PendingPairing struct {
 
 // PairingService manages pending sender approvals in timeline settings.
 type PairingService struct {
-	store pairingStore
+	store   pairingStore
+	persons personStore
 }
 
 func NewPairingService(timeSvc *timeline.TimelineService) *PairingService {
-	return &PairingService{store: timeSvc}
+	return &PairingService{store: timeSvc, persons: timeSvc}
 }
 
 type pairingStore interface {
@@ -239,6 +240,16 @@ func addChannelAllowFrom(cfg *config.Config, channel, senderID string) error {
 		cfg.Channels.MSTeams.AllowFrom = appendUnique(cfg.Channels.MSTeams.AllowFrom, senderID)
 	case "whatsapp":
 		cfg.Channels.WhatsApp.AllowFrom = appendUnique(cfg.Channels.WhatsApp.AllowFrom, senderID)
+	case "matrix":
+		cfg.Channels.Matrix.AllowFrom = appendUnique(cfg.Channels.Matrix.AllowFrom, senderID)
+	case "webhook":
+		// Webhook allowlists are per account; the approved sender is added to
+		// every account that uses pairing.
+		for i := range cfg.Channels.Webhook.Accounts {
+			if cfg.Channels.Webhook.Accounts[i].DmPolicy == config.DmPolicyPairing {
+				cfg.Channels.Webhook.Accounts[i].AllowFrom = appendUnique(cfg.Channels.Webhook.Accounts[i].AllowFrom, senderID)
+			}
+		}
 	default:
 		return fmt.Errorf("unsupported channel: %s", channel)
 	}
@@ -271,6 +282,10 @@ func normalizeAllowEntryForChannel(channel, raw string) string {
 		v = strings.TrimPrefix(strings.ToLower(v), "msteams:")
 		v = strings.TrimPrefix(v, "teams:")
 		v = strings.TrimPrefix(v, "user:")
+	case "matrix":
+		v = strings.TrimPrefix(v, "matrix:")
+	case "webhook":
+		v = strings.TrimPrefix(v, "webhook:")
 	default:
 		return strings.TrimSpace(raw)
 	}
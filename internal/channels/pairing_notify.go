@@ -34,6 +34,14 @@ func NotifyPairingApproved(ctx context.Context, cfg *config.Config, entry *Pendi
 	case "whatsapp":
 		// WhatsApp pairing notifications remain handled by existing channel flow.
 		return nil
+	case "webhook":
+		// Pairing replies go to the account callback; there is no per-sender
+		// address to notify.
+		return nil
+	case "matrix":
+		// Encrypted rooms need the running gateway's device session; the sender
+		// is told on their next message.
+		return nil
 	default:
 		return fmt.Errorf("unsupported channel: %s", entry.Channel)
 	}
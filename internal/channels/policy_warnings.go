@@ -7,7 +7,7 @@ import (
 	"github.com/KafClaw/KafClaw/internal/config"
 )
 
-// CollectUnsafeGroupPolicyWarnings reports risky Slack/Teams group policy states.
+// CollectUnsafeGroupPolicyWarnings reports risky Slack/Teams/Matrix group policy states.
 func CollectUnsafeGroupPolicyWarnings(cfg *config.Config) []string {
 	if cfg == nil {
 		return nil
@@ -25,6 +25,12 @@ func CollectUnsafeGroupPolicyWarnings(cfg *config.Config) []string {
 		cfg.Channels.MSTeams.RequireMention,
 		cfg.Channels.MSTeams.GroupAllowFrom,
 	)...)
+	out = append(out, collectUnsafeGroupPolicyWarningsForChannel(
+		"matrix",
+		cfg.Channels.Matrix.GroupPolicy,
+		cfg.Channels.Matrix.RequireMention,
+		cfg.Channels.Matrix.GroupAllowFrom,
+	)...)
 	return out
 }
 
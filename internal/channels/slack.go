@@ -63,6 +63,16 @@ func (c *SlackChannel) Send(ctx context.Context, msg *bus.OutboundMessage) error
 	if strings.TrimSpace(ac.OutboundURL) == "" {
 		return nil
 	}
+	mediaURLs, mediaFiles := splitLocalMedia(msg.MediaURLs)
+	card, action := msg.Card, strings.TrimSpace(msg.Action)
+	if id := approvalPromptID(msg); id != "" {
+		// Approval prompts are posted as a Block Kit card rather than run
+		// as a bridge action; button clicks come back as approve:/deny:.
+		action = ""
+		if len(card) == 0 {
+			card = slackApprovalCard(msg.Content, id)
+		}
+	}
 	body, _ := json.Marshal(map[string]any{
 		"channel":             "slack",
 		"account_id":          accountID,
@@ -72,9 +82,10 @@ func (c *SlackChannel) Send(ctx context.Context, msg *bus.OutboundMessage) error
 		"stream_mode":         strings.TrimSpace(ac.StreamMode),
 		"stream_chunk_chars":  ac.StreamChunkChars,
 		"content":             msg.Content,
-		"media_urls":          msg.MediaURLs,
-		"card":                msg.Card,
-		"action":              strings.TrimSpace(msg.Action),
+		"media_urls":          mediaURLs,
+		"media_files":         mediaFiles,
+		"card":                card,
+		"action":              action,
 		"action_params":       msg.ActionParams,
 		"poll_question":       strings.TrimSpace(msg.PollQuestion),
 		"poll_options":        msg.PollOptions,
@@ -100,15 +111,44 @@ func (c *SlackChannel) Send(ctx context.Context, msg *bus.OutboundMessage) error
 	return nil
 }
 
+// slackApprovalActionPrefix prefixes the action_id of approval buttons; the
+// bridge forwards their value ("approve:<id>" / "deny:<id>") as the text.
+const slackApprovalActionPrefix = "kafclaw_approval_"
+
+// slackApprovalCard renders an approval prompt with Approve/Deny buttons.
+func slackApprovalCard(text, approvalID string) map[string]any {
+	button := func(verdict, label, style string) map[string]any {
+		return map[string]any{
+			"type":      "button",
+			"action_id": slackApprovalActionPrefix + verdict,
+			"text":      map[string]any{"type": "plain_text", "text": label},
+			"style":     style,
+			"value":     verdict + ":" + approvalID,
+		}
+	}
+	return map[string]any{
+		"text": text,
+		"blocks": []map[string]any{
+			{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": text}},
+			{"type": "actions", "block_id": "kafclaw_approval:" + approvalID, "elements": []map[string]any{
+				button("approve", "Approve", "primary"),
+				button("deny", "Deny", "danger"),
+			}},
+		},
+	}
+}
+
 func (c *SlackChannel) HandleInbound(senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool) error {
-	return c.HandleInboundWithAccountAndHints("default", senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, 0, 0)
+	return c.HandleInboundWithAccountAndHints("default", senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, 0, 0, nil)
 }
 
 func (c *SlackChannel) HandleInboundWithAccount(accountID, senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool) error {
-	return c.HandleInboundWithAccountAndHints(accountID, senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, 0, 0)
+	return c.HandleInboundWithAccountAndHints(accountID, senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, 0, 0, nil)
 }
 
-func (c *SlackChannel) HandleInboundWithAccountAndHints(accountID, senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool, historyLimit, dmHistoryLimit int) error {
+// HandleInboundWithAccountAndHints publishes an inbound Slack message. Voice
+// notes forwarded by the bridge are stored locally for transcription.
+func (c *SlackChannel) HandleInboundWithAccountAndHints(accountID, senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool, historyLimit, dmHistoryLimit int, audio []InboundAudio) error {
 	ac := c.slackAccountConfig(accountID)
 	decision := EvaluateAccess(AccessContext{
 		SenderID:     senderID,
@@ -161,6 +201,7 @@ func (c *SlackChannel) HandleInboundWithAccountAndHints(accountID, senderID, cha
 		ThreadID:  strings.TrimSpace(threadID),
 		MessageID: strings.TrimSpace(messageID),
 		Content:   text,
+		Media:     saveInboundAudio(c.Name(), messageID, audio),
 		Metadata:  metadata,
 	})
 	return nil
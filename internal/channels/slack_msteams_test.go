@@ -3,10 +3,13 @@ package channels
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -114,6 +117,64 @@ func TestSlackHandleInboundSetsRoomSessionScope(t *testing.T) {
 	}
 }
 
+// useTempInboundAudioDir points inbound voice note storage at a temp dir.
+func useTempInboundAudioDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	prev := inboundAudioDir
+	inboundAudioDir = func() string { return dir }
+	t.Cleanup(func() { inboundAudioDir = prev })
+	return dir
+}
+
+func TestSlackAndTeamsInboundAudioSavedAfterAccessCheck(t *testing.T) {
+	dir := useTempInboundAudioDir(t)
+	msgBus := bus.NewMessageBus()
+	slack := NewSlackChannel(config.SlackConfig{
+		Enabled:   true,
+		AllowFrom: []string{"U123"},
+		DmPolicy:  config.DmPolicyAllowlist,
+	}, msgBus, nil)
+	audio := []InboundAudio{{Filename: "clip.webm", Data: []byte("voice")}, {Filename: "notes.pdf", Data: []byte("pdf")}}
+
+	if err := slack.HandleInboundWithAccountAndHints("default", "U999", "D1", "", "1700.1", "[Audio Message]", false, false, 0, 0, audio); err != nil {
+		t.Fatalf("denied inbound: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("audio from a denied sender was stored: %v", entries)
+	}
+
+	if err := slack.HandleInboundWithAccountAndHints("default", "U123", "D1", "", "1700.2", "[Audio Message]", false, false, 0, 0, audio); err != nil {
+		t.Fatalf("handle inbound: %v", err)
+	}
+	msg, err := msgBus.ConsumeInbound(t.Context())
+	if err != nil {
+		t.Fatalf("consume inbound: %v", err)
+	}
+	if len(msg.Media) != 1 || filepath.Dir(msg.Media[0]) != dir || filepath.Ext(msg.Media[0]) != ".webm" {
+		t.Fatalf("expected one stored voice note, got %v", msg.Media)
+	}
+	if data, _ := os.ReadFile(msg.Media[0]); string(data) != "voice" {
+		t.Fatalf("stored audio = %q", data)
+	}
+
+	teams := NewMSTeamsChannel(config.MSTeamsConfig{
+		Enabled:   true,
+		AllowFrom: []string{"A123"},
+		DmPolicy:  config.DmPolicyAllowlist,
+	}, msgBus, nil)
+	if err := teams.HandleInboundWithContextAndHints("default", "A123", "conv1", "", "m1", "", false, false, "", "", 0, 0, []InboundAudio{{Filename: "voice.m4a", Data: []byte("m4a")}}); err != nil {
+		t.Fatalf("teams inbound: %v", err)
+	}
+	msg, err = msgBus.ConsumeInbound(t.Context())
+	if err != nil {
+		t.Fatalf("consume inbound: %v", err)
+	}
+	if len(msg.Media) != 1 || !strings.HasPrefix(filepath.Base(msg.Media[0]), "msteams-m1-") {
+		t.Fatalf("expected stored teams voice note, got %v", msg.Media)
+	}
+}
+
 func TestSlackSendUsesOutboundBridge(t *testing.T) {
 	var got map[string]any
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -709,3 +770,124 @@ func TestMSTeamsSessionScopeUserMode(t *testing.T) {
 		t.Fatalf("unexpected scope: %q", scope)
 	}
 }
+
+func TestSlackSendInlinesLocalVoiceReplies(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	reply := filepath.Join(t.TempDir(), "reply.mp3")
+	if err := os.WriteFile(reply, []byte("mp3"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	chart := filepath.Join(t.TempDir(), "chart.png")
+	if err := os.WriteFile(chart, []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ch := NewSlackChannel(config.SlackConfig{Enabled: true, OutboundURL: srv.URL}, bus.NewMessageBus(), nil)
+	err := ch.Send(context.Background(), &bus.OutboundMessage{
+		Channel:   "slack",
+		ChatID:    "C123",
+		Content:   "hello",
+		MediaURLs: []string{"https://files.example.com/a.png", reply, chart},
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	urls, _ := got["media_urls"].([]any)
+	files, _ := got["media_files"].([]any)
+	if len(urls) != 1 || urls[0] != "https://files.example.com/a.png" || len(files) != 2 {
+		t.Fatalf("expected remote url and one inline file, got %#v", got)
+	}
+	file, _ := files[0].(map[string]any)
+	if file["filename"] != "reply.mp3" || file["content_base64"] != "bXAz" {
+		t.Fatalf("unexpected inline file %#v", file)
+	}
+	if file, _ := files[1].(map[string]any); file["filename"] != "chart.png" {
+		t.Fatalf("expected local artifact inline, got %#v", file)
+	}
+}
+
+func TestSlackSendRendersApprovalCard(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ch := NewSlackChannel(config.SlackConfig{Enabled: true, OutboundURL: srv.URL}, bus.NewMessageBus(), nil)
+	err := ch.Send(context.Background(), &bus.OutboundMessage{
+		Channel:      "slack",
+		ChatID:       "C123",
+		Content:      "Tool \"exec\" (tier 2) requires approval.",
+		Action:       "approval_request",
+		ActionParams: map[string]any{"approval_id": "ap-1"},
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if got["action"] != "" {
+		t.Fatalf("expected approval prompt to be posted, not run as action: %#v", got["action"])
+	}
+	card, _ := got["card"].(map[string]any)
+	blocks, _ := card["blocks"].([]any)
+	if len(blocks) != 2 {
+		t.Fatalf("expected section and actions blocks, got %#v", card)
+	}
+	actions, _ := blocks[1].(map[string]any)
+	elements, _ := actions["elements"].([]any)
+	var values []string
+	for _, e := range elements {
+		btn, _ := e.(map[string]any)
+		values = append(values, fmt.Sprint(btn["action_id"], "=", btn["value"]))
+	}
+	if strings.Join(values, ",") != "kafclaw_approval_approve=approve:ap-1,kafclaw_approval_deny=deny:ap-1" {
+		t.Fatalf("unexpected approval buttons: %v", values)
+	}
+}
+
+func TestMSTeamsSendRendersApprovalCard(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ch := NewMSTeamsChannel(config.MSTeamsConfig{Enabled: true, OutboundURL: srv.URL}, bus.NewMessageBus(), nil)
+	err := ch.Send(context.Background(), &bus.OutboundMessage{
+		Channel:      "msteams",
+		ChatID:       "conv-1",
+		Content:      "Tool \"exec\" (tier 2) requires approval.",
+		Action:       "approval_request",
+		ActionParams: map[string]any{"approval_id": "ap-2", "tool": "exec", "tier": 2},
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if got["content"] != "Tool \"exec\" (tier 2) requires approval." {
+		t.Fatalf("expected prompt text as content, got %#v", got["content"])
+	}
+	card, _ := got["card"].(map[string]any)
+	if card["type"] != "AdaptiveCard" {
+		t.Fatalf("expected adaptive card, got %#v", card)
+	}
+	actions, _ := card["actions"].([]any)
+	if len(actions) != 2 {
+		t.Fatalf("expected approve and deny actions, got %#v", actions)
+	}
+	for i, want := range []string{"approve:ap-2", "deny:ap-2"} {
+		act, _ := actions[i].(map[string]any)
+		data, _ := act["data"].(map[string]any)
+		if act["type"] != "Action.Submit" || data["text"] != want || data["kafclaw_approval"] != "ap-2" {
+			t.Fatalf("unexpected action %d: %#v", i, act)
+		}
+	}
+}
@@ -0,0 +1,443 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/KafClaw/KafClaw/internal/voice"
+)
+
+// Headers used to sign webhook requests in both directions.
+const (
+	WebhookTimestampHeader = "X-KafClaw-Timestamp"
+	WebhookSignatureHeader = "X-KafClaw-Signature"
+	WebhookAccountHeader   = "X-KafClaw-Account"
+)
+
+// WebhookError is returned by HandleSignedInbound with the HTTP status the
+// gateway should answer with.
+type WebhookError struct {
+	Status  int
+	Message string
+}
+
+func (e *WebhookError) Error() string { return e.Message }
+
+// WebhookChannel accepts HMAC-signed JSON posts from arbitrary systems and
+// delivers replies to a per-account callback URL.
+type WebhookChannel struct {
+	BaseChannel
+	config   config.WebhookConfig
+	timeline *timeline.TimelineService
+	client   *http.Client
+
+	// backoff returns the next retry time after a failed attempt.
+	backoff func(attempts int) time.Time
+
+	mu     sync.Mutex
+	seen   map[string]time.Time // signature -> expiry
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewWebhookChannel(cfg config.WebhookConfig, messageBus *bus.MessageBus, tl *timeline.TimelineService) *WebhookChannel {
+	return &WebhookChannel{
+		BaseChannel: BaseChannel{Bus: messageBus},
+		config:      cfg,
+		timeline:    tl,
+		client:      &http.Client{Timeout: 15 * time.Second},
+		backoff:     bus.DeliveryBackoff,
+		seen:        map[string]time.Time{},
+	}
+}
+
+func (c *WebhookChannel) Name() string { return "webhook" }
+
+func (c *WebhookChannel) Start(ctx context.Context) error {
+	if !c.config.Enabled {
+		return nil
+	}
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.Bus.Subscribe(c.Name(), func(msg *bus.OutboundMessage) {
+		// Dispatch is synchronous; retries must not block other channels.
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.deliver(ctx, msg)
+		}()
+	})
+	return nil
+}
+
+// Stop abandons pending retries; their tasks stay in their last recorded
+// delivery state.
+func (c *WebhookChannel) Stop() error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+	return nil
+}
+
+// deliver sends one outbound message, retrying transient failures with the
+// shared delivery backoff. Only the final outcome is written to the timeline
+// so the delivery worker does not re-dispatch a message still being retried.
+func (c *WebhookChannel) deliver(ctx context.Context, msg *bus.OutboundMessage) {
+	maxRetries := c.maxRetries(msg.ChatID)
+	for attempt := 0; ; attempt++ {
+		err := c.Send(ctx, msg)
+		if err == nil {
+			c.recordDelivery(msg, timeline.DeliverySent, "")
+			return
+		}
+		reason, cls := classifyDeliveryError(err)
+		if cls != deliveryTransient || attempt+1 >= maxRetries {
+			c.recordDelivery(msg, timeline.DeliveryFailed, reason)
+			return
+		}
+		wait := time.Until(c.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (c *WebhookChannel) recordDelivery(msg *bus.OutboundMessage, status, reason string) {
+	if c.timeline == nil || strings.TrimSpace(msg.TaskID) == "" {
+		return
+	}
+	_ = c.timeline.UpdateTaskDeliveryWithReason(msg.TaskID, status, nil, reason)
+}
+
+func (c *WebhookChannel) maxRetries(chatID string) int {
+	accountID, _ := parseAccountChat(chatID)
+	if _, ok := c.account(accountID); !ok {
+		return 1
+	}
+	if c.config.MaxRetries > 0 {
+		return c.config.MaxRetries
+	}
+	return 5
+}
+
+// Send posts the outbound message as JSON to the account's callback URL.
+// Accounts without a callback URL are receive-only.
+func (c *WebhookChannel) Send(ctx context.Context, msg *bus.OutboundMessage) error {
+	accountID, chatID := parseAccountChat(strings.TrimSpace(msg.ChatID))
+	acct, ok := c.account(accountID)
+	if !ok {
+		return fmt.Errorf("webhook account %q not configured", accountID)
+	}
+	callback := strings.TrimSpace(acct.CallbackURL)
+	if callback == "" {
+		return nil
+	}
+	out := *msg
+	out.ChatID = chatID
+	body, err := json.Marshal(&out)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callback, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range acct.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookAccountHeader, accountIDOrDefault(acct.ID))
+	secret := acct.CallbackSecret
+	if strings.TrimSpace(secret) == "" {
+		secret = acct.Secret
+	}
+	if strings.TrimSpace(secret) != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, ts)
+		req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(secret, ts, body))
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook callback status: %d %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the signature header value for body:
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// HandleSignedInbound verifies a signed webhook post for accountID, maps the
+// JSON payload to an inbound message and publishes it to the bus.
+func (c *WebhookChannel) HandleSignedInbound(accountID string, header http.Header, body []byte) error {
+	if !c.config.Enabled {
+		return &WebhookError{Status: http.StatusNotFound, Message: "webhook channel disabled"}
+	}
+	acct, ok := c.account(accountID)
+	if !ok || !acct.Enabled {
+		return &WebhookError{Status: http.StatusNotFound, Message: "unknown webhook account"}
+	}
+	if strings.TrimSpace(acct.Secret) == "" {
+		return &WebhookError{Status: http.StatusForbidden, Message: "webhook account has no secret"}
+	}
+	if err := c.verifySignature(acct.Secret, header, body, time.Now()); err != nil {
+		return err
+	}
+
+	var payload any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&payload); err != nil {
+		return &WebhookError{Status: http.StatusBadRequest, Message: "invalid JSON payload"}
+	}
+	m := acct.Mapping
+	text := resolveWebhookField(payload, m.Text, "text")
+	audio, werr := webhookAudio(payload, m)
+	if werr != nil {
+		return werr
+	}
+	if strings.TrimSpace(text) == "" {
+		if audio == nil {
+			return &WebhookError{Status: http.StatusBadRequest, Message: "mapped text is empty"}
+		}
+		text = "[Audio Message]"
+	}
+	senderID := resolveWebhookField(payload, m.SenderID, "sender_id")
+	if senderID == "" {
+		senderID = accountIDOrDefault(acct.ID)
+	}
+	chatID := resolveWebhookField(payload, m.ChatID, "chat_id")
+	if chatID == "" {
+		chatID = senderID
+	}
+	threadID := resolveWebhookField(payload, m.ThreadID, "thread_id")
+	messageID := resolveWebhookField(payload, m.MessageID, "message_id")
+	if messageID == "" {
+		sum := sha256.Sum256(body)
+		messageID = hex.EncodeToString(sum[:16])
+	}
+	return c.publishInbound(acct, senderID, chatID, threadID, messageID, text, audio)
+}
+
+// webhookAudio decodes the mapped inline voice note, if any.
+func webhookAudio(payload any, m config.WebhookFieldMapping) ([]InboundAudio, *WebhookError) {
+	content := strings.TrimSpace(resolveWebhookField(payload, m.Audio, "audio_base64"))
+	if content == "" {
+		return nil, nil
+	}
+	name := resolveWebhookField(payload, m.AudioFilename, "audio_filename")
+	if !voice.IsAudio(name) {
+		return nil, &WebhookError{Status: http.StatusBadRequest, Message: "mapped audio filename needs an audio extension"}
+	}
+	if base64.StdEncoding.DecodedLen(len(content)) > maxInboundAudioBytes {
+		return nil, &WebhookError{Status: http.StatusRequestEntityTooLarge, Message: "mapped audio too large"}
+	}
+	data, err := base64.StdEncoding.DecodeString(content)
+	if err != nil || len(data) == 0 {
+		return nil, &WebhookError{Status: http.StatusBadRequest, Message: "mapped audio is not valid base64"}
+	}
+	return []InboundAudio{{Filename: name, Data: data}}, nil
+}
+
+func (c *WebhookChannel) publishInbound(acct config.WebhookAccountConfig, senderID, chatID, threadID, messageID, text string, audio []InboundAudio) error {
+	// The HMAC already authenticates the integration, so DMs default to open.
+	dmPolicy := acct.DmPolicy
+	if dmPolicy == "" {
+		dmPolicy = config.DmPolicyOpen
+	}
+	decision := EvaluateAccess(AccessContext{SenderID: senderID}, AccessConfig{
+		Channel:   c.Name(),
+		AllowFrom: acct.AllowFrom,
+		DmPolicy:  dmPolicy,
+	})
+	accountID := accountIDOrDefault(acct.ID)
+	scopedChatID := withAccountChat(accountID, chatID)
+	if decision.RequiresPairing {
+		if c.timeline == nil {
+			return nil
+		}
+		pending, err := NewPairingService(c.timeline).CreateOrGetPending(c.Name(), senderID, 0)
+		if err != nil {
+			return err
+		}
+		c.Bus.PublishOutbound(&bus.OutboundMessage{
+			Channel: c.Name(),
+			ChatID:  scopedChatID,
+			Content: BuildPairingReply(c.Name(), fmt.Sprintf("Webhook sender: %s", senderID), pending.Code),
+		})
+		return nil
+	}
+	if !decision.Allowed {
+		return &WebhookError{Status: http.StatusForbidden, Message: "sender not allowed: " + decision.Reason}
+	}
+	c.Bus.PublishInbound(&bus.InboundMessage{
+		Channel:        c.Name(),
+		SenderID:       senderID,
+		ChatID:         scopedChatID,
+		ThreadID:       threadID,
+		MessageID:      messageID,
+		IdempotencyKey: "webhook:" + accountID + ":" + messageID,
+		Content:        text,
+		Media:          saveInboundAudio(c.Name(), accountID+"-"+messageID, audio),
+		Metadata: map[string]any{
+			bus.MetaKeyMessageType:    bus.MessageTypeExternal,
+			bus.MetaKeySessionScope:   buildSessionScope(c.Name(), accountID, chatID, threadID, senderID, acct.SessionScope),
+			bus.MetaKeyChannelAccount: accountID,
+		},
+	})
+	return nil
+}
+
+// verifySignature checks the timestamp window and HMAC and rejects signatures
+// already seen inside the replay window.
+func (c *WebhookChannel) verifySignature(secret string, header http.Header, body []byte, now time.Time) error {
+	ts := strings.TrimSpace(header.Get(WebhookTimestampHeader))
+	// Hex is case-insensitive: normalise once so the replay cache sees
+	// every spelling of a signature as the same one.
+	sig := strings.ToLower(strings.TrimSpace(header.Get(WebhookSignatureHeader)))
+	if ts == "" || sig == "" {
+		return &WebhookError{Status: http.StatusUnauthorized, Message: "missing signature headers"}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return &WebhookError{Status: http.StatusUnauthorized, Message: "invalid timestamp"}
+	}
+	window := c.replayWindow()
+	sent := time.Unix(unix, 0)
+	if sent.Before(now.Add(-window)) || sent.After(now.Add(window)) {
+		return &WebhookError{Status: http.StatusUnauthorized, Message: "timestamp outside replay window"}
+	}
+	if !hmac.Equal([]byte(SignWebhookPayload(secret, ts, body)), []byte(sig)) {
+		return &WebhookError{Status: http.StatusUnauthorized, Message: "invalid signature"}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, exp := range c.seen {
+		if now.After(exp) {
+			delete(c.seen, k)
+		}
+	}
+	if _, dup := c.seen[sig]; dup {
+		return &WebhookError{Status: http.StatusConflict, Message: "replayed request"}
+	}
+	c.seen[sig] = sent.Add(window)
+	return nil
+}
+
+func (c *WebhookChannel) replayWindow() time.Duration {
+	if c.config.ReplayWindowSec > 0 {
+		return time.Duration(c.config.ReplayWindowSec) * time.Second
+	}
+	return 5 * time.Minute
+}
+
+func (c *WebhookChannel) account(accountID string) (config.WebhookAccountConfig, bool) {
+	id := accountIDOrDefault(accountID)
+	for _, acct := range c.config.Accounts {
+		if accountIDOrDefault(acct.ID) == id {
+			return acct, true
+		}
+	}
+	return config.WebhookAccountConfig{}, false
+}
+
+// resolveWebhookField evaluates a mapping expression against the payload.
+// Expressions containing "{{" are templates; anything else is a single path.
+func resolveWebhookField(payload any, expr, fallback string) string {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		expr = fallback
+	}
+	if !strings.Contains(expr, "{{") {
+		return strings.TrimSpace(webhookValueString(lookupWebhookPath(payload, expr)))
+	}
+	var b strings.Builder
+	rest := expr
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			b.WriteString(rest)
+			break
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			b.WriteString(rest)
+			break
+		}
+		b.WriteString(rest[:start])
+		path := strings.TrimSpace(rest[start+2 : start+end])
+		b.WriteString(webhookValueString(lookupWebhookPath(payload, path)))
+		rest = rest[start+end+2:]
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// lookupWebhookPath walks a dotted path; numeric segments index arrays.
+func lookupWebhookPath(v any, path string) any {
+	if strings.TrimSpace(path) == "" {
+		return nil
+	}
+	for _, seg := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			v = node[seg]
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+func webhookValueString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		raw, err := json.Marshal(val)
+		if err != nil {
+			return ""
+		}
+		return string(raw)
+	}
+}
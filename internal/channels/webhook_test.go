@@ -0,0 +1,288 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func signedWebhookHeader(secret string, body []byte, at time.Time) http.Header {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	h := http.Header{}
+	h.Set(WebhookTimestampHeader, ts)
+	h.Set(WebhookSignatureHeader, SignWebhookPayload(secret, ts, body))
+	return h
+}
+
+func webhookStatus(err error) int {
+	var werr *WebhookError
+	if errors.As(err, &werr) {
+		return werr.Status
+	}
+	return 0
+}
+
+func TestWebhookInboundSignatureReplayAndIdempotency(t *testing.T) {
+	mb := bus.NewMessageBus()
+	ch := NewWebhookChannel(config.WebhookConfig{
+		Enabled:         true,
+		ReplayWindowSec: 60,
+		Accounts: []config.WebhookAccountConfig{
+			{ID: "ci", Enabled: true, Secret: "s3cret"},
+			{ID: "nosecret", Enabled: true},
+		},
+	}, mb, nil)
+
+	body := []byte(`{"sender_id":"jenkins","chat_id":"build-42","message_id":"evt-1","text":"build failed"}`)
+	now := time.Now()
+
+	if err := ch.HandleSignedInbound("ci", signedWebhookHeader("wrong", body, now), body); webhookStatus(err) != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for bad signature, got %v", err)
+	}
+	if err := ch.HandleSignedInbound("ci", signedWebhookHeader("s3cret", body, now.Add(-2*time.Minute)), body); webhookStatus(err) != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for stale timestamp, got %v", err)
+	}
+	if err := ch.HandleSignedInbound("nosecret", signedWebhookHeader("", body, now), body); webhookStatus(err) != http.StatusForbidden {
+		t.Fatalf("expected 403 for account without secret, got %v", err)
+	}
+	if err := ch.HandleSignedInbound("missing", signedWebhookHeader("s3cret", body, now), body); webhookStatus(err) != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown account, got %v", err)
+	}
+
+	header := signedWebhookHeader("s3cret", body, now)
+	if err := ch.HandleSignedInbound("ci", header, body); err != nil {
+		t.Fatalf("signed inbound: %v", err)
+	}
+	if err := ch.HandleSignedInbound("ci", header, body); webhookStatus(err) != http.StatusConflict {
+		t.Fatalf("expected 409 for replay, got %v", err)
+	}
+	recased := header.Clone()
+	recased.Set(WebhookSignatureHeader, " "+strings.ToUpper(header.Get(WebhookSignatureHeader))+" ")
+	if err := ch.HandleSignedInbound("ci", recased, body); webhookStatus(err) != http.StatusConflict {
+		t.Fatalf("expected 409 for replay with a re-cased signature, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, err := mb.ConsumeInbound(ctx)
+	if err != nil {
+		t.Fatalf("consume inbound: %v", err)
+	}
+	if msg.Channel != "webhook" || msg.SenderID != "jenkins" || msg.Content != "build failed" {
+		t.Fatalf("unexpected inbound: %+v", msg)
+	}
+	if msg.ChatID != withAccountChat("ci", "build-42") {
+		t.Fatalf("expected account-scoped chat id, got %q", msg.ChatID)
+	}
+	if msg.IdempotencyKey != "webhook:ci:evt-1" {
+		t.Fatalf("unexpected idempotency key: %q", msg.IdempotencyKey)
+	}
+	if got := msg.Metadata[bus.MetaKeyChannelAccount]; got != "ci" {
+		t.Fatalf("unexpected channel account: %v", got)
+	}
+	if mb.InboundSize() != 0 {
+		t.Fatalf("replayed request must not be published")
+	}
+}
+
+func TestWebhookFieldMapping(t *testing.T) {
+	payload := map[string]any{
+		"alerts": []any{map[string]any{
+			"labels": map[string]any{"host": "db-1", "severity": "critical"},
+			"id":     json.Number("77"),
+		}},
+		"receiver": "pager",
+	}
+	if got := resolveWebhookField(payload, "alerts.0.labels.host", ""); got != "db-1" {
+		t.Fatalf("path lookup: %q", got)
+	}
+	if got := resolveWebhookField(payload, "alerts.0.id", ""); got != "77" {
+		t.Fatalf("number lookup: %q", got)
+	}
+	if got := resolveWebhookField(payload, "[{{alerts.0.labels.severity}}] {{alerts.0.labels.host}} via {{receiver}}", ""); got != "[critical] db-1 via pager" {
+		t.Fatalf("template: %q", got)
+	}
+	if got := resolveWebhookField(payload, "alerts.3.id", ""); got != "" {
+		t.Fatalf("out of range index should be empty, got %q", got)
+	}
+	if got := resolveWebhookField(map[string]any{"text": "hi"}, "", "text"); got != "hi" {
+		t.Fatalf("fallback field: %q", got)
+	}
+
+	mb := bus.NewMessageBus()
+	ch := NewWebhookChannel(config.WebhookConfig{
+		Enabled: true,
+		Accounts: []config.WebhookAccountConfig{{
+			ID: "alerts", Enabled: true, Secret: "k",
+			Mapping: config.WebhookFieldMapping{
+				SenderID: "receiver",
+				ChatID:   "alerts.0.labels.host",
+				Text:     "Alert {{alerts.0.labels.severity}} on {{alerts.0.labels.host}}",
+			},
+		}},
+	}, mb, nil)
+	body := []byte(`{"receiver":"pager","alerts":[{"labels":{"host":"db-1","severity":"critical"}}]}`)
+	if err := ch.HandleSignedInbound("alerts", signedWebhookHeader("k", body, time.Now()), body); err != nil {
+		t.Fatalf("mapped inbound: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, err := mb.ConsumeInbound(ctx)
+	if err != nil {
+		t.Fatalf("consume inbound: %v", err)
+	}
+	if msg.SenderID != "pager" || msg.Content != "Alert critical on db-1" || msg.ChatID != withAccountChat("alerts", "db-1") {
+		t.Fatalf("unexpected mapped inbound: %+v", msg)
+	}
+	if msg.IdempotencyKey == "" {
+		t.Fatalf("expected body-derived idempotency key")
+	}
+}
+
+func TestWebhookInboundAudio(t *testing.T) {
+	dir := useTempInboundAudioDir(t)
+	mb := bus.NewMessageBus()
+	ch := NewWebhookChannel(config.WebhookConfig{
+		Enabled: true,
+		Accounts: []config.WebhookAccountConfig{{
+			ID: "phone", Enabled: true, Secret: "k",
+			Mapping: config.WebhookFieldMapping{Audio: "recording.data", AudioFilename: "recording.name"},
+		}},
+	}, mb, nil)
+
+	bad := []byte(`{"sender_id":"pbx","recording":{"data":"dm9pY2U=","name":"call.txt"}}`)
+	if err := ch.HandleSignedInbound("phone", signedWebhookHeader("k", bad, time.Now()), bad); webhookStatus(err) != http.StatusBadRequest {
+		t.Fatalf("expected 400 for non-audio filename, got %v", err)
+	}
+
+	body := []byte(`{"sender_id":"pbx","recording":{"data":"dm9pY2U=","name":"call.ogg"}}`)
+	if err := ch.HandleSignedInbound("phone", signedWebhookHeader("k", body, time.Now()), body); err != nil {
+		t.Fatalf("audio-only inbound: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, err := mb.ConsumeInbound(ctx)
+	if err != nil {
+		t.Fatalf("consume inbound: %v", err)
+	}
+	if msg.Content != "[Audio Message]" || len(msg.Media) != 1 || filepath.Dir(msg.Media[0]) != dir {
+		t.Fatalf("unexpected audio inbound: %+v", msg)
+	}
+	if data, _ := os.ReadFile(msg.Media[0]); string(data) != "voice" {
+		t.Fatalf("stored audio = %q", data)
+	}
+}
+
+func TestWebhookOutboundSignedAndRetried(t *testing.T) {
+	var calls atomic.Int32
+	var gotBody atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		want := SignWebhookPayload("cb", r.Header.Get(WebhookTimestampHeader), raw)
+		if r.Header.Get(WebhookSignatureHeader) != want || r.Header.Get("X-Team") != "ops" {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		if calls.Add(1) < 3 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		gotBody.Store(raw)
+	}))
+	defer srv.Close()
+
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	defer tl.Close()
+	task, err := tl.CreateTask(&timeline.AgentTask{Channel: "webhook", ChatID: "x", TraceID: "tr-1"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	ch := NewWebhookChannel(config.WebhookConfig{
+		Enabled:    true,
+		MaxRetries: 5,
+		Accounts: []config.WebhookAccountConfig{{
+			ID: "ci", Enabled: true, Secret: "in", CallbackSecret: "cb",
+			CallbackURL: srv.URL, Headers: map[string]string{"X-Team": "ops"},
+		}},
+	}, bus.NewMessageBus(), tl)
+	ch.backoff = func(int) time.Time { return time.Now() }
+
+	ch.deliver(context.Background(), &bus.OutboundMessage{
+		Channel: "webhook",
+		ChatID:  withAccountChat("ci", "build-42"),
+		TaskID:  task.TaskID,
+		Content: "on it",
+	})
+	if calls.Load() != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls.Load())
+	}
+	var out bus.OutboundMessage
+	if err := json.Unmarshal(gotBody.Load().([]byte), &out); err != nil {
+		t.Fatalf("decode callback body: %v", err)
+	}
+	if out.ChatID != "build-42" || out.Content != "on it" {
+		t.Fatalf("unexpected callback body: %+v", out)
+	}
+	got, err := tl.GetTask(task.TaskID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got.DeliveryStatus != timeline.DeliverySent {
+		t.Fatalf("expected sent, got %s", got.DeliveryStatus)
+	}
+}
+
+func TestWebhookOutboundTerminalErrorStopsRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "gone", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	defer tl.Close()
+	task, err := tl.CreateTask(&timeline.AgentTask{Channel: "webhook", ChatID: "x", TraceID: "tr-2"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	ch := NewWebhookChannel(config.WebhookConfig{
+		Enabled:  true,
+		Accounts: []config.WebhookAccountConfig{{ID: "ci", Enabled: true, Secret: "in", CallbackURL: srv.URL}},
+	}, bus.NewMessageBus(), tl)
+	ch.backoff = func(int) time.Time { return time.Now() }
+	ch.deliver(context.Background(), &bus.OutboundMessage{ChatID: withAccountChat("ci", "c"), TaskID: task.TaskID, Content: "x"})
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected a single attempt, got %d", calls.Load())
+	}
+	got, err := tl.GetTask(task.TaskID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got.DeliveryStatus != timeline.DeliveryFailed {
+		t.Fatalf("expected failed, got %s", got.DeliveryStatus)
+	}
+}
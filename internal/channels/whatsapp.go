@@ -42,6 +42,7 @@ type WhatsAppChannel struct {
 	denylist  map[string]bool
 	token     string
 	mu        sync.Mutex
+	approvals approvalPromptIndex // prompt message ID -> approval ID
 }
 
 // NewWhatsAppChannel creates a new WhatsApp channel.
@@ -159,13 +160,95 @@ func (c *WhatsAppChannel) Send(ctx context.Context, msg *bus.OutboundMessage) er
 		return fmt.Errorf("invalid JID: %w", err)
 	}
 
-	// Use Protobuf message
-	waMsg := &waE2E.Message{
-		Conversation: proto.String(msg.Content),
+	// Local audio attachments (voice replies) go out as voice notes first.
+	for _, media := range msg.MediaURLs {
+		if !isLocalAudioFile(media) {
+			continue
+		}
+		if err := c.sendVoiceNote(ctx, jid, media); err != nil {
+			return err
+		}
+	}
+	if strings.TrimSpace(msg.Content) == "" {
+		return nil
+	}
+
+	approvalID := approvalPromptID(msg)
+	waMsg := whatsappOutboundMessage(msg.Content, approvalID, c.config.ApprovalButtons)
+	resp, err := c.client.SendMessage(ctx, jid, waMsg)
+	if err != nil {
+		return err
 	}
+	c.approvals.remember(resp.ID, approvalID)
+	return nil
+}
+
+// whatsappOutboundMessage builds the protobuf message for text content.
+// Approval prompts get Approve/Deny buttons when enabled, otherwise a
+// reaction hint; both are answered back as approve:/deny: replies.
+func whatsappOutboundMessage(content, approvalID string, buttons bool) *waE2E.Message {
+	if approvalID == "" {
+		return &waE2E.Message{Conversation: proto.String(content)}
+	}
+	if !buttons {
+		return &waE2E.Message{Conversation: proto.String(content + "\n" + approvalReactionHint)}
+	}
+	button := func(verdict, label string) *waE2E.ButtonsMessage_Button {
+		return &waE2E.ButtonsMessage_Button{
+			ButtonID:   proto.String(verdict + ":" + approvalID),
+			ButtonText: &waE2E.ButtonsMessage_Button_ButtonText{DisplayText: proto.String(label)},
+			Type:       waE2E.ButtonsMessage_Button_RESPONSE.Enum(),
+		}
+	}
+	return &waE2E.Message{ButtonsMessage: &waE2E.ButtonsMessage{
+		ContentText: proto.String(content),
+		FooterText:  proto.String(approvalReactionHint),
+		HeaderType:  waE2E.ButtonsMessage_EMPTY.Enum(),
+		Buttons:     []*waE2E.ButtonsMessage_Button{button("approve", "Approve"), button("deny", "Deny")},
+	}}
+}
 
-	_, err = c.client.SendMessage(ctx, jid, waMsg)
+// approvalReply turns an approval button tap, or a reaction on a tracked
+// approval prompt, into an "approve:<id>" / "deny:<id>" reply.
+func (c *WhatsAppChannel) approvalReply(m *waE2E.Message) (string, bool) {
+	if br := m.GetButtonsResponseMessage(); br != nil && isApprovalReply(br.GetSelectedButtonID()) {
+		return strings.TrimSpace(br.GetSelectedButtonID()), true
+	}
+	if rm := m.GetReactionMessage(); rm != nil {
+		approvalID, ok := c.approvals.lookup(rm.GetKey().GetID())
+		if !ok {
+			return "", false
+		}
+		if verdict := approvalReactionVerdict(rm.GetText()); verdict != "" {
+			return verdict + ":" + approvalID, true
+		}
+	}
+	return "", false
+}
 
+// sendVoiceNote uploads an Ogg/Opus file and sends it as a push-to-talk
+// voice note.
+func (c *WhatsAppChannel) sendVoiceNote(ctx context.Context, jid types.JID, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read voice note: %w", err)
+	}
+	up, err := c.client.Upload(ctx, data, whatsmeow.MediaAudio)
+	if err != nil {
+		return fmt.Errorf("upload voice note: %w", err)
+	}
+	_, err = c.client.SendMessage(ctx, jid, &waE2E.Message{
+		AudioMessage: &waE2E.AudioMessage{
+			URL:           proto.String(up.URL),
+			DirectPath:    proto.String(up.DirectPath),
+			MediaKey:      up.MediaKey,
+			FileEncSHA256: up.FileEncSHA256,
+			FileSHA256:    up.FileSHA256,
+			FileLength:    proto.Uint64(up.FileLength),
+			Mimetype:      proto.String("audio/ogg; codecs=opus"),
+			PTT:           proto.Bool(true),
+		},
+	})
 	return err
 }
 
@@ -188,7 +271,7 @@ func (c *WhatsAppChannel) handleOutbound(msg *bus.OutboundMessage) {
 		if c.timeline != nil && msg.TaskID != "" {
 			reason, cls := classifyDeliveryError(err)
 			if cls == deliveryTransient {
-				nextAt := deliveryBackoff(0)
+				nextAt := bus.DeliveryBackoff(0)
 				_ = c.timeline.UpdateTaskDeliveryWithReason(msg.TaskID, timeline.DeliveryPending, &nextAt, reason)
 			} else {
 				_ = c.timeline.UpdateTaskDeliveryWithReason(msg.TaskID, timeline.DeliveryFailed, nil, reason)
@@ -202,15 +285,6 @@ func (c *WhatsAppChannel) handleOutbound(msg *bus.OutboundMessage) {
 	}
 }
 
-func deliveryBackoff(attempts int) time.Time {
-	delay := 30 * time.Second * time.Duration(1<<uint(attempts))
-	maxDelay := 5 * time.Minute
-	if delay > maxDelay {
-		delay = maxDelay
-	}
-	return time.Now().Add(delay)
-}
-
 func (c *WhatsAppChannel) sendOutbound(ctx context.Context, msg *bus.OutboundMessage) error {
 	if c.sendFn != nil {
 		return c.sendFn(ctx, msg)
@@ -255,7 +329,9 @@ func (c *WhatsAppChannel) eventHandler(evt interface{}) {
 		content := ""
 		mediaPath := "" // Declare outside scope
 
-		if v.Message.GetConversation() != "" {
+		if reply, ok := c.approvalReply(v.Message); ok {
+			content = reply
+		} else if v.Message.GetConversation() != "" {
 			content = v.Message.GetConversation()
 		} else if v.Message.GetExtendedTextMessage().GetText() != "" {
 			content = v.Message.GetExtendedTextMessage().GetText()
@@ -293,24 +369,15 @@ func (c *WhatsAppChannel) eventHandler(evt interface{}) {
 				}
 				fileName := fmt.Sprintf("%s.%s", v.Info.ID, ext)
 				home, _ := os.UserHomeDir()
-				filePath := filepath.Join(home, ".kafclaw", "workspace", "media", "audio", fileName)
+				dirPath := filepath.Join(home, ".kafclaw", "workspace", "media", "audio")
+				os.MkdirAll(dirPath, 0755)
+				filePath := filepath.Join(dirPath, fileName)
 				os.WriteFile(filePath, data, 0644)
 
 				mediaPath = filePath // Capture it
 
+				// Transcription happens in the agent's voice pipeline.
 				fmt.Printf("🔊 Audio saved to %s\n", filePath)
-
-				// Transcribe
-				transcript, err := c.provider.Transcribe(context.Background(), &provider.AudioRequest{
-					FilePath: filePath,
-				})
-				if err == nil {
-					fmt.Printf("📝 Transcript: %s\n", transcript.Text)
-					content = "[Audio Transcript]: " + transcript.Text
-					// Note: Transcript echo removed - no automatic response
-				} else {
-					fmt.Printf("❌ Transcription error: %v\n", err)
-				}
 			} else {
 				fmt.Printf("❌ Download error: %v\n", err)
 			}
@@ -421,6 +488,7 @@ func (c *WhatsAppChannel) eventHandler(evt interface{}) {
 				TraceID:        traceID,
 				IdempotencyKey: "wa:" + v.Info.ID,
 				Content:        content,
+				Media:          nonEmpty(mediaPath),
 				Timestamp:      v.Info.Timestamp,
 				Metadata: map[string]any{
 					bus.MetaKeyMessageType: msgType,
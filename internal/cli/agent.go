@@ -3,52 +3,165 @@ package cli
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 
 	"github.com/KafClaw/KafClaw/internal/agent"
 	"github.com/KafClaw/KafClaw/internal/bus"
 	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/gatewayclient"
+	"github.com/KafClaw/KafClaw/internal/policy"
 	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/tui"
 	"github.com/spf13/cobra"
 )
 
 var (
 	agentMessage   string
 	agentSessionID string
+	agentRemote    string
+	agentDashboard string
+	agentToken     string
 )
 
 var agentCmd = &cobra.Command{
 	Use:   "agent",
 	Short: "Chat with the agent directly in CLI",
-	Run:   runAgent,
+	Long: `Chat with the agent directly in CLI.
+
+With --message the agent answers once and exits. Without it an interactive
+chat starts: replies render as markdown, tool calls stream in, and approval
+prompts are answered inline. Type /help for slash commands.
+
+--remote attaches the interactive chat to a running gateway instead of an
+in-process agent loop.`,
+	Run: runAgent,
 }
 
 func init() {
-	agentCmd.Flags().StringVarP(&agentMessage, "message", "m", "", "Message to send to the agent")
+	agentCmd.Flags().StringVarP(&agentMessage, "message", "m", "", "Message to send to the agent (omit for interactive chat)")
 	agentCmd.Flags().StringVarP(&agentSessionID, "session", "s", "cli:default", "Session ID")
+	agentCmd.Flags().StringVar(&agentRemote, "remote", "", "Gateway API URL to attach to (e.g. http://127.0.0.1:18790)")
+	agentCmd.Flags().StringVar(&agentDashboard, "dashboard", "", "Gateway dashboard URL (default: remote host on gateway.dashboardPort)")
+	agentCmd.Flags().StringVar(&agentToken, "token", "", "Gateway auth token (default: gateway.authToken from config)")
 }
 
 func runAgent(cmd *cobra.Command, args []string) {
+	// Load Config
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Config warning: %v (using defaults)\n", err)
+	}
+
+	if agentRemote != "" {
+		if agentMessage != "" {
+			fmt.Println("Error: --remote is only supported for interactive chat")
+			os.Exit(1)
+		}
+		runAgentRemote(cfg)
+		return
+	}
+
 	if agentMessage == "" {
-		fmt.Println("Error: --message is required")
-		os.Exit(1)
+		runAgentInteractive(cfg)
+		return
 	}
 
 	printHeader("🤖 KafClaw Agent")
+	msgBus := bus.NewMessageBus()
+	loop := newCLIAgentLoop(cfg, agent.LoopOptions{Bus: msgBus})
+	defer loop.Stop()
 
-	// Load Config
-	cfg, err := config.Load()
+	fmt.Printf("🤖 KafClaw (%s)\n", cfg.Model.Name)
+	fmt.Println("Thinking...")
+
+	ctx := context.Background()
+	response, err := loop.ProcessDirect(ctx, agentMessage, agentSessionID)
 	if err != nil {
-		fmt.Printf("Config warning: %v (using defaults)\n", err)
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
 	}
+
+	fmt.Println("\n" + response)
+}
+
+// runAgentInteractive runs the terminal chat on an in-process loop. Tools
+// above tier 1 need an inline approval, unlike one-shot --message runs.
+func runAgentInteractive(cfg *config.Config) {
+	msgBus := bus.NewMessageBus()
+	backend := tui.NewLocalBackend(msgBus)
+	engine := policy.NewDefaultEngine()
+	loop := newCLIAgentLoop(cfg, agent.LoopOptions{
+		Bus:          msgBus,
+		Policy:       engine,
+		ToolProgress: backend.OnToolProgress,
+	})
+	backend.SetLoop(loop)
+	defer loop.Stop()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go msgBus.DispatchOutbound(ctx)
+
+	if err := tui.New(backend, os.Stdin, os.Stdout, agentSessionID).Run(ctx); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runAgentRemote runs the terminal chat against a running gateway.
+func runAgentRemote(cfg *config.Config) {
+	client := newRemoteGatewayClient(cfg, agentRemote, agentDashboard, agentToken)
+	backend := tui.NewRemoteBackend(client.APIURL, client.DashboardURL, client.Token)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := tui.New(backend, os.Stdin, os.Stdout, agentSessionID).Run(ctx); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// newRemoteGatewayClient builds a client for subcommands run with --remote.
+// The dashboard URL defaults to the API host on gateway.dashboardPort and
+// the token to gateway.authToken.
+func newRemoteGatewayClient(cfg *config.Config, apiURL, dashboardURL, token string) *gatewayclient.Client {
+	if dashboardURL == "" {
+		dashboardURL = defaultDashboardURL(apiURL, cfg.Gateway.DashboardPort)
+	}
+	if token == "" {
+		token = cfg.Gateway.AuthToken
+	}
+	return gatewayclient.New(apiURL, dashboardURL, token)
+}
+
+// defaultDashboardURL swaps the port of the gateway API URL for the
+// dashboard port.
+func defaultDashboardURL(apiURL string, dashboardPort int) string {
+	if dashboardPort == 0 {
+		dashboardPort = 18791
+	}
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Host == "" {
+		return apiURL
+	}
+	u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(dashboardPort))
+	return u.String()
+}
+
+// newCLIAgentLoop builds an agent loop from config for CLI use. Bus, Policy
+// and ToolProgress are taken from opts.
+func newCLIAgentLoop(cfg *config.Config, opts agent.LoopOptions) *agent.Loop {
 	if warn, err := config.EnsureWorkRepo(cfg.Paths.WorkRepoPath); err != nil {
 		fmt.Printf("Work repo error: %v\n", err)
 	} else if warn != "" {
 		fmt.Printf("Work repo warning: %s\n", warn)
 	}
 
-	// Setup components
-	msgBus := bus.NewMessageBus()
 	prov, err := provider.Resolve(cfg, "main")
 	if err != nil {
 		fmt.Printf("Provider error: %v\n", err)
@@ -61,8 +174,10 @@ func runAgent(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	loop := agent.NewLoop(agent.LoopOptions{
-		Bus:                     msgBus,
+	return agent.NewLoop(agent.LoopOptions{
+		Bus:                     opts.Bus,
+		Policy:                  opts.Policy,
+		ToolProgress:            opts.ToolProgress,
 		Provider:                prov,
 		Workspace:               cfg.Paths.Workspace,
 		WorkRepo:                cfg.Paths.WorkRepoPath,
@@ -82,16 +197,4 @@ func runAgent(cmd *cobra.Command, args []string) {
 		SubagentToolsDeny:       cfg.Tools.Subagents.Tools.Deny,
 		Config:                  cfg,
 	})
-
-	fmt.Printf("🤖 KafClaw (%s)\n", cfg.Model.Name)
-	fmt.Println("Thinking...")
-
-	ctx := context.Background()
-	response, err := loop.ProcessDirect(ctx, agentMessage, agentSessionID)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
-	}
-
-	fmt.Println("\n" + response)
 }
@@ -0,0 +1,21 @@
+package cli
+
+import "testing"
+
+func TestDefaultDashboardURL(t *testing.T) {
+	cases := []struct {
+		api  string
+		port int
+		want string
+	}{
+		{"http://127.0.0.1:18790", 18791, "http://127.0.0.1:18791"},
+		{"https://gw.example.org", 9000, "https://gw.example.org:9000"},
+		{"http://[::1]:18790/", 0, "http://[::1]:18791/"},
+		{"not a url", 18791, "not a url"},
+	}
+	for _, tc := range cases {
+		if got := defaultDashboardURL(tc.api, tc.port); got != tc.want {
+			t.Errorf("defaultDashboardURL(%q, %d) = %q, want %q", tc.api, tc.port, got, tc.want)
+		}
+	}
+}
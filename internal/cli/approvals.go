@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/approval"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+	"github.com/KafClaw/KafClaw/internal/gatewayclient"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/spf13/cobra"
+)
+
+var (
+	approvalsCmd = &cobra.Command{
+		Use:   "approvals",
+		Short: "Manage remembered tool approvals (grants)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	approvalsGrantsCmd = &cobra.Command{
+		Use:   "grants",
+		Short: "List approval grants",
+		Args:  cobra.NoArgs,
+		RunE:  runApprovalsGrants,
+	}
+
+	approvalsRevokeCmd = &cobra.Command{
+		Use:   "revoke <grant-id>",
+		Short: "Revoke an approval grant; matching calls prompt again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if client := approvalsRemoteClient(cmd); client != nil {
+				if _, err := client.RevokeApprovalGrant(context.Background(), gatewayapi.ApprovalGrantRequest{ID: args[0]}); err != nil {
+					return err
+				}
+			} else {
+				tl, err := openTimelineService()
+				if err != nil {
+					return err
+				}
+				defer tl.Close()
+				if err := approval.RevokeGrant(tl, args[0], "cli"); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Revoked grant %s\n", args[0])
+			return nil
+		},
+	}
+)
+
+func init() {
+	approvalsGrantsCmd.Flags().Bool("all", false, "Include revoked and expired grants")
+	approvalsGrantsCmd.Flags().Bool("json", false, "Output machine-readable JSON")
+	approvalsCmd.PersistentFlags().String("remote", "", "Gateway API URL to manage grants on (e.g. http://127.0.0.1:18790)")
+	approvalsCmd.PersistentFlags().String("dashboard", "", "Gateway dashboard URL (default: remote host on gateway.dashboardPort)")
+	approvalsCmd.PersistentFlags().String("token", "", "Gateway auth token (default: gateway.authToken from config)")
+
+	approvalsCmd.AddCommand(approvalsGrantsCmd)
+	approvalsCmd.AddCommand(approvalsRevokeCmd)
+	rootCmd.AddCommand(approvalsCmd)
+}
+
+func runApprovalsGrants(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	var grants []timeline.ApprovalGrantRecord
+	if client := approvalsRemoteClient(cmd); client != nil {
+		var err error
+		if grants, err = client.ListApprovalGrants(context.Background(), gatewayapi.ApprovalGrantsRequest{All: all}); err != nil {
+			return err
+		}
+	} else {
+		tl, err := openTimelineService()
+		if err != nil {
+			return err
+		}
+		defer tl.Close()
+		if grants, err = approval.ListGrants(tl, all); err != nil {
+			return err
+		}
+	}
+	w := cmd.OutOrStdout()
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(grants)
+	}
+	if len(grants) == 0 {
+		fmt.Fprintln(w, "No approval grants.")
+		return nil
+	}
+	now := time.Now()
+	for _, g := range grants {
+		status := "active"
+		switch {
+		case g.RevokedAt != nil:
+			status = "revoked by " + g.RevokedBy
+		case g.ExpiresAt != nil && !g.ExpiresAt.After(now):
+			status = "expired"
+		}
+		fmt.Fprintf(w, "%s [%s] %s uses=%d by=%s approval=%s\n",
+			g.GrantID, status, approval.DescribeGrant(g), g.UseCount, g.CreatedBy, g.ApprovalID)
+	}
+	return nil
+}
+
+// approvalsRemoteClient returns a gateway client when --remote is set, so
+// grants are managed on a running gateway instead of the local timeline.
+func approvalsRemoteClient(cmd *cobra.Command) *gatewayclient.Client {
+	remote, _ := cmd.Flags().GetString("remote")
+	if remote == "" {
+		return nil
+	}
+	cfg, err := config.Load()
+	if err != nil || cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	dashboard, _ := cmd.Flags().GetString("dashboard")
+	token, _ := cmd.Flags().GetString("token")
+	return newRemoteGatewayClient(cfg, remote, dashboard, token)
+}
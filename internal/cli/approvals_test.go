@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestApprovalsGrantCommands(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".kafclaw"), 0o755); err != nil {
+		t.Fatalf("mkdir config dir: %v", err)
+	}
+	origHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", origHome)
+	_ = os.Setenv("HOME", tmpDir)
+
+	tl, err := openTimelineService()
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	expires := time.Now().Add(time.Hour)
+	for _, g := range []timeline.ApprovalGrantRecord{
+		{GrantID: "g-push", ApprovalID: "ap-1", Tool: "exec", ArgPattern: "git push origin *", MaxTier: 2, Scope: "ttl", CreatedBy: "slack:UA", ExpiresAt: &expires},
+		{GrantID: "g-make", ApprovalID: "ap-2", Tool: "exec", ArgPattern: "make", MaxTier: 2, Scope: "always", CreatedBy: "operator"},
+	} {
+		if err := tl.InsertApprovalGrant(&g); err != nil {
+			t.Fatalf("insert grant: %v", err)
+		}
+	}
+	_ = tl.Close()
+
+	out, err := runRootCommand(t, "approvals", "grants", "--all=false", "--json=false")
+	if err != nil {
+		t.Fatalf("approvals grants: %v", err)
+	}
+	if !strings.Contains(out, `g-push [active] exec "git push origin *"`) || !strings.Contains(out, "g-make [active]") {
+		t.Fatalf("unexpected grants output %q", out)
+	}
+
+	if out, err := runRootCommand(t, "approvals", "revoke", "g-push"); err != nil || !strings.Contains(out, "Revoked grant g-push") {
+		t.Fatalf("revoke: %v %q", err, out)
+	}
+	if _, err := runRootCommand(t, "approvals", "revoke", "g-push"); err == nil {
+		t.Fatal("expected revoking a revoked grant to fail")
+	}
+
+	out, err = runRootCommand(t, "approvals", "grants", "--all=false", "--json")
+	if err != nil {
+		t.Fatalf("approvals grants --json: %v", err)
+	}
+	var active []timeline.ApprovalGrantRecord
+	if err := json.Unmarshal([]byte(out), &active); err != nil {
+		t.Fatalf("unmarshal grants: %v\n%s", err, out)
+	}
+	if len(active) != 1 || active[0].GrantID != "g-make" {
+		t.Fatalf("expected only g-make active, got %+v", active)
+	}
+	out, _ = runRootCommand(t, "approvals", "grants", "--all", "--json=false")
+	if !strings.Contains(out, "g-push [revoked by cli]") {
+		t.Fatalf("expected revoked grant with --all, got %q", out)
+	}
+}
+
+func TestApprovalsGrantCommandsRemote(t *testing.T) {
+	var revoked, auth, all string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/approvals/grants":
+			all = r.URL.Query().Get("all")
+			_ = json.NewEncoder(w).Encode([]timeline.ApprovalGrantRecord{
+				{GrantID: "g-remote", Tool: "exec", ArgPattern: "make", Scope: "always", CreatedBy: "operator"},
+			})
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/v1/approvals/grants/"):
+			revoked = strings.TrimPrefix(r.URL.Path, "/api/v1/approvals/grants/")
+			_ = json.NewEncoder(w).Encode(map[string]string{"status": "revoked", "grant_id": revoked})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	t.Cleanup(func() {
+		for _, name := range []string{"remote", "dashboard", "token"} {
+			_ = approvalsCmd.PersistentFlags().Set(name, "")
+		}
+	})
+
+	out, err := runRootCommand(t, "approvals", "grants", "--remote", srv.URL, "--dashboard", srv.URL, "--token", "tok", "--all", "--json")
+	if err != nil {
+		t.Fatalf("remote grants: %v", err)
+	}
+	var grants []timeline.ApprovalGrantRecord
+	if err := json.Unmarshal([]byte(out), &grants); err != nil || len(grants) != 1 || grants[0].GrantID != "g-remote" || all != "true" {
+		t.Fatalf("unexpected remote grants %q (err=%v)", out, err)
+	}
+	if auth != "Bearer tok" {
+		t.Fatalf("expected bearer token, got %q", auth)
+	}
+
+	if out, err := runRootCommand(t, "approvals", "revoke", "g-remote", "--remote", srv.URL, "--dashboard", srv.URL); err != nil || !strings.Contains(out, "Revoked grant g-remote") {
+		t.Fatalf("remote revoke: %v %q", err, out)
+	}
+	if revoked != "g-remote" {
+		t.Fatalf("expected gateway revoke of g-remote, got %q", revoked)
+	}
+}
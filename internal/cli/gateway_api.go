@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/agent"
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/channels"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+	"github.com/KafClaw/KafClaw/internal/group"
+	"github.com/KafClaw/KafClaw/internal/memory"
+	"github.com/KafClaw/KafClaw/internal/orchestrator"
+	"github.com/KafClaw/KafClaw/internal/rbac"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// gatewayAPI implements the operations of the gatewayapi route table on
+// top of the running gateway. Optional subsystems (orchestrator, observer,
+// ER1) are nil when disabled.
+type gatewayAPI struct {
+	ctx       context.Context
+	cfg       *config.Config
+	timeline  *timeline.TimelineService
+	auth      *rbac.Authenticator
+	bus       *bus.MessageBus
+	loop      *agent.Loop
+	orch      *orchestrator.Orchestrator
+	groups    *groupState
+	startTime time.Time
+
+	expertise     *memory.ExpertiseTracker
+	workingMemory *memory.WorkingMemoryStore
+	observer      *memory.Observer
+	er1           *memory.ER1Client
+	lifecycle     *memory.LifecycleManager
+
+	whatsapp *channels.WhatsAppChannel
+	slack    *channels.SlackChannel
+	msteams  *channels.MSTeamsChannel
+	webhook  *channels.WebhookChannel
+
+	systemRepo  string
+	workRepo    func() string
+	setWorkRepo func(string)
+
+	mode              func() string
+	recalcMode        func()
+	buildGroupManager func(config.GroupConfig) *group.Manager
+	startGroupKafka   func(config.GroupConfig, *group.Manager, context.Context, group.OrchestratorHandler) context.CancelFunc
+
+	specOnce sync.Once
+	spec     []byte
+	specErr  error
+}
+
+// register binds every operation to its handler. Registration does not
+// touch the gateway's subsystems, so a zero gatewayAPI can be registered
+// to check the route table is covered.
+func (a *gatewayAPI) register(api, dash *gatewayapi.Router) {
+	gatewayapi.Handle(api, "chat", a.chat)
+	(&openaiAPI{cfg: a.cfg, loop: a.loop, timeline: a.timeline}).register(api)
+
+	a.registerCore(dash)
+	a.registerGroup(dash)
+	a.registerMemory(dash)
+	a.registerRepo(dash)
+	a.registerUsers(dash)
+	a.registerSessions(dash)
+}
+
+// standaloneBlocked refuses mutating group operations in standalone mode.
+func (a *gatewayAPI) standaloneBlocked() error {
+	if a.mode() == "standalone" {
+		return gatewayapi.JSONError(http.StatusForbidden, "group operations are disabled in standalone mode")
+	}
+	return nil
+}
+
+// activeGroup returns the group manager when this agent is in a group.
+func (a *gatewayAPI) activeGroup() (*group.Manager, error) {
+	mgr := a.groups.Manager()
+	if mgr == nil || !mgr.Active() {
+		return nil, gatewayapi.Errorf(http.StatusBadRequest, "not in a group")
+	}
+	return mgr, nil
+}
+
+// resolveRepo maps the repo parameter to a path: "identity" is the system
+// repo, anything else the work repo.
+func (a *gatewayAPI) resolveRepo(repo string) string {
+	if repo == "identity" {
+		return a.systemRepo
+	}
+	return a.workRepo()
+}
+
+// openAPISpec returns the OpenAPI document, generated once.
+func (a *gatewayAPI) openAPISpec() ([]byte, error) {
+	a.specOnce.Do(func() {
+		a.spec, a.specErr = gatewayapi.Spec(version)
+	})
+	return a.spec, a.specErr
+}
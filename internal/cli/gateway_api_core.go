@@ -0,0 +1,508 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/KafClaw/KafClaw/internal/channels"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+	"github.com/KafClaw/KafClaw/internal/orchestrator"
+	"github.com/KafClaw/KafClaw/internal/rbac"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func (a *gatewayAPI) registerCore(rt *gatewayapi.Router) {
+	rt.HandleFunc("getOpenAPI", func(w http.ResponseWriter, r *http.Request) {
+		spec, err := a.openAPISpec()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	})
+	gatewayapi.Handle(rt, "getStatus", a.status)
+
+	// BUG-0011: Prometheus scrape endpoint on the dashboard port. Go
+	// runtime + process metrics only; unauthenticated like /api/v1/status.
+	rt.HandleFunc("getMetrics", promhttp.Handler().ServeHTTP)
+
+	gatewayapi.Handle(rt, "verifyAuth", a.verifyAuth)
+	gatewayapi.Handle(rt, "whoAmI", a.whoAmI)
+
+	// Web UI login (OIDC authorization code flow) and logout.
+	rt.HandleFunc("oidcLogin", func(w http.ResponseWriter, r *http.Request) { a.auth.LoginHandler(w, r) })
+	rt.HandleFunc("oidcCallback", func(w http.ResponseWriter, r *http.Request) { a.auth.CallbackHandler(w, r) })
+	rt.HandleFunc("logout", func(w http.ResponseWriter, r *http.Request) { a.auth.LogoutHandler(w, r) })
+	rt.HandleFunc("logoutRedirect", func(w http.ResponseWriter, r *http.Request) { a.auth.LogoutHandler(w, r) })
+
+	gatewayapi.Handle(rt, "slackInbound", a.slackInbound)
+	gatewayapi.Handle(rt, "msteamsInbound", a.msteamsInbound)
+	gatewayapi.Handle(rt, "webhookInbound", a.webhookInbound)
+
+	gatewayapi.Handle(rt, "getOrchestratorStatus", a.orchestratorStatus)
+	gatewayapi.Handle(rt, "getOrchestratorHierarchy", func(r *http.Request, _ struct{}) ([]orchestrator.AgentNode, error) {
+		if a.orch == nil {
+			return []orchestrator.AgentNode{}, nil
+		}
+		return a.orch.GetHierarchy(), nil
+	})
+	gatewayapi.Handle(rt, "listOrchestratorZones", func(r *http.Request, _ struct{}) ([]orchestrator.ZoneSummary, error) {
+		if a.orch == nil {
+			return []orchestrator.ZoneSummary{}, nil
+		}
+		return a.orch.GetZones(), nil
+	})
+	gatewayapi.Handle(rt, "listOrchestratorAgents", func(r *http.Request, _ struct{}) ([]orchestrator.AgentNode, error) {
+		if a.orch == nil {
+			return []orchestrator.AgentNode{}, nil
+		}
+		return a.orch.GetAgents(), nil
+	})
+	gatewayapi.Handle(rt, "dispatchOrchestratorTask", a.dispatchOrchestratorTask)
+
+	gatewayapi.Handle(rt, "listTimeline", a.listTimeline)
+	gatewayapi.Handle(rt, "getTrace", a.trace)
+	gatewayapi.Handle(rt, "listPolicyDecisions", a.policyDecisions)
+	gatewayapi.Handle(rt, "getTraceGraph", func(r *http.Request, req gatewayapi.TraceRequest) (timeline.TraceGraph, error) {
+		graph, err := a.timeline.GetTraceGraph(strings.TrimSpace(req.ID))
+		if err != nil {
+			return timeline.TraceGraph{}, err
+		}
+		return *graph, nil
+	})
+}
+
+func (a *gatewayAPI) chat(r *http.Request, req gatewayapi.ChatRequest) (string, error) {
+	msg := req.Message
+	if msg == "" {
+		return "", gatewayapi.Errorf(http.StatusBadRequest, "Missing message parameter")
+	}
+	session := req.Session
+	if session == "" {
+		session = "local:default"
+	}
+
+	fmt.Printf("🌐 Local Network Request: %s\n", msg)
+	// Clients may pick the trace ID so they can follow progress
+	// (tool spans, approvals) while the request is in flight.
+	traceID := strings.TrimSpace(req.Trace)
+	if traceID == "" {
+		traceID = newTraceID()
+	}
+	inMeta, _ := json.Marshal(map[string]any{
+		"channel":      "local",
+		"sender":       session,
+		"message_type": "TEXT",
+		"content":      msg,
+	})
+	_ = a.timeline.AddEvent(&timeline.TimelineEvent{
+		EventID:        fmt.Sprintf("LOCAL_IN_%d", time.Now().UnixNano()),
+		TraceID:        traceID,
+		Timestamp:      time.Now(),
+		SenderID:       session,
+		SenderName:     "Local",
+		EventType:      "TEXT",
+		ContentText:    msg,
+		Classification: "LOCAL_INBOUND",
+		Authorized:     true,
+		Metadata:       string(inMeta),
+	})
+	resp, err := a.loop.ProcessDirectWithTrace(a.ctx, msg, session, traceID)
+	if err != nil {
+		outErrMeta, _ := json.Marshal(map[string]any{
+			"response_text":   err.Error(),
+			"delivery_status": "error",
+		})
+		_ = a.timeline.AddEvent(&timeline.TimelineEvent{
+			EventID:        fmt.Sprintf("LOCAL_OUT_%d", time.Now().UnixNano()),
+			TraceID:        traceID,
+			Timestamp:      time.Now(),
+			SenderID:       "AGENT",
+			SenderName:     "Agent",
+			EventType:      "SYSTEM",
+			ContentText:    err.Error(),
+			Classification: "LOCAL_OUTBOUND status=error",
+			Authorized:     true,
+			Metadata:       string(outErrMeta),
+		})
+		fmt.Printf("📤 Local outbound status=error session=%s\n", session)
+		return "", err
+	}
+	outMeta, _ := json.Marshal(map[string]any{
+		"response_text":   resp,
+		"delivery_status": "sent",
+	})
+	_ = a.timeline.AddEvent(&timeline.TimelineEvent{
+		EventID:        fmt.Sprintf("LOCAL_OUT_%d", time.Now().UnixNano()),
+		TraceID:        traceID,
+		Timestamp:      time.Now(),
+		SenderID:       "AGENT",
+		SenderName:     "Agent",
+		EventType:      "SYSTEM",
+		ContentText:    resp,
+		Classification: "LOCAL_OUTBOUND status=sent",
+		Authorized:     true,
+		Metadata:       string(outMeta),
+	})
+	fmt.Printf("📤 Local outbound status=sent session=%s\n", session)
+	return resp, nil
+}
+
+func (a *gatewayAPI) status(r *http.Request, _ struct{}) (gatewayapi.GatewayStatus, error) {
+	agentID := a.cfg.Group.AgentID
+	if agentID == "" {
+		hostname, _ := os.Hostname()
+		agentID = fmt.Sprintf("kafclaw-%s", hostname)
+	}
+	// orchestrator_enabled reflects the configured intent (env/config),
+	// consistent with group_enabled. orchestrator_active reflects whether
+	// the orchestrator was actually constructed (needs a group manager).
+	// Reporting only construction state hid the honoured env and made the
+	// operator chase an already-applied config change (BUG-0016).
+	return gatewayapi.GatewayStatus{
+		Version:             version,
+		Mode:                a.mode(),
+		AgentID:             agentID,
+		UptimeSeconds:       int(time.Since(a.startTime).Seconds()),
+		GroupEnabled:        a.cfg.Group.Enabled,
+		OrchestratorEnabled: a.cfg.Orchestrator.Enabled,
+		OrchestratorActive:  a.orch != nil,
+		Model:               a.loop.Model(),
+	}, nil
+}
+
+func (a *gatewayAPI) verifyAuth(r *http.Request, _ struct{}) (gatewayapi.AuthVerification, error) {
+	if !a.auth.Required() {
+		return gatewayapi.AuthVerification{Valid: true}, nil
+	}
+	id, _ := a.auth.Authenticate(r)
+	out := gatewayapi.AuthVerification{Valid: id != nil, AuthRequired: true}
+	if id != nil {
+		out.Principal = id.Principal
+		out.Scopes = id.Scopes
+	}
+	return out, nil
+}
+
+func (a *gatewayAPI) whoAmI(r *http.Request, _ struct{}) (gatewayapi.WhoAmI, error) {
+	id, _ := rbac.IdentityFrom(r.Context())
+	return gatewayapi.WhoAmI{
+		Identity:     id,
+		AuthRequired: a.auth.Required(),
+		OIDC:         a.auth.OIDCEnabled(),
+	}, nil
+}
+
+// verifyChannelToken checks the bridge token sent as X-Channel-Token or a
+// Bearer token.
+func verifyChannelToken(r *http.Request, sent, expected string) bool {
+	expected = strings.TrimSpace(expected)
+	if expected == "" {
+		return true
+	}
+	h := strings.TrimSpace(sent)
+	if h == "" {
+		h = strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	}
+	return h == expected
+}
+
+// resolveInboundToken returns the inbound token of a bridge account,
+// falling back to the channel token.
+func resolveInboundToken(accountID, channelToken string, accounts map[string]string) string {
+	id := strings.TrimSpace(strings.ToLower(accountID))
+	if id == "" || id == "default" {
+		return channelToken
+	}
+	for acctID, token := range accounts {
+		if strings.EqualFold(strings.TrimSpace(acctID), id) {
+			if strings.TrimSpace(token) != "" {
+				return token
+			}
+			return channelToken
+		}
+	}
+	return channelToken
+}
+
+func slackInboundTokens(cfg config.SlackConfig) map[string]string {
+	out := make(map[string]string, len(cfg.Accounts))
+	for _, acct := range cfg.Accounts {
+		out[acct.ID] = acct.InboundToken
+	}
+	return out
+}
+
+func msteamsInboundTokens(cfg config.MSTeamsConfig) map[string]string {
+	out := make(map[string]string, len(cfg.Accounts))
+	for _, acct := range cfg.Accounts {
+		out[acct.ID] = acct.InboundToken
+	}
+	return out
+}
+
+func checkChannelInbound(r *http.Request, req gatewayapi.ChannelInboundRequest, expected string) error {
+	if !verifyChannelToken(r, req.Token, expected) {
+		return gatewayapi.Errorf(http.StatusUnauthorized, "invalid channel token")
+	}
+	if strings.TrimSpace(req.SenderID) == "" || strings.TrimSpace(req.ChatID) == "" {
+		return gatewayapi.Errorf(http.StatusBadRequest, "sender_id and chat_id required")
+	}
+	return nil
+}
+
+// inboundAudio decodes the voice notes a bridge forwarded inline; entries
+// that do not decode are dropped.
+func inboundAudio(files []gatewayapi.MediaFile) []channels.InboundAudio {
+	var out []channels.InboundAudio
+	for _, f := range files {
+		data, err := base64.StdEncoding.DecodeString(f.ContentBase64)
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		out = append(out, channels.InboundAudio{Filename: filepath.Base(f.Filename), Data: data})
+	}
+	return out
+}
+
+func (a *gatewayAPI) slackInbound(r *http.Request, req gatewayapi.ChannelInboundRequest) (gatewayapi.OK, error) {
+	slackCfg := a.cfg.Channels.Slack
+	if err := checkChannelInbound(r, req, resolveInboundToken(req.AccountID, slackCfg.InboundToken, slackInboundTokens(slackCfg))); err != nil {
+		return gatewayapi.OK{}, err
+	}
+	if err := a.slack.HandleInboundWithAccountAndHints(
+		req.AccountID,
+		req.SenderID,
+		req.ChatID,
+		req.ThreadID,
+		req.MessageID,
+		req.Text,
+		req.IsGroup,
+		req.WasMentioned,
+		req.HistoryLimit,
+		req.DMHistoryLimit,
+		inboundAudio(req.MediaFiles),
+	); err != nil {
+		return gatewayapi.OK{}, err
+	}
+	return gatewayapi.OK{OK: true}, nil
+}
+
+func (a *gatewayAPI) msteamsInbound(r *http.Request, req gatewayapi.ChannelInboundRequest) (gatewayapi.OK, error) {
+	teamsCfg := a.cfg.Channels.MSTeams
+	if err := checkChannelInbound(r, req, resolveInboundToken(req.AccountID, teamsCfg.InboundToken, msteamsInboundTokens(teamsCfg))); err != nil {
+		return gatewayapi.OK{}, err
+	}
+	if err := a.msteams.HandleInboundWithContextAndHints(
+		req.AccountID,
+		req.SenderID,
+		req.ChatID,
+		req.ThreadID,
+		req.MessageID,
+		req.Text,
+		req.IsGroup,
+		req.WasMentioned,
+		req.GroupID,
+		req.ChannelID,
+		req.HistoryLimit,
+		req.DMHistoryLimit,
+		inboundAudio(req.MediaFiles),
+	); err != nil {
+		return gatewayapi.OK{}, err
+	}
+	return gatewayapi.OK{OK: true}, nil
+}
+
+// webhookInbound verifies the signature headers against the raw payload.
+func (a *gatewayAPI) webhookInbound(r *http.Request, req gatewayapi.WebhookInboundRequest) (gatewayapi.OK, error) {
+	if err := a.webhook.HandleSignedInbound(req.Account, r.Header, req.Payload); err != nil {
+		var werr *channels.WebhookError
+		if errors.As(err, &werr) {
+			return gatewayapi.OK{}, gatewayapi.Errorf(werr.Status, "%s", werr.Message)
+		}
+		return gatewayapi.OK{}, err
+	}
+	return gatewayapi.OK{OK: true}, nil
+}
+
+func (a *gatewayAPI) orchestratorStatus(r *http.Request, _ struct{}) (gatewayapi.OrchestratorStatus, error) {
+	if a.orch == nil {
+		// Enabled (env/config) may be true while the orchestrator is not
+		// active because no group manager was built yet (BUG-0016).
+		return gatewayapi.OrchestratorStatus{Enabled: a.cfg.Orchestrator.Enabled}, nil
+	}
+	st := a.orch.Status()
+	return gatewayapi.OrchestratorStatus{
+		Enabled:    st.Enabled,
+		Active:     true,
+		Role:       st.Role,
+		AgentID:    st.AgentID,
+		ZoneID:     st.ZoneID,
+		ParentID:   st.ParentID,
+		AgentCount: st.AgentCount,
+		ZoneCount:  st.ZoneCount,
+	}, nil
+}
+
+func (a *gatewayAPI) dispatchOrchestratorTask(r *http.Request, req gatewayapi.DispatchRequest) (gatewayapi.TaskSubmitted, error) {
+	if a.orch == nil {
+		if a.cfg.Orchestrator.Enabled {
+			return gatewayapi.TaskSubmitted{}, gatewayapi.Errorf(http.StatusServiceUnavailable, "orchestrator enabled but not active: no group manager (set KAFCLAW_GROUP_ENABLED=true and KAFCLAW_GROUP_NAME)")
+		}
+		return gatewayapi.TaskSubmitted{}, gatewayapi.Errorf(http.StatusBadRequest, "orchestrator not enabled")
+	}
+	taskID := newTraceID()
+	if err := a.orch.DispatchTask(a.ctx, taskID, req.Description, req.TargetZone); err != nil {
+		return gatewayapi.TaskSubmitted{}, err
+	}
+	return gatewayapi.TaskSubmitted{Status: "dispatched", TaskID: taskID}, nil
+}
+
+func (a *gatewayAPI) listTimeline(r *http.Request, req gatewayapi.TimelineRequest) ([]timeline.TimelineEvent, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = 100
+	}
+	return a.timeline.GetEvents(timeline.FilterArgs{
+		Limit:    limit,
+		Offset:   req.Offset,
+		SenderID: req.Sender,
+		TraceID:  req.TraceID,
+	})
+}
+
+func (a *gatewayAPI) trace(r *http.Request, req gatewayapi.TraceRequest) (gatewayapi.Trace, error) {
+	traceID := strings.TrimSpace(req.ID)
+	if traceID == "" {
+		return gatewayapi.Trace{}, gatewayapi.Errorf(http.StatusBadRequest, "trace_id required")
+	}
+	events, err := a.timeline.GetEvents(timeline.FilterArgs{
+		Limit:   500,
+		TraceID: traceID,
+	})
+	if err != nil {
+		return gatewayapi.Trace{}, err
+	}
+
+	spans := make([]gatewayapi.TraceSpan, 0, len(events))
+	for _, e := range events {
+		spans = append(spans, traceSpan(e))
+	}
+
+	// Also fetch task + policy decisions for this trace
+	out := gatewayapi.Trace{TraceID: traceID, Spans: spans}
+	if task, err := a.timeline.GetTaskByTraceID(traceID); err == nil && task != nil {
+		out.Task = &gatewayapi.TraceTask{
+			TaskID:           task.TaskID,
+			Status:           task.Status,
+			DeliveryStatus:   task.DeliveryStatus,
+			PromptTokens:     task.PromptTokens,
+			CompletionTokens: task.CompletionTokens,
+			TotalTokens:      task.TotalTokens,
+			Channel:          task.Channel,
+			CreatedAt:        task.CreatedAt,
+			CompletedAt:      task.CompletedAt,
+		}
+	}
+	if decisions, err := a.timeline.ListPolicyDecisions(traceID); err == nil {
+		for _, d := range decisions {
+			out.PolicyDecisions = append(out.PolicyDecisions, gatewayapi.TracePolicyDecision{
+				Tool:    d.Tool,
+				Tier:    d.Tier,
+				Allowed: d.Allowed,
+				Reason:  d.Reason,
+				Time:    d.CreatedAt.Format("15:04:05"),
+			})
+		}
+	}
+	return out, nil
+}
+
+// traceSpan derives the span type, duration and output preview of a
+// timeline event.
+func traceSpan(e timeline.TimelineEvent) gatewayapi.TraceSpan {
+	spanType := "EVENT"
+	switch {
+	case strings.Contains(e.Classification, "INBOUND") || e.SenderName == "User":
+		spanType = "INBOUND"
+	case strings.Contains(e.Classification, "OUTBOUND") || e.SenderName == "Agent":
+		spanType = "OUTBOUND"
+	case strings.Contains(e.Classification, "LLM"):
+		spanType = "LLM"
+	case strings.Contains(e.Classification, "TOOL"):
+		spanType = "TOOL"
+	}
+
+	// Parse metadata JSON if present
+	var meta map[string]any
+	if e.Metadata != "" {
+		_ = json.Unmarshal([]byte(e.Metadata), &meta)
+	}
+
+	// Extract duration from metadata
+	dur := ""
+	if meta != nil {
+		if ms, ok := meta["duration_ms"].(float64); ok {
+			dur = fmt.Sprintf("%dms", int64(ms))
+		}
+	}
+
+	// Build output preview
+	output := ""
+	switch spanType {
+	case "INBOUND", "OUTBOUND":
+		output = e.ContentText
+		// Add basic metadata for INBOUND/OUTBOUND if not already present
+		if meta == nil {
+			meta = map[string]any{}
+		}
+		if spanType == "INBOUND" {
+			meta["channel"] = e.SenderID
+			meta["sender"] = e.SenderName
+			meta["message_type"] = e.EventType
+			meta["content"] = e.ContentText
+		} else {
+			meta["response_text"] = e.ContentText
+		}
+	case "LLM":
+		if rt, ok := meta["response_text"].(string); ok && rt != "" {
+			if len(rt) > 200 {
+				output = rt[:200] + "..."
+			} else {
+				output = rt
+			}
+		}
+	case "TOOL":
+		if tn, ok := meta["tool_name"].(string); ok {
+			output = tn
+		}
+	}
+
+	return gatewayapi.TraceSpan{
+		ID:       e.EventID,
+		Type:     spanType,
+		Title:    e.Classification,
+		Time:     e.Timestamp.Format("15:04:05"),
+		Duration: dur,
+		Output:   output,
+		Metadata: meta,
+	}
+}
+
+func (a *gatewayAPI) policyDecisions(r *http.Request, req gatewayapi.PolicyDecisionsRequest) ([]timeline.PolicyDecisionRecord, error) {
+	if req.TraceID == "" {
+		return nil, gatewayapi.Errorf(http.StatusBadRequest, "trace_id required")
+	}
+	return a.timeline.ListPolicyDecisions(req.TraceID)
+}
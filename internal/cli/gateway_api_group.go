@@ -0,0 +1,605 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+	"github.com/KafClaw/KafClaw/internal/group"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func (a *gatewayAPI) registerGroup(rt *gatewayapi.Router) {
+	gatewayapi.Handle(rt, "getGroupStatus", func(r *http.Request, _ struct{}) (gatewayapi.GroupStatus, error) {
+		mgr := a.groups.Manager()
+		if mgr == nil {
+			return gatewayapi.GroupStatus{}, nil
+		}
+		return groupStatus(mgr), nil
+	})
+	gatewayapi.Handle(rt, "listGroupMembers", a.listGroupMembers)
+	gatewayapi.Handle(rt, "joinGroup", a.joinGroup)
+	gatewayapi.Handle(rt, "leaveGroup", a.leaveGroup)
+	gatewayapi.Handle(rt, "getGroupConfig", a.groupConfig)
+	gatewayapi.Handle(rt, "updateGroupConfig", a.updateGroupConfig)
+	gatewayapi.Handle(rt, "submitGroupTask", a.submitGroupTask)
+	gatewayapi.Handle(rt, "listGroupTasks", func(r *http.Request, req gatewayapi.GroupTasksRequest) ([]timeline.GroupTaskRecord, error) {
+		tasks, err := a.timeline.ListGroupTasks(req.Direction, req.Status, defaultLimit(req.Limit, 50), req.Offset)
+		if tasks == nil {
+			tasks = []timeline.GroupTaskRecord{}
+		}
+		return tasks, err
+	})
+	gatewayapi.Handle(rt, "listGroupTraces", func(r *http.Request, req gatewayapi.GroupTracesRequest) ([]timeline.GroupTrace, error) {
+		traces, err := a.timeline.ListAllGroupTraces(defaultLimit(req.Limit, 50), req.Offset, req.AgentID)
+		if traces == nil {
+			traces = []timeline.GroupTrace{}
+		}
+		return traces, err
+	})
+	gatewayapi.Handle(rt, "listGroupMemory", func(r *http.Request, req gatewayapi.GroupMemoryRequest) ([]timeline.GroupMemoryItemRecord, error) {
+		items, err := a.timeline.ListGroupMemoryItems(req.AuthorID, defaultLimit(req.Limit, 50), req.Offset)
+		if items == nil {
+			items = []timeline.GroupMemoryItemRecord{}
+		}
+		return items, err
+	})
+	gatewayapi.Handle(rt, "shareGroupMemory", a.shareGroupMemory)
+	gatewayapi.Handle(rt, "listGroupSkills", func(r *http.Request, req gatewayapi.GroupSkillsRequest) ([]timeline.GroupSkillChannelRecord, error) {
+		groupName := req.GroupName
+		if groupName == "" {
+			if mgr := a.groups.Manager(); mgr != nil {
+				groupName = mgr.GroupName()
+			}
+		}
+		channels, err := a.timeline.ListGroupSkillChannels(groupName)
+		if channels == nil {
+			channels = []timeline.GroupSkillChannelRecord{}
+		}
+		return channels, err
+	})
+	gatewayapi.Handle(rt, "registerGroupSkill", a.registerGroupSkill)
+	gatewayapi.Handle(rt, "submitSkillTask", a.submitSkillTask)
+	gatewayapi.Handle(rt, "onboardGroup", a.onboardGroup)
+	gatewayapi.Handle(rt, "listMembershipHistory", func(r *http.Request, req gatewayapi.MembershipHistoryRequest) ([]timeline.GroupMembershipHistoryRecord, error) {
+		history, err := a.timeline.GetMembershipHistory(req.AgentID, req.GroupName, defaultLimit(req.Limit, 50), req.Offset)
+		if history == nil {
+			history = []timeline.GroupMembershipHistoryRecord{}
+		}
+		return history, err
+	})
+	gatewayapi.Handle(rt, "listPreviousGroupMembers", func(r *http.Request, _ struct{}) ([]timeline.GroupMemberRecord, error) {
+		members, err := a.timeline.ListPreviousGroupMembers()
+		if members == nil {
+			members = []timeline.GroupMemberRecord{}
+		}
+		return members, err
+	})
+	gatewayapi.Handle(rt, "rejoinGroupMember", a.rejoinGroupMember)
+	gatewayapi.Handle(rt, "getGroupStats", func(r *http.Request, _ struct{}) (timeline.GroupStats, error) {
+		stats, err := a.timeline.GetGroupStats()
+		if err != nil {
+			return timeline.GroupStats{}, err
+		}
+		return *stats, nil
+	})
+	gatewayapi.Handle(rt, "listGroupAudit", func(r *http.Request, req gatewayapi.AuditRequest) ([]timeline.UnifiedAuditEntry, error) {
+		entries, err := a.timeline.ListUnifiedAudit(timeline.AuditFilter{
+			Source:    req.Source,
+			EventType: req.EventType,
+			AgentID:   req.AgentID,
+			Limit:     defaultLimit(req.Limit, 50),
+			Offset:    req.Offset,
+		})
+		if entries == nil {
+			entries = []timeline.UnifiedAuditEntry{}
+		}
+		return entries, err
+	})
+
+	gatewayapi.Handle(rt, "getGroupManifest", func(r *http.Request, _ struct{}) (group.TopicManifest, error) {
+		mgr := a.groups.Manager()
+		if mgr == nil {
+			return group.TopicManifest{}, gatewayapi.JSONError(http.StatusNotFound, "no group manager")
+		}
+		tm := mgr.TopicManager()
+		if tm == nil {
+			return group.TopicManifest{}, gatewayapi.JSONError(http.StatusNotFound, "no topic manager")
+		}
+		return tm.Manifest(), nil
+	})
+	gatewayapi.Handle(rt, "listGroupTopics", a.listGroupTopics)
+	gatewayapi.Handle(rt, "listGroupTopicMessages", func(r *http.Request, req gatewayapi.TopicMessagesRequest) (gatewayapi.TopicMessages, error) {
+		if req.Topic == "" {
+			return gatewayapi.TopicMessages{}, gatewayapi.Errorf(http.StatusBadRequest, "topic required")
+		}
+		msgs, err := a.timeline.GetTopicMessages(req.Topic, defaultLimit(req.Limit, 50))
+		if msgs == nil {
+			msgs = []timeline.TopicMessageLogRecord{}
+		}
+		return gatewayapi.TopicMessages{Messages: msgs}, err
+	})
+	gatewayapi.Handle(rt, "getGroupTopicFlow", func(r *http.Request, _ struct{}) (gatewayapi.TopicFlow, error) {
+		flow, err := a.timeline.GetTopicFlowData()
+		if flow == nil {
+			flow = []timeline.TopicFlowEdge{}
+		}
+		return gatewayapi.TopicFlow{Edges: flow}, err
+	})
+	gatewayapi.Handle(rt, "getGroupTopicHealth", func(r *http.Request, _ struct{}) (gatewayapi.TopicHealthList, error) {
+		health, err := a.timeline.GetTopicHealth()
+		if health == nil {
+			health = []timeline.TopicHealth{}
+		}
+		return gatewayapi.TopicHealthList{Health: health}, err
+	})
+	gatewayapi.Handle(rt, "ensureGroupTopic", a.ensureGroupTopic)
+	gatewayapi.Handle(rt, "getGroupTopicXP", func(r *http.Request, _ struct{}) (gatewayapi.XPLeaderboard, error) {
+		xp, err := a.timeline.GetAgentXP()
+		if xp == nil {
+			xp = []timeline.AgentXP{}
+		}
+		return gatewayapi.XPLeaderboard{Leaderboard: xp}, err
+	})
+	gatewayapi.Handle(rt, "getGroupTopicDensity", a.groupTopicDensity)
+}
+
+// defaultLimit returns limit, or def when it is not positive.
+func defaultLimit(limit, def int) int {
+	if limit <= 0 {
+		return def
+	}
+	return limit
+}
+
+// groupStatus converts the manager's status map.
+func groupStatus(mgr *group.Manager) gatewayapi.GroupStatus {
+	st := mgr.Status()
+	var out gatewayapi.GroupStatus
+	out.Active, _ = st["active"].(bool)
+	out.GroupName, _ = st["group_name"].(string)
+	out.AgentID, _ = st["agent_id"].(string)
+	out.MemberCount, _ = st["member_count"].(int)
+	out.LFSProxyURL, _ = st["lfs_proxy_url"].(string)
+	out.LFSHealthy, _ = st["lfs_healthy"].(bool)
+	return out
+}
+
+// listGroupMembers serves the in-memory roster, which is always current,
+// and falls back to the DB roster that covers members persisted across
+// restarts.
+func (a *gatewayAPI) listGroupMembers(r *http.Request, _ struct{}) ([]gatewayapi.GroupMember, error) {
+	if mgr := a.groups.Manager(); mgr != nil && mgr.Active() {
+		liveMembers := mgr.Members()
+		out := make([]gatewayapi.GroupMember, 0, len(liveMembers))
+		for _, m := range liveMembers {
+			caps, _ := json.Marshal(m.Capabilities)
+			chs, _ := json.Marshal(m.Channels)
+			out = append(out, gatewayapi.GroupMember{
+				AgentID:      m.AgentID,
+				AgentName:    m.AgentName,
+				SoulSummary:  m.SoulSummary,
+				Capabilities: string(caps),
+				Channels:     string(chs),
+				Model:        m.Model,
+				Role:         m.Role,
+				Status:       m.Status,
+				LastSeen:     m.LastSeen,
+			})
+		}
+		return out, nil
+	}
+
+	members, err := a.timeline.ListGroupMembers()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]gatewayapi.GroupMember, 0, len(members))
+	for _, m := range members {
+		out = append(out, gatewayapi.GroupMember{
+			AgentID:      m.AgentID,
+			AgentName:    m.AgentName,
+			SoulSummary:  m.SoulSummary,
+			Capabilities: m.Capabilities,
+			Channels:     m.Channels,
+			Model:        m.Model,
+			Status:       m.Status,
+			LastSeen:     m.LastSeen,
+			LeftAt:       m.LeftAt,
+		})
+	}
+	return out, nil
+}
+
+func (a *gatewayAPI) joinGroup(r *http.Request, req gatewayapi.JoinGroupRequest) (gatewayapi.GroupStatus, error) {
+	if err := a.standaloneBlocked(); err != nil {
+		return gatewayapi.GroupStatus{}, err
+	}
+	groupName := strings.TrimSpace(req.GroupName)
+	if groupName == "" {
+		return gatewayapi.GroupStatus{}, gatewayapi.Errorf(http.StatusBadRequest, "group_name required")
+	}
+
+	// Leave existing group if active
+	if mgr := a.groups.Manager(); mgr != nil && mgr.Active() {
+		leaveCtx, leaveCancel := context.WithTimeout(a.ctx, 5*time.Second)
+		_ = mgr.Leave(leaveCtx)
+		leaveCancel()
+		a.groups.Clear()
+	}
+
+	grpCfg := a.cfg.Group
+	grpCfg.GroupName = groupName
+	grpCfg.Enabled = true
+	if req.LFSProxyURL != "" {
+		grpCfg.LFSProxyURL = req.LFSProxyURL
+	}
+	if req.KafkaBrokers != "" {
+		grpCfg.KafkaBrokers = req.KafkaBrokers
+	}
+	if req.AgentID != "" {
+		grpCfg.AgentID = req.AgentID
+	}
+
+	mgr := a.buildGroupManager(grpCfg)
+
+	joinCtx, joinCancel := context.WithTimeout(a.ctx, 15*time.Second)
+	defer joinCancel()
+	if err := mgr.Join(joinCtx); err != nil {
+		return gatewayapi.GroupStatus{}, gatewayapi.Errorf(http.StatusInternalServerError, "join failed: %v", err)
+	}
+
+	setupGroupBusSubscription(mgr, a.bus)
+	kafkaCancel := a.startGroupKafka(grpCfg, mgr, a.ctx, orchDiscoveryHandler(a.orch))
+	a.groups.SetManager(mgr, kafkaCancel)
+
+	_ = a.timeline.SetSetting("group_name", groupName)
+	_ = a.timeline.SetSetting("group_active", "true")
+	if req.LFSProxyURL != "" {
+		_ = a.timeline.SetSetting("kafscale_lfs_proxy_url", req.LFSProxyURL)
+	}
+
+	a.cfg.Group.Enabled = true
+	a.recalcMode()
+	return groupStatus(mgr), nil
+}
+
+func (a *gatewayAPI) leaveGroup(r *http.Request, _ struct{}) (gatewayapi.Ack, error) {
+	if err := a.standaloneBlocked(); err != nil {
+		return gatewayapi.Ack{}, err
+	}
+	mgr := a.groups.Manager()
+	if mgr == nil {
+		return gatewayapi.Ack{}, gatewayapi.Errorf(http.StatusBadRequest, "not in a group")
+	}
+
+	leaveCtx, leaveCancel := context.WithTimeout(a.ctx, 10*time.Second)
+	defer leaveCancel()
+	if err := mgr.Leave(leaveCtx); err != nil {
+		return gatewayapi.Ack{}, gatewayapi.Errorf(http.StatusInternalServerError, "leave failed: %v", err)
+	}
+
+	a.groups.Clear()
+	_ = a.timeline.SetSetting("group_active", "false")
+	a.cfg.Group.Enabled = false
+	a.recalcMode()
+	return gatewayapi.Ack{Status: "left"}, nil
+}
+
+func (a *gatewayAPI) groupConfig(r *http.Request, _ struct{}) (gatewayapi.GroupConfig, error) {
+	mgr := a.groups.Manager()
+	if mgr == nil {
+		return gatewayapi.GroupConfig{
+			Enabled:        a.cfg.Group.Enabled,
+			GroupName:      a.cfg.Group.GroupName,
+			LFSProxyURL:    a.cfg.Group.LFSProxyURL,
+			APIKey:         maskSecret(a.cfg.Group.LFSProxyAPIKey),
+			KafkaBrokers:   a.cfg.Group.KafkaBrokers,
+			ConsumerGroup:  a.cfg.Group.ConsumerGroup,
+			AgentID:        a.cfg.Group.AgentID,
+			PollIntervalMs: a.cfg.Group.PollIntervalMs,
+		}, nil
+	}
+	grpCfg := mgr.Config()
+	return gatewayapi.GroupConfig{
+		Enabled:        grpCfg.Enabled,
+		GroupName:      grpCfg.GroupName,
+		LFSProxyURL:    grpCfg.LFSProxyURL,
+		APIKey:         maskSecret(grpCfg.LFSProxyAPIKey),
+		KafkaBrokers:   grpCfg.KafkaBrokers,
+		ConsumerGroup:  grpCfg.ConsumerGroup,
+		AgentID:        mgr.AgentID(),
+		PollIntervalMs: grpCfg.PollIntervalMs,
+	}, nil
+}
+
+// groupConfigSettings maps group config keys to the settings they are
+// stored as, and whether a change needs a rejoin to take effect.
+var groupConfigSettings = map[string]struct {
+	setting string
+	rejoin  bool
+}{
+	"lfs_proxy_url":    {"kafscale_lfs_proxy_url", true},
+	"api_key":          {"kafscale_lfs_proxy_api_key", true},
+	"kafka_brokers":    {"kafka_brokers", true},
+	"consumer_group":   {"kafka_consumer_group", true},
+	"agent_id":         {"group_agent_id", true},
+	"poll_interval_ms": {"group_poll_interval_ms", false},
+}
+
+func (a *gatewayAPI) updateGroupConfig(r *http.Request, req gatewayapi.GroupConfigUpdate) (gatewayapi.GroupConfigSaved, error) {
+	if err := a.standaloneBlocked(); err != nil {
+		return gatewayapi.GroupConfigSaved{}, err
+	}
+	requiresRejoin := false
+	for key, val := range req {
+		s, ok := groupConfigSettings[key]
+		if !ok {
+			continue
+		}
+		_ = a.timeline.SetSetting(s.setting, val)
+		requiresRejoin = requiresRejoin || s.rejoin
+	}
+	return gatewayapi.GroupConfigSaved{Status: "ok", RequiresRejoin: requiresRejoin}, nil
+}
+
+func (a *gatewayAPI) submitGroupTask(r *http.Request, req gatewayapi.GroupTaskRequest) (gatewayapi.TaskSubmitted, error) {
+	if err := a.standaloneBlocked(); err != nil {
+		return gatewayapi.TaskSubmitted{}, err
+	}
+	mgr, err := a.activeGroup()
+	if err != nil {
+		return gatewayapi.TaskSubmitted{}, err
+	}
+	if strings.TrimSpace(req.Description) == "" {
+		return gatewayapi.TaskSubmitted{}, gatewayapi.Errorf(http.StatusBadRequest, "description required")
+	}
+
+	taskID := newTraceID()
+	submitCtx, submitCancel := context.WithTimeout(a.ctx, 10*time.Second)
+	defer submitCancel()
+	if err := mgr.SubmitTask(submitCtx, taskID, req.Description, req.Content); err != nil {
+		return gatewayapi.TaskSubmitted{}, gatewayapi.Errorf(http.StatusInternalServerError, "submit failed: %v", err)
+	}
+
+	// Persist to local DB
+	_ = a.timeline.InsertGroupTask(&timeline.GroupTaskRecord{
+		TaskID:      taskID,
+		Description: req.Description,
+		Content:     req.Content,
+		Direction:   "outgoing",
+		RequesterID: mgr.AgentID(),
+		Status:      "pending",
+	})
+	return gatewayapi.TaskSubmitted{Status: "submitted", TaskID: taskID}, nil
+}
+
+func (a *gatewayAPI) shareGroupMemory(r *http.Request, req gatewayapi.ShareMemoryRequest) (gatewayapi.Ack, error) {
+	if err := a.standaloneBlocked(); err != nil {
+		return gatewayapi.Ack{}, err
+	}
+	mgr, err := a.activeGroup()
+	if err != nil {
+		return gatewayapi.Ack{}, err
+	}
+	contentType := req.ContentType
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	if err := mgr.ShareMemory(a.ctx, req.Title, contentType, []byte(req.Content), req.Tags); err != nil {
+		return gatewayapi.Ack{}, err
+	}
+	return gatewayapi.Ack{Status: "shared"}, nil
+}
+
+func (a *gatewayAPI) registerGroupSkill(r *http.Request, req gatewayapi.RegisterSkillRequest) (gatewayapi.SkillRegistered, error) {
+	if err := a.standaloneBlocked(); err != nil {
+		return gatewayapi.SkillRegistered{}, err
+	}
+	mgr, err := a.activeGroup()
+	if err != nil {
+		return gatewayapi.SkillRegistered{}, err
+	}
+	if req.SkillName == "" {
+		return gatewayapi.SkillRegistered{}, gatewayapi.Errorf(http.StatusBadRequest, "skill_name required")
+	}
+	if err := mgr.RegisterSkill(a.ctx, req.SkillName, a.groups.Consumer()); err != nil {
+		return gatewayapi.SkillRegistered{}, err
+	}
+	return gatewayapi.SkillRegistered{Status: "registered", Skill: req.SkillName}, nil
+}
+
+func (a *gatewayAPI) submitSkillTask(r *http.Request, req gatewayapi.SkillTaskRequest) (gatewayapi.TaskSubmitted, error) {
+	if err := a.standaloneBlocked(); err != nil {
+		return gatewayapi.TaskSubmitted{}, err
+	}
+	mgr, err := a.activeGroup()
+	if err != nil {
+		return gatewayapi.TaskSubmitted{}, err
+	}
+	if req.SkillName == "" {
+		return gatewayapi.TaskSubmitted{}, gatewayapi.Errorf(http.StatusBadRequest, "skill_name required")
+	}
+	taskID := newTraceID()
+	if err := mgr.SubmitSkillTask(a.ctx, taskID, req.SkillName, req.Description, req.Content); err != nil {
+		return gatewayapi.TaskSubmitted{}, err
+	}
+	return gatewayapi.TaskSubmitted{Status: "submitted", TaskID: taskID, Skill: req.SkillName}, nil
+}
+
+func (a *gatewayAPI) onboardGroup(r *http.Request, _ struct{}) (gatewayapi.Ack, error) {
+	if err := a.standaloneBlocked(); err != nil {
+		return gatewayapi.Ack{}, err
+	}
+	mgr := a.groups.Manager()
+	if mgr == nil {
+		return gatewayapi.Ack{}, gatewayapi.Errorf(http.StatusBadRequest, "group not configured")
+	}
+	if err := mgr.Onboard(a.ctx); err != nil {
+		return gatewayapi.Ack{}, err
+	}
+	return gatewayapi.Ack{Status: "onboard_request_sent"}, nil
+}
+
+func (a *gatewayAPI) rejoinGroupMember(r *http.Request, req gatewayapi.RejoinRequest) (gatewayapi.Rejoined, error) {
+	if err := a.standaloneBlocked(); err != nil {
+		return gatewayapi.Rejoined{}, err
+	}
+	if req.AgentID == "" {
+		return gatewayapi.Rejoined{}, gatewayapi.Errorf(http.StatusBadRequest, "agent_id required")
+	}
+
+	// Look up previous config from membership history
+	groupName := req.GroupName
+	if groupName == "" {
+		if mgr := a.groups.Manager(); mgr != nil {
+			groupName = mgr.GroupName()
+		}
+	}
+	prevConfig, err := a.timeline.GetLatestMembershipConfig(req.AgentID, groupName)
+	if err != nil {
+		return gatewayapi.Rejoined{}, gatewayapi.Errorf(http.StatusNotFound, "no previous config found for this agent")
+	}
+
+	// Reactivate the member in the roster
+	if err := a.timeline.ReactivateGroupMember(req.AgentID); err != nil {
+		return gatewayapi.Rejoined{}, gatewayapi.Errorf(http.StatusInternalServerError, "reactivate failed: %v", err)
+	}
+	return gatewayapi.Rejoined{Status: "rejoined", AgentID: req.AgentID, RestoredConfig: *prevConfig}, nil
+}
+
+// listGroupTopics builds the topic list from the manifest, merges traffic
+// stats and health, and adds topics only seen in the message log.
+func (a *gatewayAPI) listGroupTopics(r *http.Request, _ struct{}) (gatewayapi.GroupTopics, error) {
+	coreTopics := []gatewayapi.GroupTopic{}
+	skillTopics := []gatewayapi.GroupTopic{}
+	if mgr := a.groups.Manager(); mgr != nil {
+		if tm := mgr.TopicManager(); tm != nil {
+			manifest := tm.Manifest()
+			for _, td := range manifest.CoreTopics {
+				coreTopics = append(coreTopics, gatewayapi.GroupTopic{
+					Name: td.Name, Category: td.Category, Description: td.Description, Consumers: td.Consumers,
+				})
+			}
+			for _, td := range manifest.SkillTopics {
+				skillTopics = append(skillTopics, gatewayapi.GroupTopic{
+					Name: td.Name, Category: td.Category, Description: td.Description, Consumers: td.Consumers,
+				})
+			}
+		}
+	}
+
+	topicStats, _ := a.timeline.GetTopicStats()
+	statsMap := make(map[string]timeline.TopicStat)
+	for _, ts := range topicStats {
+		statsMap[ts.TopicName] = ts
+	}
+	topicHealth, _ := a.timeline.GetTopicHealth()
+	healthMap := make(map[string]timeline.TopicHealth)
+	for _, th := range topicHealth {
+		healthMap[th.TopicName] = th
+	}
+
+	knownTopics := make(map[string]bool)
+	enrich := func(topics []gatewayapi.GroupTopic) {
+		for i := range topics {
+			name := topics[i].Name
+			knownTopics[name] = true
+			if st, ok := statsMap[name]; ok {
+				topics[i].Stats = &st
+			}
+			if h, ok := healthMap[name]; ok {
+				topics[i].Health = &h
+			}
+		}
+	}
+	enrich(coreTopics)
+	enrich(skillTopics)
+
+	// Add topics from stats that aren't in the manifest (fallback discovery)
+	for topicName, st := range statsMap {
+		if knownTopics[topicName] {
+			continue
+		}
+		entry := gatewayapi.GroupTopic{
+			Name:        topicName,
+			Category:    inferTopicCategory(topicName),
+			Description: "Discovered from message log",
+			Consumers:   []string{},
+			Stats:       &st,
+		}
+		if h, ok := healthMap[topicName]; ok {
+			entry.Health = &h
+		}
+		if strings.Contains(topicName, ".skill.") {
+			skillTopics = append(skillTopics, entry)
+		} else {
+			coreTopics = append(coreTopics, entry)
+		}
+	}
+
+	// XP leaderboard (deduplicated by agent_id)
+	xpRaw, _ := a.timeline.GetAgentXP()
+	seen := make(map[string]bool)
+	xp := make([]timeline.AgentXP, 0, len(xpRaw))
+	for _, x := range xpRaw {
+		if !seen[x.AgentID] {
+			seen[x.AgentID] = true
+			xp = append(xp, x)
+		}
+	}
+	return gatewayapi.GroupTopics{Topics: coreTopics, SkillTopics: skillTopics, XPLeaderboard: xp}, nil
+}
+
+func (a *gatewayAPI) ensureGroupTopic(r *http.Request, req gatewayapi.EnsureTopicRequest) (gatewayapi.TopicEnsured, error) {
+	if err := a.standaloneBlocked(); err != nil {
+		return gatewayapi.TopicEnsured{}, err
+	}
+	mgr := a.groups.Manager()
+	if mgr == nil {
+		return gatewayapi.TopicEnsured{}, gatewayapi.JSONError(http.StatusBadRequest, "no group manager")
+	}
+	if req.TopicName == "" {
+		return gatewayapi.TopicEnsured{}, gatewayapi.Errorf(http.StatusBadRequest, "topic_name required")
+	}
+	if err := mgr.EnsureTopic(r.Context(), req.TopicName); err != nil {
+		return gatewayapi.TopicEnsured{}, gatewayapi.JSONError(http.StatusInternalServerError, err.Error())
+	}
+	// Log the ensure event locally so stats are immediately visible
+	// (the Kafka round-trip may not complete before the UI refreshes).
+	_ = a.timeline.LogTopicMessage(&timeline.TopicMessageLogRecord{
+		TopicName:     req.TopicName,
+		SenderID:      mgr.AgentID(),
+		EnvelopeType:  "heartbeat",
+		CorrelationID: "ensure",
+		PayloadSize:   0,
+	})
+	return gatewayapi.TopicEnsured{OK: true, Topic: req.TopicName}, nil
+}
+
+// groupTopicDensity returns hourly buckets and envelope types for the
+// sparkline popup.
+func (a *gatewayAPI) groupTopicDensity(r *http.Request, req gatewayapi.TopicDensityRequest) (gatewayapi.TopicDensity, error) {
+	if req.Topic == "" {
+		return gatewayapi.TopicDensity{}, gatewayapi.Errorf(http.StatusBadRequest, "topic parameter required")
+	}
+	hours := defaultLimit(req.Hours, 48)
+	buckets, err := a.timeline.GetTopicMessageDensity(req.Topic, hours)
+	if err != nil {
+		return gatewayapi.TopicDensity{}, err
+	}
+	if buckets == nil {
+		buckets = []timeline.TopicDensityBucket{}
+	}
+	envTypes, err := a.timeline.GetTopicEnvelopeTypeCounts(req.Topic)
+	if err != nil {
+		return gatewayapi.TopicDensity{}, err
+	}
+	if envTypes == nil {
+		envTypes = map[string]int{}
+	}
+	return gatewayapi.TopicDensity{Topic: req.Topic, Hours: hours, Buckets: buckets, EnvelopeTypes: envTypes}, nil
+}
@@ -0,0 +1,281 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func (a *gatewayAPI) registerMemory(rt *gatewayapi.Router) {
+	gatewayapi.Handle(rt, "getSettings", a.settings)
+	gatewayapi.Handle(rt, "updateSetting", a.updateSetting)
+
+	gatewayapi.Handle(rt, "getMemoryStatus", a.memoryStatus)
+	gatewayapi.Handle(rt, "getMemoryMetrics", func(r *http.Request, _ struct{}) (gatewayapi.MemoryMetrics, error) {
+		return collectMemoryKnowledgeMetrics(a.timeline)
+	})
+	gatewayapi.Handle(rt, "resetMemory", a.resetMemory)
+	gatewayapi.Handle(rt, "updateMemoryConfig", a.updateMemoryConfig)
+	gatewayapi.Handle(rt, "pruneMemory", func(r *http.Request, _ struct{}) (gatewayapi.MemoryDeleted, error) {
+		deleted, err := a.lifecycle.Prune()
+		if err != nil {
+			return gatewayapi.MemoryDeleted{}, err
+		}
+		fmt.Printf("🧹 Memory prune triggered: deleted=%d\n", deleted)
+		return gatewayapi.MemoryDeleted{Status: "ok", Deleted: deleted}, nil
+	})
+
+	gatewayapi.Handle(rt, "getEmbeddingStatus", a.embeddingStatus)
+	rt.HandleFunc("getEmbeddingHealth", func(w http.ResponseWriter, r *http.Request) {
+		health := probeEmbeddingRuntime(a.cfg)
+		code := http.StatusOK
+		if !health.Ready {
+			code = http.StatusServiceUnavailable
+		}
+		gatewayapi.WriteJSON(w, code, health)
+	})
+	gatewayapi.Handle(rt, "installEmbedding", a.installEmbedding)
+	gatewayapi.Handle(rt, "reindexEmbedding", a.reindexEmbedding)
+}
+
+func (a *gatewayAPI) settings(r *http.Request, req gatewayapi.SettingsRequest) (gatewayapi.Setting, error) {
+	if req.Key != "" {
+		val, err := a.timeline.GetSetting(req.Key)
+		if err != nil {
+			val = ""
+		}
+		return gatewayapi.Setting{Key: req.Key, Value: &val}, nil
+	}
+	// Return silent_mode by default
+	silent := a.timeline.IsSilentMode()
+	return gatewayapi.Setting{SilentMode: &silent}, nil
+}
+
+func (a *gatewayAPI) updateSetting(r *http.Request, req gatewayapi.SettingUpdate) (gatewayapi.Ack, error) {
+	if err := a.timeline.SetSetting(req.Key, req.Value); err != nil {
+		return gatewayapi.Ack{}, err
+	}
+	fmt.Printf("⚙️ Setting changed: %s = %s\n", req.Key, req.Value)
+	// Auto-reload WhatsApp auth when allowlist/denylist changes
+	if req.Key == "whatsapp_allowlist" || req.Key == "whatsapp_denylist" || req.Key == "whatsapp_pair_token" {
+		a.whatsapp.ReloadAuth()
+	}
+	return gatewayapi.Ack{Status: "ok"}, nil
+}
+
+func (a *gatewayAPI) memoryStatus(r *http.Request, _ struct{}) (gatewayapi.MemoryStatus, error) {
+	// Get chunk stats from lifecycle manager
+	stats, _ := a.lifecycle.Stats()
+
+	out := gatewayapi.MemoryStatus{
+		Layers: []gatewayapi.MemoryLayer{
+			{Name: "soul", SourcePrefix: "soul:", Description: "Identity and personality files loaded at startup", TTLDays: 0, ChunkCount: stats.BySource["soul"], Color: "#a855f7"},
+			{Name: "conversation", SourcePrefix: "conversation:", Description: "Auto-indexed Q&A pairs from your conversations", TTLDays: 30, ChunkCount: stats.BySource["conversation"], Color: "#58a6ff"},
+			{Name: "tool", SourcePrefix: "tool:", Description: "Tool execution outputs and results", TTLDays: 14, ChunkCount: stats.BySource["tool"], Color: "#fb923c"},
+			{Name: "group", SourcePrefix: "group:", Description: "Shared knowledge from group collaboration", TTLDays: 60, ChunkCount: stats.BySource["group"], Color: "#22c55e"},
+			{Name: "er1", SourcePrefix: "er1:", Description: "Personal memories synced from ER1", TTLDays: 0, ChunkCount: stats.BySource["er1"], Color: "#fbbf24"},
+			{Name: "observation", SourcePrefix: "observation:", Description: "Compressed observations from conversation analysis", TTLDays: 0, ChunkCount: stats.BySource["observation"], Color: "#67e8f9"},
+		},
+		Totals: gatewayapi.MemoryTotals{
+			TotalChunks: stats.TotalChunks,
+			MaxChunks:   50000,
+			Oldest:      stats.OldestChunk,
+			Newest:      stats.NewestChunk,
+		},
+		Config: gatewayapi.MemoryStatusSettings{
+			ObserverEnabled:    a.observer != nil,
+			ObserverThreshold:  50,
+			ObserverMaxObs:     200,
+			ER1SyncIntervalSec: 300,
+			MaxChunks:          50000,
+		},
+	}
+
+	if a.workingMemory != nil {
+		if entries, err := a.workingMemory.ListAll(); err == nil {
+			out.WorkingMemory.Entries = len(entries)
+			if len(entries) > 0 {
+				preview := entries[0].Content
+				if len(preview) > 500 {
+					preview = preview[:500] + "..."
+				}
+				out.WorkingMemory.Preview = preview
+			}
+		}
+	}
+
+	if a.observer != nil {
+		st := a.observer.Status()
+		out.Observer = gatewayapi.ObserverStatus{
+			Enabled:          st.Enabled,
+			ObservationCount: st.ObservationCount,
+			QueueDepth:       st.QueueDepth,
+		}
+		if !st.LastObservation.IsZero() {
+			out.Observer.LastObservation = &st.LastObservation
+		}
+		if obs, err := a.observer.AllObservations(20); err == nil {
+			for _, o := range obs {
+				out.Observations = append(out.Observations, gatewayapi.Observation{
+					ID:       o.ID,
+					Content:  o.Content,
+					Priority: o.Priority,
+					Date:     o.ObservedAt.Format(time.RFC3339),
+				})
+			}
+		}
+		out.Config.ObserverThreshold = a.cfg.Observer.MessageThreshold
+		out.Config.ObserverMaxObs = a.cfg.Observer.MaxObservations
+	}
+
+	if a.er1 != nil {
+		es := a.er1.Status()
+		out.ER1 = gatewayapi.ER1Status{
+			Connected:   es.Connected,
+			URL:         es.URL,
+			SyncedCount: stats.BySource["er1"],
+		}
+		if !es.LastSync.IsZero() {
+			out.ER1.LastSync = &es.LastSync
+		}
+		out.Config.ER1URL = es.URL
+		if sec := int(a.cfg.ER1.SyncInterval.Seconds()); sec > 0 {
+			out.Config.ER1SyncIntervalSec = sec
+		}
+	}
+
+	if a.expertise != nil {
+		if skills, err := a.expertise.ListExpertise(); err == nil {
+			for _, s := range skills {
+				out.Expertise = append(out.Expertise, gatewayapi.Expertise{
+					Skill: s.SkillName,
+					Score: s.Score,
+					Trend: s.Trend,
+					Uses:  s.SuccessCount + s.FailureCount,
+				})
+			}
+		}
+	}
+	return out, nil
+}
+
+func (a *gatewayAPI) resetMemory(r *http.Request, req gatewayapi.MemoryResetRequest) (gatewayapi.MemoryDeleted, error) {
+	deleted := 0
+	var err error
+	switch req.Layer {
+	case "all":
+		deleted, err = a.lifecycle.DeleteAll()
+		if err == nil && a.workingMemory != nil {
+			_ = a.workingMemory.DeleteAll()
+		}
+	case "working_memory":
+		if a.workingMemory != nil {
+			err = a.workingMemory.DeleteAll()
+		}
+	case "soul", "conversation", "tool", "group", "er1", "observation":
+		deleted, err = a.lifecycle.DeleteBySource(req.Layer + ":")
+	default:
+		return gatewayapi.MemoryDeleted{}, gatewayapi.Errorf(http.StatusBadRequest, "invalid layer")
+	}
+	if err != nil {
+		return gatewayapi.MemoryDeleted{}, err
+	}
+	fmt.Printf("🧹 Memory reset: layer=%s deleted=%d\n", req.Layer, deleted)
+	return gatewayapi.MemoryDeleted{Status: "ok", Deleted: deleted}, nil
+}
+
+func (a *gatewayAPI) updateMemoryConfig(r *http.Request, req gatewayapi.MemoryConfigUpdate) (gatewayapi.Ack, error) {
+	// Save each config key as a setting
+	for key, value := range req {
+		strVal := fmt.Sprintf("%v", value)
+		if err := a.timeline.SetSetting("memory_"+key, strVal); err != nil {
+			return gatewayapi.Ack{}, err
+		}
+		fmt.Printf("⚙️ Memory config changed: %s = %s\n", key, strVal)
+	}
+	return gatewayapi.Ack{Status: "ok"}, nil
+}
+
+func (a *gatewayAPI) embeddingStatus(r *http.Request, _ struct{}) (gatewayapi.EmbeddingStatus, error) {
+	emb := a.cfg.Memory.Embedding
+	embeddedCount, _ := countEmbeddedMemoryChunks()
+	pendingInstallAt, _ := a.timeline.GetSetting("memory_embedding_install_requested_at")
+	pendingInstallModel, _ := a.timeline.GetSetting("memory_embedding_install_model")
+	return gatewayapi.EmbeddingStatus{
+		Status: "ok",
+		Embedding: gatewayapi.EmbeddingConfig{
+			Enabled:         emb.Enabled,
+			Provider:        emb.Provider,
+			Model:           emb.Model,
+			Dimension:       emb.Dimension,
+			Normalize:       emb.Normalize,
+			CacheDir:        emb.CacheDir,
+			AutoDownload:    emb.AutoDownload,
+			Endpoint:        emb.Endpoint,
+			StartupTimeoutS: emb.StartupTimeoutSec,
+			Fingerprint:     memoryEmbeddingFingerprint(a.cfg),
+		},
+		Runtime: probeEmbeddingRuntime(a.cfg),
+		Index:   gatewayapi.EmbeddingIndex{EmbeddedChunks: embeddedCount},
+		Install: gatewayapi.EmbeddingInstall{
+			Pending:            strings.TrimSpace(pendingInstallAt) != "",
+			RequestedAt:        strings.TrimSpace(pendingInstallAt),
+			RequestedModel:     strings.TrimSpace(pendingInstallModel),
+			CachePathAvailable: embeddingCachePresent(emb.CacheDir),
+		},
+	}, nil
+}
+
+func (a *gatewayAPI) installEmbedding(r *http.Request, req gatewayapi.EmbeddingInstallRequest) (gatewayapi.EmbeddingInstallRequested, error) {
+	model := strings.TrimSpace(req.Model)
+	if model == "" {
+		model = strings.TrimSpace(a.cfg.Memory.Embedding.Model)
+	}
+	if model == "" {
+		return gatewayapi.EmbeddingInstallRequested{}, gatewayapi.Errorf(http.StatusBadRequest, "embedding model is required")
+	}
+	_ = a.timeline.SetSetting("memory_embedding_install_requested_at", time.Now().UTC().Format(time.RFC3339))
+	_ = a.timeline.SetSetting("memory_embedding_install_model", model)
+
+	if cacheDir := strings.TrimSpace(a.cfg.Memory.Embedding.CacheDir); cacheDir != "" {
+		expanded := cacheDir
+		if strings.HasPrefix(expanded, "~") {
+			if home, err := os.UserHomeDir(); err == nil {
+				expanded = filepath.Join(home, strings.TrimPrefix(expanded, "~"))
+			}
+		}
+		_ = os.MkdirAll(expanded, 0o755)
+	}
+	return gatewayapi.EmbeddingInstallRequested{Status: "ok", Action: "install-requested", Model: model}, nil
+}
+
+func (a *gatewayAPI) reindexEmbedding(r *http.Request, req gatewayapi.EmbeddingReindexRequest) (gatewayapi.EmbeddingReindexed, error) {
+	if !req.ConfirmWipe {
+		return gatewayapi.EmbeddingReindexed{}, gatewayapi.Errorf(http.StatusBadRequest, "confirmWipe must be true")
+	}
+	wiped, err := wipeAllMemoryChunks()
+	if err != nil {
+		return gatewayapi.EmbeddingReindexed{}, err
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = "manual_reindex"
+	}
+	_ = a.timeline.AddEvent(&timeline.TimelineEvent{
+		EventID:        fmt.Sprintf("MEMORY_EMBED_REINDEX_%d", time.Now().UnixNano()),
+		Timestamp:      time.Now(),
+		SenderID:       "system",
+		SenderName:     "KafClaw",
+		EventType:      "SYSTEM",
+		ContentText:    fmt.Sprintf("embedding reindex requested; wiped_chunks=%d reason=%s", wiped, reason),
+		Classification: "MEMORY_EMBEDDING_REINDEX",
+		Authorized:     true,
+	})
+	return gatewayapi.EmbeddingReindexed{Status: "ok", WipedChunks: wiped, Reason: reason}, nil
+}
@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+)
+
+func (a *gatewayAPI) registerRepo(rt *gatewayapi.Router) {
+	gatewayapi.Handle(rt, "getWorkRepo", func(r *http.Request, _ struct{}) (gatewayapi.WorkRepo, error) {
+		return gatewayapi.WorkRepo{Path: a.workRepo()}, nil
+	})
+	gatewayapi.Handle(rt, "setWorkRepo", a.updateWorkRepo)
+
+	gatewayapi.Handle(rt, "getRepoTree", func(r *http.Request, req gatewayapi.RepoPathRequest) ([]gatewayapi.RepoItem, error) {
+		base := a.resolveRepo(req.Repo)
+		repoPath := base
+		if sub := strings.TrimSpace(req.Path); sub != "" {
+			repoPath = filepath.Join(repoPath, sub)
+		}
+		return listRepoTree(repoPath, base)
+	})
+	gatewayapi.Handle(rt, "getRepoFile", a.repoFile)
+	gatewayapi.Handle(rt, "getRepoStatus", func(r *http.Request, req gatewayapi.RepoRequest) (gatewayapi.RepoStatus, error) {
+		rp := a.resolveRepo(req.Repo)
+		out, err := runGit(rp, "status", "-sb")
+		if err != nil {
+			return gatewayapi.RepoStatus{Error: err.Error()}, nil
+		}
+		remote, _ := runGit(rp, "remote", "-v")
+		return gatewayapi.RepoStatus{Status: out, Remote: remote}, nil
+	})
+	gatewayapi.Handle(rt, "searchRepos", a.searchRepos)
+	gatewayapi.Handle(rt, "getGitHubAuth", func(r *http.Request, req gatewayapi.RepoRequest) (gatewayapi.GitHubAuth, error) {
+		out, err := runGh(a.resolveRepo(req.Repo), "auth", "status", "-h", "github.com")
+		if err != nil {
+			return gatewayapi.GitHubAuth{Status: "not_authenticated", Detail: err.Error()}, nil
+		}
+		return gatewayapi.GitHubAuth{Status: "ok", Detail: out}, nil
+	})
+	gatewayapi.Handle(rt, "listRepoBranches", func(r *http.Request, req gatewayapi.RepoRequest) (gatewayapi.RepoBranches, error) {
+		out, err := runGit(a.resolveRepo(req.Repo), "branch", "--format=%(refname:short)")
+		if err != nil {
+			return gatewayapi.RepoBranches{}, err
+		}
+		return gatewayapi.RepoBranches{Branches: nonEmptyLines(out)}, nil
+	})
+	gatewayapi.Handle(rt, "checkoutRepoBranch", func(r *http.Request, req gatewayapi.RepoCheckoutRequest) (gatewayapi.RepoResult, error) {
+		branch := strings.TrimSpace(req.Branch)
+		if branch == "" || strings.HasPrefix(branch, "-") {
+			return gatewayapi.RepoResult{}, gatewayapi.Errorf(http.StatusBadRequest, "invalid branch name")
+		}
+		return repoResult(runGit(a.resolveRepo(req.Repo), "checkout", branch))
+	})
+	gatewayapi.Handle(rt, "getRepoLog", func(r *http.Request, req gatewayapi.RepoLogRequest) (gatewayapi.RepoLog, error) {
+		limit := req.Limit
+		if limit == 0 {
+			limit = 20
+		}
+		if limit < 1 || limit > 500 {
+			return gatewayapi.RepoLog{}, gatewayapi.Errorf(http.StatusBadRequest, "limit must be a number between 1 and 500")
+		}
+		out, err := runGit(a.resolveRepo(req.Repo), "log", "--oneline", "-n", strconv.Itoa(limit))
+		if err != nil {
+			return gatewayapi.RepoLog{}, err
+		}
+		return gatewayapi.RepoLog{Commits: nonEmptyLines(out)}, nil
+	})
+	gatewayapi.Handle(rt, "getRepoFileDiff", func(r *http.Request, req gatewayapi.RepoPathRequest) (gatewayapi.RepoDiff, error) {
+		rel := filepath.Clean(strings.TrimSpace(req.Path))
+		if rel == "" || rel == "." || strings.HasPrefix(rel, "-") {
+			return gatewayapi.RepoDiff{}, gatewayapi.Errorf(http.StatusBadRequest, "invalid path")
+		}
+		out, err := runGit(a.resolveRepo(req.Repo), "diff", "--", rel)
+		return gatewayapi.RepoDiff{Diff: out}, err
+	})
+	gatewayapi.Handle(rt, "getRepoDiff", func(r *http.Request, req gatewayapi.RepoPathRequest) (gatewayapi.RepoDiff, error) {
+		rel := filepath.Clean(strings.TrimSpace(req.Path))
+		args := []string{"diff"}
+		if rel != "" && rel != "." && !strings.HasPrefix(rel, "-") {
+			args = append(args, "--", rel)
+		}
+		out, err := runGit(a.resolveRepo(req.Repo), args...)
+		return gatewayapi.RepoDiff{Diff: out}, err
+	})
+	gatewayapi.Handle(rt, "commitRepo", func(r *http.Request, req gatewayapi.RepoCommitRequest) (gatewayapi.RepoResult, error) {
+		msg := strings.TrimSpace(req.Message)
+		if msg == "" {
+			return gatewayapi.RepoResult{}, gatewayapi.Errorf(http.StatusBadRequest, "message required")
+		}
+		rp := a.resolveRepo(req.Repo)
+		if _, err := runGit(rp, "add", "-A"); err != nil {
+			return gatewayapi.RepoResult{}, err
+		}
+		return repoResult(runGit(rp, "commit", "-m", msg))
+	})
+	gatewayapi.Handle(rt, "pullRepo", func(r *http.Request, req gatewayapi.RepoRequest) (gatewayapi.RepoResult, error) {
+		return repoResult(runGit(a.resolveRepo(req.Repo), "pull", "--ff-only"))
+	})
+	gatewayapi.Handle(rt, "pushRepo", func(r *http.Request, req gatewayapi.RepoRequest) (gatewayapi.RepoResult, error) {
+		return repoResult(runGit(a.resolveRepo(req.Repo), "push"))
+	})
+	gatewayapi.Handle(rt, "initRepo", a.initRepo)
+	gatewayapi.Handle(rt, "createPullRequest", func(r *http.Request, req gatewayapi.PullRequestRequest) (gatewayapi.RepoResult, error) {
+		if strings.TrimSpace(req.Title) == "" {
+			return gatewayapi.RepoResult{}, gatewayapi.Errorf(http.StatusBadRequest, "title required")
+		}
+		args := []string{"pr", "create", "--title", req.Title, "--body", req.Body}
+		if req.Base != "" {
+			args = append(args, "--base", req.Base)
+		}
+		if req.Head != "" {
+			args = append(args, "--head", req.Head)
+		}
+		if req.Draft {
+			args = append(args, "--draft")
+		}
+		return repoResult(runGh(a.resolveRepo(req.Repo), args...))
+	})
+}
+
+// repoResult wraps the output of a git or gh command.
+func repoResult(out string, err error) (gatewayapi.RepoResult, error) {
+	if err != nil {
+		return gatewayapi.RepoResult{}, err
+	}
+	return gatewayapi.RepoResult{Result: out}, nil
+}
+
+// nonEmptyLines splits command output into trimmed, non-empty lines.
+func nonEmptyLines(out string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func (a *gatewayAPI) updateWorkRepo(r *http.Request, req gatewayapi.WorkRepoUpdate) (gatewayapi.WorkRepo, error) {
+	newPath := strings.TrimSpace(req.Path)
+	if newPath == "" {
+		return gatewayapi.WorkRepo{}, gatewayapi.Errorf(http.StatusBadRequest, "path required")
+	}
+	// If multiple absolute paths got concatenated, keep the last one.
+	if idx := strings.LastIndex(newPath, "/Users/"); idx > 0 {
+		newPath = newPath[idx:]
+	}
+	if idx := strings.LastIndex(newPath, "C:\\"); idx > 0 {
+		newPath = newPath[idx:]
+	}
+	if strings.HasPrefix(newPath, "~") {
+		home, _ := os.UserHomeDir()
+		newPath = filepath.Join(home, newPath[1:])
+	}
+	if !filepath.IsAbs(newPath) {
+		if abs, err := filepath.Abs(newPath); err == nil {
+			newPath = abs
+		}
+	}
+	if warn, err := config.EnsureWorkRepo(newPath); err != nil {
+		return gatewayapi.WorkRepo{}, err
+	} else if warn != "" {
+		fmt.Printf("Work repo warning: %s\n", warn)
+	}
+	if err := a.timeline.SetSetting("work_repo_path", newPath); err != nil {
+		return gatewayapi.WorkRepo{}, err
+	}
+	a.setWorkRepo(newPath)
+	return gatewayapi.WorkRepo{Status: "ok", Path: newPath}, nil
+}
+
+func (a *gatewayAPI) repoFile(r *http.Request, req gatewayapi.RepoPathRequest) (gatewayapi.RepoFile, error) {
+	repo := a.resolveRepo(req.Repo)
+	rel := filepath.Clean(strings.TrimSpace(req.Path))
+	if rel == "" || rel == "." || strings.Contains(rel, "..") {
+		return gatewayapi.RepoFile{}, gatewayapi.Errorf(http.StatusBadRequest, "path required")
+	}
+	full := filepath.Join(repo, rel)
+	if verified, err := filepath.Rel(repo, full); err != nil || strings.HasPrefix(verified, "..") {
+		return gatewayapi.RepoFile{}, gatewayapi.Errorf(http.StatusBadRequest, "path outside repo")
+	}
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return gatewayapi.RepoFile{}, err
+	}
+	if !utf8.Valid(data) {
+		return gatewayapi.RepoFile{Path: rel, Content: "[binary file]"}, nil
+	}
+	if len(data) > 200_000 {
+		return gatewayapi.RepoFile{Path: rel, Content: string(data[:200_000]) + "\n... (truncated)"}, nil
+	}
+	return gatewayapi.RepoFile{Path: rel, Content: string(data)}, nil
+}
+
+func (a *gatewayAPI) searchRepos(r *http.Request, _ struct{}) (gatewayapi.RepoSearch, error) {
+	root, _ := a.timeline.GetSetting("default_repo_search_path")
+	root = strings.TrimSpace(root)
+	if root == "" {
+		return gatewayapi.RepoSearch{Repos: []string{}}, nil
+	}
+	if strings.HasPrefix(root, "~") {
+		home, _ := os.UserHomeDir()
+		root = filepath.Join(home, root[1:])
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return gatewayapi.RepoSearch{Root: root, Repos: []string{}}, nil
+	}
+	repos := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		path := filepath.Join(root, e.Name())
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+			repos = append(repos, path)
+		}
+	}
+	return gatewayapi.RepoSearch{Root: root, Repos: repos}, nil
+}
+
+func (a *gatewayAPI) initRepo(r *http.Request, req gatewayapi.RepoInitRequest) (gatewayapi.Ack, error) {
+	repo := a.resolveRepo(req.Repo)
+	if warn, err := config.EnsureWorkRepo(repo); err != nil {
+		return gatewayapi.Ack{}, err
+	} else if warn != "" {
+		fmt.Printf("Work repo warning: %s\n", warn)
+	}
+	remoteURL := strings.TrimSpace(req.RemoteURL)
+	if remoteURL != "" && !strings.HasPrefix(remoteURL, "-") {
+		_, _ = runGit(repo, "remote", "remove", "origin")
+		if _, err := runGit(repo, "remote", "add", "origin", remoteURL); err != nil {
+			return gatewayapi.Ack{}, err
+		}
+	}
+	return gatewayapi.Ack{Status: "ok"}, nil
+}
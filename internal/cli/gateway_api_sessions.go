@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/approval"
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+	"github.com/KafClaw/KafClaw/internal/rbac"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/KafClaw/KafClaw/internal/tools"
+)
+
+func (a *gatewayAPI) registerSessions(rt *gatewayapi.Router) {
+	gatewayapi.Handle(rt, "listTasks", func(r *http.Request, req gatewayapi.TasksRequest) ([]timeline.AgentTask, error) {
+		tasks, err := a.timeline.ListTasks(req.Status, req.Channel, defaultLimit(req.Limit, 50), req.Offset)
+		if tasks == nil {
+			tasks = []timeline.AgentTask{}
+		}
+		return tasks, err
+	})
+	gatewayapi.Handle(rt, "getTask", func(r *http.Request, req gatewayapi.TaskRequest) (timeline.AgentTask, error) {
+		task, err := a.timeline.GetTask(strings.TrimSpace(req.ID))
+		if err != nil {
+			return timeline.AgentTask{}, gatewayapi.Errorf(http.StatusNotFound, "task not found")
+		}
+		return *task, nil
+	})
+
+	gatewayapi.Handle(rt, "listSessions", func(r *http.Request, _ struct{}) ([]gatewayapi.SessionSummary, error) {
+		out := []gatewayapi.SessionSummary{}
+		for _, s := range a.loop.Sessions().List() {
+			out = append(out, gatewayapi.SessionSummary{Key: s.Key, CreatedAt: s.CreatedAt, UpdatedAt: s.UpdatedAt})
+		}
+		return out, nil
+	})
+	gatewayapi.Handle(rt, "getSessionHistory", func(r *http.Request, req gatewayapi.SessionHistoryRequest) (gatewayapi.SessionHistory, error) {
+		key := strings.TrimSpace(req.Key)
+		sess, ok := a.loop.Sessions().Get(key)
+		if !ok {
+			return gatewayapi.SessionHistory{}, gatewayapi.Errorf(http.StatusNotFound, "session not found")
+		}
+		return gatewayapi.SessionHistory{
+			Key:      key,
+			Messages: sess.GetHistory(defaultLimit(req.Limit, 20)),
+		}, nil
+	})
+	gatewayapi.Handle(rt, "listSubagents", func(r *http.Request, req gatewayapi.SessionRequest) ([]tools.SubagentRunView, error) {
+		key := strings.TrimSpace(req.Session)
+		if key == "" {
+			key = "cli:default"
+		}
+		return a.loop.Subagents(key), nil
+	})
+	gatewayapi.Handle(rt, "listProcesses", func(r *http.Request, req gatewayapi.SessionRequest) ([]tools.ProcessInfo, error) {
+		return a.loop.Processes().List(strings.TrimSpace(req.Session)), nil
+	})
+	gatewayapi.Handle(rt, "killProcess", func(r *http.Request, req gatewayapi.ProcessRequest) (tools.ProcessInfo, error) {
+		info, err := a.loop.Processes().Kill("", req.ID)
+		if err != nil {
+			return tools.ProcessInfo{}, gatewayapi.Errorf(http.StatusNotFound, "%s", err)
+		}
+		return info, nil
+	})
+
+	gatewayapi.Handle(rt, "listPendingApprovals", func(r *http.Request, _ struct{}) ([]timeline.ApprovalRecord, error) {
+		approvals, err := a.timeline.GetPendingApprovals()
+		if approvals == nil {
+			approvals = []timeline.ApprovalRecord{}
+		}
+		return approvals, err
+	})
+	gatewayapi.Handle(rt, "listApprovalGrants", func(r *http.Request, req gatewayapi.ApprovalGrantsRequest) ([]timeline.ApprovalGrantRecord, error) {
+		grants, err := approval.ListGrants(a.timeline, req.All)
+		if grants == nil {
+			grants = []timeline.ApprovalGrantRecord{}
+		}
+		return grants, err
+	})
+	gatewayapi.Handle(rt, "revokeApprovalGrant", func(r *http.Request, req gatewayapi.ApprovalGrantRequest) (gatewayapi.GrantRevoked, error) {
+		grantID := strings.TrimSpace(req.ID)
+		revokedBy := "api"
+		if id, ok := rbac.IdentityFrom(r.Context()); ok && id.Principal != "" {
+			revokedBy = "api:" + id.Principal
+		}
+		if err := approval.RevokeGrant(a.timeline, grantID, revokedBy); err != nil {
+			if errors.Is(err, approval.ErrGrantNotFound) {
+				return gatewayapi.GrantRevoked{}, gatewayapi.Errorf(http.StatusNotFound, "%s", err)
+			}
+			return gatewayapi.GrantRevoked{}, err
+		}
+		return gatewayapi.GrantRevoked{Status: "revoked", GrantID: grantID}, nil
+	})
+	gatewayapi.Handle(rt, "listApprovalEvents", func(r *http.Request, req gatewayapi.ApprovalRequest) ([]timeline.ApprovalEventRecord, error) {
+		events, err := a.timeline.ListApprovalEvents(strings.TrimSpace(req.ID))
+		if events == nil {
+			events = []timeline.ApprovalEventRecord{}
+		}
+		return events, err
+	})
+	gatewayapi.Handle(rt, "respondApproval", a.respondApproval)
+}
+
+// respondApproval hands an approve or deny decision to the agent through
+// the bus, where the approval manager picks it up. Admins answer from the
+// approval console, which overrides routing rules and quorum; other
+// principals answer as webui:<principal> under the approval rules.
+func (a *gatewayAPI) respondApproval(r *http.Request, req gatewayapi.ApprovalDecision) (gatewayapi.ApprovalSent, error) {
+	approvalID := strings.TrimSpace(req.ID)
+	remember := strings.TrimSpace(req.Remember + " " + req.Pattern)
+	if req.Approved && remember != "" {
+		if _, err := approval.ParseGrantSpec(remember); err != nil {
+			return gatewayapi.ApprovalSent{}, gatewayapi.Errorf(http.StatusBadRequest, "%s", err)
+		}
+	}
+
+	content := "deny:" + approvalID
+	if req.Approved {
+		content = strings.TrimSpace("approve:" + approvalID + " " + remember)
+	}
+	sender, chatID := approvalResponder(r)
+	a.bus.PublishInbound(&bus.InboundMessage{
+		Channel:   "webui",
+		SenderID:  sender,
+		ChatID:    chatID,
+		TraceID:   newTraceID(),
+		Content:   content,
+		Timestamp: time.Now(),
+		Metadata: map[string]any{
+			bus.MetaKeyMessageType: bus.MessageTypeInternal,
+		},
+	})
+	return gatewayapi.ApprovalSent{Status: "sent", ApprovalID: approvalID}, nil
+}
+
+// approvalResponder returns the sender and chat of a decision by the caller
+// of r. Only callers holding the admin scope use the operator chat: a token
+// of an admin narrowed to fewer scopes decides as its principal.
+func approvalResponder(r *http.Request) (sender, chatID string) {
+	id, ok := rbac.IdentityFrom(r.Context())
+	if !ok {
+		return "anonymous", "approval:anonymous"
+	}
+	sender = id.Principal
+	if sender == "" {
+		sender = "local"
+	}
+	if id.Allows(rbac.ScopeAdmin) {
+		return sender, "approval"
+	}
+	return sender, "approval:" + sender
+}
@@ -0,0 +1,273 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/agent"
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+	"github.com/KafClaw/KafClaw/internal/memory"
+	"github.com/KafClaw/KafClaw/internal/policy"
+	"github.com/KafClaw/KafClaw/internal/rbac"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestGatewayAPIRegistersEveryRoute(t *testing.T) {
+	api := gatewayapi.NewRouter(http.NewServeMux(), gatewayapi.ServerAPI)
+	dash := gatewayapi.NewRouter(http.NewServeMux(), gatewayapi.ServerDashboard)
+	(&gatewayAPI{}).register(api, dash)
+	if missing := append(api.Unregistered(), dash.Unregistered()...); len(missing) > 0 {
+		t.Fatalf("operations without a handler: %v", missing)
+	}
+}
+
+func TestDashboardPublicPathMatchesRouteTable(t *testing.T) {
+	for _, route := range gatewayapi.Routes() {
+		if route.Server != gatewayapi.ServerDashboard {
+			continue
+		}
+		path := route.Path
+		for _, name := range route.PathParams() {
+			path = strings.ReplaceAll(path, "{"+name+"}", "x")
+		}
+		r := httptest.NewRequest(route.Method, path, nil)
+		if got := dashboardPublicPath(r); got != route.Public {
+			t.Errorf("%s %s: public = %v, route table says %v", route.Method, route.Path, got, route.Public)
+		}
+	}
+}
+
+func TestApprovalResponderOnlyAdminsOverride(t *testing.T) {
+	cases := []struct {
+		id           *rbac.Identity
+		sender, chat string
+	}{
+		{&rbac.Identity{Principal: "alice", Method: rbac.MethodToken, Roles: []rbac.Role{rbac.RoleAdmin}, Scopes: rbac.AllScopes}, "alice", "approval"},
+		{&rbac.Identity{Principal: "alice", Method: rbac.MethodToken, Roles: []rbac.Role{rbac.RoleAdmin}, Scopes: []rbac.Scope{rbac.ScopeRead, rbac.ScopeApprovals}}, "alice", "approval:alice"},
+		{&rbac.Identity{Principal: "bob", Method: rbac.MethodToken, Roles: []rbac.Role{rbac.RoleOperator, rbac.RoleApprover}, Scopes: rbac.ScopesForRoles([]rbac.Role{rbac.RoleOperator, rbac.RoleApprover})}, "bob", "approval:bob"},
+		{&rbac.Identity{Method: rbac.MethodNone, Scopes: rbac.AllScopes}, "local", "approval"},
+		{nil, "anonymous", "approval:anonymous"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/approvals/ap1", nil)
+		if c.id != nil {
+			r = r.WithContext(rbac.WithIdentity(r.Context(), c.id))
+		}
+		if sender, chat := approvalResponder(r); sender != c.sender || chat != c.chat {
+			t.Errorf("%+v: got %s/%s, want %s/%s", c.id, sender, chat, c.sender, c.chat)
+		}
+	}
+}
+
+// contractServers runs both gateway servers on a fresh timeline with a
+// scripted LLM.
+func contractServers(t *testing.T) (apiSrv, dashSrv *httptest.Server, tl *timeline.TimelineService) {
+	t.Helper()
+	// The loop keeps its sessions under $HOME/.kafclaw.
+	t.Setenv("HOME", t.TempDir())
+	workspace := t.TempDir()
+	notes := filepath.Join(workspace, "notes.txt")
+	if err := os.WriteFile(notes, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("write notes: %v", err)
+	}
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	t.Cleanup(func() { _ = tl.Close() })
+
+	cfg := config.DefaultConfig()
+	cfg.Paths.Workspace = workspace
+	auth, err := rbac.NewAuthenticator(cfg.Gateway, tl)
+	if err != nil {
+		t.Fatalf("authenticator: %v", err)
+	}
+	msgBus := bus.NewMessageBus()
+	loop := agent.NewLoop(agent.LoopOptions{
+		Bus:           msgBus,
+		Provider:      &scriptedLLM{path: notes},
+		Timeline:      tl,
+		Policy:        policy.NewDefaultEngine(),
+		Workspace:     workspace,
+		WorkRepo:      workspace,
+		Model:         "scripted",
+		MaxIterations: 5,
+	})
+	a := &gatewayAPI{
+		ctx:           context.Background(),
+		cfg:           cfg,
+		timeline:      tl,
+		auth:          auth,
+		bus:           msgBus,
+		loop:          loop,
+		groups:        &groupState{},
+		startTime:     time.Now(),
+		expertise:     memory.NewExpertiseTracker(tl.DB()),
+		workingMemory: memory.NewWorkingMemoryStore(tl.DB()),
+		lifecycle:     memory.NewLifecycleManager(tl.DB(), memory.LifecycleConfig{}),
+		systemRepo:    workspace,
+		workRepo:      func() string { return workspace },
+		setWorkRepo:   func(string) {},
+		mode:          func() string { return "standalone" },
+		recalcMode:    func() {},
+	}
+	apiMux, dashMux := http.NewServeMux(), http.NewServeMux()
+	a.register(gatewayapi.NewRouter(apiMux, gatewayapi.ServerAPI), gatewayapi.NewRouter(dashMux, gatewayapi.ServerDashboard))
+	apiSrv = httptest.NewServer(apiMux)
+	t.Cleanup(apiSrv.Close)
+	dashSrv = httptest.NewServer(dashMux)
+	t.Cleanup(dashSrv.Close)
+	return apiSrv, dashSrv, tl
+}
+
+// contractCall sends a request for an operation and checks the response
+// against the served OpenAPI document.
+func contractCall(t *testing.T, v *gatewayapi.Validator, base, operationID, path string, body string) (int, []byte) {
+	t.Helper()
+	route := gatewayapi.MustLookup(operationID)
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, _ := http.NewRequest(route.Method, base+path, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s: %v", operationID, err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	if err := v.ValidateResponse(operationID, resp.StatusCode, resp.Header.Get("Content-Type"), raw); err != nil {
+		t.Errorf("%s %s: %v\n%s", route.Method, path, err, raw)
+	}
+	return resp.StatusCode, raw
+}
+
+func TestGatewayAPIResponsesMatchSpec(t *testing.T) {
+	apiSrv, dashSrv, tl := contractServers(t)
+
+	resp, err := http.Get(dashSrv.URL + "/api/openapi.json")
+	if err != nil {
+		t.Fatalf("get spec: %v", err)
+	}
+	served, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	want, err := gatewayapi.Spec(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(served, want) {
+		t.Fatalf("served OpenAPI document differs from the route table")
+	}
+	v, err := gatewayapi.NewValidator(served)
+	if err != nil {
+		t.Fatalf("parse spec: %v", err)
+	}
+
+	// Seed records so list responses carry items.
+	user, err := tl.CreateWebUser("alice")
+	if err != nil {
+		t.Fatalf("create web user: %v", err)
+	}
+	person, err := tl.CreatePerson("Alice")
+	if err != nil {
+		t.Fatalf("create person: %v", err)
+	}
+	_ = tl.AddEvent(&timeline.TimelineEvent{
+		EventID: "E1", TraceID: "trace-1", Timestamp: time.Now(), SenderID: "alice",
+		EventType: "TEXT", ContentText: "hi", Classification: "LOCAL_INBOUND", Authorized: true,
+	})
+
+	// Every GET operation of the dashboard, called with sample parameters.
+	// Routes that leave the process (OIDC, gh, the embedding runtime) or
+	// are not JSON (Prometheus) are skipped.
+	skip := map[string]bool{
+		"getMetrics": true, "oidcLogin": true, "oidcCallback": true, "logoutRedirect": true,
+		"getEmbeddingHealth": true, "getGitHubAuth": true, "getOpenAPI": true,
+	}
+	params := map[string]string{
+		"id":  "trace-1",
+		"key": "cli:default",
+	}
+	queries := map[string]string{
+		"getWebLink":             "web_user_id=" + strconv.FormatInt(user.ID, 10),
+		"getSettings":            "key=silent_mode",
+		"getRepoFile":            "path=notes.txt",
+		"getRepoFileDiff":        "path=notes.txt",
+		"listGroupTopicMessages": "topic=group.test.tasks",
+		"getGroupTopicDensity":   "topic=group.test.tasks",
+		"listPolicyDecisions":    "trace_id=trace-1",
+	}
+	for _, route := range gatewayapi.Routes() {
+		if route.Server != gatewayapi.ServerDashboard || route.Method != http.MethodGet || skip[route.OperationID] {
+			continue
+		}
+		path := route.Path
+		for _, name := range route.PathParams() {
+			value := params[name]
+			if route.OperationID == "getPerson" {
+				value = person.PersonID
+			}
+			path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(value))
+		}
+		if q := queries[route.OperationID]; q != "" {
+			path += "?" + q
+		}
+		contractCall(t, v, dashSrv.URL, route.OperationID, path, "")
+	}
+
+	// Mutations and their error responses.
+	contractCall(t, v, dashSrv.URL, "createWebUser", "/api/v1/webusers", `{"name":"bob"}`)
+	contractCall(t, v, dashSrv.URL, "createWebUser", "/api/v1/webusers", `{"name":""}`)
+	contractCall(t, v, dashSrv.URL, "setWebUserForceSend", "/api/v1/webusers/force", `{"web_user_id":`+strconv.FormatInt(user.ID, 10)+`,"force_send":true}`)
+	contractCall(t, v, dashSrv.URL, "createPerson", "/api/v1/persons", `{"display_name":"Bob"}`)
+	contractCall(t, v, dashSrv.URL, "updateSetting", "/api/v1/settings", `{"key":"silent_mode","value":"true"}`)
+	contractCall(t, v, dashSrv.URL, "joinGroup", "/api/v1/group/join", `{"group_name":"g"}`)
+	contractCall(t, v, dashSrv.URL, "respondApproval", "/api/v1/approvals/ap1", `{"approved":false}`)
+	if status, _ := contractCall(t, v, dashSrv.URL, "getRepoLog", "/api/v1/repo/log?limit=abc", ""); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid limit, got %d", status)
+	}
+
+	// The API server: local chat and the OpenAI-compatible endpoints.
+	contractCall(t, v, apiSrv.URL, "chat", "/chat?message=hi&session=cli:contract", "")
+	contractCall(t, v, apiSrv.URL, "listModels", "/v1/models", "")
+	contractCall(t, v, apiSrv.URL, "createChatCompletion", "/v1/chat/completions", `{"messages":[{"role":"user","content":"hi"}]}`)
+	contractCall(t, v, apiSrv.URL, "createChatCompletion", "/v1/chat/completions", `{"model":"nope","messages":[{"role":"user","content":"hi"}]}`)
+
+	stream, err := http.Post(apiSrv.URL+"/v1/chat/completions", "application/json",
+		strings.NewReader(`{"stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"hi"}]}`))
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	defer stream.Body.Close()
+	events := 0
+	scanner := bufio.NewScanner(stream.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok || data == "[DONE]" {
+			continue
+		}
+		events++
+		if err := v.ValidateStreamEvent("createChatCompletion", []byte(data)); err != nil {
+			t.Errorf("stream event: %v\n%s", err, data)
+		}
+	}
+	if events == 0 {
+		t.Fatalf("expected streamed chunks")
+	}
+}
@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/channels"
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func (a *gatewayAPI) registerUsers(rt *gatewayapi.Router) {
+	gatewayapi.Handle(rt, "listWebUsers", func(r *http.Request, _ struct{}) ([]timeline.WebUser, error) {
+		users, err := a.timeline.ListWebUsers()
+		if users == nil {
+			users = []timeline.WebUser{}
+		}
+		return users, err
+	})
+	gatewayapi.Handle(rt, "createWebUser", a.createWebUser)
+	gatewayapi.Handle(rt, "setWebUserForceSend", func(r *http.Request, req gatewayapi.WebUserForceSend) (gatewayapi.Ack, error) {
+		if req.WebUserID == 0 {
+			return gatewayapi.Ack{}, gatewayapi.Errorf(http.StatusBadRequest, "web_user_id required")
+		}
+		if err := a.timeline.SetWebUserForceSend(req.WebUserID, req.ForceSend); err != nil {
+			return gatewayapi.Ack{}, err
+		}
+		return gatewayapi.Ack{Status: "ok"}, nil
+	})
+	gatewayapi.Handle(rt, "getWebLink", func(r *http.Request, req gatewayapi.WebLinkRequest) (gatewayapi.WebLink, error) {
+		if req.WebUserID == 0 {
+			return gatewayapi.WebLink{}, gatewayapi.Errorf(http.StatusBadRequest, "invalid web_user_id")
+		}
+		jid, ok, err := a.timeline.GetWebLink(req.WebUserID)
+		if err != nil {
+			return gatewayapi.WebLink{}, err
+		}
+		if !ok {
+			jid = ""
+		}
+		return gatewayapi.WebLink{WebUserID: strconv.FormatInt(req.WebUserID, 10), WhatsAppJID: jid}, nil
+	})
+	gatewayapi.Handle(rt, "setWebLink", a.setWebLink)
+
+	gatewayapi.Handle(rt, "listPersons", func(r *http.Request, _ struct{}) ([]timeline.Person, error) {
+		persons, err := a.timeline.ListPersons()
+		if persons == nil {
+			persons = []timeline.Person{}
+		}
+		return persons, err
+	})
+	gatewayapi.Handle(rt, "createPerson", func(r *http.Request, req gatewayapi.CreatePersonRequest) (timeline.Person, error) {
+		person, err := a.timeline.CreatePerson(req.DisplayName)
+		if err != nil {
+			return timeline.Person{}, gatewayapi.Errorf(http.StatusBadRequest, "%s", err)
+		}
+		return *person, nil
+	})
+	gatewayapi.Handle(rt, "getPerson", func(r *http.Request, req gatewayapi.PersonRequest) (timeline.Person, error) {
+		person, err := a.timeline.GetPerson(req.ID)
+		if err != nil {
+			return timeline.Person{}, gatewayapi.Errorf(http.StatusNotFound, "%s", err)
+		}
+		return *person, nil
+	})
+	gatewayapi.Handle(rt, "createPersonLinkCode", func(r *http.Request, req gatewayapi.LinkCodeRequest) (gatewayapi.IdentityLinkCode, error) {
+		link, err := channels.NewPairingService(a.timeline).CreateIdentityLinkCode(req.ID, req.Channel, time.Duration(req.TTLMinutes)*time.Minute)
+		if err != nil {
+			return gatewayapi.IdentityLinkCode{}, gatewayapi.Errorf(http.StatusBadRequest, "%s", err)
+		}
+		return gatewayapi.IdentityLinkCode{
+			PersonID:  link.PersonID,
+			Channel:   link.Channel,
+			Code:      link.Code,
+			CreatedAt: link.CreatedAt,
+			ExpiresAt: link.ExpiresAt,
+		}, nil
+	})
+	gatewayapi.Handle(rt, "unlinkPersonIdentity", func(r *http.Request, req gatewayapi.UnlinkIdentityRequest) (gatewayapi.Ack, error) {
+		current, ok, err := a.timeline.ResolvePersonID(req.Channel, req.SenderID)
+		if err != nil {
+			return gatewayapi.Ack{}, err
+		}
+		if !ok || current != req.ID {
+			return gatewayapi.Ack{}, gatewayapi.Errorf(http.StatusNotFound, "identity is not linked to this person")
+		}
+		if _, err := a.timeline.UnlinkPersonIdentity(req.Channel, req.SenderID); err != nil {
+			return gatewayapi.Ack{}, err
+		}
+		return gatewayapi.Ack{Status: "ok"}, nil
+	})
+	gatewayapi.Handle(rt, "mergePerson", func(r *http.Request, req gatewayapi.MergePersonRequest) (timeline.Person, error) {
+		if err := a.timeline.MergePersons(req.ID, req.Into); err != nil {
+			return timeline.Person{}, gatewayapi.Errorf(http.StatusBadRequest, "%s", err)
+		}
+		person, err := a.timeline.GetPerson(req.Into)
+		if err != nil {
+			return timeline.Person{}, err
+		}
+		return *person, nil
+	})
+	gatewayapi.Handle(rt, "setPersonPreference", func(r *http.Request, req gatewayapi.PersonPreferenceRequest) (gatewayapi.Ack, error) {
+		if err := a.timeline.SetPersonPreference(req.ID, req.Key, req.Value); err != nil {
+			return gatewayapi.Ack{}, gatewayapi.Errorf(http.StatusBadRequest, "%s", err)
+		}
+		return gatewayapi.Ack{Status: "ok"}, nil
+	})
+
+	gatewayapi.Handle(rt, "sendWebChat", a.sendWebChat)
+}
+
+func (a *gatewayAPI) createWebUser(r *http.Request, req gatewayapi.CreateWebUserRequest) (timeline.WebUser, error) {
+	user, err := a.timeline.CreateWebUser(strings.TrimSpace(req.Name))
+	if err != nil {
+		return timeline.WebUser{}, gatewayapi.Errorf(http.StatusBadRequest, "%s", err)
+	}
+	if principal := strings.TrimSpace(req.Principal); principal != "" {
+		if err := a.timeline.SetWebUserPrincipal(user.ID, principal); err != nil {
+			return timeline.WebUser{}, err
+		}
+		user.Principal = principal
+	}
+	return *user, nil
+}
+
+func (a *gatewayAPI) setWebLink(r *http.Request, req gatewayapi.WebLinkUpdate) (gatewayapi.Ack, error) {
+	if req.WebUserID == 0 {
+		return gatewayapi.Ack{}, gatewayapi.Errorf(http.StatusBadRequest, "web_user_id required")
+	}
+	if strings.TrimSpace(req.WhatsAppJID) == "" {
+		if err := a.timeline.UnlinkWebUser(req.WebUserID); err != nil {
+			return gatewayapi.Ack{}, err
+		}
+	} else {
+		jid := normalizeWhatsAppJID(strings.TrimSpace(req.WhatsAppJID))
+		if err := a.timeline.LinkWebUser(req.WebUserID, jid); err != nil {
+			return gatewayapi.Ack{}, err
+		}
+	}
+	return gatewayapi.Ack{Status: "ok"}, nil
+}
+
+// sendWebChat hands a web user's message to the agent. When the user is
+// linked to a WhatsApp chat, the input is mirrored there as well, subject
+// to silent mode.
+func (a *gatewayAPI) sendWebChat(r *http.Request, req gatewayapi.WebChatRequest) (gatewayapi.Ack, error) {
+	if req.WebUserID == 0 || strings.TrimSpace(req.Message) == "" {
+		return gatewayapi.Ack{}, gatewayapi.Errorf(http.StatusBadRequest, "web_user_id and message required")
+	}
+	user, err := a.timeline.GetWebUser(req.WebUserID)
+	if err != nil {
+		return gatewayapi.Ack{}, gatewayapi.Errorf(http.StatusBadRequest, "web user not found")
+	}
+	traceID := newTraceID()
+
+	// Resolve link (optional) and maybe forward the input itself to WhatsApp
+	jid, ok, err := a.timeline.GetWebLink(req.WebUserID)
+	if err != nil {
+		return gatewayapi.Ack{}, gatewayapi.Errorf(http.StatusInternalServerError, "link lookup failed")
+	}
+	if ok && jid != "" {
+		jid = normalizeWhatsAppJID(jid)
+		forceSend := user.ForceSend
+		status := "queued"
+
+		if a.timeline.IsSilentMode() && !forceSend {
+			fmt.Printf("🔇 webui input suppressed (silent mode) to %s web_user_id=%d\n", jid, req.WebUserID)
+			status = "suppressed"
+		} else if a.timeline.IsSilentMode() && forceSend {
+			sendCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := a.whatsapp.Send(sendCtx, &bus.OutboundMessage{
+				Channel: "whatsapp",
+				ChatID:  jid,
+				TraceID: traceID,
+				Content: req.Message,
+			}); err != nil {
+				fmt.Printf("⚠️ webui input direct send error: %v\n", err)
+				status = "error"
+			} else {
+				status = "sent"
+			}
+		} else {
+			a.bus.PublishOutbound(&bus.OutboundMessage{
+				Channel: "whatsapp",
+				ChatID:  jid,
+				TraceID: traceID,
+				Content: req.Message,
+			})
+		}
+
+		_ = a.timeline.AddEvent(&timeline.TimelineEvent{
+			EventID:        fmt.Sprintf("WEBUI_INPUT_ACK_%d", time.Now().UnixNano()),
+			TraceID:        traceID,
+			Timestamp:      time.Now(),
+			SenderID:       "AGENT",
+			SenderName:     "Agent",
+			EventType:      "SYSTEM",
+			ContentText:    req.Message,
+			Classification: fmt.Sprintf("WEBUI_INPUT_OUTBOUND->%s force=%v status=%s", jid, forceSend, status),
+			Authorized:     true,
+		})
+	}
+
+	// Log inbound from Web UI
+	webuiInMeta, _ := json.Marshal(map[string]any{
+		"channel":      "webui",
+		"sender":       user.Name,
+		"message_type": "TEXT",
+		"content":      req.Message,
+	})
+	_ = a.timeline.AddEvent(&timeline.TimelineEvent{
+		EventID:        fmt.Sprintf("WEBUI_IN_%d", time.Now().UnixNano()),
+		TraceID:        traceID,
+		Timestamp:      time.Now(),
+		SenderID:       fmt.Sprintf("webui:%s", user.Name),
+		SenderName:     user.Name,
+		EventType:      "TEXT",
+		ContentText:    req.Message,
+		Classification: "WEBUI_INBOUND",
+		Authorized:     true,
+		Metadata:       string(webuiInMeta),
+	})
+
+	// Publish inbound to agent
+	a.bus.PublishInbound(&bus.InboundMessage{
+		Channel:        "webui",
+		SenderID:       fmt.Sprintf("webui:%s", user.Name),
+		ChatID:         fmt.Sprintf("%d", req.WebUserID),
+		TraceID:        traceID,
+		IdempotencyKey: "web:" + traceID,
+		Content:        req.Message,
+		Timestamp:      time.Now(),
+		Metadata: map[string]any{
+			bus.MetaKeyMessageType: bus.MessageTypeExternal,
+		},
+	})
+	return gatewayapi.Ack{Status: "queued"}, nil
+}
@@ -291,15 +291,14 @@ func TestCollectMemoryKnowledgeMetrics(t *testing.T) {
 	if err != nil {
 		t.Fatalf("collect metrics: %v", err)
 	}
-	if got["status"] != "ok" {
-		t.Fatalf("expected status ok, got %#v", got["status"])
+	if got.Status != "ok" {
+		t.Fatalf("expected status ok, got %#v", got.Status)
 	}
-	mem, ok := got["memory"].(map[string]any)
-	if !ok {
+	if got.Memory == nil {
 		t.Fatalf("missing memory metrics: %#v", got)
 	}
-	if mem["overflowEvents"] != 3 {
-		t.Fatalf("expected overflowEvents=3, got %#v", mem["overflowEvents"])
+	if got.Memory.OverflowEvents != 3 {
+		t.Fatalf("expected overflowEvents=3, got %#v", got.Memory.OverflowEvents)
 	}
 }
 
@@ -0,0 +1,410 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/agent"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+	"github.com/KafClaw/KafClaw/internal/rbac"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// openaiDefaultModel is the model ID of the gateway's own agent.
+const openaiDefaultModel = "kafclaw"
+
+// openaiSessionHeader selects one of the caller's agent sessions; it takes
+// precedence over the request's user field.
+const openaiSessionHeader = "X-KafClaw-Session"
+
+// openaiKeepAlive is the interval of SSE comments sent while a streamed
+// turn waits on tools or approvals.
+var openaiKeepAlive = 15 * time.Second
+
+// openaiAPI serves an OpenAI-compatible chat completions API backed by the
+// full agent loop (tools, memory, policy, approvals).
+type openaiAPI struct {
+	cfg      *config.Config
+	loop     *agent.Loop
+	timeline *timeline.TimelineService
+}
+
+type openaiChatRequest struct {
+	Model         string              `json:"model"`
+	Messages      []openaiChatMessage `json:"messages"`
+	Stream        bool                `json:"stream"`
+	StreamOptions *struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options,omitempty"`
+	User string `json:"user,omitempty"`
+}
+
+type openaiChatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+type openaiUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// openaiExtension is the non-standard "kafclaw" object of responses and
+// chunks; OpenAI clients ignore it.
+type openaiExtension struct {
+	TraceID   string            `json:"trace_id,omitempty"`
+	Session   string            `json:"session,omitempty"`
+	Approvals []agent.TurnEvent `json:"approvals,omitempty"`
+	Event     *agent.TurnEvent  `json:"event,omitempty"`
+}
+
+func (a *openaiAPI) register(rt *gatewayapi.Router) {
+	rt.HandleFunc("listModels", a.handleModels)
+	rt.HandleFunc("createChatCompletion", a.handleChatCompletions)
+}
+
+// models lists the gateway agent and every agents.list entry.
+func (a *openaiAPI) models() []string {
+	ids := []string{openaiDefaultModel}
+	if a.cfg != nil && a.cfg.Agents != nil {
+		for _, entry := range a.cfg.Agents.List {
+			if id := strings.TrimSpace(entry.ID); id != "" && id != openaiDefaultModel {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// resolveAgent maps the request model to an agents.list ID; the default
+// model and an empty model use the gateway's own agent ("").
+func (a *openaiAPI) resolveAgent(model string) (string, bool) {
+	model = strings.TrimSpace(model)
+	if model == "" || model == openaiDefaultModel {
+		return "", true
+	}
+	if a.cfg != nil && a.cfg.Agents != nil {
+		for _, entry := range a.cfg.Agents.List {
+			id := strings.TrimSpace(entry.ID)
+			if id == model || (entry.Name != "" && strings.EqualFold(strings.TrimSpace(entry.Name), model)) {
+				return id, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (a *openaiAPI) handleModels(w http.ResponseWriter, r *http.Request) {
+	data := make([]map[string]any, 0)
+	for _, id := range a.models() {
+		data = append(data, map[string]any{"id": id, "object": "model", "created": 0, "owned_by": "kafclaw"})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"object": "list", "data": data})
+}
+
+func (a *openaiAPI) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req openaiChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "", "invalid JSON body: "+err.Error())
+		return
+	}
+	agentID, ok := a.resolveAgent(req.Model)
+	if !ok {
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "model_not_found",
+			fmt.Sprintf("model %q does not exist; GET /v1/models lists the agents", req.Model))
+		return
+	}
+	if len(req.Messages) == 0 || req.Messages[len(req.Messages)-1].Role != "user" {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "", "the last message must have role user")
+		return
+	}
+	content := openaiMessageText(req.Messages[len(req.Messages)-1].Content)
+	if strings.TrimSpace(content) == "" {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "", "the last user message is empty")
+		return
+	}
+	model := strings.TrimSpace(req.Model)
+	if model == "" {
+		model = openaiDefaultModel
+	}
+	sessionKey := a.sessionKey(r, req.User)
+	a.seedSession(sessionKey, req.Messages[:len(req.Messages)-1])
+
+	traceID := newTraceID()
+	w.Header().Set("X-KafClaw-Trace-Id", traceID)
+	w.Header().Set("X-KafClaw-Session", sessionKey)
+	a.logEvent(traceID, sessionKey, "OPENAI_IN", "OPENAI_INBOUND", content)
+
+	c := &openaiCompletion{
+		id:      "chatcmpl-" + traceID,
+		model:   model,
+		created: time.Now().Unix(),
+		ext:     openaiExtension{TraceID: traceID, Session: sessionKey},
+	}
+	if req.Stream {
+		includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+		a.stream(w, r, c, agentID, content, sessionKey, includeUsage)
+		return
+	}
+
+	turn := &agent.Turn{AgentID: agentID, OnEvent: c.recordApproval}
+	resp, err := a.loop.ProcessDirectWithTrace(agent.WithTurn(r.Context(), turn), content, sessionKey, traceID)
+	if err != nil {
+		a.logEvent(traceID, sessionKey, "OPENAI_OUT", "OPENAI_OUTBOUND status=error", err.Error())
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "", err.Error())
+		return
+	}
+	a.logEvent(traceID, sessionKey, "OPENAI_OUT", "OPENAI_OUTBOUND status=sent", resp)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"id":      c.id,
+		"object":  "chat.completion",
+		"created": c.created,
+		"model":   c.model,
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       map[string]string{"role": "assistant", "content": resp},
+			"finish_reason": "stop",
+		}},
+		"usage":   usageOf(turn),
+		"kafclaw": c.extension(),
+	})
+}
+
+// stream runs the turn and writes it as chat.completion.chunk SSE events.
+// Turn events (tool calls, approvals) are sent as chunks without choices
+// carrying a "kafclaw.event" object.
+func (a *openaiAPI) stream(w http.ResponseWriter, r *http.Request, c *openaiCompletion, agentID, content, sessionKey string, includeUsage bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "", "streaming unsupported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	events := make(chan agent.TurnEvent, 64)
+	turn := &agent.Turn{AgentID: agentID, OnEvent: func(ev agent.TurnEvent) {
+		c.recordApproval(ev)
+		select {
+		case events <- ev:
+		case <-ctx.Done():
+		}
+	}}
+	type result struct {
+		text string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		text, err := a.loop.ProcessDirectWithTrace(agent.WithTurn(ctx, turn), content, sessionKey, c.ext.TraceID)
+		done <- result{text, err}
+	}()
+
+	send := func(v any) {
+		b, _ := json.Marshal(v)
+		fmt.Fprintf(w, "data: %s\n\n", b)
+		flusher.Flush()
+	}
+	send(c.chunk(map[string]any{"role": "assistant", "content": ""}, nil))
+
+	keepAlive := time.NewTicker(openaiKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case ev := <-events:
+			chunk := c.chunk(nil, nil)
+			chunk["kafclaw"] = openaiExtension{TraceID: c.ext.TraceID, Event: &ev}
+			send(chunk)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case res := <-done:
+			// Drain events emitted just before the turn finished.
+			for n := len(events); n > 0; n-- {
+				ev := <-events
+				chunk := c.chunk(nil, nil)
+				chunk["kafclaw"] = openaiExtension{TraceID: c.ext.TraceID, Event: &ev}
+				send(chunk)
+			}
+			if res.err != nil {
+				a.logEvent(c.ext.TraceID, sessionKey, "OPENAI_OUT", "OPENAI_OUTBOUND status=error", res.err.Error())
+				send(map[string]any{"error": map[string]any{"message": res.err.Error(), "type": "server_error"}})
+			} else {
+				a.logEvent(c.ext.TraceID, sessionKey, "OPENAI_OUT", "OPENAI_OUTBOUND status=sent", res.text)
+				send(c.chunk(map[string]any{"content": res.text}, nil))
+				stop := "stop"
+				final := c.chunk(map[string]any{}, &stop)
+				final["kafclaw"] = c.extension()
+				send(final)
+				if includeUsage {
+					usage := c.chunk(nil, nil)
+					usage["usage"] = usageOf(turn)
+					send(usage)
+				}
+			}
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			flusher.Flush()
+			return
+		}
+	}
+}
+
+// sessionKey picks the agent session openai:<principal>:<client id>. The
+// client id (the X-KafClaw-Session header, the request's user field, then
+// "default") only selects among the authenticated principal's own sessions;
+// callers without a principal (auth disabled) share "anonymous".
+func (a *openaiAPI) sessionKey(r *http.Request, user string) string {
+	principal := "anonymous"
+	if id, ok := rbac.IdentityFrom(r.Context()); ok && id.Principal != "" {
+		principal = url.QueryEscape(id.Principal)
+	}
+	client := strings.TrimSpace(r.Header.Get(openaiSessionHeader))
+	if client == "" {
+		client = strings.TrimSpace(user)
+	}
+	if client == "" {
+		client = "default"
+	}
+	return "openai:" + principal + ":" + client
+}
+
+// seedSession adds the earlier messages of a request to a new session, so
+// clients that resend the whole conversation keep their context. Existing
+// sessions keep their own history; system messages are ignored since the
+// agent brings its own system prompt.
+func (a *openaiAPI) seedSession(sessionKey string, history []openaiChatMessage) {
+	sess := a.loop.Sessions().GetOrCreate(sessionKey)
+	if len(sess.GetHistory(1)) > 0 {
+		return
+	}
+	seeded := false
+	for _, m := range history {
+		if m.Role != "user" && m.Role != "assistant" {
+			continue
+		}
+		if text := openaiMessageText(m.Content); text != "" {
+			sess.AddMessage(m.Role, text)
+			seeded = true
+		}
+	}
+	if seeded {
+		a.loop.Sessions().Save(sess)
+	}
+}
+
+func (a *openaiAPI) logEvent(traceID, sessionKey, prefix, classification, text string) {
+	if a.timeline == nil {
+		return
+	}
+	sender, name := sessionKey, "OpenAI API"
+	if strings.HasSuffix(prefix, "_OUT") {
+		sender, name = "AGENT", "Agent"
+	}
+	_ = a.timeline.AddEvent(&timeline.TimelineEvent{
+		EventID:        fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano()),
+		TraceID:        traceID,
+		Timestamp:      time.Now(),
+		SenderID:       sender,
+		SenderName:     name,
+		EventType:      "TEXT",
+		ContentText:    text,
+		Classification: classification,
+		Authorized:     true,
+	})
+}
+
+// openaiCompletion holds the identity of one completion and the approvals
+// raised during its turn.
+type openaiCompletion struct {
+	id      string
+	model   string
+	created int64
+	ext     openaiExtension
+	mu      sync.Mutex
+}
+
+func (c *openaiCompletion) recordApproval(ev agent.TurnEvent) {
+	if ev.Type != agent.TurnEventApprovalRequired && ev.Type != agent.TurnEventApprovalResolved {
+		return
+	}
+	c.mu.Lock()
+	c.ext.Approvals = append(c.ext.Approvals, ev)
+	c.mu.Unlock()
+}
+
+func (c *openaiCompletion) extension() openaiExtension {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ext := c.ext
+	ext.Approvals = append([]agent.TurnEvent(nil), c.ext.Approvals...)
+	return ext
+}
+
+// chunk builds a chat.completion.chunk; a nil delta yields no choices.
+func (c *openaiCompletion) chunk(delta map[string]any, finishReason *string) map[string]any {
+	choices := []map[string]any{}
+	if delta != nil {
+		choices = append(choices, map[string]any{"index": 0, "delta": delta, "finish_reason": finishReason})
+	}
+	return map[string]any{
+		"id":      c.id,
+		"object":  "chat.completion.chunk",
+		"created": c.created,
+		"model":   c.model,
+		"choices": choices,
+	}
+}
+
+func usageOf(turn *agent.Turn) openaiUsage {
+	u := turn.Usage()
+	return openaiUsage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, TotalTokens: u.TotalTokens}
+}
+
+// openaiMessageText returns the text of a message content, which is a
+// string or an array of content parts.
+func openaiMessageText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(raw, &parts) != nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range parts {
+		if p.Type == "text" && p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func writeOpenAIError(w http.ResponseWriter, status int, errType, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	body := map[string]any{"message": message, "type": errType}
+	if code != "" {
+		body["code"] = code
+	}
+	json.NewEncoder(w).Encode(map[string]any{"error": body})
+}
@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/agent"
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+	"github.com/KafClaw/KafClaw/internal/policy"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/rbac"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// scriptedLLM reads notes.txt with a tool call, then answers. Each call
+// reports usage so the aggregated totals can be checked.
+type scriptedLLM struct {
+	mu    sync.Mutex
+	path  string
+	calls int
+}
+
+func (s *scriptedLLM) Chat(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	last := req.Messages[len(req.Messages)-1]
+	if last.Role == "tool" {
+		return &provider.ChatResponse{Content: "it says hello", Usage: provider.Usage{PromptTokens: 150, CompletionTokens: 5, TotalTokens: 155}}, nil
+	}
+	return &provider.ChatResponse{
+		ToolCalls: []provider.ToolCall{{ID: "call_read", Name: "read_file", Arguments: map[string]any{"path": s.path}}},
+		Usage:     provider.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120},
+	}, nil
+}
+func (s *scriptedLLM) Transcribe(context.Context, *provider.AudioRequest) (*provider.AudioResponse, error) {
+	return &provider.AudioResponse{}, nil
+}
+func (s *scriptedLLM) Speak(context.Context, *provider.TTSRequest) (*provider.TTSResponse, error) {
+	return &provider.TTSResponse{}, nil
+}
+func (s *scriptedLLM) DefaultModel() string { return "scripted" }
+
+func newOpenAITestServer(t *testing.T) (*httptest.Server, *agent.Loop) {
+	t.Helper()
+	// The loop keeps its sessions under $HOME/.kafclaw.
+	t.Setenv("HOME", t.TempDir())
+	workspace := t.TempDir()
+	notes := filepath.Join(workspace, "notes.txt")
+	if err := os.WriteFile(notes, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("write notes: %v", err)
+	}
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	t.Cleanup(func() { _ = tl.Close() })
+
+	cfg := config.DefaultConfig()
+	cfg.Agents = &config.AgentsConfig{List: []config.AgentListEntry{{ID: "writer", Name: "Writer"}}}
+	loop := agent.NewLoop(agent.LoopOptions{
+		Bus:           bus.NewMessageBus(),
+		Provider:      &scriptedLLM{path: notes},
+		Timeline:      tl,
+		Policy:        policy.NewDefaultEngine(),
+		Workspace:     workspace,
+		WorkRepo:      workspace,
+		Model:         "scripted",
+		MaxIterations: 5,
+	})
+	mux := http.NewServeMux()
+	(&openaiAPI{cfg: cfg, loop: loop, timeline: tl}).register(gatewayapi.NewRouter(mux, gatewayapi.ServerAPI))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, loop
+}
+
+func postChatCompletion(t *testing.T, srv *httptest.Server, body string, header map[string]string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestOpenAIModelsListsAgents(t *testing.T) {
+	srv, _ := newOpenAITestServer(t)
+	resp, err := http.Get(srv.URL + "/v1/models")
+	if err != nil {
+		t.Fatalf("get models: %v", err)
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Object string `json:"object"`
+		Data   []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.Object != "list" || len(out.Data) != 2 || out.Data[0].ID != "kafclaw" || out.Data[1].ID != "writer" {
+		t.Fatalf("unexpected models %+v", out)
+	}
+}
+
+func TestOpenAIChatCompletionReportsLoopUsage(t *testing.T) {
+	srv, loop := newOpenAITestServer(t)
+	body := `{"model":"kafclaw","user":"alice","messages":[
+		{"role":"system","content":"ignored"},
+		{"role":"user","content":"hi"},
+		{"role":"assistant","content":"hello"},
+		{"role":"user","content":[{"type":"text","text":"What is in notes.txt?"}]}]}`
+	resp := postChatCompletion(t, srv, body, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("X-KafClaw-Session"); got != "openai:anonymous:alice" {
+		t.Fatalf("expected session from user field, got %q", got)
+	}
+	var out struct {
+		Object  string `json:"object"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage   openaiUsage     `json:"usage"`
+		KafClaw openaiExtension `json:"kafclaw"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.Object != "chat.completion" || len(out.Choices) != 1 || out.Choices[0].Message.Content != "it says hello" {
+		t.Fatalf("unexpected completion %+v", out)
+	}
+	if out.Usage != (openaiUsage{PromptTokens: 250, CompletionTokens: 25, TotalTokens: 275}) {
+		t.Fatalf("expected usage summed over the loop, got %+v", out.Usage)
+	}
+	if out.KafClaw.TraceID == "" || out.KafClaw.Session != "openai:anonymous:alice" {
+		t.Fatalf("unexpected extension %+v", out.KafClaw)
+	}
+	// The earlier turns seeded the new session; the system message did not.
+	history := loop.Sessions().GetOrCreate("openai:anonymous:alice").GetHistory(10)
+	if len(history) < 2 || history[0].Content != "hi" || history[1].Content != "hello" {
+		t.Fatalf("expected seeded history, got %+v", history)
+	}
+}
+
+func TestOpenAISessionKeyIsScopedToPrincipal(t *testing.T) {
+	a := &openaiAPI{}
+	request := func(principal string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		r.Header.Set("X-KafClaw-Session", "shared")
+		return r.WithContext(rbac.WithIdentity(r.Context(), &rbac.Identity{Principal: principal}))
+	}
+	alice, bob := a.sessionKey(request("alice"), ""), a.sessionKey(request("bob"), "")
+	if alice != "openai:alice:shared" || bob != "openai:bob:shared" {
+		t.Fatalf("expected per-principal sessions, got %q and %q", alice, bob)
+	}
+	// A principal name cannot reach into another principal's sessions.
+	if got := a.sessionKey(request("eve:alice"), ""); got == alice || strings.HasPrefix(got, "openai:eve:") {
+		t.Fatalf("principal with a separator reached %q", got)
+	}
+}
+
+func TestOpenAIChatCompletionUnknownModel(t *testing.T) {
+	srv, _ := newOpenAITestServer(t)
+	resp := postChatCompletion(t, srv, `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp.StatusCode)
+	}
+	var out struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	if out.Error.Code != "model_not_found" {
+		t.Fatalf("unexpected error %+v", out)
+	}
+}
+
+func TestOpenAIChatCompletionStream(t *testing.T) {
+	srv, _ := newOpenAITestServer(t)
+	body := `{"model":"kafclaw","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"What is in notes.txt?"}]}`
+	resp := postChatCompletion(t, srv, body, map[string]string{"X-KafClaw-Session": "ci-run", "Accept": "text/event-stream"})
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	if got := resp.Header.Get("X-KafClaw-Session"); got != "openai:anonymous:ci-run" {
+		t.Fatalf("expected session from header, got %q", got)
+	}
+
+	var chunks []map[string]any
+	done := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		data := strings.TrimPrefix(line, "data: ")
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+		var chunk map[string]any
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("bad chunk %q: %v", data, err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if !done {
+		t.Fatal("stream did not end with [DONE]")
+	}
+
+	var events, content []string
+	var usage map[string]any
+	for _, c := range chunks {
+		if ext, ok := c["kafclaw"].(map[string]any); ok {
+			if ev, ok := ext["event"].(map[string]any); ok {
+				events = append(events, ev["type"].(string))
+			}
+		}
+		for _, choice := range c["choices"].([]any) {
+			if text, _ := choice.(map[string]any)["delta"].(map[string]any)["content"].(string); text != "" {
+				content = append(content, text)
+			}
+		}
+		if u, ok := c["usage"].(map[string]any); ok {
+			usage = u
+		}
+	}
+	if strings.Join(events, ",") != "tool_call,tool_result" {
+		t.Fatalf("unexpected events %v", events)
+	}
+	if strings.Join(content, "") != "it says hello" {
+		t.Fatalf("unexpected content %v", content)
+	}
+	if usage == nil || usage["total_tokens"].(float64) != 275 {
+		t.Fatalf("expected aggregated usage chunk, got %v", usage)
+	}
+}
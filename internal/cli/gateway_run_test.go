@@ -248,6 +248,9 @@ func TestRunGatewayServesDashboardEndpoints(t *testing.T) {
 	call(http.MethodGet, "/api/v1/tasks/nope", "")
 	call(http.MethodGet, "/api/v1/approvals/pending", "")
 	call(http.MethodPost, "/api/v1/approvals/nope", `{"status":"approved"}`)
+	call(http.MethodPost, "/api/v1/approvals/nope", `{"approved":true,"remember":"1h","pattern":"git push *"}`)
+	call(http.MethodGet, "/api/v1/approvals/grants?all=true", "")
+	call(http.MethodDelete, "/api/v1/approvals/grants/nope", "")
 	call(http.MethodGet, "/timeline", "")
 	call(http.MethodGet, "/group", "")
 	call(http.MethodGet, "/approvals", "")
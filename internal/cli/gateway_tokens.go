@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/approval"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/rbac"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gatewayTokensCmd = &cobra.Command{
+		Use:   "tokens",
+		Short: "Manage scoped gateway API tokens",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	gatewayTokensCreateCmd = &cobra.Command{
+		Use:   "create <principal>",
+		Short: "Create an API token for a principal in gateway.principals",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runGatewayTokensCreate,
+	}
+
+	gatewayTokensListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List API tokens",
+		Args:  cobra.NoArgs,
+		RunE:  runGatewayTokensList,
+	}
+
+	gatewayTokensRevokeCmd = &cobra.Command{
+		Use:   "revoke <token-id>",
+		Short: "Revoke an API token",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tl, err := openTimelineService()
+			if err != nil {
+				return err
+			}
+			defer tl.Close()
+			if err := rbac.RevokeToken(tl, args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Revoked token %s\n", args[0])
+			return nil
+		},
+	}
+)
+
+func init() {
+	gatewayTokensCreateCmd.Flags().String("name", "", "Label shown in token listings")
+	gatewayTokensCreateCmd.Flags().String("scopes", "", "Comma-separated scopes narrowing the principal's roles (default: all of them)")
+	gatewayTokensCreateCmd.Flags().String("ttl", "", "Token lifetime, e.g. 12h or 30d (default: no expiry)")
+	gatewayTokensListCmd.Flags().Bool("all", false, "Include revoked and expired tokens")
+	gatewayTokensListCmd.Flags().Bool("json", false, "Output machine-readable JSON")
+
+	gatewayTokensCmd.AddCommand(gatewayTokensCreateCmd)
+	gatewayTokensCmd.AddCommand(gatewayTokensListCmd)
+	gatewayTokensCmd.AddCommand(gatewayTokensRevokeCmd)
+	gatewayCmd.AddCommand(gatewayTokensCmd)
+}
+
+func runGatewayTokensCreate(cmd *cobra.Command, args []string) error {
+	name, _ := cmd.Flags().GetString("name")
+	scopesFlag, _ := cmd.Flags().GetString("scopes")
+	ttlFlag, _ := cmd.Flags().GetString("ttl")
+
+	scopes, err := rbac.ParseScopes(scopesFlag)
+	if err != nil {
+		return err
+	}
+	var ttl time.Duration
+	if strings.TrimSpace(ttlFlag) != "" {
+		// Same duration syntax as approval grants, including days.
+		spec, err := approval.ParseGrantSpec(ttlFlag)
+		if err != nil || spec.Scope != approval.ScopeTTL || spec.Pattern != "" {
+			return fmt.Errorf("invalid --ttl %q (use a duration such as 12h or 30d)", ttlFlag)
+		}
+		ttl = spec.TTL
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	tl, err := openTimelineService()
+	if err != nil {
+		return err
+	}
+	defer tl.Close()
+
+	token, rec, err := rbac.CreateToken(tl, cfg.Gateway.Principals, rbac.TokenSpec{
+		Principal: args[0],
+		Name:      name,
+		Scopes:    scopes,
+		TTL:       ttl,
+		CreatedBy: "cli",
+	})
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Created token %s for %s\n", rec.TokenID, rec.Principal)
+	if rec.ExpiresAt != nil {
+		fmt.Fprintf(w, "Expires: %s\n", rec.ExpiresAt.Local().Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "Token (shown once): %s\n", token)
+	return nil
+}
+
+func runGatewayTokensList(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	tl, err := openTimelineService()
+	if err != nil {
+		return err
+	}
+	defer tl.Close()
+
+	tokens, err := rbac.ListTokens(tl, all)
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tokens)
+	}
+	if len(tokens) == 0 {
+		fmt.Fprintln(w, "No API tokens.")
+		return nil
+	}
+	now := time.Now()
+	for _, t := range tokens {
+		status := "active"
+		switch {
+		case t.RevokedAt != nil:
+			status = "revoked"
+		case t.ExpiresAt != nil && !t.ExpiresAt.After(now):
+			status = "expired"
+		}
+		scopes := t.Scopes
+		if scopes == "" {
+			scopes = "all"
+		}
+		lastUsed := "never"
+		if t.LastUsedAt != nil {
+			lastUsed = t.LastUsedAt.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s [%s] %s %s… name=%q scopes=%s last_used=%s\n",
+			t.TokenID, status, t.Principal, t.Prefix, t.Name, scopes, lastUsed)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestGatewayTokensCommands(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgDir := filepath.Join(tmpDir, ".kafclaw")
+	if err := os.MkdirAll(cfgDir, 0o755); err != nil {
+		t.Fatalf("mkdir config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cfgDir, "config.json"), []byte(`{"gateway":{"principals":[{"name":"ci","roles":["operator"]}]}}`), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	origHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", origHome)
+	_ = os.Setenv("HOME", tmpDir)
+
+	if _, err := runRootCommand(t, "gateway", "tokens", "create", "nobody", "--name=", "--scopes=", "--ttl="); err == nil {
+		t.Fatal("expected unknown principal to fail")
+	}
+	if _, err := runRootCommand(t, "gateway", "tokens", "create", "ci", "--name=", "--scopes=settings", "--ttl="); err == nil {
+		t.Fatal("expected scope beyond the principal's roles to fail")
+	}
+	if _, err := runRootCommand(t, "gateway", "tokens", "create", "ci", "--name=", "--scopes=", "--ttl=always"); err == nil {
+		t.Fatal("expected invalid ttl to fail")
+	}
+
+	out, err := runRootCommand(t, "gateway", "tokens", "create", "ci", "--name=deploy", "--scopes=read,chat", "--ttl=30d")
+	if err != nil {
+		t.Fatalf("tokens create: %v", err)
+	}
+	if !strings.Contains(out, "Token (shown once): kct_") || !strings.Contains(out, "Expires:") {
+		t.Fatalf("unexpected create output %q", out)
+	}
+
+	out, err = runRootCommand(t, "gateway", "tokens", "list", "--all=false", "--json")
+	if err != nil {
+		t.Fatalf("tokens list: %v", err)
+	}
+	var tokens []timeline.APITokenRecord
+	if err := json.Unmarshal([]byte(out), &tokens); err != nil {
+		t.Fatalf("unmarshal tokens: %v\n%s", err, out)
+	}
+	if len(tokens) != 1 || tokens[0].Principal != "ci" || tokens[0].Scopes != "read,chat" {
+		t.Fatalf("unexpected tokens %+v", tokens)
+	}
+	if strings.Contains(out, "token_hash") || strings.Contains(out, `"hash"`) {
+		t.Fatalf("token hash must not be listed: %s", out)
+	}
+
+	id := tokens[0].TokenID
+	if out, err := runRootCommand(t, "gateway", "tokens", "revoke", id); err != nil || !strings.Contains(out, "Revoked token "+id) {
+		t.Fatalf("revoke: %v %q", err, out)
+	}
+	if _, err := runRootCommand(t, "gateway", "tokens", "revoke", id); err == nil {
+		t.Fatal("expected revoking a revoked token to fail")
+	}
+	out, _ = runRootCommand(t, "gateway", "tokens", "list", "--all", "--json=false")
+	if !strings.Contains(out, id+" [revoked] ci") {
+		t.Fatalf("expected revoked token with --all, got %q", out)
+	}
+}
@@ -7,12 +7,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"strings"
 	"time"
 
 	"github.com/KafClaw/KafClaw/internal/config"
 	"github.com/KafClaw/KafClaw/internal/group"
 	"github.com/KafClaw/KafClaw/internal/knowledge"
+	"github.com/KafClaw/KafClaw/internal/memory"
 	"github.com/KafClaw/KafClaw/internal/timeline"
 	"github.com/spf13/cobra"
 )
@@ -35,6 +37,8 @@ var (
 
 	knowledgeStatusFilter string
 	knowledgeLimit        int
+
+	knowledgeReportOnly bool
 )
 
 var knowledgeCmd = &cobra.Command{
@@ -75,6 +79,16 @@ var knowledgeFactsCmd = &cobra.Command{
 	RunE:  runKnowledgeFacts,
 }
 
+var knowledgeSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Request a facts snapshot from peer claws and show divergence",
+	Long: `Publishes a snapshot request with this claw's facts digest to the knowledge
+facts topic. Peers answer with the fact versions this claw is missing; the
+running gateway replays them. The report lists the last digest comparison
+with each peer.`,
+	RunE: runKnowledgeSync,
+}
+
 func init() {
 	knowledgeCmd.PersistentFlags().BoolVar(&knowledgeJSON, "json", false, "Output machine-readable JSON")
 
@@ -99,7 +113,10 @@ func init() {
 	knowledgeFactsCmd.Flags().StringVar(&knowledgeGroup, "group", "", "Group filter")
 	knowledgeFactsCmd.Flags().IntVar(&knowledgeLimit, "limit", 50, "Maximum rows to return")
 
-	knowledgeCmd.AddCommand(knowledgeStatusCmd, knowledgeProposeCmd, knowledgeVoteCmd, knowledgeDecisionsCmd, knowledgeFactsCmd)
+	knowledgeSyncCmd.Flags().StringVar(&knowledgeGroup, "group", "", "Knowledge group name (defaults to config knowledge.group)")
+	knowledgeSyncCmd.Flags().BoolVar(&knowledgeReportOnly, "report", false, "Only show the divergence report; do not request a snapshot")
+
+	knowledgeCmd.AddCommand(knowledgeStatusCmd, knowledgeProposeCmd, knowledgeVoteCmd, knowledgeDecisionsCmd, knowledgeFactsCmd, knowledgeSyncCmd)
 	rootCmd.AddCommand(knowledgeCmd)
 }
 
@@ -261,6 +278,17 @@ func runKnowledgeVote(cmd *cobra.Command, args []string) error {
 	if clawID == "" || instanceID == "" {
 		return fmt.Errorf("clawId/instanceId are required (configure node.clawId/node.instanceId or pass --as-claw/--as-instance)")
 	}
+	var tags []string
+	_ = json.Unmarshal([]byte(prop.Tags), &tags)
+	// The local claw reports its own role, zone and expertise for peers to
+	// display; tallies never trust them (see knowledgeBallots).
+	var role, zoneID string
+	var expertise float64
+	if strings.EqualFold(clawID, strings.TrimSpace(cfg.Node.ClawID)) {
+		role = strings.TrimSpace(cfg.Orchestrator.Role)
+		zoneID = strings.TrimSpace(cfg.Orchestrator.ZoneID)
+		expertise = knowledgeVoterExpertise(timeSvc, tags)
+	}
 	traceID := newTraceID()
 	if err := timeSvc.UpsertKnowledgeVote(&timeline.KnowledgeVoteRecord{
 		ProposalID: proposalID,
@@ -269,6 +297,9 @@ func runKnowledgeVote(cmd *cobra.Command, args []string) error {
 		Vote:       voteVal,
 		Reason:     strings.TrimSpace(knowledgeReason),
 		TraceID:    traceID,
+		Role:       role,
+		ZoneID:     zoneID,
+		Expertise:  expertise,
 	}); err != nil {
 		return err
 	}
@@ -276,30 +307,22 @@ func runKnowledgeVote(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	vmap := make(map[string]string, len(votes))
-	for _, v := range votes {
-		vmap[v.ClawID] = strings.ToLower(strings.TrimSpace(v.Vote))
-	}
 	poolSize := knowledgePoolSize
 	if poolSize <= 0 {
 		poolSize = estimateKnowledgePoolSize(timeSvc, cfg)
 	}
-	policy := knowledge.VotingPolicy{
-		Enabled:       cfg.Knowledge.Voting.Enabled,
-		MinPoolSize:   cfg.Knowledge.Voting.MinPoolSize,
-		QuorumYes:     cfg.Knowledge.Voting.QuorumYes,
-		QuorumNo:      cfg.Knowledge.Voting.QuorumNo,
-		Timeout:       time.Duration(cfg.Knowledge.Voting.TimeoutSec) * time.Second,
-		AllowSelfVote: cfg.Knowledge.Voting.AllowSelfVote,
-	}
-	decision := knowledge.EvaluateQuorum(
+	decision := knowledge.EvaluateBallots(
 		prop.ProposerClawID,
+		tags,
 		poolSize,
-		vmap,
+		knowledgeBallots(cfg, timeSvc, tags, votes),
 		prop.CreatedAt,
 		time.Now(),
-		policy,
+		knowledgeVotingPolicy(cfg),
 	)
+	if err := timeSvc.UpdateKnowledgeProposalTally(proposalID, decision.YesWeight, decision.NoWeight, decision.Rationale); err != nil {
+		return err
+	}
 	if decision.Status != knowledge.VoteStatusPending {
 		if err := timeSvc.UpdateKnowledgeProposalDecision(
 			proposalID,
@@ -310,6 +333,9 @@ func runKnowledgeVote(cmd *cobra.Command, args []string) error {
 		); err != nil {
 			return err
 		}
+		if _, err := knowledge.ApplyApprovedProposal(timeSvc, proposalID); err != nil {
+			return err
+		}
 	}
 
 	if knowledgePublish {
@@ -325,6 +351,9 @@ func runKnowledgeVote(cmd *cobra.Command, args []string) error {
 				ProposalID: proposalID,
 				Vote:       voteVal,
 				Reason:     strings.TrimSpace(knowledgeReason),
+				Role:       role,
+				ZoneID:     zoneID,
+				Expertise:  expertise,
 			},
 		}
 		if err := publishKnowledgeEnvelope(cfg, timeSvc, cfg.Knowledge.Topics.Votes, voteEnv); err != nil {
@@ -345,6 +374,9 @@ func runKnowledgeVote(cmd *cobra.Command, args []string) error {
 					Yes:        decision.Yes,
 					No:         decision.No,
 					Reason:     decision.Reason,
+					YesWeight:  decision.YesWeight,
+					NoWeight:   decision.NoWeight,
+					Rationale:  decision.Rationale,
 				},
 			}
 			if err := publishKnowledgeEnvelope(cfg, timeSvc, cfg.Knowledge.Topics.Decisions, decEnv); err != nil {
@@ -385,6 +417,23 @@ func runKnowledgeDecisions(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if !knowledgeJSON {
+		w := cmd.OutOrStdout()
+		if len(list) == 0 {
+			fmt.Fprintf(w, "No %s decisions.\n", status)
+			return nil
+		}
+		fmt.Fprintf(w, "%-24s %-9s %12s %12s  %s\n", "PROPOSAL", "STATUS", "YES (WEIGHT)", "NO (WEIGHT)", "RATIONALE")
+		for _, p := range list {
+			rationale := p.Rationale
+			if rationale == "" {
+				rationale = p.Reason
+			}
+			fmt.Fprintf(w, "%-24s %-9s %12s %12s  %s\n", p.ProposalID, p.Status,
+				fmt.Sprintf("%d (%g)", p.YesVotes, p.YesWeight), fmt.Sprintf("%d (%g)", p.NoVotes, p.NoWeight), rationale)
+		}
+		return nil
+	}
 	return printKnowledgeOutput(cmd.OutOrStdout(), map[string]any{
 		"status":    "ok",
 		"action":    "decisions",
@@ -420,6 +469,164 @@ func runKnowledgeFacts(cmd *cobra.Command, args []string) error {
 	})
 }
 
+func runKnowledgeSync(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := requireKnowledgeGovernanceEnabled(cfg); err != nil {
+		return err
+	}
+	timeSvc, err := loadGroupTimeline()
+	if err != nil {
+		return err
+	}
+	defer timeSvc.Close()
+
+	groupName := strings.TrimSpace(knowledgeGroup)
+	if groupName == "" {
+		groupName = strings.TrimSpace(cfg.Knowledge.Group)
+	}
+	if groupName == "" {
+		return fmt.Errorf("knowledge group is required")
+	}
+	root, digest, err := knowledge.LocalDigest(timeSvc, groupName)
+	if err != nil {
+		return err
+	}
+	out := map[string]any{
+		"status":    "ok",
+		"action":    "sync",
+		"group":     groupName,
+		"root":      root,
+		"facts":     len(digest),
+		"requested": false,
+	}
+	if !knowledgeReportOnly {
+		clawID, instanceID := strings.TrimSpace(cfg.Node.ClawID), strings.TrimSpace(cfg.Node.InstanceID)
+		if clawID == "" || instanceID == "" {
+			return fmt.Errorf("node.clawId and node.instanceId must be configured")
+		}
+		env, err := knowledge.NewSnapshotRequest(timeSvc, groupName, clawID, instanceID, time.Now())
+		if err != nil {
+			return err
+		}
+		if err := publishKnowledgeEnvelope(cfg, timeSvc, cfg.Knowledge.Topics.Facts, env); err != nil {
+			return err
+		}
+		out["requested"] = true
+		out["requestId"] = env.TraceID
+	}
+	peers, err := timeSvc.ListKnowledgeSyncPeers(groupName)
+	if err != nil {
+		return err
+	}
+	out["peers"] = peers
+	if knowledgeJSON {
+		return printKnowledgeOutput(cmd.OutOrStdout(), out)
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "group %s: %d fact(s), root %s\n", groupName, len(digest), root)
+	if id, ok := out["requestId"].(string); ok {
+		fmt.Fprintf(w, "snapshot requested: %s (peers answer to the running gateway)\n", id)
+	}
+	if len(peers) == 0 {
+		fmt.Fprintln(w, "No peer digests recorded yet.")
+		return nil
+	}
+	fmt.Fprintf(w, "%-20s %6s %8s %7s %6s %6s %9s  %s\n", "PEER", "FACTS", "MISSING", "BEHIND", "AHEAD", "EXTRA", "DIVERGED", "CHECKED")
+	for _, p := range peers {
+		state := ""
+		if p.Root == root {
+			state = "  in sync"
+		}
+		fmt.Fprintf(w, "%-20s %6d %8d %7d %6d %6d %9d  %s%s\n",
+			p.ClawID, p.FactCount, p.Missing, p.Behind, p.Ahead, p.Extra, p.Diverged, p.CheckedAt.Local().Format(time.RFC3339), state)
+	}
+	return nil
+}
+
+// knowledgeVotingPolicy builds the voting policy from knowledge.voting.
+func knowledgeVotingPolicy(cfg *config.Config) knowledge.VotingPolicy {
+	v := cfg.Knowledge.Voting
+	policy := knowledge.VotingPolicy{
+		Enabled:          v.Enabled,
+		MinPoolSize:      v.MinPoolSize,
+		QuorumYes:        v.QuorumYes,
+		QuorumNo:         v.QuorumNo,
+		Timeout:          time.Duration(v.TimeoutSec) * time.Second,
+		AllowSelfVote:    v.AllowSelfVote,
+		QuorumYesPercent: v.QuorumYesPercent,
+		QuorumNoPercent:  v.QuorumNoPercent,
+		Weights: knowledge.VoterWeights{
+			ClawWeights:     v.ClawWeights,
+			RoleWeights:     v.RoleWeights,
+			ZoneWeights:     v.ZoneWeights,
+			ExpertiseWeight: v.ExpertiseWeight,
+		},
+		VetoRoles: v.VetoRoles,
+	}
+	for _, tp := range v.TagPolicies {
+		policy.TagPolicies = append(policy.TagPolicies, knowledge.TagPolicy{
+			Tag:              tp.Tag,
+			QuorumYes:        tp.QuorumYes,
+			QuorumYesPercent: tp.QuorumYesPercent,
+			RequireYesRoles:  tp.RequireYesRoles,
+			VetoRoles:        tp.VetoRoles,
+		})
+	}
+	return policy
+}
+
+// knowledgeBallots turns stored votes into ballots. Role, zone and
+// expertise reported by a voter are ignored: the local claw's come from its
+// own config and expertise tracker, other claws' role and zone only from
+// knowledge.voting.voters. An unpinned remote claw votes without role or
+// zone and weighs 1 unless clawWeights says otherwise.
+func knowledgeBallots(cfg *config.Config, timeSvc *timeline.TimelineService, tags []string, votes []timeline.KnowledgeVoteRecord) []knowledge.Ballot {
+	localClaw := strings.TrimSpace(cfg.Node.ClawID)
+	out := make([]knowledge.Ballot, 0, len(votes))
+	for _, v := range votes {
+		b := knowledge.Ballot{ClawID: v.ClawID, Vote: v.Vote}
+		if localClaw != "" && strings.EqualFold(localClaw, strings.TrimSpace(v.ClawID)) {
+			b.Role = strings.TrimSpace(cfg.Orchestrator.Role)
+			b.ZoneID = strings.TrimSpace(cfg.Orchestrator.ZoneID)
+			b.Expertise = knowledgeVoterExpertise(timeSvc, tags)
+		}
+		for id, pin := range cfg.Knowledge.Voting.Voters {
+			if !strings.EqualFold(strings.TrimSpace(id), strings.TrimSpace(v.ClawID)) {
+				continue
+			}
+			if strings.TrimSpace(pin.Role) != "" {
+				b.Role = pin.Role
+			}
+			if strings.TrimSpace(pin.ZoneID) != "" {
+				b.ZoneID = pin.ZoneID
+			}
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// knowledgeVoterExpertise returns the local claw's best expertise score for
+// the skills named by the proposal's tags ("schema" or "schema:orders").
+func knowledgeVoterExpertise(timeSvc *timeline.TimelineService, tags []string) float64 {
+	tracker := memory.NewExpertiseTracker(timeSvc.DB())
+	best := 0.0
+	for _, tag := range tags {
+		skill, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), ":")
+		if skill == "" {
+			continue
+		}
+		if s, err := tracker.GetExpertise(skill); err == nil && s != nil && s.Score > best {
+			best = s.Score
+		}
+	}
+	return math.Min(1, best)
+}
+
 func estimateKnowledgePoolSize(timeSvc *timeline.TimelineService, cfg *config.Config) int {
 	if timeSvc != nil {
 		if members, err := timeSvc.ListGroupMembers(); err == nil {
@@ -463,6 +670,21 @@ func publishKnowledgeEnvelope(cfg *config.Config, timeSvc *timeline.TimelineServ
 	return err
 }
 
+// knowledgeEnvelopePublisher publishes agent-made proposals and facts to
+// their knowledge topics; nil when the node is not in a group.
+func knowledgeEnvelopePublisher(cfg *config.Config, timeSvc *timeline.TimelineService) func(knowledge.Envelope) error {
+	if cfg == nil || timeSvc == nil || !cfg.Knowledge.Enabled || !cfg.Group.Enabled {
+		return nil
+	}
+	return func(env knowledge.Envelope) error {
+		topic := cfg.Knowledge.Topics.Facts
+		if env.Type == knowledge.TypeProposal {
+			topic = cfg.Knowledge.Topics.Proposals
+		}
+		return publishKnowledgeEnvelope(cfg, timeSvc, topic, env)
+	}
+}
+
 func printKnowledgeOutput(w io.Writer, v map[string]any) error {
 	if knowledgeJSON {
 		b, _ := json.MarshalIndent(v, "", "  ")
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/knowledge"
 	"github.com/KafClaw/KafClaw/internal/timeline"
 )
 
@@ -159,3 +161,164 @@ func TestKnowledgeCLIRejectsWhenGovernanceDisabled(t *testing.T) {
 		t.Fatal("expected governance disabled error")
 	}
 }
+
+func TestKnowledgeSyncReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgDir := filepath.Join(tmpDir, ".kafclaw")
+	if err := os.MkdirAll(cfgDir, 0o755); err != nil {
+		t.Fatalf("mkdir config dir: %v", err)
+	}
+	cfg := `{
+	  "node": {"clawId":"local-claw","instanceId":"inst-local"},
+	  "knowledge": {"enabled": true, "governanceEnabled": true, "group": "g1"}
+	}`
+	if err := os.WriteFile(filepath.Join(cfgDir, "config.json"), []byte(cfg), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	origHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", origHome)
+	_ = os.Setenv("HOME", tmpDir)
+	defer func() { knowledgeReportOnly, knowledgeJSON = false, false }()
+
+	tl, err := timeline.NewTimelineService(filepath.Join(cfgDir, "timeline.db"))
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	if err := tl.UpsertKnowledgeFactLatest(&timeline.KnowledgeFactRecord{
+		FactID: "f1", GroupName: "g1", Subject: "service", Predicate: "runbook", Object: "v2", Version: 1, Source: "test", Tags: "[]",
+	}); err != nil {
+		t.Fatalf("upsert fact: %v", err)
+	}
+	if err := tl.UpsertKnowledgeSyncPeer(&timeline.KnowledgeSyncPeerRecord{
+		ClawID: "claw-b", GroupName: "g1", Root: "other", FactCount: 2, Missing: 1,
+	}); err != nil {
+		t.Fatalf("upsert peer: %v", err)
+	}
+	_ = tl.Close()
+
+	out, err := runRootCommand(t, "knowledge", "sync", "--report", "--json")
+	if err != nil {
+		t.Fatalf("knowledge sync --report --json: %v", err)
+	}
+	var payload struct {
+		Group     string `json:"group"`
+		Facts     int    `json:"facts"`
+		Requested bool   `json:"requested"`
+		Peers     []struct {
+			ClawID  string `json:"claw_id"`
+			Missing int    `json:"missing"`
+		} `json:"peers"`
+	}
+	if err := json.Unmarshal([]byte(out), &payload); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out)
+	}
+	if payload.Group != "g1" || payload.Facts != 1 || payload.Requested || len(payload.Peers) != 1 || payload.Peers[0].Missing != 1 {
+		t.Fatalf("unexpected sync report: %s", out)
+	}
+
+	out, err = runRootCommand(t, "knowledge", "sync", "--report", "--json=false")
+	if err != nil {
+		t.Fatalf("knowledge sync --report: %v", err)
+	}
+	if !strings.Contains(out, "claw-b") || !strings.Contains(out, "MISSING") || strings.Contains(out, "in sync") {
+		t.Fatalf("unexpected text report: %s", out)
+	}
+}
+
+func TestKnowledgeVoteWeightedPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgDir := filepath.Join(tmpDir, ".kafclaw")
+	if err := os.MkdirAll(cfgDir, 0o755); err != nil {
+		t.Fatalf("mkdir config dir: %v", err)
+	}
+	cfg := `{
+	  "node": {"clawId":"local-claw","instanceId":"inst-local"},
+	  "knowledge": {
+	    "enabled": true,
+	    "governanceEnabled": true,
+	    "group": "g1",
+	    "voting": {
+	      "enabled": true, "minPoolSize": 3, "quorumYes": 2, "quorumNo": 2, "timeoutSec": 120,
+	      "roleWeights": {"orchestrator": 2},
+	      "voters": {"claw-boss": {"role": "orchestrator"}},
+	      "tagPolicies": [{"tag": "security:*", "requireYesRoles": ["orchestrator"]}]
+	    }
+	  }
+	}`
+	if err := os.WriteFile(filepath.Join(cfgDir, "config.json"), []byte(cfg), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	origHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", origHome)
+	_ = os.Setenv("HOME", tmpDir)
+	defer func() { knowledgeJSON, knowledgeTags, knowledgePoolSize = false, "", 0 }()
+
+	if _, err := runRootCommand(t, "knowledge", "propose", "--proposal-id=p-sec", "--group=g1",
+		"--statement=Rotate TLS keys monthly", "--tags=security:tls", "--json"); err != nil {
+		t.Fatalf("knowledge propose: %v", err)
+	}
+	vote := func(claw string) map[string]any {
+		t.Helper()
+		out, err := runRootCommand(t, "knowledge", "vote", "--proposal-id=p-sec", "--vote=yes",
+			"--as-claw="+claw, "--as-instance=inst-"+claw, "--pool-size=4", "--json")
+		if err != nil {
+			t.Fatalf("knowledge vote %s: %v", claw, err)
+		}
+		var payload struct {
+			Decision map[string]any `json:"decision"`
+		}
+		if err := json.Unmarshal([]byte(out), &payload); err != nil {
+			t.Fatalf("decode vote output: %v\n%s", err, out)
+		}
+		return payload.Decision
+	}
+	vote("claw-a")
+	d := vote("claw-b")
+	if d["Status"] != "pending" || !strings.Contains(d["Rationale"].(string), "awaiting yes from orchestrator") {
+		t.Fatalf("expected pending for orchestrator yes, got %+v", d)
+	}
+	d = vote("claw-boss")
+	if d["Status"] != "approved" || d["YesWeight"].(float64) != 4 {
+		t.Fatalf("expected weighted approval, got %+v", d)
+	}
+
+	out, err := runRootCommand(t, "knowledge", "decisions", "--status=approved", "--json=false")
+	if err != nil {
+		t.Fatalf("knowledge decisions: %v", err)
+	}
+	if !strings.Contains(out, "p-sec") || !strings.Contains(out, "3 (4)") || !strings.Contains(out, "claw-boss yes 2 [orchestrator]") {
+		t.Fatalf("expected weighted tally in decisions output, got: %s", out)
+	}
+}
+
+func TestKnowledgeBallotsIgnoreReportedAttributes(t *testing.T) {
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	defer tl.Close()
+	cfg := config.DefaultConfig()
+	cfg.Node.ClawID = "local-claw"
+	cfg.Orchestrator.Role = "worker"
+	cfg.Orchestrator.ZoneID = "zone-local"
+	cfg.Knowledge.Voting.Voters = map[string]config.KnowledgeVoterConfig{"claw-boss": {Role: "orchestrator", ZoneID: "zone-hq"}}
+
+	ballots := knowledgeBallots(cfg, tl, []string{"schema"}, []timeline.KnowledgeVoteRecord{
+		{ClawID: "local-claw", Vote: "yes", Role: "orchestrator"},
+		{ClawID: "claw-boss", Vote: "yes", Role: "observer", ZoneID: "zone-x"},
+		{ClawID: "claw-liar", Vote: "no", Role: "orchestrator", ZoneID: "zone-hq", Expertise: 1},
+	})
+	want := []knowledge.Ballot{
+		{ClawID: "local-claw", Vote: "yes", Role: "worker", ZoneID: "zone-local"},
+		{ClawID: "claw-boss", Vote: "yes", Role: "orchestrator", ZoneID: "zone-hq"},
+		{ClawID: "claw-liar", Vote: "no"},
+	}
+	if len(ballots) != len(want) {
+		t.Fatalf("expected %d ballots, got %+v", len(want), ballots)
+	}
+	for i := range want {
+		if ballots[i] != want[i] {
+			t.Fatalf("ballot %d = %+v, want %+v", i, ballots[i], want[i])
+		}
+	}
+}
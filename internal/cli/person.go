@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/channels"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/spf13/cobra"
+)
+
+var personLinkTTL time.Duration
+
+var personCmd = &cobra.Command{
+	Use:   "person",
+	Short: "Manage the cross-channel person registry",
+}
+
+var personListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List persons and their linked channel identities",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeSvc, err := openTimelineService()
+		if err != nil {
+			return err
+		}
+		defer timeSvc.Close()
+
+		persons, err := timeSvc.ListPersons()
+		if err != nil {
+			return err
+		}
+		if len(persons) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No persons registered.")
+			return nil
+		}
+		for _, p := range persons {
+			printPerson(cmd.OutOrStdout(), &p)
+		}
+		return nil
+	},
+}
+
+var personShowCmd = &cobra.Command{
+	Use:   "show <person-id>",
+	Short: "Show a person with identities and preferences",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeSvc, err := openTimelineService()
+		if err != nil {
+			return err
+		}
+		defer timeSvc.Close()
+
+		p, err := timeSvc.GetPerson(args[0])
+		if err != nil {
+			return err
+		}
+		printPerson(cmd.OutOrStdout(), p)
+		return nil
+	},
+}
+
+var personCreateCmd = &cobra.Command{
+	Use:   "create <display-name>",
+	Short: "Register a new person",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeSvc, err := openTimelineService()
+		if err != nil {
+			return err
+		}
+		defer timeSvc.Close()
+
+		p, err := timeSvc.CreatePerson(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Created person %s (%s)\n", p.PersonID, p.DisplayName)
+		return nil
+	},
+}
+
+var personLinkCodeCmd = &cobra.Command{
+	Use:   "link-code <person-id> <channel>",
+	Short: "Issue a code that links a channel identity when sent on that channel",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeSvc, err := openTimelineService()
+		if err != nil {
+			return err
+		}
+		defer timeSvc.Close()
+
+		link, err := channels.NewPairingService(timeSvc).CreateIdentityLinkCode(args[0], args[1], personLinkTTL)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Send \"link %s\" from %s to link that identity to %s (expires %s)\n",
+			link.Code,
+			link.Channel,
+			link.PersonID,
+			link.ExpiresAt.Format(time.RFC3339),
+		)
+		return nil
+	},
+}
+
+var personUnlinkCmd = &cobra.Command{
+	Use:   "unlink <channel> <sender-id>",
+	Short: "Remove a channel identity from its person",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeSvc, err := openTimelineService()
+		if err != nil {
+			return err
+		}
+		defer timeSvc.Close()
+
+		ok, err := timeSvc.UnlinkPersonIdentity(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("identity %s %s is not linked", args[0], args[1])
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Unlinked %s sender %s\n", args[0], args[1])
+		return nil
+	},
+}
+
+var personMergeCmd = &cobra.Command{
+	Use:   "merge <from-person-id> <into-person-id>",
+	Short: "Merge one person into another (identities, working memory, preferences)",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeSvc, err := openTimelineService()
+		if err != nil {
+			return err
+		}
+		defer timeSvc.Close()
+
+		if err := timeSvc.MergePersons(args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Merged %s into %s\n", args[0], args[1])
+		return nil
+	},
+}
+
+var personPrefCmd = &cobra.Command{
+	Use:   "pref <person-id> <key> [value]",
+	Short: "Set a person preference (omit value to remove it)",
+	Args:  cobra.RangeArgs(2, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeSvc, err := openTimelineService()
+		if err != nil {
+			return err
+		}
+		defer timeSvc.Close()
+
+		value := ""
+		if len(args) == 3 {
+			value = args[2]
+		}
+		if err := timeSvc.SetPersonPreference(args[0], args[1], value); err != nil {
+			return err
+		}
+		if value == "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed preference %s for %s\n", args[1], args[0])
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Set preference %s=%s for %s\n", args[1], value, args[0])
+		}
+		return nil
+	},
+}
+
+func printPerson(out io.Writer, p *timeline.Person) {
+	fmt.Fprintf(out, "%s %s\n", p.PersonID, p.DisplayName)
+	for _, id := range p.Identities {
+		fmt.Fprintf(out, "  %s %s (linked %s)\n", id.Channel, id.SenderID, id.LinkedAt.Format(time.RFC3339))
+	}
+	keys := make([]string, 0, len(p.Preferences))
+	for k := range p.Preferences {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(out, "  pref %s=%s\n", k, p.Preferences[k])
+	}
+}
+
+func init() {
+	personLinkCodeCmd.Flags().DurationVar(&personLinkTTL, "ttl", 15*time.Minute, "How long the link code stays valid")
+	personCmd.AddCommand(personListCmd)
+	personCmd.AddCommand(personShowCmd)
+	personCmd.AddCommand(personCreateCmd)
+	personCmd.AddCommand(personLinkCodeCmd)
+	personCmd.AddCommand(personUnlinkCmd)
+	personCmd.AddCommand(personMergeCmd)
+	personCmd.AddCommand(personPrefCmd)
+	rootCmd.AddCommand(personCmd)
+}
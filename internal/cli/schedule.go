@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/scheduler"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scheduleCmd = &cobra.Command{
+		Use:   "schedule",
+		Short: "Manage scheduled agent jobs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	scheduleAddCmd = &cobra.Command{
+		Use:   "add",
+		Short: "Schedule a one-off, cron or interval job",
+		Example: `  kafclaw schedule add --when "in 2 hours" --message "Check the deploy"
+  kafclaw schedule add --name standup --when "every weekday 9:00 Europe/Berlin" --message "Summarize open PRs" --channel slack --chat C123
+  kafclaw schedule add --when "cron */30 * * * *" --message "Poll the queue" --misfire skip`,
+		Args: cobra.NoArgs,
+		RunE: runScheduleAdd,
+	}
+
+	scheduleListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List scheduled jobs",
+		Args:  cobra.NoArgs,
+		RunE:  runScheduleList,
+	}
+
+	scheduleRmCmd = &cobra.Command{
+		Use:     "rm <name>",
+		Aliases: []string{"remove"},
+		Short:   "Remove a scheduled job and its run history",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withScheduleTimeline(func(tl *timeline.TimelineService) error {
+				if err := scheduler.RemoveJob(tl, args[0]); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Removed job %s\n", args[0])
+				return nil
+			})
+		},
+	}
+
+	schedulePauseCmd = &cobra.Command{
+		Use:   "pause <name>",
+		Short: "Pause a scheduled job",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withScheduleTimeline(func(tl *timeline.TimelineService) error {
+				if err := scheduler.PauseJob(tl, args[0]); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Paused job %s\n", args[0])
+				return nil
+			})
+		},
+	}
+
+	scheduleResumeCmd = &cobra.Command{
+		Use:   "resume <name>",
+		Short: "Resume a paused job; runs missed while paused are skipped",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withScheduleTimeline(func(tl *timeline.TimelineService) error {
+				job, err := scheduler.ResumeJob(tl, args[0], time.Now())
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Resumed job %s (next run %s)\n", job.JobName, formatNextRun(job))
+				return nil
+			})
+		},
+	}
+
+	scheduleRunsCmd = &cobra.Command{
+		Use:   "runs <name>",
+		Short: "Show the run history of a job",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runScheduleRuns,
+	}
+)
+
+func init() {
+	scheduleAddCmd.Flags().String("when", "", "Schedule, e.g. \"in 2 hours\", \"every weekday 9:00 Europe/Berlin\", \"every 15m\", \"cron 0 8 * * 1\"")
+	scheduleAddCmd.Flags().String("message", "", "Prompt the agent runs when the job is due")
+	scheduleAddCmd.Flags().String("name", "", "Job name (default: generated)")
+	scheduleAddCmd.Flags().String("channel", "", "Channel to run in and reply to (default: internal scheduler session)")
+	scheduleAddCmd.Flags().String("chat", "", "Chat ID on the channel")
+	scheduleAddCmd.Flags().String("thread", "", "Thread ID on the channel")
+	scheduleAddCmd.Flags().String("sender", "", "Sender ID the job runs as")
+	scheduleAddCmd.Flags().String("misfire", scheduler.MisfireRunOnce, "Runs missed during downtime: run_once, skip or run_all")
+	scheduleAddCmd.Flags().Duration("timeout", 0, "Run timeout (default: scheduler.jobTimeout)")
+	scheduleAddCmd.Flags().String("tz", "", "Timezone for clock times (default: scheduler.timezone or host timezone)")
+	scheduleListCmd.Flags().Bool("json", false, "Output machine-readable JSON")
+	scheduleRunsCmd.Flags().Int("limit", 20, "Maximum runs to show")
+	scheduleRunsCmd.Flags().Bool("json", false, "Output machine-readable JSON")
+
+	scheduleCmd.AddCommand(scheduleAddCmd)
+	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleRmCmd)
+	scheduleCmd.AddCommand(schedulePauseCmd)
+	scheduleCmd.AddCommand(scheduleResumeCmd)
+	scheduleCmd.AddCommand(scheduleRunsCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
+
+func runScheduleAdd(cmd *cobra.Command, args []string) error {
+	when, _ := cmd.Flags().GetString("when")
+	message, _ := cmd.Flags().GetString("message")
+	name, _ := cmd.Flags().GetString("name")
+	channel, _ := cmd.Flags().GetString("channel")
+	chat, _ := cmd.Flags().GetString("chat")
+	thread, _ := cmd.Flags().GetString("thread")
+	sender, _ := cmd.Flags().GetString("sender")
+	misfire, _ := cmd.Flags().GetString("misfire")
+	tz, _ := cmd.Flags().GetString("tz")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if strings.TrimSpace(when) == "" || strings.TrimSpace(message) == "" {
+		return fmt.Errorf("--when and --message are required")
+	}
+	channel, chat = strings.TrimSpace(channel), strings.TrimSpace(chat)
+	if (channel == "") != (chat == "") {
+		return fmt.Errorf("--channel and --chat must be set together")
+	}
+
+	if strings.TrimSpace(tz) == "" {
+		if cfg, err := config.Load(); err == nil {
+			tz = cfg.Scheduler.Timezone
+		}
+	}
+	loc, err := scheduler.Location(tz)
+	if err != nil {
+		return err
+	}
+	return withScheduleTimeline(func(tl *timeline.TimelineService) error {
+		job, err := scheduler.AddJob(tl, scheduler.JobSpec{
+			Name:    name,
+			When:    when,
+			Content: message,
+			Target: scheduler.Target{
+				Channel:  channel,
+				ChatID:   chat,
+				ThreadID: strings.TrimSpace(thread),
+				SenderID: strings.TrimSpace(sender),
+			},
+			Misfire:   misfire,
+			Timeout:   timeout,
+			CreatedBy: "cli",
+		}, time.Now(), loc)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Scheduled job %s: %s (next run %s)\n", job.JobName, scheduler.DescribeSchedule(*job), formatNextRun(job))
+		return nil
+	})
+}
+
+func runScheduleList(cmd *cobra.Command, args []string) error {
+	asJSON, _ := cmd.Flags().GetBool("json")
+	return withScheduleTimeline(func(tl *timeline.TimelineService) error {
+		jobs, err := scheduler.ListJobs(tl)
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			return printScheduleJSON(cmd.OutOrStdout(), jobs)
+		}
+		w := cmd.OutOrStdout()
+		if len(jobs) == 0 {
+			fmt.Fprintln(w, "No scheduled jobs.")
+			return nil
+		}
+		for _, j := range jobs {
+			target := j.Channel + ":" + j.ChatID
+			if j.ThreadID != "" {
+				target += "#" + j.ThreadID
+			}
+			fmt.Fprintf(w, "%s [%s] %s next=%s runs=%d target=%s\n  %s\n",
+				j.JobName, scheduler.JobStatus(j), scheduler.DescribeSchedule(j), formatNextRun(&j), j.RunCount, target, j.Content)
+		}
+		return nil
+	})
+}
+
+func runScheduleRuns(cmd *cobra.Command, args []string) error {
+	limit, _ := cmd.Flags().GetInt("limit")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	name := strings.ToLower(strings.TrimSpace(args[0]))
+	return withScheduleTimeline(func(tl *timeline.TimelineService) error {
+		if _, err := tl.GetScheduledJob(name); err != nil {
+			return fmt.Errorf("job %q not found", args[0])
+		}
+		runs, err := tl.ListScheduledJobRuns(name, limit)
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			return printScheduleJSON(cmd.OutOrStdout(), runs)
+		}
+		w := cmd.OutOrStdout()
+		if len(runs) == 0 {
+			fmt.Fprintln(w, "No runs recorded.")
+			return nil
+		}
+		for _, r := range runs {
+			status := r.Status
+			if r.TaskStatus != "" {
+				status += "/" + r.TaskStatus
+			}
+			fmt.Fprintf(w, "%s %s trace=%s\n", r.ScheduledFor.Local().Format(time.RFC3339), status, r.TraceID)
+			if out := strings.TrimSpace(r.Output); out != "" {
+				fmt.Fprintf(w, "  %s\n", truncateScheduleOutput(out, 200))
+			}
+		}
+		return nil
+	})
+}
+
+func withScheduleTimeline(fn func(*timeline.TimelineService) error) error {
+	tl, err := openTimelineService()
+	if err != nil {
+		return err
+	}
+	defer tl.Close()
+	return fn(tl)
+}
+
+func formatNextRun(job *timeline.ScheduledJobRecord) string {
+	if job.NextRunAt == nil {
+		return "-"
+	}
+	return job.NextRunAt.Local().Format(time.RFC3339)
+}
+
+func printScheduleJSON(w io.Writer, payload any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(payload)
+}
+
+func truncateScheduleOutput(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
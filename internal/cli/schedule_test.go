@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestScheduleCommands(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgDir := filepath.Join(tmpDir, ".kafclaw")
+	if err := os.MkdirAll(cfgDir, 0o755); err != nil {
+		t.Fatalf("mkdir config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cfgDir, "config.json"), []byte(`{"scheduler":{"enabled":true,"timezone":"UTC"}}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	origHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", origHome)
+	_ = os.Setenv("HOME", tmpDir)
+
+	out, err := runRootCommand(t, "schedule", "add", "--name=standup", "--when=every weekday 9:00 Europe/Berlin",
+		"--message=Summarize open PRs", "--channel=slack", "--chat=C123", "--thread=1700000000.1", "--sender=", "--misfire=skip", "--tz=", "--timeout=5m")
+	if err != nil {
+		t.Fatalf("schedule add: %v (%s)", err, out)
+	}
+	if !strings.Contains(out, "Scheduled job standup") || !strings.Contains(out, "Europe/Berlin") {
+		t.Fatalf("unexpected add output %q", out)
+	}
+	if _, err := runRootCommand(t, "schedule", "add", "--name=bad", "--when=every 5s", "--message=x", "--channel=", "--chat=", "--thread=", "--misfire=run_once", "--timeout=0s"); err == nil {
+		t.Fatal("expected too-short interval to fail")
+	}
+
+	out, err = runRootCommand(t, "schedule", "list", "--json")
+	if err != nil {
+		t.Fatalf("schedule list: %v", err)
+	}
+	var jobs []timeline.ScheduledJobRecord
+	if err := json.Unmarshal([]byte(out), &jobs); err != nil {
+		t.Fatalf("unmarshal list: %v\n%s", err, out)
+	}
+	if len(jobs) != 1 || jobs[0].Schedule != "0 9 * * 1-5" || jobs[0].ThreadID != "1700000000.1" || jobs[0].MisfirePolicy != "skip" || jobs[0].TimeoutSeconds != 300 {
+		t.Fatalf("unexpected jobs %+v", jobs)
+	}
+
+	if out, err := runRootCommand(t, "schedule", "pause", "standup"); err != nil || !strings.Contains(out, "Paused") {
+		t.Fatalf("pause: %v %q", err, out)
+	}
+	out, _ = runRootCommand(t, "schedule", "list", "--json=false")
+	if !strings.Contains(out, "standup [paused]") || !strings.Contains(out, "target=slack:C123#1700000000.1") {
+		t.Fatalf("unexpected list output %q", out)
+	}
+	if out, err := runRootCommand(t, "schedule", "resume", "standup"); err != nil || !strings.Contains(out, "Resumed job standup") {
+		t.Fatalf("resume: %v %q", err, out)
+	}
+	if out, err := runRootCommand(t, "schedule", "runs", "standup", "--json=false"); err != nil || out != "No runs recorded." {
+		t.Fatalf("runs: %v %q", err, out)
+	}
+	if out, err := runRootCommand(t, "schedule", "rm", "standup"); err != nil || !strings.Contains(out, "Removed job standup") {
+		t.Fatalf("rm: %v %q", err, out)
+	}
+	if _, err := runRootCommand(t, "schedule", "rm", "standup"); err == nil {
+		t.Fatal("expected removing a missing job to fail")
+	}
+}
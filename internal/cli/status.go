@@ -80,6 +80,20 @@ var statusCmd = &cobra.Command{
 		} else if cfg != nil {
 			fmt.Println("MSTeams:  ✗ Disabled")
 		}
+		if cfg != nil && cfg.Channels.Matrix.Enabled {
+			enc := "off"
+			if cfg.Channels.Matrix.Encryption {
+				enc = "on"
+			}
+			fmt.Printf("Matrix:   ✓ Enabled (homeserver=%s e2ee=%s)\n", nonEmpty(cfg.Channels.Matrix.Homeserver, "(missing)"), enc)
+		} else if cfg != nil {
+			fmt.Println("Matrix:   ✗ Disabled")
+		}
+		if cfg != nil && cfg.Channels.Webhook.Enabled {
+			fmt.Printf("Webhook:  ✓ Enabled (accounts=%d)\n", len(cfg.Channels.Webhook.Accounts))
+		} else if cfg != nil {
+			fmt.Println("Webhook:  ✗ Disabled")
+		}
 		if cfg != nil {
 			printSlackStatusDetails(cfg)
 			printMSTeamsStatusDetails(cfg)
@@ -15,6 +15,7 @@ import (
 	"github.com/KafClaw/KafClaw/internal/channels"
 	"github.com/KafClaw/KafClaw/internal/config"
 	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/sandbox"
 	skillruntime "github.com/KafClaw/KafClaw/internal/skills"
 )
 
@@ -306,10 +307,43 @@ func RunDoctorWithOptions(opts DoctorOptions) (DoctorReport, error) {
 
 	appendRateLimitDoctorChecks(&report)
 	appendSkillsDoctorChecks(&report, cfg, opts)
+	appendSandboxDoctorChecks(&report, cfg)
 
 	return report, nil
 }
 
+// appendSandboxDoctorChecks reports the kernel features behind
+// runtimeIsolation=native and fails when it is selected but unusable.
+func appendSandboxDoctorChecks(report *DoctorReport, cfg *config.Config) {
+	if report == nil || cfg == nil {
+		return
+	}
+	var users []string
+	if strings.EqualFold(strings.TrimSpace(cfg.Tools.Exec.RuntimeIsolation), "native") {
+		users = append(users, "tools.exec")
+	}
+	if cfg.Skills.Enabled && strings.EqualFold(strings.TrimSpace(cfg.Skills.RuntimeIsolation), "native") {
+		users = append(users, "skills")
+	}
+	features := sandbox.Probe()
+	msg := features.Summary()
+	if len(features.Notes) > 0 {
+		msg += " (" + strings.Join(features.Notes, "; ") + ")"
+	}
+	check := DoctorCheck{Name: "sandbox_native", Status: DoctorPass, Message: msg}
+	switch {
+	case len(users) == 0:
+		check.Message = "native sandbox not selected; " + msg
+	case !features.Usable():
+		check.Status = DoctorFail
+		check.Message = fmt.Sprintf("runtimeIsolation=native selected for %s but unavailable: %s", strings.Join(users, ", "), msg)
+	case features.LandlockABI == 0 || !features.CgroupDelegated:
+		check.Status = DoctorWarn
+		check.Message = fmt.Sprintf("runtimeIsolation=native for %s runs with reduced confinement: %s", strings.Join(users, ", "), msg)
+	}
+	report.Checks = append(report.Checks, check)
+}
+
 func appendSkillsDoctorChecks(report *DoctorReport, cfg *config.Config, opts DoctorOptions) {
 	if cfg == nil {
 		return
@@ -6,7 +6,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/KafClaw/KafClaw/internal/config"
 	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/sandbox"
 	skillruntime "github.com/KafClaw/KafClaw/internal/skills"
 )
 
@@ -777,3 +779,30 @@ func TestTrimEnvQuotes(t *testing.T) {
 		t.Fatalf("expected bare value unchanged, got %q", got)
 	}
 }
+
+func TestAppendSandboxDoctorChecks(t *testing.T) {
+	cfg := config.DefaultConfig()
+	report := &DoctorReport{}
+	appendSandboxDoctorChecks(report, cfg)
+	if len(report.Checks) != 1 || report.Checks[0].Name != "sandbox_native" {
+		t.Fatalf("expected one sandbox_native check, got %#v", report.Checks)
+	}
+	if report.Checks[0].Status != DoctorPass || !strings.Contains(report.Checks[0].Message, "not selected") {
+		t.Fatalf("expected informational pass when native is unused, got %#v", report.Checks[0])
+	}
+
+	cfg.Tools.Exec.RuntimeIsolation = "native"
+	report = &DoctorReport{}
+	appendSandboxDoctorChecks(report, cfg)
+	check := report.Checks[0]
+	if !strings.Contains(check.Message, "user namespaces:") {
+		t.Fatalf("expected kernel feature summary, got %q", check.Message)
+	}
+	if sandbox.Probe().Usable() {
+		if check.Status == DoctorFail {
+			t.Fatalf("expected no failure with usable sandbox, got %#v", check)
+		}
+	} else if check.Status != DoctorFail || !strings.Contains(check.Message, "tools.exec") {
+		t.Fatalf("expected failure naming tools.exec, got %#v", check)
+	}
+}
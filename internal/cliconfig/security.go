@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/sandbox"
 	skillruntime "github.com/KafClaw/KafClaw/internal/skills"
 )
 
@@ -228,11 +229,25 @@ func appendGapChecks(cfg *config.Config, report *SecurityReport) {
 	}
 	if cfg.Skills.Enabled {
 		mode := strings.ToLower(strings.TrimSpace(cfg.Skills.RuntimeIsolation))
-		if mode != "strict" {
+		if mode == "native" {
+			if err := sandbox.Preflight(); err != nil {
+				report.Checks = append(report.Checks, SecurityCheck{
+					Name:    "gap_runtime_isolation",
+					Status:  SecurityFail,
+					Message: fmt.Sprintf("native isolation configured but the sandbox is unavailable: %v", err),
+				})
+			} else {
+				report.Checks = append(report.Checks, SecurityCheck{
+					Name:    "gap_runtime_isolation",
+					Status:  SecurityPass,
+					Message: "native sandbox isolation is enabled and usable",
+				})
+			}
+		} else if mode != "strict" {
 			report.Checks = append(report.Checks, SecurityCheck{
 				Name:    "gap_runtime_isolation",
 				Status:  SecurityWarn,
-				Message: "skills runtime isolation is not strict; set `skills.runtimeIsolation` to `strict` to require container isolation",
+				Message: "skills runtime isolation is not strict; set `skills.runtimeIsolation` to `strict` (container) or `native` (Linux sandbox)",
 			})
 		} else {
 			if runtimeBin, err := skillruntime.StrictIsolationPreflight(); err != nil {
@@ -65,6 +65,9 @@ type ChannelsConfig struct {
 	Feishu   FeishuConfig   `json:"feishu"`
 	Slack    SlackConfig    `json:"slack"`
 	MSTeams  MSTeamsConfig  `json:"msteams"`
+	Matrix   MatrixConfig   `json:"matrix"`
+	Webhook  WebhookConfig  `json:"webhook"`
+	Voice    VoiceConfig    `json:"voice"`
 }
 
 // TelegramConfig configures the Telegram channel.
@@ -90,6 +93,9 @@ type WhatsAppConfig struct {
 	DropUnauthorized bool     `json:"dropUnauthorized" envconfig:"WHATSAPP_DROP_UNAUTHORIZED"`
 	IgnoreReactions  bool     `json:"ignoreReactions" envconfig:"WHATSAPP_IGNORE_REACTIONS"`
 	SessionScope     string   `json:"sessionScope" envconfig:"WHATSAPP_SESSION_SCOPE"`
+	// ApprovalButtons sends approval prompts as interactive buttons; not all
+	// WhatsApp clients render them, so the default is a reaction prompt.
+	ApprovalButtons bool `json:"approvalButtons" envconfig:"WHATSAPP_APPROVAL_BUTTONS"`
 }
 
 // FeishuConfig configures the Feishu channel.
@@ -191,6 +197,82 @@ type MSTeamsAccountConfig struct {
 	RequireMention bool        `json:"requireMention"`
 }
 
+// MatrixConfig configures the Matrix channel.
+type MatrixConfig struct {
+	Enabled         bool        `json:"enabled" envconfig:"MATRIX_ENABLED"`
+	Homeserver      string      `json:"homeserver" envconfig:"MATRIX_HOMESERVER"`
+	UserID          string      `json:"userId" envconfig:"MATRIX_USER_ID"`
+	AccessToken     string      `json:"accessToken" envconfig:"MATRIX_ACCESS_TOKEN"`
+	DeviceID        string      `json:"deviceId" envconfig:"MATRIX_DEVICE_ID"`
+	Encryption      bool        `json:"encryption" envconfig:"MATRIX_ENCRYPTION"`
+	CryptoStorePath string      `json:"cryptoStorePath,omitempty" envconfig:"MATRIX_CRYPTO_STORE_PATH"`
+	SyncTimeoutSec  int         `json:"syncTimeoutSec" envconfig:"MATRIX_SYNC_TIMEOUT_SEC"`
+	SessionScope    string      `json:"sessionScope" envconfig:"MATRIX_SESSION_SCOPE"`
+	AllowFrom       []string    `json:"allowFrom"`
+	GroupAllowFrom  []string    `json:"groupAllowFrom"`
+	DmPolicy        DmPolicy    `json:"dmPolicy"`
+	GroupPolicy     GroupPolicy `json:"groupPolicy"`
+	RequireMention  bool        `json:"requireMention" envconfig:"MATRIX_REQUIRE_MENTION"`
+}
+
+// Voice reply modes.
+const (
+	VoiceReplyOff     = "off"     // always reply in text
+	VoiceReplyInbound = "inbound" // reply in voice to voice notes
+	VoiceReplyAlways  = "always"  // always attach a voice reply
+)
+
+// VoiceConfig configures the channel-agnostic voice pipeline: transcription
+// of inbound audio and synthesized voice replies.
+type VoiceConfig struct {
+	Enabled bool `json:"enabled" envconfig:"VOICE_ENABLED"`
+	// ReplyMode is the default for chats without a per-chat preference.
+	ReplyMode string `json:"replyMode" envconfig:"VOICE_REPLY_MODE"`
+	// TTSVoice is the provider voice used for synthesis (e.g. "nova").
+	TTSVoice string `json:"ttsVoice" envconfig:"VOICE_TTS_VOICE"`
+	// MaxReplyChars skips synthesis for longer replies (text only).
+	MaxReplyChars int    `json:"maxReplyChars" envconfig:"VOICE_MAX_REPLY_CHARS"`
+	FFmpegPath    string `json:"ffmpegPath" envconfig:"VOICE_FFMPEG_PATH"`
+}
+
+// WebhookConfig configures the generic signed webhook channel.
+type WebhookConfig struct {
+	Enabled         bool                   `json:"enabled" envconfig:"WEBHOOK_ENABLED"`
+	ReplayWindowSec int                    `json:"replayWindowSec" envconfig:"WEBHOOK_REPLAY_WINDOW_SEC"`
+	MaxRetries      int                    `json:"maxRetries" envconfig:"WEBHOOK_MAX_RETRIES"`
+	Accounts        []WebhookAccountConfig `json:"accounts,omitempty"`
+}
+
+// WebhookAccountConfig configures one named webhook integration.
+type WebhookAccountConfig struct {
+	ID             string              `json:"id"`
+	Enabled        bool                `json:"enabled"`
+	Secret         string              `json:"secret"`
+	CallbackURL    string              `json:"callbackUrl"`
+	CallbackSecret string              `json:"callbackSecret,omitempty"`
+	Headers        map[string]string   `json:"headers,omitempty"`
+	Mapping        WebhookFieldMapping `json:"mapping"`
+	SessionScope   string              `json:"sessionScope"`
+	AllowFrom      []string            `json:"allowFrom"`
+	DmPolicy       DmPolicy            `json:"dmPolicy"`
+}
+
+// WebhookFieldMapping turns an arbitrary JSON payload into an inbound message.
+// Each value is a dotted path ("issue.fields.summary", "alerts.0.labels.host")
+// or a template with {{path}} placeholders. Empty values use the default
+// field names (sender_id, chat_id, thread_id, message_id, text).
+type WebhookFieldMapping struct {
+	SenderID  string `json:"senderId"`
+	ChatID    string `json:"chatId"`
+	ThreadID  string `json:"threadId"`
+	MessageID string `json:"messageId"`
+	Text      string `json:"text"`
+	// Audio is base64 voice note content and AudioFilename its name; the
+	// extension tells the voice pipeline the format.
+	Audio         string `json:"audio"`
+	AudioFilename string `json:"audioFilename"`
+}
+
 // ---------------------------------------------------------------------------
 // Providers – LLM API keys & endpoints
 // ---------------------------------------------------------------------------
@@ -235,6 +317,31 @@ type GatewayConfig struct {
 	TLSCert       string `json:"tlsCert" envconfig:"TLS_CERT"`
 	TLSKey        string `json:"tlsKey" envconfig:"TLS_KEY"`
 	DaemonRuntime string `json:"daemonRuntime" envconfig:"DAEMON_RUNTIME"`
+	// Principals are the named identities API tokens and OIDC logins map
+	// to. AuthToken, when set, acts as an admin principal named "admin".
+	Principals []GatewayPrincipalConfig `json:"principals,omitempty"`
+	OIDC       GatewayOIDCConfig        `json:"oidc" envconfig:"OIDC"`
+}
+
+// GatewayPrincipalConfig is a named gateway principal.
+type GatewayPrincipalConfig struct {
+	Name string `json:"name"`
+	// Roles: viewer, operator, approver, admin.
+	Roles []string `json:"roles"`
+	// Emails and Subjects are OIDC claims that log in as this principal.
+	Emails   []string `json:"emails,omitempty"`
+	Subjects []string `json:"subjects,omitempty"`
+}
+
+// GatewayOIDCConfig enables OIDC login for the web UI.
+type GatewayOIDCConfig struct {
+	Issuer       string   `json:"issuer" envconfig:"ISSUER"`
+	ClientID     string   `json:"clientId" envconfig:"CLIENT_ID"`
+	ClientSecret string   `json:"clientSecret" envconfig:"CLIENT_SECRET"`
+	RedirectURL  string   `json:"redirectUrl" envconfig:"REDIRECT_URL"` // e.g. https://host:18791/auth/oidc/callback
+	Scopes       []string `json:"scopes,omitempty"`                     // default: openid, email, profile
+	// SessionTTLSeconds bounds web UI sessions (default 12h).
+	SessionTTLSeconds int `json:"sessionTtlSeconds" envconfig:"SESSION_TTL_SECONDS"`
 }
 
 // ---------------------------------------------------------------------------
@@ -291,6 +398,10 @@ type KnowledgeConfig struct {
 	Topics            KnowledgeTopicsConfig  `json:"topics"`
 	Publish           KnowledgePublishConfig `json:"publish"`
 	Voting            KnowledgeVotingConfig  `json:"voting"`
+	// ContextMaxFacts caps the facts injected into the prompt per turn
+	// (0 disables the facts lane); ContextBudgetChars caps their size.
+	ContextMaxFacts    int `json:"contextMaxFacts" envconfig:"CONTEXT_MAX_FACTS"`
+	ContextBudgetChars int `json:"contextBudgetChars" envconfig:"CONTEXT_BUDGET_CHARS"`
 }
 
 // KnowledgeTopicsConfig defines topic names used by the knowledge protocol.
@@ -317,6 +428,36 @@ type KnowledgeVotingConfig struct {
 	QuorumNo      int  `json:"quorumNo" envconfig:"QUORUM_NO"`
 	TimeoutSec    int  `json:"timeoutSec" envconfig:"TIMEOUT_SEC"`
 	AllowSelfVote bool `json:"allowSelfVote" envconfig:"ALLOW_SELF_VOTE"`
+
+	// Weighted voting. Quorums compare vote weights; a claw weighs 1 unless
+	// clawWeights, roleWeights/zoneWeights or expertiseWeight say otherwise.
+	QuorumYesPercent int                `json:"quorumYesPercent" envconfig:"QUORUM_YES_PERCENT"`
+	QuorumNoPercent  int                `json:"quorumNoPercent" envconfig:"QUORUM_NO_PERCENT"`
+	ClawWeights      map[string]float64 `json:"clawWeights"`
+	RoleWeights      map[string]float64 `json:"roleWeights"`
+	ZoneWeights      map[string]float64 `json:"zoneWeights"`
+	ExpertiseWeight  float64            `json:"expertiseWeight" envconfig:"EXPERTISE_WEIGHT"`
+	// Voters pins the role and zone of known claws. What a remote vote
+	// reports is never trusted: unpinned claws vote without role or zone.
+	Voters      map[string]KnowledgeVoterConfig `json:"voters"`
+	VetoRoles   []string                        `json:"vetoRoles"`
+	TagPolicies []KnowledgeTagPolicyConfig      `json:"tagPolicies"`
+}
+
+// KnowledgeVoterConfig pins the voting attributes of one claw.
+type KnowledgeVoterConfig struct {
+	Role   string `json:"role"`
+	ZoneID string `json:"zoneId"`
+}
+
+// KnowledgeTagPolicyConfig adds voting rules for proposals whose tags match
+// Tag (exact or glob, e.g. "security:*").
+type KnowledgeTagPolicyConfig struct {
+	Tag              string   `json:"tag"`
+	QuorumYes        int      `json:"quorumYes"`
+	QuorumYesPercent int      `json:"quorumYesPercent"`
+	RequireYesRoles  []string `json:"requireYesRoles"`
+	VetoRoles        []string `json:"vetoRoles"`
 }
 
 // ---------------------------------------------------------------------------
@@ -339,8 +480,14 @@ type OrchestratorConfig struct {
 // ToolsConfig contains tool-specific settings.
 type ToolsConfig struct {
 	Exec      ExecToolConfig      `json:"exec"`
+	Read      ReadToolConfig      `json:"read"`
 	Web       WebToolConfig       `json:"web"`
+	HTTP      HTTPToolConfig      `json:"http"`
+	Cache     ToolCacheConfig     `json:"cache"`
+	Parallel  ToolParallelConfig  `json:"parallel"`
+	CodeRun   CodeRunToolConfig   `json:"codeRun"`
 	Subagents SubagentsToolConfig `json:"subagents"`
+	Approvals ToolApprovalsConfig `json:"approvals"`
 }
 
 // SkillsConfig contains skill-system settings.
@@ -352,6 +499,7 @@ type SkillsConfig struct {
 	NodeManager           string                      `json:"nodeManager" envconfig:"NODE_MANAGER"`
 	Scope                 string                      `json:"scope" envconfig:"SCOPE"`
 	RuntimeIsolation      string                      `json:"runtimeIsolation" envconfig:"RUNTIME_ISOLATION"`
+	StrictSandbox         bool                        `json:"strictSandbox" envconfig:"STRICT_SANDBOX"`
 	LinkPolicy            SkillLinkPolicyConfig       `json:"linkPolicy"`
 	Entries               map[string]SkillEntryConfig `json:"entries,omitempty"`
 }
@@ -444,12 +592,37 @@ type SchedulerConfig struct {
 	MaxConcLLM     int           `json:"maxConcLLM" envconfig:"MAX_CONC_LLM"`
 	MaxConcShell   int           `json:"maxConcShell" envconfig:"MAX_CONC_SHELL"`
 	MaxConcDefault int           `json:"maxConcDefault" envconfig:"MAX_CONC_DEFAULT"`
+	// JobTimeout bounds a job run; its concurrency slot is held until the
+	// agent finishes or the timeout passes.
+	JobTimeout time.Duration `json:"jobTimeout" envconfig:"JOB_TIMEOUT"`
+	// Timezone is the default timezone of user schedules (IANA name, e.g.
+	// "Europe/Berlin"); empty uses the host timezone.
+	Timezone string `json:"timezone,omitempty" envconfig:"TIMEZONE"`
 }
 
 // ExecToolConfig contains shell execution tool settings.
 type ExecToolConfig struct {
 	Timeout             time.Duration `json:"timeout"`
 	RestrictToWorkspace bool          `json:"restrictToWorkspace" envconfig:"EXEC_RESTRICT_WORKSPACE"`
+	// RuntimeIsolation is "host" (plain subprocess) or "native" (Linux
+	// namespaces, Landlock, seccomp and cgroup limits).
+	RuntimeIsolation string `json:"runtimeIsolation" envconfig:"EXEC_RUNTIME_ISOLATION"`
+	// AllowNetwork keeps network access for natively sandboxed commands.
+	AllowNetwork bool `json:"allowNetwork" envconfig:"EXEC_ALLOW_NETWORK"`
+	// StrictSandbox refuses natively sandboxed commands when Landlock,
+	// seccomp or cgroup limits are unavailable.
+	StrictSandbox bool `json:"strictSandbox" envconfig:"EXEC_STRICT_SANDBOX"`
+}
+
+// ReadToolConfig restricts the paths read_file and list_dir may read.
+// KafClaw's own secret stores are always denied.
+type ReadToolConfig struct {
+	// AllowRoots, when set, limits reads to these directories plus the
+	// workspace and the work repo.
+	AllowRoots []string `json:"allowRoots,omitempty"`
+	// DenyPaths are files or directories denied in addition to the
+	// built-in secret locations.
+	DenyPaths []string `json:"denyPaths,omitempty"`
 }
 
 // WebToolConfig contains web tool settings.
@@ -457,6 +630,96 @@ type WebToolConfig struct {
 	Search SearchConfig `json:"search"`
 }
 
+// HTTPToolConfig configures the http_request tool. The tool is registered
+// only when AllowedHosts is not empty.
+type HTTPToolConfig struct {
+	// AllowedHosts lists reachable hosts; "*.example.com" matches subdomains.
+	AllowedHosts     []string `json:"allowedHosts,omitempty"`
+	AllowHTTP        bool     `json:"allowHttp"`
+	MaxResponseBytes int      `json:"maxResponseBytes"`
+	TimeoutSeconds   int      `json:"timeoutSeconds"`
+	// Credentials are referenced by name in tool calls; their values are
+	// injected at request time and never returned to the model.
+	Credentials map[string]HTTPCredentialConfig `json:"credentials,omitempty"`
+}
+
+// HTTPCredentialConfig names where a credential comes from and how it is sent.
+// Exactly one of Secret or OAuthProvider is set.
+type HTTPCredentialConfig struct {
+	// Secret is the key of an env secret stored in the local tomb.
+	Secret string `json:"secret,omitempty"`
+	// OAuthProvider ("google-workspace" or "m365") and OAuthProfile select an
+	// enrolled skills OAuth token.
+	OAuthProvider string `json:"oauthProvider,omitempty"`
+	OAuthProfile  string `json:"oauthProfile,omitempty"`
+	// Header defaults to Authorization; Scheme defaults to Bearer there and
+	// to none for other headers.
+	Header string `json:"header,omitempty"`
+	Scheme string `json:"scheme,omitempty"`
+	// Hosts limits where the credential is sent (default: AllowedHosts).
+	Hosts []string `json:"hosts,omitempty"`
+}
+
+// ToolCacheConfig controls reuse of read-only tool results within a trace
+// or session.
+type ToolCacheConfig struct {
+	Enabled bool `json:"enabled" envconfig:"ENABLED"`
+	// Scope is "trace" (one task and its subagents) or "session".
+	Scope      string `json:"scope" envconfig:"SCOPE"`
+	MaxEntries int    `json:"maxEntries"`
+	// TTLSeconds overrides the TTL of cacheable tools; 0 disables caching
+	// for that tool.
+	TTLSeconds map[string]int `json:"ttlSeconds,omitempty"`
+}
+
+// ToolParallelConfig controls concurrent execution of parallel-safe tool
+// calls returned in one model response.
+type ToolParallelConfig struct {
+	// MaxConcurrent caps concurrent calls per loop; 1 runs every call in turn.
+	MaxConcurrent int `json:"maxConcurrent" envconfig:"MAX_CONCURRENT"`
+}
+
+// CodeRunToolConfig controls the code_run interpreter tool. Runs use the
+// skills isolation runtime (skills.runtimeIsolation).
+type CodeRunToolConfig struct {
+	Enabled        bool    `json:"enabled" envconfig:"ENABLED"`
+	TimeoutSeconds int     `json:"timeoutSeconds" envconfig:"TIMEOUT_SECONDS"`
+	MemoryMB       int     `json:"memoryMb" envconfig:"MEMORY_MB"`
+	CPUs           float64 `json:"cpus" envconfig:"CPUS"`
+	MaxOutputBytes int     `json:"maxOutputBytes"`
+	// MaxArtifactBytes skips generated files larger than this.
+	MaxArtifactBytes int64 `json:"maxArtifactBytes"`
+	Network          bool  `json:"network" envconfig:"NETWORK"`
+	// PythonImage and NodeImage are used with container isolation.
+	PythonImage string `json:"pythonImage,omitempty"`
+	NodeImage   string `json:"nodeImage,omitempty"`
+}
+
+// ToolApprovalsConfig routes interactive tool approvals. Without a matching
+// rule anyone in the originating chat may answer an approval prompt.
+type ToolApprovalsConfig struct {
+	Rules []ToolApprovalRuleConfig `json:"rules,omitempty"`
+}
+
+// ToolApprovalRuleConfig applies to approvals of tools matching Tool (a glob,
+// empty for any tool) at MinTier or above. The first matching rule wins.
+type ToolApprovalRuleConfig struct {
+	Tool    string `json:"tool,omitempty"`
+	MinTier int    `json:"minTier,omitempty"`
+	// Approvers are "<channel>:<senderID>", "person:<id>" for a linked
+	// identity, or "*"; empty allows anyone. Bare sender IDs match nobody.
+	Approvers []string `json:"approvers,omitempty"`
+	// Quorum is the number of distinct approvers required (default 1).
+	Quorum int `json:"quorum,omitempty"`
+	// AllowRequester lets the sender who triggered the tool call approve it.
+	AllowRequester bool `json:"allowRequester,omitempty"`
+	// EscalateAfterSeconds without a decision adds EscalateTo to the
+	// approvers and re-sends the prompt; the request then stays open for
+	// another approval timeout.
+	EscalateAfterSeconds int      `json:"escalateAfterSeconds,omitempty"`
+	EscalateTo           []string `json:"escalateTo,omitempty"`
+}
+
 // SearchConfig contains web search settings.
 type SearchConfig struct {
 	APIKey     string `json:"apiKey" envconfig:"BRAVE_API_KEY"`
@@ -660,17 +923,40 @@ func DefaultConfig() *Config {
 				TimeoutSec:    120,
 				AllowSelfVote: false,
 			},
+			ContextMaxFacts:    8,
+			ContextBudgetChars: 1200,
 		},
 		Tools: ToolsConfig{
 			Exec: ExecToolConfig{
 				Timeout:             60 * time.Second,
 				RestrictToWorkspace: true, // Secure default
+				RuntimeIsolation:    "host",
 			},
 			Web: WebToolConfig{
 				Search: SearchConfig{
 					MaxResults: 10,
 				},
 			},
+			HTTP: HTTPToolConfig{
+				MaxResponseBytes: 256 * 1024,
+				TimeoutSeconds:   30,
+			},
+			Cache: ToolCacheConfig{
+				Scope:      "trace",
+				MaxEntries: 512,
+			},
+			Parallel: ToolParallelConfig{
+				MaxConcurrent: 4,
+			},
+			CodeRun: CodeRunToolConfig{
+				TimeoutSeconds:   60,
+				MemoryMB:         512,
+				CPUs:             1,
+				MaxOutputBytes:   64 * 1024,
+				MaxArtifactBytes: 10 << 20,
+				PythonImage:      "python:3.12-slim",
+				NodeImage:        "node:22-slim",
+			},
 			Subagents: SubagentsToolConfig{
 				MaxConcurrent:       8,
 				MaxSpawnDepth:       1,
@@ -710,6 +996,7 @@ func DefaultConfig() *Config {
 			MaxConcLLM:     3,
 			MaxConcShell:   1,
 			MaxConcDefault: 5,
+			JobTimeout:     10 * time.Minute,
 		},
 		ER1: ER1IntegrationConfig{
 			SyncInterval: 5 * time.Minute,
@@ -742,6 +1029,24 @@ func DefaultConfig() *Config {
 			WhatsApp: WhatsAppConfig{
 				SessionScope: "room",
 			},
+			Matrix: MatrixConfig{
+				Encryption:     true,
+				SyncTimeoutSec: 30,
+				DmPolicy:       DmPolicyPairing,
+				GroupPolicy:    GroupPolicyAllowlist,
+				RequireMention: true,
+				SessionScope:   "room",
+			},
+			Webhook: WebhookConfig{
+				ReplayWindowSec: 300,
+				MaxRetries:      5,
+			},
+			Voice: VoiceConfig{
+				Enabled:       true,
+				ReplyMode:     VoiceReplyOff,
+				MaxReplyChars: 1500,
+				FFmpegPath:    "ffmpeg",
+			},
 		},
 	}
 }
@@ -190,6 +190,9 @@ func Load() (*Config, error) {
 	envconfig.Process("MIKROBOT_CHANNELS_FEISHU", &cfg.Channels.Feishu)
 	envconfig.Process("MIKROBOT_CHANNELS_SLACK", &cfg.Channels.Slack)
 	envconfig.Process("MIKROBOT_CHANNELS_MSTEAMS", &cfg.Channels.MSTeams)
+	envconfig.Process("MIKROBOT_CHANNELS_MATRIX", &cfg.Channels.Matrix)
+	envconfig.Process("MIKROBOT_CHANNELS_WEBHOOK", &cfg.Channels.Webhook)
+	envconfig.Process("MIKROBOT_CHANNELS_VOICE", &cfg.Channels.Voice)
 	envconfig.Process("MIKROBOT_GATEWAY", &cfg.Gateway)
 	envconfig.Process("MIKROBOT_NODE", &cfg.Node)
 	envconfig.Process("MIKROBOT_MEMORY_EMBEDDING", &cfg.Memory.Embedding)
@@ -200,6 +203,9 @@ func Load() (*Config, error) {
 	envconfig.Process("MIKROBOT_TOOLS_EXEC", &cfg.Tools.Exec)
 	envconfig.Process("MIKROBOT_TOOLS_WEB_SEARCH", &cfg.Tools.Web.Search)
 	envconfig.Process("MIKROBOT_TOOLS_SUBAGENTS", &cfg.Tools.Subagents)
+	envconfig.Process("MIKROBOT_TOOLS_CACHE", &cfg.Tools.Cache)
+	envconfig.Process("MIKROBOT_TOOLS_PARALLEL", &cfg.Tools.Parallel)
+	envconfig.Process("MIKROBOT_TOOLS_CODE_RUN", &cfg.Tools.CodeRun)
 	envconfig.Process("MIKROBOT_SKILLS", &cfg.Skills)
 	legacyAgentDefaults := SubagentsToolConfig{}
 	if cfg.Agents != nil {
@@ -226,6 +232,9 @@ func Load() (*Config, error) {
 	envconfig.Process("KAFCLAW_CHANNELS_FEISHU", &cfg.Channels.Feishu)
 	envconfig.Process("KAFCLAW_CHANNELS_SLACK", &cfg.Channels.Slack)
 	envconfig.Process("KAFCLAW_CHANNELS_MSTEAMS", &cfg.Channels.MSTeams)
+	envconfig.Process("KAFCLAW_CHANNELS_MATRIX", &cfg.Channels.Matrix)
+	envconfig.Process("KAFCLAW_CHANNELS_WEBHOOK", &cfg.Channels.Webhook)
+	envconfig.Process("KAFCLAW_CHANNELS_VOICE", &cfg.Channels.Voice)
 	envconfig.Process("KAFCLAW_GATEWAY", &cfg.Gateway)
 	envconfig.Process("KAFCLAW_NODE", &cfg.Node)
 	envconfig.Process("KAFCLAW_MEMORY_EMBEDDING", &cfg.Memory.Embedding)
@@ -236,6 +245,9 @@ func Load() (*Config, error) {
 	envconfig.Process("KAFCLAW_TOOLS_EXEC", &cfg.Tools.Exec)
 	envconfig.Process("KAFCLAW_TOOLS_WEB_SEARCH", &cfg.Tools.Web.Search)
 	envconfig.Process("KAFCLAW_TOOLS_SUBAGENTS", &cfg.Tools.Subagents)
+	envconfig.Process("KAFCLAW_TOOLS_CACHE", &cfg.Tools.Cache)
+	envconfig.Process("KAFCLAW_TOOLS_PARALLEL", &cfg.Tools.Parallel)
+	envconfig.Process("KAFCLAW_TOOLS_CODE_RUN", &cfg.Tools.CodeRun)
 	envconfig.Process("KAFCLAW_SKILLS", &cfg.Skills)
 	agentDefaults := SubagentsToolConfig{}
 	if cfg.Agents != nil {
@@ -302,6 +314,12 @@ func Load() (*Config, error) {
 	default:
 		cfg.Tools.Subagents.MemoryShareMode = "handoff"
 	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Tools.Exec.RuntimeIsolation)) {
+	case "native":
+		cfg.Tools.Exec.RuntimeIsolation = "native"
+	default:
+		cfg.Tools.Exec.RuntimeIsolation = "host"
+	}
 
 	if cfg.Skills.NodeManager == "" {
 		cfg.Skills.NodeManager = "npm"
@@ -323,7 +341,7 @@ func Load() (*Config, error) {
 	switch strings.ToLower(strings.TrimSpace(cfg.Skills.RuntimeIsolation)) {
 	case "", "auto":
 		cfg.Skills.RuntimeIsolation = "auto"
-	case "host", "strict":
+	case "host", "strict", "native":
 		cfg.Skills.RuntimeIsolation = strings.ToLower(strings.TrimSpace(cfg.Skills.RuntimeIsolation))
 	default:
 		cfg.Skills.RuntimeIsolation = "auto"
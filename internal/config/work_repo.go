@@ -54,7 +54,7 @@ func ResolveArtifactPath(workRepoPath, kind, filename string) (string, error) {
 		k = "docs"
 	}
 	switch k {
-	case "requirements", "tasks", "docs":
+	case "requirements", "tasks", "docs", "artifacts":
 	default:
 		k = "docs"
 	}
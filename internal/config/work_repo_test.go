@@ -105,6 +105,11 @@ func TestResolveArtifactPath(t *testing.T) {
 		t.Fatalf("unexpected tasks path: %q", got)
 	}
 
+	got, err = ResolveArtifactPath("/tmp/work", "artifacts", "run/chart.png")
+	if err != nil || got != filepath.Join("/tmp/work", "artifacts", "run", "chart.png") {
+		t.Fatalf("unexpected artifacts path: %q, %v", got, err)
+	}
+
 	got, err = ResolveArtifactPath("/tmp/work", "invalid-kind", "")
 	if err != nil {
 		t.Fatalf("resolve default path: %v", err)
@@ -0,0 +1,107 @@
+package gatewayapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// GenerateClient returns the Go source of one Client method per route, for
+// a package named pkg that defines Client and its call method. Routes
+// marked NoClient are skipped.
+func GenerateClient(pkg string) ([]byte, error) {
+	g := &clientGen{imports: map[string]bool{"context": true}}
+	var body bytes.Buffer
+	for _, route := range routes {
+		if route.NoClient {
+			continue
+		}
+		g.method(&body, route)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by go run ./gen; DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	var std, module []string
+	for imp := range g.imports {
+		if strings.Contains(strings.Split(imp, "/")[0], ".") {
+			module = append(module, imp)
+		} else {
+			std = append(std, imp)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(module)
+	for i, group := range [][]string{std, module} {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		for _, imp := range group {
+			fmt.Fprintf(&out, "\t%q\n", imp)
+		}
+	}
+	out.WriteString(")\n")
+	out.Write(body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+type clientGen struct {
+	imports map[string]bool
+}
+
+func (g *clientGen) method(w *bytes.Buffer, route Route) {
+	name := strings.ToUpper(route.OperationID[:1]) + route.OperationID[1:]
+	params := "ctx context.Context"
+	reqArg := "nil"
+	if route.Request != nil {
+		params += ", req " + g.typeName(route.Request)
+		reqArg = "req"
+	}
+	summary := route.Summary
+	if summary != "" {
+		summary = strings.ToLower(summary[:1]) + summary[1:]
+	}
+	fmt.Fprintf(w, "\n// %s calls %s %s: %s.\n", name, route.Method, route.Path, summary)
+
+	resp := route.Response
+	switch {
+	case resp == nil:
+		fmt.Fprintf(w, "func (c *Client) %s(%s) error {\n\treturn c.call(ctx, %q, %s, nil)\n}\n",
+			name, params, route.OperationID, reqArg)
+	case resp.Kind() == reflect.Struct:
+		t := g.typeName(resp)
+		fmt.Fprintf(w, "func (c *Client) %s(%s) (*%s, error) {\n\tvar out %s\n\tif err := c.call(ctx, %q, %s, &out); err != nil {\n\t\treturn nil, err\n\t}\n\treturn &out, nil\n}\n",
+			name, params, t, t, route.OperationID, reqArg)
+	default:
+		t := g.typeName(resp)
+		fmt.Fprintf(w, "func (c *Client) %s(%s) (%s, error) {\n\tvar out %s\n\terr := c.call(ctx, %q, %s, &out)\n\treturn out, err\n}\n",
+			name, params, t, t, route.OperationID, reqArg)
+	}
+}
+
+// typeName spells t in the generated package, recording its imports.
+func (g *clientGen) typeName(t reflect.Type) string {
+	if t == rawType {
+		g.imports["encoding/json"] = true
+		return "json.RawMessage"
+	}
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name()
+		}
+		g.imports[t.PkgPath()] = true
+		return path.Base(t.PkgPath()) + "." + t.Name()
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + g.typeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + g.typeName(t.Elem())
+	case reflect.Map:
+		return "map[" + g.typeName(t.Key()) + "]" + g.typeName(t.Elem())
+	}
+	return t.String()
+}
@@ -0,0 +1,205 @@
+package gatewayapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxRawBody caps request bodies read whole (signed webhook payloads, which
+// may carry an inline voice note).
+const maxRawBody = 16 << 20
+
+// paramKind returns where a request struct field is carried: "path",
+// "query", "header", "raw" (the whole body) or "" for a JSON body field.
+func paramKind(f reflect.StructField) (kind, name string) {
+	for _, k := range []string{"path", "query", "header"} {
+		if v, ok := f.Tag.Lookup(k); ok {
+			return k, v
+		}
+	}
+	if f.Tag.Get("body") == "raw" {
+		return "raw", ""
+	}
+	return "", ""
+}
+
+// jsonName returns the JSON name of a body field, or "" when the field is
+// not encoded.
+func jsonName(f reflect.StructField) (name string, omitempty bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, strings.Contains(","+opts+",", ",omitempty,")
+}
+
+// bodyKind reports how the body of a request type is encoded: "json",
+// "raw" or "" for no body.
+func bodyKind(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	if t.Kind() != reflect.Struct {
+		return "json"
+	}
+	kind := ""
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		switch k, _ := paramKind(f); k {
+		case "raw":
+			return "raw"
+		case "":
+			if name, _ := jsonName(f); name != "" {
+				kind = "json"
+			}
+		}
+	}
+	return kind
+}
+
+// DecodeRequest fills req, a pointer to the route's request type, from r.
+func DecodeRequest(route Route, r *http.Request, req any) error {
+	v := reflect.ValueOf(req).Elem()
+	t := v.Type()
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			kind, name := paramKind(f)
+			var raw string
+			switch kind {
+			case "path":
+				raw = r.PathValue(name)
+			case "query":
+				raw = r.URL.Query().Get(name)
+			case "header":
+				raw = r.Header.Get(name)
+			case "raw":
+				b, err := io.ReadAll(io.LimitReader(r.Body, maxRawBody))
+				if err != nil {
+					return Errorf(http.StatusBadRequest, "failed to read body")
+				}
+				v.Field(i).SetBytes(b)
+				continue
+			default:
+				continue
+			}
+			if err := setScalar(v.Field(i), raw); err != nil {
+				return Errorf(http.StatusBadRequest, "invalid %s", name)
+			}
+		}
+	}
+	if bodyKind(t) != "json" {
+		return nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return Errorf(http.StatusBadRequest, "failed to read body")
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if route.OptionalBody {
+			return nil
+		}
+		return Errorf(http.StatusBadRequest, "invalid request body")
+	}
+	if err := json.Unmarshal(body, req); err != nil {
+		return Errorf(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+func setScalar(v reflect.Value, raw string) error {
+	if raw == "" {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	default:
+		return fmt.Errorf("unsupported parameter kind %s", v.Kind())
+	}
+	return nil
+}
+
+// EncodedRequest is a request value laid out for the wire.
+type EncodedRequest struct {
+	Path   string // route path with wildcards substituted
+	Query  url.Values
+	Header http.Header
+	Body   []byte // nil when the route takes no body
+}
+
+// EncodeRequest lays out req, a value of the route's request type, as path,
+// query, headers and body. It is the inverse of DecodeRequest.
+func EncodeRequest(route Route, req any) (*EncodedRequest, error) {
+	out := &EncodedRequest{Path: route.Path, Query: url.Values{}, Header: http.Header{}}
+	if route.Request == nil {
+		return out, nil
+	}
+	v := reflect.ValueOf(req)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Type() != route.Request {
+		return nil, fmt.Errorf("gatewayapi: %s takes %s, not %s", route.OperationID, route.Request, v.Type())
+	}
+	t := v.Type()
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			kind, name := paramKind(t.Field(i))
+			fv := v.Field(i)
+			if kind == "raw" {
+				out.Body = fv.Bytes()
+				continue
+			}
+			if kind == "" || fv.IsZero() {
+				if kind == "path" {
+					return nil, fmt.Errorf("gatewayapi: %s requires %s", route.OperationID, name)
+				}
+				continue
+			}
+			s := fmt.Sprint(fv.Interface())
+			switch kind {
+			case "path":
+				out.Path = strings.Replace(out.Path, "{"+name+"}", url.PathEscape(s), 1)
+			case "query":
+				out.Query.Set(name, s)
+			case "header":
+				out.Header.Set(name, s)
+			}
+		}
+	}
+	if bodyKind(t) == "json" {
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		out.Body = b
+	}
+	return out, nil
+}
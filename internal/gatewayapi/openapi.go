@@ -0,0 +1,270 @@
+package gatewayapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/rbac"
+)
+
+// Default listener addresses advertised in the document.
+const (
+	specAPIServer       = "http://localhost:18790"
+	specDashboardServer = "http://localhost:18791"
+)
+
+// Spec returns the OpenAPI 3.1 document of the route table, generated from
+// the request and response types.
+func Spec(version string) ([]byte, error) {
+	b := &specBuilder{schemas: map[string]any{}, names: map[reflect.Type]string{}}
+	paths := map[string]map[string]any{}
+	for _, route := range routes {
+		ops := paths[route.Path]
+		if ops == nil {
+			ops = map[string]any{}
+			paths[route.Path] = ops
+		}
+		ops[strings.ToLower(route.Method)] = b.operation(route)
+	}
+	doc := map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "KafClaw Gateway API",
+			"version":     version,
+			"description": "Generated from the gateway route table. Dashboard operations are served on gateway.dashboardPort, the chat and OpenAI-compatible operations on gateway.port.",
+		},
+		"servers":  []any{map[string]any{"url": specDashboardServer}},
+		"security": []any{map[string]any{"bearerAuth": []string{}}, map[string]any{"sessionCookie": []string{}}},
+		"paths":    paths,
+		"components": map[string]any{
+			"schemas": b.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth":    map[string]any{"type": "http", "scheme": "bearer", "description": "gateway.authToken or a scoped API token"},
+				"sessionCookie": map[string]any{"type": "apiKey", "in": "cookie", "name": rbac.SessionCookie},
+			},
+		},
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type specBuilder struct {
+	schemas map[string]any
+	names   map[reflect.Type]string
+}
+
+func (b *specBuilder) operation(route Route) map[string]any {
+	op := map[string]any{
+		"operationId":     route.OperationID,
+		"summary":         route.Summary,
+		"tags":            []string{route.Tag},
+		"x-kafclaw-scope": string(rbac.RequiredScope(route.Method, route.Path)),
+	}
+	if route.Server == ServerAPI {
+		op["servers"] = []any{map[string]any{"url": specAPIServer}}
+	}
+	if route.Public {
+		op["security"] = []any{}
+	}
+	if params := b.parameters(route.Request); len(params) > 0 {
+		op["parameters"] = params
+	}
+	switch bodyKind(route.Request) {
+	case "json":
+		op["requestBody"] = map[string]any{
+			"required": !route.OptionalBody,
+			"content":  map[string]any{"application/json": map[string]any{"schema": b.schema(route.Request)}},
+		}
+	case "raw":
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": map[string]any{}}},
+		}
+	}
+
+	responses := map[string]any{}
+	statuses := route.Statuses
+	if len(statuses) == 0 {
+		statuses = []int{http.StatusOK}
+	}
+	for _, status := range statuses {
+		resp := map[string]any{"description": http.StatusText(status)}
+		if route.Response != nil && status != http.StatusNoContent && status != http.StatusFound {
+			content := map[string]any{route.MediaType(): map[string]any{"schema": b.schema(route.Response)}}
+			if route.Stream != nil {
+				content["text/event-stream"] = map[string]any{
+					"schema":                 map[string]any{"type": "string"},
+					"x-kafclaw-stream-event": b.schema(route.Stream),
+				}
+			}
+			resp["content"] = content
+		}
+		if status == http.StatusFound {
+			resp["headers"] = map[string]any{"Location": map[string]any{"schema": map[string]any{"type": "string"}}}
+		}
+		responses[strconv.Itoa(status)] = resp
+	}
+	errContent := map[string]any{
+		"text/plain":       map[string]any{"schema": map[string]any{"type": "string"}},
+		"application/json": map[string]any{"schema": b.schema(reflect.TypeFor[ErrorResponse]())},
+	}
+	if route.Error != nil {
+		errContent = map[string]any{"application/json": map[string]any{"schema": b.schema(route.Error)}}
+	}
+	responses["default"] = map[string]any{"description": "Error", "content": errContent}
+	op["responses"] = responses
+	return op
+}
+
+func (b *specBuilder) parameters(t reflect.Type) []any {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	var params []any
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		kind, name := paramKind(f)
+		if kind == "" || kind == "raw" {
+			continue
+		}
+		params = append(params, map[string]any{
+			"name":     name,
+			"in":       kind,
+			"required": kind == "path",
+			"schema":   b.schema(f.Type),
+		})
+	}
+	return params
+}
+
+var (
+	timeType      = reflect.TypeFor[time.Time]()
+	rawType       = reflect.TypeFor[json.RawMessage]()
+	marshalerType = reflect.TypeFor[json.Marshaler]()
+	textType      = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// schema returns the JSON Schema of values of t as encoding/json writes
+// them. Named structs become components.
+func (b *specBuilder) schema(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawType, t.Kind() == reflect.Interface:
+		return map[string]any{}
+	case t.Kind() != reflect.Pointer && (t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType)):
+		return map[string]any{}
+	case t.Kind() != reflect.Pointer && (t.Implements(textType) || reflect.PointerTo(t).Implements(textType)):
+		return map[string]any{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Pointer:
+		return nullable(b.schema(t.Elem()))
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": []string{"string", "null"}, "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": []string{"array", "null"}, "items": b.schema(t.Elem())}
+	case reflect.Array:
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		name := b.componentName(t)
+		if _, ok := b.schemas[name]; !ok {
+			b.schemas[name] = map[string]any{} // placeholder for recursive types
+			b.schemas[name] = b.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]any{}
+}
+
+// componentName names gatewayapi types bare and other packages' types
+// "pkg.Name".
+func (b *specBuilder) componentName(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if t.PkgPath() != reflect.TypeFor[Route]().PkgPath() {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	b.names[t] = name
+	return name
+}
+
+func (b *specBuilder) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	required := []string{}
+	b.fields(t, props, &required)
+	s := map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func (b *specBuilder) fields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if kind, _ := paramKind(f); kind != "" {
+			continue
+		}
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.fields(ft, props, required)
+				continue
+			}
+		}
+		name, omitempty := jsonName(f)
+		if name == "" {
+			continue
+		}
+		props[name] = b.schema(f.Type)
+		if !omitempty {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// nullable widens s to also accept null.
+func nullable(s map[string]any) map[string]any {
+	switch typ := s["type"].(type) {
+	case string:
+		out := make(map[string]any, len(s))
+		for k, v := range s {
+			out[k] = v
+		}
+		out["type"] = []string{typ, "null"}
+		return out
+	case []string:
+		return s
+	}
+	if len(s) == 0 {
+		return s
+	}
+	return map[string]any{"anyOf": []any{s, map[string]any{"type": "null"}}}
+}
@@ -0,0 +1,74 @@
+package gatewayapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSpecDocumentsEveryRoute(t *testing.T) {
+	raw, err := Spec("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		OpenAPI string                               `json:"openapi"`
+		Paths   map[string]map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Fatalf("openapi = %q", doc.OpenAPI)
+	}
+	seen := map[string]bool{}
+	for _, route := range Routes() {
+		if seen[route.OperationID] {
+			t.Errorf("duplicate operation %s", route.OperationID)
+		}
+		seen[route.OperationID] = true
+		op := doc.Paths[route.Path][strings.ToLower(route.Method)]
+		if op == nil || op["operationId"] != route.OperationID {
+			t.Errorf("%s %s is not documented as %s", route.Method, route.Path, route.OperationID)
+			continue
+		}
+		_, hasSecurity := op["security"]
+		if hasSecurity != route.Public {
+			t.Errorf("%s: security override = %v, public = %v", route.OperationID, hasSecurity, route.Public)
+		}
+	}
+}
+
+func TestValidatorChecksResponseSchema(t *testing.T) {
+	raw, err := Spec("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := NewValidator(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := `{"version":"1","mode":"standalone","agent_id":"a","uptime_seconds":3,"group_enabled":false,"orchestrator_enabled":false,"orchestrator_active":false,"model":"m"}`
+	if err := v.ValidateResponse("getStatus", http.StatusOK, "application/json", []byte(ok)); err != nil {
+		t.Fatalf("valid status rejected: %v", err)
+	}
+	bad := map[string]string{
+		"wrong type":    strings.Replace(ok, `"uptime_seconds":3`, `"uptime_seconds":"3"`, 1),
+		"missing field": strings.Replace(ok, `"model":"m"`, `"extra":"m"`, 1),
+	}
+	for name, body := range bad {
+		if err := v.ValidateResponse("getStatus", http.StatusOK, "application/json", []byte(body)); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+	if err := v.ValidateResponse("getStatus", http.StatusCreated, "application/json", []byte(ok)); err == nil {
+		t.Errorf("expected an undocumented status to fail")
+	}
+	if err := v.ValidateResponse("getStatus", http.StatusBadRequest, "text/plain; charset=utf-8", []byte("nope")); err != nil {
+		t.Errorf("plain-text error rejected: %v", err)
+	}
+	if err := v.ValidateResponse("getStatus", http.StatusForbidden, "application/json", []byte(`{"error":"no"}`)); err != nil {
+		t.Errorf("JSON error rejected: %v", err)
+	}
+}
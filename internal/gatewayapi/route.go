@@ -0,0 +1,108 @@
+// Package gatewayapi is the typed contract of the gateway HTTP API: the
+// route table, request/response types, the router that binds handlers to
+// routes, the generated OpenAPI document and the Go client generator.
+package gatewayapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Server identifies which gateway listener serves a route.
+type Server string
+
+const (
+	ServerAPI       Server = "api"       // gateway.port: /chat and the OpenAI-compatible endpoints
+	ServerDashboard Server = "dashboard" // gateway.dashboardPort: /api/v1 and the web UI
+)
+
+// Route describes one gateway operation.
+type Route struct {
+	OperationID string
+	Method      string
+	Path        string // ServeMux path, with {name} wildcards
+	Server      Server
+	Tag         string
+	Summary     string
+
+	// Request and Response are the Go types of the decoded request and the
+	// encoded response; nil means none. Request struct fields tagged
+	// path, query or header are parameters, the remaining JSON fields form
+	// the body.
+	Request  reflect.Type
+	Response reflect.Type
+
+	ContentType  string       // response media type; "" means application/json
+	Stream       reflect.Type // server-sent event payload when the request asks to stream
+	Error        reflect.Type // JSON error body; nil means the plain-text default
+	Statuses     []int        // success statuses; nil means 200
+	Public       bool         // served without authentication
+	OptionalBody bool         // an empty request body decodes as the zero value
+	NoClient     bool         // browser or signed flows left out of the generated client
+}
+
+// Pattern returns the ServeMux pattern of the route.
+func (r Route) Pattern() string {
+	return r.Method + " " + r.Path
+}
+
+// PathParams returns the wildcard names of the route path in order.
+func (r Route) PathParams() []string {
+	var names []string
+	for _, seg := range strings.Split(r.Path, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			names = append(names, strings.TrimSuffix(strings.Trim(seg, "{}"), "..."))
+		}
+	}
+	return names
+}
+
+// SuccessStatus returns the first success status of the route.
+func (r Route) SuccessStatus() int {
+	if len(r.Statuses) == 0 {
+		return 200
+	}
+	return r.Statuses[0]
+}
+
+// MediaType returns the response media type of the route.
+func (r Route) MediaType() string {
+	if r.ContentType == "" {
+		return "application/json"
+	}
+	return r.ContentType
+}
+
+// Lookup returns the route with the given operation ID.
+func Lookup(operationID string) (Route, bool) {
+	r, ok := routeIndex[operationID]
+	return r, ok
+}
+
+// MustLookup is Lookup for operation IDs known at compile time.
+func MustLookup(operationID string) Route {
+	r, ok := Lookup(operationID)
+	if !ok {
+		panic(fmt.Sprintf("gatewayapi: unknown operation %q", operationID))
+	}
+	return r
+}
+
+var routeIndex = func() map[string]Route {
+	idx := make(map[string]Route, len(routes))
+	for _, r := range routes {
+		if _, dup := idx[r.OperationID]; dup {
+			panic(fmt.Sprintf("gatewayapi: duplicate operation %q", r.OperationID))
+		}
+		idx[r.OperationID] = r
+	}
+	return idx
+}()
+
+// Routes returns the route table in declaration order.
+func Routes() []Route {
+	out := make([]Route, len(routes))
+	copy(out, routes)
+	return out
+}
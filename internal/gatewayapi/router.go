@@ -0,0 +1,145 @@
+package gatewayapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+// Error is a handler error with the HTTP status it maps to. Other errors
+// are reported as 500.
+type Error struct {
+	Status  int
+	Message string
+	JSON    bool // write {"error": message} instead of plain text
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Errorf returns a plain-text handler error.
+func Errorf(status int, format string, args ...any) *Error {
+	return &Error{Status: status, Message: fmt.Sprintf(format, args...)}
+}
+
+// JSONError returns a handler error written as an ErrorResponse body.
+func JSONError(status int, message string) *Error {
+	return &Error{Status: status, Message: message, JSON: true}
+}
+
+// WriteError writes err as the response.
+func WriteError(w http.ResponseWriter, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if apiErr.JSON {
+		WriteJSON(w, apiErr.Status, ErrorResponse{Error: apiErr.Message})
+		return
+	}
+	http.Error(w, apiErr.Message, apiErr.Status)
+}
+
+// WriteJSON writes v as a JSON response with status.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Router binds handlers to the routes of one server on a ServeMux.
+type Router struct {
+	mux        *http.ServeMux
+	server     Server
+	registered map[string]bool
+}
+
+// NewRouter returns a router for server's routes on mux. It answers CORS
+// preflight requests for every path.
+func NewRouter(mux *http.ServeMux, server Server) *Router {
+	mux.HandleFunc("OPTIONS /", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Channel-Token, X-KafClaw-Session")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return &Router{mux: mux, server: server, registered: map[string]bool{}}
+}
+
+// HandleFunc registers a raw handler for an operation whose response is
+// not a single JSON value (streams, redirects, text bodies).
+func (rt *Router) HandleFunc(operationID string, h http.HandlerFunc) {
+	route := rt.claim(operationID)
+	rt.mux.HandleFunc(route.Pattern(), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		h(w, r)
+	})
+}
+
+// Unregistered lists the operation IDs of this router's server that have
+// no handler.
+func (rt *Router) Unregistered() []string {
+	var missing []string
+	for _, route := range routes {
+		if route.Server == rt.server && !rt.registered[route.OperationID] {
+			missing = append(missing, route.OperationID)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func (rt *Router) claim(operationID string) Route {
+	route := MustLookup(operationID)
+	if route.Server != rt.server {
+		panic(fmt.Sprintf("gatewayapi: %s is served by %s, not %s", operationID, route.Server, rt.server))
+	}
+	if rt.registered[operationID] {
+		panic(fmt.Sprintf("gatewayapi: %s registered twice", operationID))
+	}
+	rt.registered[operationID] = true
+	return route
+}
+
+// Handle registers a typed handler: the request is decoded into Req and
+// the returned Resp is written with the route's success status. Req and
+// Resp must match the route table; struct{} stands for "none".
+func Handle[Req, Resp any](rt *Router, operationID string, h func(*http.Request, Req) (Resp, error)) {
+	route := rt.claim(operationID)
+	checkType(operationID, "request", route.Request, reflect.TypeFor[Req]())
+	checkType(operationID, "response", route.Response, reflect.TypeFor[Resp]())
+	text := route.MediaType() != "application/json"
+	rt.mux.HandleFunc(route.Pattern(), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		var req Req
+		if err := DecodeRequest(route, r, &req); err != nil {
+			WriteError(w, err)
+			return
+		}
+		resp, err := h(r, req)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		if text {
+			w.Header().Set("Content-Type", route.MediaType()+"; charset=utf-8")
+			w.WriteHeader(route.SuccessStatus())
+			io.WriteString(w, any(resp).(string))
+			return
+		}
+		WriteJSON(w, route.SuccessStatus(), resp)
+	})
+}
+
+func checkType(operationID, what string, want, got reflect.Type) {
+	if want == nil {
+		want = reflect.TypeFor[struct{}]()
+	}
+	if want != got {
+		panic(fmt.Sprintf("gatewayapi: %s %s is %s, handler uses %s", operationID, what, want, got))
+	}
+}
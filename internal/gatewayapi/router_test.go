@@ -0,0 +1,77 @@
+package gatewayapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleDecodesParametersAndWritesErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	rt := NewRouter(mux, ServerDashboard)
+	Handle(rt, "getSessionHistory", func(r *http.Request, req SessionHistoryRequest) (SessionHistory, error) {
+		if req.Limit > 100 {
+			return SessionHistory{}, JSONError(http.StatusBadRequest, "limit too large")
+		}
+		return SessionHistory{Key: req.Key}, nil
+	})
+	Handle(rt, "respondApproval", func(r *http.Request, req ApprovalDecision) (ApprovalSent, error) {
+		return ApprovalSent{Status: "sent", ApprovalID: req.ID}, nil
+	})
+
+	cases := []struct {
+		method, target, body string
+		status               int
+		want                 string
+	}{
+		{"GET", "/api/v1/sessions/cli:x?limit=5", "", 200, `"key":"cli:x"`},
+		{"GET", "/api/v1/sessions/cli:x?limit=five", "", 400, "invalid limit"},
+		{"GET", "/api/v1/sessions/cli:x?limit=500", "", 400, `{"error":"limit too large"}`},
+		{"POST", "/api/v1/sessions/cli:x", "", 405, ""},
+		{"POST", "/api/v1/approvals/ap1", `{"approved":true}`, 200, `"approval_id":"ap1"`},
+		{"POST", "/api/v1/approvals/ap1", "", 400, "invalid request body"},
+		{"POST", "/api/v1/approvals/ap1", "{", 400, "invalid request body"},
+		{"OPTIONS", "/api/v1/approvals/ap1", "", 204, ""},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(c.method, c.target, strings.NewReader(c.body)))
+		body, _ := io.ReadAll(rec.Body)
+		if rec.Code != c.status || !strings.Contains(string(body), c.want) {
+			t.Errorf("%s %s: %d %s, want %d containing %q", c.method, c.target, rec.Code, body, c.status, c.want)
+		}
+	}
+	if missing := rt.Unregistered(); len(missing) == 0 {
+		t.Fatalf("expected unregistered dashboard operations")
+	}
+}
+
+func TestHandleRejectsTypesThatDriftFromTheRouteTable(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic for a mismatched response type")
+		}
+	}()
+	Handle(NewRouter(http.NewServeMux(), ServerDashboard), "getStatus", func(*http.Request, struct{}) (map[string]any, error) {
+		return nil, nil
+	})
+}
+
+func TestEncodeRequestRoundTrips(t *testing.T) {
+	route := MustLookup("getSessionHistory")
+	enc, err := EncodeRequest(route, SessionHistoryRequest{Key: "a b", Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc.Path != "/api/v1/sessions/a%20b" || enc.Query.Get("limit") != "3" || enc.Body != nil {
+		t.Fatalf("unexpected encoding %+v", enc)
+	}
+	r := httptest.NewRequest("GET", enc.Path+"?"+enc.Query.Encode(), nil)
+	r.SetPathValue("key", "a b")
+	var got SessionHistoryRequest
+	if err := DecodeRequest(route, r, &got); err != nil || got != (SessionHistoryRequest{Key: "a b", Limit: 3}) {
+		t.Fatalf("decoded %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,265 @@
+package gatewayapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/KafClaw/KafClaw/internal/group"
+	"github.com/KafClaw/KafClaw/internal/orchestrator"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/KafClaw/KafClaw/internal/tools"
+)
+
+func typ[T any]() reflect.Type { return reflect.TypeFor[T]() }
+
+const (
+	get  = http.MethodGet
+	post = http.MethodPost
+	del  = http.MethodDelete
+)
+
+// routes is the gateway API. Adding an endpoint means adding its route
+// here, binding a handler in internal/cli and running go generate for the
+// client; the contract tests fail until all three agree.
+var routes = []Route{
+	// API server
+	{OperationID: "chat", Method: post, Path: "/chat", Server: ServerAPI, Tag: "chat",
+		Summary: "Send a message to the agent and wait for the reply",
+		Request: typ[ChatRequest](), Response: typ[string](), ContentType: "text/plain"},
+	{OperationID: "listModels", Method: get, Path: "/v1/models", Server: ServerAPI, Tag: "openai",
+		Summary:  "List the agents served as OpenAI models",
+		Response: typ[ModelList](), Error: typ[OpenAIErrorResponse]()},
+	{OperationID: "createChatCompletion", Method: post, Path: "/v1/chat/completions", Server: ServerAPI, Tag: "openai",
+		Summary: "Run an agent turn as an OpenAI chat completion",
+		Request: typ[ChatCompletionRequest](), Response: typ[ChatCompletion](), Stream: typ[ChatCompletionChunk](),
+		Error: typ[OpenAIErrorResponse]()},
+
+	// Gateway and auth
+	{OperationID: "getOpenAPI", Method: get, Path: "/api/openapi.json", Server: ServerDashboard, Tag: "gateway",
+		Summary: "This OpenAPI document", Response: typ[json.RawMessage](), Public: true},
+	{OperationID: "getStatus", Method: get, Path: "/api/v1/status", Server: ServerDashboard, Tag: "gateway",
+		Summary: "Health check", Response: typ[GatewayStatus](), Public: true},
+	{OperationID: "getMetrics", Method: get, Path: "/metrics", Server: ServerDashboard, Tag: "gateway",
+		Summary: "Prometheus metrics", Response: typ[string](), ContentType: "text/plain", Public: true, NoClient: true},
+	{OperationID: "verifyAuth", Method: post, Path: "/api/v1/auth/verify", Server: ServerDashboard, Tag: "auth",
+		Summary: "Check the request's credentials", Response: typ[AuthVerification](), Public: true},
+	{OperationID: "whoAmI", Method: get, Path: "/api/v1/auth/whoami", Server: ServerDashboard, Tag: "auth",
+		Summary: "Describe the caller", Response: typ[WhoAmI]()},
+	{OperationID: "oidcLogin", Method: get, Path: "/auth/oidc/login", Server: ServerDashboard, Tag: "auth",
+		Summary: "Redirect to the OIDC issuer", Statuses: []int{http.StatusFound}, Public: true, NoClient: true},
+	{OperationID: "oidcCallback", Method: get, Path: "/auth/oidc/callback", Server: ServerDashboard, Tag: "auth",
+		Summary: "Complete an OIDC login and start a web session", Request: typ[OIDCCallbackRequest](),
+		Statuses: []int{http.StatusFound}, Public: true, NoClient: true},
+	{OperationID: "logout", Method: post, Path: "/auth/logout", Server: ServerDashboard, Tag: "auth",
+		Summary: "End the web session", Statuses: []int{http.StatusNoContent}, Public: true, NoClient: true},
+	{OperationID: "logoutRedirect", Method: get, Path: "/auth/logout", Server: ServerDashboard, Tag: "auth",
+		Summary: "End the web session and return to the dashboard", Statuses: []int{http.StatusFound}, Public: true, NoClient: true},
+
+	// Channel bridges
+	{OperationID: "slackInbound", Method: post, Path: "/api/v1/channels/slack/inbound", Server: ServerDashboard, Tag: "channels",
+		Summary: "Deliver a message from the Slack bridge", Request: typ[ChannelInboundRequest](), Response: typ[OK]()},
+	{OperationID: "msteamsInbound", Method: post, Path: "/api/v1/channels/msteams/inbound", Server: ServerDashboard, Tag: "channels",
+		Summary: "Deliver a message from the Teams bridge", Request: typ[ChannelInboundRequest](), Response: typ[OK]()},
+	{OperationID: "webhookInbound", Method: post, Path: "/api/v1/channels/webhook/{account}", Server: ServerDashboard, Tag: "channels",
+		Summary: "Deliver a signed webhook message", Request: typ[WebhookInboundRequest](), Response: typ[OK](),
+		Public: true, NoClient: true},
+
+	// Orchestrator
+	{OperationID: "getOrchestratorStatus", Method: get, Path: "/api/v1/orchestrator/status", Server: ServerDashboard, Tag: "orchestrator",
+		Summary: "Orchestrator role of this agent", Response: typ[OrchestratorStatus]()},
+	{OperationID: "getOrchestratorHierarchy", Method: get, Path: "/api/v1/orchestrator/hierarchy", Server: ServerDashboard, Tag: "orchestrator",
+		Summary: "Agent hierarchy", Response: typ[[]orchestrator.AgentNode]()},
+	{OperationID: "listOrchestratorZones", Method: get, Path: "/api/v1/orchestrator/zones", Server: ServerDashboard, Tag: "orchestrator",
+		Summary: "Zones with member counts", Response: typ[[]orchestrator.ZoneSummary]()},
+	{OperationID: "listOrchestratorAgents", Method: get, Path: "/api/v1/orchestrator/agents", Server: ServerDashboard, Tag: "orchestrator",
+		Summary: "Known agents", Response: typ[[]orchestrator.AgentNode]()},
+	{OperationID: "dispatchOrchestratorTask", Method: post, Path: "/api/v1/orchestrator/dispatch", Server: ServerDashboard, Tag: "orchestrator",
+		Summary: "Dispatch a task into a zone", Request: typ[DispatchRequest](), Response: typ[TaskSubmitted]()},
+
+	// Timeline and traces
+	{OperationID: "listTimeline", Method: get, Path: "/api/v1/timeline", Server: ServerDashboard, Tag: "timeline",
+		Summary: "Timeline events, newest first", Request: typ[TimelineRequest](), Response: typ[[]timeline.TimelineEvent]()},
+	{OperationID: "getTrace", Method: get, Path: "/api/v1/trace/{id}", Server: ServerDashboard, Tag: "timeline",
+		Summary: "Spans, task and policy decisions of a trace", Request: typ[TraceRequest](), Response: typ[Trace]()},
+	{OperationID: "listPolicyDecisions", Method: get, Path: "/api/v1/policy-decisions", Server: ServerDashboard, Tag: "timeline",
+		Summary: "Policy decisions of a trace", Request: typ[PolicyDecisionsRequest](), Response: typ[[]timeline.PolicyDecisionRecord]()},
+	{OperationID: "getTraceGraph", Method: get, Path: "/api/v1/trace-graph/{id}", Server: ServerDashboard, Tag: "timeline",
+		Summary: "Call graph of a trace", Request: typ[TraceRequest](), Response: typ[timeline.TraceGraph]()},
+
+	// Group
+	{OperationID: "getGroupStatus", Method: get, Path: "/api/v1/group/status", Server: ServerDashboard, Tag: "group",
+		Summary: "Group membership of this agent", Response: typ[GroupStatus]()},
+	{OperationID: "listGroupMembers", Method: get, Path: "/api/v1/group/members", Server: ServerDashboard, Tag: "group",
+		Summary: "Group roster", Response: typ[[]GroupMember]()},
+	{OperationID: "joinGroup", Method: post, Path: "/api/v1/group/join", Server: ServerDashboard, Tag: "group",
+		Summary: "Join a group", Request: typ[JoinGroupRequest](), Response: typ[GroupStatus]()},
+	{OperationID: "leaveGroup", Method: post, Path: "/api/v1/group/leave", Server: ServerDashboard, Tag: "group",
+		Summary: "Leave the group", Response: typ[Ack]()},
+	{OperationID: "getGroupConfig", Method: get, Path: "/api/v1/group/config", Server: ServerDashboard, Tag: "group",
+		Summary: "Effective group configuration", Response: typ[GroupConfig]()},
+	{OperationID: "updateGroupConfig", Method: post, Path: "/api/v1/group/config", Server: ServerDashboard, Tag: "group",
+		Summary: "Store group settings", Request: typ[GroupConfigUpdate](), Response: typ[GroupConfigSaved]()},
+	{OperationID: "submitGroupTask", Method: post, Path: "/api/v1/group/tasks/submit", Server: ServerDashboard, Tag: "group",
+		Summary: "Submit a task to the group", Request: typ[GroupTaskRequest](), Response: typ[TaskSubmitted]()},
+	{OperationID: "listGroupTasks", Method: get, Path: "/api/v1/group/tasks", Server: ServerDashboard, Tag: "group",
+		Summary: "Group tasks", Request: typ[GroupTasksRequest](), Response: typ[[]timeline.GroupTaskRecord]()},
+	{OperationID: "listGroupTraces", Method: get, Path: "/api/v1/group/traces", Server: ServerDashboard, Tag: "group",
+		Summary: "Traces shared by group members", Request: typ[GroupTracesRequest](), Response: typ[[]timeline.GroupTrace]()},
+	{OperationID: "listGroupMemory", Method: get, Path: "/api/v1/group/memory", Server: ServerDashboard, Tag: "group",
+		Summary: "Shared memory items", Request: typ[GroupMemoryRequest](), Response: typ[[]timeline.GroupMemoryItemRecord]()},
+	{OperationID: "shareGroupMemory", Method: post, Path: "/api/v1/group/memory", Server: ServerDashboard, Tag: "group",
+		Summary: "Share a memory item", Request: typ[ShareMemoryRequest](), Response: typ[Ack]()},
+	{OperationID: "listGroupSkills", Method: get, Path: "/api/v1/group/skills", Server: ServerDashboard, Tag: "group",
+		Summary: "Skill channels", Request: typ[GroupSkillsRequest](), Response: typ[[]timeline.GroupSkillChannelRecord]()},
+	{OperationID: "registerGroupSkill", Method: post, Path: "/api/v1/group/skills", Server: ServerDashboard, Tag: "group",
+		Summary: "Register a skill channel", Request: typ[RegisterSkillRequest](), Response: typ[SkillRegistered]()},
+	{OperationID: "submitSkillTask", Method: post, Path: "/api/v1/group/skills/task", Server: ServerDashboard, Tag: "group",
+		Summary: "Submit a task to a skill channel", Request: typ[SkillTaskRequest](), Response: typ[TaskSubmitted]()},
+	{OperationID: "onboardGroup", Method: post, Path: "/api/v1/group/onboard", Server: ServerDashboard, Tag: "group",
+		Summary: "Request onboarding into the group", Response: typ[Ack]()},
+	{OperationID: "listMembershipHistory", Method: get, Path: "/api/v1/group/membership/history", Server: ServerDashboard, Tag: "group",
+		Summary: "Join and leave history", Request: typ[MembershipHistoryRequest](), Response: typ[[]timeline.GroupMembershipHistoryRecord]()},
+	{OperationID: "listPreviousGroupMembers", Method: get, Path: "/api/v1/group/members/previous", Server: ServerDashboard, Tag: "group",
+		Summary: "Members that left", Response: typ[[]timeline.GroupMemberRecord]()},
+	{OperationID: "rejoinGroupMember", Method: post, Path: "/api/v1/group/rejoin", Server: ServerDashboard, Tag: "group",
+		Summary: "Reactivate a previous member", Request: typ[RejoinRequest](), Response: typ[Rejoined]()},
+	{OperationID: "getGroupStats", Method: get, Path: "/api/v1/group/stats", Server: ServerDashboard, Tag: "group",
+		Summary: "Group activity counters", Response: typ[timeline.GroupStats]()},
+	{OperationID: "listGroupAudit", Method: get, Path: "/api/v1/group/audit", Server: ServerDashboard, Tag: "group",
+		Summary: "Unified audit log", Request: typ[AuditRequest](), Response: typ[[]timeline.UnifiedAuditEntry]()},
+	{OperationID: "getGroupManifest", Method: get, Path: "/api/v1/group/manifest", Server: ServerDashboard, Tag: "topics",
+		Summary: "Topic manifest of the group", Response: typ[group.TopicManifest]()},
+	{OperationID: "listGroupTopics", Method: get, Path: "/api/v1/group/topics", Server: ServerDashboard, Tag: "topics",
+		Summary: "Topics with stats, health and the XP leaderboard", Response: typ[GroupTopics]()},
+	{OperationID: "listGroupTopicMessages", Method: get, Path: "/api/v1/group/topics/messages", Server: ServerDashboard, Tag: "topics",
+		Summary: "Recent messages of a topic", Request: typ[TopicMessagesRequest](), Response: typ[TopicMessages]()},
+	{OperationID: "getGroupTopicFlow", Method: get, Path: "/api/v1/group/topics/flow", Server: ServerDashboard, Tag: "topics",
+		Summary: "Sender to topic traffic", Response: typ[TopicFlow]()},
+	{OperationID: "getGroupTopicHealth", Method: get, Path: "/api/v1/group/topics/health", Server: ServerDashboard, Tag: "topics",
+		Summary: "Topic health", Response: typ[TopicHealthList]()},
+	{OperationID: "ensureGroupTopic", Method: post, Path: "/api/v1/group/topics/ensure", Server: ServerDashboard, Tag: "topics",
+		Summary: "Create a topic if missing", Request: typ[EnsureTopicRequest](), Response: typ[TopicEnsured]()},
+	{OperationID: "getGroupTopicXP", Method: get, Path: "/api/v1/group/topics/xp", Server: ServerDashboard, Tag: "topics",
+		Summary: "Agent XP leaderboard", Response: typ[XPLeaderboard]()},
+	{OperationID: "getGroupTopicDensity", Method: get, Path: "/api/v1/group/topics/density", Server: ServerDashboard, Tag: "topics",
+		Summary: "Hourly message counts of a topic", Request: typ[TopicDensityRequest](), Response: typ[TopicDensity]()},
+
+	// Settings and memory
+	{OperationID: "getSettings", Method: get, Path: "/api/v1/settings", Server: ServerDashboard, Tag: "settings",
+		Summary: "Read a setting, or silent mode", Request: typ[SettingsRequest](), Response: typ[Setting]()},
+	{OperationID: "updateSetting", Method: post, Path: "/api/v1/settings", Server: ServerDashboard, Tag: "settings",
+		Summary: "Store a setting", Request: typ[SettingUpdate](), Response: typ[Ack]()},
+	{OperationID: "getMemoryStatus", Method: get, Path: "/api/v1/memory/status", Server: ServerDashboard, Tag: "memory",
+		Summary: "Memory layers, observer, ER1 and expertise", Response: typ[MemoryStatus]()},
+	{OperationID: "getMemoryMetrics", Method: get, Path: "/api/v1/memory/metrics", Server: ServerDashboard, Tag: "memory",
+		Summary: "Memory and knowledge quality metrics", Response: typ[MemoryMetrics]()},
+	{OperationID: "resetMemory", Method: post, Path: "/api/v1/memory/reset", Server: ServerDashboard, Tag: "memory",
+		Summary: "Delete a memory layer", Request: typ[MemoryResetRequest](), Response: typ[MemoryDeleted]()},
+	{OperationID: "updateMemoryConfig", Method: post, Path: "/api/v1/memory/config", Server: ServerDashboard, Tag: "memory",
+		Summary: "Store memory settings", Request: typ[MemoryConfigUpdate](), Response: typ[Ack]()},
+	{OperationID: "pruneMemory", Method: post, Path: "/api/v1/memory/prune", Server: ServerDashboard, Tag: "memory",
+		Summary: "Prune expired memory chunks", Response: typ[MemoryDeleted]()},
+	{OperationID: "getEmbeddingStatus", Method: get, Path: "/api/v1/memory/embedding/status", Server: ServerDashboard, Tag: "memory",
+		Summary: "Embedding configuration and runtime", Response: typ[EmbeddingStatus]()},
+	{OperationID: "getEmbeddingHealth", Method: get, Path: "/api/v1/memory/embedding/healthz", Server: ServerDashboard, Tag: "memory",
+		Summary: "Probe the embedding runtime; 503 when not ready", Response: typ[EmbeddingHealth](),
+		Statuses: []int{http.StatusOK, http.StatusServiceUnavailable}},
+	{OperationID: "installEmbedding", Method: post, Path: "/api/v1/memory/embedding/install", Server: ServerDashboard, Tag: "memory",
+		Summary: "Request an embedding model install", Request: typ[EmbeddingInstallRequest](), Response: typ[EmbeddingInstallRequested](),
+		OptionalBody: true},
+	{OperationID: "reindexEmbedding", Method: post, Path: "/api/v1/memory/embedding/reindex", Server: ServerDashboard, Tag: "memory",
+		Summary: "Wipe all chunks for re-embedding", Request: typ[EmbeddingReindexRequest](), Response: typ[EmbeddingReindexed]()},
+
+	// Repositories
+	{OperationID: "getWorkRepo", Method: get, Path: "/api/v1/workrepo", Server: ServerDashboard, Tag: "repo",
+		Summary: "Work repository path", Response: typ[WorkRepo]()},
+	{OperationID: "setWorkRepo", Method: post, Path: "/api/v1/workrepo", Server: ServerDashboard, Tag: "repo",
+		Summary: "Switch the work repository", Request: typ[WorkRepoUpdate](), Response: typ[WorkRepo]()},
+	{OperationID: "getRepoTree", Method: get, Path: "/api/v1/repo/tree", Server: ServerDashboard, Tag: "repo",
+		Summary: "Files and directories", Request: typ[RepoPathRequest](), Response: typ[[]RepoItem]()},
+	{OperationID: "getRepoFile", Method: get, Path: "/api/v1/repo/file", Server: ServerDashboard, Tag: "repo",
+		Summary: "File content", Request: typ[RepoPathRequest](), Response: typ[RepoFile]()},
+	{OperationID: "getRepoStatus", Method: get, Path: "/api/v1/repo/status", Server: ServerDashboard, Tag: "repo",
+		Summary: "git status and remotes", Request: typ[RepoRequest](), Response: typ[RepoStatus]()},
+	{OperationID: "searchRepos", Method: get, Path: "/api/v1/repo/search", Server: ServerDashboard, Tag: "repo",
+		Summary: "Git repositories under the search root", Response: typ[RepoSearch]()},
+	{OperationID: "getGitHubAuth", Method: get, Path: "/api/v1/repo/gh-auth", Server: ServerDashboard, Tag: "repo",
+		Summary: "GitHub CLI login state", Request: typ[RepoRequest](), Response: typ[GitHubAuth]()},
+	{OperationID: "listRepoBranches", Method: get, Path: "/api/v1/repo/branches", Server: ServerDashboard, Tag: "repo",
+		Summary: "Local branches", Request: typ[RepoRequest](), Response: typ[RepoBranches]()},
+	{OperationID: "checkoutRepoBranch", Method: post, Path: "/api/v1/repo/checkout", Server: ServerDashboard, Tag: "repo",
+		Summary: "Check out a branch", Request: typ[RepoCheckoutRequest](), Response: typ[RepoResult]()},
+	{OperationID: "getRepoLog", Method: get, Path: "/api/v1/repo/log", Server: ServerDashboard, Tag: "repo",
+		Summary: "Recent commits", Request: typ[RepoLogRequest](), Response: typ[RepoLog]()},
+	{OperationID: "getRepoFileDiff", Method: get, Path: "/api/v1/repo/diff-file", Server: ServerDashboard, Tag: "repo",
+		Summary: "Uncommitted changes of a file", Request: typ[RepoPathRequest](), Response: typ[RepoDiff]()},
+	{OperationID: "getRepoDiff", Method: get, Path: "/api/v1/repo/diff", Server: ServerDashboard, Tag: "repo",
+		Summary: "Uncommitted changes", Request: typ[RepoPathRequest](), Response: typ[RepoDiff]()},
+	{OperationID: "commitRepo", Method: post, Path: "/api/v1/repo/commit", Server: ServerDashboard, Tag: "repo",
+		Summary: "Commit all changes", Request: typ[RepoCommitRequest](), Response: typ[RepoResult]()},
+	{OperationID: "pullRepo", Method: post, Path: "/api/v1/repo/pull", Server: ServerDashboard, Tag: "repo",
+		Summary: "Fast-forward pull", Request: typ[RepoRequest](), Response: typ[RepoResult]()},
+	{OperationID: "pushRepo", Method: post, Path: "/api/v1/repo/push", Server: ServerDashboard, Tag: "repo",
+		Summary: "Push", Request: typ[RepoRequest](), Response: typ[RepoResult]()},
+	{OperationID: "initRepo", Method: post, Path: "/api/v1/repo/init", Server: ServerDashboard, Tag: "repo",
+		Summary: "Initialize the repository and set origin", Request: typ[RepoInitRequest](), Response: typ[Ack]()},
+	{OperationID: "createPullRequest", Method: post, Path: "/api/v1/repo/pr", Server: ServerDashboard, Tag: "repo",
+		Summary: "Open a GitHub pull request", Request: typ[PullRequestRequest](), Response: typ[RepoResult]()},
+
+	// Web users and persons
+	{OperationID: "listWebUsers", Method: get, Path: "/api/v1/webusers", Server: ServerDashboard, Tag: "users",
+		Summary: "Web UI users", Response: typ[[]timeline.WebUser]()},
+	{OperationID: "createWebUser", Method: post, Path: "/api/v1/webusers", Server: ServerDashboard, Tag: "users",
+		Summary: "Create a web UI user", Request: typ[CreateWebUserRequest](), Response: typ[timeline.WebUser]()},
+	{OperationID: "setWebUserForceSend", Method: post, Path: "/api/v1/webusers/force", Server: ServerDashboard, Tag: "users",
+		Summary: "Deliver to the linked chat in silent mode", Request: typ[WebUserForceSend](), Response: typ[Ack]()},
+	{OperationID: "getWebLink", Method: get, Path: "/api/v1/weblinks", Server: ServerDashboard, Tag: "users",
+		Summary: "WhatsApp link of a web user", Request: typ[WebLinkRequest](), Response: typ[WebLink]()},
+	{OperationID: "setWebLink", Method: post, Path: "/api/v1/weblinks", Server: ServerDashboard, Tag: "users",
+		Summary: "Link or unlink a web user", Request: typ[WebLinkUpdate](), Response: typ[Ack]()},
+	{OperationID: "listPersons", Method: get, Path: "/api/v1/persons", Server: ServerDashboard, Tag: "persons",
+		Summary: "Persons", Response: typ[[]timeline.Person]()},
+	{OperationID: "createPerson", Method: post, Path: "/api/v1/persons", Server: ServerDashboard, Tag: "persons",
+		Summary: "Create a person", Request: typ[CreatePersonRequest](), Response: typ[timeline.Person]()},
+	{OperationID: "getPerson", Method: get, Path: "/api/v1/persons/{id}", Server: ServerDashboard, Tag: "persons",
+		Summary: "A person and its identities", Request: typ[PersonRequest](), Response: typ[timeline.Person]()},
+	{OperationID: "createPersonLinkCode", Method: post, Path: "/api/v1/persons/{id}/link-code", Server: ServerDashboard, Tag: "persons",
+		Summary: "Issue an identity link code", Request: typ[LinkCodeRequest](), Response: typ[IdentityLinkCode]()},
+	{OperationID: "unlinkPersonIdentity", Method: post, Path: "/api/v1/persons/{id}/unlink", Server: ServerDashboard, Tag: "persons",
+		Summary: "Remove a channel identity", Request: typ[UnlinkIdentityRequest](), Response: typ[Ack]()},
+	{OperationID: "mergePerson", Method: post, Path: "/api/v1/persons/{id}/merge", Server: ServerDashboard, Tag: "persons",
+		Summary: "Merge into another person", Request: typ[MergePersonRequest](), Response: typ[timeline.Person]()},
+	{OperationID: "setPersonPreference", Method: post, Path: "/api/v1/persons/{id}/preferences", Server: ServerDashboard, Tag: "persons",
+		Summary: "Store a preference", Request: typ[PersonPreferenceRequest](), Response: typ[Ack]()},
+
+	// Chat, tasks and sessions
+	{OperationID: "sendWebChat", Method: post, Path: "/api/v1/webchat/send", Server: ServerDashboard, Tag: "chat",
+		Summary: "Send a message as a web user", Request: typ[WebChatRequest](), Response: typ[Ack]()},
+	{OperationID: "listTasks", Method: get, Path: "/api/v1/tasks", Server: ServerDashboard, Tag: "tasks",
+		Summary: "Agent tasks", Request: typ[TasksRequest](), Response: typ[[]timeline.AgentTask]()},
+	{OperationID: "getTask", Method: get, Path: "/api/v1/tasks/{id}", Server: ServerDashboard, Tag: "tasks",
+		Summary: "An agent task", Request: typ[TaskRequest](), Response: typ[timeline.AgentTask]()},
+	{OperationID: "listSessions", Method: get, Path: "/api/v1/sessions", Server: ServerDashboard, Tag: "sessions",
+		Summary: "Agent sessions", Response: typ[[]SessionSummary]()},
+	{OperationID: "getSessionHistory", Method: get, Path: "/api/v1/sessions/{key}", Server: ServerDashboard, Tag: "sessions",
+		Summary: "Recent messages of a session", Request: typ[SessionHistoryRequest](), Response: typ[SessionHistory]()},
+	{OperationID: "listSubagents", Method: get, Path: "/api/v1/subagents", Server: ServerDashboard, Tag: "sessions",
+		Summary: "Subagent runs of a session", Request: typ[SessionRequest](), Response: typ[[]tools.SubagentRunView]()},
+	{OperationID: "listProcesses", Method: get, Path: "/api/v1/processes", Server: ServerDashboard, Tag: "processes",
+		Summary: "Background processes, of one session or all", Request: typ[SessionRequest](), Response: typ[[]tools.ProcessInfo]()},
+	{OperationID: "killProcess", Method: post, Path: "/api/v1/processes/{id}/kill", Server: ServerDashboard, Tag: "processes",
+		Summary: "Kill a background process", Request: typ[ProcessRequest](), Response: typ[tools.ProcessInfo]()},
+
+	// Approvals
+	{OperationID: "listPendingApprovals", Method: get, Path: "/api/v1/approvals/pending", Server: ServerDashboard, Tag: "approvals",
+		Summary: "Approvals waiting for a decision", Response: typ[[]timeline.ApprovalRecord]()},
+	{OperationID: "listApprovalGrants", Method: get, Path: "/api/v1/approvals/grants", Server: ServerDashboard, Tag: "approvals",
+		Summary: "Remembered approval grants", Request: typ[ApprovalGrantsRequest](), Response: typ[[]timeline.ApprovalGrantRecord]()},
+	{OperationID: "revokeApprovalGrant", Method: del, Path: "/api/v1/approvals/grants/{id}", Server: ServerDashboard, Tag: "approvals",
+		Summary: "Revoke a grant", Request: typ[ApprovalGrantRequest](), Response: typ[GrantRevoked]()},
+	{OperationID: "listApprovalEvents", Method: get, Path: "/api/v1/approvals/{id}/events", Server: ServerDashboard, Tag: "approvals",
+		Summary: "Audit trail of an approval", Request: typ[ApprovalRequest](), Response: typ[[]timeline.ApprovalEventRecord]()},
+	{OperationID: "respondApproval", Method: post, Path: "/api/v1/approvals/{id}", Server: ServerDashboard, Tag: "approvals",
+		Summary: "Approve or deny", Request: typ[ApprovalDecision](), Response: typ[ApprovalSent]()},
+}
@@ -0,0 +1,376 @@
+package gatewayapi
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/rbac"
+	"github.com/KafClaw/KafClaw/internal/session"
+)
+
+// ErrorResponse is the JSON error body of group operations refused in
+// standalone mode. Other errors are plain text.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Ack acknowledges a request that returns no data.
+type Ack struct {
+	Status string `json:"status"`
+}
+
+// OK acknowledges an inbound channel message.
+type OK struct {
+	OK bool `json:"ok"`
+}
+
+// ChatRequest sends a message to the agent and waits for the reply.
+type ChatRequest struct {
+	Message string `query:"message" json:"-"`
+	Session string `query:"session" json:"-"` // default "local:default"
+	Trace   string `query:"trace" json:"-"`   // client-chosen trace ID to follow progress
+}
+
+// GatewayStatus is the unauthenticated health check.
+type GatewayStatus struct {
+	Version             string `json:"version"`
+	Mode                string `json:"mode"`
+	AgentID             string `json:"agent_id"`
+	UptimeSeconds       int    `json:"uptime_seconds"`
+	GroupEnabled        bool   `json:"group_enabled"`
+	OrchestratorEnabled bool   `json:"orchestrator_enabled"`
+	OrchestratorActive  bool   `json:"orchestrator_active"`
+	Model               string `json:"model"`
+}
+
+// AuthVerification reports whether the request's credentials are valid.
+type AuthVerification struct {
+	Valid        bool         `json:"valid"`
+	AuthRequired bool         `json:"auth_required"`
+	Principal    string       `json:"principal,omitempty"`
+	Scopes       []rbac.Scope `json:"scopes,omitempty"`
+}
+
+// WhoAmI describes the caller.
+type WhoAmI struct {
+	Identity     *rbac.Identity `json:"identity"`
+	AuthRequired bool           `json:"auth_required"`
+	OIDC         bool           `json:"oidc"`
+}
+
+// OIDCCallbackRequest is the authorization response of the OIDC issuer.
+type OIDCCallbackRequest struct {
+	Code  string `query:"code" json:"-"`
+	State string `query:"state" json:"-"`
+	Error string `query:"error" json:"-"`
+}
+
+// ChannelInboundRequest is a message posted by a Slack or Teams bridge.
+type ChannelInboundRequest struct {
+	Token          string `header:"X-Channel-Token" json:"-"`
+	AccountID      string `json:"account_id"`
+	SenderID       string `json:"sender_id"`
+	ChatID         string `json:"chat_id"`
+	ThreadID       string `json:"thread_id"`
+	MessageID      string `json:"message_id"`
+	Text           string `json:"text"`
+	IsGroup        bool   `json:"is_group"`
+	WasMentioned   bool   `json:"was_mentioned"`
+	GroupID        string `json:"group_id"`
+	ChannelID      string `json:"channel_id"`
+	HistoryLimit   int    `json:"history_limit"`
+	DMHistoryLimit int    `json:"dm_history_limit"`
+	// MediaFiles carries voice notes the bridge downloaded with the
+	// channel's credentials.
+	MediaFiles []MediaFile `json:"media_files,omitempty"`
+}
+
+// MediaFile is an attachment sent inline as base64.
+type MediaFile struct {
+	Filename      string `json:"filename"`
+	ContentBase64 string `json:"content_base64"`
+}
+
+// WebhookInboundRequest is a signed generic webhook post; the signature
+// headers are checked against the raw payload.
+type WebhookInboundRequest struct {
+	Account string          `path:"account" json:"-"`
+	Payload json.RawMessage `body:"raw" json:"-"`
+}
+
+// TimelineRequest filters timeline events.
+type TimelineRequest struct {
+	Limit   int    `query:"limit" json:"-"` // default 100
+	Offset  int    `query:"offset" json:"-"`
+	Sender  string `query:"sender" json:"-"`
+	TraceID string `query:"trace_id" json:"-"`
+}
+
+// TraceRequest selects one trace.
+type TraceRequest struct {
+	ID string `path:"id" json:"-"`
+}
+
+// PolicyDecisionsRequest selects the policy decisions of a trace.
+type PolicyDecisionsRequest struct {
+	TraceID string `query:"trace_id" json:"-"`
+}
+
+// Trace is the span view of one trace.
+type Trace struct {
+	TraceID         string                `json:"trace_id"`
+	Spans           []TraceSpan           `json:"spans"`
+	Task            *TraceTask            `json:"task"`
+	PolicyDecisions []TracePolicyDecision `json:"policy_decisions"`
+}
+
+// TraceSpan is one timeline event of a trace.
+type TraceSpan struct {
+	ID       string         `json:"id"`
+	Type     string         `json:"type"` // INBOUND, OUTBOUND, LLM, TOOL or EVENT
+	Title    string         `json:"title"`
+	Time     string         `json:"time"`
+	Duration string         `json:"duration"`
+	Output   string         `json:"output"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// TraceTask is the agent task of a trace.
+type TraceTask struct {
+	TaskID           string     `json:"task_id"`
+	Status           string     `json:"status"`
+	DeliveryStatus   string     `json:"delivery_status"`
+	PromptTokens     int        `json:"prompt_tokens"`
+	CompletionTokens int        `json:"completion_tokens"`
+	TotalTokens      int        `json:"total_tokens"`
+	Channel          string     `json:"channel"`
+	CreatedAt        time.Time  `json:"created_at"`
+	CompletedAt      *time.Time `json:"completed_at"`
+}
+
+// TracePolicyDecision is a policy decision taken during a trace.
+type TracePolicyDecision struct {
+	Tool    string `json:"tool"`
+	Tier    int    `json:"tier"`
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+	Time    string `json:"time"`
+}
+
+// OrchestratorStatus is the orchestrator role of this agent. Enabled
+// reflects configuration, Active whether the orchestrator is running.
+type OrchestratorStatus struct {
+	Enabled    bool   `json:"enabled"`
+	Active     bool   `json:"active"`
+	Role       string `json:"role,omitempty"`
+	AgentID    string `json:"agent_id,omitempty"`
+	ZoneID     string `json:"zone_id,omitempty"`
+	ParentID   string `json:"parent_id,omitempty"`
+	AgentCount int    `json:"agent_count"`
+	ZoneCount  int    `json:"zone_count"`
+}
+
+// DispatchRequest dispatches a task into an orchestrator zone.
+type DispatchRequest struct {
+	Description string `json:"description"`
+	TargetZone  string `json:"target_zone"` // default "public"
+}
+
+// TaskSubmitted acknowledges a submitted or dispatched task.
+type TaskSubmitted struct {
+	Status string `json:"status"`
+	TaskID string `json:"task_id"`
+	Skill  string `json:"skill,omitempty"`
+}
+
+// SettingsRequest reads one setting, or silent mode when Key is empty.
+type SettingsRequest struct {
+	Key string `query:"key" json:"-"`
+}
+
+// Setting is a stored setting, or the silent mode flag.
+type Setting struct {
+	Key        string  `json:"key,omitempty"`
+	Value      *string `json:"value,omitempty"`
+	SilentMode *bool   `json:"silent_mode,omitempty"`
+}
+
+// SettingUpdate stores a setting.
+type SettingUpdate struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// WorkRepo is the agent's work repository.
+type WorkRepo struct {
+	Status string `json:"status,omitempty"`
+	Path   string `json:"path"`
+}
+
+// WorkRepoUpdate switches the work repository.
+type WorkRepoUpdate struct {
+	Path string `json:"path"`
+}
+
+// CreateWebUserRequest creates a web UI user.
+type CreateWebUserRequest struct {
+	Name string `json:"name"`
+	// Principal links the web user to a gateway principal so OIDC logins
+	// as that principal chat as this user.
+	Principal string `json:"principal,omitempty"`
+}
+
+// WebUserForceSend toggles delivery to a web user's linked WhatsApp chat
+// in silent mode.
+type WebUserForceSend struct {
+	WebUserID int64 `json:"web_user_id"`
+	ForceSend bool  `json:"force_send"`
+}
+
+// WebLinkRequest selects the WhatsApp link of a web user.
+type WebLinkRequest struct {
+	WebUserID int64 `query:"web_user_id" json:"-"`
+}
+
+// WebLink links a web user to a WhatsApp JID; an empty JID unlinks.
+type WebLink struct {
+	WebUserID   string `json:"web_user_id"`
+	WhatsAppJID string `json:"whatsapp_jid"`
+}
+
+// WebLinkUpdate links or unlinks a web user.
+type WebLinkUpdate struct {
+	WebUserID   int64  `json:"web_user_id"`
+	WhatsAppJID string `json:"whatsapp_jid"`
+}
+
+// CreatePersonRequest creates a person.
+type CreatePersonRequest struct {
+	DisplayName string `json:"display_name"`
+}
+
+// PersonRequest selects a person.
+type PersonRequest struct {
+	ID string `path:"id" json:"-"`
+}
+
+// LinkCodeRequest issues a code that links a channel identity to a person.
+type LinkCodeRequest struct {
+	ID         string `path:"id" json:"-"`
+	Channel    string `json:"channel"`
+	TTLMinutes int    `json:"ttl_minutes"`
+}
+
+// IdentityLinkCode is a pending identity link.
+type IdentityLinkCode struct {
+	PersonID  string    `json:"person_id"`
+	Channel   string    `json:"channel"`
+	Code      string    `json:"code"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UnlinkIdentityRequest removes a channel identity from a person.
+type UnlinkIdentityRequest struct {
+	ID       string `path:"id" json:"-"`
+	Channel  string `json:"channel"`
+	SenderID string `json:"sender_id"`
+}
+
+// MergePersonRequest merges a person into another.
+type MergePersonRequest struct {
+	ID   string `path:"id" json:"-"`
+	Into string `json:"into"`
+}
+
+// PersonPreferenceRequest stores a person preference.
+type PersonPreferenceRequest struct {
+	ID    string `path:"id" json:"-"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// WebChatRequest sends a message as a web user.
+type WebChatRequest struct {
+	WebUserID int64  `json:"web_user_id"`
+	Message   string `json:"message"`
+}
+
+// TasksRequest filters agent tasks.
+type TasksRequest struct {
+	Status  string `query:"status" json:"-"`
+	Channel string `query:"channel" json:"-"`
+	Limit   int    `query:"limit" json:"-"` // default 50
+	Offset  int    `query:"offset" json:"-"`
+}
+
+// TaskRequest selects an agent task.
+type TaskRequest struct {
+	ID string `path:"id" json:"-"`
+}
+
+// SessionSummary is one agent session.
+type SessionSummary struct {
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SessionHistoryRequest selects the recent messages of a session.
+type SessionHistoryRequest struct {
+	Key   string `path:"key" json:"-"`
+	Limit int    `query:"limit" json:"-"` // default 20
+}
+
+// SessionHistory is the recent history of a session.
+type SessionHistory struct {
+	Key      string            `json:"key"`
+	Messages []session.Message `json:"messages"`
+}
+
+// SessionRequest selects the runs of one agent session.
+type SessionRequest struct {
+	Session string `query:"session" json:"-"`
+}
+
+// ProcessRequest selects a background process.
+type ProcessRequest struct {
+	ID string `path:"id" json:"-"`
+}
+
+// ApprovalGrantsRequest lists approval grants.
+type ApprovalGrantsRequest struct {
+	All bool `query:"all" json:"-"` // include revoked and expired grants
+}
+
+// ApprovalGrantRequest selects an approval grant.
+type ApprovalGrantRequest struct {
+	ID string `path:"id" json:"-"`
+}
+
+// GrantRevoked acknowledges a revoked grant.
+type GrantRevoked struct {
+	Status  string `json:"status"`
+	GrantID string `json:"grant_id"`
+}
+
+// ApprovalRequest selects an approval.
+type ApprovalRequest struct {
+	ID string `path:"id" json:"-"`
+}
+
+// ApprovalDecision answers a pending approval.
+type ApprovalDecision struct {
+	ID       string `path:"id" json:"-"`
+	Approved bool   `json:"approved"`
+	// Remember ("session", "1h", "always") and Pattern store the approval
+	// as a grant.
+	Remember string `json:"remember,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+}
+
+// ApprovalSent acknowledges a decision handed to the agent.
+type ApprovalSent struct {
+	Status     string `json:"status"`
+	ApprovalID string `json:"approval_id"`
+}
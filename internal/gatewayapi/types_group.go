@@ -0,0 +1,219 @@
+package gatewayapi
+
+import (
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// GroupStatus is this agent's group membership.
+type GroupStatus struct {
+	Active      bool   `json:"active"`
+	GroupName   string `json:"group_name"`
+	AgentID     string `json:"agent_id"`
+	MemberCount int    `json:"member_count"`
+	LFSProxyURL string `json:"lfs_proxy_url"`
+	LFSHealthy  bool   `json:"lfs_healthy"`
+}
+
+// GroupMember is a roster entry. Capabilities and Channels are JSON arrays
+// encoded as strings.
+type GroupMember struct {
+	AgentID      string     `json:"agent_id"`
+	AgentName    string     `json:"agent_name"`
+	SoulSummary  string     `json:"soul_summary"`
+	Capabilities string     `json:"capabilities"`
+	Channels     string     `json:"channels"`
+	Model        string     `json:"model"`
+	Role         string     `json:"role,omitempty"`
+	Status       string     `json:"status"`
+	LastSeen     time.Time  `json:"last_seen"`
+	LeftAt       *time.Time `json:"left_at,omitempty"`
+}
+
+// JoinGroupRequest joins a group, leaving the current one first.
+type JoinGroupRequest struct {
+	GroupName    string `json:"group_name"`
+	LFSProxyURL  string `json:"lfs_proxy_url"`
+	KafkaBrokers string `json:"kafka_brokers"`
+	AgentID      string `json:"agent_id"`
+}
+
+// GroupConfig is the effective group configuration; APIKey is masked.
+type GroupConfig struct {
+	Enabled        bool   `json:"enabled"`
+	GroupName      string `json:"group_name"`
+	LFSProxyURL    string `json:"lfs_proxy_url"`
+	APIKey         string `json:"api_key"`
+	KafkaBrokers   string `json:"kafka_brokers"`
+	ConsumerGroup  string `json:"consumer_group"`
+	AgentID        string `json:"agent_id"`
+	PollIntervalMs int    `json:"poll_interval_ms"`
+}
+
+// GroupConfigUpdate maps group config keys (lfs_proxy_url, api_key,
+// kafka_brokers, consumer_group, agent_id, poll_interval_ms) to new values.
+type GroupConfigUpdate map[string]string
+
+// GroupConfigSaved acknowledges a config update.
+type GroupConfigSaved struct {
+	Status         string `json:"status"`
+	RequiresRejoin bool   `json:"requires_rejoin"`
+}
+
+// GroupTaskRequest submits a task to the group.
+type GroupTaskRequest struct {
+	Description string `json:"description"`
+	Content     string `json:"content"`
+}
+
+// GroupTasksRequest filters group tasks.
+type GroupTasksRequest struct {
+	Direction string `query:"direction" json:"-"` // incoming or outgoing
+	Status    string `query:"status" json:"-"`
+	Limit     int    `query:"limit" json:"-"` // default 50
+	Offset    int    `query:"offset" json:"-"`
+}
+
+// GroupTracesRequest filters group traces.
+type GroupTracesRequest struct {
+	AgentID string `query:"agent_id" json:"-"`
+	Limit   int    `query:"limit" json:"-"` // default 50
+	Offset  int    `query:"offset" json:"-"`
+}
+
+// GroupMemoryRequest filters shared memory items.
+type GroupMemoryRequest struct {
+	AuthorID string `query:"author_id" json:"-"`
+	Limit    int    `query:"limit" json:"-"` // default 50
+	Offset   int    `query:"offset" json:"-"`
+}
+
+// ShareMemoryRequest shares a memory item with the group.
+type ShareMemoryRequest struct {
+	Title       string   `json:"title"`
+	ContentType string   `json:"content_type"` // default text/plain
+	Content     string   `json:"content"`
+	Tags        []string `json:"tags"`
+}
+
+// GroupSkillsRequest lists the skill channels of a group.
+type GroupSkillsRequest struct {
+	GroupName string `query:"group_name" json:"-"` // default: the joined group
+}
+
+// RegisterSkillRequest registers a skill channel.
+type RegisterSkillRequest struct {
+	SkillName string `json:"skill_name"`
+}
+
+// SkillRegistered acknowledges a registered skill.
+type SkillRegistered struct {
+	Status string `json:"status"`
+	Skill  string `json:"skill"`
+}
+
+// SkillTaskRequest submits a task to a skill channel.
+type SkillTaskRequest struct {
+	SkillName   string `json:"skill_name"`
+	Description string `json:"description"`
+	Content     string `json:"content"`
+}
+
+// MembershipHistoryRequest filters join/leave history.
+type MembershipHistoryRequest struct {
+	AgentID   string `query:"agent_id" json:"-"`
+	GroupName string `query:"group_name" json:"-"`
+	Limit     int    `query:"limit" json:"-"` // default 50
+	Offset    int    `query:"offset" json:"-"`
+}
+
+// RejoinRequest reactivates a previous member.
+type RejoinRequest struct {
+	AgentID   string `json:"agent_id"`
+	GroupName string `json:"group_name"`
+}
+
+// Rejoined reports a reactivated member and its last membership config.
+type Rejoined struct {
+	Status         string                                `json:"status"`
+	AgentID        string                                `json:"agent_id"`
+	RestoredConfig timeline.GroupMembershipHistoryRecord `json:"restored_config"`
+}
+
+// AuditRequest filters the unified audit log.
+type AuditRequest struct {
+	Source    string `query:"source" json:"-"`
+	EventType string `query:"event_type" json:"-"`
+	AgentID   string `query:"agent_id" json:"-"`
+	Limit     int    `query:"limit" json:"-"` // default 50
+	Offset    int    `query:"offset" json:"-"`
+}
+
+// GroupTopics is the topic registry enriched with traffic stats and health.
+type GroupTopics struct {
+	Topics        []GroupTopic       `json:"topics"`
+	SkillTopics   []GroupTopic       `json:"skill_topics"`
+	XPLeaderboard []timeline.AgentXP `json:"xp_leaderboard"`
+}
+
+// GroupTopic is one group topic.
+type GroupTopic struct {
+	Name        string                `json:"name"`
+	Category    string                `json:"category"`
+	Description string                `json:"description"`
+	Consumers   []string              `json:"consumers"`
+	Stats       *timeline.TopicStat   `json:"stats,omitempty"`
+	Health      *timeline.TopicHealth `json:"health,omitempty"`
+}
+
+// TopicMessagesRequest selects the recent messages of a topic.
+type TopicMessagesRequest struct {
+	Topic string `query:"topic" json:"-"`
+	Limit int    `query:"limit" json:"-"` // default 50
+}
+
+// TopicMessages are the recent messages of a topic.
+type TopicMessages struct {
+	Messages []timeline.TopicMessageLogRecord `json:"messages"`
+}
+
+// TopicFlow is the sender → topic traffic graph.
+type TopicFlow struct {
+	Edges []timeline.TopicFlowEdge `json:"edges"`
+}
+
+// TopicHealthList is the health of every topic.
+type TopicHealthList struct {
+	Health []timeline.TopicHealth `json:"health"`
+}
+
+// EnsureTopicRequest creates a topic if it does not exist.
+type EnsureTopicRequest struct {
+	TopicName string `json:"topic_name"`
+}
+
+// TopicEnsured acknowledges an ensured topic.
+type TopicEnsured struct {
+	OK    bool   `json:"ok"`
+	Topic string `json:"topic"`
+}
+
+// XPLeaderboard ranks agents by experience points.
+type XPLeaderboard struct {
+	Leaderboard []timeline.AgentXP `json:"leaderboard"`
+}
+
+// TopicDensityRequest selects hourly message counts of a topic.
+type TopicDensityRequest struct {
+	Topic string `query:"topic" json:"-"`
+	Hours int    `query:"hours" json:"-"` // default 48
+}
+
+// TopicDensity is the hourly message count and envelope mix of a topic.
+type TopicDensity struct {
+	Topic         string                        `json:"topic"`
+	Hours         int                           `json:"hours"`
+	Buckets       []timeline.TopicDensityBucket `json:"buckets"`
+	EnvelopeTypes map[string]int                `json:"envelope_types"`
+}
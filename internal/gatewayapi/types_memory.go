@@ -0,0 +1,207 @@
+package gatewayapi
+
+import "time"
+
+// MemoryStatus summarizes every memory layer.
+type MemoryStatus struct {
+	Layers        []MemoryLayer        `json:"layers"`
+	WorkingMemory WorkingMemory        `json:"working_memory"`
+	Observer      ObserverStatus       `json:"observer"`
+	Observations  []Observation        `json:"observations"`
+	ER1           ER1Status            `json:"er1"`
+	Expertise     []Expertise          `json:"expertise"`
+	Totals        MemoryTotals         `json:"totals"`
+	Config        MemoryStatusSettings `json:"config"`
+}
+
+// MemoryLayer is one source of memory chunks.
+type MemoryLayer struct {
+	Name         string `json:"name"`
+	SourcePrefix string `json:"source_prefix"`
+	Description  string `json:"description"`
+	TTLDays      int    `json:"ttl_days"`
+	ChunkCount   int    `json:"chunk_count"`
+	Color        string `json:"color"`
+}
+
+// WorkingMemory summarizes the working memory store.
+type WorkingMemory struct {
+	Entries int    `json:"entries"`
+	Preview string `json:"preview"`
+}
+
+// ObserverStatus is the state of the observational memory.
+type ObserverStatus struct {
+	Enabled          bool       `json:"enabled"`
+	ObservationCount int        `json:"observation_count"`
+	QueueDepth       int        `json:"queue_depth"`
+	LastObservation  *time.Time `json:"last_observation"`
+}
+
+// Observation is a compressed observation.
+type Observation struct {
+	ID       string `json:"id"`
+	Content  string `json:"content"`
+	Priority string `json:"priority"`
+	Date     string `json:"date"`
+}
+
+// ER1Status is the state of the ER1 personal memory sync.
+type ER1Status struct {
+	Connected   bool       `json:"connected"`
+	LastSync    *time.Time `json:"last_sync"`
+	SyncedCount int        `json:"synced_count"`
+	URL         string     `json:"url"`
+}
+
+// Expertise is the tracked score of a skill.
+type Expertise struct {
+	Skill string  `json:"skill"`
+	Score float64 `json:"score"`
+	Trend string  `json:"trend"`
+	Uses  int     `json:"uses"`
+}
+
+// MemoryTotals counts memory chunks.
+type MemoryTotals struct {
+	TotalChunks int        `json:"total_chunks"`
+	MaxChunks   int        `json:"max_chunks"`
+	Oldest      *time.Time `json:"oldest,omitempty"`
+	Newest      *time.Time `json:"newest,omitempty"`
+}
+
+// MemoryStatusSettings is the effective memory configuration.
+type MemoryStatusSettings struct {
+	ObserverEnabled    bool   `json:"observer_enabled"`
+	ObserverThreshold  int    `json:"observer_threshold"`
+	ObserverMaxObs     int    `json:"observer_max_obs"`
+	ER1URL             string `json:"er1_url"`
+	ER1SyncIntervalSec int    `json:"er1_sync_interval_sec"`
+	MaxChunks          int    `json:"max_chunks"`
+}
+
+// MemoryMetrics are memory and knowledge quality metrics.
+type MemoryMetrics struct {
+	Status    string            `json:"status"`
+	Memory    *MemoryVolume     `json:"memory,omitempty"`
+	Knowledge *KnowledgeMetrics `json:"knowledge,omitempty"`
+	SLO       MemorySLO         `json:"slo"`
+}
+
+// MemoryVolume counts stored and embedded chunks.
+type MemoryVolume struct {
+	ChunksTotal     int64   `json:"chunksTotal"`
+	ChunksEmbedded  int64   `json:"chunksEmbedded"`
+	OverflowEvents  int     `json:"overflowEvents"`
+	OverflowPer1000 float64 `json:"overflowPer1000"`
+}
+
+// KnowledgeMetrics counts knowledge facts and decisions.
+type KnowledgeMetrics struct {
+	FactsAccepted int                `json:"factsAccepted"`
+	FactsStale    int                `json:"factsStale"`
+	FactsConflict int                `json:"factsConflict"`
+	FactsLatest   int                `json:"factsLatest"`
+	Decisions     KnowledgeDecisions `json:"decisions"`
+}
+
+// KnowledgeDecisions counts decided knowledge proposals.
+type KnowledgeDecisions struct {
+	Approved int `json:"approved"`
+	Rejected int `json:"rejected"`
+	Expired  int `json:"expired"`
+}
+
+// MemorySLO are proxy quality ratios.
+type MemorySLO struct {
+	PrecisionProxy float64 `json:"precisionProxy"`
+	RecallProxy    float64 `json:"recallProxy"`
+	ConflictRate   float64 `json:"conflictRate"`
+}
+
+// MemoryResetRequest deletes a memory layer: soul, conversation, tool,
+// group, er1, observation, working_memory or all.
+type MemoryResetRequest struct {
+	Layer string `json:"layer"`
+}
+
+// MemoryDeleted reports deleted chunks.
+type MemoryDeleted struct {
+	Status  string `json:"status"`
+	Deleted int    `json:"deleted"`
+}
+
+// MemoryConfigUpdate maps memory config keys to values; each is stored as
+// the setting memory_<key>.
+type MemoryConfigUpdate map[string]any
+
+// EmbeddingStatus describes the embedding configuration and runtime.
+type EmbeddingStatus struct {
+	Status    string           `json:"status"`
+	Embedding EmbeddingConfig  `json:"embedding"`
+	Runtime   EmbeddingHealth  `json:"runtime"`
+	Index     EmbeddingIndex   `json:"index"`
+	Install   EmbeddingInstall `json:"install"`
+}
+
+// EmbeddingConfig is the effective memory.embedding configuration.
+type EmbeddingConfig struct {
+	Enabled         bool   `json:"enabled"`
+	Provider        string `json:"provider"`
+	Model           string `json:"model"`
+	Dimension       int    `json:"dimension"`
+	Normalize       bool   `json:"normalize"`
+	CacheDir        string `json:"cacheDir"`
+	AutoDownload    bool   `json:"autoDownload"`
+	Endpoint        string `json:"endpoint"`
+	StartupTimeoutS int    `json:"startupTimeoutS"`
+	Fingerprint     string `json:"fingerprint"`
+}
+
+// EmbeddingHealth is the result of probing the embedding runtime.
+type EmbeddingHealth struct {
+	Ready      bool      `json:"ready"`
+	Status     string    `json:"status"`
+	Detail     string    `json:"detail"`
+	CheckedAt  time.Time `json:"checkedAt"`
+	HTTPStatus int       `json:"httpStatus,omitempty"`
+}
+
+// EmbeddingIndex counts embedded chunks.
+type EmbeddingIndex struct {
+	EmbeddedChunks int64 `json:"embeddedChunks"`
+}
+
+// EmbeddingInstall is a pending model install request.
+type EmbeddingInstall struct {
+	Pending            bool   `json:"pending"`
+	RequestedAt        string `json:"requestedAt"`
+	RequestedModel     string `json:"requestedModel"`
+	CachePathAvailable bool   `json:"cachePathAvailable"`
+}
+
+// EmbeddingInstallRequest requests a model install; the configured model
+// is used when Model is empty.
+type EmbeddingInstallRequest struct {
+	Model string `json:"model"`
+}
+
+// EmbeddingInstallRequested acknowledges an install request.
+type EmbeddingInstallRequested struct {
+	Status string `json:"status"`
+	Action string `json:"action"`
+	Model  string `json:"model"`
+}
+
+// EmbeddingReindexRequest wipes all chunks so they are re-embedded.
+type EmbeddingReindexRequest struct {
+	ConfirmWipe bool   `json:"confirmWipe"`
+	Reason      string `json:"reason"`
+}
+
+// EmbeddingReindexed reports wiped chunks.
+type EmbeddingReindexed struct {
+	Status      string `json:"status"`
+	WipedChunks int64  `json:"wipedChunks"`
+	Reason      string `json:"reason"`
+}
@@ -0,0 +1,123 @@
+package gatewayapi
+
+import (
+	"encoding/json"
+
+	"github.com/KafClaw/KafClaw/internal/agent"
+)
+
+// ModelList lists the agents served as OpenAI models.
+type ModelList struct {
+	Object string  `json:"object"` // "list"
+	Data   []Model `json:"data"`
+}
+
+// Model is one agent.
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"` // "model"
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// ChatCompletionRequest is an OpenAI chat completions request. The last
+// message must have role user; earlier messages seed a new session.
+type ChatCompletionRequest struct {
+	// Session selects one of the caller's agent sessions; it takes
+	// precedence over User.
+	Session       string             `header:"X-KafClaw-Session" json:"-"`
+	Model         string             `json:"model"`
+	Messages      []ChatMessage      `json:"messages"`
+	Stream        bool               `json:"stream"`
+	StreamOptions *ChatStreamOptions `json:"stream_options,omitempty"`
+	User          string             `json:"user,omitempty"`
+}
+
+// ChatMessage is a request message; Content is a string or an array of
+// content parts.
+type ChatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// ChatStreamOptions tunes a streamed completion.
+type ChatStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// ChatCompletion is the reply of a non-streamed request.
+type ChatCompletion struct {
+	ID      string           `json:"id"`
+	Object  string           `json:"object"` // "chat.completion"
+	Created int64            `json:"created"`
+	Model   string           `json:"model"`
+	Choices []ChatChoice     `json:"choices"`
+	Usage   Usage            `json:"usage"`
+	KafClaw KafClawExtension `json:"kafclaw"`
+}
+
+// ChatChoice is the assistant reply.
+type ChatChoice struct {
+	Index        int              `json:"index"`
+	Message      ChatReplyMessage `json:"message"`
+	FinishReason string           `json:"finish_reason"`
+}
+
+// ChatReplyMessage is an assistant message.
+type ChatReplyMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatCompletionChunk is one server-sent event of a streamed request.
+// Chunks without choices carry a turn event or the usage.
+type ChatCompletionChunk struct {
+	ID      string            `json:"id"`
+	Object  string            `json:"object"` // "chat.completion.chunk"
+	Created int64             `json:"created"`
+	Model   string            `json:"model"`
+	Choices []ChunkChoice     `json:"choices"`
+	Usage   *Usage            `json:"usage,omitempty"`
+	KafClaw *KafClawExtension `json:"kafclaw,omitempty"`
+}
+
+// ChunkChoice is a streamed delta.
+type ChunkChoice struct {
+	Index        int     `json:"index"`
+	Delta        Delta   `json:"delta"`
+	FinishReason *string `json:"finish_reason"`
+}
+
+// Delta is the increment of the assistant message.
+type Delta struct {
+	Role    string  `json:"role,omitempty"`
+	Content *string `json:"content,omitempty"`
+}
+
+// Usage is the token usage summed over every LLM call of the turn.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// KafClawExtension is the non-standard "kafclaw" object of responses and
+// chunks; OpenAI clients ignore it.
+type KafClawExtension struct {
+	TraceID   string            `json:"trace_id,omitempty"`
+	Session   string            `json:"session,omitempty"`
+	Approvals []agent.TurnEvent `json:"approvals,omitempty"`
+	Event     *agent.TurnEvent  `json:"event,omitempty"`
+}
+
+// OpenAIErrorResponse is the error body of the OpenAI-compatible API.
+type OpenAIErrorResponse struct {
+	Error OpenAIError `json:"error"`
+}
+
+// OpenAIError describes a failed OpenAI-compatible request.
+type OpenAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
@@ -0,0 +1,103 @@
+package gatewayapi
+
+// RepoRequest selects a repository. Repo is "identity" for the agent's
+// system repo; anything else selects the work repo, as in every repo
+// operation.
+type RepoRequest struct {
+	Repo string `query:"repo" json:"-"`
+}
+
+// RepoPathRequest selects a path in a repository.
+type RepoPathRequest struct {
+	Repo string `query:"repo" json:"-"`
+	Path string `query:"path" json:"-"`
+}
+
+// RepoItem is a file or directory of a repository tree.
+type RepoItem struct {
+	Path  string `json:"path"`
+	Name  string `json:"name"`
+	Type  string `json:"type"` // file or dir
+	Depth int    `json:"depth"`
+	Size  int64  `json:"size"`
+}
+
+// RepoFile is the content of a repository file, truncated at 200 kB.
+type RepoFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// RepoStatus is `git status -sb` and the remotes; Error is set when the
+// repository cannot be read.
+type RepoStatus struct {
+	Status string `json:"status"`
+	Remote string `json:"remote,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RepoSearch lists git repositories under the search root.
+type RepoSearch struct {
+	Root  string   `json:"root"`
+	Repos []string `json:"repos"`
+}
+
+// GitHubAuth is the `gh auth status` of the repository.
+type GitHubAuth struct {
+	Status string `json:"status"` // ok or not_authenticated
+	Detail string `json:"detail"`
+}
+
+// RepoBranches lists local branches.
+type RepoBranches struct {
+	Branches []string `json:"branches"`
+}
+
+// RepoCheckoutRequest checks out a branch.
+type RepoCheckoutRequest struct {
+	Repo   string `query:"repo" json:"-"`
+	Branch string `json:"branch"`
+}
+
+// RepoLogRequest lists recent commits.
+type RepoLogRequest struct {
+	Repo  string `query:"repo" json:"-"`
+	Limit int    `query:"limit" json:"-"` // 1-500, default 20
+}
+
+// RepoLog lists commits as `git log --oneline` lines.
+type RepoLog struct {
+	Commits []string `json:"commits"`
+}
+
+// RepoDiff is a unified diff.
+type RepoDiff struct {
+	Diff string `json:"diff"`
+}
+
+// RepoCommitRequest commits all changes.
+type RepoCommitRequest struct {
+	Repo    string `query:"repo" json:"-"`
+	Message string `json:"message"`
+}
+
+// RepoResult is the output of a git or gh command.
+type RepoResult struct {
+	Result string `json:"result"`
+}
+
+// RepoInitRequest initializes the repository and sets its origin.
+type RepoInitRequest struct {
+	Repo      string `query:"repo" json:"-"`
+	RemoteURL string `json:"remote_url"`
+}
+
+// PullRequestRequest opens a GitHub pull request.
+type PullRequestRequest struct {
+	Repo  string `query:"repo" json:"-"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Base  string `json:"base"`
+	Head  string `json:"head"`
+	Draft bool   `json:"draft"`
+}
@@ -0,0 +1,232 @@
+package gatewayapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Validator checks responses against an OpenAPI document produced by Spec.
+// It understands the subset of JSON Schema that Spec emits.
+type Validator struct {
+	doc map[string]any
+	ops map[string]map[string]any
+}
+
+// NewValidator parses an OpenAPI document.
+func NewValidator(spec []byte) (*Validator, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+	v := &Validator{doc: doc, ops: map[string]map[string]any{}}
+	paths, _ := doc["paths"].(map[string]any)
+	for _, item := range paths {
+		methods, _ := item.(map[string]any)
+		for _, raw := range methods {
+			op, _ := raw.(map[string]any)
+			if id, _ := op["operationId"].(string); id != "" {
+				v.ops[id] = op
+			}
+		}
+	}
+	return v, nil
+}
+
+// ValidateResponse checks that an operation may answer with status, content
+// type and body.
+func (v *Validator) ValidateResponse(operationID string, status int, contentType string, body []byte) error {
+	op, ok := v.ops[operationID]
+	if !ok {
+		return fmt.Errorf("unknown operation %s", operationID)
+	}
+	responses, _ := op["responses"].(map[string]any)
+	resp, ok := responses[strconv.Itoa(status)].(map[string]any)
+	if !ok {
+		if status < 400 {
+			return fmt.Errorf("%s: undocumented status %d", operationID, status)
+		}
+		resp, _ = responses["default"].(map[string]any)
+	}
+	content, _ := resp["content"].(map[string]any)
+	if len(content) == 0 {
+		if len(strings.TrimSpace(string(body))) > 0 {
+			return fmt.Errorf("%s %d: undocumented body", operationID, status)
+		}
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	media, ok := content[mediaType].(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s %d: undocumented content type %q", operationID, status, contentType)
+	}
+	schema, _ := media["schema"].(map[string]any)
+	var value any
+	if mediaType == "application/json" {
+		if err := json.Unmarshal(body, &value); err != nil {
+			return fmt.Errorf("%s %d: %v", operationID, status, err)
+		}
+	} else {
+		value = string(body)
+	}
+	if err := v.validate(schema, value, "$"); err != nil {
+		return fmt.Errorf("%s %d: %w", operationID, status, err)
+	}
+	return nil
+}
+
+// ValidateStreamEvent checks one server-sent event payload of a streaming
+// operation.
+func (v *Validator) ValidateStreamEvent(operationID string, data []byte) error {
+	op, ok := v.ops[operationID]
+	if !ok {
+		return fmt.Errorf("unknown operation %s", operationID)
+	}
+	responses, _ := op["responses"].(map[string]any)
+	resp, _ := responses["200"].(map[string]any)
+	content, _ := resp["content"].(map[string]any)
+	media, _ := content["text/event-stream"].(map[string]any)
+	schema, ok := media["x-kafclaw-stream-event"].(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s does not stream", operationID)
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("%s event: %v", operationID, err)
+	}
+	if err := v.validate(schema, value, "$"); err != nil {
+		return fmt.Errorf("%s event: %w", operationID, err)
+	}
+	return nil
+}
+
+func (v *Validator) validate(schema map[string]any, value any, at string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			return err
+		}
+		return v.validate(target, value, at)
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		var errs []string
+		for _, alt := range anyOf {
+			s, _ := alt.(map[string]any)
+			err := v.validate(s, value, at)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return fmt.Errorf("%s: no alternative matches (%s)", at, strings.Join(errs, "; "))
+	}
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		got := jsonType(value)
+		matched := false
+		for _, t := range types {
+			if t == got || (t == "number" && got == "integer") {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: %s, want %s", at, got, strings.Join(types, " or "))
+		}
+	}
+	switch val := value.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		if req, ok := schema["required"].([]any); ok {
+			for _, name := range req {
+				if _, present := val[name.(string)]; !present {
+					return fmt.Errorf("%s: missing %s", at, name)
+				}
+			}
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if ps, ok := props[k].(map[string]any); ok {
+				if err := v.validate(ps, val[k], at+"."+k); err != nil {
+					return err
+				}
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra && props != nil {
+					return fmt.Errorf("%s: unexpected property %s", at, k)
+				}
+			case map[string]any:
+				if err := v.validate(extra, val[k], at+"."+k); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range val {
+				if err := v.validate(items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (v *Validator) resolve(ref string) (map[string]any, error) {
+	const prefix = "#/components/schemas/"
+	if !strings.HasPrefix(ref, prefix) {
+		return nil, fmt.Errorf("unsupported $ref %s", ref)
+	}
+	components, _ := v.doc["components"].(map[string]any)
+	schemas, _ := components["schemas"].(map[string]any)
+	s, ok := schemas[strings.TrimPrefix(ref, prefix)].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unresolved $ref %s", ref)
+	}
+	return s, nil
+}
+
+func schemaTypes(t any) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		out := make([]string, 0, len(t))
+		for _, s := range t {
+			out = append(out, s.(string))
+		}
+		return out
+	}
+	return nil
+}
+
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
@@ -0,0 +1,113 @@
+// Package gatewayclient is a Go client for the gateway HTTP API. The
+// operation methods in client_gen.go are generated from the gatewayapi
+// route table; run go generate after changing it.
+package gatewayclient
+
+//go:generate go run ./gen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+)
+
+// Client calls a running gateway.
+type Client struct {
+	// APIURL is the gateway API server (gateway.port), e.g. http://host:18790.
+	APIURL string
+	// DashboardURL is the dashboard server (gateway.dashboardPort).
+	DashboardURL string
+	// Token is sent as a Bearer token when the gateway requires auth.
+	Token string
+
+	HTTPClient *http.Client
+}
+
+// New creates a client for the gateway at the given URLs.
+func New(apiURL, dashboardURL, token string) *Client {
+	return &Client{
+		APIURL:       strings.TrimRight(apiURL, "/"),
+		DashboardURL: strings.TrimRight(dashboardURL, "/"),
+		Token:        strings.TrimSpace(token),
+		HTTPClient:   &http.Client{},
+	}
+}
+
+// Error is a non-2xx gateway response.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("gateway status: %d %s", e.StatusCode, e.Message)
+}
+
+// call runs an operation. req is the route's request value or nil; out
+// points at the response value or is nil. A string out receives the raw
+// body; an empty body leaves out untouched.
+func (c *Client) call(ctx context.Context, operationID string, req, out any) error {
+	route := gatewayapi.MustLookup(operationID)
+	enc, err := gatewayapi.EncodeRequest(route, req)
+	if err != nil {
+		return err
+	}
+	base := c.DashboardURL
+	if route.Server == gatewayapi.ServerAPI {
+		base = c.APIURL
+	}
+	target := base + enc.Path
+	if len(enc.Query) > 0 {
+		target += "?" + enc.Query.Encode()
+	}
+	var body io.Reader
+	if enc.Body != nil {
+		body = bytes.NewReader(enc.Body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, route.Method, target, body)
+	if err != nil {
+		return err
+	}
+	for k, v := range enc.Header {
+		httpReq.Header[k] = v
+	}
+	if enc.Body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(raw))}
+	}
+	switch out := out.(type) {
+	case nil:
+		return nil
+	case *string:
+		*out = string(raw)
+		return nil
+	}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
@@ -0,0 +1,855 @@
+// Code generated by go run ./gen; DO NOT EDIT.
+
+package gatewayclient
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+	"github.com/KafClaw/KafClaw/internal/group"
+	"github.com/KafClaw/KafClaw/internal/orchestrator"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/KafClaw/KafClaw/internal/tools"
+)
+
+// Chat calls POST /chat: send a message to the agent and wait for the reply.
+func (c *Client) Chat(ctx context.Context, req gatewayapi.ChatRequest) (string, error) {
+	var out string
+	err := c.call(ctx, "chat", req, &out)
+	return out, err
+}
+
+// ListModels calls GET /v1/models: list the agents served as OpenAI models.
+func (c *Client) ListModels(ctx context.Context) (*gatewayapi.ModelList, error) {
+	var out gatewayapi.ModelList
+	if err := c.call(ctx, "listModels", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateChatCompletion calls POST /v1/chat/completions: run an agent turn as an OpenAI chat completion.
+func (c *Client) CreateChatCompletion(ctx context.Context, req gatewayapi.ChatCompletionRequest) (*gatewayapi.ChatCompletion, error) {
+	var out gatewayapi.ChatCompletion
+	if err := c.call(ctx, "createChatCompletion", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOpenAPI calls GET /api/openapi.json: this OpenAPI document.
+func (c *Client) GetOpenAPI(ctx context.Context) (json.RawMessage, error) {
+	var out json.RawMessage
+	err := c.call(ctx, "getOpenAPI", nil, &out)
+	return out, err
+}
+
+// GetStatus calls GET /api/v1/status: health check.
+func (c *Client) GetStatus(ctx context.Context) (*gatewayapi.GatewayStatus, error) {
+	var out gatewayapi.GatewayStatus
+	if err := c.call(ctx, "getStatus", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// VerifyAuth calls POST /api/v1/auth/verify: check the request's credentials.
+func (c *Client) VerifyAuth(ctx context.Context) (*gatewayapi.AuthVerification, error) {
+	var out gatewayapi.AuthVerification
+	if err := c.call(ctx, "verifyAuth", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WhoAmI calls GET /api/v1/auth/whoami: describe the caller.
+func (c *Client) WhoAmI(ctx context.Context) (*gatewayapi.WhoAmI, error) {
+	var out gatewayapi.WhoAmI
+	if err := c.call(ctx, "whoAmI", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SlackInbound calls POST /api/v1/channels/slack/inbound: deliver a message from the Slack bridge.
+func (c *Client) SlackInbound(ctx context.Context, req gatewayapi.ChannelInboundRequest) (*gatewayapi.OK, error) {
+	var out gatewayapi.OK
+	if err := c.call(ctx, "slackInbound", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MsteamsInbound calls POST /api/v1/channels/msteams/inbound: deliver a message from the Teams bridge.
+func (c *Client) MsteamsInbound(ctx context.Context, req gatewayapi.ChannelInboundRequest) (*gatewayapi.OK, error) {
+	var out gatewayapi.OK
+	if err := c.call(ctx, "msteamsInbound", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOrchestratorStatus calls GET /api/v1/orchestrator/status: orchestrator role of this agent.
+func (c *Client) GetOrchestratorStatus(ctx context.Context) (*gatewayapi.OrchestratorStatus, error) {
+	var out gatewayapi.OrchestratorStatus
+	if err := c.call(ctx, "getOrchestratorStatus", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOrchestratorHierarchy calls GET /api/v1/orchestrator/hierarchy: agent hierarchy.
+func (c *Client) GetOrchestratorHierarchy(ctx context.Context) ([]orchestrator.AgentNode, error) {
+	var out []orchestrator.AgentNode
+	err := c.call(ctx, "getOrchestratorHierarchy", nil, &out)
+	return out, err
+}
+
+// ListOrchestratorZones calls GET /api/v1/orchestrator/zones: zones with member counts.
+func (c *Client) ListOrchestratorZones(ctx context.Context) ([]orchestrator.ZoneSummary, error) {
+	var out []orchestrator.ZoneSummary
+	err := c.call(ctx, "listOrchestratorZones", nil, &out)
+	return out, err
+}
+
+// ListOrchestratorAgents calls GET /api/v1/orchestrator/agents: known agents.
+func (c *Client) ListOrchestratorAgents(ctx context.Context) ([]orchestrator.AgentNode, error) {
+	var out []orchestrator.AgentNode
+	err := c.call(ctx, "listOrchestratorAgents", nil, &out)
+	return out, err
+}
+
+// DispatchOrchestratorTask calls POST /api/v1/orchestrator/dispatch: dispatch a task into a zone.
+func (c *Client) DispatchOrchestratorTask(ctx context.Context, req gatewayapi.DispatchRequest) (*gatewayapi.TaskSubmitted, error) {
+	var out gatewayapi.TaskSubmitted
+	if err := c.call(ctx, "dispatchOrchestratorTask", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTimeline calls GET /api/v1/timeline: timeline events, newest first.
+func (c *Client) ListTimeline(ctx context.Context, req gatewayapi.TimelineRequest) ([]timeline.TimelineEvent, error) {
+	var out []timeline.TimelineEvent
+	err := c.call(ctx, "listTimeline", req, &out)
+	return out, err
+}
+
+// GetTrace calls GET /api/v1/trace/{id}: spans, task and policy decisions of a trace.
+func (c *Client) GetTrace(ctx context.Context, req gatewayapi.TraceRequest) (*gatewayapi.Trace, error) {
+	var out gatewayapi.Trace
+	if err := c.call(ctx, "getTrace", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListPolicyDecisions calls GET /api/v1/policy-decisions: policy decisions of a trace.
+func (c *Client) ListPolicyDecisions(ctx context.Context, req gatewayapi.PolicyDecisionsRequest) ([]timeline.PolicyDecisionRecord, error) {
+	var out []timeline.PolicyDecisionRecord
+	err := c.call(ctx, "listPolicyDecisions", req, &out)
+	return out, err
+}
+
+// GetTraceGraph calls GET /api/v1/trace-graph/{id}: call graph of a trace.
+func (c *Client) GetTraceGraph(ctx context.Context, req gatewayapi.TraceRequest) (*timeline.TraceGraph, error) {
+	var out timeline.TraceGraph
+	if err := c.call(ctx, "getTraceGraph", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGroupStatus calls GET /api/v1/group/status: group membership of this agent.
+func (c *Client) GetGroupStatus(ctx context.Context) (*gatewayapi.GroupStatus, error) {
+	var out gatewayapi.GroupStatus
+	if err := c.call(ctx, "getGroupStatus", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListGroupMembers calls GET /api/v1/group/members: group roster.
+func (c *Client) ListGroupMembers(ctx context.Context) ([]gatewayapi.GroupMember, error) {
+	var out []gatewayapi.GroupMember
+	err := c.call(ctx, "listGroupMembers", nil, &out)
+	return out, err
+}
+
+// JoinGroup calls POST /api/v1/group/join: join a group.
+func (c *Client) JoinGroup(ctx context.Context, req gatewayapi.JoinGroupRequest) (*gatewayapi.GroupStatus, error) {
+	var out gatewayapi.GroupStatus
+	if err := c.call(ctx, "joinGroup", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// LeaveGroup calls POST /api/v1/group/leave: leave the group.
+func (c *Client) LeaveGroup(ctx context.Context) (*gatewayapi.Ack, error) {
+	var out gatewayapi.Ack
+	if err := c.call(ctx, "leaveGroup", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGroupConfig calls GET /api/v1/group/config: effective group configuration.
+func (c *Client) GetGroupConfig(ctx context.Context) (*gatewayapi.GroupConfig, error) {
+	var out gatewayapi.GroupConfig
+	if err := c.call(ctx, "getGroupConfig", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateGroupConfig calls POST /api/v1/group/config: store group settings.
+func (c *Client) UpdateGroupConfig(ctx context.Context, req gatewayapi.GroupConfigUpdate) (*gatewayapi.GroupConfigSaved, error) {
+	var out gatewayapi.GroupConfigSaved
+	if err := c.call(ctx, "updateGroupConfig", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SubmitGroupTask calls POST /api/v1/group/tasks/submit: submit a task to the group.
+func (c *Client) SubmitGroupTask(ctx context.Context, req gatewayapi.GroupTaskRequest) (*gatewayapi.TaskSubmitted, error) {
+	var out gatewayapi.TaskSubmitted
+	if err := c.call(ctx, "submitGroupTask", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListGroupTasks calls GET /api/v1/group/tasks: group tasks.
+func (c *Client) ListGroupTasks(ctx context.Context, req gatewayapi.GroupTasksRequest) ([]timeline.GroupTaskRecord, error) {
+	var out []timeline.GroupTaskRecord
+	err := c.call(ctx, "listGroupTasks", req, &out)
+	return out, err
+}
+
+// ListGroupTraces calls GET /api/v1/group/traces: traces shared by group members.
+func (c *Client) ListGroupTraces(ctx context.Context, req gatewayapi.GroupTracesRequest) ([]timeline.GroupTrace, error) {
+	var out []timeline.GroupTrace
+	err := c.call(ctx, "listGroupTraces", req, &out)
+	return out, err
+}
+
+// ListGroupMemory calls GET /api/v1/group/memory: shared memory items.
+func (c *Client) ListGroupMemory(ctx context.Context, req gatewayapi.GroupMemoryRequest) ([]timeline.GroupMemoryItemRecord, error) {
+	var out []timeline.GroupMemoryItemRecord
+	err := c.call(ctx, "listGroupMemory", req, &out)
+	return out, err
+}
+
+// ShareGroupMemory calls POST /api/v1/group/memory: share a memory item.
+func (c *Client) ShareGroupMemory(ctx context.Context, req gatewayapi.ShareMemoryRequest) (*gatewayapi.Ack, error) {
+	var out gatewayapi.Ack
+	if err := c.call(ctx, "shareGroupMemory", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListGroupSkills calls GET /api/v1/group/skills: skill channels.
+func (c *Client) ListGroupSkills(ctx context.Context, req gatewayapi.GroupSkillsRequest) ([]timeline.GroupSkillChannelRecord, error) {
+	var out []timeline.GroupSkillChannelRecord
+	err := c.call(ctx, "listGroupSkills", req, &out)
+	return out, err
+}
+
+// RegisterGroupSkill calls POST /api/v1/group/skills: register a skill channel.
+func (c *Client) RegisterGroupSkill(ctx context.Context, req gatewayapi.RegisterSkillRequest) (*gatewayapi.SkillRegistered, error) {
+	var out gatewayapi.SkillRegistered
+	if err := c.call(ctx, "registerGroupSkill", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SubmitSkillTask calls POST /api/v1/group/skills/task: submit a task to a skill channel.
+func (c *Client) SubmitSkillTask(ctx context.Context, req gatewayapi.SkillTaskRequest) (*gatewayapi.TaskSubmitted, error) {
+	var out gatewayapi.TaskSubmitted
+	if err := c.call(ctx, "submitSkillTask", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// OnboardGroup calls POST /api/v1/group/onboard: request onboarding into the group.
+func (c *Client) OnboardGroup(ctx context.Context) (*gatewayapi.Ack, error) {
+	var out gatewayapi.Ack
+	if err := c.call(ctx, "onboardGroup", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListMembershipHistory calls GET /api/v1/group/membership/history: join and leave history.
+func (c *Client) ListMembershipHistory(ctx context.Context, req gatewayapi.MembershipHistoryRequest) ([]timeline.GroupMembershipHistoryRecord, error) {
+	var out []timeline.GroupMembershipHistoryRecord
+	err := c.call(ctx, "listMembershipHistory", req, &out)
+	return out, err
+}
+
+// ListPreviousGroupMembers calls GET /api/v1/group/members/previous: members that left.
+func (c *Client) ListPreviousGroupMembers(ctx context.Context) ([]timeline.GroupMemberRecord, error) {
+	var out []timeline.GroupMemberRecord
+	err := c.call(ctx, "listPreviousGroupMembers", nil, &out)
+	return out, err
+}
+
+// RejoinGroupMember calls POST /api/v1/group/rejoin: reactivate a previous member.
+func (c *Client) RejoinGroupMember(ctx context.Context, req gatewayapi.RejoinRequest) (*gatewayapi.Rejoined, error) {
+	var out gatewayapi.Rejoined
+	if err := c.call(ctx, "rejoinGroupMember", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGroupStats calls GET /api/v1/group/stats: group activity counters.
+func (c *Client) GetGroupStats(ctx context.Context) (*timeline.GroupStats, error) {
+	var out timeline.GroupStats
+	if err := c.call(ctx, "getGroupStats", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListGroupAudit calls GET /api/v1/group/audit: unified audit log.
+func (c *Client) ListGroupAudit(ctx context.Context, req gatewayapi.AuditRequest) ([]timeline.UnifiedAuditEntry, error) {
+	var out []timeline.UnifiedAuditEntry
+	err := c.call(ctx, "listGroupAudit", req, &out)
+	return out, err
+}
+
+// GetGroupManifest calls GET /api/v1/group/manifest: topic manifest of the group.
+func (c *Client) GetGroupManifest(ctx context.Context) (*group.TopicManifest, error) {
+	var out group.TopicManifest
+	if err := c.call(ctx, "getGroupManifest", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListGroupTopics calls GET /api/v1/group/topics: topics with stats, health and the XP leaderboard.
+func (c *Client) ListGroupTopics(ctx context.Context) (*gatewayapi.GroupTopics, error) {
+	var out gatewayapi.GroupTopics
+	if err := c.call(ctx, "listGroupTopics", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListGroupTopicMessages calls GET /api/v1/group/topics/messages: recent messages of a topic.
+func (c *Client) ListGroupTopicMessages(ctx context.Context, req gatewayapi.TopicMessagesRequest) (*gatewayapi.TopicMessages, error) {
+	var out gatewayapi.TopicMessages
+	if err := c.call(ctx, "listGroupTopicMessages", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGroupTopicFlow calls GET /api/v1/group/topics/flow: sender to topic traffic.
+func (c *Client) GetGroupTopicFlow(ctx context.Context) (*gatewayapi.TopicFlow, error) {
+	var out gatewayapi.TopicFlow
+	if err := c.call(ctx, "getGroupTopicFlow", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGroupTopicHealth calls GET /api/v1/group/topics/health: topic health.
+func (c *Client) GetGroupTopicHealth(ctx context.Context) (*gatewayapi.TopicHealthList, error) {
+	var out gatewayapi.TopicHealthList
+	if err := c.call(ctx, "getGroupTopicHealth", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// EnsureGroupTopic calls POST /api/v1/group/topics/ensure: create a topic if missing.
+func (c *Client) EnsureGroupTopic(ctx context.Context, req gatewayapi.EnsureTopicRequest) (*gatewayapi.TopicEnsured, error) {
+	var out gatewayapi.TopicEnsured
+	if err := c.call(ctx, "ensureGroupTopic", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGroupTopicXP calls GET /api/v1/group/topics/xp: agent XP leaderboard.
+func (c *Client) GetGroupTopicXP(ctx context.Context) (*gatewayapi.XPLeaderboard, error) {
+	var out gatewayapi.XPLeaderboard
+	if err := c.call(ctx, "getGroupTopicXP", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGroupTopicDensity calls GET /api/v1/group/topics/density: hourly message counts of a topic.
+func (c *Client) GetGroupTopicDensity(ctx context.Context, req gatewayapi.TopicDensityRequest) (*gatewayapi.TopicDensity, error) {
+	var out gatewayapi.TopicDensity
+	if err := c.call(ctx, "getGroupTopicDensity", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSettings calls GET /api/v1/settings: read a setting, or silent mode.
+func (c *Client) GetSettings(ctx context.Context, req gatewayapi.SettingsRequest) (*gatewayapi.Setting, error) {
+	var out gatewayapi.Setting
+	if err := c.call(ctx, "getSettings", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateSetting calls POST /api/v1/settings: store a setting.
+func (c *Client) UpdateSetting(ctx context.Context, req gatewayapi.SettingUpdate) (*gatewayapi.Ack, error) {
+	var out gatewayapi.Ack
+	if err := c.call(ctx, "updateSetting", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMemoryStatus calls GET /api/v1/memory/status: memory layers, observer, ER1 and expertise.
+func (c *Client) GetMemoryStatus(ctx context.Context) (*gatewayapi.MemoryStatus, error) {
+	var out gatewayapi.MemoryStatus
+	if err := c.call(ctx, "getMemoryStatus", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMemoryMetrics calls GET /api/v1/memory/metrics: memory and knowledge quality metrics.
+func (c *Client) GetMemoryMetrics(ctx context.Context) (*gatewayapi.MemoryMetrics, error) {
+	var out gatewayapi.MemoryMetrics
+	if err := c.call(ctx, "getMemoryMetrics", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResetMemory calls POST /api/v1/memory/reset: delete a memory layer.
+func (c *Client) ResetMemory(ctx context.Context, req gatewayapi.MemoryResetRequest) (*gatewayapi.MemoryDeleted, error) {
+	var out gatewayapi.MemoryDeleted
+	if err := c.call(ctx, "resetMemory", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateMemoryConfig calls POST /api/v1/memory/config: store memory settings.
+func (c *Client) UpdateMemoryConfig(ctx context.Context, req gatewayapi.MemoryConfigUpdate) (*gatewayapi.Ack, error) {
+	var out gatewayapi.Ack
+	if err := c.call(ctx, "updateMemoryConfig", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PruneMemory calls POST /api/v1/memory/prune: prune expired memory chunks.
+func (c *Client) PruneMemory(ctx context.Context) (*gatewayapi.MemoryDeleted, error) {
+	var out gatewayapi.MemoryDeleted
+	if err := c.call(ctx, "pruneMemory", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetEmbeddingStatus calls GET /api/v1/memory/embedding/status: embedding configuration and runtime.
+func (c *Client) GetEmbeddingStatus(ctx context.Context) (*gatewayapi.EmbeddingStatus, error) {
+	var out gatewayapi.EmbeddingStatus
+	if err := c.call(ctx, "getEmbeddingStatus", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetEmbeddingHealth calls GET /api/v1/memory/embedding/healthz: probe the embedding runtime; 503 when not ready.
+func (c *Client) GetEmbeddingHealth(ctx context.Context) (*gatewayapi.EmbeddingHealth, error) {
+	var out gatewayapi.EmbeddingHealth
+	if err := c.call(ctx, "getEmbeddingHealth", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// InstallEmbedding calls POST /api/v1/memory/embedding/install: request an embedding model install.
+func (c *Client) InstallEmbedding(ctx context.Context, req gatewayapi.EmbeddingInstallRequest) (*gatewayapi.EmbeddingInstallRequested, error) {
+	var out gatewayapi.EmbeddingInstallRequested
+	if err := c.call(ctx, "installEmbedding", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ReindexEmbedding calls POST /api/v1/memory/embedding/reindex: wipe all chunks for re-embedding.
+func (c *Client) ReindexEmbedding(ctx context.Context, req gatewayapi.EmbeddingReindexRequest) (*gatewayapi.EmbeddingReindexed, error) {
+	var out gatewayapi.EmbeddingReindexed
+	if err := c.call(ctx, "reindexEmbedding", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWorkRepo calls GET /api/v1/workrepo: work repository path.
+func (c *Client) GetWorkRepo(ctx context.Context) (*gatewayapi.WorkRepo, error) {
+	var out gatewayapi.WorkRepo
+	if err := c.call(ctx, "getWorkRepo", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetWorkRepo calls POST /api/v1/workrepo: switch the work repository.
+func (c *Client) SetWorkRepo(ctx context.Context, req gatewayapi.WorkRepoUpdate) (*gatewayapi.WorkRepo, error) {
+	var out gatewayapi.WorkRepo
+	if err := c.call(ctx, "setWorkRepo", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRepoTree calls GET /api/v1/repo/tree: files and directories.
+func (c *Client) GetRepoTree(ctx context.Context, req gatewayapi.RepoPathRequest) ([]gatewayapi.RepoItem, error) {
+	var out []gatewayapi.RepoItem
+	err := c.call(ctx, "getRepoTree", req, &out)
+	return out, err
+}
+
+// GetRepoFile calls GET /api/v1/repo/file: file content.
+func (c *Client) GetRepoFile(ctx context.Context, req gatewayapi.RepoPathRequest) (*gatewayapi.RepoFile, error) {
+	var out gatewayapi.RepoFile
+	if err := c.call(ctx, "getRepoFile", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRepoStatus calls GET /api/v1/repo/status: git status and remotes.
+func (c *Client) GetRepoStatus(ctx context.Context, req gatewayapi.RepoRequest) (*gatewayapi.RepoStatus, error) {
+	var out gatewayapi.RepoStatus
+	if err := c.call(ctx, "getRepoStatus", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SearchRepos calls GET /api/v1/repo/search: git repositories under the search root.
+func (c *Client) SearchRepos(ctx context.Context) (*gatewayapi.RepoSearch, error) {
+	var out gatewayapi.RepoSearch
+	if err := c.call(ctx, "searchRepos", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGitHubAuth calls GET /api/v1/repo/gh-auth: gitHub CLI login state.
+func (c *Client) GetGitHubAuth(ctx context.Context, req gatewayapi.RepoRequest) (*gatewayapi.GitHubAuth, error) {
+	var out gatewayapi.GitHubAuth
+	if err := c.call(ctx, "getGitHubAuth", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListRepoBranches calls GET /api/v1/repo/branches: local branches.
+func (c *Client) ListRepoBranches(ctx context.Context, req gatewayapi.RepoRequest) (*gatewayapi.RepoBranches, error) {
+	var out gatewayapi.RepoBranches
+	if err := c.call(ctx, "listRepoBranches", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CheckoutRepoBranch calls POST /api/v1/repo/checkout: check out a branch.
+func (c *Client) CheckoutRepoBranch(ctx context.Context, req gatewayapi.RepoCheckoutRequest) (*gatewayapi.RepoResult, error) {
+	var out gatewayapi.RepoResult
+	if err := c.call(ctx, "checkoutRepoBranch", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRepoLog calls GET /api/v1/repo/log: recent commits.
+func (c *Client) GetRepoLog(ctx context.Context, req gatewayapi.RepoLogRequest) (*gatewayapi.RepoLog, error) {
+	var out gatewayapi.RepoLog
+	if err := c.call(ctx, "getRepoLog", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRepoFileDiff calls GET /api/v1/repo/diff-file: uncommitted changes of a file.
+func (c *Client) GetRepoFileDiff(ctx context.Context, req gatewayapi.RepoPathRequest) (*gatewayapi.RepoDiff, error) {
+	var out gatewayapi.RepoDiff
+	if err := c.call(ctx, "getRepoFileDiff", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRepoDiff calls GET /api/v1/repo/diff: uncommitted changes.
+func (c *Client) GetRepoDiff(ctx context.Context, req gatewayapi.RepoPathRequest) (*gatewayapi.RepoDiff, error) {
+	var out gatewayapi.RepoDiff
+	if err := c.call(ctx, "getRepoDiff", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CommitRepo calls POST /api/v1/repo/commit: commit all changes.
+func (c *Client) CommitRepo(ctx context.Context, req gatewayapi.RepoCommitRequest) (*gatewayapi.RepoResult, error) {
+	var out gatewayapi.RepoResult
+	if err := c.call(ctx, "commitRepo", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PullRepo calls POST /api/v1/repo/pull: fast-forward pull.
+func (c *Client) PullRepo(ctx context.Context, req gatewayapi.RepoRequest) (*gatewayapi.RepoResult, error) {
+	var out gatewayapi.RepoResult
+	if err := c.call(ctx, "pullRepo", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PushRepo calls POST /api/v1/repo/push: push.
+func (c *Client) PushRepo(ctx context.Context, req gatewayapi.RepoRequest) (*gatewayapi.RepoResult, error) {
+	var out gatewayapi.RepoResult
+	if err := c.call(ctx, "pushRepo", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// InitRepo calls POST /api/v1/repo/init: initialize the repository and set origin.
+func (c *Client) InitRepo(ctx context.Context, req gatewayapi.RepoInitRequest) (*gatewayapi.Ack, error) {
+	var out gatewayapi.Ack
+	if err := c.call(ctx, "initRepo", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreatePullRequest calls POST /api/v1/repo/pr: open a GitHub pull request.
+func (c *Client) CreatePullRequest(ctx context.Context, req gatewayapi.PullRequestRequest) (*gatewayapi.RepoResult, error) {
+	var out gatewayapi.RepoResult
+	if err := c.call(ctx, "createPullRequest", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWebUsers calls GET /api/v1/webusers: web UI users.
+func (c *Client) ListWebUsers(ctx context.Context) ([]timeline.WebUser, error) {
+	var out []timeline.WebUser
+	err := c.call(ctx, "listWebUsers", nil, &out)
+	return out, err
+}
+
+// CreateWebUser calls POST /api/v1/webusers: create a web UI user.
+func (c *Client) CreateWebUser(ctx context.Context, req gatewayapi.CreateWebUserRequest) (*timeline.WebUser, error) {
+	var out timeline.WebUser
+	if err := c.call(ctx, "createWebUser", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetWebUserForceSend calls POST /api/v1/webusers/force: deliver to the linked chat in silent mode.
+func (c *Client) SetWebUserForceSend(ctx context.Context, req gatewayapi.WebUserForceSend) (*gatewayapi.Ack, error) {
+	var out gatewayapi.Ack
+	if err := c.call(ctx, "setWebUserForceSend", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWebLink calls GET /api/v1/weblinks: whatsApp link of a web user.
+func (c *Client) GetWebLink(ctx context.Context, req gatewayapi.WebLinkRequest) (*gatewayapi.WebLink, error) {
+	var out gatewayapi.WebLink
+	if err := c.call(ctx, "getWebLink", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetWebLink calls POST /api/v1/weblinks: link or unlink a web user.
+func (c *Client) SetWebLink(ctx context.Context, req gatewayapi.WebLinkUpdate) (*gatewayapi.Ack, error) {
+	var out gatewayapi.Ack
+	if err := c.call(ctx, "setWebLink", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListPersons calls GET /api/v1/persons: persons.
+func (c *Client) ListPersons(ctx context.Context) ([]timeline.Person, error) {
+	var out []timeline.Person
+	err := c.call(ctx, "listPersons", nil, &out)
+	return out, err
+}
+
+// CreatePerson calls POST /api/v1/persons: create a person.
+func (c *Client) CreatePerson(ctx context.Context, req gatewayapi.CreatePersonRequest) (*timeline.Person, error) {
+	var out timeline.Person
+	if err := c.call(ctx, "createPerson", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPerson calls GET /api/v1/persons/{id}: a person and its identities.
+func (c *Client) GetPerson(ctx context.Context, req gatewayapi.PersonRequest) (*timeline.Person, error) {
+	var out timeline.Person
+	if err := c.call(ctx, "getPerson", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreatePersonLinkCode calls POST /api/v1/persons/{id}/link-code: issue an identity link code.
+func (c *Client) CreatePersonLinkCode(ctx context.Context, req gatewayapi.LinkCodeRequest) (*gatewayapi.IdentityLinkCode, error) {
+	var out gatewayapi.IdentityLinkCode
+	if err := c.call(ctx, "createPersonLinkCode", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UnlinkPersonIdentity calls POST /api/v1/persons/{id}/unlink: remove a channel identity.
+func (c *Client) UnlinkPersonIdentity(ctx context.Context, req gatewayapi.UnlinkIdentityRequest) (*gatewayapi.Ack, error) {
+	var out gatewayapi.Ack
+	if err := c.call(ctx, "unlinkPersonIdentity", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MergePerson calls POST /api/v1/persons/{id}/merge: merge into another person.
+func (c *Client) MergePerson(ctx context.Context, req gatewayapi.MergePersonRequest) (*timeline.Person, error) {
+	var out timeline.Person
+	if err := c.call(ctx, "mergePerson", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetPersonPreference calls POST /api/v1/persons/{id}/preferences: store a preference.
+func (c *Client) SetPersonPreference(ctx context.Context, req gatewayapi.PersonPreferenceRequest) (*gatewayapi.Ack, error) {
+	var out gatewayapi.Ack
+	if err := c.call(ctx, "setPersonPreference", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SendWebChat calls POST /api/v1/webchat/send: send a message as a web user.
+func (c *Client) SendWebChat(ctx context.Context, req gatewayapi.WebChatRequest) (*gatewayapi.Ack, error) {
+	var out gatewayapi.Ack
+	if err := c.call(ctx, "sendWebChat", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTasks calls GET /api/v1/tasks: agent tasks.
+func (c *Client) ListTasks(ctx context.Context, req gatewayapi.TasksRequest) ([]timeline.AgentTask, error) {
+	var out []timeline.AgentTask
+	err := c.call(ctx, "listTasks", req, &out)
+	return out, err
+}
+
+// GetTask calls GET /api/v1/tasks/{id}: an agent task.
+func (c *Client) GetTask(ctx context.Context, req gatewayapi.TaskRequest) (*timeline.AgentTask, error) {
+	var out timeline.AgentTask
+	if err := c.call(ctx, "getTask", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListSessions calls GET /api/v1/sessions: agent sessions.
+func (c *Client) ListSessions(ctx context.Context) ([]gatewayapi.SessionSummary, error) {
+	var out []gatewayapi.SessionSummary
+	err := c.call(ctx, "listSessions", nil, &out)
+	return out, err
+}
+
+// GetSessionHistory calls GET /api/v1/sessions/{key}: recent messages of a session.
+func (c *Client) GetSessionHistory(ctx context.Context, req gatewayapi.SessionHistoryRequest) (*gatewayapi.SessionHistory, error) {
+	var out gatewayapi.SessionHistory
+	if err := c.call(ctx, "getSessionHistory", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListSubagents calls GET /api/v1/subagents: subagent runs of a session.
+func (c *Client) ListSubagents(ctx context.Context, req gatewayapi.SessionRequest) ([]tools.SubagentRunView, error) {
+	var out []tools.SubagentRunView
+	err := c.call(ctx, "listSubagents", req, &out)
+	return out, err
+}
+
+// ListProcesses calls GET /api/v1/processes: background processes, of one session or all.
+func (c *Client) ListProcesses(ctx context.Context, req gatewayapi.SessionRequest) ([]tools.ProcessInfo, error) {
+	var out []tools.ProcessInfo
+	err := c.call(ctx, "listProcesses", req, &out)
+	return out, err
+}
+
+// KillProcess calls POST /api/v1/processes/{id}/kill: kill a background process.
+func (c *Client) KillProcess(ctx context.Context, req gatewayapi.ProcessRequest) (*tools.ProcessInfo, error) {
+	var out tools.ProcessInfo
+	if err := c.call(ctx, "killProcess", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListPendingApprovals calls GET /api/v1/approvals/pending: approvals waiting for a decision.
+func (c *Client) ListPendingApprovals(ctx context.Context) ([]timeline.ApprovalRecord, error) {
+	var out []timeline.ApprovalRecord
+	err := c.call(ctx, "listPendingApprovals", nil, &out)
+	return out, err
+}
+
+// ListApprovalGrants calls GET /api/v1/approvals/grants: remembered approval grants.
+func (c *Client) ListApprovalGrants(ctx context.Context, req gatewayapi.ApprovalGrantsRequest) ([]timeline.ApprovalGrantRecord, error) {
+	var out []timeline.ApprovalGrantRecord
+	err := c.call(ctx, "listApprovalGrants", req, &out)
+	return out, err
+}
+
+// RevokeApprovalGrant calls DELETE /api/v1/approvals/grants/{id}: revoke a grant.
+func (c *Client) RevokeApprovalGrant(ctx context.Context, req gatewayapi.ApprovalGrantRequest) (*gatewayapi.GrantRevoked, error) {
+	var out gatewayapi.GrantRevoked
+	if err := c.call(ctx, "revokeApprovalGrant", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListApprovalEvents calls GET /api/v1/approvals/{id}/events: audit trail of an approval.
+func (c *Client) ListApprovalEvents(ctx context.Context, req gatewayapi.ApprovalRequest) ([]timeline.ApprovalEventRecord, error) {
+	var out []timeline.ApprovalEventRecord
+	err := c.call(ctx, "listApprovalEvents", req, &out)
+	return out, err
+}
+
+// RespondApproval calls POST /api/v1/approvals/{id}: approve or deny.
+func (c *Client) RespondApproval(ctx context.Context, req gatewayapi.ApprovalDecision) (*gatewayapi.ApprovalSent, error) {
+	var out gatewayapi.ApprovalSent
+	if err := c.call(ctx, "respondApproval", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
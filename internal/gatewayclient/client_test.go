@@ -0,0 +1,69 @@
+package gatewayclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+)
+
+func TestGeneratedClientIsCurrent(t *testing.T) {
+	want, err := gatewayapi.GenerateClient("gatewayclient")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("client_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("client_gen.go is stale; run go generate ./internal/gatewayclient")
+	}
+}
+
+func TestClientRoutesByServerAndEncodesRequests(t *testing.T) {
+	api := http.NewServeMux()
+	gatewayapi.Handle(gatewayapi.NewRouter(api, gatewayapi.ServerAPI), "chat",
+		func(r *http.Request, req gatewayapi.ChatRequest) (string, error) {
+			if r.Header.Get("Authorization") != "Bearer tok" {
+				return "", gatewayapi.Errorf(http.StatusUnauthorized, "unauthorized")
+			}
+			return req.Session + ": " + req.Message, nil
+		})
+	dash := http.NewServeMux()
+	dr := gatewayapi.NewRouter(dash, gatewayapi.ServerDashboard)
+	gatewayapi.Handle(dr, "respondApproval", func(r *http.Request, req gatewayapi.ApprovalDecision) (gatewayapi.ApprovalSent, error) {
+		if !req.Approved {
+			return gatewayapi.ApprovalSent{}, gatewayapi.Errorf(http.StatusBadRequest, "denied")
+		}
+		return gatewayapi.ApprovalSent{Status: "ok", ApprovalID: req.ID}, nil
+	})
+	apiSrv := httptest.NewServer(api)
+	defer apiSrv.Close()
+	dashSrv := httptest.NewServer(dash)
+	defer dashSrv.Close()
+
+	c := New(apiSrv.URL+"/", dashSrv.URL, " tok ")
+	ctx := context.Background()
+	reply, err := c.Chat(ctx, gatewayapi.ChatRequest{Message: "hi there", Session: "cli:x"})
+	if err != nil || reply != "cli:x: hi there" {
+		t.Fatalf("chat = %q, %v", reply, err)
+	}
+	sent, err := c.RespondApproval(ctx, gatewayapi.ApprovalDecision{ID: "ap/1", Approved: true})
+	if err != nil || sent.ApprovalID != "ap/1" {
+		t.Fatalf("respond = %+v, %v", sent, err)
+	}
+	_, err = c.RespondApproval(ctx, gatewayapi.ApprovalDecision{ID: "ap1"})
+	var gwErr *Error
+	if !errors.As(err, &gwErr) || gwErr.StatusCode != http.StatusBadRequest || gwErr.Message != "denied" {
+		t.Fatalf("expected 400 error, got %v", err)
+	}
+	if _, err := c.GetTrace(ctx, gatewayapi.TraceRequest{}); err == nil {
+		t.Fatalf("expected missing path parameter to fail")
+	}
+}
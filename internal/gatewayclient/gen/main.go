@@ -0,0 +1,19 @@
+// Command gen writes client_gen.go from the gatewayapi route table.
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+)
+
+func main() {
+	src, err := gatewayapi.GenerateClient("gatewayclient")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("client_gen.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -124,6 +124,13 @@ func (r *GroupRouter) handleMessage(msg ConsumerMessage) {
 		})
 	}
 
+	// Lease claims are applied in log order by every member, the claimant
+	// included.
+	if msg.Topic == r.topics.Announce && env.Type == EnvelopeLease {
+		r.manager.HandleLease(&env)
+		return
+	}
+
 	// Skip our own messages
 	if env.SenderID == r.manager.identity.AgentID {
 		return
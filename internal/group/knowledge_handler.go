@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/KafClaw/KafClaw/internal/knowledge"
@@ -16,17 +17,41 @@ type KnowledgeEnvelopeHandler interface {
 	Process(topic string, raw []byte) error
 }
 
+// snapshotRequestInterval rate-limits snapshot requests per group.
+const snapshotRequestInterval = time.Minute
+
+// KnowledgeSync lets the handler take part in anti-entropy sync: answer
+// snapshot requests of peers and request a snapshot after a fact version
+// gap. Publish sends an envelope to the knowledge facts topic.
+type KnowledgeSync struct {
+	InstanceID string
+	Publish    func(env knowledge.Envelope) error
+}
+
 type defaultKnowledgeHandler struct {
 	timeline          *timeline.TimelineService
 	localID           string
 	governanceEnabled bool
+	sync              KnowledgeSync
+
+	syncMu       sync.Mutex
+	lastSnapshot map[string]time.Time
 }
 
 func NewKnowledgeHandler(timeSvc *timeline.TimelineService, localClawID string, governanceEnabled bool) KnowledgeEnvelopeHandler {
+	return NewKnowledgeHandlerWithSync(timeSvc, localClawID, governanceEnabled, KnowledgeSync{})
+}
+
+// NewKnowledgeHandlerWithSync creates a knowledge handler that also answers
+// and sends snapshot requests through ks.
+func NewKnowledgeHandlerWithSync(timeSvc *timeline.TimelineService, localClawID string, governanceEnabled bool, ks KnowledgeSync) KnowledgeEnvelopeHandler {
+	ks.InstanceID = strings.TrimSpace(ks.InstanceID)
 	return &defaultKnowledgeHandler{
 		timeline:          timeSvc,
 		localID:           strings.TrimSpace(localClawID),
 		governanceEnabled: governanceEnabled,
+		sync:              ks,
+		lastSnapshot:      map[string]time.Time{},
 	}
 }
 
@@ -82,6 +107,17 @@ func (h *defaultKnowledgeHandler) Process(topic string, raw []byte) error {
 			}
 			applyStatus = status
 			applyReason = reason
+		case knowledge.TypeSnapshotRequest:
+			if err := h.applySnapshotRequest(env); err != nil {
+				return err
+			}
+		case knowledge.TypeSnapshotResponse:
+			status, reason, err := h.applySnapshotResponse(env)
+			if err != nil {
+				return err
+			}
+			applyStatus = status
+			applyReason = reason
 		}
 		payload, _ := json.Marshal(env.Payload)
 		_ = h.timeline.AddEvent(&timeline.TimelineEvent{
@@ -129,6 +165,7 @@ func (h *defaultKnowledgeHandler) applyProposalPayload(env knowledge.Envelope) e
 		ProposerClawID:     strings.TrimSpace(env.ClawID),
 		ProposerInstanceID: strings.TrimSpace(env.InstanceID),
 		Status:             "pending",
+		Fact:               mustJSONFact(p.Fact),
 	})
 }
 
@@ -144,6 +181,8 @@ func (h *defaultKnowledgeHandler) applyVotePayload(env knowledge.Envelope) error
 	if err := p.Validate(); err != nil {
 		return fmt.Errorf("validate vote payload: %w", err)
 	}
+	// Role, zone and expertise in the payload are the voter's own claims;
+	// they are not stored so no tally can weigh them.
 	return h.timeline.UpsertKnowledgeVote(&timeline.KnowledgeVoteRecord{
 		ProposalID: p.ProposalID,
 		ClawID:     strings.TrimSpace(env.ClawID),
@@ -166,13 +205,22 @@ func (h *defaultKnowledgeHandler) applyDecisionPayload(env knowledge.Envelope) e
 	if err := p.Validate(); err != nil {
 		return fmt.Errorf("validate decision payload: %w", err)
 	}
-	return h.timeline.UpdateKnowledgeProposalDecision(
+	if err := h.timeline.UpdateKnowledgeProposalDecision(
 		strings.TrimSpace(p.ProposalID),
 		strings.ToLower(strings.TrimSpace(p.Outcome)),
 		p.Yes,
 		p.No,
 		strings.TrimSpace(p.Reason),
-	)
+	); err != nil {
+		return err
+	}
+	if p.YesWeight > 0 || p.NoWeight > 0 || strings.TrimSpace(p.Rationale) != "" {
+		if err := h.timeline.UpdateKnowledgeProposalTally(strings.TrimSpace(p.ProposalID), p.YesWeight, p.NoWeight, strings.TrimSpace(p.Rationale)); err != nil {
+			return err
+		}
+	}
+	_, err = knowledge.ApplyApprovedProposal(h.timeline, strings.TrimSpace(p.ProposalID))
+	return err
 }
 
 func (h *defaultKnowledgeHandler) applyFactPayload(env knowledge.Envelope) (status string, reason string, err error) {
@@ -184,43 +232,104 @@ func (h *defaultKnowledgeHandler) applyFactPayload(env knowledge.Envelope) (stat
 	if err := json.Unmarshal(data, &p); err != nil {
 		return "", "", fmt.Errorf("unmarshal fact payload: %w", err)
 	}
-	if err := p.Validate(); err != nil {
-		return "", "", fmt.Errorf("validate fact payload: %w", err)
+	result, err := knowledge.ApplyFact(h.timeline, p)
+	if err != nil {
+		return "", "", err
+	}
+	if result.Status == knowledge.FactApplyConflict &&
+		(strings.HasPrefix(result.Reason, "version_gap_") || result.Reason == "new_fact_must_start_at_v1") {
+		// Versions were missed; catch up from the peers.
+		h.requestSnapshot(p.Group)
+	}
+	return result.Status, result.Reason, nil
+}
+
+// applySnapshotRequest records the requester's divergence and answers with
+// the fact versions it lacks. When the requester is ahead, the handler
+// requests a snapshot itself.
+func (h *defaultKnowledgeHandler) applySnapshotRequest(env knowledge.Envelope) error {
+	var req knowledge.SnapshotRequestPayload
+	if err := decodeKnowledgePayload(env, &req); err != nil {
+		return fmt.Errorf("snapshot request payload: %w", err)
+	}
+	diff, err := knowledge.RecordPeerDigest(h.timeline, strings.TrimSpace(env.ClawID), req.Group, req.Root, req.Facts)
+	if err != nil {
+		return err
+	}
+	if !h.canSync() {
+		return nil
+	}
+	resp, _, err := knowledge.NewSnapshotResponse(h.timeline, req, strings.TrimSpace(env.ClawID), h.localID, h.sync.InstanceID, time.Now())
+	if err != nil {
+		return err
 	}
+	if err := h.sync.Publish(resp); err != nil {
+		slog.Warn("Knowledge snapshot response failed", "request", req.RequestID, "error", err)
+	}
+	if len(diff.Missing) > 0 || len(diff.Behind) > 0 {
+		h.requestSnapshot(req.Group)
+	}
+	return nil
+}
 
-	current, err := h.timeline.GetKnowledgeFactLatest(p.FactID)
+// applySnapshotResponse replays the versions of a response to this claw's
+// request and records the responder's divergence.
+func (h *defaultKnowledgeHandler) applySnapshotResponse(env knowledge.Envelope) (status string, reason string, err error) {
+	var resp knowledge.SnapshotResponsePayload
+	if err := decodeKnowledgePayload(env, &resp); err != nil {
+		return "", "", fmt.Errorf("snapshot response payload: %w", err)
+	}
+	if h.localID == "" || !strings.EqualFold(strings.TrimSpace(resp.RequesterClawID), h.localID) {
+		return "ignored", "other_requester", nil
+	}
+	result, err := knowledge.ApplySnapshotResponse(h.timeline, resp)
 	if err != nil {
 		return "", "", err
 	}
-	var existing *knowledge.FactState
-	if current != nil {
-		existing = &knowledge.FactState{
-			FactID:    current.FactID,
-			Subject:   current.Subject,
-			Predicate: current.Predicate,
-			Object:    current.Object,
-			Version:   current.Version,
-		}
+	if _, err := knowledge.RecordPeerDigest(h.timeline, strings.TrimSpace(env.ClawID), resp.Group, resp.Root, resp.Digest); err != nil {
+		return "", "", err
 	}
-	result := knowledge.EvaluateFactApply(existing, p)
-	if result.Status == knowledge.FactApplyAccepted {
-		rec := &timeline.KnowledgeFactRecord{
-			FactID:     p.FactID,
-			GroupName:  p.Group,
-			Subject:    p.Subject,
-			Predicate:  p.Predicate,
-			Object:     p.Object,
-			Version:    p.Version,
-			Source:     p.Source,
-			ProposalID: p.ProposalID,
-			DecisionID: p.DecisionID,
-			Tags:       mustJSONTags(p.Tags),
-		}
-		if err := h.timeline.UpsertKnowledgeFactLatest(rec); err != nil {
-			return "", "", err
-		}
+	return "accepted", fmt.Sprintf("applied=%d stale=%d conflicts=%d", result.Applied, result.Stale, result.Conflicts), nil
+}
+
+// requestSnapshot publishes a snapshot request for group, at most once per
+// snapshotRequestInterval.
+func (h *defaultKnowledgeHandler) requestSnapshot(group string) {
+	group = strings.TrimSpace(group)
+	if !h.canSync() || group == "" {
+		return
 	}
-	return result.Status, result.Reason, nil
+	h.syncMu.Lock()
+	now := time.Now()
+	if last, ok := h.lastSnapshot[group]; ok && now.Sub(last) < snapshotRequestInterval {
+		h.syncMu.Unlock()
+		return
+	}
+	h.lastSnapshot[group] = now
+	h.syncMu.Unlock()
+
+	env, err := knowledge.NewSnapshotRequest(h.timeline, group, h.localID, h.sync.InstanceID, now)
+	if err == nil {
+		err = h.sync.Publish(env)
+	}
+	if err != nil {
+		slog.Warn("Knowledge snapshot request failed", "group", group, "error", err)
+	}
+}
+
+func (h *defaultKnowledgeHandler) canSync() bool {
+	return h.sync.Publish != nil && h.localID != "" && h.sync.InstanceID != ""
+}
+
+func decodeKnowledgePayload(env knowledge.Envelope, v interface{ Validate() error }) error {
+	data, err := json.Marshal(env.Payload)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	return v.Validate()
 }
 
 func mustJSONTags(tags []string) string {
@@ -231,9 +340,21 @@ func mustJSONTags(tags []string) string {
 	return string(b)
 }
 
+func mustJSONFact(f *knowledge.ProposedFact) string {
+	if f == nil {
+		return ""
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
 func isGovernedKnowledgeType(t string) bool {
 	switch strings.ToLower(strings.TrimSpace(t)) {
-	case knowledge.TypeProposal, knowledge.TypeVote, knowledge.TypeDecision, knowledge.TypeFact:
+	case knowledge.TypeProposal, knowledge.TypeVote, knowledge.TypeDecision, knowledge.TypeFact,
+		knowledge.TypeSnapshotRequest, knowledge.TypeSnapshotResponse:
 		return true
 	default:
 		return false
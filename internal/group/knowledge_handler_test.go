@@ -2,6 +2,7 @@ package group
 
 import (
 	"encoding/json"
+	"fmt"
 	"path/filepath"
 	"testing"
 	"time"
@@ -270,3 +271,157 @@ func TestKnowledgeHandlerProcess_GovernanceDisabledSkipsGovernedTypes(t *testing
 		t.Fatalf("expected no proposal persisted when governance disabled, got %+v", prop)
 	}
 }
+
+func TestKnowledgeHandlerProcess_ApprovedProposalAppliesFact(t *testing.T) {
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	defer tl.Close()
+
+	h := NewKnowledgeHandler(tl, "local-claw", true)
+	makeRaw := func(idem string, msgType string, payload any) []byte {
+		raw, _ := json.Marshal(knowledge.Envelope{
+			SchemaVersion:  knowledge.CurrentSchemaVersion,
+			Type:           msgType,
+			TraceID:        "trace-fact",
+			Timestamp:      time.Now(),
+			IdempotencyKey: idem,
+			ClawID:         "remote-claw",
+			InstanceID:     "inst-1",
+			Payload:        payload,
+		})
+		return raw
+	}
+	fact := &knowledge.ProposedFact{Subject: "payments-api", Predicate: "owned_by", Object: "team-billing"}
+	if err := h.Process("group.g1.knowledge.proposals", makeRaw("idem-p", knowledge.TypeProposal, knowledge.ProposalPayload{
+		ProposalID: "kp-fact",
+		Group:      "g1",
+		Statement:  fact.Statement(),
+		Fact:       fact,
+	})); err != nil {
+		t.Fatalf("process proposal: %v", err)
+	}
+	factID := knowledge.FactID("g1", fact.Subject, fact.Predicate)
+	if got, _ := tl.GetKnowledgeFactLatest(factID); got != nil {
+		t.Fatalf("fact must wait for approval, got %+v", got)
+	}
+	if err := h.Process("group.g1.knowledge.decisions", makeRaw("idem-d", knowledge.TypeDecision, knowledge.DecisionPayload{
+		ProposalID: "kp-fact",
+		Outcome:    "approved",
+		Yes:        2,
+		YesWeight:  3.5,
+		Rationale:  "yes 3.5/2, no 0/2",
+	})); err != nil {
+		t.Fatalf("process decision: %v", err)
+	}
+	if prop, _ := tl.GetKnowledgeProposal("kp-fact"); prop == nil || prop.YesWeight != 3.5 || prop.Rationale != "yes 3.5/2, no 0/2" {
+		t.Fatalf("expected weighted tally on the proposal, got %+v", prop)
+	}
+	got, err := tl.GetKnowledgeFactLatest(factID)
+	if err != nil || got == nil || got.Object != "team-billing" || got.Version != 1 || got.ProposalID != "kp-fact" {
+		t.Fatalf("unexpected fact after approval: %+v %v", got, err)
+	}
+}
+
+func TestKnowledgeHandlerProcess_VersionGapTriggersSnapshotSync(t *testing.T) {
+	open := func() *timeline.TimelineService {
+		tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+		if err != nil {
+			t.Fatalf("open timeline: %v", err)
+		}
+		t.Cleanup(func() { tl.Close() })
+		return tl
+	}
+	tlA, tlB := open(), open()
+	var toA, toB [][]byte
+	publishTo := func(queue *[][]byte) func(knowledge.Envelope) error {
+		return func(env knowledge.Envelope) error {
+			raw, err := json.Marshal(env)
+			*queue = append(*queue, raw)
+			return err
+		}
+	}
+	hA := NewKnowledgeHandlerWithSync(tlA, "claw-a", true, KnowledgeSync{InstanceID: "inst-a", Publish: publishTo(&toB)})
+	hB := NewKnowledgeHandlerWithSync(tlB, "claw-b", true, KnowledgeSync{InstanceID: "inst-b", Publish: publishTo(&toA)})
+
+	// claw-a holds v1..v3; claw-b only sees v1 and then v3.
+	var last knowledge.FactPayload
+	for _, owner := range []string{"team-a", "team-b", "team-c"} {
+		p, err := knowledge.NextFact(tlA, "g1", knowledge.ProposedFact{Subject: "payments-api", Predicate: "owned_by", Object: owner}, "test")
+		if err != nil {
+			t.Fatalf("next fact: %v", err)
+		}
+		if _, err := knowledge.ApplyFact(tlA, p); err != nil {
+			t.Fatalf("apply on claw-a: %v", err)
+		}
+		if p.Version != 2 {
+			raw, _ := json.Marshal(knowledge.Envelope{
+				SchemaVersion:  knowledge.CurrentSchemaVersion,
+				Type:           knowledge.TypeFact,
+				TraceID:        "trace-fact",
+				Timestamp:      time.Now(),
+				IdempotencyKey: fmt.Sprintf("idem-v%d", p.Version),
+				ClawID:         "claw-a",
+				InstanceID:     "inst-a",
+				Payload:        p,
+			})
+			if err := hB.Process("group.g1.knowledge.facts", raw); err != nil {
+				t.Fatalf("process v%d on claw-b: %v", p.Version, err)
+			}
+		}
+		last = p
+	}
+	if len(toA) != 1 {
+		t.Fatalf("expected one snapshot request after the gap, got %d", len(toA))
+	}
+	// A second gap within the interval does not request again.
+	raw, _ := json.Marshal(knowledge.Envelope{
+		SchemaVersion:  knowledge.CurrentSchemaVersion,
+		Type:           knowledge.TypeFact,
+		TraceID:        "trace-fact",
+		Timestamp:      time.Now(),
+		IdempotencyKey: "idem-v3-again",
+		ClawID:         "claw-a",
+		InstanceID:     "inst-a",
+		Payload:        last,
+	})
+	if err := hB.Process("group.g1.knowledge.facts", raw); err != nil {
+		t.Fatalf("process repeated gap: %v", err)
+	}
+	if len(toA) != 1 {
+		t.Fatalf("expected snapshot requests to be rate-limited, got %d", len(toA))
+	}
+
+	if err := hA.Process("group.g1.knowledge.facts", toA[0]); err != nil {
+		t.Fatalf("process snapshot request on claw-a: %v", err)
+	}
+	if len(toB) != 1 {
+		t.Fatalf("expected one snapshot response, got %d", len(toB))
+	}
+	if err := hB.Process("group.g1.knowledge.facts", toB[0]); err != nil {
+		t.Fatalf("process snapshot response on claw-b: %v", err)
+	}
+	got, err := tlB.GetKnowledgeFactLatest(last.FactID)
+	if err != nil || got == nil || got.Version != 3 || got.Object != "team-c" {
+		t.Fatalf("expected claw-b to catch up to v3, got %+v %v", got, err)
+	}
+	peers, _ := tlB.ListKnowledgeSyncPeers("g1")
+	if len(peers) != 1 || peers[0].ClawID != "claw-a" || peers[0].Behind != 0 || peers[0].Missing != 0 {
+		t.Fatalf("unexpected peer record on claw-b: %+v", peers)
+	}
+	peers, _ = tlA.ListKnowledgeSyncPeers("g1")
+	if len(peers) != 1 || peers[0].ClawID != "claw-b" || peers[0].Ahead != 1 {
+		t.Fatalf("expected claw-a to record claw-b as behind, got %+v", peers)
+	}
+
+	// A response meant for another claw is ignored.
+	tlC := open()
+	hC := NewKnowledgeHandlerWithSync(tlC, "claw-c", true, KnowledgeSync{})
+	if err := hC.Process("group.g1.knowledge.facts", toB[0]); err != nil {
+		t.Fatalf("process foreign response: %v", err)
+	}
+	if got, _ := tlC.GetKnowledgeFactLatest(last.FactID); got != nil {
+		t.Fatalf("expected foreign response to be ignored, got %+v", got)
+	}
+}
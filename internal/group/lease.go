@@ -0,0 +1,210 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// Capability leases elect one member to do group-wide singleton work (such
+// as firing builtin scheduler jobs). A lease is a term with a holder, an
+// expiry and a fencing token. Terms are claimed on the announce topic and
+// every member applies the claims in log order with the same rules, so all
+// members agree on the holder without a separate store:
+//
+//   - a new term is accepted only once the previous one expired (judged by
+//     the claim's own timestamp) and only with the next fencing token;
+//   - the holder renews its term with the same token before it expires;
+//   - claims with a stale token or against a live term are ignored.
+//
+// The holder stops acting a margin before expiry, and a member that cannot
+// reach the log cannot renew, so a partitioned holder steps down before
+// anyone else can take over. The announce topic must keep a single
+// partition for the log order to be total.
+
+type leaseState struct {
+	holder  string
+	token   int64
+	expires time.Time
+	// maxToken is the highest token of any accepted term, including terms
+	// that expired before this member learned of them.
+	maxToken int64
+}
+
+// leaseTTL is how long a term lasts without renewal. Holders renew every
+// heartbeat, so a term survives two missed heartbeats.
+func (m *Manager) leaseTTL() time.Duration {
+	return 3 * m.heartbeatInterval()
+}
+
+// leaseMargin is how long before expiry a holder stops acting, covering
+// clock skew between members.
+func (m *Manager) leaseMargin() time.Duration {
+	return m.heartbeatInterval() / 2
+}
+
+// leaseReady reports whether this member has observed the group for a full
+// heartbeat window since joining. Until then its view of the leases may
+// miss a live term, so it neither claims nor acts.
+func (m *Manager) leaseReady(now time.Time) bool {
+	m.leaseMu.Lock()
+	joined := m.joinedAt
+	m.leaseMu.Unlock()
+	return !joined.IsZero() && now.Sub(joined) >= m.heartbeatInterval()
+}
+
+// HoldsLease reports whether this member holds the lease for capability at
+// now, and the term's fencing token. The first call also makes this member
+// campaign for the lease on each heartbeat.
+func (m *Manager) HoldsLease(capability string, now time.Time) (int64, bool) {
+	if !slices.Contains(m.identity.Capabilities, capability) {
+		return 0, false
+	}
+	m.leaseMu.Lock()
+	m.campaigns[capability] = true
+	st := m.leases[capability]
+	m.leaseMu.Unlock()
+	if !m.leaseReady(now) {
+		return 0, false
+	}
+	if st.holder != m.identity.AgentID || !now.Before(st.expires.Add(-m.leaseMargin())) {
+		return 0, false
+	}
+	return st.token, true
+}
+
+// LeaseHolder returns the holder of capability's lease at now, or "" when
+// no live term is known.
+func (m *Manager) LeaseHolder(capability string, now time.Time) string {
+	m.leaseMu.Lock()
+	defer m.leaseMu.Unlock()
+	st := m.leases[capability]
+	if !now.Before(st.expires) {
+		return ""
+	}
+	return st.holder
+}
+
+// maintainLeases renews the terms this member holds and claims a new term
+// for each campaigned capability whose lease expired.
+func (m *Manager) maintainLeases(ctx context.Context, now time.Time) {
+	if !m.Active() || !m.leaseReady(now) {
+		return
+	}
+	var claims []LeaseClaimPayload
+	m.leaseMu.Lock()
+	for capability := range m.campaigns {
+		st := m.leases[capability]
+		switch {
+		case st.holder == m.identity.AgentID && now.Before(st.expires):
+			claims = append(claims, LeaseClaimPayload{Capability: capability, Holder: st.holder, Token: st.token})
+		case !now.Before(st.expires):
+			claims = append(claims, LeaseClaimPayload{Capability: capability, Holder: m.identity.AgentID, Token: st.maxToken + 1})
+		}
+	}
+	m.leaseMu.Unlock()
+	for _, c := range claims {
+		c.TTLMs = m.leaseTTL().Milliseconds()
+		if err := m.publishLeaseClaim(ctx, c); err != nil {
+			slog.Debug("Lease claim failed", "capability", c.Capability, "error", err)
+		}
+	}
+}
+
+// releaseLeases gives up the terms this member holds, so the next member
+// can take over without waiting for expiry.
+func (m *Manager) releaseLeases(ctx context.Context, now time.Time) {
+	var claims []LeaseClaimPayload
+	m.leaseMu.Lock()
+	for capability, st := range m.leases {
+		if st.holder == m.identity.AgentID && now.Before(st.expires) {
+			claims = append(claims, LeaseClaimPayload{Capability: capability, Holder: st.holder, Token: st.token})
+		}
+	}
+	m.leaseMu.Unlock()
+	for _, c := range claims {
+		_ = m.publishLeaseClaim(ctx, c)
+	}
+}
+
+func (m *Manager) publishLeaseClaim(ctx context.Context, c LeaseClaimPayload) error {
+	env := &GroupEnvelope{
+		Type:          EnvelopeLease,
+		CorrelationID: fmt.Sprintf("lease-%s-%d-%d", c.Capability, c.Token, time.Now().UnixNano()),
+		SenderID:      m.identity.AgentID,
+		Timestamp:     time.Now(),
+		Payload:       c,
+	}
+	return m.lfs.ProduceEnvelope(ctx, m.topics.Announce, env)
+}
+
+// HandleLease applies a lease claim read from the announce topic. It must
+// also be called for this member's own claims.
+func (m *Manager) HandleLease(env *GroupEnvelope) {
+	data, err := json.Marshal(env.Payload)
+	if err != nil {
+		return
+	}
+	var c LeaseClaimPayload
+	if err := json.Unmarshal(data, &c); err != nil {
+		slog.Warn("HandleLease: unmarshal payload", "error", err)
+		return
+	}
+	// Only the claimant may claim for itself.
+	if c.Capability == "" || c.Holder == "" || c.Holder != env.SenderID {
+		return
+	}
+	at := env.Timestamp
+	m.leaseMu.Lock()
+	defer m.leaseMu.Unlock()
+	st := m.leases[c.Capability]
+	ttl := time.Duration(c.TTLMs) * time.Millisecond
+	switch {
+	case c.Holder == st.holder && c.Token == st.token && !at.After(st.expires):
+		// Renewal, or release when the TTL is zero.
+		st.expires = at.Add(ttl)
+	case at.After(st.expires) && c.Token == st.maxToken+1 && ttl > 0:
+		st.holder, st.token, st.expires = c.Holder, c.Token, at.Add(ttl)
+		st.maxToken = c.Token
+		slog.Info("Lease term started", "capability", c.Capability, "holder", c.Holder, "token", c.Token)
+	default:
+		return
+	}
+	m.leases[c.Capability] = st
+}
+
+// observeLeases merges the lease views a member reported in its heartbeat:
+// a live term with a higher token than known replaces the local view, and
+// tokens only move forward.
+func (m *Manager) observeLeases(at time.Time, views []LeaseView) {
+	if len(views) == 0 {
+		return
+	}
+	m.leaseMu.Lock()
+	defer m.leaseMu.Unlock()
+	for _, v := range views {
+		if v.Capability == "" {
+			continue
+		}
+		st := m.leases[v.Capability]
+		if v.Token > st.token && v.ExpiresAt.After(at) {
+			st.holder, st.token, st.expires = v.Holder, v.Token, v.ExpiresAt
+		}
+		st.maxToken = max(st.maxToken, v.Token, v.MaxToken)
+		m.leases[v.Capability] = st
+	}
+}
+
+// leaseViews is this member's view of the leases, sent with heartbeats.
+func (m *Manager) leaseViews() []LeaseView {
+	m.leaseMu.Lock()
+	defer m.leaseMu.Unlock()
+	var out []LeaseView
+	for capability, st := range m.leases {
+		out = append(out, LeaseView{Capability: capability, Holder: st.holder, Token: st.token, ExpiresAt: st.expires, MaxToken: st.maxToken})
+	}
+	return out
+}
@@ -0,0 +1,158 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func leaseClaim(holder string, token int64, at time.Time, ttl time.Duration) *GroupEnvelope {
+	return &GroupEnvelope{
+		Type:      EnvelopeLease,
+		SenderID:  holder,
+		Timestamp: at,
+		Payload:   LeaseClaimPayload{Capability: "scheduler", Holder: holder, Token: token, TTLMs: ttl.Milliseconds()},
+	}
+}
+
+// newLeaseTestManager returns a manager for agentID that has observed the
+// group for a heartbeat window.
+func newLeaseTestManager(serverURL, agentID string, now time.Time) *Manager {
+	m := newTestManager(serverURL)
+	m.identity.AgentID = agentID
+	m.identity.Capabilities = append(m.identity.Capabilities, "scheduler")
+	m.joinedAt = now.Add(-m.heartbeatInterval())
+	return m
+}
+
+func TestLeaseClaimsAppliedInLogOrder(t *testing.T) {
+	t0 := time.Now()
+	m := newLeaseTestManager("http://127.0.0.1:0", "b-agent", t0)
+	ttl := m.leaseTTL()
+
+	// Every member applies the same log; the first valid claim wins.
+	m.HandleLease(leaseClaim("z-agent", 1, t0, ttl))
+	m.HandleLease(leaseClaim("b-agent", 1, t0.Add(time.Millisecond), ttl))
+	m.HandleLease(leaseClaim("b-agent", 2, t0.Add(time.Second), ttl))
+	if got := m.LeaseHolder("scheduler", t0.Add(time.Second)); got != "z-agent" {
+		t.Fatalf("expected the first claim to hold the lease, got %q", got)
+	}
+	if _, ok := m.HoldsLease("scheduler", t0.Add(time.Second)); ok {
+		t.Fatal("a later claim must not take a live lease")
+	}
+
+	// A renewal extends the term; after it lapses the next token wins.
+	m.HandleLease(leaseClaim("z-agent", 1, t0.Add(ttl/2), ttl))
+	if got := m.LeaseHolder("scheduler", t0.Add(ttl+time.Second)); got != "z-agent" {
+		t.Fatalf("renewal did not extend the term, holder %q", got)
+	}
+	expired := t0.Add(ttl/2 + ttl + time.Second)
+	m.HandleLease(leaseClaim("b-agent", 2, expired, ttl))
+	if got := m.LeaseHolder("scheduler", expired); got != "b-agent" {
+		t.Fatalf("expected b-agent to take over after expiry, got %q", got)
+	}
+	if token, ok := m.HoldsLease("scheduler", expired); !ok || token != 2 {
+		t.Fatalf("expected to hold the lease with token 2, got %d %v", token, ok)
+	}
+
+	// The old holder's stale renewal is fenced off.
+	m.HandleLease(leaseClaim("z-agent", 1, expired.Add(time.Second), ttl))
+	if got := m.LeaseHolder("scheduler", expired.Add(time.Second)); got != "b-agent" {
+		t.Fatalf("stale token changed the holder to %q", got)
+	}
+
+	// Claims for someone else are ignored.
+	forged := leaseClaim("z-agent", 3, expired.Add(2*ttl), ttl)
+	forged.SenderID = "mallory"
+	m.HandleLease(forged)
+	if got := m.LeaseHolder("scheduler", expired.Add(2*ttl)); got != "" {
+		t.Fatalf("forged claim was applied, holder %q", got)
+	}
+}
+
+func TestLeaseWaitsOneHeartbeatWindowAfterJoin(t *testing.T) {
+	now := time.Now()
+	m := newLeaseTestManager("http://127.0.0.1:0", "a-agent", now)
+	m.joinedAt = now
+	m.HandleLease(leaseClaim("a-agent", 1, now, m.leaseTTL()))
+	if _, ok := m.HoldsLease("scheduler", now); ok {
+		t.Fatal("a freshly joined gateway must not act before observing the roster")
+	}
+	if _, ok := m.HoldsLease("scheduler", now.Add(m.heartbeatInterval())); !ok {
+		t.Fatal("expected to hold the lease after one heartbeat window")
+	}
+	// The holder steps down a margin before expiry.
+	if _, ok := m.HoldsLease("scheduler", now.Add(m.leaseTTL()-m.leaseMargin())); ok {
+		t.Fatal("holder must stop acting before the term expires")
+	}
+}
+
+func TestLeaseLearnedFromHeartbeatViews(t *testing.T) {
+	now := time.Now()
+	m := newLeaseTestManager("http://127.0.0.1:0", "a-agent", now)
+	m.HandleAnnounce(&GroupEnvelope{
+		Type:      EnvelopeAnnounce,
+		SenderID:  "z-agent",
+		Timestamp: now,
+		Payload: AnnouncePayload{
+			Action:   "heartbeat",
+			Identity: AgentIdentity{AgentID: "z-agent", Capabilities: []string{"scheduler"}},
+			Leases:   []LeaseView{{Capability: "scheduler", Holder: "z-agent", Token: 5, ExpiresAt: now.Add(time.Minute), MaxToken: 5}},
+		},
+	})
+	if got := m.LeaseHolder("scheduler", now); got != "z-agent" {
+		t.Fatalf("expected the live term from the heartbeat, got %q", got)
+	}
+	// A claim with a token the group already used is rejected.
+	m.HandleLease(leaseClaim("a-agent", 1, now.Add(2*time.Minute), m.leaseTTL()))
+	if got := m.LeaseHolder("scheduler", now.Add(2*time.Minute)); got != "" {
+		t.Fatalf("reused token was accepted, holder %q", got)
+	}
+	m.HandleLease(leaseClaim("a-agent", 6, now.Add(2*time.Minute), m.leaseTTL()))
+	if token, ok := m.HoldsLease("scheduler", now.Add(2*time.Minute)); !ok || token != 6 {
+		t.Fatalf("expected token 6, got %d %v", token, ok)
+	}
+}
+
+func TestMaintainLeasesClaimsRenewsAndReleases(t *testing.T) {
+	var mu sync.Mutex
+	var claims []LeaseClaimPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env struct {
+			Type    string            `json:"type"`
+			Payload LeaseClaimPayload `json:"payload"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&env)
+		if env.Type == EnvelopeLease {
+			mu.Lock()
+			claims = append(claims, env.Payload)
+			mu.Unlock()
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	m := newLeaseTestManager(server.URL, "a-agent", now)
+	m.active = true
+	if _, ok := m.HoldsLease("scheduler", now); ok {
+		t.Fatal("no term claimed yet")
+	}
+	m.maintainLeases(context.Background(), now)
+	m.HandleLease(leaseClaim("a-agent", 1, now, m.leaseTTL()))
+	m.maintainLeases(context.Background(), now.Add(time.Second))
+	m.releaseLeases(context.Background(), now.Add(2*time.Second))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(claims) != 3 {
+		t.Fatalf("expected claim, renewal and release, got %+v", claims)
+	}
+	if claims[0].Token != 1 || claims[1].Token != 1 || claims[1].TTLMs == 0 || claims[2].TTLMs != 0 {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+}
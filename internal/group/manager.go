@@ -29,6 +29,12 @@ type Manager struct {
 	active    bool
 	activeMu  sync.RWMutex
 	cancelHB  context.CancelFunc
+
+	// Capability leases (see lease.go).
+	leases    map[string]leaseState
+	campaigns map[string]bool
+	joinedAt  time.Time
+	leaseMu   sync.Mutex
 }
 
 // NewManager creates a new group manager.
@@ -47,6 +53,8 @@ func NewManager(cfg config.GroupConfig, timeSvc *timeline.TimelineService, ident
 		extTopics: extTopics,
 		topicMgr:  topicMgr,
 		roster:    make(map[string]*GroupMember),
+		leases:    make(map[string]leaseState),
+		campaigns: make(map[string]bool),
 	}
 }
 
@@ -132,6 +140,9 @@ func (m *Manager) Join(ctx context.Context) error {
 	}
 
 	m.active = true
+	m.leaseMu.Lock()
+	m.joinedAt = time.Now()
+	m.leaseMu.Unlock()
 
 	// Start heartbeat
 	hbCtx, cancel := context.WithCancel(context.Background())
@@ -155,6 +166,10 @@ func (m *Manager) Leave(ctx context.Context) error {
 	if m.cancelHB != nil {
 		m.cancelHB()
 	}
+	m.releaseLeases(ctx, time.Now())
+	m.leaseMu.Lock()
+	m.joinedAt = time.Time{}
+	m.leaseMu.Unlock()
 
 	// Announce leave
 	env := &GroupEnvelope{
@@ -266,6 +281,7 @@ func (m *Manager) HandleAnnounce(env *GroupEnvelope) {
 	id := payload.Identity
 	switch payload.Action {
 	case "join", "heartbeat":
+		m.observeLeases(env.Timestamp, payload.Leases)
 		member := &GroupMember{
 			AgentID:      id.AgentID,
 			AgentName:    id.AgentName,
@@ -587,12 +603,16 @@ func (m *Manager) EnsureTopic(ctx context.Context, topicName string) error {
 	return nil
 }
 
-func (m *Manager) startHeartbeat(ctx context.Context) {
-	interval := 30 * time.Second
+func (m *Manager) heartbeatInterval() time.Duration {
 	if m.cfg.PollIntervalMs > 0 {
 		// Heartbeat interval = 15x poll interval (30s default at 2000ms poll)
-		interval = time.Duration(m.cfg.PollIntervalMs*15) * time.Millisecond
+		return time.Duration(m.cfg.PollIntervalMs*15) * time.Millisecond
 	}
+	return 30 * time.Second
+}
+
+func (m *Manager) startHeartbeat(ctx context.Context) {
+	interval := m.heartbeatInterval()
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -607,6 +627,7 @@ func (m *Manager) startHeartbeat(ctx context.Context) {
 			return
 		case <-ticker.C:
 			m.sendHeartbeat(ctx)
+			m.maintainLeases(ctx, time.Now())
 		case <-staleTicker.C:
 			if m.timeline != nil {
 				cutoff := time.Now().Add(-interval * 3)
@@ -630,6 +651,7 @@ func (m *Manager) sendHeartbeat(ctx context.Context) {
 		Payload: AnnouncePayload{
 			Action:   "heartbeat",
 			Identity: m.identity,
+			Leases:   m.leaseViews(),
 		},
 	}
 	if err := m.lfs.ProduceEnvelope(ctx, m.topics.Announce, env); err != nil {
@@ -47,12 +47,36 @@ const (
 	EnvelopeAudit         = "audit"
 	EnvelopeTaskStatus    = "task_status"
 	EnvelopeRoster        = "roster"
+	EnvelopeLease         = "lease"
 )
 
 // AnnouncePayload is sent on join/leave/heartbeat.
 type AnnouncePayload struct {
 	Action   string        `json:"action"` // "join", "leave", "heartbeat"
 	Identity AgentIdentity `json:"identity"`
+	// Leases is the sender's view of the capability leases, so members that
+	// joined after a term started learn its holder and fencing token.
+	Leases []LeaseView `json:"leases,omitempty"`
+}
+
+// LeaseClaimPayload claims, renews or releases a capability lease. Claims
+// are published on the announce topic and applied by every member in log
+// order, including the claimant.
+type LeaseClaimPayload struct {
+	Capability string `json:"capability"`
+	Holder     string `json:"holder"`
+	Token      int64  `json:"token"`  // fencing token; a new term takes the next one
+	TTLMs      int64  `json:"ttl_ms"` // 0 releases the lease
+}
+
+// LeaseView is one member's view of a capability lease: the live term and
+// the highest fencing token seen.
+type LeaseView struct {
+	Capability string    `json:"capability"`
+	Holder     string    `json:"holder"`
+	Token      int64     `json:"token"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	MaxToken   int64     `json:"max_token"`
 }
 
 // TaskRequestPayload is a task request from one agent to the group.
@@ -15,6 +15,10 @@ const (
 	TypeVote         = "vote"
 	TypeDecision     = "decision"
 	TypeFact         = "fact"
+	// Snapshot request/response envelopes carry the anti-entropy sync of
+	// facts between claws.
+	TypeSnapshotRequest  = "snapshot_request"
+	TypeSnapshotResponse = "snapshot_response"
 )
 
 type Envelope struct {
@@ -51,7 +55,8 @@ func (e Envelope) ValidateBase() error {
 		return fmt.Errorf("instanceId is required")
 	}
 	switch e.Type {
-	case TypeCapabilities, TypePresence, TypeProposal, TypeVote, TypeDecision, TypeFact:
+	case TypeCapabilities, TypePresence, TypeProposal, TypeVote, TypeDecision, TypeFact,
+		TypeSnapshotRequest, TypeSnapshotResponse:
 		return nil
 	default:
 		return fmt.Errorf("unsupported type: %s", e.Type)
@@ -64,6 +69,9 @@ type ProposalPayload struct {
 	Title      string   `json:"title"`
 	Statement  string   `json:"statement"`
 	Tags       []string `json:"tags,omitempty"`
+	// Fact, when set, is written to the group's facts once the proposal
+	// is approved.
+	Fact *ProposedFact `json:"fact,omitempty"`
 }
 
 func (p ProposalPayload) Validate() error {
@@ -76,13 +84,42 @@ func (p ProposalPayload) Validate() error {
 	if strings.TrimSpace(p.Statement) == "" {
 		return fmt.Errorf("statement is required")
 	}
+	if p.Fact != nil {
+		return p.Fact.Validate()
+	}
+	return nil
+}
+
+// ProposedFact is the subject/predicate/object a proposal asks the group
+// to agree on.
+type ProposedFact struct {
+	Subject   string `json:"subject"`
+	Predicate string `json:"predicate"`
+	Object    string `json:"object"`
+}
+
+func (f ProposedFact) Validate() error {
+	if strings.TrimSpace(f.Subject) == "" || strings.TrimSpace(f.Predicate) == "" || strings.TrimSpace(f.Object) == "" {
+		return fmt.Errorf("subject/predicate/object are required")
+	}
 	return nil
 }
 
+// Statement renders the fact as a one-line proposal statement.
+func (f ProposedFact) Statement() string {
+	return strings.TrimSpace(f.Subject) + " " + strings.TrimSpace(f.Predicate) + " " + strings.TrimSpace(f.Object)
+}
+
 type VotePayload struct {
 	ProposalID string `json:"proposalId"`
 	Vote       string `json:"vote"` // yes|no
 	Reason     string `json:"reason,omitempty"`
+
+	// Role, ZoneID and Expertise describe the voter for weighted voting.
+	// Expertise is the voter's score (0..1) for the proposal's tags.
+	Role      string  `json:"role,omitempty"`
+	ZoneID    string  `json:"zoneId,omitempty"`
+	Expertise float64 `json:"expertise,omitempty"`
 }
 
 func (p VotePayload) Validate() error {
@@ -91,10 +128,13 @@ func (p VotePayload) Validate() error {
 	}
 	switch strings.ToLower(strings.TrimSpace(p.Vote)) {
 	case "yes", "no":
-		return nil
 	default:
 		return fmt.Errorf("vote must be yes|no")
 	}
+	if p.Expertise < 0 || p.Expertise > 1 {
+		return fmt.Errorf("expertise must be between 0 and 1")
+	}
+	return nil
 }
 
 type DecisionPayload struct {
@@ -103,6 +143,11 @@ type DecisionPayload struct {
 	Yes        int    `json:"yes"`
 	No         int    `json:"no"`
 	Reason     string `json:"reason,omitempty"`
+
+	// YesWeight, NoWeight and Rationale carry the weighted tally.
+	YesWeight float64 `json:"yesWeight,omitempty"`
+	NoWeight  float64 `json:"noWeight,omitempty"`
+	Rationale string  `json:"rationale,omitempty"`
 }
 
 func (p DecisionPayload) Validate() error {
@@ -114,7 +159,7 @@ func (p DecisionPayload) Validate() error {
 	default:
 		return fmt.Errorf("outcome must be approved|rejected|expired")
 	}
-	if p.Yes < 0 || p.No < 0 {
+	if p.Yes < 0 || p.No < 0 || p.YesWeight < 0 || p.NoWeight < 0 {
 		return fmt.Errorf("yes/no must be >= 0")
 	}
 	return nil
@@ -152,3 +197,59 @@ func (p FactPayload) Validate() error {
 	}
 	return nil
 }
+
+// FactDigest identifies the state of one fact: its version and a hash of
+// subject, predicate and object.
+type FactDigest struct {
+	FactID  string `json:"factId"`
+	Version int    `json:"version"`
+	Hash    string `json:"hash"`
+}
+
+// SnapshotRequestPayload asks peers for the facts of a group the sender is
+// missing or behind on. Facts is the sender's digest.
+type SnapshotRequestPayload struct {
+	RequestID string       `json:"requestId"`
+	Group     string       `json:"group"`
+	Root      string       `json:"root"`
+	Facts     []FactDigest `json:"facts"`
+}
+
+func (p SnapshotRequestPayload) Validate() error {
+	if strings.TrimSpace(p.RequestID) == "" {
+		return fmt.Errorf("requestId is required")
+	}
+	if strings.TrimSpace(p.Group) == "" {
+		return fmt.Errorf("group is required")
+	}
+	return nil
+}
+
+// SnapshotResponsePayload answers a snapshot request with the responder's
+// digest and the fact versions the requester lacks, oldest first per fact.
+type SnapshotResponsePayload struct {
+	RequestID       string        `json:"requestId"`
+	Group           string        `json:"group"`
+	RequesterClawID string        `json:"requesterClawId"`
+	Root            string        `json:"root"`
+	Digest          []FactDigest  `json:"digest"`
+	Facts           []FactPayload `json:"facts,omitempty"`
+}
+
+func (p SnapshotResponsePayload) Validate() error {
+	if strings.TrimSpace(p.RequestID) == "" {
+		return fmt.Errorf("requestId is required")
+	}
+	if strings.TrimSpace(p.Group) == "" {
+		return fmt.Errorf("group is required")
+	}
+	if strings.TrimSpace(p.RequesterClawID) == "" {
+		return fmt.Errorf("requesterClawId is required")
+	}
+	for _, f := range p.Facts {
+		if err := f.Validate(); err != nil {
+			return fmt.Errorf("fact %s: %w", f.FactID, err)
+		}
+	}
+	return nil
+}
@@ -49,6 +49,21 @@ func EvaluateFactApply(existing *FactState, incoming FactPayload) FactApplyResul
 	return FactApplyResult{Status: FactApplyConflict, Reason: fmt.Sprintf("version_gap_%d_to_%d", existing.Version, incoming.Version)}
 }
 
+// EvaluateSnapshotApply is EvaluateFactApply for versions replayed from a
+// peer's snapshot: a forward version gap is accepted, since the peer holds
+// the accepted history the local claw missed.
+func EvaluateSnapshotApply(existing *FactState, incoming FactPayload) FactApplyResult {
+	result := EvaluateFactApply(existing, incoming)
+	current := 0
+	if existing != nil {
+		current = existing.Version
+	}
+	if result.Status == FactApplyConflict && incoming.Version > current+1 {
+		return FactApplyResult{Status: FactApplyAccepted, Reason: "snapshot_catch_up"}
+	}
+	return result
+}
+
 func sameFactContent(existing *FactState, incoming FactPayload) bool {
 	if existing == nil {
 		return false
@@ -0,0 +1,67 @@
+package knowledge
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+var rankStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "was": true, "with": true,
+	"what": true, "who": true, "how": true, "does": true, "this": true, "that": true,
+	"from": true, "our": true, "you": true, "can": true, "about": true, "which": true,
+}
+
+// RankFacts orders facts by the terms they share with query, returning at
+// most max facts. Subject matches count double; facts sharing no term are
+// dropped and ties keep the input order.
+func RankFacts(facts []timeline.KnowledgeFactRecord, query string, max int) []timeline.KnowledgeFactRecord {
+	terms := rankTerms(query)
+	if len(terms) == 0 || max <= 0 {
+		return nil
+	}
+	type scored struct {
+		fact  timeline.KnowledgeFactRecord
+		score int
+	}
+	var ranked []scored
+	for _, f := range facts {
+		score := 0
+		for t := range rankTerms(f.Subject) {
+			if terms[t] {
+				score += 2
+			}
+		}
+		for t := range rankTerms(f.Predicate + " " + f.Object) {
+			if terms[t] {
+				score++
+			}
+		}
+		if score > 0 {
+			ranked = append(ranked, scored{fact: f, score: score})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	if len(ranked) > max {
+		ranked = ranked[:max]
+	}
+	out := make([]timeline.KnowledgeFactRecord, len(ranked))
+	for i, r := range ranked {
+		out[i] = r.fact
+	}
+	return out
+}
+
+func rankTerms(text string) map[string]bool {
+	terms := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(w) >= 3 && !rankStopWords[w] {
+			terms[w] = true
+		}
+	}
+	return terms
+}
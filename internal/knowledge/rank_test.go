@@ -0,0 +1,30 @@
+package knowledge
+
+import (
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestRankFactsByTermOverlap(t *testing.T) {
+	facts := []timeline.KnowledgeFactRecord{
+		{FactID: "f1", Subject: "search", Predicate: "owned_by", Object: "team-discovery"},
+		{FactID: "f2", Subject: "payments-api", Predicate: "runbook", Object: "wiki/payments"},
+		{FactID: "f3", Subject: "ledger", Predicate: "depends_on", Object: "payments-api"},
+		{FactID: "f4", Subject: "payments-api", Predicate: "owned_by", Object: "team-billing"},
+	}
+	got := RankFacts(facts, "Who owns the payments API?", 3)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 facts, got %+v", got)
+	}
+	// Subject matches outrank object matches; ties keep input order.
+	if got[0].FactID != "f2" || got[1].FactID != "f4" || got[2].FactID != "f3" {
+		t.Fatalf("unexpected order: %s %s %s", got[0].FactID, got[1].FactID, got[2].FactID)
+	}
+	if got := RankFacts(facts, "the weather today", 5); len(got) != 0 {
+		t.Fatalf("expected no facts for unrelated query, got %+v", got)
+	}
+	if got := RankFacts(facts, "payments", 0); got != nil {
+		t.Fatalf("expected nil for max=0, got %+v", got)
+	}
+}
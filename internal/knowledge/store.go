@@ -0,0 +1,132 @@
+package knowledge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// FactID derives the ID of the fact a group holds for subject and predicate,
+// so that every update of "subject predicate" versions the same fact.
+func FactID(group, subject, predicate string) string {
+	key := strings.Join([]string{
+		strings.ToLower(strings.TrimSpace(group)),
+		strings.ToLower(strings.TrimSpace(subject)),
+		strings.ToLower(strings.TrimSpace(predicate)),
+	}, "\x00")
+	sum := sha256.Sum256([]byte(key))
+	return "kf-" + hex.EncodeToString(sum[:8])
+}
+
+// NextFact builds the payload that sets f as the next version of its fact in
+// group.
+func NextFact(tl *timeline.TimelineService, group string, f ProposedFact, source string) (FactPayload, error) {
+	if err := f.Validate(); err != nil {
+		return FactPayload{}, err
+	}
+	p := FactPayload{
+		FactID:    FactID(group, f.Subject, f.Predicate),
+		Group:     strings.TrimSpace(group),
+		Subject:   strings.TrimSpace(f.Subject),
+		Predicate: strings.TrimSpace(f.Predicate),
+		Object:    strings.TrimSpace(f.Object),
+		Version:   1,
+		Source:    source,
+	}
+	current, err := tl.GetKnowledgeFactLatest(p.FactID)
+	if err != nil {
+		return FactPayload{}, err
+	}
+	if current != nil {
+		p.Version = current.Version + 1
+	}
+	return p, nil
+}
+
+// ApplyFact stores p as the latest state of its fact when EvaluateFactApply
+// accepts it.
+func ApplyFact(tl *timeline.TimelineService, p FactPayload) (FactApplyResult, error) {
+	return applyFact(tl, p, EvaluateFactApply)
+}
+
+func applyFact(tl *timeline.TimelineService, p FactPayload, evaluate func(*FactState, FactPayload) FactApplyResult) (FactApplyResult, error) {
+	if err := p.Validate(); err != nil {
+		return FactApplyResult{}, fmt.Errorf("validate fact payload: %w", err)
+	}
+	current, err := tl.GetKnowledgeFactLatest(p.FactID)
+	if err != nil {
+		return FactApplyResult{}, err
+	}
+	var existing *FactState
+	if current != nil {
+		existing = &FactState{
+			FactID:    current.FactID,
+			Subject:   current.Subject,
+			Predicate: current.Predicate,
+			Object:    current.Object,
+			Version:   current.Version,
+		}
+	}
+	result := evaluate(existing, p)
+	if result.Status != FactApplyAccepted {
+		return result, nil
+	}
+	tags, _ := json.Marshal(p.Tags)
+	if p.Tags == nil {
+		tags = []byte("[]")
+	}
+	if err := tl.UpsertKnowledgeFactLatest(&timeline.KnowledgeFactRecord{
+		FactID:     p.FactID,
+		GroupName:  p.Group,
+		Subject:    p.Subject,
+		Predicate:  p.Predicate,
+		Object:     p.Object,
+		Version:    p.Version,
+		Source:     p.Source,
+		ProposalID: p.ProposalID,
+		DecisionID: p.DecisionID,
+		Tags:       string(tags),
+	}); err != nil {
+		return FactApplyResult{}, err
+	}
+	return result, nil
+}
+
+// ApplyApprovedProposal writes the fact carried by an approved proposal.
+// It returns nil when the proposal is not approved, carries no fact, or its
+// fact was already applied or is rejected by the version policy.
+func ApplyApprovedProposal(tl *timeline.TimelineService, proposalID string) (*FactPayload, error) {
+	prop, err := tl.GetKnowledgeProposal(proposalID)
+	if err != nil || prop == nil {
+		return nil, err
+	}
+	if prop.Status != VoteStatusApproved || strings.TrimSpace(prop.Fact) == "" {
+		return nil, nil
+	}
+	var f ProposedFact
+	if err := json.Unmarshal([]byte(prop.Fact), &f); err != nil {
+		return nil, fmt.Errorf("proposal %s fact: %w", proposalID, err)
+	}
+	current, err := tl.GetKnowledgeFactLatest(FactID(prop.GroupName, f.Subject, f.Predicate))
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && current.ProposalID == prop.ProposalID {
+		return nil, nil
+	}
+	p, err := NextFact(tl, prop.GroupName, f, "proposal:"+prop.ProposalID)
+	if err != nil {
+		return nil, err
+	}
+	p.ProposalID = prop.ProposalID
+	_ = json.Unmarshal([]byte(prop.Tags), &p.Tags)
+	result, err := ApplyFact(tl, p)
+	if err != nil || result.Status != FactApplyAccepted {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,93 @@
+package knowledge
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func newStoreTimeline(t *testing.T) *timeline.TimelineService {
+	t.Helper()
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	t.Cleanup(func() { _ = tl.Close() })
+	return tl
+}
+
+func TestFactIDIsStablePerSubjectAndPredicate(t *testing.T) {
+	a := FactID("g1", "Payments-API", "owned_by")
+	if b := FactID("g1", " payments-api ", "OWNED_BY"); a != b {
+		t.Fatalf("expected case/space-insensitive ID, got %s vs %s", a, b)
+	}
+	if FactID("g2", "payments-api", "owned_by") == a || FactID("g1", "payments-api", "runbook") == a {
+		t.Fatal("expected different IDs for different group or predicate")
+	}
+}
+
+func TestNextFactVersionsExistingFact(t *testing.T) {
+	tl := newStoreTimeline(t)
+	f := ProposedFact{Subject: "payments-api", Predicate: "owned_by", Object: "team-a"}
+	p, err := NextFact(tl, "g1", f, "test")
+	if err != nil || p.Version != 1 {
+		t.Fatalf("expected v1, got %+v %v", p, err)
+	}
+	if res, err := ApplyFact(tl, p); err != nil || res.Status != FactApplyAccepted {
+		t.Fatalf("apply v1: %+v %v", res, err)
+	}
+	f.Object = "team-b"
+	p, err = NextFact(tl, "g1", f, "test")
+	if err != nil || p.Version != 2 {
+		t.Fatalf("expected v2, got %+v %v", p, err)
+	}
+	if res, err := ApplyFact(tl, p); err != nil || res.Status != FactApplyAccepted {
+		t.Fatalf("apply v2: %+v %v", res, err)
+	}
+	// Replaying the old version is rejected by the version policy.
+	p.Version, p.Object = 1, "team-a"
+	if res, err := ApplyFact(tl, p); err != nil || res.Status != FactApplyConflict {
+		t.Fatalf("expected conflict for regression, got %+v %v", res, err)
+	}
+	got, _ := tl.GetKnowledgeFactLatest(p.FactID)
+	if got == nil || got.Version != 2 || got.Object != "team-b" {
+		t.Fatalf("unexpected latest fact %+v", got)
+	}
+}
+
+func TestApplyApprovedProposalWritesFactOnce(t *testing.T) {
+	tl := newStoreTimeline(t)
+	if err := tl.CreateKnowledgeProposal(&timeline.KnowledgeProposalRecord{
+		ProposalID:         "kp-1",
+		GroupName:          "g1",
+		Statement:          "payments-api owned_by team-billing",
+		Tags:               `["ops"]`,
+		ProposerClawID:     "claw-a",
+		ProposerInstanceID: "inst-a",
+		Fact:               `{"subject":"payments-api","predicate":"owned_by","object":"team-billing"}`,
+	}); err != nil {
+		t.Fatalf("create proposal: %v", err)
+	}
+	if p, err := ApplyApprovedProposal(tl, "kp-1"); err != nil || p != nil {
+		t.Fatalf("pending proposal must not apply, got %+v %v", p, err)
+	}
+	if err := tl.UpdateKnowledgeProposalDecision("kp-1", VoteStatusApproved, 2, 0, ""); err != nil {
+		t.Fatalf("decide: %v", err)
+	}
+	p, err := ApplyApprovedProposal(tl, "kp-1")
+	if err != nil || p == nil {
+		t.Fatalf("expected fact to apply, got %+v %v", p, err)
+	}
+	got, _ := tl.GetKnowledgeFactLatest(FactID("g1", "payments-api", "owned_by"))
+	if got == nil || got.Object != "team-billing" || got.Version != 1 || got.ProposalID != "kp-1" || got.Tags != `["ops"]` {
+		t.Fatalf("unexpected fact %+v", got)
+	}
+	if p, err := ApplyApprovedProposal(tl, "kp-1"); err != nil || p != nil {
+		t.Fatalf("second apply must be a no-op, got %+v %v", p, err)
+	}
+	got, _ = tl.GetKnowledgeFactLatest(FactID("g1", "payments-api", "owned_by"))
+	if got.Version != 1 {
+		t.Fatalf("expected version to stay 1, got %d", got.Version)
+	}
+}
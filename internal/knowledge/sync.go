@@ -0,0 +1,280 @@
+package knowledge
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// MaxSnapshotFacts caps the facts of a group covered by one digest.
+const MaxSnapshotFacts = 10000
+
+// HashFact hashes the content of a fact for digests.
+func HashFact(subject, predicate, object string) string {
+	sum := sha256.Sum256([]byte(subject + "\x00" + predicate + "\x00" + object))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Digest returns the per-fact version vector of facts, sorted by fact ID,
+// and its root hash. Two claws have the same root exactly when they hold
+// the same versions with the same content.
+func Digest(facts []FactState) (root string, entries []FactDigest) {
+	entries = make([]FactDigest, 0, len(facts))
+	for _, f := range facts {
+		entries = append(entries, FactDigest{
+			FactID:  f.FactID,
+			Version: f.Version,
+			Hash:    HashFact(f.Subject, f.Predicate, f.Object),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].FactID < entries[j].FactID })
+	h := sha256.New()
+	for _, e := range entries {
+		fmt.Fprintf(h, "%s:%d:%s\n", e.FactID, e.Version, e.Hash)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16]), entries
+}
+
+// DigestDiff lists the facts that differ between a local and a remote
+// digest, from the local claw's view.
+type DigestDiff struct {
+	// Missing facts exist only remotely.
+	Missing []string `json:"missing,omitempty"`
+	// Behind facts have a newer version remotely.
+	Behind []string `json:"behind,omitempty"`
+	// Ahead facts have a newer version locally.
+	Ahead []string `json:"ahead,omitempty"`
+	// Extra facts exist only locally.
+	Extra []string `json:"extra,omitempty"`
+	// Diverged facts have the same version with different content.
+	Diverged []string `json:"diverged,omitempty"`
+}
+
+// InSync reports whether both digests are equal.
+func (d DigestDiff) InSync() bool {
+	return len(d.Missing)+len(d.Behind)+len(d.Ahead)+len(d.Extra)+len(d.Diverged) == 0
+}
+
+// CompareDigests compares a local and a remote digest.
+func CompareDigests(local, remote []FactDigest) DigestDiff {
+	var d DigestDiff
+	remoteByID := make(map[string]FactDigest, len(remote))
+	for _, r := range remote {
+		remoteByID[r.FactID] = r
+	}
+	seen := make(map[string]bool, len(local))
+	for _, l := range local {
+		seen[l.FactID] = true
+		r, ok := remoteByID[l.FactID]
+		switch {
+		case !ok:
+			d.Extra = append(d.Extra, l.FactID)
+		case r.Version > l.Version:
+			d.Behind = append(d.Behind, l.FactID)
+		case r.Version < l.Version:
+			d.Ahead = append(d.Ahead, l.FactID)
+		case r.Hash != l.Hash:
+			d.Diverged = append(d.Diverged, l.FactID)
+		}
+	}
+	for _, r := range remote {
+		if !seen[r.FactID] {
+			d.Missing = append(d.Missing, r.FactID)
+		}
+	}
+	sort.Strings(d.Missing)
+	return d
+}
+
+// LocalDigest returns the digest of the facts of group.
+func LocalDigest(tl *timeline.TimelineService, group string) (string, []FactDigest, error) {
+	recs, err := tl.SearchKnowledgeFacts(timeline.KnowledgeFactQuery{Group: group, Limit: MaxSnapshotFacts})
+	if err != nil {
+		return "", nil, err
+	}
+	states := make([]FactState, 0, len(recs))
+	for _, r := range recs {
+		states = append(states, FactState{
+			FactID:    r.FactID,
+			Subject:   r.Subject,
+			Predicate: r.Predicate,
+			Object:    r.Object,
+			Version:   r.Version,
+		})
+	}
+	root, entries := Digest(states)
+	return root, entries, nil
+}
+
+// NewSnapshotRequest builds the snapshot request envelope for group,
+// carrying the local digest.
+func NewSnapshotRequest(tl *timeline.TimelineService, group, clawID, instanceID string, now time.Time) (Envelope, error) {
+	group = strings.TrimSpace(group)
+	if group == "" {
+		return Envelope{}, fmt.Errorf("knowledge group is required")
+	}
+	root, entries, err := LocalDigest(tl, group)
+	if err != nil {
+		return Envelope{}, err
+	}
+	requestID := "ks-" + newSyncID()
+	return Envelope{
+		SchemaVersion:  CurrentSchemaVersion,
+		Type:           TypeSnapshotRequest,
+		TraceID:        requestID,
+		Timestamp:      now,
+		IdempotencyKey: "knowledge:snapshot_request:" + requestID,
+		ClawID:         clawID,
+		InstanceID:     instanceID,
+		Payload: SnapshotRequestPayload{
+			RequestID: requestID,
+			Group:     group,
+			Root:      root,
+			Facts:     entries,
+		},
+	}, nil
+}
+
+// NewSnapshotResponse answers req from requesterClawID with the local digest
+// and every recorded version the requester is missing. It also returns the
+// comparison of the local digest with the requester's.
+func NewSnapshotResponse(tl *timeline.TimelineService, req SnapshotRequestPayload, requesterClawID, clawID, instanceID string, now time.Time) (Envelope, DigestDiff, error) {
+	root, local, err := LocalDigest(tl, req.Group)
+	if err != nil {
+		return Envelope{}, DigestDiff{}, err
+	}
+	diff := CompareDigests(local, req.Facts)
+	remoteVersion := make(map[string]int, len(req.Facts))
+	for _, r := range req.Facts {
+		remoteVersion[r.FactID] = r.Version
+	}
+	var facts []FactPayload
+	for _, id := range append(append([]string{}, diff.Extra...), diff.Ahead...) {
+		versions, err := tl.ListKnowledgeFactVersions(id, remoteVersion[id])
+		if err != nil {
+			return Envelope{}, DigestDiff{}, err
+		}
+		if len(versions) == 0 {
+			latest, err := tl.GetKnowledgeFactLatest(id)
+			if err != nil {
+				return Envelope{}, DigestDiff{}, err
+			}
+			if latest == nil {
+				continue
+			}
+			versions = append(versions, *latest)
+		}
+		for _, v := range versions {
+			facts = append(facts, factPayloadFromRecord(v))
+		}
+	}
+	return Envelope{
+		SchemaVersion:  CurrentSchemaVersion,
+		Type:           TypeSnapshotResponse,
+		TraceID:        req.RequestID,
+		Timestamp:      now,
+		IdempotencyKey: "knowledge:snapshot_response:" + req.RequestID + ":" + clawID,
+		ClawID:         clawID,
+		InstanceID:     instanceID,
+		Payload: SnapshotResponsePayload{
+			RequestID:       req.RequestID,
+			Group:           req.Group,
+			RequesterClawID: requesterClawID,
+			Root:            root,
+			Digest:          local,
+			Facts:           facts,
+		},
+	}, diff, nil
+}
+
+// SyncResult counts the replayed versions of a snapshot response by outcome.
+type SyncResult struct {
+	Applied   int `json:"applied"`
+	Stale     int `json:"stale"`
+	Conflicts int `json:"conflicts"`
+}
+
+// ApplySnapshotResponse replays the fact versions of resp in version order.
+// Forward version gaps are accepted (EvaluateSnapshotApply).
+func ApplySnapshotResponse(tl *timeline.TimelineService, resp SnapshotResponsePayload) (SyncResult, error) {
+	facts := append([]FactPayload{}, resp.Facts...)
+	sort.SliceStable(facts, func(i, j int) bool {
+		if facts[i].FactID != facts[j].FactID {
+			return facts[i].FactID < facts[j].FactID
+		}
+		return facts[i].Version < facts[j].Version
+	})
+	var res SyncResult
+	for _, f := range facts {
+		if strings.TrimSpace(f.Group) != strings.TrimSpace(resp.Group) {
+			res.Conflicts++
+			continue
+		}
+		result, err := applyFact(tl, f, EvaluateSnapshotApply)
+		if err != nil {
+			return res, err
+		}
+		switch result.Status {
+		case FactApplyAccepted:
+			res.Applied++
+		case FactApplyStale:
+			res.Stale++
+		default:
+			res.Conflicts++
+		}
+	}
+	return res, nil
+}
+
+// RecordPeerDigest compares the local digest of group with a peer's and
+// stores the result for the divergence report.
+func RecordPeerDigest(tl *timeline.TimelineService, peerClawID, group, peerRoot string, peer []FactDigest) (DigestDiff, error) {
+	_, local, err := LocalDigest(tl, group)
+	if err != nil {
+		return DigestDiff{}, err
+	}
+	diff := CompareDigests(local, peer)
+	err = tl.UpsertKnowledgeSyncPeer(&timeline.KnowledgeSyncPeerRecord{
+		ClawID:    peerClawID,
+		GroupName: group,
+		Root:      peerRoot,
+		FactCount: len(peer),
+		Missing:   len(diff.Missing),
+		Behind:    len(diff.Behind),
+		Ahead:     len(diff.Ahead),
+		Extra:     len(diff.Extra),
+		Diverged:  len(diff.Diverged),
+	})
+	return diff, err
+}
+
+func factPayloadFromRecord(r timeline.KnowledgeFactRecord) FactPayload {
+	p := FactPayload{
+		FactID:     r.FactID,
+		Group:      r.GroupName,
+		Subject:    r.Subject,
+		Predicate:  r.Predicate,
+		Object:     r.Object,
+		Version:    r.Version,
+		Source:     r.Source,
+		ProposalID: r.ProposalID,
+		DecisionID: r.DecisionID,
+	}
+	_ = json.Unmarshal([]byte(r.Tags), &p.Tags)
+	return p
+}
+
+func newSyncID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err == nil {
+		return hex.EncodeToString(b[:])
+	}
+	return fmt.Sprintf("%d", time.Now().UnixNano())
+}
@@ -0,0 +1,161 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestDigestAndCompare(t *testing.T) {
+	rootA, a := Digest([]FactState{
+		{FactID: "f2", Subject: "s", Predicate: "p", Object: "o2", Version: 2},
+		{FactID: "f1", Subject: "s", Predicate: "p", Object: "o1", Version: 1},
+		{FactID: "f3", Subject: "s", Predicate: "p", Object: "x", Version: 1},
+		{FactID: "f4", Subject: "s", Predicate: "p", Object: "o4", Version: 3},
+	})
+	if a[0].FactID != "f1" || a[3].FactID != "f4" {
+		t.Fatalf("expected digest sorted by fact ID, got %+v", a)
+	}
+	rootB, b := Digest([]FactState{
+		{FactID: "f1", Subject: "s", Predicate: "p", Object: "o1", Version: 1},
+		{FactID: "f2", Subject: "s", Predicate: "p", Object: "o3", Version: 3},
+		{FactID: "f3", Subject: "s", Predicate: "p", Object: "y", Version: 1},
+		{FactID: "f4", Subject: "s", Predicate: "p", Object: "o4", Version: 1},
+		{FactID: "f5", Subject: "s", Predicate: "p", Object: "o5", Version: 1},
+	})
+	if rootA == rootB {
+		t.Fatal("expected different roots")
+	}
+	if again, _ := Digest([]FactState{{FactID: "f1", Subject: "s", Predicate: "p", Object: "o1", Version: 1}}); again == rootA {
+		t.Fatal("expected root to depend on all facts")
+	}
+
+	d := CompareDigests(a, b)
+	if d.InSync() {
+		t.Fatal("expected divergence")
+	}
+	if len(d.Behind) != 1 || d.Behind[0] != "f2" ||
+		len(d.Diverged) != 1 || d.Diverged[0] != "f3" ||
+		len(d.Ahead) != 1 || d.Ahead[0] != "f4" ||
+		len(d.Missing) != 1 || d.Missing[0] != "f5" || len(d.Extra) != 0 {
+		t.Fatalf("unexpected diff %+v", d)
+	}
+	if !CompareDigests(a, a).InSync() {
+		t.Fatal("expected equal digests to be in sync")
+	}
+}
+
+func TestEvaluateSnapshotApplyAcceptsForwardGaps(t *testing.T) {
+	ex := &FactState{FactID: "f1", Subject: "s", Predicate: "p", Object: "o1", Version: 1}
+	in := FactPayload{FactID: "f1", Group: "g", Subject: "s", Predicate: "p", Object: "o4", Version: 4, Source: "x"}
+	if got := EvaluateFactApply(ex, in); got.Status != FactApplyConflict {
+		t.Fatalf("expected regular apply to reject the gap, got %+v", got)
+	}
+	if got := EvaluateSnapshotApply(ex, in); got.Status != FactApplyAccepted || got.Reason != "snapshot_catch_up" {
+		t.Fatalf("expected snapshot catch-up, got %+v", got)
+	}
+	if got := EvaluateSnapshotApply(nil, in); got.Status != FactApplyAccepted {
+		t.Fatalf("expected snapshot to create a fact at v4, got %+v", got)
+	}
+	in.Version, in.Object = 1, "other"
+	if got := EvaluateSnapshotApply(ex, in); got.Status != FactApplyConflict {
+		t.Fatalf("expected diverged content to stay a conflict, got %+v", got)
+	}
+}
+
+func TestSnapshotRequestResponseReplaysMissingVersions(t *testing.T) {
+	ahead := newStoreTimeline(t)
+	behind := newStoreTimeline(t)
+	f := ProposedFact{Subject: "payments-api", Predicate: "owned_by"}
+	for i, owner := range []string{"team-a", "team-b", "team-c"} {
+		f.Object = owner
+		p, err := NextFact(ahead, "g1", f, "test")
+		if err != nil {
+			t.Fatalf("next fact: %v", err)
+		}
+		if _, err := ApplyFact(ahead, p); err != nil {
+			t.Fatalf("apply v%d: %v", i+1, err)
+		}
+		if i == 0 {
+			if _, err := ApplyFact(behind, p); err != nil {
+				t.Fatalf("apply v1 on behind: %v", err)
+			}
+		}
+	}
+	other, _ := NextFact(ahead, "g1", ProposedFact{Subject: "search", Predicate: "owned_by", Object: "team-d"}, "test")
+	if _, err := ApplyFact(ahead, other); err != nil {
+		t.Fatalf("apply other: %v", err)
+	}
+
+	now := time.Now()
+	reqEnv, err := NewSnapshotRequest(behind, "g1", "claw-b", "inst-b", now)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if err := reqEnv.ValidateBase(); err != nil {
+		t.Fatalf("invalid request envelope: %v", err)
+	}
+	req := reqEnv.Payload.(SnapshotRequestPayload)
+	if len(req.Facts) != 1 || req.Facts[0].Version != 1 {
+		t.Fatalf("unexpected request digest %+v", req.Facts)
+	}
+
+	respEnv, diff, err := NewSnapshotResponse(ahead, req, "claw-b", "claw-a", "inst-a", now)
+	if err != nil {
+		t.Fatalf("response: %v", err)
+	}
+	if len(diff.Ahead) != 1 || len(diff.Extra) != 1 {
+		t.Fatalf("unexpected responder diff %+v", diff)
+	}
+	// Round-trip through JSON as on the wire.
+	raw, _ := json.Marshal(respEnv.Payload)
+	var resp SnapshotResponsePayload
+	if err := json.Unmarshal(raw, &resp); err != nil || resp.Validate() != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Facts) != 3 {
+		t.Fatalf("expected v2, v3 and the missing fact, got %+v", resp.Facts)
+	}
+
+	res, err := ApplySnapshotResponse(behind, resp)
+	if err != nil {
+		t.Fatalf("apply snapshot: %v", err)
+	}
+	if res.Applied != 3 || res.Conflicts != 0 {
+		t.Fatalf("unexpected sync result %+v", res)
+	}
+	rootA, _, _ := LocalDigest(ahead, "g1")
+	rootB, _, _ := LocalDigest(behind, "g1")
+	if rootA != rootB {
+		t.Fatal("expected digests to converge")
+	}
+	versions, _ := behind.ListKnowledgeFactVersions(FactID("g1", "payments-api", "owned_by"), 0)
+	if len(versions) != 3 {
+		t.Fatalf("expected the full version history to be replayed, got %d", len(versions))
+	}
+
+	diff, err = RecordPeerDigest(behind, "claw-a", "g1", resp.Root, resp.Digest)
+	if err != nil || !diff.InSync() {
+		t.Fatalf("expected in-sync peer record, got %+v %v", diff, err)
+	}
+	peers, _ := behind.ListKnowledgeSyncPeers("g1")
+	if len(peers) != 1 || peers[0].ClawID != "claw-a" || peers[0].FactCount != 2 || peers[0].Root != rootA {
+		t.Fatalf("unexpected peer records %+v", peers)
+	}
+}
+
+func TestApplySnapshotResponseRejectsForeignGroup(t *testing.T) {
+	tl := newStoreTimeline(t)
+	res, err := ApplySnapshotResponse(tl, SnapshotResponsePayload{
+		RequestID: "r", Group: "g1", RequesterClawID: "c",
+		Facts: []FactPayload{{FactID: "f", Group: "g2", Subject: "s", Predicate: "p", Object: "o", Version: 1, Source: "x"}},
+	})
+	if err != nil || res.Conflicts != 1 || res.Applied != 0 {
+		t.Fatalf("expected foreign group fact to be rejected, got %+v %v", res, err)
+	}
+	if facts, _ := tl.SearchKnowledgeFacts(timeline.KnowledgeFactQuery{}); len(facts) != 0 {
+		t.Fatalf("expected no facts, got %+v", facts)
+	}
+}
@@ -2,6 +2,10 @@ package knowledge
 
 import (
 	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -20,6 +24,49 @@ type VotingPolicy struct {
 	QuorumNo      int
 	Timeout       time.Duration
 	AllowSelfVote bool
+
+	// QuorumYesPercent and QuorumNoPercent raise the quorums to a
+	// percentage of the pool size (a default voter weighs 1).
+	QuorumYesPercent int
+	QuorumNoPercent  int
+	// Weights weighs each ballot; the zero value counts one per claw.
+	Weights VoterWeights
+	// VetoRoles reject a proposal on a single no vote from the role.
+	VetoRoles []string
+	// TagPolicies add rules for proposals with matching tags.
+	TagPolicies []TagPolicy
+}
+
+// VoterWeights weighs ballots. A ballot weighs ClawWeights[clawId] when
+// set; otherwise RoleWeights[role] (default 1) times ZoneWeights[zone]
+// (default 1), plus ExpertiseWeight times the voter's expertise (0..1).
+// Keys match case-insensitively.
+type VoterWeights struct {
+	ClawWeights     map[string]float64
+	RoleWeights     map[string]float64
+	ZoneWeights     map[string]float64
+	ExpertiseWeight float64
+}
+
+// TagPolicy applies to proposals carrying a tag matching Tag, either an
+// exact tag or a glob such as "security:*". Matching policies only make
+// the decision stricter.
+type TagPolicy struct {
+	Tag              string
+	QuorumYes        int
+	QuorumYesPercent int
+	// RequireYesRoles each need at least one yes vote before approval.
+	RequireYesRoles []string
+	VetoRoles       []string
+}
+
+// Ballot is one claw's vote with the attributes weighted policies use.
+type Ballot struct {
+	ClawID    string
+	Vote      string // yes|no
+	Role      string
+	ZoneID    string
+	Expertise float64
 }
 
 type VoteDecision struct {
@@ -27,6 +74,11 @@ type VoteDecision struct {
 	Yes    int
 	No     int
 	Reason string
+
+	YesWeight float64
+	NoWeight  float64
+	// Rationale explains the tally, the thresholds and the rules applied.
+	Rationale string
 }
 
 func (p VotingPolicy) Validate() error {
@@ -39,6 +91,30 @@ func (p VotingPolicy) Validate() error {
 	if p.Timeout <= 0 {
 		return fmt.Errorf("timeout must be > 0")
 	}
+	if !validPercent(p.QuorumYesPercent) || !validPercent(p.QuorumNoPercent) {
+		return fmt.Errorf("quorum percent must be between 0 and 100")
+	}
+	if p.Weights.ExpertiseWeight < 0 {
+		return fmt.Errorf("expertise weight must be >= 0")
+	}
+	for _, m := range []map[string]float64{p.Weights.ClawWeights, p.Weights.RoleWeights, p.Weights.ZoneWeights} {
+		for k, w := range m {
+			if w < 0 {
+				return fmt.Errorf("weight for %q must be >= 0", k)
+			}
+		}
+	}
+	for _, tp := range p.TagPolicies {
+		if strings.TrimSpace(tp.Tag) == "" {
+			return fmt.Errorf("tag policy tag is required")
+		}
+		if _, err := path.Match(tp.Tag, ""); err != nil {
+			return fmt.Errorf("tag policy %q: %w", tp.Tag, err)
+		}
+		if tp.QuorumYes < 0 || !validPercent(tp.QuorumYesPercent) {
+			return fmt.Errorf("tag policy %q: invalid quorum", tp.Tag)
+		}
+	}
 	return nil
 }
 
@@ -49,6 +125,9 @@ func (p VotingPolicy) Validate() error {
 // - approved: yes >= QuorumYes and yes > no
 // - rejected: no >= QuorumNo
 // - expired: timeout elapsed without quorum
+//
+// Votes carry no voter attributes, so role and zone weights, veto roles
+// and tag policies do not apply; see EvaluateBallots.
 func EvaluateQuorum(
 	proposerClawID string,
 	poolSize int,
@@ -56,6 +135,29 @@ func EvaluateQuorum(
 	createdAt time.Time,
 	now time.Time,
 	policy VotingPolicy,
+) VoteDecision {
+	ballots := make([]Ballot, 0, len(votes))
+	for clawID, v := range votes {
+		ballots = append(ballots, Ballot{ClawID: clawID, Vote: v})
+	}
+	return EvaluateBallots(proposerClawID, nil, poolSize, ballots, createdAt, now, policy)
+}
+
+// EvaluateBallots applies the EvaluateQuorum rules to weighted ballots of a
+// proposal with tags:
+// - yes/no are compared by weight against the quorums, raised by
+// QuorumYesPercent/QuorumNoPercent and matching tag policies
+// - a no vote from a veto role rejects immediately
+// - approval also needs a yes vote from each role a matching tag policy
+// requires
+func EvaluateBallots(
+	proposerClawID string,
+	tags []string,
+	poolSize int,
+	ballots []Ballot,
+	createdAt time.Time,
+	now time.Time,
+	policy VotingPolicy,
 ) VoteDecision {
 	if err := policy.Validate(); err != nil {
 		return VoteDecision{Status: VoteStatusRejected, Reason: err.Error()}
@@ -67,30 +169,177 @@ func EvaluateQuorum(
 		return VoteDecision{Status: VoteStatusApproved, Reason: "pool below min size"}
 	}
 
-	yes, no := tallyVotes(votes, proposerClawID, policy.AllowSelfVote)
-	if yes >= policy.QuorumYes && yes > no {
-		return VoteDecision{Status: VoteStatusApproved, Yes: yes, No: no}
+	rules := policy.rulesFor(tags, poolSize)
+	sorted := append([]Ballot{}, ballots...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ClawID < sorted[j].ClawID })
+
+	var d VoteDecision
+	var tally []string
+	vetoedBy := ""
+	yesRoles := map[string]bool{}
+	for _, b := range sorted {
+		clawID := strings.TrimSpace(b.ClawID)
+		if !policy.AllowSelfVote && strings.EqualFold(clawID, strings.TrimSpace(proposerClawID)) {
+			continue
+		}
+		vote := strings.ToLower(strings.TrimSpace(b.Vote))
+		if vote != "yes" && vote != "no" {
+			continue
+		}
+		role := strings.ToLower(strings.TrimSpace(b.Role))
+		w := policy.Weights.weight(b)
+		if vote == "yes" {
+			d.Yes++
+			d.YesWeight += w
+			if role != "" {
+				yesRoles[role] = true
+			}
+		} else {
+			d.No++
+			d.NoWeight += w
+			if vetoedBy == "" && role != "" && rules.veto[role] {
+				vetoedBy = clawID + " (" + role + ")"
+			}
+		}
+		entry := clawID + " " + vote + " " + formatWeight(w)
+		if role != "" {
+			entry += " [" + role + "]"
+		}
+		tally = append(tally, entry)
+	}
+	d.YesWeight = roundWeight(d.YesWeight)
+	d.NoWeight = roundWeight(d.NoWeight)
+
+	var awaiting []string
+	for _, role := range rules.requireYes {
+		if !yesRoles[role] {
+			awaiting = append(awaiting, role)
+		}
 	}
-	if no >= policy.QuorumNo {
-		return VoteDecision{Status: VoteStatusRejected, Yes: yes, No: no}
+
+	switch {
+	case vetoedBy != "":
+		d.Status = VoteStatusRejected
+		d.Reason = "veto by " + vetoedBy
+	case d.YesWeight >= rules.yesNeed && d.YesWeight > d.NoWeight && len(awaiting) == 0:
+		d.Status = VoteStatusApproved
+	case d.NoWeight >= rules.noNeed:
+		d.Status = VoteStatusRejected
+	case !createdAt.IsZero() && now.Sub(createdAt) >= policy.Timeout:
+		d.Status = VoteStatusExpired
+		d.Reason = "voting timeout"
+	default:
+		d.Status = VoteStatusPending
+	}
+
+	parts := []string{fmt.Sprintf("yes %s/%s, no %s/%s",
+		formatWeight(d.YesWeight), formatWeight(rules.yesNeed), formatWeight(d.NoWeight), formatWeight(rules.noNeed))}
+	if len(tally) > 0 {
+		parts = append(parts, "votes: "+strings.Join(tally, ", "))
 	}
-	if !createdAt.IsZero() && now.Sub(createdAt) >= policy.Timeout {
-		return VoteDecision{Status: VoteStatusExpired, Yes: yes, No: no, Reason: "voting timeout"}
+	if len(rules.matched) > 0 {
+		parts = append(parts, "tag policies: "+strings.Join(rules.matched, ", "))
 	}
-	return VoteDecision{Status: VoteStatusPending, Yes: yes, No: no}
+	if vetoedBy != "" {
+		parts = append(parts, "vetoed by "+vetoedBy)
+	} else if len(awaiting) > 0 {
+		parts = append(parts, "awaiting yes from "+strings.Join(awaiting, ", "))
+	}
+	d.Rationale = strings.Join(parts, "; ")
+	return d
 }
 
-func tallyVotes(votes map[string]string, proposerClawID string, allowSelf bool) (yes int, no int) {
-	for clawID, v := range votes {
-		if !allowSelf && strings.EqualFold(strings.TrimSpace(clawID), strings.TrimSpace(proposerClawID)) {
+// voteRules are the thresholds and role rules for one proposal.
+type voteRules struct {
+	yesNeed    float64
+	noNeed     float64
+	veto       map[string]bool
+	requireYes []string
+	matched    []string
+}
+
+func (p VotingPolicy) rulesFor(tags []string, poolSize int) voteRules {
+	r := voteRules{
+		yesNeed: math.Max(float64(p.QuorumYes), percentOf(p.QuorumYesPercent, poolSize)),
+		noNeed:  math.Max(float64(p.QuorumNo), percentOf(p.QuorumNoPercent, poolSize)),
+		veto:    map[string]bool{},
+	}
+	for _, role := range p.VetoRoles {
+		r.veto[strings.ToLower(strings.TrimSpace(role))] = true
+	}
+	required := map[string]bool{}
+	for _, tp := range p.TagPolicies {
+		if !tagPolicyMatches(tp.Tag, tags) {
 			continue
 		}
-		switch strings.ToLower(strings.TrimSpace(v)) {
-		case "yes":
-			yes++
-		case "no":
-			no++
+		r.matched = append(r.matched, tp.Tag)
+		r.yesNeed = math.Max(r.yesNeed, math.Max(float64(tp.QuorumYes), percentOf(tp.QuorumYesPercent, poolSize)))
+		for _, role := range tp.VetoRoles {
+			r.veto[strings.ToLower(strings.TrimSpace(role))] = true
+		}
+		for _, role := range tp.RequireYesRoles {
+			role = strings.ToLower(strings.TrimSpace(role))
+			if role != "" && !required[role] {
+				required[role] = true
+				r.requireYes = append(r.requireYes, role)
+			}
+		}
+	}
+	return r
+}
+
+func (w VoterWeights) weight(b Ballot) float64 {
+	if v, ok := lookupFold(w.ClawWeights, b.ClawID); ok {
+		return v
+	}
+	base := 1.0
+	if v, ok := lookupFold(w.RoleWeights, b.Role); ok {
+		base = v
+	}
+	if v, ok := lookupFold(w.ZoneWeights, b.ZoneID); ok {
+		base *= v
+	}
+	expertise := math.Min(1, math.Max(0, b.Expertise))
+	return base + w.ExpertiseWeight*expertise
+}
+
+func tagPolicyMatches(pattern string, tags []string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	for _, tag := range tags {
+		if ok, _ := path.Match(pattern, strings.ToLower(strings.TrimSpace(tag))); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupFold(m map[string]float64, key string) (float64, bool) {
+	key = strings.TrimSpace(key)
+	if key == "" || len(m) == 0 {
+		return 0, false
+	}
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(strings.TrimSpace(k), key) {
+			return v, true
 		}
 	}
-	return yes, no
+	return 0, false
+}
+
+func percentOf(percent, poolSize int) float64 {
+	if percent <= 0 {
+		return 0
+	}
+	return math.Ceil(float64(percent*poolSize) / 100)
+}
+
+func validPercent(p int) bool { return p >= 0 && p <= 100 }
+
+func roundWeight(w float64) float64 { return math.Round(w*100) / 100 }
+
+func formatWeight(w float64) string {
+	return strconv.FormatFloat(roundWeight(w), 'f', -1, 64)
 }
@@ -1,6 +1,7 @@
 package knowledge
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -130,3 +131,120 @@ func TestEvaluateQuorum_DisabledPolicyApproves(t *testing.T) {
 		t.Fatalf("expected approved with voting disabled, got %+v", decision)
 	}
 }
+
+func TestVotingPolicyValidate_WeightedFields(t *testing.T) {
+	p := testPolicy()
+	p.QuorumYesPercent = 120
+	if err := p.Validate(); err == nil {
+		t.Fatal("expected percent validation error")
+	}
+	p = testPolicy()
+	p.Weights.RoleWeights = map[string]float64{"worker": -1}
+	if err := p.Validate(); err == nil {
+		t.Fatal("expected negative weight validation error")
+	}
+	p = testPolicy()
+	p.TagPolicies = []TagPolicy{{Tag: "security:["}}
+	if err := p.Validate(); err == nil {
+		t.Fatal("expected bad tag pattern validation error")
+	}
+}
+
+func TestEvaluateBallots_RoleWeightOutweighsWorkers(t *testing.T) {
+	now := time.Now()
+	p := testPolicy()
+	p.QuorumYes = 3
+	p.Weights.RoleWeights = map[string]float64{"Orchestrator": 3}
+	decision := EvaluateBallots("proposer", nil, 4, []Ballot{
+		{ClawID: "expert", Vote: "yes", Role: "orchestrator"},
+		{ClawID: "claw-a", Vote: "no", Role: "worker"},
+		{ClawID: "claw-b", Vote: "no", Role: "worker"},
+	}, now, now, p)
+	if decision.Status != VoteStatusApproved {
+		t.Fatalf("expected weighted approval, got %+v", decision)
+	}
+	if decision.Yes != 1 || decision.No != 2 || decision.YesWeight != 3 || decision.NoWeight != 2 {
+		t.Fatalf("unexpected tally: %+v", decision)
+	}
+	if !strings.Contains(decision.Rationale, "yes 3/3, no 2/2") || !strings.Contains(decision.Rationale, "expert yes 3 [orchestrator]") {
+		t.Fatalf("unexpected rationale: %q", decision.Rationale)
+	}
+}
+
+func TestEvaluateBallots_ClawZoneAndExpertiseWeights(t *testing.T) {
+	w := VoterWeights{
+		ClawWeights:     map[string]float64{"pinned": 0.5},
+		RoleWeights:     map[string]float64{"worker": 2},
+		ZoneWeights:     map[string]float64{"eu": 1.5},
+		ExpertiseWeight: 2,
+	}
+	cases := []struct {
+		b    Ballot
+		want float64
+	}{
+		{Ballot{ClawID: "pinned", Role: "worker", ZoneID: "eu", Expertise: 1}, 0.5},
+		{Ballot{ClawID: "c", Role: "worker", ZoneID: "EU"}, 3},
+		{Ballot{ClawID: "c", Expertise: 0.25}, 1.5},
+		{Ballot{ClawID: "c", Expertise: 7}, 3},
+		{Ballot{ClawID: "c"}, 1},
+	}
+	for _, tc := range cases {
+		if got := w.weight(tc.b); got != tc.want {
+			t.Fatalf("weight(%+v) = %v, want %v", tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestEvaluateBallots_VetoRoleRejects(t *testing.T) {
+	now := time.Now()
+	p := testPolicy()
+	p.VetoRoles = []string{"orchestrator"}
+	decision := EvaluateBallots("proposer", nil, 4, []Ballot{
+		{ClawID: "claw-a", Vote: "yes"},
+		{ClawID: "claw-b", Vote: "yes"},
+		{ClawID: "claw-c", Vote: "yes"},
+		{ClawID: "boss", Vote: "no", Role: "orchestrator"},
+	}, now, now, p)
+	if decision.Status != VoteStatusRejected || decision.Reason != "veto by boss (orchestrator)" {
+		t.Fatalf("expected veto, got %+v", decision)
+	}
+}
+
+func TestEvaluateBallots_TagPolicyRequiresRole(t *testing.T) {
+	now := time.Now()
+	p := testPolicy()
+	p.TagPolicies = []TagPolicy{{Tag: "security:*", RequireYesRoles: []string{"orchestrator"}}}
+	ballots := []Ballot{
+		{ClawID: "claw-a", Vote: "yes", Role: "worker"},
+		{ClawID: "claw-b", Vote: "yes", Role: "worker"},
+	}
+	if d := EvaluateBallots("proposer", []string{"ops"}, 4, ballots, now, now, p); d.Status != VoteStatusApproved {
+		t.Fatalf("expected unmatched tag policy to be ignored, got %+v", d)
+	}
+	d := EvaluateBallots("proposer", []string{"Security:TLS"}, 4, ballots, now, now, p)
+	if d.Status != VoteStatusPending || !strings.Contains(d.Rationale, "awaiting yes from orchestrator") ||
+		!strings.Contains(d.Rationale, "tag policies: security:*") {
+		t.Fatalf("expected pending for orchestrator yes, got %+v", d)
+	}
+	ballots = append(ballots, Ballot{ClawID: "boss", Vote: "yes", Role: "orchestrator"})
+	if d := EvaluateBallots("proposer", []string{"security:tls"}, 4, ballots, now, now, p); d.Status != VoteStatusApproved {
+		t.Fatalf("expected approval with orchestrator yes, got %+v", d)
+	}
+}
+
+func TestEvaluateBallots_PercentQuorum(t *testing.T) {
+	now := time.Now()
+	p := testPolicy()
+	p.QuorumYesPercent = 50
+	ballots := []Ballot{{ClawID: "a", Vote: "yes"}, {ClawID: "b", Vote: "yes"}}
+	if d := EvaluateBallots("proposer", nil, 10, ballots, now, now, p); d.Status != VoteStatusPending {
+		t.Fatalf("expected 2 of 10 to miss a 50%% quorum, got %+v", d)
+	}
+	if d := EvaluateBallots("proposer", nil, 4, ballots, now, now, p); d.Status != VoteStatusApproved {
+		t.Fatalf("expected 2 of 4 to meet a 50%% quorum, got %+v", d)
+	}
+	p.TagPolicies = []TagPolicy{{Tag: "schema", QuorumYesPercent: 75}}
+	if d := EvaluateBallots("proposer", []string{"schema"}, 4, ballots, now, now, p); d.Status != VoteStatusPending {
+		t.Fatalf("expected tag policy to raise the quorum, got %+v", d)
+	}
+}
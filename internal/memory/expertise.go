@@ -216,13 +216,14 @@ func computeTrend(tx *sql.Tx, skillName string) string {
 // ClassifySkill maps a tool name to a skill domain.
 func ClassifySkill(toolName string) string {
 	switch {
-	case toolName == "read_file" || toolName == "write_file" || toolName == "edit_file" || toolName == "list_dir":
+	case toolName == "read_file" || toolName == "write_file" || toolName == "edit_file" || toolName == "list_dir" ||
+		toolName == "grep" || toolName == "glob" || toolName == "apply_patch":
 		return "filesystem"
-	case toolName == "exec":
+	case toolName == "exec" || strings.HasPrefix(toolName, "process_"):
 		return "shell"
 	case toolName == "remember" || toolName == "recall":
 		return "memory"
-	case toolName == "web_search" || toolName == "web_fetch":
+	case toolName == "web_search" || toolName == "web_fetch" || toolName == "http_request":
 		return "research"
 	case toolName == "message":
 		return "communication"
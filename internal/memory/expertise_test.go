@@ -161,9 +161,11 @@ func TestClassifySkill(t *testing.T) {
 		{"read_file", "filesystem"},
 		{"write_file", "filesystem"},
 		{"exec", "shell"},
+		{"process_start", "shell"},
 		{"remember", "memory"},
 		{"recall", "memory"},
 		{"web_search", "research"},
+		{"http_request", "research"},
 		{"message", "communication"},
 		{"unknown_tool", "general"},
 	}
@@ -132,18 +132,18 @@ func (o *Orchestrator) GetHierarchy() []AgentNode {
 }
 
 // GetZones returns all zones with member counts.
-func (o *Orchestrator) GetZones() []map[string]any {
+func (o *Orchestrator) GetZones() []ZoneSummary {
 	zones := o.zones.AllZones()
-	result := make([]map[string]any, len(zones))
+	result := make([]ZoneSummary, len(zones))
 	for i, z := range zones {
-		result[i] = map[string]any{
-			"zone_id":      z.ZoneID,
-			"name":         z.Name,
-			"visibility":   z.Visibility,
-			"owner_id":     z.OwnerID,
-			"parent_zone":  z.ParentZone,
-			"member_count": o.zones.MemberCount(z.ZoneID),
-			"created_at":   z.CreatedAt,
+		result[i] = ZoneSummary{
+			ZoneID:      z.ZoneID,
+			Name:        z.Name,
+			Visibility:  z.Visibility,
+			OwnerID:     z.OwnerID,
+			ParentZone:  z.ParentZone,
+			MemberCount: o.zones.MemberCount(z.ZoneID),
+			CreatedAt:   z.CreatedAt,
 		}
 	}
 	return result
@@ -25,6 +25,17 @@ type Zone struct {
 	CreatedAt  time.Time `json:"created_at"`
 }
 
+// ZoneSummary is a zone with its member count.
+type ZoneSummary struct {
+	ZoneID      string    `json:"zone_id"`
+	Name        string    `json:"name"`
+	Visibility  string    `json:"visibility"`
+	OwnerID     string    `json:"owner_id"`
+	ParentZone  string    `json:"parent_zone"`
+	MemberCount int       `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 // DiscoveryPayload is sent on the orchestrator Kafka topic for discovery.
 type DiscoveryPayload struct {
 	Action    string      `json:"action"` // "discover", "hierarchy_update", "zone_update"
@@ -35,6 +35,8 @@ type RequestMeta struct {
 	BlockReason      string               // reason for blocking
 	ProviderOverride provider.LLMProvider // middleware can swap the provider
 	CostUSD          float64              // set by FinOps recorder
+	ToolCacheHits    int                  // tool results served from cache since the previous LLM call
+	ToolCacheSaved   int                  // result characters not re-sent because they were deduplicated
 }
 
 // NewRequestMeta creates a RequestMeta with initialized Tags map.
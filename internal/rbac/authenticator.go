@@ -0,0 +1,295 @@
+package rbac
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// legacyPrincipal is the principal gateway.authToken authenticates as.
+const legacyPrincipal = "admin"
+
+// SessionCookie carries the web UI session after an OIDC login.
+const SessionCookie = "kafclaw_session"
+
+const defaultSessionTTL = 12 * time.Hour
+
+// ErrInvalidCredentials is returned when a request presents a token or
+// session that does not authenticate.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Authenticator resolves gateway requests to identities and enforces
+// per-endpoint scopes.
+type Authenticator struct {
+	authToken  string
+	principals []config.GatewayPrincipalConfig
+	tl         *timeline.TimelineService
+	oidc       *oidcProvider
+	secure     bool
+
+	sessionTTL time.Duration
+	mu         sync.Mutex
+	sessions   map[string]session
+
+	now func() time.Time
+}
+
+type session struct {
+	identity Identity
+	expires  time.Time
+}
+
+// NewAuthenticator validates the principals in cfg and returns an
+// authenticator backed by tl for API tokens and audit.
+func NewAuthenticator(cfg config.GatewayConfig, tl *timeline.TimelineService) (*Authenticator, error) {
+	seen := map[string]bool{}
+	for _, p := range cfg.Principals {
+		name := strings.ToLower(strings.TrimSpace(p.Name))
+		if name == "" {
+			return nil, fmt.Errorf("gateway.principals: principal without name")
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("gateway.principals: duplicate principal %s", p.Name)
+		}
+		seen[name] = true
+		if len(p.Roles) == 0 {
+			return nil, fmt.Errorf("gateway.principals: principal %s has no roles", p.Name)
+		}
+		if _, err := principalRoles(p); err != nil {
+			return nil, fmt.Errorf("gateway.principals: %w", err)
+		}
+	}
+	a := &Authenticator{
+		authToken:  strings.TrimSpace(cfg.AuthToken),
+		principals: cfg.Principals,
+		tl:         tl,
+		secure:     cfg.TLSCert != "" && cfg.TLSKey != "",
+		sessionTTL: defaultSessionTTL,
+		sessions:   map[string]session{},
+		now:        time.Now,
+	}
+	if cfg.OIDC.SessionTTLSeconds > 0 {
+		a.sessionTTL = time.Duration(cfg.OIDC.SessionTTLSeconds) * time.Second
+	}
+	if strings.TrimSpace(cfg.OIDC.Issuer) != "" {
+		if strings.TrimSpace(cfg.OIDC.ClientID) == "" || strings.TrimSpace(cfg.OIDC.RedirectURL) == "" {
+			return nil, fmt.Errorf("gateway.oidc: clientId and redirectUrl are required with issuer")
+		}
+		a.oidc = newOIDCProvider(cfg.OIDC)
+	}
+	return a, nil
+}
+
+// Required reports whether requests must authenticate. Without an auth
+// token, principals or OIDC the gateway stays open as before.
+func (a *Authenticator) Required() bool {
+	return a.authToken != "" || len(a.principals) > 0 || a.oidc != nil
+}
+
+// OIDCEnabled reports whether web UI login via OIDC is configured.
+func (a *Authenticator) OIDCEnabled() bool {
+	return a.oidc != nil
+}
+
+// Authenticate resolves the caller of r. It returns nil without error when
+// the request carries no credentials.
+func (a *Authenticator) Authenticate(r *http.Request) (*Identity, error) {
+	if c, err := r.Cookie(SessionCookie); err == nil && c.Value != "" {
+		if id := a.lookupSession(c.Value); id != nil {
+			return id, nil
+		}
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if token == "" {
+		return nil, nil
+	}
+	if a.authToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.authToken)) == 1 {
+		return &Identity{Principal: legacyPrincipal, Method: MethodLegacy, Roles: []Role{RoleAdmin}, Scopes: ScopesForRoles([]Role{RoleAdmin})}, nil
+	}
+	if !strings.HasPrefix(token, tokenPrefix) || a.tl == nil {
+		return nil, ErrInvalidCredentials
+	}
+	rec, err := a.tl.GetActiveAPITokenByHash(HashToken(token), a.now())
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	id := a.principalIdentity(rec.Principal, MethodToken)
+	if id == nil {
+		// The principal was removed from the config; its tokens go with it.
+		return nil, ErrInvalidCredentials
+	}
+	id.TokenID = rec.TokenID
+	if rec.Scopes != "" {
+		narrowed, _ := ParseScopes(rec.Scopes)
+		id.Scopes = intersectScopes(id.Scopes, narrowed)
+	}
+	_ = a.tl.TouchAPIToken(rec.TokenID)
+	return id, nil
+}
+
+// principalIdentity builds the identity of a configured principal.
+func (a *Authenticator) principalIdentity(name, method string) *Identity {
+	p, ok := findPrincipal(a.principals, name)
+	if !ok {
+		return nil
+	}
+	roles, err := principalRoles(p)
+	if err != nil {
+		return nil
+	}
+	return &Identity{Principal: p.Name, Method: method, Roles: roles, Scopes: ScopesForRoles(roles)}
+}
+
+// Middleware authenticates and authorizes every request before next. public
+// requests (health checks, signed webhooks, the login flow) skip auth.
+// Mutating calls are written to the api_audit table, including denied ones.
+func (a *Authenticator) Middleware(next http.Handler, public func(*http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := RequiredScope(r.Method, r.URL.Path)
+		if r.Method == http.MethodOptions || (public != nil && public(r)) {
+			a.serve(w, r, next, &Identity{Method: MethodNone}, scope)
+			return
+		}
+		id, err := a.Authenticate(r)
+		if id == nil && err == nil && !a.Required() {
+			id = &Identity{Method: MethodNone, Scopes: AllScopes}
+		}
+		if id == nil {
+			if a.oidc != nil && r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html") {
+				http.Redirect(w, r, "/auth/oidc/login", http.StatusFound)
+				return
+			}
+			a.audit(r, &Identity{Method: MethodNone}, scope, http.StatusUnauthorized)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !id.Allows(scope) {
+			a.audit(r, id, scope, http.StatusForbidden)
+			http.Error(w, fmt.Sprintf("forbidden: %s scope required", scope), http.StatusForbidden)
+			return
+		}
+		a.serve(w, r, next, id, scope)
+	})
+}
+
+func (a *Authenticator) serve(w http.ResponseWriter, r *http.Request, next http.Handler, id *Identity, scope Scope) {
+	r = r.WithContext(WithIdentity(r.Context(), id))
+	if !IsMutating(r.Method) {
+		next.ServeHTTP(w, r)
+		return
+	}
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(rec, r)
+	a.audit(r, id, scope, rec.status)
+}
+
+// audit records a mutating call; reads are not audited.
+func (a *Authenticator) audit(r *http.Request, id *Identity, scope Scope, status int) {
+	if a.tl == nil || !IsMutating(r.Method) {
+		return
+	}
+	_ = a.tl.InsertAPIAudit(&timeline.APIAuditRecord{
+		Principal:  id.Principal,
+		AuthMethod: id.Method,
+		TokenID:    id.TokenID,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Scope:      string(scope),
+		Status:     status,
+		RemoteAddr: r.RemoteAddr,
+	})
+}
+
+// startSession stores id and sets the session cookie.
+func (a *Authenticator) startSession(w http.ResponseWriter, id Identity) {
+	key := randomToken()
+	id.Method = MethodSession
+	a.mu.Lock()
+	now := a.now()
+	for k, s := range a.sessions {
+		if now.After(s.expires) {
+			delete(a.sessions, k)
+		}
+	}
+	a.sessions[key] = session{identity: id, expires: now.Add(a.sessionTTL)}
+	a.mu.Unlock()
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    key,
+		Path:     "/",
+		MaxAge:   int(a.sessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   a.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (a *Authenticator) lookupSession(key string) *Identity {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s, ok := a.sessions[key]
+	if !ok {
+		return nil
+	}
+	if a.now().After(s.expires) {
+		delete(a.sessions, key)
+		return nil
+	}
+	// Re-resolve roles so config changes apply to live sessions.
+	if fresh := a.principalIdentity(s.identity.Principal, MethodSession); fresh != nil {
+		fresh.WebUserID = s.identity.WebUserID
+		return fresh
+	}
+	delete(a.sessions, key)
+	return nil
+}
+
+// LogoutHandler ends the web UI session.
+func (a *Authenticator) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(SessionCookie); err == nil {
+		a.mu.Lock()
+		delete(a.sessions, c.Value)
+		a.mu.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{Name: SessionCookie, Value: "", Path: "/", MaxAge: -1, HttpOnly: true, Secure: a.secure, SameSite: http.SameSiteLaxMode})
+	if r.Method == http.MethodGet {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func intersectScopes(a, b []Scope) []Scope {
+	held := &Identity{Scopes: a}
+	var out []Scope
+	for _, s := range b {
+		if held.Allows(s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Flush keeps streamed (SSE) responses working behind the middleware.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,411 @@
+package rbac
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+// loginTTL bounds how long an OIDC login may take between redirect and
+// callback.
+const loginTTL = 10 * time.Minute
+
+// oidcProvider implements the authorization code flow with PKCE against
+// an OpenID Connect issuer, verifying RS256 ID tokens via the issuer JWKS.
+type oidcProvider struct {
+	cfg    config.GatewayOIDCConfig
+	client *http.Client
+
+	mu      sync.Mutex
+	meta    *oidcMetadata
+	keys    map[string]*rsa.PublicKey
+	pending map[string]pendingLogin
+}
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type pendingLogin struct {
+	nonce    string
+	verifier string
+	expires  time.Time
+}
+
+type idTokenClaims struct {
+	Issuer        string          `json:"iss"`
+	Subject       string          `json:"sub"`
+	Audience      json.RawMessage `json:"aud"`
+	Expiry        int64           `json:"exp"`
+	Nonce         string          `json:"nonce"`
+	Email         string          `json:"email"`
+	EmailVerified *bool           `json:"email_verified"`
+}
+
+func newOIDCProvider(cfg config.GatewayOIDCConfig) *oidcProvider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &oidcProvider{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: 15 * time.Second},
+		keys:    map[string]*rsa.PublicKey{},
+		pending: map[string]pendingLogin{},
+	}
+}
+
+// metadata fetches and caches the issuer discovery document.
+func (p *oidcProvider) metadata(ctx context.Context) (*oidcMetadata, error) {
+	p.mu.Lock()
+	meta := p.meta
+	p.mu.Unlock()
+	if meta != nil {
+		return meta, nil
+	}
+	issuer := strings.TrimRight(p.cfg.Issuer, "/")
+	meta = &oidcMetadata{}
+	if err := p.getJSON(ctx, issuer+"/.well-known/openid-configuration", meta); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimRight(meta.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery: incomplete provider metadata")
+	}
+	p.mu.Lock()
+	p.meta = meta
+	p.mu.Unlock()
+	return meta, nil
+}
+
+func (p *oidcProvider) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// authURL starts a login and returns the issuer URL to redirect to.
+func (p *oidcProvider) authURL(ctx context.Context, now time.Time) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	state, nonce, verifier := randomToken(), randomToken(), randomToken()
+	challenge := sha256.Sum256([]byte(verifier))
+
+	p.mu.Lock()
+	for k, l := range p.pending {
+		if now.After(l.expires) {
+			delete(p.pending, k)
+		}
+	}
+	p.pending[state] = pendingLogin{nonce: nonce, verifier: verifier, expires: now.Add(loginTTL)}
+	p.mu.Unlock()
+
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// exchange redeems the callback code and returns the verified ID token
+// claims.
+func (p *oidcProvider) exchange(ctx context.Context, state, code string, now time.Time) (*idTokenClaims, error) {
+	p.mu.Lock()
+	login, ok := p.pending[state]
+	delete(p.pending, state)
+	p.mu.Unlock()
+	if !ok || now.After(login.expires) {
+		return nil, fmt.Errorf("unknown or expired login state")
+	}
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {login.verifier},
+	}
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc token exchange: %s", resp.Status)
+	}
+	var tok struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tok); err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if tok.IDToken == "" {
+		return nil, fmt.Errorf("oidc token exchange: no id_token in response")
+	}
+	return p.verify(ctx, meta, tok.IDToken, login.nonce, now)
+}
+
+// verify checks the signature and standard claims of an ID token.
+func (p *oidcProvider) verify(ctx context.Context, meta *oidcMetadata, raw, nonce string, now time.Time) (*idTokenClaims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("id token: malformed")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("id token header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("id token: unsupported alg %q", header.Alg)
+	}
+	key, err := p.key(ctx, meta, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("id token signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return nil, fmt.Errorf("id token: bad signature")
+	}
+
+	var claims idTokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("id token claims: %w", err)
+	}
+	if strings.TrimRight(claims.Issuer, "/") != strings.TrimRight(meta.Issuer, "/") {
+		return nil, fmt.Errorf("id token: unexpected issuer %q", claims.Issuer)
+	}
+	if !audienceContains(claims.Audience, p.cfg.ClientID) {
+		return nil, fmt.Errorf("id token: not issued for this client")
+	}
+	if claims.Expiry == 0 || now.After(time.Unix(claims.Expiry, 0).Add(time.Minute)) {
+		return nil, fmt.Errorf("id token: expired")
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("id token: nonce mismatch")
+	}
+	return &claims, nil
+}
+
+// key returns the signing key kid, refreshing the JWKS once when unknown
+// so issuer key rotation is picked up.
+func (p *oidcProvider) key(ctx context.Context, meta *oidcMetadata, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	k, ok := p.keys[kid]
+	p.mu.Unlock()
+	if ok {
+		return k, nil
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, err1 := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, err2 := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	// A single-key JWKS may omit kid.
+	if kid == "" && len(keys) == 1 {
+		for _, k := range keys {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("id token: unknown signing key %q", kid)
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func audienceContains(raw json.RawMessage, clientID string) bool {
+	var one string
+	if json.Unmarshal(raw, &one) == nil {
+		return one == clientID
+	}
+	var many []string
+	if json.Unmarshal(raw, &many) == nil {
+		for _, a := range many {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// principalForClaims maps a login to a configured principal by verified
+// email, then by subject.
+func (a *Authenticator) principalForClaims(c *idTokenClaims) (config.GatewayPrincipalConfig, bool) {
+	emailOK := c.Email != "" && (c.EmailVerified == nil || *c.EmailVerified)
+	for _, p := range a.principals {
+		if emailOK {
+			for _, e := range p.Emails {
+				if strings.EqualFold(strings.TrimSpace(e), c.Email) {
+					return p, true
+				}
+			}
+		}
+		for _, s := range p.Subjects {
+			if c.Subject != "" && strings.TrimSpace(s) == c.Subject {
+				return p, true
+			}
+		}
+	}
+	return config.GatewayPrincipalConfig{}, false
+}
+
+// LoginHandler redirects the browser to the OIDC issuer.
+func (a *Authenticator) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	if a.oidc == nil {
+		http.Error(w, "oidc login not configured", http.StatusNotFound)
+		return
+	}
+	u, err := a.oidc.authURL(r.Context(), a.now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	http.Redirect(w, r, u, http.StatusFound)
+}
+
+// CallbackHandler completes an OIDC login: it verifies the ID token, maps
+// it to a principal, links the principal's web user and starts a session.
+func (a *Authenticator) CallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if a.oidc == nil {
+		http.Error(w, "oidc login not configured", http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		http.Error(w, "login failed: "+e, http.StatusUnauthorized)
+		return
+	}
+	claims, err := a.oidc.exchange(r.Context(), q.Get("state"), q.Get("code"), a.now())
+	if err != nil {
+		http.Error(w, "login failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+	p, ok := a.principalForClaims(claims)
+	if !ok {
+		http.Error(w, "login failed: no gateway principal for this account", http.StatusForbidden)
+		return
+	}
+	id := a.principalIdentity(p.Name, MethodSession)
+	if id == nil {
+		http.Error(w, "login failed: invalid principal", http.StatusForbidden)
+		return
+	}
+	if a.tl != nil {
+		if webUserID, err := a.linkWebUser(id.Principal); err == nil {
+			id.WebUserID = webUserID
+		}
+	}
+	a.startSession(w, *id)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// linkWebUser returns the web user of principal, creating one named after
+// the principal on first login.
+func (a *Authenticator) linkWebUser(principal string) (int64, error) {
+	u, err := a.tl.GetWebUserByPrincipal(principal)
+	if err == nil {
+		return u.ID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	u, err = a.tl.CreateWebUser(principal)
+	if err != nil {
+		return 0, err
+	}
+	if u.Principal == "" {
+		if err := a.tl.SetWebUserPrincipal(u.ID, principal); err != nil {
+			return 0, err
+		}
+	}
+	return u.ID, nil
+}
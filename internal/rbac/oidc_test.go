@@ -0,0 +1,184 @@
+package rbac
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+// fakeIssuer is a minimal OpenID provider issuing RS256 ID tokens.
+type fakeIssuer struct {
+	srv    *httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]any // extra claims for the next token
+	nonce  string
+	pkce   string
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	f := &fakeIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.srv.URL,
+			"authorization_endpoint": f.srv.URL + "/authorize",
+			"token_endpoint":         f.srv.URL + "/token",
+			"jwks_uri":               f.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != f.pkce {
+			http.Error(w, "invalid_grant", http.StatusBadRequest)
+			return
+		}
+		claims := map[string]any{"iss": f.srv.URL, "aud": "kafclaw", "exp": time.Now().Add(time.Hour).Unix(), "nonce": f.nonce}
+		for k, v := range f.claims {
+			claims[k] = v
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": f.sign(t, claims)})
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeIssuer) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+	body, _ := json.Marshal(claims)
+	signing := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	digest := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signing + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// login runs the browser side of the flow and returns the callback response.
+func (f *fakeIssuer) login(t *testing.T, a *Authenticator) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	a.LoginHandler(rec, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login: %d %s", rec.Code, rec.Body.String())
+	}
+	loc, _ := url.Parse(rec.Header().Get("Location"))
+	q := loc.Query()
+	if !strings.HasPrefix(loc.String(), f.srv.URL+"/authorize") || q.Get("code_challenge_method") != "S256" || q.Get("client_id") != "kafclaw" {
+		t.Fatalf("unexpected authorize redirect %s", loc)
+	}
+	f.nonce, f.pkce = q.Get("nonce"), q.Get("code_challenge")
+
+	rec = httptest.NewRecorder()
+	a.CallbackHandler(rec, httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?code=good-code&state="+url.QueryEscape(q.Get("state")), nil))
+	return rec
+}
+
+func newOIDCAuthenticator(t *testing.T, issuer string) *Authenticator {
+	t.Helper()
+	a, err := NewAuthenticator(config.GatewayConfig{
+		Principals: testPrincipals,
+		OIDC:       config.GatewayOIDCConfig{Issuer: issuer, ClientID: "kafclaw", RedirectURL: "http://localhost:18791/auth/oidc/callback"},
+	}, newTestTimeline(t))
+	if err != nil {
+		t.Fatalf("new authenticator: %v", err)
+	}
+	return a
+}
+
+func TestOIDCLoginStartsSession(t *testing.T) {
+	f := newFakeIssuer(t)
+	a := newOIDCAuthenticator(t, f.srv.URL)
+	f.claims = map[string]any{"sub": "u-1", "email": "Alice@example.com", "email_verified": true}
+
+	rec := f.login(t, a)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("callback: %d %s", rec.Code, rec.Body.String())
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != SessionCookie || !cookies[0].HttpOnly {
+		t.Fatalf("expected session cookie, got %+v", cookies)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/settings", nil)
+	req.AddCookie(cookies[0])
+	id, err := a.Authenticate(req)
+	if err != nil || id == nil || id.Principal != "alice" || id.Method != MethodSession || !id.Allows(ScopeApprovals) || id.Allows(ScopeChat) {
+		t.Fatalf("unexpected session identity %+v err=%v", id, err)
+	}
+	u, err := a.tl.GetWebUserByPrincipal("alice")
+	if err != nil || u.ID != id.WebUserID {
+		t.Fatalf("expected linked web user, got %+v err=%v (identity %+v)", u, err, id)
+	}
+
+	rec = httptest.NewRecorder()
+	a.LogoutHandler(rec, req)
+	if id, _ := a.Authenticate(req); id != nil {
+		t.Fatalf("expected session ended by logout, got %+v", id)
+	}
+}
+
+func TestOIDCLoginRejections(t *testing.T) {
+	f := newFakeIssuer(t)
+	a := newOIDCAuthenticator(t, f.srv.URL)
+
+	f.claims = map[string]any{"sub": "u-2", "email": "alice@example.com", "email_verified": false}
+	if rec := f.login(t, a); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected unverified email without subject match to be refused, got %d", rec.Code)
+	}
+	f.claims = map[string]any{"sub": "sub-ops", "aud": "someone-else"}
+	if rec := f.login(t, a); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected foreign audience refused, got %d", rec.Code)
+	}
+	f.claims = map[string]any{"sub": "sub-ops", "exp": time.Now().Add(-time.Hour).Unix()}
+	if rec := f.login(t, a); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected expired token refused, got %d", rec.Code)
+	}
+	f.claims = map[string]any{"sub": "sub-ops"}
+	if rec := f.login(t, a); rec.Code != http.StatusFound {
+		t.Fatalf("expected subject login, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec := httptest.NewRecorder()
+	a.CallbackHandler(rec, httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?code=good-code&state=forged", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected unknown state refused, got %d", rec.Code)
+	}
+
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), nil)
+	req := httptest.NewRequest(http.MethodGet, "/timeline", nil)
+	req.Header.Set("Accept", "text/html")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/auth/oidc/login" {
+		t.Fatalf("expected browser redirect to login, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,193 @@
+// Package rbac provides gateway principals, roles, scoped API tokens and
+// OIDC login for the web UI.
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Role is a named bundle of scopes assigned to principals.
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"
+	RoleOperator Role = "operator"
+	RoleApprover Role = "approver"
+	RoleAdmin    Role = "admin"
+)
+
+// Scope is a permission checked per endpoint.
+type Scope string
+
+const (
+	ScopeRead      Scope = "read"      // GET endpoints
+	ScopeChat      Scope = "chat"      // send messages to the agent
+	ScopeApprovals Scope = "approvals" // decide tool approvals, manage grants
+	ScopeRepo      Scope = "repo"      // work repo changes (commit, push, checkout)
+	ScopeGroup     Scope = "group"     // group membership, tasks and topics
+	ScopeProcesses Scope = "processes" // kill background processes
+	ScopeMemory    Scope = "memory"    // reset, prune and reconfigure memory
+	ScopeSettings  Scope = "settings"  // settings, web users and person links
+	ScopeAdmin     Scope = "admin"     // everything else
+)
+
+// AllScopes lists every scope.
+var AllScopes = []Scope{ScopeRead, ScopeChat, ScopeApprovals, ScopeRepo, ScopeGroup, ScopeProcesses, ScopeMemory, ScopeSettings, ScopeAdmin}
+
+var roleScopes = map[Role][]Scope{
+	RoleViewer:   {ScopeRead},
+	RoleApprover: {ScopeRead, ScopeApprovals},
+	RoleOperator: {ScopeRead, ScopeChat, ScopeRepo, ScopeGroup, ScopeProcesses},
+	RoleAdmin:    AllScopes,
+}
+
+// ParseRole validates a role name.
+func ParseRole(s string) (Role, error) {
+	r := Role(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := roleScopes[r]; !ok {
+		return "", fmt.Errorf("unknown role %q (use viewer, operator, approver or admin)", s)
+	}
+	return r, nil
+}
+
+// ParseScopes validates a comma-separated scope list.
+func ParseScopes(s string) ([]Scope, error) {
+	var out []Scope
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		known := false
+		for _, sc := range AllScopes {
+			if Scope(part) == sc {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown scope %q", part)
+		}
+		out = append(out, Scope(part))
+	}
+	return out, nil
+}
+
+// ScopesForRoles returns the union of the scopes of roles, sorted.
+func ScopesForRoles(roles []Role) []Scope {
+	set := map[Scope]bool{}
+	for _, r := range roles {
+		for _, s := range roleScopes[r] {
+			set[s] = true
+		}
+	}
+	out := make([]Scope, 0, len(set))
+	for s := range set {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// JoinScopes renders scopes as a comma-separated list.
+func JoinScopes(scopes []Scope) string {
+	parts := make([]string, len(scopes))
+	for i, s := range scopes {
+		parts[i] = string(s)
+	}
+	return strings.Join(parts, ",")
+}
+
+// scopeRoutes maps path prefixes of mutating calls to their scope; the
+// first match wins and unlisted paths need ScopeAdmin.
+var scopeRoutes = []struct {
+	prefix string
+	scope  Scope
+}{
+	{"/chat", ScopeChat},
+	{"/v1/chat/completions", ScopeChat},
+	{"/api/v1/webchat/", ScopeChat},
+	{"/api/v1/channels/", ScopeChat},
+	{"/api/v1/orchestrator/dispatch", ScopeChat},
+	{"/api/v1/approvals/", ScopeApprovals},
+	{"/api/v1/repo/", ScopeRepo},
+	{"/api/v1/workrepo", ScopeRepo},
+	{"/api/v1/group/", ScopeGroup},
+	{"/api/v1/processes/", ScopeProcesses},
+	{"/api/v1/memory/", ScopeMemory},
+	{"/api/v1/settings", ScopeSettings},
+	{"/api/v1/webusers", ScopeSettings},
+	{"/api/v1/weblinks", ScopeSettings},
+	{"/api/v1/persons", ScopeSettings},
+}
+
+// RequiredScope returns the scope a request needs. Reads need ScopeRead;
+// mutating calls need the scope of their endpoint.
+func RequiredScope(method, path string) Scope {
+	if !IsMutating(method) {
+		return ScopeRead
+	}
+	for _, r := range scopeRoutes {
+		if strings.HasPrefix(path, r.prefix) {
+			return r.scope
+		}
+	}
+	return ScopeAdmin
+}
+
+// IsMutating reports whether method changes state.
+func IsMutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// Authentication methods.
+const (
+	MethodToken   = "token"   // API token from `kafclaw gateway tokens`
+	MethodSession = "session" // web UI session from an OIDC login
+	MethodLegacy  = "legacy"  // gateway.authToken
+	MethodNone    = "none"    // auth disabled or public endpoint
+)
+
+// Identity is the authenticated caller of a request.
+type Identity struct {
+	Principal string  `json:"principal"`
+	Method    string  `json:"method"`
+	TokenID   string  `json:"token_id,omitempty"`
+	Roles     []Role  `json:"roles,omitempty"`
+	Scopes    []Scope `json:"scopes"`
+	WebUserID int64   `json:"web_user_id,omitempty"`
+}
+
+// Allows reports whether the identity holds scope. ScopeAdmin implies all.
+func (id *Identity) Allows(scope Scope) bool {
+	if id == nil {
+		return false
+	}
+	for _, s := range id.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+type identityKey struct{}
+
+// WithIdentity returns ctx carrying id.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFrom returns the identity stored by the middleware, if any.
+func IdentityFrom(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok && id != nil
+}
@@ -0,0 +1,197 @@
+package rbac
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func newTestTimeline(t *testing.T) *timeline.TimelineService {
+	t.Helper()
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("new timeline: %v", err)
+	}
+	t.Cleanup(func() { _ = tl.Close() })
+	return tl
+}
+
+var testPrincipals = []config.GatewayPrincipalConfig{
+	{Name: "ci", Roles: []string{"operator"}},
+	{Name: "alice", Roles: []string{"approver"}, Emails: []string{"alice@example.com"}},
+	{Name: "ops", Roles: []string{"admin"}, Subjects: []string{"sub-ops"}},
+}
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         Scope
+	}{
+		{http.MethodGet, "/api/v1/settings", ScopeRead},
+		{http.MethodPost, "/chat", ScopeChat},
+		{http.MethodPost, "/api/v1/approvals/ap-1", ScopeApprovals},
+		{http.MethodDelete, "/api/v1/approvals/grants/g-1", ScopeApprovals},
+		{http.MethodPost, "/api/v1/repo/commit", ScopeRepo},
+		{http.MethodPost, "/api/v1/memory/reset", ScopeMemory},
+		{http.MethodPost, "/api/v1/settings", ScopeSettings},
+		{http.MethodPost, "/api/v1/mode", ScopeAdmin},
+	}
+	for _, tt := range tests {
+		if got := RequiredScope(tt.method, tt.path); got != tt.want {
+			t.Fatalf("RequiredScope(%s %s) = %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+	if _, err := ParseScopes("read, chat"); err != nil {
+		t.Fatalf("parse scopes: %v", err)
+	}
+	if _, err := ParseScopes("read,everything"); err == nil {
+		t.Fatal("expected unknown scope to fail")
+	}
+	if _, err := ParseRole("root"); err == nil {
+		t.Fatal("expected unknown role to fail")
+	}
+}
+
+func TestNewAuthenticatorValidatesPrincipals(t *testing.T) {
+	for _, bad := range [][]config.GatewayPrincipalConfig{
+		{{Name: "", Roles: []string{"viewer"}}},
+		{{Name: "a", Roles: []string{"viewer"}}, {Name: "A", Roles: []string{"admin"}}},
+		{{Name: "a"}},
+		{{Name: "a", Roles: []string{"superuser"}}},
+	} {
+		if _, err := NewAuthenticator(config.GatewayConfig{Principals: bad}, nil); err == nil {
+			t.Fatalf("expected principals %+v to be rejected", bad)
+		}
+	}
+	if _, err := NewAuthenticator(config.GatewayConfig{OIDC: config.GatewayOIDCConfig{Issuer: "https://idp"}}, nil); err == nil {
+		t.Fatal("expected oidc without client id to be rejected")
+	}
+}
+
+func TestTokenLifecycle(t *testing.T) {
+	tl := newTestTimeline(t)
+	if _, _, err := CreateToken(tl, testPrincipals, TokenSpec{Principal: "nobody"}); !errors.Is(err, ErrUnknownPrincipal) {
+		t.Fatalf("expected ErrUnknownPrincipal, got %v", err)
+	}
+	if _, _, err := CreateToken(tl, testPrincipals, TokenSpec{Principal: "ci", Scopes: []Scope{ScopeSettings}}); err == nil {
+		t.Fatal("expected a scope beyond the principal's roles to be rejected")
+	}
+
+	token, rec, err := CreateToken(tl, testPrincipals, TokenSpec{Principal: "CI", Name: "deploy", Scopes: []Scope{ScopeRead, ScopeChat}, TTL: time.Hour, CreatedBy: "cli"})
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	if !strings.HasPrefix(token, rec.Prefix) || rec.Principal != "ci" || rec.Hash == token || rec.ExpiresAt == nil {
+		t.Fatalf("unexpected token record %+v", rec)
+	}
+	if active, _ := ListTokens(tl, false); len(active) != 1 || active[0].TokenID != rec.TokenID {
+		t.Fatalf("expected token listed, got %+v", active)
+	}
+	if err := RevokeToken(tl, rec.TokenID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if err := RevokeToken(tl, rec.TokenID); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("expected ErrTokenNotFound, got %v", err)
+	}
+	if active, _ := ListTokens(tl, false); len(active) != 0 {
+		t.Fatalf("expected no active tokens, got %+v", active)
+	}
+	if all, _ := ListTokens(tl, true); len(all) != 1 || all[0].RevokedAt == nil {
+		t.Fatalf("expected revoked token with all, got %+v", all)
+	}
+}
+
+func TestMiddlewareEnforcesScopesAndAudits(t *testing.T) {
+	tl := newTestTimeline(t)
+	a, err := NewAuthenticator(config.GatewayConfig{AuthToken: "legacy-secret", Principals: testPrincipals}, tl)
+	if err != nil {
+		t.Fatalf("new authenticator: %v", err)
+	}
+	narrow, _, err := CreateToken(tl, testPrincipals, TokenSpec{Principal: "ci", Scopes: []Scope{ScopeRead}})
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	ci, ciRec, _ := CreateToken(tl, testPrincipals, TokenSpec{Principal: "ci"})
+
+	var seen *Identity
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = IdentityFrom(r.Context())
+		w.WriteHeader(http.StatusAccepted)
+	}), func(r *http.Request) bool { return r.URL.Path == "/api/v1/status" })
+
+	do := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := do(http.MethodGet, "/api/v1/status", ""); code != http.StatusAccepted {
+		t.Fatalf("public endpoint: %d", code)
+	}
+	if code := do(http.MethodGet, "/api/v1/settings", ""); code != http.StatusUnauthorized {
+		t.Fatalf("anonymous read: %d", code)
+	}
+	if code := do(http.MethodGet, "/api/v1/settings", "kct_bogus"); code != http.StatusUnauthorized {
+		t.Fatalf("unknown token: %d", code)
+	}
+	if code := do(http.MethodGet, "/api/v1/settings", narrow); code != http.StatusAccepted {
+		t.Fatalf("narrow token read: %d", code)
+	}
+	if code := do(http.MethodPost, "/chat", narrow); code != http.StatusForbidden {
+		t.Fatalf("narrow token chat: %d", code)
+	}
+	if code := do(http.MethodPost, "/chat", ci); code != http.StatusAccepted || seen.Principal != "ci" || seen.Method != MethodToken || seen.TokenID != ciRec.TokenID {
+		t.Fatalf("operator chat: %d %+v", code, seen)
+	}
+	if code := do(http.MethodPost, "/api/v1/approvals/ap-1", ci); code != http.StatusForbidden {
+		t.Fatalf("operator approval: %d", code)
+	}
+	if code := do(http.MethodPost, "/api/v1/memory/reset", "legacy-secret"); code != http.StatusAccepted || seen.Principal != "admin" || seen.Method != MethodLegacy {
+		t.Fatalf("legacy token: %d %+v", code, seen)
+	}
+
+	_ = RevokeToken(tl, ciRec.TokenID)
+	if code := do(http.MethodPost, "/chat", ci); code != http.StatusUnauthorized {
+		t.Fatalf("revoked token: %d", code)
+	}
+	if stored, _ := ListTokens(tl, true); stored[0].LastUsedAt == nil {
+		t.Fatalf("expected token use recorded, got %+v", stored[0])
+	}
+
+	rows, err := tl.ListUnifiedAudit(timeline.AuditFilter{Source: "api", Limit: 20})
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	// Mutating calls: narrow chat (403), ci chat (202), ci approval (403),
+	// legacy memory reset (202), revoked chat (401).
+	if len(rows) != 5 {
+		t.Fatalf("expected 5 audited calls, got %d: %+v", len(rows), rows)
+	}
+}
+
+func TestMiddlewareOpenWithoutAuth(t *testing.T) {
+	a, err := NewAuthenticator(config.GatewayConfig{}, nil)
+	if err != nil {
+		t.Fatalf("new authenticator: %v", err)
+	}
+	if a.Required() {
+		t.Fatal("expected auth not required without token, principals or oidc")
+	}
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/mode", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected open gateway, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,139 @@
+package rbac
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// tokenPrefix marks KafClaw API tokens so they are recognizable in logs
+// and secret scanners.
+const tokenPrefix = "kct_"
+
+var (
+	// ErrTokenNotFound is returned when revoking an unknown or already
+	// revoked token.
+	ErrTokenNotFound = errors.New("no active api token")
+	// ErrUnknownPrincipal is returned for principals missing from
+	// gateway.principals.
+	ErrUnknownPrincipal = errors.New("unknown principal")
+)
+
+// TokenSpec describes a token to create.
+type TokenSpec struct {
+	Principal string
+	Name      string
+	// Scopes narrow the token below the principal's roles; empty keeps
+	// all of the principal's scopes.
+	Scopes    []Scope
+	TTL       time.Duration // zero: no expiry
+	CreatedBy string
+}
+
+// HashToken returns the stored form of a token.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateToken issues a token for a configured principal and returns the
+// plaintext, which is shown once and never stored.
+func CreateToken(tl *timeline.TimelineService, principals []config.GatewayPrincipalConfig, spec TokenSpec) (string, *timeline.APITokenRecord, error) {
+	p, ok := findPrincipal(principals, spec.Principal)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s (add it to gateway.principals)", ErrUnknownPrincipal, spec.Principal)
+	}
+	roles, err := principalRoles(p)
+	if err != nil {
+		return "", nil, err
+	}
+	granted := &Identity{Scopes: ScopesForRoles(roles)}
+	for _, s := range spec.Scopes {
+		if !granted.Allows(s) {
+			return "", nil, fmt.Errorf("principal %s has no %q scope", p.Name, s)
+		}
+	}
+
+	token := tokenPrefix + randomToken()
+	rec := &timeline.APITokenRecord{
+		TokenID:   newID(),
+		Principal: p.Name,
+		Name:      strings.TrimSpace(spec.Name),
+		Prefix:    token[:len(tokenPrefix)+6],
+		Hash:      HashToken(token),
+		Scopes:    JoinScopes(spec.Scopes),
+		CreatedBy: spec.CreatedBy,
+		CreatedAt: time.Now(),
+	}
+	if spec.TTL > 0 {
+		expires := rec.CreatedAt.Add(spec.TTL)
+		rec.ExpiresAt = &expires
+	}
+	if err := tl.InsertAPIToken(rec); err != nil {
+		return "", nil, err
+	}
+	return token, rec, nil
+}
+
+// ListTokens returns the active tokens, or all tokens (revoked and expired
+// included) when all is set.
+func ListTokens(tl *timeline.TimelineService, all bool) ([]timeline.APITokenRecord, error) {
+	return tl.ListAPITokens(all, time.Now())
+}
+
+// RevokeToken revokes an active token.
+func RevokeToken(tl *timeline.TimelineService, tokenID string) error {
+	tokenID = strings.TrimSpace(tokenID)
+	if err := tl.RevokeAPIToken(tokenID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrTokenNotFound, tokenID)
+		}
+		return err
+	}
+	return nil
+}
+
+func findPrincipal(principals []config.GatewayPrincipalConfig, name string) (config.GatewayPrincipalConfig, bool) {
+	name = strings.TrimSpace(name)
+	for _, p := range principals {
+		if name != "" && strings.EqualFold(strings.TrimSpace(p.Name), name) {
+			p.Name = strings.TrimSpace(p.Name)
+			return p, true
+		}
+	}
+	return config.GatewayPrincipalConfig{}, false
+}
+
+func principalRoles(p config.GatewayPrincipalConfig) ([]Role, error) {
+	roles := make([]Role, 0, len(p.Roles))
+	for _, name := range p.Roles {
+		r, err := ParseRole(name)
+		if err != nil {
+			return nil, fmt.Errorf("principal %s: %w", p.Name, err)
+		}
+		roles = append(roles, r)
+	}
+	return roles, nil
+}
+
+func newID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// randomToken returns 256 random bits, base64url encoded.
+func randomToken() string {
+	var b [32]byte
+	_, _ = rand.Read(b[:])
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
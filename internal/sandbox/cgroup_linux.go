@@ -0,0 +1,85 @@
+package sandbox
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/sys/unix"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+var cgroupSeq atomic.Uint64
+
+// cgroup is a per-command child of the caller's cgroup v2 group. The
+// command is started directly inside it (clone3 CLONE_INTO_CGROUP).
+type cgroup struct {
+	dir string
+	fd  int
+}
+
+// newCgroup creates a child cgroup with the policy's limits. It fails
+// unless cgroup v2 is mounted and the caller's group is delegated with the
+// memory, pids and cpu controllers enabled for children.
+func newCgroup(p Policy) (*cgroup, error) {
+	base, err := ownCgroupDir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(base, fmt.Sprintf("kafclaw-sandbox-%d-%d", os.Getpid(), cgroupSeq.Add(1)))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create cgroup: %w", err)
+	}
+	cpuQuota := int64(p.CPUs * 100000)
+	limits := []struct{ file, value string }{
+		{"memory.max", strconv.FormatInt(p.MemoryBytes, 10)},
+		{"memory.swap.max", "0"},
+		{"pids.max", strconv.Itoa(p.MaxPIDs)},
+		{"cpu.max", fmt.Sprintf("%d 100000", cpuQuota)},
+	}
+	for _, l := range limits {
+		if err := os.WriteFile(filepath.Join(dir, l.file), []byte(l.value), 0o644); err != nil {
+			if l.file == "memory.swap.max" && os.IsNotExist(err) {
+				continue // kernels without swap accounting
+			}
+			os.Remove(dir)
+			return nil, fmt.Errorf("set %s: %w", l.file, err)
+		}
+	}
+	fd, err := unix.Open(dir, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		os.Remove(dir)
+		return nil, fmt.Errorf("open cgroup: %w", err)
+	}
+	return &cgroup{dir: dir, fd: fd}, nil
+}
+
+// remove closes the cgroup handle and deletes the (now empty) group.
+func (c *cgroup) remove() {
+	unix.Close(c.fd)
+	os.Remove(c.dir)
+}
+
+// ownCgroupDir returns the cgroup v2 directory of the current process.
+func ownCgroupDir() (string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("cgroup v2 is not mounted at %s", cgroupRoot)
+	}
+	f, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if rel, ok := strings.CutPrefix(sc.Text(), "0::"); ok {
+			return filepath.Join(cgroupRoot, filepath.Clean("/"+rel)), nil
+		}
+	}
+	return "", fmt.Errorf("no cgroup v2 entry in /proc/self/cgroup")
+}
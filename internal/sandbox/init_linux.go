@@ -0,0 +1,111 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// runInit runs in the helper process, which starts as root inside the new
+// namespaces. It sets up mounts, drops all capabilities, applies Landlock
+// and seccomp to this thread and execs the command.
+func runInit(s spec) error {
+	// Landlock, seccomp and no_new_privs apply to the calling thread; exec
+	// must happen on the same one.
+	runtime.LockOSThread()
+
+	if len(s.Argv) == 0 {
+		return fmt.Errorf("empty command")
+	}
+	if err := setupMounts(s.Writable); err != nil {
+		return err
+	}
+	_ = unix.Sethostname([]byte("sandbox"))
+	if s.WorkDir != "" {
+		if err := os.Chdir(s.WorkDir); err != nil {
+			return fmt.Errorf("chdir %s: %w", s.WorkDir, err)
+		}
+	}
+	path, err := lookPath(s.Argv[0], s.Env)
+	if err != nil {
+		return err
+	}
+	if err := dropCapabilities(); err != nil {
+		return err
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("set no_new_privs: %w", err)
+	}
+	if err := applyLandlock(s.Writable, s.Network); err != nil {
+		return err
+	}
+	if seccompArch != 0 {
+		if err := applySeccomp(); err != nil {
+			return err
+		}
+	}
+	return syscall.Exec(path, s.Argv, s.Env)
+}
+
+// lockedSecurebits: uid 0 gains no capabilities on exec and cannot turn
+// that back on (SECBIT_NOROOT, SECBIT_NO_SETUID_FIXUP and SECBIT_KEEP_CAPS
+// off, all locked).
+const lockedSecurebits = 1<<0 | 1<<1 | 1<<2 | 1<<3 | 1<<5
+
+// dropCapabilities empties the bounding, ambient, inheritable, permitted
+// and effective sets and locks securebits, so the command stays without
+// capabilities even though it runs as uid 0 in the namespace.
+func dropCapabilities() error {
+	last := 63
+	if raw, err := os.ReadFile("/proc/sys/kernel/cap_last_cap"); err == nil {
+		if v, err := strconv.Atoi(strings.TrimSpace(string(raw))); err == nil {
+			last = v
+		}
+	}
+	for c := 0; c <= last; c++ {
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0); err != nil && err != unix.EINVAL {
+			return fmt.Errorf("drop bounding capability %d: %w", c, err)
+		}
+	}
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil && err != unix.EINVAL {
+		return fmt.Errorf("clear ambient capabilities: %w", err)
+	}
+	if err := unix.Prctl(unix.PR_SET_SECUREBITS, lockedSecurebits, 0, 0, 0); err != nil {
+		return fmt.Errorf("set securebits: %w", err)
+	}
+	hdr := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+	var data [2]unix.CapUserData
+	if err := unix.Capset(&hdr, &data[0]); err != nil {
+		return fmt.Errorf("clear capabilities: %w", err)
+	}
+	return nil
+}
+
+// lookPath resolves name against the PATH of the command's environment.
+func lookPath(name string, env []string) (string, error) {
+	if strings.Contains(name, "/") {
+		return name, nil
+	}
+	pathList := "/usr/local/bin:/usr/bin:/bin"
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "PATH="); ok {
+			pathList = v
+		}
+	}
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			dir = "."
+		}
+		candidate := filepath.Join(dir, name)
+		if st, err := os.Stat(candidate); err == nil && !st.IsDir() && st.Mode()&0o111 != 0 {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%s: executable not found in PATH", name)
+}
@@ -0,0 +1,214 @@
+package sandbox
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// devNodes are bind-mounted from the host into the sandbox's private /dev.
+var devNodes = []string{"null", "zero", "full", "random", "urandom", "tty"}
+
+// setupMounts makes every mount read-only except the writable dirs, and
+// gives the sandbox a private /tmp, a minimal /dev and, when permitted, a
+// /proc for its own PID namespace.
+func setupMounts(writable []string) error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	// Writable dirs become their own rw mounts, kept open so the ones under
+	// /tmp can be re-attached after the private /tmp hides them.
+	fds := make(map[string]int, len(writable))
+	for _, dir := range writable {
+		if err := unix.Mount(dir, dir, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return fmt.Errorf("bind %s: %w", dir, err)
+		}
+		fd, err := unix.Open(dir, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", dir, err)
+		}
+		fds[dir] = fd
+	}
+
+	points, err := readMountPoints("/proc/self/mountinfo")
+	if err != nil {
+		return err
+	}
+	for _, mp := range points {
+		if underAny(mp, writable) {
+			continue
+		}
+		if err := remountReadOnly(mp); err != nil && mp == "/" {
+			return fmt.Errorf("remount / read-only: %w", err)
+		}
+		// Other mounts that refuse a read-only remount (locked pseudo
+		// filesystems) stay as they are; Landlock still denies writes.
+	}
+
+	if err := unix.Mount("tmpfs", "/tmp", "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "size=64m,mode=1777"); err != nil {
+		return fmt.Errorf("mount private /tmp: %w", err)
+	}
+	for _, dir := range writable {
+		if !under(dir, "/tmp") {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("recreate %s: %w", dir, err)
+		}
+		src := "/proc/self/fd/" + strconv.Itoa(fds[dir])
+		if err := unix.Mount(src, dir, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return fmt.Errorf("bind %s: %w", dir, err)
+		}
+	}
+	for _, fd := range fds {
+		unix.Close(fd)
+	}
+
+	if err := setupDev(); err != nil {
+		return err
+	}
+	// A fresh /proc hides host processes. It is refused when the host /proc
+	// has masked paths (as in most containers); the host view is then kept.
+	_ = unix.Mount("proc", "/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, "")
+	return nil
+}
+
+// setupDev replaces /dev with a tmpfs holding only harmless device nodes,
+// so a sandbox mapped to root's uid cannot open host block devices.
+func setupDev() error {
+	// Keep handles on the host nodes; the tmpfs below hides them.
+	nodes := make(map[string]int, len(devNodes))
+	for _, name := range devNodes {
+		if fd, err := unix.Open(filepath.Join("/dev", name), unix.O_PATH|unix.O_CLOEXEC, 0); err == nil {
+			nodes[name] = fd
+		}
+	}
+	defer func() {
+		for _, fd := range nodes {
+			unix.Close(fd)
+		}
+	}()
+	if err := unix.Mount("tmpfs", "/dev", "tmpfs", unix.MS_NOSUID|unix.MS_NOEXEC, "size=64k,mode=755"); err != nil {
+		return fmt.Errorf("mount private /dev: %w", err)
+	}
+	for name, fd := range nodes {
+		target := filepath.Join("/dev", name)
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0o666)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", target, err)
+		}
+		f.Close()
+		if err := unix.Mount("/proc/self/fd/"+strconv.Itoa(fd), target, "", unix.MS_BIND, ""); err != nil {
+			return fmt.Errorf("bind %s: %w", target, err)
+		}
+	}
+	for link, dest := range map[string]string{
+		"fd":     "/proc/self/fd",
+		"stdin":  "/proc/self/fd/0",
+		"stdout": "/proc/self/fd/1",
+		"stderr": "/proc/self/fd/2",
+	} {
+		if err := os.Symlink(dest, filepath.Join("/dev", link)); err != nil {
+			return fmt.Errorf("link /dev/%s: %w", link, err)
+		}
+	}
+	if err := os.Mkdir("/dev/shm", 0o1777); err != nil {
+		return fmt.Errorf("create /dev/shm: %w", err)
+	}
+	if err := unix.Mount("tmpfs", "/dev/shm", "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "size=64m,mode=1777"); err != nil {
+		return fmt.Errorf("mount /dev/shm: %w", err)
+	}
+	return remountReadOnly("/dev")
+}
+
+// remountReadOnly remounts one mount point read-only, keeping the flags a
+// user namespace is not allowed to clear.
+func remountReadOnly(mp string) error {
+	var st unix.Statfs_t
+	if err := unix.Statfs(mp, &st); err != nil {
+		return err
+	}
+	flags := uintptr(unix.MS_BIND | unix.MS_REMOUNT | unix.MS_RDONLY)
+	for stFlag, msFlag := range map[int64]uintptr{
+		unix.ST_NOSUID:     unix.MS_NOSUID,
+		unix.ST_NODEV:      unix.MS_NODEV,
+		unix.ST_NOEXEC:     unix.MS_NOEXEC,
+		unix.ST_NOATIME:    unix.MS_NOATIME,
+		unix.ST_NODIRATIME: unix.MS_NODIRATIME,
+		unix.ST_RELATIME:   unix.MS_RELATIME,
+	} {
+		if int64(st.Flags)&stFlag != 0 {
+			flags |= msFlag
+		}
+	}
+	return unix.Mount("", mp, "", flags, "")
+}
+
+// readMountPoints returns the distinct mount points listed in a mountinfo
+// file, parents before children.
+func readMountPoints(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read mountinfo: %w", err)
+	}
+	defer f.Close()
+	var points []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		points = append(points, unescapeMountPath(fields[4]))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read mountinfo: %w", err)
+	}
+	slices.SortFunc(points, func(a, b string) int {
+		if d := len(a) - len(b); d != 0 {
+			return d
+		}
+		return strings.Compare(a, b)
+	})
+	return slices.Compact(points), nil
+}
+
+// unescapeMountPath decodes the octal escapes (\040 for space, ...) used in
+// mountinfo paths.
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func under(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, "/")+"/")
+}
+
+func underAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if under(path, dir) {
+			return true
+		}
+	}
+	return false
+}
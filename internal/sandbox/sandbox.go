@@ -0,0 +1,132 @@
+// Package sandbox runs commands in a daemonless Linux sandbox: user, mount,
+// PID, IPC, UTS and (unless allowed) network namespaces, a read-only root
+// with writable bind mounts, Landlock write rules, a seccomp filter and
+// cgroup v2 CPU/memory/pids limits.
+//
+// The sandbox re-executes the current binary as a small init helper, so
+// every binary that creates sandboxed commands must call Init first thing
+// in main (and in TestMain for tests that run sandboxed commands).
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupported is returned when the kernel or binary cannot provide the
+// native sandbox.
+var ErrUnsupported = errors.New("native sandbox unavailable")
+
+// Default resource limits applied when a Policy leaves them zero.
+const (
+	DefaultMemoryBytes = 512 << 20
+	DefaultMaxPIDs     = 128
+	DefaultCPUs        = 1.0
+)
+
+// Policy describes what a sandboxed command may do.
+type Policy struct {
+	// WritableDirs are bind-mounted read-write; everything else is read-only.
+	WritableDirs []string
+	// WorkDir is the command's working directory.
+	WorkDir string
+	// Env is the complete environment of the command.
+	Env []string
+	// Network keeps the host network namespace. When false the command
+	// gets an empty network namespace and Landlock denies TCP.
+	Network bool
+	// Strict refuses to run the command when Landlock, seccomp or cgroup
+	// limits are unavailable. Otherwise the sandbox runs without them and
+	// logs a warning.
+	Strict bool
+
+	MemoryBytes int64
+	MaxPIDs     int
+	CPUs        float64
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.MemoryBytes <= 0 {
+		p.MemoryBytes = DefaultMemoryBytes
+	}
+	if p.MaxPIDs <= 0 {
+		p.MaxPIDs = DefaultMaxPIDs
+	}
+	if p.CPUs <= 0 {
+		p.CPUs = DefaultCPUs
+	}
+	return p
+}
+
+// Features reports which kernel features the sandbox can use.
+type Features struct {
+	UserNamespaces  bool     `json:"userNamespaces"`
+	LandlockABI     int      `json:"landlockAbi"`
+	Seccomp         bool     `json:"seccomp"`
+	CgroupV2        bool     `json:"cgroupV2"`
+	CgroupDelegated bool     `json:"cgroupDelegated"`
+	Notes           []string `json:"notes,omitempty"`
+}
+
+// Usable reports whether sandboxed commands can run at all. Landlock,
+// seccomp and cgroup limits are applied when available.
+func (f Features) Usable() bool {
+	return f.UserNamespaces
+}
+
+// Missing lists the confinement layers the sandbox runs without.
+func (f Features) Missing() []string {
+	var missing []string
+	if f.LandlockABI == 0 {
+		missing = append(missing, "landlock")
+	}
+	if !f.Seccomp {
+		missing = append(missing, "seccomp")
+	}
+	if !f.CgroupDelegated {
+		missing = append(missing, "cgroup limits")
+	}
+	return missing
+}
+
+// Summary renders the features as one line for diagnostics.
+func (f Features) Summary() string {
+	landlock := "unavailable"
+	if f.LandlockABI > 0 {
+		landlock = fmt.Sprintf("ABI %d", f.LandlockABI)
+	}
+	cgroup := "unavailable"
+	switch {
+	case f.CgroupDelegated:
+		cgroup = "delegated"
+	case f.CgroupV2:
+		cgroup = "not delegated"
+	}
+	parts := []string{
+		"user namespaces: " + yesNo(f.UserNamespaces),
+		"landlock: " + landlock,
+		"seccomp: " + yesNo(f.Seccomp),
+		"cgroup v2: " + cgroup,
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Preflight returns an error when the native sandbox cannot be used.
+func Preflight() error {
+	f := Probe()
+	if f.Usable() {
+		return nil
+	}
+	if len(f.Notes) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnsupported, strings.Join(f.Notes, "; "))
+	}
+	return ErrUnsupported
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,229 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// specEnv carries the serialized spec from Command to the init helper.
+	specEnv = "KAFCLAW_SANDBOX_SPEC"
+	// initFailureExit is the helper's exit code when sandbox setup fails
+	// before the command starts.
+	initFailureExit = 125
+)
+
+var (
+	initCalled   atomic.Bool
+	degradedOnce sync.Once
+)
+
+// spec is what the init helper needs to set up the sandbox and exec.
+type spec struct {
+	Argv     []string `json:"argv"`
+	Env      []string `json:"env"`
+	WorkDir  string   `json:"workDir"`
+	Writable []string `json:"writable"`
+	Network  bool     `json:"network"`
+}
+
+// Init must be called at the start of main. In a sandbox helper process it
+// sets up the sandbox and replaces itself with the command; it never
+// returns there. Otherwise it returns immediately.
+func Init() {
+	initCalled.Store(true)
+	raw, ok := os.LookupEnv(specEnv)
+	if !ok {
+		return
+	}
+	var s spec
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: invalid spec: %v\n", err)
+		os.Exit(initFailureExit)
+	}
+	err := runInit(s)
+	fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+	os.Exit(initFailureExit)
+}
+
+// Command prepares argv to run inside the sandbox. The returned release
+// function removes per-command resources and must be called after the
+// command finished.
+func Command(ctx context.Context, p Policy, argv []string) (*exec.Cmd, func(), error) {
+	if len(argv) == 0 {
+		return nil, nil, fmt.Errorf("sandbox: command is required")
+	}
+	if !initCalled.Load() {
+		return nil, nil, fmt.Errorf("%w: sandbox.Init was not called by this binary", ErrUnsupported)
+	}
+	if err := Preflight(); err != nil {
+		return nil, nil, err
+	}
+	features := Probe()
+	if missing := features.Missing(); len(missing) > 0 {
+		if p.Strict {
+			return nil, nil, fmt.Errorf("%w: strict sandbox requires %s", ErrUnsupported, strings.Join(missing, ", "))
+		}
+		degradedOnce.Do(func() {
+			slog.Warn("Native sandbox running without some confinement", "missing", strings.Join(missing, ", "))
+		})
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, nil, fmt.Errorf("sandbox: resolve executable: %w", err)
+	}
+	p = p.withDefaults()
+
+	writable := make([]string, 0, len(p.WritableDirs))
+	for _, dir := range p.WritableDirs {
+		if strings.TrimSpace(dir) == "" {
+			continue
+		}
+		abs, err := filepath.Abs(dir)
+		if err == nil {
+			abs, err = filepath.EvalSymlinks(abs)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("sandbox: writable dir %s: %w", dir, err)
+		}
+		writable = append(writable, abs)
+	}
+	workDir := p.WorkDir
+	if workDir != "" {
+		if abs, err := filepath.Abs(workDir); err == nil {
+			workDir = abs
+		}
+	}
+	raw, err := json.Marshal(spec{
+		Argv:     argv,
+		Env:      p.Env,
+		WorkDir:  workDir,
+		Writable: writable,
+		Network:  p.Network,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, self)
+	cmd.Args = append([]string{"kafclaw-sandbox"}, argv...)
+	cmd.Env = []string{specEnv + "=" + string(raw)}
+	cmd.SysProcAttr = sysProcAttr(p.Network)
+
+	release := func() {}
+	if features.CgroupDelegated {
+		cg, err := newCgroup(p)
+		if err != nil {
+			if p.Strict {
+				return nil, nil, fmt.Errorf("sandbox: cgroup limits: %w", err)
+			}
+			slog.Warn("Sandbox cgroup limits unavailable", "error", err)
+		} else {
+			cmd.SysProcAttr.UseCgroupFD = true
+			cmd.SysProcAttr.CgroupFD = cg.fd
+			release = cg.remove
+		}
+	}
+	return cmd, release, nil
+}
+
+func sysProcAttr(network bool) *syscall.SysProcAttr {
+	flags := uintptr(unix.CLONE_NEWUSER | unix.CLONE_NEWNS | unix.CLONE_NEWPID | unix.CLONE_NEWIPC | unix.CLONE_NEWUTS)
+	if !network {
+		flags |= unix.CLONE_NEWNET
+	}
+	// The helper needs to be root in the namespace to set up mounts; it
+	// drops every capability before running the command.
+	return &syscall.SysProcAttr{
+		Cloneflags:                 flags,
+		UidMappings:                []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}},
+		GidMappings:                []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}},
+		GidMappingsEnableSetgroups: false,
+		Pdeathsig:                  syscall.SIGKILL,
+	}
+}
+
+var (
+	probeOnce sync.Once
+	probed    Features
+)
+
+// Probe reports the sandbox features of the running kernel. The result is
+// computed once per process.
+func Probe() Features {
+	probeOnce.Do(func() { probed = probe() })
+	return probed
+}
+
+func probe() Features {
+	var f Features
+	if err := probeUserNamespaces(); err != nil {
+		f.Notes = append(f.Notes, "user namespaces: "+err.Error())
+	} else {
+		f.UserNamespaces = true
+	}
+	f.LandlockABI = landlockABI()
+	if f.LandlockABI == 0 {
+		f.Notes = append(f.Notes, "landlock not enabled (writes are limited by read-only mounts only)")
+	}
+	if seccompArch != 0 {
+		if _, err := unix.PrctlRetInt(unix.PR_GET_SECCOMP, 0, 0, 0, 0); err == nil {
+			f.Seccomp = true
+		}
+	}
+	if !f.Seccomp {
+		f.Notes = append(f.Notes, "seccomp filter unsupported on this kernel or architecture")
+	}
+	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err == nil {
+		f.CgroupV2 = true
+		if cg, err := newCgroup(Policy{}.withDefaults()); err == nil {
+			cg.remove()
+			f.CgroupDelegated = true
+		} else {
+			f.Notes = append(f.Notes, "cgroup v2 not delegated to this user: "+err.Error())
+		}
+	} else {
+		f.Notes = append(f.Notes, "cgroup v2 not mounted (no CPU/memory/pids limits)")
+	}
+	return f
+}
+
+// probeUserNamespaces starts a trivial process in fresh namespaces.
+func probeUserNamespaces() error {
+	if raw, err := os.ReadFile("/proc/sys/user/max_user_namespaces"); err == nil && strings.TrimSpace(string(raw)) == "0" {
+		return fmt.Errorf("disabled (user.max_user_namespaces=0)")
+	}
+	if raw, err := os.ReadFile("/proc/sys/kernel/unprivileged_userns_clone"); err == nil && strings.TrimSpace(string(raw)) == "0" && os.Getuid() != 0 {
+		return fmt.Errorf("disabled for unprivileged users (kernel.unprivileged_userns_clone=0)")
+	}
+	bin := ""
+	for _, candidate := range []string{"true", "sh"} {
+		if p, err := exec.LookPath(candidate); err == nil {
+			bin = p
+			break
+		}
+	}
+	if bin == "" {
+		return fmt.Errorf("no probe binary (true/sh) in PATH")
+	}
+	cmd := exec.Command(bin)
+	if filepath.Base(bin) == "sh" {
+		cmd.Args = []string{"sh", "-c", "exit 0"}
+	}
+	cmd.SysProcAttr = sysProcAttr(false)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("cannot create namespaces: %w", err)
+	}
+	return nil
+}
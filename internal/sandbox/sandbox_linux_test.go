@@ -0,0 +1,171 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestMain(m *testing.M) {
+	Init()
+	os.Exit(m.Run())
+}
+
+func TestReadMountPoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mountinfo")
+	info := strings.Join([]string{
+		"22 1 8:1 / / rw,relatime - ext4 /dev/sda1 rw",
+		"40 22 0:5 / /dev rw,nosuid - devtmpfs udev rw",
+		"41 22 0:6 / /mnt/my\\040disk rw - ext4 /dev/sdb1 rw",
+		"42 22 0:6 / /dev rw,nosuid - devtmpfs udev rw",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(info), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readMountPoints(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/", "/dev", "/mnt/my disk"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("mount points = %q, want %q", got, want)
+	}
+	if !underAny("/work/repo/sub", []string{"/work/repo"}) || underAny("/work/repository", []string{"/work/repo"}) {
+		t.Fatal("under must match whole path components")
+	}
+}
+
+func TestSeccompFilterJumpsStayInProgram(t *testing.T) {
+	if seccompArch == 0 {
+		t.Skip("no seccomp filter on this architecture")
+	}
+	prog := seccompFilter()
+	if prog[0].K != seccompDataArch || prog[2].K != unix.SECCOMP_RET_KILL_PROCESS {
+		t.Fatal("filter must check the architecture first")
+	}
+	for i, ins := range prog {
+		if ins.Code&0x07 != unix.BPF_JMP {
+			continue
+		}
+		for _, off := range []uint8{ins.Jt, ins.Jf} {
+			if i+1+int(off) >= len(prog) {
+				t.Fatalf("instruction %d jumps past the end", i)
+			}
+		}
+	}
+	last := prog[len(prog)-3:]
+	if last[0].K != unix.SECCOMP_RET_ALLOW || last[1].K != seccompErrnoEPERM || last[2].K != seccompErrnoENOSYS {
+		t.Fatalf("unexpected filter tail %+v", last)
+	}
+}
+
+func TestLandlockWriteAccessByABI(t *testing.T) {
+	if landlockWriteAccess(1)&unix.LANDLOCK_ACCESS_FS_REFER != 0 {
+		t.Fatal("ABI 1 does not know REFER")
+	}
+	if landlockWriteAccess(3)&unix.LANDLOCK_ACCESS_FS_TRUNCATE == 0 {
+		t.Fatal("ABI 3 must handle TRUNCATE")
+	}
+	if landlockWriteAccess(4)&unix.LANDLOCK_ACCESS_FS_READ_FILE != 0 {
+		t.Fatal("reads must stay unrestricted")
+	}
+}
+
+func TestCommandConfinesWritesAndNetwork(t *testing.T) {
+	if err := Preflight(); err != nil {
+		t.Skipf("native sandbox unavailable: %v", err)
+	}
+	writable := t.TempDir()
+	readonly := t.TempDir()
+	script := `echo ok > "$W/out"
+if echo x > "$R/blocked" 2>/dev/null; then echo wrote-readonly; fi
+if echo x > /tmp/scratch; then echo tmp-ok; fi
+grep -c : /proc/net/dev
+grep CapEff /proc/self/status`
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	cmd, release, err := Command(ctx, Policy{
+		WritableDirs: []string{writable},
+		WorkDir:      writable,
+		Env:          []string{"PATH=/usr/local/bin:/usr/bin:/bin", "W=" + writable, "R=" + readonly},
+	}, []string{"sh", "-c", script})
+	if err != nil {
+		t.Fatalf("command: %v", err)
+	}
+	defer release()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("run: %v\nstdout: %s\nstderr: %s", err, stdout.String(), stderr.String())
+	}
+	out := stdout.String()
+	if strings.Contains(out, "wrote-readonly") {
+		t.Fatalf("write outside writable dirs succeeded:\n%s", out)
+	}
+	if !strings.Contains(out, "tmp-ok") {
+		t.Fatalf("private /tmp not writable:\n%s\n%s", out, stderr.String())
+	}
+	lines := strings.Fields(out)
+	if len(lines) < 3 || lines[len(lines)-3] != "1" {
+		t.Fatalf("expected only loopback in the network namespace, got:\n%s", out)
+	}
+	if lines[len(lines)-1] != "0000000000000000" {
+		t.Fatalf("command must run without capabilities, got:\n%s", out)
+	}
+	if data, err := os.ReadFile(filepath.Join(writable, "out")); err != nil || string(data) != "ok\n" {
+		t.Fatalf("writable dir write missing: %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(readonly, "blocked")); err == nil {
+		t.Fatal("read-only dir was written")
+	}
+}
+
+func TestCommandDeniesMountInsideSandbox(t *testing.T) {
+	if err := Preflight(); err != nil {
+		t.Skipf("native sandbox unavailable: %v", err)
+	}
+	if seccompArch == 0 {
+		t.Skip("no seccomp filter on this architecture")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	cmd, release, err := Command(ctx, Policy{Env: []string{"PATH=/usr/bin:/bin"}}, []string{"grep", "Seccomp:", "/proc/self/status"})
+	if err != nil {
+		t.Fatalf("command: %v", err)
+	}
+	defer release()
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.Contains(string(out), "2") {
+		t.Fatalf("expected seccomp filter mode 2, got %q", out)
+	}
+}
+
+func TestStrictCommandRequiresEveryLayer(t *testing.T) {
+	if err := Preflight(); err != nil {
+		t.Skipf("native sandbox unavailable: %v", err)
+	}
+	cmd, release, err := Command(context.Background(), Policy{Strict: true, Env: []string{"PATH=/usr/bin:/bin"}}, []string{"true"})
+	if missing := Probe().Missing(); len(missing) > 0 {
+		if err == nil || !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("expected strict sandbox to refuse without %v, got %v", missing, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("command: %v", err)
+	}
+	defer release()
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+}
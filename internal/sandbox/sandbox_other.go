@@ -0,0 +1,22 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+)
+
+// Init is a no-op on platforms without the native sandbox.
+func Init() {}
+
+// Probe reports that no sandbox features are available.
+func Probe() Features {
+	return Features{Notes: []string{"native sandbox requires Linux, running on " + runtime.GOOS}}
+}
+
+// Command always fails on platforms without the native sandbox.
+func Command(ctx context.Context, p Policy, argv []string) (*exec.Cmd, func(), error) {
+	return nil, nil, ErrUnsupported
+}
@@ -0,0 +1,41 @@
+package sandbox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicyDefaults(t *testing.T) {
+	p := Policy{}.withDefaults()
+	if p.MemoryBytes != DefaultMemoryBytes || p.MaxPIDs != DefaultMaxPIDs || p.CPUs != DefaultCPUs {
+		t.Fatalf("unexpected defaults %+v", p)
+	}
+	p = Policy{MemoryBytes: 1 << 20, MaxPIDs: 4, CPUs: 0.5}.withDefaults()
+	if p.MemoryBytes != 1<<20 || p.MaxPIDs != 4 || p.CPUs != 0.5 {
+		t.Fatalf("explicit limits overwritten: %+v", p)
+	}
+}
+
+func TestFeaturesSummary(t *testing.T) {
+	f := Features{UserNamespaces: true, LandlockABI: 4, Seccomp: true, CgroupV2: true}
+	got := f.Summary()
+	for _, want := range []string{"user namespaces: yes", "landlock: ABI 4", "seccomp: yes", "cgroup v2: not delegated"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("summary %q missing %q", got, want)
+		}
+	}
+	if !f.Usable() || (Features{LandlockABI: 4}).Usable() {
+		t.Fatal("usability must follow user namespace support")
+	}
+}
+
+func TestFeaturesMissing(t *testing.T) {
+	full := Features{UserNamespaces: true, LandlockABI: 1, Seccomp: true, CgroupV2: true, CgroupDelegated: true}
+	if missing := full.Missing(); len(missing) != 0 {
+		t.Fatalf("expected nothing missing, got %v", missing)
+	}
+	got := strings.Join(Features{UserNamespaces: true, CgroupV2: true}.Missing(), ", ")
+	if got != "landlock, seccomp, cgroup limits" {
+		t.Fatalf("missing = %q", got)
+	}
+}
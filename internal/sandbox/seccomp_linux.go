@@ -0,0 +1,105 @@
+//go:build linux && (amd64 || arm64)
+
+package sandbox
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// deniedSyscalls fail with EPERM inside the sandbox: mount and namespace
+// manipulation, tracing, kernel modules and keyrings, and host-wide
+// settings.
+var deniedSyscalls = append([]uintptr{
+	unix.SYS_MOUNT, unix.SYS_UMOUNT2, unix.SYS_PIVOT_ROOT, unix.SYS_CHROOT,
+	unix.SYS_FSOPEN, unix.SYS_FSCONFIG, unix.SYS_FSMOUNT, unix.SYS_FSPICK,
+	unix.SYS_MOVE_MOUNT, unix.SYS_OPEN_TREE, unix.SYS_MOUNT_SETATTR,
+	unix.SYS_UNSHARE, unix.SYS_SETNS,
+	unix.SYS_PTRACE, unix.SYS_PROCESS_VM_READV, unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_KEXEC_LOAD, unix.SYS_KEXEC_FILE_LOAD,
+	unix.SYS_INIT_MODULE, unix.SYS_FINIT_MODULE, unix.SYS_DELETE_MODULE,
+	unix.SYS_REBOOT, unix.SYS_SWAPON, unix.SYS_SWAPOFF, unix.SYS_ACCT,
+	unix.SYS_BPF, unix.SYS_PERF_EVENT_OPEN, unix.SYS_USERFAULTFD,
+	unix.SYS_KEYCTL, unix.SYS_ADD_KEY, unix.SYS_REQUEST_KEY,
+	unix.SYS_OPEN_BY_HANDLE_AT, unix.SYS_NAME_TO_HANDLE_AT,
+	unix.SYS_SETTIMEOFDAY, unix.SYS_CLOCK_SETTIME, unix.SYS_CLOCK_ADJTIME, unix.SYS_ADJTIMEX,
+	unix.SYS_QUOTACTL, unix.SYS_SYSLOG, unix.SYS_LOOKUP_DCOOKIE, unix.SYS_NFSSERVCTL, unix.SYS_VHANGUP,
+}, archDeniedSyscalls...)
+
+// cloneNamespaceFlags are rejected in clone(2); clone3 hides its flags in
+// memory, so it returns ENOSYS and libc falls back to clone.
+const cloneNamespaceFlags = unix.CLONE_NEWNS | unix.CLONE_NEWUSER | unix.CLONE_NEWPID |
+	unix.CLONE_NEWNET | unix.CLONE_NEWIPC | unix.CLONE_NEWUTS | unix.CLONE_NEWCGROUP
+
+// Offsets into struct seccomp_data.
+const (
+	seccompDataNR       = 0
+	seccompDataArch     = 4
+	seccompDataArg0Low  = 16 // little-endian low word of args[0]
+	seccompErrnoEPERM   = unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)
+	seccompErrnoENOSYS  = unix.SECCOMP_RET_ERRNO | uint32(unix.ENOSYS)
+	seccompJumpToLabel  = ^uint8(0)
+	bpfLoadWord         = unix.BPF_LD | unix.BPF_W | unix.BPF_ABS
+	bpfJumpEqual        = unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K
+	bpfJumpGreaterEqual = unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K
+	bpfJumpSet          = unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K
+	bpfReturn           = unix.BPF_RET | unix.BPF_K
+)
+
+// seccompFilter builds the BPF program. Other architectures are killed,
+// denied syscalls return EPERM, everything else is allowed.
+func seccompFilter() []unix.SockFilter {
+	type jump struct {
+		at    int
+		label string
+	}
+	var prog []unix.SockFilter
+	var jumps []jump
+	stmt := func(code uint16, k uint32) {
+		prog = append(prog, unix.SockFilter{Code: code, K: k})
+	}
+	// jumpTo emits a conditional jump to label when true, else falls through.
+	jumpTo := func(code uint16, k uint32, label string) {
+		jumps = append(jumps, jump{at: len(prog), label: label})
+		prog = append(prog, unix.SockFilter{Code: code, K: k, Jt: seccompJumpToLabel})
+	}
+
+	stmt(bpfLoadWord, seccompDataArch)
+	prog = append(prog, unix.SockFilter{Code: bpfJumpEqual, K: seccompArch, Jt: 1})
+	stmt(bpfReturn, unix.SECCOMP_RET_KILL_PROCESS)
+	stmt(bpfLoadWord, seccompDataNR)
+	if seccompX32Bit != 0 {
+		jumpTo(bpfJumpGreaterEqual, seccompX32Bit, "eperm")
+	}
+	for _, nr := range deniedSyscalls {
+		jumpTo(bpfJumpEqual, uint32(nr), "eperm")
+	}
+	jumpTo(bpfJumpEqual, unix.SYS_CLONE3, "enosys")
+	prog = append(prog, unix.SockFilter{Code: bpfJumpEqual, K: unix.SYS_CLONE, Jf: 2})
+	stmt(bpfLoadWord, seccompDataArg0Low)
+	jumpTo(bpfJumpSet, cloneNamespaceFlags, "eperm")
+	labels := map[string]int{"allow": len(prog)}
+	stmt(bpfReturn, unix.SECCOMP_RET_ALLOW)
+	labels["eperm"] = len(prog)
+	stmt(bpfReturn, seccompErrnoEPERM)
+	labels["enosys"] = len(prog)
+	stmt(bpfReturn, seccompErrnoENOSYS)
+
+	for _, j := range jumps {
+		prog[j.at].Jt = uint8(labels[j.label] - j.at - 1)
+	}
+	return prog
+}
+
+// applySeccomp installs the filter on the calling thread. no_new_privs
+// must already be set.
+func applySeccomp() error {
+	filter := seccompFilter()
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0); err != nil {
+		return fmt.Errorf("install seccomp filter: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package sandbox
+
+import "golang.org/x/sys/unix"
+
+const (
+	seccompArch = unix.AUDIT_ARCH_X86_64
+	// seccompX32Bit marks x32 ABI syscall numbers, which are all denied.
+	seccompX32Bit = 0x40000000
+)
+
+var archDeniedSyscalls = []uintptr{unix.SYS_IOPL, unix.SYS_IOPERM, unix.SYS_USELIB}
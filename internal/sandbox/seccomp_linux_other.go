@@ -0,0 +1,14 @@
+//go:build linux && !amd64 && !arm64
+
+package sandbox
+
+// The seccomp filter is only built for amd64 and arm64; elsewhere the
+// sandbox relies on namespaces and Landlock.
+const (
+	seccompArch   = 0
+	seccompX32Bit = 0
+)
+
+var archDeniedSyscalls []uintptr
+
+func applySeccomp() error { return nil }
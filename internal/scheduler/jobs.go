@@ -0,0 +1,275 @@
+package scheduler
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// Misfire policies decide what happens to runs missed while the scheduler
+// was down.
+const (
+	// MisfireRunOnce runs a job once for all missed runs (default).
+	MisfireRunOnce = "run_once"
+	// MisfireSkip records missed runs without running them.
+	MisfireSkip = "skip"
+	// MisfireRunAll runs every missed run, up to MaxCatchUpRuns.
+	MisfireRunAll = "run_all"
+)
+
+// MaxCatchUpRuns caps the runs dispatched for one job in one tick.
+const MaxCatchUpRuns = 10
+
+// Run statuses in scheduled_job_runs. A dispatched run ends as completed,
+// failed or timeout.
+const (
+	RunDispatched = "dispatched"
+	RunCompleted  = "completed"
+	RunFailed     = "failed"
+	RunTimedOut   = "timeout"
+	RunMissed     = "missed"
+	// RunSkipped is a run dropped because its category was at capacity.
+	RunSkipped = "skipped_concurrency"
+)
+
+var jobNameExpr = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// Target is where a user job runs and delivers its result: the channel,
+// chat and thread of the conversation that created it.
+type Target struct {
+	Channel     string `json:"channel,omitempty"`
+	ChatID      string `json:"chatId,omitempty"`
+	ThreadID    string `json:"threadId,omitempty"`
+	SenderID    string `json:"senderId,omitempty"`
+	SessionKey  string `json:"sessionKey,omitempty"`
+	MessageType string `json:"messageType,omitempty"`
+}
+
+// JobSpec describes a user job to create.
+type JobSpec struct {
+	// Name is optional; a random name is generated when empty.
+	Name string
+	// When is parsed with ParseSchedule.
+	When string
+	// Content is the prompt the agent runs when the job is due.
+	Content string
+	Target  Target
+	// Misfire is one of the Misfire* policies (default run_once).
+	Misfire string
+	// Timeout bounds each run; 0 uses the scheduler's JobTimeout.
+	Timeout   time.Duration
+	CreatedBy string
+}
+
+// AddJob parses spec.When in loc and stores the job with its first run.
+func AddJob(tl *timeline.TimelineService, spec JobSpec, now time.Time, loc *time.Location) (*timeline.ScheduledJobRecord, error) {
+	if strings.TrimSpace(spec.Content) == "" {
+		return nil, fmt.Errorf("job content is required")
+	}
+	misfire, err := normalizeMisfire(spec.Misfire)
+	if err != nil {
+		return nil, err
+	}
+	sched, err := ParseSchedule(spec.When, now, loc)
+	if err != nil {
+		return nil, err
+	}
+	next := sched.Next(now)
+	if next.IsZero() {
+		return nil, fmt.Errorf("schedule %q never runs", spec.When)
+	}
+	name := strings.ToLower(strings.TrimSpace(spec.Name))
+	if name == "" {
+		name = newJobName()
+	}
+	if spec.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative")
+	}
+	if !jobNameExpr.MatchString(name) {
+		return nil, fmt.Errorf("invalid job name %q (use a-z, 0-9, '.', '_' or '-')", spec.Name)
+	}
+	if existing, err := tl.GetScheduledJob(name); err == nil && existing != nil {
+		return nil, fmt.Errorf("job %q already exists", name)
+	}
+
+	t := spec.Target
+	if t.Channel == "" {
+		t.Channel, t.ChatID = "scheduler", "scheduler:"+name
+	}
+	if t.MessageType == "" {
+		t.MessageType = "internal"
+	}
+	rec := &timeline.ScheduledJobRecord{
+		JobName:       name,
+		Kind:          sched.Kind,
+		Schedule:      sched.Expr,
+		Timezone:      sched.Timezone(),
+		Content:       strings.TrimSpace(spec.Content),
+		Channel:       t.Channel,
+		ChatID:        t.ChatID,
+		ThreadID:      t.ThreadID,
+		SenderID:      t.SenderID,
+		SessionKey:    t.SessionKey,
+		MessageType:   t.MessageType,
+		MisfirePolicy: misfire,
+		NextRunAt:     &next,
+		CreatedBy:     spec.CreatedBy,
+
+		TimeoutSeconds: int(spec.Timeout / time.Second),
+	}
+	if err := tl.CreateScheduledJob(rec); err != nil {
+		return nil, err
+	}
+	return tl.GetScheduledJob(name)
+}
+
+// PauseJob stops a job from running until it is resumed.
+func PauseJob(tl *timeline.TimelineService, name string) error {
+	job, err := getUserJob(tl, name)
+	if err != nil {
+		return err
+	}
+	return tl.SetScheduledJobState(job.JobName, true, job.NextRunAt)
+}
+
+// ResumeJob reactivates a paused job. Runs that fell due while it was
+// paused are skipped: the next run is computed from now.
+func ResumeJob(tl *timeline.TimelineService, name string, now time.Time) (*timeline.ScheduledJobRecord, error) {
+	job, err := getUserJob(tl, name)
+	if err != nil {
+		return nil, err
+	}
+	sched, err := NewSchedule(job.Kind, job.Schedule, job.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	next := sched.Next(now)
+	var nextPtr *time.Time
+	if !next.IsZero() {
+		nextPtr = &next
+	}
+	if err := tl.SetScheduledJobState(job.JobName, false, nextPtr); err != nil {
+		return nil, err
+	}
+	return tl.GetScheduledJob(job.JobName)
+}
+
+// RemoveJob deletes a user job and its run history.
+func RemoveJob(tl *timeline.TimelineService, name string) error {
+	job, err := getUserJob(tl, name)
+	if err != nil {
+		return err
+	}
+	return tl.DeleteScheduledJob(job.JobName)
+}
+
+// ListJobs returns the user jobs, including paused and finished ones.
+func ListJobs(tl *timeline.TimelineService) ([]timeline.ScheduledJobRecord, error) {
+	all, err := tl.ListScheduledJobs()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]timeline.ScheduledJobRecord, 0, len(all))
+	for _, j := range all {
+		if j.Kind != "" {
+			out = append(out, j)
+		}
+	}
+	return out, nil
+}
+
+// JobStatus describes a user job as active, paused or done.
+func JobStatus(job timeline.ScheduledJobRecord) string {
+	switch {
+	case job.Paused:
+		return "paused"
+	case job.NextRunAt == nil:
+		return "done"
+	default:
+		return "active"
+	}
+}
+
+// DescribeSchedule renders a job's schedule for listings.
+func DescribeSchedule(job timeline.ScheduledJobRecord) string {
+	sched, err := NewSchedule(job.Kind, job.Schedule, job.Timezone)
+	if err != nil {
+		return job.Kind + " " + job.Schedule
+	}
+	return sched.String()
+}
+
+func getUserJob(tl *timeline.TimelineService, name string) (*timeline.ScheduledJobRecord, error) {
+	job, err := tl.GetScheduledJob(strings.ToLower(strings.TrimSpace(name)))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && job.Kind == "") {
+		return nil, fmt.Errorf("job %q not found", name)
+	}
+	return job, err
+}
+
+func normalizeMisfire(policy string) (string, error) {
+	switch p := strings.ToLower(strings.TrimSpace(policy)); p {
+	case "":
+		return MisfireRunOnce, nil
+	case MisfireRunOnce, MisfireSkip, MisfireRunAll:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown misfire policy %q (use run_once, skip or run_all)", policy)
+	}
+}
+
+func newJobName() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return "job-" + hex.EncodeToString(b)
+}
+
+// planRuns splits the runs of a due job into those to dispatch and those
+// recorded as missed, and returns the next run after now. Runs later than
+// grace after their time count as missed and follow the misfire policy.
+func planRuns(job timeline.ScheduledJobRecord, sched *Schedule, now time.Time, grace time.Duration) (dispatch, missed []time.Time, next time.Time) {
+	var due []time.Time
+	t := *job.NextRunAt
+	for !t.IsZero() && !t.After(now) && len(due) < 1000 {
+		due = append(due, t)
+		t = sched.Next(t)
+	}
+	next = t
+	if !next.IsZero() && !next.After(now) {
+		// Too many runs to list; continue from now.
+		next = sched.Next(now)
+	}
+	if len(due) == 0 {
+		return nil, nil, next
+	}
+	last := due[len(due)-1]
+	if now.Sub(last) <= grace {
+		// The latest run is on time; earlier ones were missed.
+		dispatch, due = []time.Time{last}, due[:len(due)-1]
+		if len(due) == 0 {
+			return dispatch, nil, next
+		}
+	}
+	switch job.MisfirePolicy {
+	case MisfireSkip:
+		missed = due
+	case MisfireRunAll:
+		if len(due) > MaxCatchUpRuns {
+			missed, due = due[:len(due)-MaxCatchUpRuns], due[len(due)-MaxCatchUpRuns:]
+		}
+		dispatch = append(due, dispatch...)
+	default:
+		if len(dispatch) == 0 {
+			dispatch, due = due[len(due)-1:], due[:len(due)-1]
+		}
+		missed = due
+	}
+	return dispatch, missed, next
+}
@@ -0,0 +1,251 @@
+package scheduler
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func newJobsTimeline(t *testing.T) *timeline.TimelineService {
+	t.Helper()
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	t.Cleanup(func() { _ = tl.Close() })
+	return tl
+}
+
+func TestPlanRunsMisfirePolicies(t *testing.T) {
+	sched, err := NewSchedule(KindInterval, "1h", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)
+	// Down for five hours: runs at 00:00..04:00 are overdue, 05:00 is now.
+	now := start.Add(5*time.Hour + 10*time.Second)
+	grace := time.Minute
+
+	tests := []struct {
+		policy           string
+		dispatch, missed int
+	}{
+		{MisfireRunOnce, 1, 5},
+		{MisfireSkip, 1, 5},
+		{MisfireRunAll, 6, 0},
+	}
+	for _, tt := range tests {
+		job := timeline.ScheduledJobRecord{MisfirePolicy: tt.policy, NextRunAt: &start}
+		dispatch, missed, next := planRuns(job, sched, now, grace)
+		if len(dispatch) != tt.dispatch || len(missed) != tt.missed {
+			t.Errorf("%s: dispatch=%d missed=%d, want %d/%d", tt.policy, len(dispatch), len(missed), tt.dispatch, tt.missed)
+		}
+		if !next.Equal(start.Add(6 * time.Hour)) {
+			t.Errorf("%s: next = %s", tt.policy, next)
+		}
+	}
+
+	// Without an on-time run, skip dispatches nothing and run_once the latest.
+	late := start.Add(5*time.Hour + 30*time.Minute)
+	job := timeline.ScheduledJobRecord{MisfirePolicy: MisfireSkip, NextRunAt: &start}
+	if dispatch, missed, _ := planRuns(job, sched, late, grace); len(dispatch) != 0 || len(missed) != 6 {
+		t.Errorf("skip: dispatch=%d missed=%d", len(dispatch), len(missed))
+	}
+	job.MisfirePolicy = MisfireRunOnce
+	dispatch, missed, _ := planRuns(job, sched, late, grace)
+	if len(dispatch) != 1 || !dispatch[0].Equal(start.Add(5*time.Hour)) || len(missed) != 5 {
+		t.Errorf("run_once: dispatch=%v missed=%d", dispatch, len(missed))
+	}
+
+	// run_all is capped at MaxCatchUpRuns.
+	longAgo := start.Add(-48 * time.Hour)
+	job = timeline.ScheduledJobRecord{MisfirePolicy: MisfireRunAll, NextRunAt: &longAgo}
+	dispatch, missed, _ = planRuns(job, sched, late, grace)
+	if len(dispatch) != MaxCatchUpRuns || len(missed) != 54-MaxCatchUpRuns {
+		t.Errorf("run_all cap: dispatch=%d missed=%d", len(dispatch), len(missed))
+	}
+}
+
+func TestUserJobsDispatchToOriginalTarget(t *testing.T) {
+	tl := newJobsTimeline(t)
+	b := bus.NewMessageBus()
+	s := New(Config{Enabled: true, TickInterval: time.Minute, LockPath: filepath.Join(t.TempDir(), "s.lock")}, b, tl)
+
+	created := time.Date(2026, 3, 6, 8, 0, 0, 0, time.UTC)
+	job, err := AddJob(tl, JobSpec{
+		Name:    "Standup",
+		When:    "every day 9:00 UTC",
+		Content: "Summarize open PRs",
+		Target: Target{
+			Channel:     "slack",
+			ChatID:      "C123",
+			ThreadID:    "1700000000.1",
+			SenderID:    "U1",
+			MessageType: bus.MessageTypeExternal,
+		},
+		Misfire:   MisfireRunOnce,
+		CreatedBy: "test",
+	}, created, time.UTC)
+	if err != nil {
+		t.Fatalf("add job: %v", err)
+	}
+	if job.JobName != "standup" || !job.NextRunAt.Equal(time.Date(2026, 3, 6, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected job %+v", job)
+	}
+	if _, err := AddJob(tl, JobSpec{Name: "standup", When: "in 1h", Content: "x"}, created, time.UTC); err == nil {
+		t.Fatal("expected duplicate name to fail")
+	}
+
+	// The scheduler was down for three days; run_once catches up once.
+	now := time.Date(2026, 3, 9, 9, 30, 0, 0, time.UTC)
+	s.runUserJobs(context.Background(), now)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, err := b.ConsumeInbound(ctx)
+	if err != nil {
+		t.Fatalf("expected dispatched job: %v", err)
+	}
+	if msg.Channel != "slack" || msg.ChatID != "C123" || msg.ThreadID != "1700000000.1" || msg.SenderID != "U1" {
+		t.Fatalf("job not sent to its original target: %+v", msg)
+	}
+	if !strings.HasPrefix(msg.TraceID, "sched-standup-") || msg.IdempotencyKey != "sched:"+msg.TraceID || !strings.Contains(msg.Content, "Summarize open PRs") {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	if msg.Metadata[bus.MetaKeyMessageType] != bus.MessageTypeExternal {
+		t.Fatalf("expected external message type, got %v", msg.Metadata)
+	}
+
+	runs, err := tl.ListScheduledJobRuns("standup", 10)
+	if err != nil {
+		t.Fatalf("list runs: %v", err)
+	}
+	if len(runs) != 4 || runs[0].Status != RunDispatched || runs[0].TraceID != msg.TraceID || runs[1].Status != RunMissed {
+		t.Fatalf("unexpected run history %+v", runs)
+	}
+	job, _ = tl.GetScheduledJob("standup")
+	if !job.NextRunAt.Equal(time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)) || job.RunCount != 1 {
+		t.Fatalf("unexpected job after catch-up %+v", job)
+	}
+
+	// Paused jobs do not run; resuming skips what fell due meanwhile.
+	if err := PauseJob(tl, "standup"); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	s.runUserJobs(context.Background(), now.Add(48*time.Hour))
+	if runs, _ := tl.ListScheduledJobRuns("standup", 10); len(runs) != 4 {
+		t.Fatalf("paused job ran: %+v", runs)
+	}
+	resumed, err := ResumeJob(tl, "standup", now.Add(48*time.Hour))
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if JobStatus(*resumed) != "active" || !resumed.NextRunAt.Equal(time.Date(2026, 3, 12, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected resumed job %+v", resumed)
+	}
+}
+
+func TestOnceJobFinishesAfterRun(t *testing.T) {
+	tl := newJobsTimeline(t)
+	b := bus.NewMessageBus()
+	s := New(Config{Enabled: true, TickInterval: time.Minute, LockPath: filepath.Join(t.TempDir(), "s.lock")}, b, tl)
+
+	created := time.Date(2026, 3, 6, 8, 0, 0, 0, time.UTC)
+	job, err := AddJob(tl, JobSpec{When: "in 2 hours", Content: "Check the deploy"}, created, time.UTC)
+	if err != nil {
+		t.Fatalf("add job: %v", err)
+	}
+	if !strings.HasPrefix(job.JobName, "job-") || job.Channel != "scheduler" || job.MessageType != bus.MessageTypeInternal {
+		t.Fatalf("unexpected defaults %+v", job)
+	}
+	s.runUserJobs(context.Background(), created.Add(2*time.Hour+time.Second))
+	job, _ = tl.GetScheduledJob(job.JobName)
+	if JobStatus(*job) != "done" || job.RunCount != 1 {
+		t.Fatalf("expected finished job, got %+v", job)
+	}
+	if due, _ := tl.ListUserScheduledJobs(); len(due) != 0 {
+		t.Fatalf("finished job still due: %+v", due)
+	}
+	if err := RemoveJob(tl, job.JobName); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := RemoveJob(tl, job.JobName); err == nil {
+		t.Fatal("expected removing a missing job to fail")
+	}
+}
+
+func TestUserJobRunsOnceAcrossSchedulersSharingTimeline(t *testing.T) {
+	tl := newJobsTimeline(t)
+	b := bus.NewMessageBus()
+	newScheduler := func() *Scheduler {
+		return New(Config{Enabled: true, TickInterval: time.Minute, LockPath: filepath.Join(t.TempDir(), "s.lock")}, b, tl)
+	}
+	s1, s2 := newScheduler(), newScheduler()
+
+	created := time.Date(2026, 3, 6, 8, 0, 0, 0, time.UTC)
+	if _, err := AddJob(tl, JobSpec{Name: "ping", When: "every 1h", Content: "ping", Timeout: 30 * time.Second}, created, time.UTC); err != nil {
+		t.Fatalf("add job: %v", err)
+	}
+	due := created.Add(time.Hour + time.Second)
+	s1.runUserJobs(context.Background(), due)
+	s2.runUserJobs(context.Background(), due)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, err := b.ConsumeInbound(ctx)
+	if err != nil {
+		t.Fatalf("expected one dispatched run: %v", err)
+	}
+	if time.Until(msg.Deadline) > 30*time.Second {
+		t.Fatalf("expected the job timeout as deadline, got %s", time.Until(msg.Deadline))
+	}
+	time.Sleep(50 * time.Millisecond)
+	if b.InboundSize() != 0 {
+		t.Fatal("run dispatched twice")
+	}
+	runs, _ := tl.ListScheduledJobRuns("ping", 10)
+	if len(runs) != 1 || runs[0].Status != RunDispatched {
+		t.Fatalf("unexpected runs %+v", runs)
+	}
+
+	msg.Complete(nil)
+	runs, _ = tl.ListScheduledJobRuns("ping", 10)
+	job, _ := tl.GetScheduledJob("ping")
+	if runs[0].Status != RunCompleted || job.LastStatus != RunCompleted {
+		t.Fatalf("expected completed run, got %+v / %s", runs, job.LastStatus)
+	}
+	if s1.semaphores[CategoryLLM].Available() != s1.semaphores[CategoryLLM].Cap() {
+		t.Fatal("slot not released after completion")
+	}
+}
+
+func TestUserJobDeferredWhileAtCapacity(t *testing.T) {
+	tl := newJobsTimeline(t)
+	b := bus.NewMessageBus()
+	s := New(Config{Enabled: true, TickInterval: time.Minute, MaxConcLLM: 1, LockPath: filepath.Join(t.TempDir(), "s.lock")}, b, tl)
+
+	created := time.Date(2026, 3, 6, 8, 0, 0, 0, time.UTC)
+	if _, err := AddJob(tl, JobSpec{Name: "ping", When: "in 1h", Content: "ping"}, created, time.UTC); err != nil {
+		t.Fatalf("add job: %v", err)
+	}
+	sem := s.semaphores[CategoryLLM]
+	if !sem.TryAcquire() {
+		t.Fatal("acquire")
+	}
+	due := created.Add(time.Hour + time.Second)
+	s.runUserJobs(context.Background(), due)
+	if job, _ := tl.GetScheduledJob("ping"); job.NextRunAt == nil || job.RunCount != 0 {
+		t.Fatalf("expected the job to stay due, got %+v", job)
+	}
+
+	sem.Release()
+	s.runUserJobs(context.Background(), due.Add(time.Minute))
+	if job, _ := tl.GetScheduledJob("ping"); job.NextRunAt != nil || job.RunCount != 1 {
+		t.Fatalf("expected the deferred run on the next tick, got %+v", job)
+	}
+}
@@ -0,0 +1,339 @@
+package scheduler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule kinds of user jobs.
+const (
+	KindOnce     = "once"
+	KindCron     = "cron"
+	KindInterval = "interval"
+)
+
+// MinInterval is the shortest interval of a repeating user job.
+const MinInterval = time.Minute
+
+// Schedule computes the run times of a user job. Expr is an RFC 3339 time
+// (once), a 5-field cron expression (cron) or a Go duration (interval).
+type Schedule struct {
+	Kind     string
+	Expr     string
+	Location *time.Location
+
+	at    time.Time
+	cron  *CronExpr
+	every time.Duration
+}
+
+// NewSchedule restores a schedule persisted as kind, expression and
+// timezone name.
+func NewSchedule(kind, expr, timezone string) (*Schedule, error) {
+	loc, err := loadLocation(timezone, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	s := &Schedule{Kind: kind, Expr: expr, Location: loc}
+	switch kind {
+	case KindOnce:
+		s.at, err = time.Parse(time.RFC3339, expr)
+	case KindCron:
+		s.cron, err = ParseCron(expr)
+	case KindInterval:
+		s.every, err = time.ParseDuration(expr)
+		if err == nil && s.every < MinInterval {
+			err = fmt.Errorf("interval %s is shorter than %s", s.every, MinInterval)
+		}
+	default:
+		err = fmt.Errorf("unknown schedule kind %q", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Next returns the first run time after t, or the zero time when there is
+// none. For interval jobs t is the previous scheduled run.
+func (s *Schedule) Next(t time.Time) time.Time {
+	switch s.Kind {
+	case KindOnce:
+		if s.at.After(t) {
+			return s.at
+		}
+		return time.Time{}
+	case KindCron:
+		return s.cron.Next(t.In(s.Location))
+	case KindInterval:
+		return t.Add(s.every)
+	}
+	return time.Time{}
+}
+
+// Timezone returns the IANA name of the schedule's location.
+func (s *Schedule) Timezone() string { return s.Location.String() }
+
+// String describes the schedule for listings.
+func (s *Schedule) String() string {
+	switch s.Kind {
+	case KindOnce:
+		return "once at " + s.at.In(s.Location).Format("2006-01-02 15:04 MST")
+	case KindCron:
+		return fmt.Sprintf("cron %q (%s)", s.Expr, s.Location)
+	case KindInterval:
+		return "every " + s.every.String()
+	}
+	return s.Kind
+}
+
+var (
+	relativeExpr  = regexp.MustCompile(`^(\d+)\s*(s|sec|secs|seconds?|m|min|mins|minutes?|h|hr|hrs|hours?|d|days?|w|weeks?)$`)
+	clockExpr     = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+	weekdayNumber = map[string]int{
+		"sun": 0, "sunday": 0, "mon": 1, "monday": 1, "tue": 2, "tuesday": 2,
+		"wed": 3, "wednesday": 3, "thu": 4, "thursday": 4, "fri": 5, "friday": 5,
+		"sat": 6, "saturday": 6,
+	}
+)
+
+// ParseSchedule parses a user schedule relative to now. Supported forms:
+//
+//	in 2 hours | in 90m                    one-off, relative
+//	at 2026-03-01 09:00 | tomorrow 8:30    one-off, absolute
+//	every 15m | every 2 hours              interval
+//	every day 9:00 | every weekday 9am     cron (also weekend, monday,...)
+//	cron */5 * * * * | 0 9 * * 1-5         cron
+//
+// A trailing IANA timezone ("every weekday 9:00 Europe/Berlin") applies to
+// clock times; loc is used otherwise.
+func ParseSchedule(spec string, now time.Time, loc *time.Location) (*Schedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	fields := strings.Fields(strings.TrimSpace(spec))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("schedule is empty")
+	}
+	if last := fields[len(fields)-1]; strings.Contains(last, "/") || last == "UTC" {
+		if tz, err := time.LoadLocation(last); err == nil {
+			loc = tz
+			fields = fields[:len(fields)-1]
+		}
+	}
+	text := strings.ToLower(strings.Join(fields, " "))
+	now = now.In(loc)
+
+	s, err := parseScheduleText(text, now, loc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse schedule %q: %w", spec, err)
+	}
+	s.Location = loc
+	if s.Kind == KindOnce {
+		if !s.at.After(now) {
+			return nil, fmt.Errorf("schedule %q is in the past", spec)
+		}
+		s.Expr = s.at.UTC().Format(time.RFC3339)
+	}
+	return s, nil
+}
+
+func parseScheduleText(text string, now time.Time, loc *time.Location) (*Schedule, error) {
+	switch {
+	case strings.HasPrefix(text, "cron "):
+		return cronSchedule(strings.TrimSpace(strings.TrimPrefix(text, "cron ")))
+	case len(strings.Fields(text)) == 5:
+		if s, err := cronSchedule(text); err == nil {
+			return s, nil
+		}
+	}
+
+	if rest, ok := strings.CutPrefix(text, "in "); ok {
+		d, err := parseSpan(rest)
+		if err != nil {
+			return nil, err
+		}
+		return &Schedule{Kind: KindOnce, at: now.Add(d).Truncate(time.Second)}, nil
+	}
+
+	if rest, ok := strings.CutPrefix(text, "every "); ok {
+		return parseEvery(rest)
+	}
+	switch {
+	case text == "hourly":
+		return parseEvery("hour")
+	case strings.HasPrefix(text, "daily "):
+		return parseEvery("day " + strings.TrimPrefix(text, "daily "))
+	case strings.HasPrefix(text, "weekdays "):
+		return parseEvery("weekday " + strings.TrimPrefix(text, "weekdays "))
+	}
+
+	at, err := parseAbsolute(strings.TrimPrefix(text, "at "), now, loc)
+	if err != nil {
+		return nil, err
+	}
+	return &Schedule{Kind: KindOnce, at: at}, nil
+}
+
+func cronSchedule(expr string) (*Schedule, error) {
+	c, err := ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Schedule{Kind: KindCron, Expr: expr, cron: c}, nil
+}
+
+// parseSpan parses "2 hours", "90m" or "1h30m".
+func parseSpan(text string) (time.Duration, error) {
+	text = strings.TrimSpace(text)
+	if d, err := time.ParseDuration(strings.ReplaceAll(text, " ", "")); err == nil && d > 0 {
+		return d, nil
+	}
+	m := relativeExpr.FindStringSubmatch(text)
+	if m == nil {
+		return 0, fmt.Errorf("expected a duration like \"2 hours\" or \"90m\"")
+	}
+	n, _ := strconv.Atoi(m[1])
+	if n <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	unit := time.Second
+	switch m[2][0] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// parseEvery parses the part after "every": an interval or a set of days
+// with a clock time.
+func parseEvery(text string) (*Schedule, error) {
+	text = strings.TrimSpace(text)
+	switch text {
+	case "minute":
+		text = "1m"
+	case "hour":
+		text = "1h"
+	}
+	if d, err := parseSpan(text); err == nil {
+		if d < MinInterval {
+			return nil, fmt.Errorf("interval %s is shorter than %s", d, MinInterval)
+		}
+		return &Schedule{Kind: KindInterval, Expr: d.String(), every: d}, nil
+	}
+
+	days, clock, _ := strings.Cut(text, " ")
+	clock = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(clock), "at "))
+	dow := ""
+	switch days {
+	case "day":
+		dow = "*"
+	case "weekday":
+		dow = "1-5"
+	case "weekend":
+		dow = "0,6"
+	default:
+		var nums []string
+		for _, name := range strings.Split(days, ",") {
+			n, ok := weekdayNumber[strings.TrimSuffix(strings.TrimSpace(name), "s")]
+			if !ok {
+				n, ok = weekdayNumber[strings.TrimSpace(name)]
+			}
+			if !ok {
+				return nil, fmt.Errorf("unknown day %q (use day, weekday, weekend or a weekday name)", name)
+			}
+			nums = append(nums, strconv.Itoa(n))
+		}
+		dow = strings.Join(nums, ",")
+	}
+	if clock == "" {
+		return nil, fmt.Errorf("missing time of day, e.g. \"every %s 9:00\"", days)
+	}
+	hour, minute, err := parseClock(clock)
+	if err != nil {
+		return nil, err
+	}
+	return cronSchedule(fmt.Sprintf("%d %d * * %s", minute, hour, dow))
+}
+
+// parseAbsolute parses one-off times: RFC 3339, "2006-01-02 15:04",
+// "tomorrow 9:00" or a clock time (today, or tomorrow once passed).
+func parseAbsolute(text string, now time.Time, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, strings.ToUpper(text)); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02t15:04", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+			return t, nil
+		}
+	}
+	day := now
+	clock := text
+	if rest, ok := strings.CutPrefix(text, "tomorrow"); ok {
+		day = now.AddDate(0, 0, 1)
+		clock = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), "at "))
+		if clock == "" {
+			clock = "9:00"
+		}
+	} else if rest, ok := strings.CutPrefix(text, "today"); ok {
+		clock = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), "at "))
+	}
+	hour, minute, err := parseClock(clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected \"in <duration>\", \"every ...\", \"at <date> <time>\" or a cron expression")
+	}
+	t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+	if day.Equal(now) && !t.After(now) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// parseClock parses "9", "9:30", "09:30", "9am" or "9:30pm".
+func parseClock(text string) (hour, minute int, err error) {
+	m := clockExpr.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return 0, 0, fmt.Errorf("invalid time of day %q", text)
+	}
+	hour, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	if hour > 23 || minute > 59 || (m[3] != "" && (hour < 1 || hour > 12)) {
+		return 0, 0, fmt.Errorf("invalid time of day %q", text)
+	}
+	switch {
+	case m[3] == "am" && hour == 12:
+		hour = 0
+	case m[3] == "pm" && hour < 12:
+		hour += 12
+	}
+	return hour, minute, nil
+}
+
+// Location resolves the default timezone of user schedules (an IANA name,
+// empty for the host timezone).
+func Location(name string) (*time.Location, error) {
+	return loadLocation(name, time.Local)
+}
+
+// loadLocation resolves a timezone name; empty uses fallback.
+func loadLocation(name string, fallback *time.Location) (*time.Location, error) {
+	if strings.TrimSpace(name) == "" {
+		return fallback, nil
+	}
+	loc, err := time.LoadLocation(strings.TrimSpace(name))
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return loc, nil
+}
@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	utc := time.UTC
+	now := time.Date(2026, 3, 6, 10, 0, 0, 0, utc) // Friday
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+
+	tests := []struct {
+		spec string
+		kind string
+		expr string
+		next time.Time
+	}{
+		{"in 2 hours", KindOnce, "2026-03-06T12:00:00Z", time.Date(2026, 3, 6, 12, 0, 0, 0, utc)},
+		{"in 90m", KindOnce, "2026-03-06T11:30:00Z", time.Date(2026, 3, 6, 11, 30, 0, 0, utc)},
+		{"tomorrow 8:30", KindOnce, "2026-03-07T08:30:00Z", time.Date(2026, 3, 7, 8, 30, 0, 0, utc)},
+		{"at 2026-03-10 14:00", KindOnce, "2026-03-10T14:00:00Z", time.Date(2026, 3, 10, 14, 0, 0, 0, utc)},
+		{"9am", KindOnce, "2026-03-07T09:00:00Z", time.Date(2026, 3, 7, 9, 0, 0, 0, utc)},
+		{"every 15m", KindInterval, "15m0s", now.Add(15 * time.Minute)},
+		{"every 2 hours", KindInterval, "2h0m0s", now.Add(2 * time.Hour)},
+		{"every weekday 9:00 Europe/Berlin", KindCron, "0 9 * * 1-5", time.Date(2026, 3, 9, 9, 0, 0, 0, berlin)},
+		{"every monday,wednesday at 7:15pm", KindCron, "15 19 * * 1,3", time.Date(2026, 3, 9, 19, 15, 0, 0, utc)},
+		{"every weekend 10:00", KindCron, "0 10 * * 0,6", time.Date(2026, 3, 7, 10, 0, 0, 0, utc)},
+		{"cron */30 * * * *", KindCron, "*/30 * * * *", time.Date(2026, 3, 6, 10, 30, 0, 0, utc)},
+		{"0 12 * * *", KindCron, "0 12 * * *", time.Date(2026, 3, 6, 12, 0, 0, 0, utc)},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec, now, utc)
+		if err != nil {
+			t.Errorf("%q: %v", tt.spec, err)
+			continue
+		}
+		if s.Kind != tt.kind || s.Expr != tt.expr {
+			t.Errorf("%q: got %s %q, want %s %q", tt.spec, s.Kind, s.Expr, tt.kind, tt.expr)
+		}
+		if next := s.Next(now); !next.Equal(tt.next) {
+			t.Errorf("%q: next = %s, want %s", tt.spec, next, tt.next)
+		}
+
+		// The persisted form restores the same schedule.
+		restored, err := NewSchedule(s.Kind, s.Expr, s.Timezone())
+		if err != nil {
+			t.Errorf("%q: restore: %v", tt.spec, err)
+			continue
+		}
+		if next := restored.Next(now); !next.Equal(tt.next) {
+			t.Errorf("%q: restored next = %s, want %s", tt.spec, next, tt.next)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	now := time.Date(2026, 3, 6, 10, 0, 0, 0, time.UTC)
+	for spec, want := range map[string]string{
+		"":                       "empty",
+		"at 2026-03-01 09:00":    "in the past",
+		"every 10s":              "shorter than",
+		"every someday 9:00":     "unknown day",
+		"every weekday":          "missing time of day",
+		"every day 25:00":        "invalid time of day",
+		"cron 61 * * * *":        "cannot parse",
+		"whenever you feel like": "cannot parse",
+	} {
+		if _, err := ParseSchedule(spec, now, time.UTC); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: err = %v, want %q", spec, err, want)
+		}
+	}
+}
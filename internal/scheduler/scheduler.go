@@ -24,10 +24,22 @@ const (
 
 // Job defines a schedulable unit of work.
 type Job struct {
-	Name     string      // Unique job identifier.
-	Cron     *CronExpr   // Parsed cron expression.
-	Category JobCategory // For semaphore selection.
-	Content  string      // Message content dispatched to the agent loop.
+	Name     string        // Unique job identifier.
+	Cron     *CronExpr     // Parsed cron expression.
+	Category JobCategory   // For semaphore selection.
+	Content  string        // Message content dispatched to the agent loop.
+	Timeout  time.Duration // Run timeout; 0 uses Config.JobTimeout.
+}
+
+// LeaderCapability is the capability gateways running a scheduler announce
+// to their group, so builtin jobs are only fired by one of them.
+const LeaderCapability = "scheduler"
+
+// Elector decides whether this gateway fires builtin jobs. In a group, only
+// the lease holder does, so each job runs once across the fleet. The fencing
+// token names the lease term and is stamped on every run it dispatches.
+type Elector interface {
+	Lease(now time.Time) (token int64, ok bool)
 }
 
 // Config holds scheduler settings.
@@ -38,6 +50,9 @@ type Config struct {
 	MaxConcShell   int           `json:"maxConcShell"`
 	MaxConcDefault int           `json:"maxConcDefault"`
 	LockPath       string        `json:"lockPath"`
+	// JobTimeout bounds a run when the job sets none. Its semaphore slot is
+	// held until the agent finishes the run or the timeout passes.
+	JobTimeout time.Duration `json:"jobTimeout"`
 }
 
 // DefaultConfig returns sensible scheduler defaults.
@@ -50,6 +65,7 @@ func DefaultConfig() Config {
 		MaxConcShell:   1,
 		MaxConcDefault: 5,
 		LockPath:       filepath.Join(home, ".kafclaw", "scheduler.lock"),
+		JobTimeout:     10 * time.Minute,
 	}
 }
 
@@ -62,6 +78,7 @@ type Scheduler struct {
 	mu         sync.RWMutex
 	semaphores map[JobCategory]*Semaphore
 	lock       *FileLock
+	elector    Elector
 }
 
 // New creates a Scheduler.
@@ -81,6 +98,9 @@ func New(cfg Config, b *bus.MessageBus, tl *timeline.TimelineService) *Scheduler
 	if cfg.LockPath == "" {
 		cfg.LockPath = DefaultConfig().LockPath
 	}
+	if cfg.JobTimeout <= 0 {
+		cfg.JobTimeout = DefaultConfig().JobTimeout
+	}
 
 	return &Scheduler{
 		cfg:      cfg,
@@ -104,6 +124,14 @@ func (s *Scheduler) Register(job *Job) {
 	slog.Info("Scheduler job registered", "name", job.Name, "category", job.Category)
 }
 
+// SetElector makes builtin jobs fire only while e elects this gateway.
+// Without an elector every scheduler fires them.
+func (s *Scheduler) SetElector(e Elector) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.elector = e
+}
+
 // Unregister removes a job by name.
 func (s *Scheduler) Unregister(name string) {
 	s.mu.Lock()
@@ -123,10 +151,13 @@ func (s *Scheduler) Jobs() []*Job {
 }
 
 // Run starts the scheduler tick loop. Blocks until context is cancelled.
+// User jobs that fell due while the scheduler was down are caught up right
+// away.
 func (s *Scheduler) Run(ctx context.Context) error {
 	slog.Info("Scheduler started", "tick", s.cfg.TickInterval, "jobs", len(s.jobs))
 	ticker := time.NewTicker(s.cfg.TickInterval)
 	defer ticker.Stop()
+	s.tickUserJobs(ctx, time.Now())
 
 	for {
 		select {
@@ -154,18 +185,184 @@ func (s *Scheduler) tick(ctx context.Context, now time.Time) {
 	defer s.lock.Unlock()
 
 	s.mu.RLock()
-	defer s.mu.RUnlock()
+	token, leader := int64(0), true
+	if s.elector != nil {
+		token, leader = s.elector.Lease(now)
+	}
+	if leader {
+		for _, job := range s.jobs {
+			if !job.Cron.Matches(now) {
+				continue
+			}
+			s.dispatch(ctx, job, now, token)
+		}
+	} else if len(s.jobs) > 0 {
+		slog.Debug("Scheduler builtin jobs skipped: not the group leader")
+	}
+	s.mu.RUnlock()
+
+	s.runUserJobs(ctx, now)
+}
+
+// tickUserJobs runs due user jobs outside the regular tick.
+func (s *Scheduler) tickUserJobs(ctx context.Context, now time.Time) {
+	acquired, err := s.lock.TryLock()
+	if err != nil || !acquired {
+		return
+	}
+	defer s.lock.Unlock()
+	s.runUserJobs(ctx, now)
+}
+
+// runUserJobs dispatches the user jobs stored in scheduled_jobs that are
+// due, applying each job's misfire policy to runs missed during downtime.
+// Each due run is claimed in the timeline first, so gateways sharing the
+// database never fire it twice.
+func (s *Scheduler) runUserJobs(ctx context.Context, now time.Time) {
+	if s.timeline == nil {
+		return
+	}
+	jobs, err := s.timeline.ListUserScheduledJobs()
+	if err != nil {
+		slog.Warn("Scheduler: list user jobs failed", "error", err)
+		return
+	}
+	// Runs up to two ticks late are on time.
+	grace := 2 * s.cfg.TickInterval
+	sem := s.semaphores[CategoryLLM]
+	for _, job := range jobs {
+		if job.NextRunAt == nil || job.NextRunAt.After(now) {
+			continue
+		}
+		sched, err := NewSchedule(job.Kind, job.Schedule, job.Timezone)
+		if err != nil {
+			slog.Warn("Scheduler: invalid user job, pausing", "job", job.JobName, "error", err)
+			_ = s.timeline.SetScheduledJobState(job.JobName, true, job.NextRunAt)
+			continue
+		}
+		dispatch, missed, next := planRuns(job, sched, now, grace)
+
+		// Take the slots first; with none free the job stays due and is
+		// retried next tick.
+		slots := 0
+		for slots < len(dispatch) && sem.TryAcquire() {
+			slots++
+		}
+		release := func() {
+			for ; slots > 0; slots-- {
+				sem.Release()
+			}
+		}
+		if len(dispatch) > 0 && slots == 0 {
+			slog.Info("Scheduler user job deferred: concurrency limit", "job", job.JobName)
+			continue
+		}
 
-	for _, job := range s.jobs {
-		if !job.Cron.Matches(now) {
+		var nextPtr *time.Time
+		if !next.IsZero() {
+			nextPtr = &next
+		}
+		claimed, err := s.timeline.ClaimScheduledJobRun(job.JobName, *job.NextRunAt, nextPtr)
+		if err != nil || !claimed {
+			if err != nil {
+				slog.Warn("Scheduler: claim user job failed", "job", job.JobName, "error", err)
+			}
+			release()
 			continue
 		}
-		s.dispatch(ctx, job, now)
+		for _, at := range missed {
+			_ = s.timeline.RecordScheduledJobRun(job.JobName, "", RunMissed, at, nextPtr)
+		}
+		for i, at := range dispatch {
+			if i >= slots {
+				_ = s.timeline.RecordScheduledJobRun(job.JobName, "", RunSkipped, at, nextPtr)
+				continue
+			}
+			s.dispatchUserJob(job, at, now, nextPtr, sem)
+		}
+		if len(missed) > 0 || len(dispatch) > slots {
+			slog.Info("Scheduler user job missed runs", "job", job.JobName, "missed", len(missed),
+				"skipped", len(dispatch)-slots, "policy", job.MisfirePolicy)
+		}
+	}
+}
+
+// dispatchUserJob records one run of a user job and publishes it into the
+// conversation that created it. The semaphore slot is released when the
+// agent finishes the run or its timeout passes.
+func (s *Scheduler) dispatchUserJob(job timeline.ScheduledJobRecord, at, now time.Time, next *time.Time, sem *Semaphore) {
+	traceID := fmt.Sprintf("sched-%s-%d", job.JobName, at.Unix())
+	if err := s.timeline.RecordScheduledJobRun(job.JobName, traceID, RunDispatched, at, next); err != nil {
+		slog.Warn("Scheduler: record user job run failed", "job", job.JobName, "error", err)
+	}
+	meta := map[string]any{
+		bus.MetaKeyMessageType: job.MessageType,
+		"scheduler_job":        job.JobName,
+		"scheduler_tick":       at.Format(time.RFC3339),
+	}
+	if job.SessionKey != "" {
+		meta[bus.MetaKeySessionScope] = job.SessionKey
+	}
+	content := fmt.Sprintf("[Scheduled job %s, due %s] %s", job.JobName, at.In(scheduleLocation(job)).Format("2006-01-02 15:04 MST"), job.Content)
+	msg := &bus.InboundMessage{
+		Channel:        job.Channel,
+		SenderID:       job.SenderID,
+		ChatID:         job.ChatID,
+		ThreadID:       job.ThreadID,
+		TraceID:        traceID,
+		IdempotencyKey: "sched:" + traceID,
+		Content:        content,
+		Metadata:       meta,
+		Timestamp:      now,
+	}
+	timeout := time.Duration(job.TimeoutSeconds) * time.Second
+	s.track(msg, sem, timeout, func(status string) {
+		if err := s.timeline.UpdateScheduledJobRunStatus(traceID, status); err != nil {
+			slog.Warn("Scheduler: update user job run failed", "job", job.JobName, "error", err)
+		}
+	})
+	slog.Info("Scheduler dispatching user job", "job", job.JobName, "channel", job.Channel, "due", at)
+	go s.bus.PublishInbound(msg)
+}
+
+// track holds a semaphore slot for msg until the agent completes it or the
+// timeout (Config.JobTimeout when zero) passes, then reports the outcome.
+// The timeout is also the message deadline, so the agent stops working on
+// it.
+func (s *Scheduler) track(msg *bus.InboundMessage, sem *Semaphore, timeout time.Duration, finish func(status string)) {
+	if timeout <= 0 {
+		timeout = s.cfg.JobTimeout
+	}
+	var once sync.Once
+	done := func(status string) {
+		once.Do(func() {
+			sem.Release()
+			finish(status)
+		})
+	}
+	timer := time.AfterFunc(timeout, func() { done(RunTimedOut) })
+	msg.Deadline = time.Now().Add(timeout)
+	msg.Done = func(err error) {
+		timer.Stop()
+		if err != nil {
+			done(RunFailed)
+			return
+		}
+		done(RunCompleted)
+	}
+}
+
+func scheduleLocation(job timeline.ScheduledJobRecord) *time.Location {
+	loc, err := loadLocation(job.Timezone, time.Local)
+	if err != nil {
+		return time.Local
 	}
+	return loc
 }
 
-// dispatch sends a job as a bus.InboundMessage if a semaphore slot is available.
-func (s *Scheduler) dispatch(ctx context.Context, job *Job, now time.Time) {
+// dispatch sends a job as a bus.InboundMessage if a semaphore slot is
+// available. The slot stays taken until the agent has finished the job.
+func (s *Scheduler) dispatch(ctx context.Context, job *Job, now time.Time, token int64) {
 	sem := s.semaphores[job.Category]
 	if sem == nil {
 		sem = s.semaphores[CategoryDefault]
@@ -173,31 +370,35 @@ func (s *Scheduler) dispatch(ctx context.Context, job *Job, now time.Time) {
 
 	if !sem.TryAcquire() {
 		slog.Warn("Scheduler job skipped: concurrency limit", "job", job.Name, "category", job.Category)
-		s.logJobRun(job.Name, "skipped_concurrency", now)
+		s.logJobRun(job.Name, RunSkipped, now)
 		return
 	}
 
 	slog.Info("Scheduler dispatching job", "job", job.Name)
 
-	// Dispatch asynchronously; release semaphore when the bus consume completes.
-	go func() {
-		defer sem.Release()
-
-		s.bus.PublishInbound(&bus.InboundMessage{
-			Channel:  "scheduler",
-			SenderID: "scheduler",
-			ChatID:   fmt.Sprintf("scheduler:%s", job.Name),
-			Content:  job.Content,
-			Metadata: map[string]any{
-				"message_type":   "internal",
-				"scheduler_job":  job.Name,
-				"scheduler_tick": now.Format(time.RFC3339),
-			},
-			Timestamp: now,
-		})
-
-		s.logJobRun(job.Name, "dispatched", now)
-	}()
+	msg := &bus.InboundMessage{
+		Channel:  "scheduler",
+		SenderID: "scheduler",
+		ChatID:   fmt.Sprintf("scheduler:%s", job.Name),
+		Content:  job.Content,
+		Metadata: map[string]any{
+			"message_type":   "internal",
+			"scheduler_job":  job.Name,
+			"scheduler_tick": now.Format(time.RFC3339),
+		},
+		Timestamp: now,
+	}
+	if token > 0 {
+		msg.Metadata["scheduler_fencing_token"] = token
+	}
+	s.track(msg, sem, job.Timeout, func(status string) {
+		if s.timeline != nil {
+			_ = s.timeline.UpdateScheduledJobStatus(job.Name, status)
+		}
+		slog.Info("Scheduler job finished", "job", job.Name, "status", status)
+	})
+	s.logJobRun(job.Name, RunDispatched, now)
+	go s.bus.PublishInbound(msg)
 }
 
 // logJobRun persists the run status to the scheduled_jobs table (best-effort).
@@ -176,3 +176,104 @@ func TestSchedulerNonMatchingJobNotDispatched(t *testing.T) {
 		t.Errorf("expected 0 dispatched messages at noon, got %d", received.Load())
 	}
 }
+
+// fixedElector holds the lease with its value as the fencing token; zero
+// means not leader.
+type fixedElector int64
+
+func (e fixedElector) Lease(time.Time) (int64, bool) { return int64(e), e > 0 }
+
+func TestSchedulerHoldsSlotUntilJobCompletes(t *testing.T) {
+	b := bus.NewMessageBus()
+	s := New(Config{
+		Enabled:      true,
+		TickInterval: time.Minute,
+		MaxConcLLM:   1,
+		JobTimeout:   time.Minute,
+		LockPath:     t.TempDir() + "/test.lock",
+	}, b, nil)
+	cron, _ := ParseCron("* * * * *")
+	s.Register(&Job{Name: "digest", Cron: cron, Category: CategoryLLM, Content: "digest"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	now := time.Now()
+	s.tick(ctx, now)
+	msg, err := b.ConsumeInbound(ctx)
+	if err != nil {
+		t.Fatalf("expected dispatched job: %v", err)
+	}
+	if msg.Done == nil || msg.Deadline.IsZero() {
+		t.Fatal("expected a completion handle and deadline on the job message")
+	}
+
+	// Still running: the next tick must not start another run.
+	s.tick(ctx, now.Add(time.Minute))
+	if n := b.InboundSize(); n != 0 || s.semaphores[CategoryLLM].Available() != 0 {
+		t.Fatalf("expected the slot to stay taken while the job runs (queued=%d)", n)
+	}
+
+	msg.Complete(nil)
+	if got := s.semaphores[CategoryLLM].Available(); got != 1 {
+		t.Fatalf("expected the slot back after completion, available=%d", got)
+	}
+	msg.Complete(nil) // a second completion is a no-op
+	if got := s.semaphores[CategoryLLM].Available(); got != 1 {
+		t.Fatalf("double completion released twice, available=%d", got)
+	}
+}
+
+func TestSchedulerReleasesSlotOnTimeout(t *testing.T) {
+	b := bus.NewMessageBus()
+	s := New(Config{Enabled: true, MaxConcDefault: 1, LockPath: t.TempDir() + "/test.lock"}, b, nil)
+	cron, _ := ParseCron("* * * * *")
+	s.Register(&Job{Name: "hang", Cron: cron, Category: CategoryDefault, Content: "hang", Timeout: 50 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	s.tick(ctx, time.Now())
+	msg, err := b.ConsumeInbound(ctx)
+	if err != nil {
+		t.Fatalf("expected dispatched job: %v", err)
+	}
+	if time.Until(msg.Deadline) > 50*time.Millisecond {
+		t.Fatalf("expected the job timeout as deadline, got %s", time.Until(msg.Deadline))
+	}
+	deadline := time.Now().Add(time.Second)
+	for s.semaphores[CategoryDefault].Available() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("slot not released after the job timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	msg.Complete(nil) // late completion must not release again
+	if got := s.semaphores[CategoryDefault].Available(); got != 1 {
+		t.Fatalf("late completion released twice, available=%d", got)
+	}
+}
+
+func TestSchedulerElectorGatesBuiltinJobs(t *testing.T) {
+	b := bus.NewMessageBus()
+	s := New(Config{Enabled: true, LockPath: t.TempDir() + "/test.lock"}, b, nil)
+	cron, _ := ParseCron("* * * * *")
+	s.Register(&Job{Name: "sync", Cron: cron, Category: CategoryDefault, Content: "sync"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s.SetElector(fixedElector(0))
+	s.tick(ctx, time.Now())
+	time.Sleep(50 * time.Millisecond)
+	if b.InboundSize() != 0 {
+		t.Fatal("follower dispatched a builtin job")
+	}
+
+	s.SetElector(fixedElector(7))
+	s.tick(ctx, time.Now())
+	msg, err := b.ConsumeInbound(ctx)
+	if err != nil {
+		t.Fatalf("leader did not dispatch: %v", err)
+	}
+	if got := msg.Metadata["scheduler_fencing_token"]; got != int64(7) {
+		t.Fatalf("expected the lease fencing token on the run, got %v", got)
+	}
+}
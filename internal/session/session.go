@@ -149,6 +149,23 @@ func (m *Manager) GetOrCreate(key string) *Session {
 	return session
 }
 
+// Get returns an existing session from the cache or disk without creating
+// one, so read paths do not leave empty sessions behind.
+func (m *Manager) Get(key string) (*Session, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if session, ok := m.cache[key]; ok {
+		return session, true
+	}
+	session := m.load(key)
+	if session == nil {
+		return nil, false
+	}
+	m.cache[key] = session
+	return session, true
+}
+
 // Save persists a session to disk.
 func (m *Manager) Save(session *Session) error {
 	m.mu.Lock()
@@ -161,3 +161,29 @@ func TestManagerListSkipsNonJSONLFiles(t *testing.T) {
 		t.Fatalf("expected non-jsonl files to be ignored, got %d entries", len(infos))
 	}
 }
+
+func TestManagerGetDoesNotCreate(t *testing.T) {
+	dir := t.TempDir()
+	m := &Manager{sessionsDir: dir, cache: map[string]*Session{}}
+
+	if s, ok := m.Get("cli:missing"); ok || s != nil {
+		t.Fatalf("expected missing session, got %+v", s)
+	}
+	if _, ok := m.cache["cli:missing"]; ok {
+		t.Fatal("expected Get not to cache a new session")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected no session files, got %d", len(entries))
+	}
+
+	s := NewSession("cli:known")
+	s.AddMessage("user", "ping")
+	if err := m.Save(s); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	m2 := &Manager{sessionsDir: dir, cache: map[string]*Session{}}
+	loaded, ok := m2.Get("cli:known")
+	if !ok || len(loaded.Messages) != 1 {
+		t.Fatalf("expected stored session, got %+v (ok=%v)", loaded, ok)
+	}
+}
@@ -0,0 +1,177 @@
+package skills
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/sandbox"
+)
+
+// CodeRun describes one interpreter run of the code_run tool.
+type CodeRun struct {
+	// Language is "python" or "node".
+	Language string
+	// Dir is the working dir and the only writable path.
+	Dir string
+	// Script is the file in Dir to run.
+	Script         string
+	Timeout        time.Duration
+	MaxOutputBytes int
+	MemoryBytes    int64
+	CPUs           float64
+	Network        bool
+	// Image is the container image for container isolation.
+	Image string
+}
+
+// codeInterpreters maps languages to their interpreter binary.
+var codeInterpreters = map[string]string{
+	"python": "python3",
+	"node":   "node",
+}
+
+// RunCode runs a script in the skills isolation runtime. Unlike
+// ExecuteSkillCommand interpreters are allowed, so it never falls back to
+// the host: "auto" uses a container runtime, then the native sandbox, and
+// host execution needs skills.runtimeIsolation=host.
+func RunCode(ctx context.Context, cfg *config.Config, run CodeRun) (*SkillExecResult, error) {
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	interpreter, ok := codeInterpreters[run.Language]
+	if !ok {
+		return nil, fmt.Errorf("unsupported language %q (use python or node)", run.Language)
+	}
+	if run.Timeout <= 0 {
+		run.Timeout = defaultExecTimeout
+	}
+	if run.MaxOutputBytes <= 0 {
+		run.MaxOutputBytes = defaultMaxOutputBytes
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, run.Timeout)
+	defer cancel()
+	stdout := newLimitedBuffer(run.MaxOutputBytes)
+	stderr := newLimitedBuffer(run.MaxOutputBytes)
+	start := time.Now()
+	err := runCodeWithIsolation(ctx, cfg, run, interpreter, stdout, stderr)
+	res := &SkillExecResult{
+		Command:         []string{interpreter, run.Script},
+		ScratchDir:      run.Dir,
+		Duration:        time.Since(start),
+		Stdout:          stdout.String(),
+		Stderr:          stderr.String(),
+		OutputTruncated: stdout.Truncated() || stderr.Truncated(),
+	}
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			res.ExitCode = ee.ExitCode()
+		} else {
+			res.ExitCode = -1
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return res, fmt.Errorf("code run timed out after %s", run.Timeout)
+		}
+		if res.ExitCode > 0 {
+			// A failing script is a result, not a runtime error.
+			return res, nil
+		}
+	}
+	return res, err
+}
+
+func runCodeWithIsolation(ctx context.Context, cfg *config.Config, run CodeRun, interpreter string, stdout, stderr io.Writer) error {
+	mode := strings.ToLower(strings.TrimSpace(cfg.Skills.RuntimeIsolation))
+	switch mode {
+	case "host":
+		cmd := exec.CommandContext(ctx, interpreter, run.Script)
+		cmd.Dir = run.Dir
+		cmd.Env = codeRunEnv(run.Dir)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		return cmd.Run()
+	case "strict":
+		runtimeBin, err := StrictIsolationPreflight()
+		if err != nil {
+			return err
+		}
+		return runCodeInContainer(ctx, runtimeBin, run, interpreter, stdout, stderr)
+	case "native":
+		return runCodeNative(ctx, cfg, run, interpreter, stdout, stderr)
+	default:
+		if runtimeBin, ok := detectContainerRuntime(); ok {
+			return runCodeInContainer(ctx, runtimeBin, run, interpreter, stdout, stderr)
+		}
+		if err := sandbox.Preflight(); err != nil {
+			return fmt.Errorf("code_run needs a container runtime or the native sandbox: %w", err)
+		}
+		return runCodeNative(ctx, cfg, run, interpreter, stdout, stderr)
+	}
+}
+
+func runCodeNative(ctx context.Context, cfg *config.Config, run CodeRun, interpreter string, stdout, stderr io.Writer) error {
+	cmd, release, err := sandbox.Command(ctx, sandbox.Policy{
+		WritableDirs: []string{run.Dir},
+		WorkDir:      run.Dir,
+		Env:          codeRunEnv(run.Dir),
+		Network:      run.Network,
+		Strict:       cfg.Skills.StrictSandbox,
+		MemoryBytes:  run.MemoryBytes,
+		CPUs:         run.CPUs,
+	}, []string{interpreter, run.Script})
+	if err != nil {
+		return err
+	}
+	defer release()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+func runCodeInContainer(ctx context.Context, runtimeBin string, run CodeRun, interpreter string, stdout, stderr io.Writer) error {
+	network := "none"
+	if run.Network {
+		network = "bridge"
+	}
+	args := []string{
+		"run", "--rm",
+		"--network", network,
+		"--read-only",
+		"--pids-limit", strconv.Itoa(sandbox.DefaultMaxPIDs),
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+		"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
+		"-v", run.Dir + ":/work:rw",
+		"-w", "/work",
+		"--tmpfs", "/tmp:rw,noexec,nosuid,size=64m",
+		"-e", "HOME=/work",
+		"-e", "TMPDIR=/tmp",
+		"-e", "MPLBACKEND=Agg",
+		"-e", "PYTHONDONTWRITEBYTECODE=1",
+	}
+	if run.MemoryBytes > 0 {
+		args = append(args, "--memory", strconv.FormatInt(run.MemoryBytes, 10))
+	}
+	if run.CPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(run.CPUs, 'f', -1, 64))
+	}
+	args = append(args, run.Image, interpreter, filepath.Join("/work", run.Script))
+	cmd := exec.CommandContext(ctx, runtimeBin, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+func codeRunEnv(dir string) []string {
+	return append(minimalRuntimeEnv(dir), "MPLBACKEND=Agg", "PYTHONDONTWRITEBYTECODE=1")
+}
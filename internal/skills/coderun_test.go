@@ -0,0 +1,63 @@
+package skills
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+func TestRunCodeHost(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node not installed")
+	}
+	cfg := config.DefaultConfig()
+	cfg.Skills.RuntimeIsolation = "host"
+	dir := t.TempDir()
+	script := func(name, code string) string {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(code), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+
+	res, err := RunCode(context.Background(), cfg, CodeRun{
+		Language: "node",
+		Dir:      dir,
+		Script:   script("ok.js", `require("fs").writeFileSync("out.txt", "hi"); console.log(process.cwd())`),
+	})
+	if err != nil || res.ExitCode != 0 {
+		t.Fatalf("run failed: %+v, %v", res, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "out.txt")); string(data) != "hi" {
+		t.Fatalf("expected file written to working dir, got %q", data)
+	}
+
+	res, err = RunCode(context.Background(), cfg, CodeRun{
+		Language: "node",
+		Dir:      dir,
+		Script:   script("fail.js", `console.error("boom"); process.exit(3)`),
+	})
+	if err != nil || res.ExitCode != 3 || !strings.Contains(res.Stderr, "boom") {
+		t.Fatalf("expected exit code result, got %+v, %v", res, err)
+	}
+
+	_, err = RunCode(context.Background(), cfg, CodeRun{
+		Language: "node",
+		Dir:      dir,
+		Script:   script("loop.js", `for (;;) {}`),
+		Timeout:  300 * time.Millisecond,
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	if _, err := RunCode(context.Background(), cfg, CodeRun{Language: "ruby", Dir: dir, Script: "x.rb"}); err == nil {
+		t.Fatal("expected unsupported language error")
+	}
+}
@@ -10,10 +10,12 @@ import (
 	"os/exec"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/sandbox"
 )
 
 const (
@@ -49,6 +51,10 @@ type runtimePolicy struct {
 	ReadOnlyWorkspace bool
 	Timeout           time.Duration
 	MaxOutputBytes    int
+	MemoryBytes       int64
+	CPUs              float64
+	AllowDomains      []string
+	DenyDomains       []string
 }
 
 // ExecuteSkillCommand runs one command in a skill sandbox with policy checks.
@@ -93,7 +99,7 @@ func ExecuteSkillCommand(cfg *config.Config, skillName string, command []string)
 	defer cancel()
 	stdoutBuf := newLimitedBuffer(policy.MaxOutputBytes)
 	stderrBuf := newLimitedBuffer(policy.MaxOutputBytes)
-	err = runSkillCommandWithIsolation(ctx, cfg, skillRoot, scratch, command, stdoutBuf, stderrBuf)
+	err = runSkillCommandWithIsolation(ctx, cfg, policy, skillRoot, scratch, command, stdoutBuf, stderrBuf)
 	dur := time.Since(start)
 	exitCode := 0
 	if err != nil {
@@ -128,7 +134,7 @@ func ExecuteSkillCommand(cfg *config.Config, skillName string, command []string)
 	}, err
 }
 
-func runSkillCommandWithIsolation(ctx context.Context, cfg *config.Config, skillRoot, scratch string, command []string, stdout io.Writer, stderr io.Writer) error {
+func runSkillCommandWithIsolation(ctx context.Context, cfg *config.Config, policy runtimePolicy, skillRoot, scratch string, command []string, stdout io.Writer, stderr io.Writer) error {
 	mode := "auto"
 	if cfg != nil {
 		mode = strings.ToLower(strings.TrimSpace(cfg.Skills.RuntimeIsolation))
@@ -144,13 +150,18 @@ func runSkillCommandWithIsolation(ctx context.Context, cfg *config.Config, skill
 		if err != nil {
 			return err
 		}
-		return runContainerIsolatedCommand(ctx, runtimeBin, skillRoot, scratch, command, stdout, stderr)
+		return runContainerIsolatedCommand(ctx, runtimeBin, policy, skillRoot, scratch, command, stdout, stderr)
+	case "native":
+		return runNativeIsolatedCommand(ctx, cfg, policy, scratch, command, stdout, stderr)
 	case "auto":
 		if runtimeBin, ok := detectContainerRuntime(); ok {
-			if err := runContainerIsolatedCommand(ctx, runtimeBin, skillRoot, scratch, command, stdout, stderr); err == nil {
+			if err := runContainerIsolatedCommand(ctx, runtimeBin, policy, skillRoot, scratch, command, stdout, stderr); err == nil {
 				return nil
 			}
 		}
+		if sandbox.Preflight() == nil {
+			return runNativeIsolatedCommand(ctx, cfg, policy, scratch, command, stdout, stderr)
+		}
 		return runHostCommand(ctx, scratch, command, stdout, stderr)
 	default:
 		return runHostCommand(ctx, scratch, command, stdout, stderr)
@@ -166,6 +177,51 @@ func runHostCommand(ctx context.Context, scratch string, command []string, stdou
 	return cmd.Run()
 }
 
+// runNativeIsolatedCommand runs the command in the Linux namespace sandbox.
+// Only the scratch dir is writable, plus the work repo when the skill
+// policy does not mark it read-only; network and resource limits follow
+// the policy.
+func runNativeIsolatedCommand(ctx context.Context, cfg *config.Config, p runtimePolicy, scratch string, command []string, stdout io.Writer, stderr io.Writer) error {
+	writable := []string{scratch}
+	if workRepo := strings.TrimSpace(cfg.Paths.WorkRepoPath); !p.ReadOnlyWorkspace && workRepo != "" {
+		writable = append(writable, workRepo)
+	}
+	cmd, release, err := sandbox.Command(ctx, sandbox.Policy{
+		WritableDirs: writable,
+		WorkDir:      scratch,
+		Env:          minimalRuntimeEnv(scratch),
+		Network:      p.Network,
+		Strict:       cfg.Skills.StrictSandbox,
+		MemoryBytes:  p.MemoryBytes,
+		CPUs:         p.CPUs,
+	}, command)
+	if err != nil {
+		return err
+	}
+	defer release()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+// containerMemory is the container memory limit, 256 MiB unless the skill
+// policy sets one.
+func containerMemory(n int64) string {
+	if n <= 0 {
+		return "256m"
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// containerCPUs is the container CPU limit, one CPU unless the skill policy
+// sets one.
+func containerCPUs(cpus float64) string {
+	if cpus <= 0 {
+		return "1.0"
+	}
+	return strconv.FormatFloat(cpus, 'f', -1, 64)
+}
+
 func detectContainerRuntime() (string, bool) {
 	for _, name := range []string{"docker", "podman"} {
 		if HasBinary(name) {
@@ -192,7 +248,7 @@ func StrictIsolationPreflight() (string, error) {
 	return runtimeBin, nil
 }
 
-func runContainerIsolatedCommand(ctx context.Context, runtimeBin, skillRoot, scratch string, command []string, stdout io.Writer, stderr io.Writer) error {
+func runContainerIsolatedCommand(ctx context.Context, runtimeBin string, p runtimePolicy, skillRoot, scratch string, command []string, stdout io.Writer, stderr io.Writer) error {
 	if len(command) == 0 {
 		return fmt.Errorf("invalid command")
 	}
@@ -205,8 +261,8 @@ func runContainerIsolatedCommand(ctx context.Context, runtimeBin, skillRoot, scr
 		"--network", "none",
 		"--read-only",
 		"--pids-limit", "64",
-		"--memory", "256m",
-		"--cpus", "1.0",
+		"--memory", containerMemory(p.MemoryBytes),
+		"--cpus", containerCPUs(p.CPUs),
 		"--cap-drop", "ALL",
 		"--security-opt", "no-new-privileges",
 		"--user", "65534:65534",
@@ -256,8 +312,16 @@ func loadRuntimePolicy(skillRoot string) (runtimePolicy, error) {
 	if manifest.Execution.MaxOutputBytes > 0 {
 		p.MaxOutputBytes = manifest.Execution.MaxOutputBytes
 	}
+	if manifest.Execution.MemoryMB > 0 {
+		p.MemoryBytes = int64(manifest.Execution.MemoryMB) << 20
+	}
+	if manifest.Execution.CPUs > 0 {
+		p.CPUs = manifest.Execution.CPUs
+	}
 	p.AllowCommands = normalizeCommandList(manifest.Execution.AllowCommands)
 	p.DenyCommands = normalizeCommandList(manifest.Execution.DenyCommands)
+	p.AllowDomains = normalizeDomains(manifest.LinkPolicy.AllowDomains)
+	p.DenyDomains = normalizeDomains(manifest.LinkPolicy.DenyDomains)
 	return p, nil
 }
 
@@ -7,8 +7,14 @@ import (
 	"testing"
 
 	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/sandbox"
 )
 
+func TestMain(m *testing.M) {
+	sandbox.Init()
+	os.Exit(m.Run())
+}
+
 func TestExecuteSkillCommand_AllowlistBlocks(t *testing.T) {
 	cfg, skillName := setupRuntimeSkill(t, `{
   "version": "1",
@@ -84,6 +90,51 @@ func TestExecuteSkillCommand_BlocksShellInterpreter(t *testing.T) {
 	}
 }
 
+func TestExecuteSkillCommand_NativeIsolationScratchOnly(t *testing.T) {
+	if err := sandbox.Preflight(); err != nil {
+		t.Skipf("native sandbox unavailable: %v", err)
+	}
+	cfg, skillName := setupRuntimeSkill(t, `{"version":"1","execution":{"allowCommands":["touch"]}}`)
+	cfg.Skills.RuntimeIsolation = "native"
+
+	res, err := ExecuteSkillCommand(cfg, skillName, []string{"touch", "made.txt"})
+	if err != nil {
+		t.Fatalf("exec failed: %v (stderr %q)", err, res.Stderr)
+	}
+	if _, err := os.Stat(filepath.Join(res.ScratchDir, "made.txt")); err != nil {
+		t.Fatalf("expected file in scratch: %v", err)
+	}
+
+	outside := filepath.Join(t.TempDir(), "escaped.txt")
+	res, err = ExecuteSkillCommand(cfg, skillName, []string{"touch", outside})
+	if err == nil || res.ExitCode == 0 {
+		t.Fatalf("expected write outside scratch to fail, got exit %d", res.ExitCode)
+	}
+	if _, statErr := os.Stat(outside); statErr == nil {
+		t.Fatal("native sandbox allowed a write outside scratch")
+	}
+}
+
+func TestLoadRuntimePolicyResourceLimits(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "SKILL-POLICY.json"), []byte(`{"version":"1","execution":{"memoryMb":128,"cpus":0.5}}`), 0o600); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	p, err := loadRuntimePolicy(root)
+	if err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+	if p.MemoryBytes != 128<<20 || p.CPUs != 0.5 {
+		t.Fatalf("expected policy limits, got memory=%d cpus=%v", p.MemoryBytes, p.CPUs)
+	}
+	if containerMemory(p.MemoryBytes) != "134217728" || containerCPUs(p.CPUs) != "0.5" {
+		t.Fatalf("unexpected container limits %s/%s", containerMemory(p.MemoryBytes), containerCPUs(p.CPUs))
+	}
+	if containerMemory(0) != "256m" || containerCPUs(0) != "1.0" {
+		t.Fatal("expected container defaults without policy limits")
+	}
+}
+
 func TestEnforceRuntimePolicyMatrix(t *testing.T) {
 	workspace := t.TempDir()
 	cases := []struct {
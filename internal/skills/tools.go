@@ -0,0 +1,401 @@
+package skills
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+// SkillToolsFile is the manifest in a skill root that declares typed tools.
+const SkillToolsFile = "SKILL-TOOLS.json"
+
+// Tool tiers as used by the agent tool registry.
+const (
+	toolTierReadOnly = 0
+	toolTierWrite    = 1
+	toolTierHighRisk = 2
+)
+
+var (
+	skillToolNameExpr    = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+	skillToolPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+)
+
+// SkillToolsManifest is the content of SKILL-TOOLS.json.
+type SkillToolsManifest struct {
+	Version string          `json:"version"`
+	Tools   []SkillToolSpec `json:"tools"`
+}
+
+// SkillToolSpec declares one tool. Exactly one of Command and HTTP is set.
+type SkillToolSpec struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+	Tier        *int           `json:"tier"`
+	// Command is an argv template; "{{param}}" is replaced by the argument
+	// of the call. The executable itself cannot be templated.
+	Command []string       `json:"command,omitempty"`
+	HTTP    *SkillToolHTTP `json:"http,omitempty"`
+}
+
+// SkillToolHTTP declares an HTTP target. Placeholders are allowed in the
+// path and query of URL, not in the host.
+type SkillToolHTTP struct {
+	Method string `json:"method,omitempty"`
+	URL    string `json:"url"`
+	// Credential names an entry of tools.http.credentials.
+	Credential string `json:"credential,omitempty"`
+	// JSONBody sends the arguments not used in URL as a JSON body.
+	JSONBody bool `json:"jsonBody,omitempty"`
+}
+
+// SkillTool is a tool declared by an enabled, installed skill.
+type SkillTool struct {
+	Skill string
+	Spec  SkillToolSpec
+	// Tier is the declared tier, raised to what the skill policy permits:
+	// commands that may write the work repo are at least tier 1 and
+	// non-read HTTP methods are tier 2.
+	Tier int
+}
+
+// LoadSkillTools reads the tool manifests of all enabled installed skills.
+// Invalid manifests and tools whose name is already taken are skipped and
+// reported in the returned errors.
+func LoadSkillTools(cfg *config.Config) ([]SkillTool, []error) {
+	if cfg == nil || !cfg.Skills.Enabled {
+		return nil, nil
+	}
+	dirs, err := ResolveStateDirs()
+	if err != nil {
+		return nil, []error{err}
+	}
+	entries, err := os.ReadDir(dirs.Installed)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, []error{err}
+	}
+
+	var out []SkillTool
+	var errs []error
+	seen := map[string]string{}
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || !EffectiveSkillEnabled(cfg, name) {
+			continue
+		}
+		root := filepath.Join(dirs.Installed, name)
+		data, err := os.ReadFile(filepath.Join(root, SkillToolsFile))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("skill %s: %w", name, err))
+			}
+			continue
+		}
+		manifest, problems := parseSkillToolsManifest(data)
+		if len(problems) > 0 {
+			errs = append(errs, fmt.Errorf("skill %s: invalid %s: %s", name, SkillToolsFile, strings.Join(problems, "; ")))
+			continue
+		}
+		policy, err := loadRuntimePolicy(root)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("skill %s: %w", name, err))
+			continue
+		}
+		for _, spec := range manifest.Tools {
+			if other, ok := seen[spec.Name]; ok {
+				errs = append(errs, fmt.Errorf("skill %s: tool %s already declared by skill %s", name, spec.Name, other))
+				continue
+			}
+			seen[spec.Name] = name
+			out = append(out, SkillTool{Skill: name, Spec: spec, Tier: effectiveToolTier(spec, policy)})
+		}
+	}
+	return out, errs
+}
+
+func parseSkillToolsManifest(data []byte) (*SkillToolsManifest, []string) {
+	var m SkillToolsManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, []string{fmt.Sprintf("invalid JSON: %v", err)}
+	}
+	var problems []string
+	if strings.TrimSpace(m.Version) == "" {
+		problems = append(problems, "`version` is required")
+	}
+	names := map[string]bool{}
+	for i, spec := range m.Tools {
+		label := spec.Name
+		if label == "" {
+			label = fmt.Sprintf("tools[%d]", i)
+		}
+		for _, p := range validateToolSpec(spec) {
+			problems = append(problems, label+": "+p)
+		}
+		if names[spec.Name] {
+			problems = append(problems, label+": duplicate tool name")
+		}
+		names[spec.Name] = true
+	}
+	return &m, problems
+}
+
+func validateToolSpec(spec SkillToolSpec) []string {
+	var problems []string
+	if !skillToolNameExpr.MatchString(spec.Name) {
+		problems = append(problems, "name must match [a-z][a-z0-9_]{0,63}")
+	}
+	if strings.TrimSpace(spec.Description) == "" {
+		problems = append(problems, "description is required")
+	}
+	if spec.Tier == nil {
+		problems = append(problems, "tier is required")
+	} else if *spec.Tier < toolTierReadOnly || *spec.Tier > toolTierHighRisk {
+		problems = append(problems, "tier must be 0, 1 or 2")
+	}
+	props := map[string]any{}
+	if spec.Parameters != nil {
+		if t, _ := spec.Parameters["type"].(string); t != "object" {
+			problems = append(problems, "parameters must be a JSON Schema with type object")
+		}
+		props, _ = spec.Parameters["properties"].(map[string]any)
+	}
+
+	var templates []string
+	switch {
+	case len(spec.Command) > 0 && spec.HTTP != nil:
+		problems = append(problems, "set either command or http, not both")
+	case len(spec.Command) > 0:
+		exe := strings.TrimSpace(spec.Command[0])
+		switch {
+		case exe == "" || skillToolPlaceholder.MatchString(exe):
+			problems = append(problems, "command[0] must be a fixed executable")
+		case slices.Contains(blockedInterpreterCommands, strings.ToLower(filepath.Base(exe))):
+			problems = append(problems, fmt.Sprintf("command %q is an interpreter shell and is blocked in skills runtime", exe))
+		}
+		templates = spec.Command[1:]
+	case spec.HTTP != nil:
+		if !slices.Contains([]string{"GET", "HEAD", "OPTIONS", "POST", "PUT", "PATCH", "DELETE"}, skillToolMethod(spec.HTTP)) {
+			problems = append(problems, fmt.Sprintf("unsupported http method %q", spec.HTTP.Method))
+		}
+		if _, err := skillToolURLBase(spec.HTTP.URL); err != nil {
+			problems = append(problems, err.Error())
+		}
+		templates = []string{spec.HTTP.URL}
+	default:
+		problems = append(problems, "command or http is required")
+	}
+	for _, tpl := range templates {
+		for _, m := range skillToolPlaceholder.FindAllStringSubmatch(tpl, -1) {
+			if _, ok := props[m[1]]; !ok {
+				problems = append(problems, fmt.Sprintf("placeholder {{%s}} is not a declared parameter", m[1]))
+			}
+		}
+	}
+	return problems
+}
+
+// skillToolURLBase returns the URL template with placeholders removed after
+// checking that scheme and host are fixed.
+func skillToolURLBase(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("http url must be absolute: %q", raw)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return "", fmt.Errorf("unsupported http url scheme %q", u.Scheme)
+	}
+	if strings.Contains(u.Host, "{") || u.User != nil {
+		return "", fmt.Errorf("http url host must be fixed and without credentials: %q", raw)
+	}
+	return skillToolPlaceholder.ReplaceAllString(raw, ""), nil
+}
+
+func skillToolMethod(h *SkillToolHTTP) string {
+	method := strings.ToUpper(strings.TrimSpace(h.Method))
+	if method == "" {
+		return "GET"
+	}
+	return method
+}
+
+func effectiveToolTier(spec SkillToolSpec, p runtimePolicy) int {
+	tier := toolTierHighRisk
+	if spec.Tier != nil {
+		tier = *spec.Tier
+	}
+	switch {
+	case spec.HTTP != nil:
+		switch skillToolMethod(spec.HTTP) {
+		case "GET", "HEAD", "OPTIONS":
+		default:
+			tier = toolTierHighRisk
+		}
+	case !p.ReadOnlyWorkspace:
+		tier = max(tier, toolTierWrite)
+	}
+	return tier
+}
+
+// Method returns the HTTP method of an HTTP tool.
+func (t SkillTool) Method() string {
+	if t.Spec.HTTP == nil {
+		return ""
+	}
+	return skillToolMethod(t.Spec.HTTP)
+}
+
+// MissingRequired lists required parameters absent from params.
+func (t SkillTool) MissingRequired(params map[string]any) []string {
+	var missing []string
+	required, _ := t.Spec.Parameters["required"].([]any)
+	for _, r := range required {
+		name, _ := r.(string)
+		if v, ok := params[name]; name != "" && (!ok || v == nil) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// RenderCommand expands the command template. An argument that is only a
+// placeholder is dropped when the parameter is missing; embedded
+// placeholders expand to "". Each value stays a single argv element, so
+// arguments cannot inject further arguments or shell syntax. A value that
+// fills a whole argument must not start with "-", or the executable would
+// read it as an option.
+func (t SkillTool) RenderCommand(params map[string]any) ([]string, error) {
+	argv := []string{t.Spec.Command[0]}
+	for _, tpl := range t.Spec.Command[1:] {
+		if m := skillToolPlaceholder.FindStringSubmatch(tpl); m != nil && m[0] == tpl {
+			if v, ok := params[m[1]]; !ok || v == nil {
+				continue
+			}
+			if v := skillToolValue(params, tpl); strings.HasPrefix(v, "-") {
+				return nil, fmt.Errorf("parameter %s must not start with \"-\"", m[1])
+			}
+		}
+		argv = append(argv, skillToolPlaceholder.ReplaceAllStringFunc(tpl, func(ph string) string {
+			return skillToolValue(params, ph)
+		}))
+	}
+	return argv, nil
+}
+
+// RenderURL expands the URL template, escaping values for the path or the
+// query, and returns the names of the parameters it used.
+func (t SkillTool) RenderURL(params map[string]any) (string, map[string]bool) {
+	used := map[string]bool{}
+	raw := t.Spec.HTTP.URL
+	path, query, hasQuery := strings.Cut(raw, "?")
+	expand := func(s string, escape func(string) string) string {
+		return skillToolPlaceholder.ReplaceAllStringFunc(s, func(ph string) string {
+			used[skillToolPlaceholder.FindStringSubmatch(ph)[1]] = true
+			return escape(skillToolValue(params, ph))
+		})
+	}
+	out := expand(path, url.PathEscape)
+	if hasQuery {
+		out += "?" + expand(query, url.QueryEscape)
+	}
+	return out, used
+}
+
+func skillToolValue(params map[string]any, placeholder string) string {
+	name := skillToolPlaceholder.FindStringSubmatch(placeholder)[1]
+	switch v := params[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64, int, int64, bool:
+		return fmt.Sprint(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// CheckSkillHTTPTarget checks that a tool of skillName may call rawURL: the
+// skill is enabled and installed, its policy allows network access, and the
+// URL passes the global and the skill's link policy. It returns the skill's
+// execution timeout.
+func CheckSkillHTTPTarget(cfg *config.Config, skillName, rawURL string) (time.Duration, error) {
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	skillName = sanitizeSkillName(skillName)
+	if skillName == "" || !EffectiveSkillEnabled(cfg, skillName) {
+		return 0, fmt.Errorf("skill %q is not enabled", skillName)
+	}
+	dirs, err := ResolveStateDirs()
+	if err != nil {
+		return 0, err
+	}
+	policy, err := loadRuntimePolicy(filepath.Join(dirs.Installed, skillName))
+	if err != nil {
+		return 0, err
+	}
+	if !policy.Network {
+		return 0, errors.New("network access is disabled by the skill policy (execution.network)")
+	}
+	if err := validatePolicyURL(cfg, rawURL); err != nil {
+		return 0, err
+	}
+	u, _ := url.Parse(rawURL)
+	host := strings.ToLower(u.Hostname())
+	if len(policy.AllowDomains) > 0 && !matchesAnyDomain(host, policy.AllowDomains) {
+		return 0, fmt.Errorf("domain blocked by skill allowlist policy: %s", host)
+	}
+	if matchesAnyDomain(host, policy.DenyDomains) {
+		return 0, fmt.Errorf("domain blocked by skill denylist policy: %s", host)
+	}
+	return policy.Timeout, nil
+}
+
+// validateToolsManifest checks SKILL-TOOLS.json when a package has one.
+func validateToolsManifest(cfg *config.Config, pkg *sourcePackage, report *VerifyReport) {
+	data, ok := findFile(pkg.Files, SkillToolsFile)
+	if !ok {
+		return
+	}
+	manifest, problems := parseSkillToolsManifest(data)
+	for _, p := range problems {
+		report.appendFinding(SeverityCritical, "tools_manifest_invalid", p, SkillToolsFile)
+	}
+	if manifest == nil {
+		return
+	}
+	network := false
+	if policyData, ok := findFile(pkg.Files, "SKILL-POLICY.json"); ok {
+		var policy skillPolicyManifest
+		if json.Unmarshal(policyData, &policy) == nil && policy.Execution.Network != nil {
+			network = *policy.Execution.Network
+		}
+	}
+	for _, spec := range manifest.Tools {
+		if spec.HTTP == nil {
+			continue
+		}
+		if !network {
+			report.appendFinding(SeverityWarn, "tools_manifest_http_without_network", fmt.Sprintf("%s: http tools need execution.network=true in SKILL-POLICY.json", spec.Name), SkillToolsFile)
+		}
+		if base, err := skillToolURLBase(spec.HTTP.URL); err == nil {
+			if err := validatePolicyURL(cfg, base); err != nil {
+				report.appendFinding(SeverityCritical, "tools_manifest_link_blocked", fmt.Sprintf("%s: %v", spec.Name, err), SkillToolsFile)
+			}
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package skills
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+const testToolsManifest = `{
+  "version": "1",
+  "tools": [
+    {
+      "name": "word_count",
+      "description": "Count words in text",
+      "tier": 0,
+      "parameters": {
+        "type": "object",
+        "properties": {"text": {"type": "string"}, "flag": {"type": "string"}},
+        "required": ["text"]
+      },
+      "command": ["wc", "{{flag}}", "--files0-from={{text}}", "{{text}}"]
+    },
+    {
+      "name": "weather",
+      "description": "Look up the weather",
+      "tier": 0,
+      "parameters": {"type": "object", "properties": {"city": {"type": "string"}, "units": {"type": "string"}}},
+      "http": {"url": "https://api.weather.example/v1/{{city}}?units={{units}}"}
+    },
+    {
+      "name": "post_note",
+      "description": "Post a note",
+      "tier": 0,
+      "parameters": {"type": "object", "properties": {"text": {"type": "string"}}},
+      "http": {"method": "POST", "url": "https://notes.example/api", "jsonBody": true}
+    }
+  ]
+}`
+
+func writeSkillTools(t *testing.T, skillName, manifest string) {
+	t.Helper()
+	dirs, err := ResolveStateDirs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dirs.Installed, skillName, SkillToolsFile), []byte(manifest), 0o600); err != nil {
+		t.Fatalf("write tools manifest: %v", err)
+	}
+}
+
+func TestLoadSkillToolsRaisesTiersFromPolicy(t *testing.T) {
+	cfg, skillName := setupRuntimeSkill(t, `{"version":"1","execution":{"readOnlyWorkspace":false}}`)
+	writeSkillTools(t, skillName, testToolsManifest)
+
+	defs, errs := LoadSkillTools(cfg)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	tiers := map[string]int{}
+	for _, d := range defs {
+		if d.Skill != skillName {
+			t.Fatalf("unexpected skill %q", d.Skill)
+		}
+		tiers[d.Spec.Name] = d.Tier
+	}
+	want := map[string]int{"word_count": 1, "weather": 0, "post_note": 2}
+	if !reflect.DeepEqual(tiers, want) {
+		t.Fatalf("tiers = %v, want %v", tiers, want)
+	}
+
+	cfg.Skills.Entries[skillName] = config.SkillEntryConfig{Enabled: false}
+	if defs, _ := LoadSkillTools(cfg); len(defs) != 0 {
+		t.Fatalf("disabled skill should not declare tools, got %d", len(defs))
+	}
+}
+
+func TestLoadSkillToolsSkipsInvalidManifest(t *testing.T) {
+	cfg, skillName := setupRuntimeSkill(t, "")
+	writeSkillTools(t, skillName, `{"version":"1","tools":[{"name":"Bad Name","description":"x","tier":0,"command":["sh","-c","{{x}}"]}]}`)
+
+	defs, errs := LoadSkillTools(cfg)
+	if len(defs) != 0 || len(errs) != 1 {
+		t.Fatalf("expected manifest to be rejected, got %d tools, errors %v", len(defs), errs)
+	}
+	msg := errs[0].Error()
+	for _, want := range []string{"name must match", "interpreter shell", "{{x}} is not a declared parameter"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("expected %q in %q", want, msg)
+		}
+	}
+}
+
+func TestParseSkillToolsManifestProblems(t *testing.T) {
+	cases := map[string]string{
+		"missing tier":     `{"version":"1","tools":[{"name":"a","description":"d","command":["ls"]}]}`,
+		"no target":        `{"version":"1","tools":[{"name":"a","description":"d","tier":0}]}`,
+		"both targets":     `{"version":"1","tools":[{"name":"a","description":"d","tier":0,"command":["ls"],"http":{"url":"https://x.example"}}]}`,
+		"templated binary": `{"version":"1","tools":[{"name":"a","description":"d","tier":0,"parameters":{"type":"object","properties":{"b":{}}},"command":["{{b}}"]}]}`,
+		"templated host":   `{"version":"1","tools":[{"name":"a","description":"d","tier":0,"parameters":{"type":"object","properties":{"h":{}}},"http":{"url":"https://{{h}}/x"}}]}`,
+		"duplicate":        `{"version":"1","tools":[{"name":"a","description":"d","tier":0,"command":["ls"]},{"name":"a","description":"d","tier":0,"command":["ls"]}]}`,
+		"no version":       `{"tools":[]}`,
+	}
+	for name, manifest := range cases {
+		if _, problems := parseSkillToolsManifest([]byte(manifest)); len(problems) == 0 {
+			t.Errorf("%s: expected problems", name)
+		}
+	}
+	if _, problems := parseSkillToolsManifest([]byte(testToolsManifest)); len(problems) > 0 {
+		t.Fatalf("valid manifest rejected: %v", problems)
+	}
+}
+
+func TestSkillToolRendering(t *testing.T) {
+	m, _ := parseSkillToolsManifest([]byte(testToolsManifest))
+	wc := SkillTool{Spec: m.Tools[0]}
+	got, err := wc.RenderCommand(map[string]any{"text": "a b; rm -rf /"})
+	want := []string{"wc", "--files0-from=a b; rm -rf /", "a b; rm -rf /"}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("RenderCommand = %q (err=%v), want %q", got, err, want)
+	}
+	if _, err := wc.RenderCommand(map[string]any{"text": "--pre=sh"}); err == nil || !strings.Contains(err.Error(), "text") {
+		t.Fatalf("expected option-like value to be rejected, got %v", err)
+	}
+	if missing := wc.MissingRequired(map[string]any{}); !reflect.DeepEqual(missing, []string{"text"}) {
+		t.Fatalf("MissingRequired = %v", missing)
+	}
+
+	weather := SkillTool{Spec: m.Tools[1]}
+	u, used := weather.RenderURL(map[string]any{"city": "New York/../admin", "units": "a&b=c"})
+	if u != "https://api.weather.example/v1/New%20York%2F..%2Fadmin?units=a%26b%3Dc" {
+		t.Fatalf("RenderURL = %q", u)
+	}
+	if !used["city"] || !used["units"] {
+		t.Fatalf("used = %v", used)
+	}
+}
+
+func TestCheckSkillHTTPTarget(t *testing.T) {
+	cfg, skillName := setupRuntimeSkill(t, "")
+	if _, err := CheckSkillHTTPTarget(cfg, skillName, "https://api.example.com/x"); err == nil || !strings.Contains(err.Error(), "execution.network") {
+		t.Fatalf("expected network policy error, got %v", err)
+	}
+
+	cfg, skillName = setupRuntimeSkill(t, `{"version":"1","execution":{"network":true,"timeoutSeconds":7},"linkPolicy":{"allowDomains":["example.com"]}}`)
+	cfg.Skills.LinkPolicy.AllowDomains = append(cfg.Skills.LinkPolicy.AllowDomains, "example.com", "example.org")
+	timeout, err := CheckSkillHTTPTarget(cfg, skillName, "https://api.example.com/x")
+	if err != nil || timeout.Seconds() != 7 {
+		t.Fatalf("expected allowed target with 7s timeout, got %v, %v", timeout, err)
+	}
+	if _, err := CheckSkillHTTPTarget(cfg, skillName, "https://evil.example.org/x"); err == nil || !strings.Contains(err.Error(), "skill allowlist") {
+		t.Fatalf("expected skill allowlist block, got %v", err)
+	}
+}
+
+func TestVerifySkillSource_ToolsManifest(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "SKILL.md"), []byte("---\nname: tools-skill\ndescription: desc\n---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, SkillToolsFile), []byte(`{"version":"1","tools":[{"name":"x","description":"d","command":["ls"]}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Skills.Enabled = true
+	report, err := VerifySkillSource(cfg, root)
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	found := false
+	for _, f := range report.Findings {
+		if f.Code == "tools_manifest_invalid" && strings.Contains(f.Message, "tier is required") {
+			found = true
+		}
+	}
+	if !found || report.OK {
+		t.Fatalf("expected critical tools manifest finding, got %#v", report.Findings)
+	}
+}
@@ -126,6 +126,8 @@ type skillPolicyManifest struct {
 		DenyCommands      []string `json:"denyCommands,omitempty"`
 		TimeoutSeconds    int      `json:"timeoutSeconds,omitempty"`
 		MaxOutputBytes    int      `json:"maxOutputBytes,omitempty"`
+		MemoryMB          int      `json:"memoryMb,omitempty"`
+		CPUs              float64  `json:"cpus,omitempty"`
 	} `json:"execution,omitempty"`
 	LinkPolicy struct {
 		AllowDomains []string `json:"allowDomains,omitempty"`
@@ -162,6 +164,7 @@ func VerifySkillSource(cfg *config.Config, target string) (*VerifyReport, error)
 	report.FileCount = len(pkg.Files)
 	scanPackage(cfg, pkg, report)
 	validateManifestAndFrontmatter(cfg, pkg, report)
+	validateToolsManifest(cfg, pkg, report)
 	report.OK = report.CriticalCount() == 0
 	return report, nil
 }
@@ -803,6 +806,9 @@ func validateManifestAndFrontmatter(cfg *config.Config, pkg *sourcePackage, repo
 	if policy.Execution.MaxOutputBytes < 0 {
 		report.appendFinding(SeverityCritical, "policy_manifest_output_limit_invalid", "execution.maxOutputBytes must be >= 0", "SKILL-POLICY.json")
 	}
+	if policy.Execution.MemoryMB < 0 || policy.Execution.CPUs < 0 {
+		report.appendFinding(SeverityCritical, "policy_manifest_resource_limit_invalid", "execution.memoryMb and execution.cpus must be >= 0", "SKILL-POLICY.json")
+	}
 	for _, c := range append([]string{}, policy.Execution.AllowCommands...) {
 		if strings.TrimSpace(c) == "" {
 			report.appendFinding(SeverityCritical, "policy_manifest_command_invalid", "execution.allowCommands cannot include empty values", "SKILL-POLICY.json")
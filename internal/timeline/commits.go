@@ -0,0 +1,49 @@
+package timeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RecordRepoCommit stores a commit made by the agent against its trace.
+func (s *TimelineService) RecordRepoCommit(r *RepoCommitRecord) error {
+	if r == nil || strings.TrimSpace(r.SHA) == "" || strings.TrimSpace(r.Repo) == "" {
+		return fmt.Errorf("repo and sha are required")
+	}
+	files := r.Files
+	if files == nil {
+		files = []string{}
+	}
+	raw, err := json.Marshal(files)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO repo_commits (trace_id, task_id, repo, sha, branch, message, files, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'))`,
+		r.TraceID, r.TaskID, r.Repo, r.SHA, r.Branch, r.Message, string(raw))
+	return err
+}
+
+// ListRepoCommitsByTrace returns the commits recorded for a trace, oldest first.
+func (s *TimelineService) ListRepoCommitsByTrace(traceID string) ([]RepoCommitRecord, error) {
+	rows, err := s.db.Query(`SELECT id, COALESCE(trace_id,''), COALESCE(task_id,''), repo, sha,
+		COALESCE(branch,''), COALESCE(message,''), files, created_at
+		FROM repo_commits WHERE trace_id = ? ORDER BY id ASC`, traceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []RepoCommitRecord
+	for rows.Next() {
+		var r RepoCommitRecord
+		var files string
+		if err := rows.Scan(&r.ID, &r.TraceID, &r.TaskID, &r.Repo, &r.SHA,
+			&r.Branch, &r.Message, &files, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(files), &r.Files)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,49 @@
+package timeline
+
+import "testing"
+
+func TestRepoCommitsLinkedToTraceGraph(t *testing.T) {
+	svc := newTestTimeline(t)
+	if err := svc.RecordRepoCommit(&RepoCommitRecord{
+		TraceID: "trace-1",
+		TaskID:  "task-1",
+		Repo:    "/work",
+		SHA:     "0123456789abcdef0123456789abcdef01234567",
+		Branch:  "main",
+		Message: "fix parser",
+		Files:   []string{"parser.go", "parser_test.go"},
+	}); err != nil {
+		t.Fatalf("record commit: %v", err)
+	}
+	if err := svc.RecordRepoCommit(&RepoCommitRecord{Repo: "/work"}); err == nil {
+		t.Fatal("expected error without sha")
+	}
+
+	commits, err := svc.ListRepoCommitsByTrace("trace-1")
+	if err != nil {
+		t.Fatalf("list commits: %v", err)
+	}
+	if len(commits) != 1 || commits[0].TaskID != "task-1" || len(commits[0].Files) != 2 || commits[0].Files[1] != "parser_test.go" {
+		t.Fatalf("unexpected commits: %+v", commits)
+	}
+	if other, _ := svc.ListRepoCommitsByTrace("trace-2"); len(other) != 0 {
+		t.Fatalf("expected no commits for other trace, got %+v", other)
+	}
+
+	graph, err := svc.GetTraceGraph("trace-1")
+	if err != nil {
+		t.Fatalf("trace graph: %v", err)
+	}
+	if len(graph.Commits) != 1 || graph.Commits[0]["message"] != "fix parser" {
+		t.Fatalf("expected commit in trace graph, got %+v", graph.Commits)
+	}
+	found := false
+	for _, n := range graph.Nodes {
+		if n.Type == "COMMIT" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected COMMIT node, got %+v", graph.Nodes)
+	}
+}
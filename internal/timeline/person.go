@@ -0,0 +1,248 @@
+package timeline
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PersonResourcePrefix prefixes working-memory resource IDs scoped to a
+// person instead of a single channel chat.
+const PersonResourcePrefix = "person:"
+
+// PersonResourceID returns the working-memory resource ID for a person.
+func PersonResourceID(personID string) string {
+	return PersonResourcePrefix + personID
+}
+
+// CreatePerson creates a new person with a generated ID.
+func (s *TimelineService) CreatePerson(displayName string) (*Person, error) {
+	displayName = strings.TrimSpace(displayName)
+	if displayName == "" {
+		return nil, fmt.Errorf("display_name is required")
+	}
+	personID := "p-" + newTaskID()[:12]
+	_, err := s.db.Exec(`INSERT INTO persons (person_id, display_name, preferences, created_at, updated_at)
+		VALUES (?, ?, '{}', datetime('now'), datetime('now'))`, personID, displayName)
+	if err != nil {
+		return nil, fmt.Errorf("create person: %w", err)
+	}
+	return s.GetPerson(personID)
+}
+
+// GetPerson returns a person with its linked identities.
+func (s *TimelineService) GetPerson(personID string) (*Person, error) {
+	personID = strings.TrimSpace(personID)
+	var p Person
+	var prefs string
+	err := s.db.QueryRow(`SELECT person_id, display_name, preferences, created_at, updated_at
+		FROM persons WHERE person_id = ?`, personID).
+		Scan(&p.PersonID, &p.DisplayName, &prefs, &p.CreatedAt, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("person %q not found", personID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.Preferences = decodePersonPreferences(prefs)
+	identities, err := s.ListPersonIdentities(personID)
+	if err != nil {
+		return nil, err
+	}
+	p.Identities = identities
+	return &p, nil
+}
+
+// ListPersons returns all persons with their identities, sorted by name.
+func (s *TimelineService) ListPersons() ([]Person, error) {
+	rows, err := s.db.Query(`SELECT person_id, display_name, preferences, created_at, updated_at
+		FROM persons ORDER BY display_name ASC, person_id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	var persons []Person
+	for rows.Next() {
+		var p Person
+		var prefs string
+		if err := rows.Scan(&p.PersonID, &p.DisplayName, &prefs, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p.Preferences = decodePersonPreferences(prefs)
+		persons = append(persons, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range persons {
+		identities, err := s.ListPersonIdentities(persons[i].PersonID)
+		if err != nil {
+			return nil, err
+		}
+		persons[i].Identities = identities
+	}
+	return persons, nil
+}
+
+// ListPersonIdentities returns the channel identities linked to a person.
+func (s *TimelineService) ListPersonIdentities(personID string) ([]PersonIdentity, error) {
+	rows, err := s.db.Query(`SELECT channel, sender_id, person_id, linked_at
+		FROM person_identities WHERE person_id = ? ORDER BY channel ASC, sender_id ASC`, personID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	identities := []PersonIdentity{}
+	for rows.Next() {
+		var id PersonIdentity
+		if err := rows.Scan(&id.Channel, &id.SenderID, &id.PersonID, &id.LinkedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, id)
+	}
+	return identities, rows.Err()
+}
+
+// LinkPersonIdentity links a channel sender to a person. A sender linked to
+// another person is moved.
+func (s *TimelineService) LinkPersonIdentity(personID, channel, senderID string) error {
+	personID = strings.TrimSpace(personID)
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	senderID = strings.TrimSpace(senderID)
+	if personID == "" || channel == "" || senderID == "" {
+		return fmt.Errorf("person_id, channel and sender_id are required")
+	}
+	if _, err := s.GetPerson(personID); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`INSERT INTO person_identities (channel, sender_id, person_id, linked_at)
+		VALUES (?, ?, ?, datetime('now'))
+		ON CONFLICT(channel, sender_id) DO UPDATE SET person_id = excluded.person_id, linked_at = excluded.linked_at`,
+		channel, senderID, personID)
+	return err
+}
+
+// UnlinkPersonIdentity removes a channel sender link. It reports whether a
+// link existed.
+func (s *TimelineService) UnlinkPersonIdentity(channel, senderID string) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM person_identities WHERE channel = ? AND sender_id = ?`,
+		strings.ToLower(strings.TrimSpace(channel)), strings.TrimSpace(senderID))
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ResolvePersonID returns the person linked to a channel sender, if any.
+func (s *TimelineService) ResolvePersonID(channel, senderID string) (string, bool, error) {
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	senderID = strings.TrimSpace(senderID)
+	if channel == "" || senderID == "" {
+		return "", false, nil
+	}
+	var personID string
+	err := s.db.QueryRow(`SELECT person_id FROM person_identities WHERE channel = ? AND sender_id = ?`,
+		channel, senderID).Scan(&personID)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return personID, true, nil
+}
+
+// SetPersonPreference sets one preference for a person. An empty value
+// removes the key.
+func (s *TimelineService) SetPersonPreference(personID, key, value string) error {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("preference key is required")
+	}
+	p, err := s.GetPerson(personID)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(value) == "" {
+		delete(p.Preferences, key)
+	} else {
+		p.Preferences[key] = value
+	}
+	return s.savePersonPreferences(s.db, p.PersonID, p.Preferences)
+}
+
+// MergePersons folds one person into another: identities, working memory
+// and preferences move to into, then from is deleted. On conflicting
+// preference keys or working-memory rows, into wins.
+func (s *TimelineService) MergePersons(fromID, intoID string) error {
+	fromID = strings.TrimSpace(fromID)
+	intoID = strings.TrimSpace(intoID)
+	if fromID == "" || intoID == "" {
+		return fmt.Errorf("from and into person IDs are required")
+	}
+	if fromID == intoID {
+		return fmt.Errorf("cannot merge a person into itself")
+	}
+	from, err := s.GetPerson(fromID)
+	if err != nil {
+		return err
+	}
+	into, err := s.GetPerson(intoID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin person merge tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE person_identities SET person_id = ? WHERE person_id = ?`, intoID, fromID); err != nil {
+		return fmt.Errorf("move identities: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO working_memory (resource_id, thread_id, content, updated_at)
+		SELECT ?, thread_id, content, updated_at FROM working_memory WHERE resource_id = ?`,
+		PersonResourceID(intoID), PersonResourceID(fromID)); err != nil {
+		return fmt.Errorf("move working memory: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM working_memory WHERE resource_id = ?`, PersonResourceID(fromID)); err != nil {
+		return fmt.Errorf("move working memory: %w", err)
+	}
+	for k, v := range from.Preferences {
+		if _, ok := into.Preferences[k]; !ok {
+			into.Preferences[k] = v
+		}
+	}
+	if err := s.savePersonPreferences(tx, intoID, into.Preferences); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM persons WHERE person_id = ?`, fromID); err != nil {
+		return fmt.Errorf("delete merged person: %w", err)
+	}
+	return tx.Commit()
+}
+
+type personExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func (s *TimelineService) savePersonPreferences(db personExecer, personID string, prefs map[string]string) error {
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`UPDATE persons SET preferences = ?, updated_at = datetime('now') WHERE person_id = ?`, string(data), personID)
+	return err
+}
+
+func decodePersonPreferences(raw string) map[string]string {
+	prefs := map[string]string{}
+	if strings.TrimSpace(raw) != "" {
+		_ = json.Unmarshal([]byte(raw), &prefs)
+	}
+	return prefs
+}
@@ -0,0 +1,126 @@
+package timeline
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPersonLinkResolveAndUnlink(t *testing.T) {
+	svc, err := NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("new timeline: %v", err)
+	}
+	defer svc.Close()
+
+	if _, err := svc.CreatePerson("  "); err == nil {
+		t.Fatal("expected display name to be required")
+	}
+	alice, err := svc.CreatePerson("Alice")
+	if err != nil {
+		t.Fatalf("create person: %v", err)
+	}
+	if err := svc.LinkPersonIdentity(alice.PersonID, "WhatsApp", "491@s.whatsapp.net"); err != nil {
+		t.Fatalf("link whatsapp: %v", err)
+	}
+	if err := svc.LinkPersonIdentity(alice.PersonID, "slack", "U123"); err != nil {
+		t.Fatalf("link slack: %v", err)
+	}
+	if err := svc.LinkPersonIdentity("p-missing", "slack", "U999"); err == nil {
+		t.Fatal("expected linking to an unknown person to fail")
+	}
+
+	id, ok, err := svc.ResolvePersonID("whatsapp", "491@s.whatsapp.net")
+	if err != nil || !ok || id != alice.PersonID {
+		t.Fatalf("resolve = %q %v %v", id, ok, err)
+	}
+	if _, ok, _ := svc.ResolvePersonID("msteams", "U123"); ok {
+		t.Fatal("identity must be scoped per channel")
+	}
+
+	got, err := svc.GetPerson(alice.PersonID)
+	if err != nil {
+		t.Fatalf("get person: %v", err)
+	}
+	if len(got.Identities) != 2 {
+		t.Fatalf("expected 2 identities, got %+v", got.Identities)
+	}
+
+	removed, err := svc.UnlinkPersonIdentity("slack", "U123")
+	if err != nil || !removed {
+		t.Fatalf("unlink = %v %v", removed, err)
+	}
+	if removed, _ := svc.UnlinkPersonIdentity("slack", "U123"); removed {
+		t.Fatal("second unlink should report nothing removed")
+	}
+	if _, ok, _ := svc.ResolvePersonID("slack", "U123"); ok {
+		t.Fatal("unlinked identity still resolves")
+	}
+}
+
+func TestMergePersonsMovesIdentitiesMemoryAndPreferences(t *testing.T) {
+	svc, err := NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("new timeline: %v", err)
+	}
+	defer svc.Close()
+
+	from, _ := svc.CreatePerson("Bob (slack)")
+	into, _ := svc.CreatePerson("Bob")
+	_ = svc.LinkPersonIdentity(from.PersonID, "slack", "U1")
+	_ = svc.LinkPersonIdentity(into.PersonID, "whatsapp", "492@s.whatsapp.net")
+	_ = svc.SetPersonPreference(from.PersonID, "language", "de")
+	_ = svc.SetPersonPreference(from.PersonID, "tone", "casual")
+	_ = svc.SetPersonPreference(into.PersonID, "tone", "formal")
+
+	db := svc.DB()
+	mustExec := func(q string, args ...any) {
+		t.Helper()
+		if _, err := db.Exec(q, args...); err != nil {
+			t.Fatalf("exec: %v", err)
+		}
+	}
+	mustExec(`INSERT INTO working_memory (resource_id, thread_id, content) VALUES (?, '', 'from-resource')`, PersonResourceID(from.PersonID))
+	mustExec(`INSERT INTO working_memory (resource_id, thread_id, content) VALUES (?, 't1', 'from-thread')`, PersonResourceID(from.PersonID))
+	mustExec(`INSERT INTO working_memory (resource_id, thread_id, content) VALUES (?, '', 'into-resource')`, PersonResourceID(into.PersonID))
+
+	if err := svc.MergePersons(from.PersonID, from.PersonID); err == nil {
+		t.Fatal("expected self-merge to fail")
+	}
+	if err := svc.MergePersons(from.PersonID, into.PersonID); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+
+	if _, err := svc.GetPerson(from.PersonID); err == nil {
+		t.Fatal("merged person should be deleted")
+	}
+	if id, ok, _ := svc.ResolvePersonID("slack", "U1"); !ok || id != into.PersonID {
+		t.Fatalf("slack identity resolves to %q", id)
+	}
+	merged, err := svc.GetPerson(into.PersonID)
+	if err != nil {
+		t.Fatalf("get merged: %v", err)
+	}
+	if merged.Preferences["language"] != "de" || merged.Preferences["tone"] != "formal" {
+		t.Fatalf("unexpected merged preferences %+v", merged.Preferences)
+	}
+
+	rows := map[string]string{}
+	r, err := db.Query(`SELECT thread_id, content FROM working_memory WHERE resource_id = ?`, PersonResourceID(into.PersonID))
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer r.Close()
+	for r.Next() {
+		var thread, content string
+		_ = r.Scan(&thread, &content)
+		rows[thread] = content
+	}
+	if rows[""] != "into-resource" || rows["t1"] != "from-thread" {
+		t.Fatalf("unexpected working memory after merge %+v", rows)
+	}
+	var leftover int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM working_memory WHERE resource_id = ?`, PersonResourceID(from.PersonID)).Scan(&leftover)
+	if leftover != 0 {
+		t.Fatalf("expected merged working memory to be moved, %d rows left", leftover)
+	}
+}
@@ -0,0 +1,95 @@
+package timeline
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUserScheduledJobLifecycle(t *testing.T) {
+	svc := newTestTimeline(t)
+	next := time.Date(2026, 3, 6, 9, 0, 0, 0, time.UTC)
+
+	// Builtin jobs tracked by UpsertScheduledJob are not user jobs.
+	if err := svc.UpsertScheduledJob("daily-sync", "ok", next); err != nil {
+		t.Fatalf("upsert builtin job: %v", err)
+	}
+	if err := svc.CreateScheduledJob(&ScheduledJobRecord{
+		JobName:       "standup",
+		Kind:          "cron",
+		Schedule:      "0 9 * * 1-5",
+		Timezone:      "Europe/Berlin",
+		Content:       "Summarize open PRs",
+		Channel:       "slack",
+		ChatID:        "C123",
+		ThreadID:      "1700000000.1",
+		SenderID:      "U1",
+		MessageType:   "external",
+		MisfirePolicy: "run_once",
+		NextRunAt:     &next,
+		CreatedBy:     "cli",
+	}); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	job, err := svc.GetScheduledJob("standup")
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	if job.ThreadID != "1700000000.1" || job.Timezone != "Europe/Berlin" || job.NextRunAt == nil || !job.NextRunAt.Equal(next) {
+		t.Fatalf("unexpected job %+v", job)
+	}
+	due, err := svc.ListUserScheduledJobs()
+	if err != nil || len(due) != 1 || due[0].JobName != "standup" {
+		t.Fatalf("expected only the user job, got %+v (%v)", due, err)
+	}
+
+	// A run links the job to the agent task by trace ID.
+	following := next.Add(24 * time.Hour)
+	if err := svc.RecordScheduledJobRun("standup", "", "missed", next.Add(-24*time.Hour), &following); err != nil {
+		t.Fatalf("record missed run: %v", err)
+	}
+	if err := svc.RecordScheduledJobRun("standup", "sched-standup-1", "dispatched", next, &following); err != nil {
+		t.Fatalf("record run: %v", err)
+	}
+	task, err := svc.CreateTask(&AgentTask{Channel: "slack", ChatID: "C123", TraceID: "sched-standup-1", ContentIn: "run"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if err := svc.UpdateTaskStatus(task.TaskID, TaskStatusCompleted, "3 PRs open", ""); err != nil {
+		t.Fatalf("complete task: %v", err)
+	}
+	runs, err := svc.ListScheduledJobRuns("standup", 10)
+	if err != nil {
+		t.Fatalf("list runs: %v", err)
+	}
+	if len(runs) != 2 || runs[0].TraceID != "sched-standup-1" || runs[0].Output != "3 PRs open" || runs[0].TaskStatus != TaskStatusCompleted || runs[1].Status != "missed" {
+		t.Fatalf("unexpected runs %+v", runs)
+	}
+	job, _ = svc.GetScheduledJob("standup")
+	if job.RunCount != 1 || job.LastTraceID != "sched-standup-1" || !job.NextRunAt.Equal(following) {
+		t.Fatalf("expected run bookkeeping, got %+v", job)
+	}
+
+	// Paused jobs are not due.
+	if err := svc.SetScheduledJobState("standup", true, job.NextRunAt); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if due, _ := svc.ListUserScheduledJobs(); len(due) != 0 {
+		t.Fatalf("expected no due jobs while paused, got %+v", due)
+	}
+	if err := svc.SetScheduledJobState("missing", true, nil); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected ErrNoRows for missing job, got %v", err)
+	}
+
+	if err := svc.DeleteScheduledJob("standup"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if runs, _ := svc.ListScheduledJobRuns("standup", 10); len(runs) != 0 {
+		t.Fatalf("expected runs to be deleted, got %+v", runs)
+	}
+	if _, err := svc.GetScheduledJob("standup"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected deleted job, got %v", err)
+	}
+}
@@ -28,6 +28,7 @@ type WebUser struct {
 	ID        int64     `json:"id"`
 	Name      string    `json:"name"`
 	ForceSend bool      `json:"force_send"`
+	Principal string    `json:"principal,omitempty"` // gateway principal that logs in as this user
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -39,6 +40,24 @@ type WebLink struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// Person is a canonical human identity that channel identities link to.
+type Person struct {
+	PersonID    string            `json:"person_id"`
+	DisplayName string            `json:"display_name"`
+	Preferences map[string]string `json:"preferences"`
+	Identities  []PersonIdentity  `json:"identities"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// PersonIdentity links a channel sender ID to a Person.
+type PersonIdentity struct {
+	Channel  string    `json:"channel"`
+	SenderID string    `json:"sender_id"`
+	PersonID string    `json:"person_id"`
+	LinkedAt time.Time `json:"linked_at"`
+}
+
 // AgentTask represents a tracked agent processing task.
 type AgentTask struct {
 	ID               int64      `json:"id"`
@@ -105,6 +124,7 @@ type TraceGraph struct {
 	Task            map[string]any   `json:"task"`
 	PolicyDecisions []map[string]any `json:"policy_decisions"`
 	Approvals       []map[string]any `json:"approvals,omitempty"`
+	Commits         []map[string]any `json:"commits,omitempty"`
 }
 
 // GroupTrace represents a trace span from a remote agent.
@@ -185,7 +205,7 @@ type AgentTraceStat struct {
 // UnifiedAuditEntry is a merged row from delegation_events, policy_decisions, and approval_requests.
 type UnifiedAuditEntry struct {
 	ID        int64     `json:"id"`
-	Source    string    `json:"source"`     // "delegation", "policy", "approval"
+	Source    string    `json:"source"`     // "delegation", "policy", "approval", "approval_event"
 	EventType string    `json:"event_type"` // submitted, accepted, allowed, denied, pending, approved, etc.
 	Tier      int       `json:"tier"`
 	AgentID   string    `json:"agent_id"`
@@ -237,7 +257,9 @@ type DelegationEventRecord struct {
 	CreatedAt  time.Time `json:"created_at"`
 }
 
-// ScheduledJobRecord represents persisted scheduler job state.
+// ScheduledJobRecord represents persisted scheduler job state. Jobs
+// registered in code only use the run bookkeeping; user jobs (Kind set)
+// also store their schedule and delivery target.
 type ScheduledJobRecord struct {
 	ID         int64     `json:"id"`
 	JobName    string    `json:"job_name"`
@@ -246,6 +268,38 @@ type ScheduledJobRecord struct {
 	RunCount   int       `json:"run_count"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
+
+	Kind          string     `json:"kind,omitempty"` // once, cron, interval
+	Schedule      string     `json:"schedule,omitempty"`
+	Timezone      string     `json:"timezone,omitempty"`
+	Content       string     `json:"content,omitempty"`
+	Channel       string     `json:"channel,omitempty"`
+	ChatID        string     `json:"chat_id,omitempty"`
+	ThreadID      string     `json:"thread_id,omitempty"`
+	SenderID      string     `json:"sender_id,omitempty"`
+	SessionKey    string     `json:"session_key,omitempty"`
+	MessageType   string     `json:"message_type,omitempty"`
+	MisfirePolicy string     `json:"misfire_policy,omitempty"`
+	Paused        bool       `json:"paused,omitempty"`
+	NextRunAt     *time.Time `json:"next_run_at,omitempty"`
+	LastTraceID   string     `json:"last_trace_id,omitempty"`
+	CreatedBy     string     `json:"created_by,omitempty"`
+
+	// TimeoutSeconds bounds a run; 0 uses the scheduler default.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// ScheduledJobRunRecord is one run of a user job. Output and TaskStatus come
+// from the agent task with the same trace ID.
+type ScheduledJobRunRecord struct {
+	ID           int64     `json:"id"`
+	JobName      string    `json:"job_name"`
+	TraceID      string    `json:"trace_id,omitempty"`
+	Status       string    `json:"status"` // dispatched, completed, failed, timeout, missed, skipped_concurrency
+	ScheduledFor time.Time `json:"scheduled_for"`
+	CreatedAt    time.Time `json:"created_at"`
+	TaskStatus   string    `json:"task_status,omitempty"`
+	Output       string    `json:"output,omitempty"`
 }
 
 // GroupMemoryItemRecord represents a shared memory item from group collaboration.
@@ -299,6 +353,23 @@ type KnowledgeFactRecord struct {
 	UpdatedAt  time.Time `json:"updated_at"`
 }
 
+// KnowledgeSyncPeerRecord is the last digest comparison with a peer claw.
+// Counts are from the local claw's view: Missing and Behind facts are newer
+// at the peer, Ahead and Extra facts are newer locally, Diverged facts have
+// the same version with different content.
+type KnowledgeSyncPeerRecord struct {
+	ClawID    string    `json:"claw_id"`
+	GroupName string    `json:"group_name"`
+	Root      string    `json:"root"`
+	FactCount int       `json:"fact_count"`
+	Missing   int       `json:"missing"`
+	Behind    int       `json:"behind"`
+	Ahead     int       `json:"ahead"`
+	Extra     int       `json:"extra"`
+	Diverged  int       `json:"diverged"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
 // KnowledgeProposalRecord is a persisted shared-knowledge proposal.
 type KnowledgeProposalRecord struct {
 	ProposalID         string    `json:"proposal_id"`
@@ -314,6 +385,17 @@ type KnowledgeProposalRecord struct {
 	Reason             string    `json:"reason"`
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
+
+	// Fact is the proposed subject/predicate/object (JSON), applied to
+	// knowledge_facts when the proposal is approved. Empty for free-text
+	// proposals.
+	Fact string `json:"fact,omitempty"`
+
+	// YesWeight and NoWeight are the weighted tally of the decision;
+	// Rationale explains how the voting policy reached it.
+	YesWeight float64 `json:"yes_weight"`
+	NoWeight  float64 `json:"no_weight"`
+	Rationale string  `json:"rationale,omitempty"`
 }
 
 // KnowledgeVoteRecord is a single claw vote for one proposal.
@@ -325,6 +407,12 @@ type KnowledgeVoteRecord struct {
 	Reason     string    `json:"reason"`
 	TraceID    string    `json:"trace_id"`
 	UpdatedAt  time.Time `json:"updated_at"`
+
+	// Role, ZoneID and Expertise are reported by the voter and weigh its
+	// vote under a weighted voting policy.
+	Role      string  `json:"role,omitempty"`
+	ZoneID    string  `json:"zone_id,omitempty"`
+	Expertise float64 `json:"expertise,omitempty"`
 }
 
 // CascadeTaskRecord represents a persisted step in a gated cascading workflow.
@@ -426,6 +514,21 @@ type PolicyDecisionRecord struct {
 	Channel   string    `json:"channel,omitempty"`
 	Allowed   bool      `json:"allowed"`
 	Reason    string    `json:"reason,omitempty"`
+	GrantID   string    `json:"grant_id,omitempty"` // approval grant that allowed the call
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RepoCommitRecord links a commit made by the agent to the trace that
+// produced it.
+type RepoCommitRecord struct {
+	ID        int64     `json:"id"`
+	TraceID   string    `json:"trace_id,omitempty"`
+	TaskID    string    `json:"task_id,omitempty"`
+	Repo      string    `json:"repo"`
+	SHA       string    `json:"sha"`
+	Branch    string    `json:"branch,omitempty"`
+	Message   string    `json:"message"`
+	Files     []string  `json:"files"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -441,10 +544,76 @@ type ApprovalRecord struct {
 	Sender      string     `json:"sender,omitempty"`
 	Channel     string     `json:"channel,omitempty"`
 	Status      string     `json:"status"`
+	Approvers   string     `json:"approvers,omitempty"`
+	Quorum      int        `json:"quorum"`
+	DecidedBy   string     `json:"decided_by,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	RespondedAt *time.Time `json:"responded_at,omitempty"`
 }
 
+// ApprovalEventRecord is one step in the audit trail of an approval:
+// created, vote, rejected, escalated, or the final decision.
+type ApprovalEventRecord struct {
+	ID         int64     `json:"id"`
+	ApprovalID string    `json:"approval_id"`
+	Actor      string    `json:"actor,omitempty"`
+	Action     string    `json:"action"`
+	Detail     string    `json:"detail,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ApprovalGrantRecord remembers an approval decision so matching tool calls
+// skip the prompt. Scope is "session" (same chat session until the agent
+// restarts), "ttl" (until ExpiresAt) or "always".
+type ApprovalGrantRecord struct {
+	ID         int64      `json:"id"`
+	GrantID    string     `json:"grant_id"`
+	ApprovalID string     `json:"approval_id,omitempty"`
+	Tool       string     `json:"tool"`
+	ArgPattern string     `json:"arg_pattern"`
+	MaxTier    int        `json:"max_tier"`
+	Scope      string     `json:"scope"`
+	SessionKey string     `json:"session_key,omitempty"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy  string     `json:"revoked_by,omitempty"`
+	UseCount   int        `json:"use_count"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// APITokenRecord is a gateway API token. Only the SHA-256 hash of the
+// token is stored; Prefix identifies it in listings.
+type APITokenRecord struct {
+	ID         int64      `json:"id"`
+	TokenID    string     `json:"token_id"`
+	Principal  string     `json:"principal"`
+	Name       string     `json:"name,omitempty"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     string     `json:"scopes,omitempty"` // comma-separated; empty means all of the principal's scopes
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// APIAuditRecord is one mutating gateway API call, allowed or denied.
+type APIAuditRecord struct {
+	ID         int64     `json:"id"`
+	Principal  string    `json:"principal,omitempty"`
+	AuthMethod string    `json:"auth_method,omitempty"` // token, session, legacy, none
+	TokenID    string    `json:"token_id,omitempty"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Scope      string    `json:"scope,omitempty"`
+	Status     int       `json:"status"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 const Schema = `
 CREATE TABLE IF NOT EXISTS timeline (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -489,6 +658,53 @@ CREATE TABLE IF NOT EXISTS web_links (
 
 CREATE INDEX IF NOT EXISTS idx_web_links_whatsapp ON web_links(whatsapp_jid);
 
+CREATE TABLE IF NOT EXISTS persons (
+	person_id TEXT PRIMARY KEY,
+	display_name TEXT NOT NULL DEFAULT '',
+	preferences TEXT NOT NULL DEFAULT '{}',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS person_identities (
+	channel TEXT NOT NULL,
+	sender_id TEXT NOT NULL,
+	person_id TEXT NOT NULL,
+	linked_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (channel, sender_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_person_identities_person ON person_identities(person_id);
+
+CREATE TABLE IF NOT EXISTS voice_transcripts (
+	audio_sha256 TEXT PRIMARY KEY,
+	transcript TEXT NOT NULL,
+	source TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS voice_preferences (
+	channel TEXT NOT NULL,
+	chat_id TEXT NOT NULL,
+	reply_mode TEXT NOT NULL,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (channel, chat_id)
+);
+
+CREATE TABLE IF NOT EXISTS repo_commits (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	trace_id TEXT,
+	task_id TEXT,
+	repo TEXT NOT NULL,
+	sha TEXT NOT NULL,
+	branch TEXT,
+	message TEXT,
+	files TEXT NOT NULL DEFAULT '[]',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_repo_commits_trace ON repo_commits(trace_id);
+
 CREATE TABLE IF NOT EXISTS tasks (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	task_id TEXT UNIQUE NOT NULL,
@@ -703,10 +919,36 @@ CREATE TABLE IF NOT EXISTS scheduled_jobs (
 	last_status TEXT DEFAULT '',
 	last_run_at DATETIME,
 	run_count INTEGER NOT NULL DEFAULT 0,
+	kind TEXT DEFAULT '',
+	schedule TEXT DEFAULT '',
+	timezone TEXT DEFAULT '',
+	content TEXT DEFAULT '',
+	channel TEXT DEFAULT '',
+	chat_id TEXT DEFAULT '',
+	thread_id TEXT DEFAULT '',
+	sender_id TEXT DEFAULT '',
+	session_key TEXT DEFAULT '',
+	message_type TEXT DEFAULT '',
+	misfire_policy TEXT DEFAULT '',
+	timeout_seconds INTEGER NOT NULL DEFAULT 0,
+	paused INTEGER NOT NULL DEFAULT 0,
+	next_run_at DATETIME,
+	last_trace_id TEXT DEFAULT '',
+	created_by TEXT DEFAULT '',
 	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE TABLE IF NOT EXISTS scheduled_job_runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	job_name TEXT NOT NULL,
+	trace_id TEXT DEFAULT '',
+	status TEXT NOT NULL,
+	scheduled_for DATETIME NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_scheduled_job_runs_job ON scheduled_job_runs(job_name, id);
+
 CREATE TABLE IF NOT EXISTS delegation_events (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	task_id TEXT NOT NULL,
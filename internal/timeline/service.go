@@ -263,6 +263,40 @@ func NewTimelineService(dbPath string) (*TimelineService, error) {
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	)`)
 	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_knowledge_facts_group ON knowledge_facts(group_name)`)
+	// Best-effort migration: accepted fact versions, replayed to peers that
+	// fell behind (anti-entropy sync). Seeded from the latest states.
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS knowledge_fact_versions (
+		fact_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		group_name TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		predicate TEXT NOT NULL,
+		object TEXT NOT NULL,
+		source TEXT NOT NULL,
+		proposal_id TEXT DEFAULT '',
+		decision_id TEXT DEFAULT '',
+		tags TEXT DEFAULT '[]',
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (fact_id, version)
+	)`)
+	_, _ = db.Exec(`INSERT OR IGNORE INTO knowledge_fact_versions
+		(fact_id, version, group_name, subject, predicate, object, source, proposal_id, decision_id, tags, applied_at)
+		SELECT fact_id, version, group_name, subject, predicate, object, source, proposal_id, decision_id, tags, updated_at
+		FROM knowledge_facts`)
+	// Best-effort migration: last digest comparison with each peer claw.
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS knowledge_sync_peers (
+		claw_id TEXT NOT NULL,
+		group_name TEXT NOT NULL,
+		root TEXT NOT NULL DEFAULT '',
+		fact_count INTEGER NOT NULL DEFAULT 0,
+		missing INTEGER NOT NULL DEFAULT 0,
+		behind INTEGER NOT NULL DEFAULT 0,
+		ahead INTEGER NOT NULL DEFAULT 0,
+		extra INTEGER NOT NULL DEFAULT 0,
+		diverged INTEGER NOT NULL DEFAULT 0,
+		checked_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (claw_id, group_name)
+	)`)
 	// Best-effort migration: knowledge proposals/votes tables.
 	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS knowledge_proposals (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -281,6 +315,8 @@ func NewTimelineService(dbPath string) (*TimelineService, error) {
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	)`)
 	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_knowledge_proposals_group ON knowledge_proposals(group_name, status)`)
+	// Best-effort migration: proposed fact (subject/predicate/object JSON) applied on approval.
+	_, _ = db.Exec(`ALTER TABLE knowledge_proposals ADD COLUMN fact TEXT DEFAULT ''`)
 	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS knowledge_votes (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		proposal_id TEXT NOT NULL,
@@ -293,6 +329,13 @@ func NewTimelineService(dbPath string) (*TimelineService, error) {
 		UNIQUE(proposal_id, claw_id)
 	)`)
 	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_knowledge_votes_proposal ON knowledge_votes(proposal_id)`)
+	// Best-effort migration: weighted voting (voter attributes, weighted tally and rationale).
+	_, _ = db.Exec(`ALTER TABLE knowledge_votes ADD COLUMN role TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE knowledge_votes ADD COLUMN zone_id TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE knowledge_votes ADD COLUMN expertise REAL DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE knowledge_proposals ADD COLUMN yes_weight REAL DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE knowledge_proposals ADD COLUMN no_weight REAL DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE knowledge_proposals ADD COLUMN rationale TEXT DEFAULT ''`)
 	// Best-effort migration: group skill channels table.
 	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS group_skill_channels (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -322,6 +365,70 @@ func NewTimelineService(dbPath string) (*TimelineService, error) {
 	)`)
 	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_approval_status ON approval_requests(status)`)
 	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_approval_id ON approval_requests(approval_id)`)
+	// Best-effort migration: approver routing and an audit trail of every
+	// approval step (votes, escalations, rejected responders).
+	_, _ = db.Exec(`ALTER TABLE approval_requests ADD COLUMN approvers TEXT`)
+	_, _ = db.Exec(`ALTER TABLE approval_requests ADD COLUMN quorum INTEGER DEFAULT 1`)
+	_, _ = db.Exec(`ALTER TABLE approval_requests ADD COLUMN decided_by TEXT`)
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS approval_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		approval_id TEXT NOT NULL,
+		actor TEXT,
+		action TEXT NOT NULL,
+		detail TEXT,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_approval_events_id ON approval_events(approval_id)`)
+	// Best-effort migration: remembered approval decisions.
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS approval_grants (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		grant_id TEXT UNIQUE NOT NULL,
+		approval_id TEXT,
+		tool TEXT NOT NULL,
+		arg_pattern TEXT NOT NULL,
+		max_tier INTEGER NOT NULL,
+		scope TEXT NOT NULL,
+		session_key TEXT,
+		created_by TEXT,
+		expires_at DATETIME,
+		revoked_at DATETIME,
+		revoked_by TEXT,
+		use_count INTEGER NOT NULL DEFAULT 0,
+		last_used_at DATETIME,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_approval_grants_tool ON approval_grants(tool)`)
+	_, _ = db.Exec(`ALTER TABLE policy_decisions ADD COLUMN grant_id TEXT`)
+	// Best-effort migration: gateway API tokens, principal links for web
+	// users and the API audit trail.
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS api_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		token_id TEXT UNIQUE NOT NULL,
+		principal TEXT NOT NULL,
+		name TEXT,
+		prefix TEXT NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		scopes TEXT,
+		expires_at DATETIME,
+		revoked_at DATETIME,
+		last_used_at DATETIME,
+		created_by TEXT,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`ALTER TABLE web_users ADD COLUMN principal TEXT`)
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS api_audit (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		principal TEXT,
+		auth_method TEXT,
+		token_id TEXT,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		scope TEXT,
+		status INTEGER NOT NULL,
+		remote_addr TEXT,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_api_audit_principal ON api_audit(principal)`)
 	// Best-effort migration: scheduled_jobs table.
 	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS scheduled_jobs (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -332,6 +439,26 @@ func NewTimelineService(dbPath string) (*TimelineService, error) {
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	)`)
+	// Best-effort migration: user job columns and run history.
+	for _, col := range []string{
+		"kind TEXT DEFAULT ''", "schedule TEXT DEFAULT ''", "timezone TEXT DEFAULT ''",
+		"content TEXT DEFAULT ''", "channel TEXT DEFAULT ''", "chat_id TEXT DEFAULT ''",
+		"thread_id TEXT DEFAULT ''", "sender_id TEXT DEFAULT ''", "session_key TEXT DEFAULT ''",
+		"message_type TEXT DEFAULT ''", "misfire_policy TEXT DEFAULT ''", "paused INTEGER NOT NULL DEFAULT 0",
+		"next_run_at DATETIME", "last_trace_id TEXT DEFAULT ''", "created_by TEXT DEFAULT ''",
+		"timeout_seconds INTEGER NOT NULL DEFAULT 0",
+	} {
+		_, _ = db.Exec(`ALTER TABLE scheduled_jobs ADD COLUMN ` + col)
+	}
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS scheduled_job_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_name TEXT NOT NULL,
+		trace_id TEXT DEFAULT '',
+		status TEXT NOT NULL,
+		scheduled_for DATETIME NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_scheduled_job_runs_job ON scheduled_job_runs(job_name, id)`)
 	// Best-effort migration: delegation_events table.
 	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS delegation_events (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -599,9 +726,115 @@ func (s *TimelineService) UpsertKnowledgeFactLatest(rec *KnowledgeFactRecord) er
 	if err != nil {
 		return fmt.Errorf("upsert knowledge fact latest: %w", err)
 	}
+	_, err = s.db.Exec(`INSERT OR IGNORE INTO knowledge_fact_versions
+		(fact_id, version, group_name, subject, predicate, object, source, proposal_id, decision_id, tags)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.FactID, rec.Version, rec.GroupName, rec.Subject, rec.Predicate, rec.Object,
+		rec.Source, rec.ProposalID, rec.DecisionID, rec.Tags,
+	)
+	if err != nil {
+		return fmt.Errorf("record knowledge fact version: %w", err)
+	}
+	return nil
+}
+
+// ListKnowledgeFactVersions returns the recorded versions of a fact newer
+// than afterVersion, oldest first.
+func (s *TimelineService) ListKnowledgeFactVersions(factID string, afterVersion int) ([]KnowledgeFactRecord, error) {
+	rows, err := s.db.Query(`SELECT fact_id, group_name, subject, predicate, object, version, source,
+		COALESCE(proposal_id,''), COALESCE(decision_id,''), COALESCE(tags,'[]'), applied_at
+		FROM knowledge_fact_versions WHERE fact_id = ? AND version > ? ORDER BY version`, factID, afterVersion)
+	if err != nil {
+		return nil, fmt.Errorf("list knowledge fact versions: %w", err)
+	}
+	defer rows.Close()
+	var out []KnowledgeFactRecord
+	for rows.Next() {
+		var rec KnowledgeFactRecord
+		if err := rows.Scan(
+			&rec.FactID,
+			&rec.GroupName,
+			&rec.Subject,
+			&rec.Predicate,
+			&rec.Object,
+			&rec.Version,
+			&rec.Source,
+			&rec.ProposalID,
+			&rec.DecisionID,
+			&rec.Tags,
+			&rec.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+// UpsertKnowledgeSyncPeer stores the latest digest comparison with a peer.
+func (s *TimelineService) UpsertKnowledgeSyncPeer(rec *KnowledgeSyncPeerRecord) error {
+	if rec == nil {
+		return fmt.Errorf("knowledge sync peer record is nil")
+	}
+	_, err := s.db.Exec(`INSERT INTO knowledge_sync_peers
+		(claw_id, group_name, root, fact_count, missing, behind, ahead, extra, diverged, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
+		ON CONFLICT(claw_id, group_name) DO UPDATE SET
+			root = excluded.root,
+			fact_count = excluded.fact_count,
+			missing = excluded.missing,
+			behind = excluded.behind,
+			ahead = excluded.ahead,
+			extra = excluded.extra,
+			diverged = excluded.diverged,
+			checked_at = datetime('now')`,
+		rec.ClawID, rec.GroupName, rec.Root, rec.FactCount,
+		rec.Missing, rec.Behind, rec.Ahead, rec.Extra, rec.Diverged,
+	)
+	if err != nil {
+		return fmt.Errorf("upsert knowledge sync peer: %w", err)
+	}
 	return nil
 }
 
+// ListKnowledgeSyncPeers returns the digest comparisons with peers,
+// optionally filtered by group.
+func (s *TimelineService) ListKnowledgeSyncPeers(groupName string) ([]KnowledgeSyncPeerRecord, error) {
+	query := `SELECT claw_id, group_name, root, fact_count, missing, behind, ahead, extra, diverged, checked_at
+		FROM knowledge_sync_peers`
+	args := []interface{}{}
+	if strings.TrimSpace(groupName) != "" {
+		query += ` WHERE group_name = ?`
+		args = append(args, strings.TrimSpace(groupName))
+	}
+	query += ` ORDER BY group_name, claw_id`
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list knowledge sync peers: %w", err)
+	}
+	defer rows.Close()
+	var out []KnowledgeSyncPeerRecord
+	for rows.Next() {
+		var rec KnowledgeSyncPeerRecord
+		if err := rows.Scan(
+			&rec.ClawID,
+			&rec.GroupName,
+			&rec.Root,
+			&rec.FactCount,
+			&rec.Missing,
+			&rec.Behind,
+			&rec.Ahead,
+			&rec.Extra,
+			&rec.Diverged,
+			&rec.CheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
 // ListKnowledgeFacts returns latest accepted facts, optionally filtered by group.
 func (s *TimelineService) ListKnowledgeFacts(groupName string, limit, offset int) ([]KnowledgeFactRecord, error) {
 	if limit <= 0 {
@@ -660,6 +893,76 @@ func (s *TimelineService) CountKnowledgeFacts(groupName string) (int, error) {
 	return count, nil
 }
 
+// KnowledgeFactQuery filters SearchKnowledgeFacts. Subject and Predicate
+// match case-insensitively; Text matches any part of subject, predicate or
+// object.
+type KnowledgeFactQuery struct {
+	Group     string
+	Subject   string
+	Predicate string
+	Text      string
+	Limit     int
+}
+
+// SearchKnowledgeFacts returns the latest accepted facts matching q, most
+// recently updated first.
+func (s *TimelineService) SearchKnowledgeFacts(q KnowledgeFactQuery) ([]KnowledgeFactRecord, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	query := `SELECT fact_id, group_name, subject, predicate, object, version, source,
+		COALESCE(proposal_id,''), COALESCE(decision_id,''), COALESCE(tags,'[]'), updated_at
+		FROM knowledge_facts WHERE 1=1`
+	args := []interface{}{}
+	if v := strings.TrimSpace(q.Group); v != "" {
+		query += ` AND group_name = ?`
+		args = append(args, v)
+	}
+	if v := strings.TrimSpace(q.Subject); v != "" {
+		query += ` AND lower(subject) = lower(?)`
+		args = append(args, v)
+	}
+	if v := strings.TrimSpace(q.Predicate); v != "" {
+		query += ` AND lower(predicate) = lower(?)`
+		args = append(args, v)
+	}
+	if v := strings.TrimSpace(q.Text); v != "" {
+		like := "%" + strings.ToLower(v) + "%"
+		query += ` AND (lower(subject) LIKE ? OR lower(predicate) LIKE ? OR lower(object) LIKE ?)`
+		args = append(args, like, like, like)
+	}
+	query += ` ORDER BY updated_at DESC, fact_id LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search knowledge facts: %w", err)
+	}
+	defer rows.Close()
+	out := make([]KnowledgeFactRecord, 0)
+	for rows.Next() {
+		var rec KnowledgeFactRecord
+		if err := rows.Scan(
+			&rec.FactID,
+			&rec.GroupName,
+			&rec.Subject,
+			&rec.Predicate,
+			&rec.Object,
+			&rec.Version,
+			&rec.Source,
+			&rec.ProposalID,
+			&rec.DecisionID,
+			&rec.Tags,
+			&rec.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
 func (s *TimelineService) CreateKnowledgeProposal(rec *KnowledgeProposalRecord) error {
 	if rec == nil {
 		return fmt.Errorf("proposal is nil")
@@ -668,10 +971,10 @@ func (s *TimelineService) CreateKnowledgeProposal(rec *KnowledgeProposalRecord)
 		rec.Status = "pending"
 	}
 	_, err := s.db.Exec(`INSERT INTO knowledge_proposals
-		(proposal_id, group_name, title, statement, tags, proposer_claw_id, proposer_instance_id, status, yes_votes, no_votes, reason, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
+		(proposal_id, group_name, title, statement, tags, proposer_claw_id, proposer_instance_id, status, yes_votes, no_votes, reason, fact, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
 		rec.ProposalID, rec.GroupName, rec.Title, rec.Statement, rec.Tags,
-		rec.ProposerClawID, rec.ProposerInstanceID, rec.Status, rec.YesVotes, rec.NoVotes, rec.Reason,
+		rec.ProposerClawID, rec.ProposerInstanceID, rec.Status, rec.YesVotes, rec.NoVotes, rec.Reason, rec.Fact,
 	)
 	if err != nil {
 		return fmt.Errorf("create knowledge proposal: %w", err)
@@ -681,7 +984,8 @@ func (s *TimelineService) CreateKnowledgeProposal(rec *KnowledgeProposalRecord)
 
 func (s *TimelineService) GetKnowledgeProposal(proposalID string) (*KnowledgeProposalRecord, error) {
 	row := s.db.QueryRow(`SELECT proposal_id, group_name, COALESCE(title,''), statement, COALESCE(tags,'[]'),
-		proposer_claw_id, proposer_instance_id, status, yes_votes, no_votes, COALESCE(reason,''), created_at, updated_at
+		proposer_claw_id, proposer_instance_id, status, yes_votes, no_votes, COALESCE(reason,''), COALESCE(fact,''),
+		COALESCE(yes_weight,0), COALESCE(no_weight,0), COALESCE(rationale,''), created_at, updated_at
 		FROM knowledge_proposals WHERE proposal_id = ?`, proposalID)
 	var rec KnowledgeProposalRecord
 	err := row.Scan(
@@ -696,6 +1000,10 @@ func (s *TimelineService) GetKnowledgeProposal(proposalID string) (*KnowledgePro
 		&rec.YesVotes,
 		&rec.NoVotes,
 		&rec.Reason,
+		&rec.Fact,
+		&rec.YesWeight,
+		&rec.NoWeight,
+		&rec.Rationale,
 		&rec.CreatedAt,
 		&rec.UpdatedAt,
 	)
@@ -713,7 +1021,8 @@ func (s *TimelineService) ListKnowledgeProposals(status string, limit, offset in
 		limit = 50
 	}
 	query := `SELECT proposal_id, group_name, COALESCE(title,''), statement, COALESCE(tags,'[]'),
-		proposer_claw_id, proposer_instance_id, status, yes_votes, no_votes, COALESCE(reason,''), created_at, updated_at
+		proposer_claw_id, proposer_instance_id, status, yes_votes, no_votes, COALESCE(reason,''), COALESCE(fact,''),
+		COALESCE(yes_weight,0), COALESCE(no_weight,0), COALESCE(rationale,''), created_at, updated_at
 		FROM knowledge_proposals WHERE 1=1`
 	args := []interface{}{}
 	if strings.TrimSpace(status) != "" {
@@ -742,6 +1051,10 @@ func (s *TimelineService) ListKnowledgeProposals(status string, limit, offset in
 			&rec.YesVotes,
 			&rec.NoVotes,
 			&rec.Reason,
+			&rec.Fact,
+			&rec.YesWeight,
+			&rec.NoWeight,
+			&rec.Rationale,
 			&rec.CreatedAt,
 			&rec.UpdatedAt,
 		); err != nil {
@@ -757,15 +1070,18 @@ func (s *TimelineService) UpsertKnowledgeVote(rec *KnowledgeVoteRecord) error {
 		return fmt.Errorf("vote is nil")
 	}
 	_, err := s.db.Exec(`INSERT INTO knowledge_votes
-		(proposal_id, claw_id, instance_id, vote, reason, trace_id, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, datetime('now'))
+		(proposal_id, claw_id, instance_id, vote, reason, trace_id, role, zone_id, expertise, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
 		ON CONFLICT(proposal_id, claw_id) DO UPDATE SET
 			instance_id = excluded.instance_id,
 			vote = excluded.vote,
 			reason = excluded.reason,
 			trace_id = excluded.trace_id,
+			role = excluded.role,
+			zone_id = excluded.zone_id,
+			expertise = excluded.expertise,
 			updated_at = datetime('now')`,
-		rec.ProposalID, rec.ClawID, rec.InstanceID, rec.Vote, rec.Reason, rec.TraceID,
+		rec.ProposalID, rec.ClawID, rec.InstanceID, rec.Vote, rec.Reason, rec.TraceID, rec.Role, rec.ZoneID, rec.Expertise,
 	)
 	if err != nil {
 		return fmt.Errorf("upsert knowledge vote: %w", err)
@@ -774,7 +1090,8 @@ func (s *TimelineService) UpsertKnowledgeVote(rec *KnowledgeVoteRecord) error {
 }
 
 func (s *TimelineService) ListKnowledgeVotes(proposalID string) ([]KnowledgeVoteRecord, error) {
-	rows, err := s.db.Query(`SELECT proposal_id, claw_id, instance_id, vote, COALESCE(reason,''), COALESCE(trace_id,''), updated_at
+	rows, err := s.db.Query(`SELECT proposal_id, claw_id, instance_id, vote, COALESCE(reason,''), COALESCE(trace_id,''),
+		COALESCE(role,''), COALESCE(zone_id,''), COALESCE(expertise,0), updated_at
 		FROM knowledge_votes WHERE proposal_id = ? ORDER BY updated_at ASC`, proposalID)
 	if err != nil {
 		return nil, fmt.Errorf("list knowledge votes: %w", err)
@@ -790,6 +1107,9 @@ func (s *TimelineService) ListKnowledgeVotes(proposalID string) ([]KnowledgeVote
 			&rec.Vote,
 			&rec.Reason,
 			&rec.TraceID,
+			&rec.Role,
+			&rec.ZoneID,
+			&rec.Expertise,
 			&rec.UpdatedAt,
 		); err != nil {
 			return nil, err
@@ -811,6 +1131,20 @@ func (s *TimelineService) UpdateKnowledgeProposalDecision(proposalID, status str
 	return nil
 }
 
+// UpdateKnowledgeProposalTally stores the weighted tally of a proposal and
+// the rationale of its decision.
+func (s *TimelineService) UpdateKnowledgeProposalTally(proposalID string, yesWeight, noWeight float64, rationale string) error {
+	_, err := s.db.Exec(`UPDATE knowledge_proposals
+		SET yes_weight = ?, no_weight = ?, rationale = ?, updated_at = datetime('now')
+		WHERE proposal_id = ?`,
+		yesWeight, noWeight, rationale, proposalID,
+	)
+	if err != nil {
+		return fmt.Errorf("update knowledge proposal tally: %w", err)
+	}
+	return nil
+}
+
 func (s *TimelineService) CreateCascadeTask(rec *CascadeTaskRecord) error {
 	if rec == nil {
 		return fmt.Errorf("cascade task is nil")
@@ -1150,7 +1484,7 @@ func (s *TimelineService) CreateWebUser(name string) (*WebUser, error) {
 
 // ListWebUsers returns all web users sorted by name.
 func (s *TimelineService) ListWebUsers() ([]WebUser, error) {
-	rows, err := s.db.Query(`SELECT id, name, COALESCE(force_send, 1), created_at FROM web_users ORDER BY name ASC`)
+	rows, err := s.db.Query(`SELECT id, name, COALESCE(force_send, 1), COALESCE(principal,''), created_at FROM web_users ORDER BY name ASC`)
 	if err != nil {
 		return nil, err
 	}
@@ -1159,7 +1493,7 @@ func (s *TimelineService) ListWebUsers() ([]WebUser, error) {
 	var users []WebUser
 	for rows.Next() {
 		var u WebUser
-		if err := rows.Scan(&u.ID, &u.Name, &u.ForceSend, &u.CreatedAt); err != nil {
+		if err := rows.Scan(&u.ID, &u.Name, &u.ForceSend, &u.Principal, &u.CreatedAt); err != nil {
 			return nil, err
 		}
 		users = append(users, u)
@@ -1170,8 +1504,8 @@ func (s *TimelineService) ListWebUsers() ([]WebUser, error) {
 // GetWebUser returns a web user by ID.
 func (s *TimelineService) GetWebUser(id int64) (*WebUser, error) {
 	var u WebUser
-	err := s.db.QueryRow(`SELECT id, name, COALESCE(force_send, 1), created_at FROM web_users WHERE id = ?`, id).
-		Scan(&u.ID, &u.Name, &u.ForceSend, &u.CreatedAt)
+	err := s.db.QueryRow(`SELECT id, name, COALESCE(force_send, 1), COALESCE(principal,''), created_at FROM web_users WHERE id = ?`, id).
+		Scan(&u.ID, &u.Name, &u.ForceSend, &u.Principal, &u.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -1181,14 +1515,39 @@ func (s *TimelineService) GetWebUser(id int64) (*WebUser, error) {
 // GetWebUserByName returns a web user by name.
 func (s *TimelineService) GetWebUserByName(name string) (*WebUser, error) {
 	var u WebUser
-	err := s.db.QueryRow(`SELECT id, name, COALESCE(force_send, 1), created_at FROM web_users WHERE name = ?`, name).
-		Scan(&u.ID, &u.Name, &u.ForceSend, &u.CreatedAt)
+	err := s.db.QueryRow(`SELECT id, name, COALESCE(force_send, 1), COALESCE(principal,''), created_at FROM web_users WHERE name = ?`, name).
+		Scan(&u.ID, &u.Name, &u.ForceSend, &u.Principal, &u.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetWebUserByPrincipal returns the web user linked to a gateway principal.
+func (s *TimelineService) GetWebUserByPrincipal(principal string) (*WebUser, error) {
+	var u WebUser
+	err := s.db.QueryRow(`SELECT id, name, COALESCE(force_send, 1), COALESCE(principal,''), created_at FROM web_users
+		WHERE principal = ? ORDER BY id ASC LIMIT 1`, principal).
+		Scan(&u.ID, &u.Name, &u.ForceSend, &u.Principal, &u.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
 
+// SetWebUserPrincipal links a web user to a gateway principal; an empty
+// principal removes the link.
+func (s *TimelineService) SetWebUserPrincipal(webUserID int64, principal string) error {
+	res, err := s.db.Exec(`UPDATE web_users SET principal = ? WHERE id = ?`, principal, webUserID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // LinkWebUser links a web user to a WhatsApp JID.
 func (s *TimelineService) LinkWebUser(webUserID int64, whatsappJID string) error {
 	if whatsappJID == "" {
@@ -1611,16 +1970,16 @@ func (s *TimelineService) GetTokenUsageSummary(days int) ([]ProviderDayStat, err
 
 // LogPolicyDecision records a policy evaluation result.
 func (s *TimelineService) LogPolicyDecision(rec *PolicyDecisionRecord) error {
-	_, err := s.db.Exec(`INSERT INTO policy_decisions (trace_id, task_id, tool, tier, sender, channel, allowed, reason)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		rec.TraceID, rec.TaskID, rec.Tool, rec.Tier, rec.Sender, rec.Channel, rec.Allowed, rec.Reason)
+	_, err := s.db.Exec(`INSERT INTO policy_decisions (trace_id, task_id, tool, tier, sender, channel, allowed, reason, grant_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.TraceID, rec.TaskID, rec.Tool, rec.Tier, rec.Sender, rec.Channel, rec.Allowed, rec.Reason, rec.GrantID)
 	return err
 }
 
 // ListPolicyDecisions returns policy decisions matching the given trace_id.
 func (s *TimelineService) ListPolicyDecisions(traceID string) ([]PolicyDecisionRecord, error) {
 	rows, err := s.db.Query(`SELECT id, COALESCE(trace_id,''), COALESCE(task_id,''), tool, tier,
-		COALESCE(sender,''), COALESCE(channel,''), allowed, COALESCE(reason,''), created_at
+		COALESCE(sender,''), COALESCE(channel,''), allowed, COALESCE(reason,''), COALESCE(grant_id,''), created_at
 		FROM policy_decisions WHERE trace_id = ? ORDER BY created_at ASC`, traceID)
 	if err != nil {
 		return nil, err
@@ -1630,7 +1989,7 @@ func (s *TimelineService) ListPolicyDecisions(traceID string) ([]PolicyDecisionR
 	for rows.Next() {
 		var r PolicyDecisionRecord
 		if err := rows.Scan(&r.ID, &r.TraceID, &r.TaskID, &r.Tool, &r.Tier,
-			&r.Sender, &r.Channel, &r.Allowed, &r.Reason, &r.CreatedAt); err != nil {
+			&r.Sender, &r.Channel, &r.Allowed, &r.Reason, &r.GrantID, &r.CreatedAt); err != nil {
 			return nil, err
 		}
 		out = append(out, r)
@@ -1803,6 +2162,32 @@ func (s *TimelineService) GetTraceGraph(traceID string) (*TraceGraph, error) {
 		}
 	}
 
+	// Commits made by the agent
+	var commitData []map[string]any
+	if commits, err := s.ListRepoCommitsByTrace(traceID); err == nil {
+		for _, c := range commits {
+			commitData = append(commitData, map[string]any{
+				"sha":     c.SHA,
+				"branch":  c.Branch,
+				"message": c.Message,
+				"files":   c.Files,
+				"repo":    c.Repo,
+				"time":    c.CreatedAt.Format("15:04:05"),
+			})
+			short := c.SHA
+			if len(short) > 12 {
+				short = short[:12]
+			}
+			nodes = append(nodes, TraceNode{
+				ID:        fmt.Sprintf("commit-%d", c.ID),
+				Type:      "COMMIT",
+				Title:     fmt.Sprintf("Commit %s: %s", short, c.Message),
+				StartTime: c.CreatedAt.Format("15:04:05"),
+				AgentID:   "local",
+			})
+		}
+	}
+
 	// Delegation events (if the task has a trace-correlated task_id)
 	if taskInfo != nil {
 		if tid, ok := taskInfo["task_id"].(string); ok && tid != "" {
@@ -1828,6 +2213,7 @@ func (s *TimelineService) GetTraceGraph(traceID string) (*TraceGraph, error) {
 		Task:            taskInfo,
 		PolicyDecisions: policyDecisions,
 		Approvals:       approvalData,
+		Commits:         commitData,
 	}, nil
 }
 
@@ -2125,7 +2511,7 @@ func (s *TimelineService) GetGroupStats() (*GroupStats, error) {
 	return stats, nil
 }
 
-// ListUnifiedAudit returns a unified audit log from delegation_events, policy_decisions, and approval_requests.
+// ListUnifiedAudit returns a unified audit log from delegation_events, policy_decisions, approval_requests and api_audit.
 func (s *TimelineService) ListUnifiedAudit(filter AuditFilter) ([]UnifiedAuditEntry, error) {
 	if filter.Limit <= 0 {
 		filter.Limit = 50
@@ -2158,6 +2544,22 @@ func (s *TimelineService) ListUnifiedAudit(filter AuditFilter) ([]UnifiedAuditEn
 
 	query += ` UNION ALL `
 
+	// approval_events
+	query += `SELECT id, 'approval_event' as source, action as event_type,
+		0 as tier, COALESCE(actor,'') as agent_id, approval_id as target_id,
+		COALESCE(detail,'') as details, created_at
+		FROM approval_events`
+
+	query += ` UNION ALL `
+
+	// api_audit
+	query += `SELECT id, 'api' as source, method as event_type,
+		0 as tier, COALESCE(principal,'') as agent_id, path as target_id,
+		'status=' || status || ' scope=' || COALESCE(scope,'') || ' auth=' || COALESCE(auth_method,'') as details, created_at
+		FROM api_audit`
+
+	query += ` UNION ALL `
+
 	// mode_change events from timeline
 	query += `SELECT id, 'mode_change' as source, classification as event_type,
 		0 as tier, sender_id as agent_id, '' as target_id,
@@ -2474,11 +2876,254 @@ func (s *TimelineService) UpdateApprovalStatus(approvalID, status string) error
 	return err
 }
 
+// UpdateApprovalDecision records the final status and who decided it
+// (comma-separated when a quorum approved).
+func (s *TimelineService) UpdateApprovalDecision(approvalID, status, decidedBy string) error {
+	_, err := s.db.Exec(`UPDATE approval_requests SET status = ?, decided_by = ?, responded_at = datetime('now') WHERE approval_id = ?`,
+		status, decidedBy, approvalID)
+	return err
+}
+
+// SetApprovalRouting stores the eligible approvers (comma-separated) and the
+// number of approvals required.
+func (s *TimelineService) SetApprovalRouting(approvalID, approvers string, quorum int) error {
+	_, err := s.db.Exec(`UPDATE approval_requests SET approvers = ?, quorum = ? WHERE approval_id = ?`,
+		approvers, quorum, approvalID)
+	return err
+}
+
+// InsertApprovalEvent appends one step to the audit trail of an approval.
+func (s *TimelineService) InsertApprovalEvent(approvalID, actor, action, detail string) error {
+	_, err := s.db.Exec(`INSERT INTO approval_events (approval_id, actor, action, detail) VALUES (?, ?, ?, ?)`,
+		approvalID, actor, action, detail)
+	return err
+}
+
+// ListApprovalEvents returns the audit trail of an approval, oldest first.
+func (s *TimelineService) ListApprovalEvents(approvalID string) ([]ApprovalEventRecord, error) {
+	rows, err := s.db.Query(`SELECT id, approval_id, COALESCE(actor,''), action, COALESCE(detail,''), created_at
+		FROM approval_events WHERE approval_id = ? ORDER BY id ASC`, approvalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ApprovalEventRecord
+	for rows.Next() {
+		var r ApprovalEventRecord
+		if err := rows.Scan(&r.ID, &r.ApprovalID, &r.Actor, &r.Action, &r.Detail, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// --- Approval Grants ---
+
+const approvalGrantColumns = `id, grant_id, COALESCE(approval_id,''), tool, arg_pattern, max_tier, scope,
+		COALESCE(session_key,''), COALESCE(created_by,''), expires_at, revoked_at, COALESCE(revoked_by,''),
+		use_count, last_used_at, created_at`
+
+func scanApprovalGrant(row rowScanner) (*ApprovalGrantRecord, error) {
+	var r ApprovalGrantRecord
+	var expiresAt, revokedAt, lastUsedAt sql.NullTime
+	if err := row.Scan(&r.ID, &r.GrantID, &r.ApprovalID, &r.Tool, &r.ArgPattern, &r.MaxTier, &r.Scope,
+		&r.SessionKey, &r.CreatedBy, &expiresAt, &revokedAt, &r.RevokedBy,
+		&r.UseCount, &lastUsedAt, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	for _, nt := range []struct {
+		src sql.NullTime
+		dst **time.Time
+	}{{expiresAt, &r.ExpiresAt}, {revokedAt, &r.RevokedAt}, {lastUsedAt, &r.LastUsedAt}} {
+		if nt.src.Valid {
+			t := nt.src.Time
+			*nt.dst = &t
+		}
+	}
+	return &r, nil
+}
+
+// InsertApprovalGrant stores a new approval grant.
+func (s *TimelineService) InsertApprovalGrant(r *ApprovalGrantRecord) error {
+	_, err := s.db.Exec(`INSERT INTO approval_grants
+		(grant_id, approval_id, tool, arg_pattern, max_tier, scope, session_key, created_by, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.GrantID, r.ApprovalID, r.Tool, r.ArgPattern, r.MaxTier, r.Scope, r.SessionKey, r.CreatedBy, nullableTime(r.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("insert approval grant %s: %w", r.GrantID, err)
+	}
+	return nil
+}
+
+// GetApprovalGrant returns a grant by ID.
+func (s *TimelineService) GetApprovalGrant(grantID string) (*ApprovalGrantRecord, error) {
+	return scanApprovalGrant(s.db.QueryRow(`SELECT `+approvalGrantColumns+`
+		FROM approval_grants WHERE grant_id = ?`, grantID))
+}
+
+// ListApprovalGrants returns grants, newest first. Unless all is set, only
+// grants that are neither revoked nor expired at now are returned.
+func (s *TimelineService) ListApprovalGrants(all bool, now time.Time) ([]ApprovalGrantRecord, error) {
+	query := `SELECT ` + approvalGrantColumns + ` FROM approval_grants`
+	var args []any
+	if !all {
+		query += ` WHERE revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)`
+		args = append(args, now.UTC())
+	}
+	return s.queryApprovalGrants(query+` ORDER BY id DESC`, args...)
+}
+
+// ListActiveApprovalGrants returns the grants for tool that are neither
+// revoked nor expired at now.
+func (s *TimelineService) ListActiveApprovalGrants(tool string, now time.Time) ([]ApprovalGrantRecord, error) {
+	return s.queryApprovalGrants(`SELECT `+approvalGrantColumns+` FROM approval_grants
+		WHERE tool = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)
+		ORDER BY id ASC`, tool, now.UTC())
+}
+
+func (s *TimelineService) queryApprovalGrants(query string, args ...any) ([]ApprovalGrantRecord, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ApprovalGrantRecord
+	for rows.Next() {
+		r, err := scanApprovalGrant(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+// RevokeApprovalGrant revokes an active grant. It returns sql.ErrNoRows when
+// the grant does not exist or was already revoked.
+func (s *TimelineService) RevokeApprovalGrant(grantID, revokedBy string) error {
+	res, err := s.db.Exec(`UPDATE approval_grants SET revoked_at = datetime('now'), revoked_by = ?
+		WHERE grant_id = ? AND revoked_at IS NULL`, revokedBy, grantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RecordApprovalGrantUse counts one tool call allowed by the grant.
+func (s *TimelineService) RecordApprovalGrantUse(grantID string) error {
+	_, err := s.db.Exec(`UPDATE approval_grants SET use_count = use_count + 1, last_used_at = datetime('now')
+		WHERE grant_id = ?`, grantID)
+	return err
+}
+
+// --- Gateway API Tokens ---
+
+const apiTokenColumns = `id, token_id, principal, COALESCE(name,''), prefix, token_hash, COALESCE(scopes,''),
+		expires_at, revoked_at, last_used_at, COALESCE(created_by,''), created_at`
+
+func scanAPIToken(row rowScanner) (*APITokenRecord, error) {
+	var r APITokenRecord
+	var expiresAt, revokedAt, lastUsedAt sql.NullTime
+	if err := row.Scan(&r.ID, &r.TokenID, &r.Principal, &r.Name, &r.Prefix, &r.Hash, &r.Scopes,
+		&expiresAt, &revokedAt, &lastUsedAt, &r.CreatedBy, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	for _, nt := range []struct {
+		src sql.NullTime
+		dst **time.Time
+	}{{expiresAt, &r.ExpiresAt}, {revokedAt, &r.RevokedAt}, {lastUsedAt, &r.LastUsedAt}} {
+		if nt.src.Valid {
+			t := nt.src.Time
+			*nt.dst = &t
+		}
+	}
+	return &r, nil
+}
+
+// InsertAPIToken stores a new API token.
+func (s *TimelineService) InsertAPIToken(r *APITokenRecord) error {
+	_, err := s.db.Exec(`INSERT INTO api_tokens
+		(token_id, principal, name, prefix, token_hash, scopes, expires_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.TokenID, r.Principal, r.Name, r.Prefix, r.Hash, r.Scopes, nullableTime(r.ExpiresAt), r.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("insert api token %s: %w", r.TokenID, err)
+	}
+	return nil
+}
+
+// GetActiveAPITokenByHash returns the token with the given hash if it is
+// neither revoked nor expired at now.
+func (s *TimelineService) GetActiveAPITokenByHash(hash string, now time.Time) (*APITokenRecord, error) {
+	return scanAPIToken(s.db.QueryRow(`SELECT `+apiTokenColumns+` FROM api_tokens
+		WHERE token_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)`, hash, now.UTC()))
+}
+
+// ListAPITokens returns API tokens, newest first. Unless all is set, only
+// tokens that are neither revoked nor expired at now are returned.
+func (s *TimelineService) ListAPITokens(all bool, now time.Time) ([]APITokenRecord, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens`
+	var args []any
+	if !all {
+		query += ` WHERE revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)`
+		args = append(args, now.UTC())
+	}
+	rows, err := s.db.Query(query+` ORDER BY id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []APITokenRecord
+	for rows.Next() {
+		r, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+// RevokeAPIToken revokes an active token. It returns sql.ErrNoRows when the
+// token does not exist or was already revoked.
+func (s *TimelineService) RevokeAPIToken(tokenID string) error {
+	res, err := s.db.Exec(`UPDATE api_tokens SET revoked_at = datetime('now')
+		WHERE token_id = ? AND revoked_at IS NULL`, tokenID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// TouchAPIToken records that a token was used.
+func (s *TimelineService) TouchAPIToken(tokenID string) error {
+	_, err := s.db.Exec(`UPDATE api_tokens SET last_used_at = datetime('now') WHERE token_id = ?`, tokenID)
+	return err
+}
+
+// InsertAPIAudit records one mutating gateway API call.
+func (s *TimelineService) InsertAPIAudit(r *APIAuditRecord) error {
+	_, err := s.db.Exec(`INSERT INTO api_audit (principal, auth_method, token_id, method, path, scope, status, remote_addr)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Principal, r.AuthMethod, r.TokenID, r.Method, r.Path, r.Scope, r.Status, r.RemoteAddr)
+	return err
+}
+
 // GetPendingApprovals returns all approval requests with status 'pending'.
 func (s *TimelineService) GetPendingApprovals() ([]ApprovalRecord, error) {
 	rows, err := s.db.Query(`SELECT id, approval_id, COALESCE(trace_id,''), COALESCE(task_id,''),
 		tool, tier, COALESCE(arguments,''), COALESCE(sender,''), COALESCE(channel,''),
-		status, created_at, responded_at
+		status, COALESCE(approvers,''), COALESCE(quorum,1), COALESCE(decided_by,''), created_at, responded_at
 		FROM approval_requests WHERE status = 'pending' ORDER BY created_at ASC`)
 	if err != nil {
 		return nil, err
@@ -2491,7 +3136,7 @@ func (s *TimelineService) GetPendingApprovals() ([]ApprovalRecord, error) {
 		var respondedAt sql.NullTime
 		if err := rows.Scan(&r.ID, &r.ApprovalID, &r.TraceID, &r.TaskID,
 			&r.Tool, &r.Tier, &r.Arguments, &r.Sender, &r.Channel,
-			&r.Status, &r.CreatedAt, &respondedAt); err != nil {
+			&r.Status, &r.Approvers, &r.Quorum, &r.DecidedBy, &r.CreatedAt, &respondedAt); err != nil {
 			return nil, err
 		}
 		if respondedAt.Valid {
@@ -2506,7 +3151,7 @@ func (s *TimelineService) GetPendingApprovals() ([]ApprovalRecord, error) {
 func (s *TimelineService) GetApprovalsByTraceID(traceID string) ([]ApprovalRecord, error) {
 	rows, err := s.db.Query(`SELECT id, approval_id, COALESCE(trace_id,''), COALESCE(task_id,''),
 		tool, tier, COALESCE(arguments,''), COALESCE(sender,''), COALESCE(channel,''),
-		status, created_at, responded_at
+		status, COALESCE(approvers,''), COALESCE(quorum,1), COALESCE(decided_by,''), created_at, responded_at
 		FROM approval_requests WHERE trace_id = ? ORDER BY created_at ASC`, traceID)
 	if err != nil {
 		return nil, err
@@ -2519,7 +3164,7 @@ func (s *TimelineService) GetApprovalsByTraceID(traceID string) ([]ApprovalRecor
 		var respondedAt sql.NullTime
 		if err := rows.Scan(&r.ID, &r.ApprovalID, &r.TraceID, &r.TaskID,
 			&r.Tool, &r.Tier, &r.Arguments, &r.Sender, &r.Channel,
-			&r.Status, &r.CreatedAt, &respondedAt); err != nil {
+			&r.Status, &r.Approvers, &r.Quorum, &r.DecidedBy, &r.CreatedAt, &respondedAt); err != nil {
 			return nil, err
 		}
 		if respondedAt.Valid {
@@ -2545,29 +3190,71 @@ func (s *TimelineService) UpsertScheduledJob(jobName, status string, runAt time.
 	return err
 }
 
-// GetScheduledJob returns a scheduled job record by name.
-func (s *TimelineService) GetScheduledJob(jobName string) (*ScheduledJobRecord, error) {
+// UpdateScheduledJobStatus sets the last status of a job without counting
+// a run, e.g. when a dispatched run completes.
+func (s *TimelineService) UpdateScheduledJobStatus(jobName, status string) error {
+	_, err := s.db.Exec(`UPDATE scheduled_jobs SET last_status = ?, updated_at = datetime('now') WHERE job_name = ?`,
+		status, jobName)
+	return err
+}
+
+const scheduledJobColumns = `id, job_name, COALESCE(last_status,''), last_run_at,
+		run_count, created_at, updated_at,
+		COALESCE(kind,''), COALESCE(schedule,''), COALESCE(timezone,''), COALESCE(content,''),
+		COALESCE(channel,''), COALESCE(chat_id,''), COALESCE(thread_id,''), COALESCE(sender_id,''),
+		COALESCE(session_key,''), COALESCE(message_type,''), COALESCE(misfire_policy,''),
+		COALESCE(paused,0), next_run_at, COALESCE(last_trace_id,''), COALESCE(created_by,''),
+		COALESCE(timeout_seconds,0)`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanScheduledJob(row rowScanner) (*ScheduledJobRecord, error) {
 	var r ScheduledJobRecord
-	var lastRunAt sql.NullTime
-	err := s.db.QueryRow(`SELECT id, job_name, COALESCE(last_status,''), last_run_at,
-		run_count, created_at, updated_at
-		FROM scheduled_jobs WHERE job_name = ?`, jobName).
-		Scan(&r.ID, &r.JobName, &r.LastStatus, &lastRunAt,
-			&r.RunCount, &r.CreatedAt, &r.UpdatedAt)
-	if err != nil {
+	var lastRunAt, nextRunAt sql.NullTime
+	if err := row.Scan(&r.ID, &r.JobName, &r.LastStatus, &lastRunAt,
+		&r.RunCount, &r.CreatedAt, &r.UpdatedAt,
+		&r.Kind, &r.Schedule, &r.Timezone, &r.Content,
+		&r.Channel, &r.ChatID, &r.ThreadID, &r.SenderID,
+		&r.SessionKey, &r.MessageType, &r.MisfirePolicy,
+		&r.Paused, &nextRunAt, &r.LastTraceID, &r.CreatedBy,
+		&r.TimeoutSeconds); err != nil {
 		return nil, err
 	}
 	if lastRunAt.Valid {
 		r.LastRunAt = lastRunAt.Time
 	}
+	if nextRunAt.Valid {
+		t := nextRunAt.Time
+		r.NextRunAt = &t
+	}
 	return &r, nil
 }
 
+// GetScheduledJob returns a scheduled job record by name.
+func (s *TimelineService) GetScheduledJob(jobName string) (*ScheduledJobRecord, error) {
+	return scanScheduledJob(s.db.QueryRow(`SELECT `+scheduledJobColumns+`
+		FROM scheduled_jobs WHERE job_name = ?`, jobName))
+}
+
 // ListScheduledJobs returns all scheduled job records.
 func (s *TimelineService) ListScheduledJobs() ([]ScheduledJobRecord, error) {
-	rows, err := s.db.Query(`SELECT id, job_name, COALESCE(last_status,''), last_run_at,
-		run_count, created_at, updated_at
+	return s.queryScheduledJobs(`SELECT ` + scheduledJobColumns + `
 		FROM scheduled_jobs ORDER BY updated_at DESC`)
+}
+
+// ListUserScheduledJobs returns the active (not paused) user jobs that
+// have a next run.
+func (s *TimelineService) ListUserScheduledJobs() ([]ScheduledJobRecord, error) {
+	return s.queryScheduledJobs(`SELECT ` + scheduledJobColumns + `
+		FROM scheduled_jobs
+		WHERE COALESCE(kind,'') != '' AND COALESCE(paused,0) = 0 AND next_run_at IS NOT NULL
+		ORDER BY id ASC`)
+}
+
+func (s *TimelineService) queryScheduledJobs(query string, args ...any) ([]ScheduledJobRecord, error) {
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -2575,20 +3262,150 @@ func (s *TimelineService) ListScheduledJobs() ([]ScheduledJobRecord, error) {
 
 	var out []ScheduledJobRecord
 	for rows.Next() {
-		var r ScheduledJobRecord
-		var lastRunAt sql.NullTime
-		if err := rows.Scan(&r.ID, &r.JobName, &r.LastStatus, &lastRunAt,
-			&r.RunCount, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		r, err := scanScheduledJob(rows)
+		if err != nil {
 			return nil, err
 		}
-		if lastRunAt.Valid {
-			r.LastRunAt = lastRunAt.Time
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+// CreateScheduledJob stores a new user job. Names are unique.
+func (s *TimelineService) CreateScheduledJob(r *ScheduledJobRecord) error {
+	_, err := s.db.Exec(`INSERT INTO scheduled_jobs
+		(job_name, kind, schedule, timezone, content, channel, chat_id, thread_id, sender_id,
+		 session_key, message_type, misfire_policy, timeout_seconds, paused, next_run_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.JobName, r.Kind, r.Schedule, r.Timezone, r.Content, r.Channel, r.ChatID, r.ThreadID, r.SenderID,
+		r.SessionKey, r.MessageType, r.MisfirePolicy, r.TimeoutSeconds, r.Paused, nullableTime(r.NextRunAt), r.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("create scheduled job %s: %w", r.JobName, err)
+	}
+	return nil
+}
+
+// SetScheduledJobState pauses or resumes a job and sets its next run.
+func (s *TimelineService) SetScheduledJobState(jobName string, paused bool, nextRunAt *time.Time) error {
+	res, err := s.db.Exec(`UPDATE scheduled_jobs SET paused = ?, next_run_at = ?, updated_at = datetime('now')
+		WHERE job_name = ?`, paused, nullableTime(nextRunAt), jobName)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteScheduledJob removes a job and its run history.
+func (s *TimelineService) DeleteScheduledJob(jobName string) error {
+	res, err := s.db.Exec(`DELETE FROM scheduled_jobs WHERE job_name = ?`, jobName)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = s.db.Exec(`DELETE FROM scheduled_job_runs WHERE job_name = ?`, jobName)
+	return err
+}
+
+// ClaimScheduledJobRun moves a job from its due run to next (nil when it has
+// none left), only if no one else has done so. It returns false when the
+// run was already claimed, e.g. by another gateway sharing the database.
+func (s *TimelineService) ClaimScheduledJobRun(jobName string, due time.Time, next *time.Time) (bool, error) {
+	res, err := s.db.Exec(`UPDATE scheduled_jobs SET next_run_at = ?, updated_at = datetime('now')
+		WHERE job_name = ? AND next_run_at = ? AND COALESCE(paused,0) = 0`,
+		nullableTime(next), jobName, due.UTC())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// UpdateScheduledJobRunStatus sets the status of the run started with
+// traceID, and the job's last status.
+func (s *TimelineService) UpdateScheduledJobRunStatus(traceID, status string) error {
+	if traceID == "" {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`UPDATE scheduled_job_runs SET status = ? WHERE trace_id = ?`, status, traceID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE scheduled_jobs SET last_status = ?, updated_at = datetime('now') WHERE last_trace_id = ?`,
+		status, traceID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RecordScheduledJobRun adds a run to the history of a user job and moves
+// the job to its next run (nil when it has none left). Runs without a trace
+// ID were not dispatched and do not count as runs of the job.
+func (s *TimelineService) RecordScheduledJobRun(jobName, traceID, status string, scheduledFor time.Time, nextRunAt *time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`INSERT INTO scheduled_job_runs (job_name, trace_id, status, scheduled_for)
+		VALUES (?, ?, ?, ?)`, jobName, traceID, status, scheduledFor.UTC()); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE scheduled_jobs SET
+			last_status = ?,
+			last_run_at = CASE WHEN ? != '' THEN ? ELSE last_run_at END,
+			run_count = run_count + CASE WHEN ? != '' THEN 1 ELSE 0 END,
+			last_trace_id = CASE WHEN ? != '' THEN ? ELSE last_trace_id END,
+			next_run_at = ?, updated_at = datetime('now')
+		WHERE job_name = ?`,
+		status, traceID, time.Now().UTC(), traceID, traceID, traceID, nullableTime(nextRunAt), jobName); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListScheduledJobRuns returns the most recent runs of a job, newest first,
+// with the output of the agent task each run started.
+func (s *TimelineService) ListScheduledJobRuns(jobName string, limit int) ([]ScheduledJobRunRecord, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := s.db.Query(`SELECT r.id, r.job_name, COALESCE(r.trace_id,''), r.status, r.scheduled_for, r.created_at,
+		COALESCE((SELECT status FROM tasks t WHERE t.trace_id = r.trace_id AND r.trace_id != '' ORDER BY t.id DESC LIMIT 1), ''),
+		COALESCE((SELECT content_out FROM tasks t WHERE t.trace_id = r.trace_id AND r.trace_id != '' ORDER BY t.id DESC LIMIT 1), '')
+		FROM scheduled_job_runs r WHERE r.job_name = ? ORDER BY r.id DESC LIMIT ?`, jobName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ScheduledJobRunRecord
+	for rows.Next() {
+		var r ScheduledJobRunRecord
+		if err := rows.Scan(&r.ID, &r.JobName, &r.TraceID, &r.Status, &r.ScheduledFor, &r.CreatedAt,
+			&r.TaskStatus, &r.Output); err != nil {
+			return nil, err
 		}
 		out = append(out, r)
 	}
 	return out, rows.Err()
 }
 
+func nullableTime(t *time.Time) any {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
 // --- Delegation Events ---
 
 // LogDelegationEvent records a delegation audit event.
@@ -387,3 +387,51 @@ func TestTraceGraphCoverage(t *testing.T) {
 		t.Fatalf("expected non-empty trace graph: %+v", graph)
 	}
 }
+
+func TestApprovalRoutingAndAuditEvents(t *testing.T) {
+	svc := newTestTimeline(t)
+
+	if err := svc.InsertApprovalRequest("ap-2", "trace-routing", "task-1", "exec", 2, "{}", "U1", "slack"); err != nil {
+		t.Fatalf("insert approval request: %v", err)
+	}
+	if err := svc.SetApprovalRouting("ap-2", "slack:U2,slack:U3", 2); err != nil {
+		t.Fatalf("set approval routing: %v", err)
+	}
+	for _, step := range [][3]string{
+		{"slack:U1", "created", "tool=exec tier=2 quorum=2"},
+		{"slack:U2", "approve", "1/2"},
+		{"slack:U3", "approve", "2/2"},
+	} {
+		if err := svc.InsertApprovalEvent("ap-2", step[0], step[1], step[2]); err != nil {
+			t.Fatalf("insert approval event: %v", err)
+		}
+	}
+	if err := svc.UpdateApprovalDecision("ap-2", "approved", "slack:U2,slack:U3"); err != nil {
+		t.Fatalf("update approval decision: %v", err)
+	}
+
+	records, err := svc.GetApprovalsByTraceID("trace-routing")
+	if err != nil || len(records) != 1 {
+		t.Fatalf("get approvals: %+v err=%v", records, err)
+	}
+	r := records[0]
+	if r.Status != "approved" || r.Approvers != "slack:U2,slack:U3" || r.Quorum != 2 || r.DecidedBy != "slack:U2,slack:U3" || r.RespondedAt == nil {
+		t.Fatalf("unexpected approval record: %+v", r)
+	}
+
+	events, err := svc.ListApprovalEvents("ap-2")
+	if err != nil || len(events) != 3 {
+		t.Fatalf("list approval events: %+v err=%v", events, err)
+	}
+	if events[0].Action != "created" || events[2].Actor != "slack:U3" || events[2].Detail != "2/2" {
+		t.Fatalf("unexpected approval events: %+v", events)
+	}
+
+	audit, err := svc.ListUnifiedAudit(AuditFilter{Source: "approval_event", Limit: 10})
+	if err != nil {
+		t.Fatalf("list unified audit: %v", err)
+	}
+	if len(audit) != 3 || audit[0].TargetID != "ap-2" {
+		t.Fatalf("expected approval events in unified audit, got %+v", audit)
+	}
+}
@@ -1,6 +1,7 @@
 package timeline
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -735,3 +736,148 @@ func TestCascadeTaskRetryRemediationAndListing(t *testing.T) {
 		t.Fatalf("unexpected list response: %+v", all)
 	}
 }
+
+func TestSearchKnowledgeFacts(t *testing.T) {
+	svc := newTestTimeline(t)
+	for _, rec := range []KnowledgeFactRecord{
+		{FactID: "f1", GroupName: "g1", Subject: "payments-api", Predicate: "owned_by", Object: "team-billing", Version: 1, Source: "s"},
+		{FactID: "f2", GroupName: "g1", Subject: "payments-api", Predicate: "runbook", Object: "wiki/payments", Version: 1, Source: "s"},
+		{FactID: "f3", GroupName: "g1", Subject: "search", Predicate: "owned_by", Object: "team-discovery", Version: 2, Source: "s"},
+		{FactID: "f4", GroupName: "g2", Subject: "payments-api", Predicate: "owned_by", Object: "team-other", Version: 1, Source: "s"},
+	} {
+		rec := rec
+		rec.Tags = "[]"
+		if err := svc.UpsertKnowledgeFactLatest(&rec); err != nil {
+			t.Fatalf("upsert %s: %v", rec.FactID, err)
+		}
+	}
+
+	cases := []struct {
+		name string
+		q    KnowledgeFactQuery
+		want []string
+	}{
+		{"subject", KnowledgeFactQuery{Group: "g1", Subject: "PAYMENTS-API"}, []string{"f1", "f2"}},
+		{"predicate", KnowledgeFactQuery{Group: "g1", Predicate: "owned_by"}, []string{"f1", "f3"}},
+		{"text", KnowledgeFactQuery{Group: "g1", Text: "Team"}, []string{"f1", "f3"}},
+		{"all groups", KnowledgeFactQuery{Subject: "payments-api", Predicate: "owned_by"}, []string{"f1", "f4"}},
+	}
+	for _, tc := range cases {
+		got, err := svc.SearchKnowledgeFacts(tc.q)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		ids := map[string]bool{}
+		for _, f := range got {
+			ids[f.FactID] = true
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: expected %v, got %+v", tc.name, tc.want, got)
+		}
+		for _, id := range tc.want {
+			if !ids[id] {
+				t.Fatalf("%s: missing %s in %+v", tc.name, id, got)
+			}
+		}
+	}
+	if got, err := svc.SearchKnowledgeFacts(KnowledgeFactQuery{Group: "g1", Limit: 1}); err != nil || len(got) != 1 {
+		t.Fatalf("expected limit to cap results at 1, got %v %+v", err, got)
+	}
+}
+
+func TestKnowledgeProposalStoresFact(t *testing.T) {
+	svc := newTestTimeline(t)
+	if err := svc.CreateKnowledgeProposal(&KnowledgeProposalRecord{
+		ProposalID:         "p-fact",
+		GroupName:          "g1",
+		Statement:          "payments-api owned_by team-billing",
+		Tags:               "[]",
+		ProposerClawID:     "claw-a",
+		ProposerInstanceID: "inst-a",
+		Fact:               `{"subject":"payments-api","predicate":"owned_by","object":"team-billing"}`,
+	}); err != nil {
+		t.Fatalf("create proposal: %v", err)
+	}
+	got, err := svc.GetKnowledgeProposal("p-fact")
+	if err != nil || got == nil {
+		t.Fatalf("get proposal: %v %+v", err, got)
+	}
+	if !strings.Contains(got.Fact, "team-billing") {
+		t.Fatalf("expected stored fact, got %q", got.Fact)
+	}
+	list, err := svc.ListKnowledgeProposals("pending", 10, 0)
+	if err != nil || len(list) != 1 || list[0].Fact != got.Fact {
+		t.Fatalf("unexpected listed proposals: %v %+v", err, list)
+	}
+}
+
+func TestKnowledgeFactVersionsAndSyncPeers(t *testing.T) {
+	svc := newTestTimeline(t)
+	rec := &KnowledgeFactRecord{FactID: "f1", GroupName: "g1", Subject: "s", Predicate: "p", Source: "test", Tags: "[]"}
+	for v := 1; v <= 3; v++ {
+		rec.Version = v
+		rec.Object = fmt.Sprintf("o%d", v)
+		if err := svc.UpsertKnowledgeFactLatest(rec); err != nil {
+			t.Fatalf("upsert v%d: %v", v, err)
+		}
+	}
+	versions, err := svc.ListKnowledgeFactVersions("f1", 1)
+	if err != nil {
+		t.Fatalf("list versions: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 2 || versions[1].Object != "o3" {
+		t.Fatalf("unexpected versions after v1: %+v", versions)
+	}
+
+	peer := &KnowledgeSyncPeerRecord{ClawID: "claw-b", GroupName: "g1", Root: "r1", FactCount: 3, Behind: 1}
+	if err := svc.UpsertKnowledgeSyncPeer(peer); err != nil {
+		t.Fatalf("upsert peer: %v", err)
+	}
+	peer.Root, peer.Behind = "r2", 0
+	if err := svc.UpsertKnowledgeSyncPeer(peer); err != nil {
+		t.Fatalf("update peer: %v", err)
+	}
+	if err := svc.UpsertKnowledgeSyncPeer(&KnowledgeSyncPeerRecord{ClawID: "claw-c", GroupName: "g2"}); err != nil {
+		t.Fatalf("upsert other group peer: %v", err)
+	}
+	peers, err := svc.ListKnowledgeSyncPeers("g1")
+	if err != nil {
+		t.Fatalf("list peers: %v", err)
+	}
+	if len(peers) != 1 || peers[0].Root != "r2" || peers[0].Behind != 0 || peers[0].CheckedAt.IsZero() {
+		t.Fatalf("unexpected peers: %+v", peers)
+	}
+	if all, _ := svc.ListKnowledgeSyncPeers(""); len(all) != 2 {
+		t.Fatalf("expected peers of all groups, got %+v", all)
+	}
+}
+
+func TestKnowledgeVoteAttributesAndTally(t *testing.T) {
+	svc := newTestTimeline(t)
+	if err := svc.CreateKnowledgeProposal(&KnowledgeProposalRecord{
+		ProposalID: "p-w", GroupName: "g1", Statement: "s", Tags: "[]",
+		ProposerClawID: "claw-a", ProposerInstanceID: "inst-a",
+	}); err != nil {
+		t.Fatalf("create proposal: %v", err)
+	}
+	if err := svc.UpsertKnowledgeVote(&KnowledgeVoteRecord{
+		ProposalID: "p-w", ClawID: "claw-b", InstanceID: "inst-b", Vote: "yes",
+		Role: "orchestrator", ZoneID: "eu", Expertise: 0.75,
+	}); err != nil {
+		t.Fatalf("upsert vote: %v", err)
+	}
+	votes, err := svc.ListKnowledgeVotes("p-w")
+	if err != nil || len(votes) != 1 {
+		t.Fatalf("list votes: %v %+v", err, votes)
+	}
+	if v := votes[0]; v.Role != "orchestrator" || v.ZoneID != "eu" || v.Expertise != 0.75 {
+		t.Fatalf("unexpected vote attributes: %+v", v)
+	}
+	if err := svc.UpdateKnowledgeProposalTally("p-w", 3.5, 1, "yes 3.5/2, no 1/2"); err != nil {
+		t.Fatalf("update tally: %v", err)
+	}
+	got, err := svc.GetKnowledgeProposal("p-w")
+	if err != nil || got == nil || got.YesWeight != 3.5 || got.NoWeight != 1 || got.Rationale != "yes 3.5/2, no 1/2" {
+		t.Fatalf("unexpected tally: %v %+v", err, got)
+	}
+}
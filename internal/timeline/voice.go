@@ -0,0 +1,67 @@
+package timeline
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// GetVoiceTranscript returns a cached transcript for audio with the given
+// SHA-256 hex digest.
+func (s *TimelineService) GetVoiceTranscript(audioSHA256 string) (string, bool, error) {
+	var text string
+	err := s.db.QueryRow(`SELECT transcript FROM voice_transcripts WHERE audio_sha256 = ?`, audioSHA256).Scan(&text)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return text, true, nil
+}
+
+// SaveVoiceTranscript caches a transcript. Source names the transcriber
+// (e.g. "local-whisper", "provider").
+func (s *TimelineService) SaveVoiceTranscript(audioSHA256, transcript, source string) error {
+	if strings.TrimSpace(audioSHA256) == "" {
+		return fmt.Errorf("audio hash is required")
+	}
+	_, err := s.db.Exec(`INSERT INTO voice_transcripts (audio_sha256, transcript, source, created_at)
+		VALUES (?, ?, ?, datetime('now'))
+		ON CONFLICT(audio_sha256) DO UPDATE SET transcript = excluded.transcript, source = excluded.source`,
+		audioSHA256, transcript, source)
+	return err
+}
+
+// GetVoiceReplyMode returns the per-chat voice reply mode, if one is set.
+func (s *TimelineService) GetVoiceReplyMode(channel, chatID string) (string, bool, error) {
+	var mode string
+	err := s.db.QueryRow(`SELECT reply_mode FROM voice_preferences WHERE channel = ? AND chat_id = ?`,
+		strings.ToLower(strings.TrimSpace(channel)), strings.TrimSpace(chatID)).Scan(&mode)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return mode, true, nil
+}
+
+// SetVoiceReplyMode stores the per-chat voice reply mode. An empty mode
+// clears the preference so the configured default applies.
+func (s *TimelineService) SetVoiceReplyMode(channel, chatID, mode string) error {
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	chatID = strings.TrimSpace(chatID)
+	if channel == "" || chatID == "" {
+		return fmt.Errorf("channel and chat_id are required")
+	}
+	if strings.TrimSpace(mode) == "" {
+		_, err := s.db.Exec(`DELETE FROM voice_preferences WHERE channel = ? AND chat_id = ?`, channel, chatID)
+		return err
+	}
+	_, err := s.db.Exec(`INSERT INTO voice_preferences (channel, chat_id, reply_mode, updated_at)
+		VALUES (?, ?, ?, datetime('now'))
+		ON CONFLICT(channel, chat_id) DO UPDATE SET reply_mode = excluded.reply_mode, updated_at = excluded.updated_at`,
+		channel, chatID, strings.TrimSpace(mode))
+	return err
+}
@@ -0,0 +1,257 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+const (
+	defaultCacheTTL        = 2 * time.Minute
+	defaultCacheMaxEntries = 512
+)
+
+// Cache dependency tags. Entries list what they read; writes name what they
+// change, and an entry is dropped when a write touches one of its tags.
+const (
+	cacheTagTree   = "tree" // any file in any directory
+	cacheTagMemory = "memory"
+	cacheTagGraph  = "graph"
+)
+
+// cacheReadRule describes a cacheable tool: its default TTL and the
+// dependency tags of one call.
+type cacheReadRule struct {
+	ttl  time.Duration
+	deps func(params map[string]any) []string
+}
+
+// cacheReadRules lists the tools whose results may be cached. Tools not
+// listed here are never served from the cache.
+var cacheReadRules = map[string]cacheReadRule{
+	"read_file": {ttl: defaultCacheTTL, deps: func(p map[string]any) []string {
+		return []string{cacheFileTag(GetString(p, "path", ""))}
+	}},
+	"list_dir": {ttl: defaultCacheTTL, deps: func(p map[string]any) []string {
+		return []string{cacheDirTag(GetString(p, "path", ""))}
+	}},
+	"grep":        {ttl: defaultCacheTTL, deps: func(map[string]any) []string { return []string{cacheTagTree} }},
+	"glob":        {ttl: defaultCacheTTL, deps: func(map[string]any) []string { return []string{cacheTagTree} }},
+	"recall":      {ttl: 5 * time.Minute, deps: func(map[string]any) []string { return []string{cacheTagMemory} }},
+	"query_graph": {ttl: 5 * time.Minute, deps: func(map[string]any) []string { return []string{cacheTagGraph} }},
+}
+
+// cacheWriteRules lists writers with a known footprint. Any other call at
+// tier 1 or above may change anything and clears the whole cache.
+var cacheWriteRules = map[string]func(params map[string]any) []string{
+	"write_file": cacheWrittenPath,
+	"edit_file":  cacheWrittenPath,
+	"remember":   func(map[string]any) []string { return []string{cacheTagMemory} },
+}
+
+func cacheFileTag(path string) string { return "file:" + expandPath(path) }
+func cacheDirTag(path string) string  { return "dir:" + expandPath(path) }
+
+// cacheWrittenPath invalidates the file, its directory listing and searches.
+func cacheWrittenPath(p map[string]any) []string {
+	path := expandPath(GetString(p, "path", ""))
+	return []string{"file:" + path, "dir:" + filepath.Dir(path), cacheTagTree}
+}
+
+// CacheCall carries the cache scope of one tool call into Registry.Execute
+// and reports whether the result came from the cache.
+type CacheCall struct {
+	// Scope isolates entries, e.g. a trace or session key. Calls without a
+	// scope bypass the cache.
+	Scope string
+	Hit   bool
+	Age   time.Duration
+}
+
+type cacheCallKey struct{}
+
+// WithCacheCall attaches call to ctx for Registry.Execute.
+func WithCacheCall(ctx context.Context, call *CacheCall) context.Context {
+	return context.WithValue(ctx, cacheCallKey{}, call)
+}
+
+func cacheCallFrom(ctx context.Context) *CacheCall {
+	call, _ := ctx.Value(cacheCallKey{}).(*CacheCall)
+	return call
+}
+
+// ResultCache stores tool results per scope so repeated identical reads in
+// a task are not executed again.
+type ResultCache struct {
+	mu         sync.Mutex
+	entries    map[string]*cacheEntry
+	ttl        map[string]time.Duration
+	maxEntries int
+	now        func() time.Time
+}
+
+type cacheEntry struct {
+	result string
+	deps   []string
+	stored time.Time
+	expiry time.Time
+}
+
+// NewResultCache creates a cache from tools.cache settings.
+func NewResultCache(cfg config.ToolCacheConfig) *ResultCache {
+	c := &ResultCache{
+		entries:    make(map[string]*cacheEntry),
+		ttl:        make(map[string]time.Duration, len(cacheReadRules)),
+		maxEntries: cfg.MaxEntries,
+		now:        time.Now,
+	}
+	if c.maxEntries <= 0 {
+		c.maxEntries = defaultCacheMaxEntries
+	}
+	for name, rule := range cacheReadRules {
+		c.ttl[name] = rule.ttl
+	}
+	for name, secs := range cfg.TTLSeconds {
+		if _, ok := cacheReadRules[name]; ok {
+			c.ttl[name] = time.Duration(secs) * time.Second
+		}
+	}
+	return c
+}
+
+// cacheKey canonicalises the arguments; encoding/json sorts map keys.
+func cacheKey(scope, name string, params map[string]any) (string, bool) {
+	args, err := json.Marshal(params)
+	if err != nil {
+		return "", false
+	}
+	return scope + "\x00" + name + "\x00" + string(args), true
+}
+
+func (c *ResultCache) lookup(scope, name string, params map[string]any) (string, time.Duration, bool) {
+	key, ok := cacheKey(scope, name, params)
+	if !ok {
+		return "", 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return "", 0, false
+	}
+	now := c.now()
+	if now.After(e.expiry) {
+		delete(c.entries, key)
+		return "", 0, false
+	}
+	return e.result, now.Sub(e.stored), true
+}
+
+func (c *ResultCache) store(scope, name string, params map[string]any, result string) {
+	ttl := c.ttl[name]
+	if ttl <= 0 {
+		return
+	}
+	key, ok := cacheKey(scope, name, params)
+	if !ok {
+		return
+	}
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.maxEntries {
+		c.evictLocked(now)
+	}
+	c.entries[key] = &cacheEntry{
+		result: result,
+		deps:   cacheReadRules[name].deps(params),
+		stored: now,
+		expiry: now.Add(ttl),
+	}
+}
+
+// evictLocked drops expired entries, then the oldest one if still full.
+func (c *ResultCache) evictLocked(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for k, e := range c.entries {
+		if now.After(e.expiry) {
+			delete(c.entries, k)
+			continue
+		}
+		if oldestKey == "" || e.stored.Before(oldest) {
+			oldestKey, oldest = k, e.stored
+		}
+	}
+	if len(c.entries) >= c.maxEntries && oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
+
+// invalidate drops entries in every scope that depend on one of tags.
+func (c *ResultCache) invalidate(tags []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range c.entries {
+		if cacheDepsOverlap(e.deps, tags) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+func cacheDepsOverlap(deps, tags []string) bool {
+	for _, d := range deps {
+		for _, t := range tags {
+			if d == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Clear drops every entry.
+func (c *ResultCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// Len returns the number of stored entries.
+func (c *ResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// execute runs tool through the cache for the given call.
+func (c *ResultCache) execute(ctx context.Context, tool Tool, call *CacheCall, params map[string]any) (string, error) {
+	name := tool.Name()
+	scope := strings.TrimSpace(call.Scope)
+	_, cacheable := cacheReadRules[name]
+	if cacheable && scope != "" {
+		if result, age, ok := c.lookup(scope, name, params); ok {
+			call.Hit, call.Age = true, age
+			return result, nil
+		}
+	}
+
+	result, err := tool.Execute(ctx, params)
+
+	switch {
+	case cacheable:
+		if scope != "" && err == nil && !strings.HasPrefix(result, "Error") {
+			c.store(scope, name, params, result)
+		}
+	case cacheWriteRules[name] != nil:
+		c.invalidate(cacheWriteRules[name](params))
+	case ToolCallTier(tool, params) >= TierWrite:
+		c.Clear()
+	}
+	return result, err
+}
@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+// countingTool counts executions and returns a fixed result.
+type countingTool struct {
+	name   string
+	tier   int
+	result string
+	calls  int
+}
+
+func (t *countingTool) Name() string               { return t.name }
+func (t *countingTool) Tier() int                  { return t.tier }
+func (t *countingTool) Description() string        { return "" }
+func (t *countingTool) Parameters() map[string]any { return nil }
+func (t *countingTool) Execute(context.Context, map[string]any) (string, error) {
+	t.calls++
+	return t.result, nil
+}
+
+func newCacheTestRegistry(cfg config.ToolCacheConfig, ts ...Tool) (*Registry, *ResultCache) {
+	r := NewRegistry()
+	for _, t := range ts {
+		r.Register(t)
+	}
+	c := NewResultCache(cfg)
+	r.SetCache(c)
+	return r, c
+}
+
+func execScoped(t *testing.T, r *Registry, scope, name string, params map[string]any) (string, bool) {
+	t.Helper()
+	call := &CacheCall{Scope: scope}
+	out, err := r.Execute(WithCacheCall(context.Background(), call), name, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out, call.Hit
+}
+
+func TestResultCache_HitsWithinScopeOnly(t *testing.T) {
+	recall := &countingTool{name: "recall", result: "facts"}
+	r, _ := newCacheTestRegistry(config.ToolCacheConfig{}, recall)
+
+	if _, hit := execScoped(t, r, "trace:a", "recall", map[string]any{"query": "x", "limit": 5.0}); hit {
+		t.Fatal("first call must miss")
+	}
+	// Argument order does not matter; the key is canonical JSON.
+	if out, hit := execScoped(t, r, "trace:a", "recall", map[string]any{"limit": 5.0, "query": "x"}); !hit || out != "facts" {
+		t.Fatalf("expected hit, got hit=%v out=%q", hit, out)
+	}
+	if _, hit := execScoped(t, r, "trace:b", "recall", map[string]any{"query": "x", "limit": 5.0}); hit {
+		t.Fatal("other scope must miss")
+	}
+	if _, err := r.Execute(context.Background(), "recall", map[string]any{"query": "x", "limit": 5.0}); err != nil {
+		t.Fatal(err)
+	}
+	if recall.calls != 3 {
+		t.Fatalf("expected 3 executions (calls without a CacheCall bypass the cache), got %d", recall.calls)
+	}
+}
+
+func TestResultCache_WriteInvalidatesPathReads(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	for _, p := range []string{a, b} {
+		if err := os.WriteFile(p, []byte("v1"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, _ := newCacheTestRegistry(config.ToolCacheConfig{}, NewReadFileTool(), NewWriteFileTool(func() string { return dir }), NewListDirTool())
+
+	execScoped(t, r, "trace:a", "read_file", map[string]any{"path": a})
+	execScoped(t, r, "trace:b", "read_file", map[string]any{"path": b})
+	execScoped(t, r, "trace:b", "list_dir", map[string]any{"path": dir})
+
+	// A write from any scope drops reads of that file and its directory.
+	execScoped(t, r, "trace:c", "write_file", map[string]any{"path": a, "content": "v2"})
+	if out, hit := execScoped(t, r, "trace:a", "read_file", map[string]any{"path": a}); hit || out != "v2" {
+		t.Fatalf("expected fresh read after write, got hit=%v out=%q", hit, out)
+	}
+	if _, hit := execScoped(t, r, "trace:b", "list_dir", map[string]any{"path": dir}); hit {
+		t.Fatal("directory listing must be invalidated by a write inside it")
+	}
+	if _, hit := execScoped(t, r, "trace:b", "read_file", map[string]any{"path": b}); !hit {
+		t.Fatal("unrelated file should stay cached")
+	}
+}
+
+func TestResultCache_UnknownWritersClearAndErrorsAreNotCached(t *testing.T) {
+	recall := &countingTool{name: "recall", result: "facts"}
+	lookup := &countingTool{name: "git_status", tier: TierReadOnly, result: "clean"}
+	writer := &countingTool{name: "exec", tier: TierHighRisk, result: "ok"}
+	failing := &countingTool{name: "query_graph", result: "Error: graph down"}
+	r, c := newCacheTestRegistry(config.ToolCacheConfig{}, recall, lookup, writer, failing)
+
+	execScoped(t, r, "s", "recall", nil)
+	execScoped(t, r, "s", "git_status", nil)
+	execScoped(t, r, "s", "git_status", nil)
+	if lookup.calls != 2 {
+		t.Fatal("tools without a cache rule must not be cached")
+	}
+	if c.Len() != 1 {
+		t.Fatalf("expected 1 entry, got %d", c.Len())
+	}
+	execScoped(t, r, "other", "exec", nil)
+	if c.Len() != 0 {
+		t.Fatal("high-risk call without a write rule should clear the cache")
+	}
+	execScoped(t, r, "s", "query_graph", nil)
+	if _, hit := execScoped(t, r, "s", "query_graph", nil); hit {
+		t.Fatal("error results must not be cached")
+	}
+}
+
+func TestResultCache_TTL(t *testing.T) {
+	recall := &countingTool{name: "recall", result: "facts"}
+	grep := &countingTool{name: "grep", result: "a.go:1:x"}
+	r, c := newCacheTestRegistry(config.ToolCacheConfig{TTLSeconds: map[string]int{"recall": 10, "grep": 0}}, recall, grep)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	execScoped(t, r, "s", "recall", nil)
+	now = now.Add(9 * time.Second)
+	if _, hit := execScoped(t, r, "s", "recall", nil); !hit {
+		t.Fatal("expected hit before TTL")
+	}
+	now = now.Add(2 * time.Second)
+	if _, hit := execScoped(t, r, "s", "recall", nil); hit {
+		t.Fatal("expected miss after TTL")
+	}
+	execScoped(t, r, "s", "grep", nil)
+	if _, hit := execScoped(t, r, "s", "grep", nil); hit {
+		t.Fatal("TTL 0 disables caching for the tool")
+	}
+}
+
+func TestResultCache_EvictsOldestWhenFull(t *testing.T) {
+	recall := &countingTool{name: "recall", result: "facts"}
+	r, c := newCacheTestRegistry(config.ToolCacheConfig{MaxEntries: 2}, recall)
+	now := time.Now()
+	c.now = func() time.Time { now = now.Add(time.Millisecond); return now }
+
+	for _, q := range []string{"a", "b", "c"} {
+		execScoped(t, r, "s", "recall", map[string]any{"query": q})
+	}
+	if c.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", c.Len())
+	}
+	if _, hit := execScoped(t, r, "s", "recall", map[string]any{"query": "a"}); hit {
+		t.Fatal("oldest entry should have been evicted")
+	}
+}
@@ -0,0 +1,345 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/skills"
+)
+
+const (
+	codeRunMaxArtifacts = 20
+	codeRunMaxScanFiles = 2000
+	codeRunScriptPrefix = ".code_run_"
+)
+
+var codeRunSessionExpr = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// codeRunSkipDirs are never scanned for artifacts.
+var codeRunSkipDirs = map[string]bool{"__pycache__": true, "node_modules": true}
+
+// CodeRunTool runs Python or Node.js snippets in the skills isolation
+// runtime. Each session keeps a working dir across runs; files a run
+// creates or changes are copied into the work repo as artifacts.
+type CodeRunTool struct {
+	cfg         *config.Config
+	repo        func() string
+	sessionKey  func(context.Context) string
+	onArtifacts func(ctx context.Context, paths []string)
+	run         func(ctx context.Context, cfg *config.Config, run skills.CodeRun) (*skills.SkillExecResult, error)
+	baseDir     func() (string, error)
+	seq         atomic.Uint64
+	locks       sync.Map // session -> *sync.Mutex
+}
+
+// NewCodeRunTool creates code_run. onArtifacts, when set, receives the
+// work repo paths of the artifacts of each run.
+func NewCodeRunTool(cfg *config.Config, repo func() string, sessionKey func(context.Context) string, onArtifacts func(context.Context, []string)) *CodeRunTool {
+	return &CodeRunTool{
+		cfg:         cfg,
+		repo:        repo,
+		sessionKey:  sessionKey,
+		onArtifacts: onArtifacts,
+		run:         skills.RunCode,
+		baseDir:     defaultCodeRunBaseDir,
+	}
+}
+
+func defaultCodeRunBaseDir() (string, error) {
+	dirs, err := skills.EnsureStateDirs()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dirs.TmpDir, "code-run"), nil
+}
+
+func (t *CodeRunTool) Name() string { return "code_run" }
+
+// Tier is 1 in a container or the native sandbox and 2 when runs execute
+// on the host.
+func (t *CodeRunTool) Tier() int {
+	if strings.EqualFold(strings.TrimSpace(t.cfg.Skills.RuntimeIsolation), "host") {
+		return TierHighRisk
+	}
+	return TierWrite
+}
+
+func (t *CodeRunTool) Description() string {
+	return "Run a Python or Node.js snippet in a sandbox for data analysis. Files in the working directory persist for the session (variables do not); new or changed files such as CSVs and charts are saved to the work repo and attached to the reply. Print results to stdout."
+}
+
+func (t *CodeRunTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"language": map[string]any{
+				"type":        "string",
+				"enum":        []string{"python", "node"},
+				"description": "Interpreter (default python)",
+			},
+			"code": map[string]any{
+				"type":        "string",
+				"description": "Source code to run",
+			},
+			"reset": map[string]any{
+				"type":        "boolean",
+				"description": "Clear the session's working directory before running",
+			},
+		},
+		"required": []string{"code"},
+	}
+}
+
+// CodeRunArtifact is a file produced by a run.
+type CodeRunArtifact struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+	MIME  string `json:"mime,omitempty"`
+	Image bool   `json:"image,omitempty"`
+}
+
+// codeRunResult is the JSON returned to the model.
+type codeRunResult struct {
+	Language   string            `json:"language"`
+	ExitCode   int               `json:"exitCode"`
+	Stdout     string            `json:"stdout,omitempty"`
+	Stderr     string            `json:"stderr,omitempty"`
+	Truncated  bool              `json:"truncated,omitempty"`
+	DurationMs int64             `json:"durationMs"`
+	Artifacts  []CodeRunArtifact `json:"artifacts,omitempty"`
+	Skipped    []string          `json:"skipped,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func (t *CodeRunTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	lang := codeRunLanguage(GetString(params, "language", "python"))
+	ext := map[string]string{"python": ".py", "node": ".js"}[lang]
+	if ext == "" {
+		return "Error: language must be python or node", nil
+	}
+	code := GetString(params, "code", "")
+	if strings.TrimSpace(code) == "" {
+		return "Error: code is required", nil
+	}
+
+	session := t.sessionKey(ctx)
+	lock, _ := t.locks.LoadOrStore(session, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	dir, err := t.sessionDir(session)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	if GetBool(params, "reset", false) {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Sprintf("Error: reset working dir: %v", err), nil
+		}
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+
+	runID := fmt.Sprintf("%s-%d", time.Now().Format("20060102-150405"), t.seq.Add(1))
+	script := codeRunScriptPrefix + runID + ext
+	if err := os.WriteFile(filepath.Join(dir, script), []byte(code), 0o600); err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	defer os.Remove(filepath.Join(dir, script))
+
+	before := scanCodeRunDir(dir)
+	cr := t.cfg.Tools.CodeRun
+	image := cr.PythonImage
+	if lang == "node" {
+		image = cr.NodeImage
+	}
+	res, runErr := t.run(ctx, t.cfg, skills.CodeRun{
+		Language:       lang,
+		Dir:            dir,
+		Script:         script,
+		Timeout:        time.Duration(cr.TimeoutSeconds) * time.Second,
+		MaxOutputBytes: cr.MaxOutputBytes,
+		MemoryBytes:    int64(cr.MemoryMB) << 20,
+		CPUs:           cr.CPUs,
+		Network:        cr.Network,
+		Image:          image,
+	})
+	if res == nil {
+		return fmt.Sprintf("Error: %v", runErr), nil
+	}
+
+	out := codeRunResult{
+		Language:   lang,
+		ExitCode:   res.ExitCode,
+		Stdout:     res.Stdout,
+		Stderr:     res.Stderr,
+		Truncated:  res.OutputTruncated,
+		DurationMs: res.Duration.Milliseconds(),
+	}
+	if runErr != nil {
+		out.Error = runErr.Error()
+	}
+	out.Artifacts, out.Skipped = t.captureArtifacts(dir, before, runID)
+	if t.onArtifacts != nil && len(out.Artifacts) > 0 {
+		paths := make([]string, len(out.Artifacts))
+		for i, a := range out.Artifacts {
+			paths[i] = a.Path
+		}
+		t.onArtifacts(ctx, paths)
+	}
+	return jsonResult(out)
+}
+
+// ResetSession removes the working dir of a session.
+func (t *CodeRunTool) ResetSession(session string) error {
+	dir, err := t.sessionDir(session)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func (t *CodeRunTool) sessionDir(session string) (string, error) {
+	base, err := t.baseDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(session))
+	name := strings.Trim(codeRunSessionExpr.ReplaceAllString(session, "_"), "._")
+	if len(name) > 48 {
+		name = name[:48]
+	}
+	return filepath.Join(base, name+"-"+hex.EncodeToString(sum[:4])), nil
+}
+
+func codeRunLanguage(v string) string {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "python", "python3", "py":
+		return "python"
+	case "node", "nodejs", "javascript", "js":
+		return "node"
+	default:
+		return ""
+	}
+}
+
+// scanCodeRunDir records the regular files of dir, skipping hidden entries
+// and dependency caches.
+func scanCodeRunDir(dir string) map[string]fileStamp {
+	files := map[string]fileStamp{}
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if len(files) >= codeRunMaxScanFiles {
+			return fs.SkipAll
+		}
+		if path == dir {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || (d.IsDir() && codeRunSkipDirs[d.Name()]) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return files
+}
+
+// captureArtifacts copies files created or changed by a run into
+// artifacts/code-run/<runID>/ of the work repo. Without a work repo the
+// files are referenced in the working dir.
+func (t *CodeRunTool) captureArtifacts(dir string, before map[string]fileStamp, runID string) ([]CodeRunArtifact, []string) {
+	after := scanCodeRunDir(dir)
+	maxBytes := t.cfg.Tools.CodeRun.MaxArtifactBytes
+	var artifacts []CodeRunArtifact
+	var skipped []string
+	for _, rel := range slices.Sorted(maps.Keys(after)) {
+		stamp := after[rel]
+		if prev, ok := before[rel]; ok && prev == stamp {
+			continue
+		}
+		if maxBytes > 0 && stamp.size > maxBytes {
+			skipped = append(skipped, fmt.Sprintf("%s (%d bytes exceeds maxArtifactBytes)", rel, stamp.size))
+			continue
+		}
+		if len(artifacts) >= codeRunMaxArtifacts {
+			skipped = append(skipped, rel+" (too many artifacts)")
+			continue
+		}
+		src := filepath.Join(dir, rel)
+		path := src
+		if repo := t.repo(); repo != "" {
+			dest, err := config.ResolveArtifactPath(repo, "artifacts", filepath.Join("code-run", runID, rel))
+			if err == nil {
+				err = copyArtifactFile(src, dest)
+			}
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("%s (%v)", rel, err))
+				continue
+			}
+			path = dest
+		}
+		mimeType := mime.TypeByExtension(strings.ToLower(filepath.Ext(rel)))
+		artifacts = append(artifacts, CodeRunArtifact{
+			Name:  filepath.ToSlash(rel),
+			Path:  path,
+			Bytes: stamp.size,
+			MIME:  mimeType,
+			Image: strings.HasPrefix(mimeType, "image/"),
+		})
+	}
+	return artifacts, skipped
+}
+
+func copyArtifactFile(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/skills"
+)
+
+func TestCodeRunToolCapturesArtifacts(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Tools.CodeRun.MaxArtifactBytes = 16
+	repo := t.TempDir()
+	base := t.TempDir()
+	var attached []string
+	tool := NewCodeRunTool(cfg, func() string { return repo }, func(context.Context) string { return "telegram:chat/1" }, func(_ context.Context, paths []string) {
+		attached = append(attached, paths...)
+	})
+	tool.baseDir = func() (string, error) { return base, nil }
+
+	var runs []skills.CodeRun
+	tool.run = func(_ context.Context, _ *config.Config, run skills.CodeRun) (*skills.SkillExecResult, error) {
+		runs = append(runs, run)
+		code, err := os.ReadFile(filepath.Join(run.Dir, run.Script))
+		if err != nil {
+			t.Fatalf("script not written: %v", err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(code)), "\n") {
+			name, content, _ := strings.Cut(line, "=")
+			if err := os.MkdirAll(filepath.Dir(filepath.Join(run.Dir, name)), 0o700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(run.Dir, name), []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return &skills.SkillExecResult{ExitCode: 0, Stdout: "ok\n"}, nil
+	}
+
+	out, _ := tool.Execute(context.Background(), map[string]any{"code": "data.csv=a,b\nplots/chart.png=png\nbig.bin=0123456789abcdefXYZ\n.hidden=x"})
+	var res codeRunResult
+	decodeToolJSON(t, out, &res)
+	if res.Language != "python" || res.Stdout != "ok\n" || len(res.Artifacts) != 2 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if len(res.Skipped) != 1 || !strings.Contains(res.Skipped[0], "big.bin") {
+		t.Fatalf("expected oversized file to be skipped, got %v", res.Skipped)
+	}
+	chart := res.Artifacts[1]
+	if chart.Name != "plots/chart.png" || !chart.Image || chart.MIME != "image/png" {
+		t.Fatalf("unexpected chart artifact %+v", chart)
+	}
+	if !strings.HasPrefix(chart.Path, filepath.Join(repo, "artifacts", "code-run")) {
+		t.Fatalf("artifact not stored in work repo: %s", chart.Path)
+	}
+	if data, err := os.ReadFile(chart.Path); err != nil || string(data) != "png" {
+		t.Fatalf("artifact copy = %q, %v", data, err)
+	}
+	if len(attached) != 2 || attached[1] != chart.Path {
+		t.Fatalf("unexpected attachments %v", attached)
+	}
+	if runs[0].Language != "python" || runs[0].Image != cfg.Tools.CodeRun.PythonImage || runs[0].MemoryBytes != 512<<20 {
+		t.Fatalf("unexpected run limits %+v", runs[0])
+	}
+	if _, err := os.Stat(filepath.Join(runs[0].Dir, runs[0].Script)); !os.IsNotExist(err) {
+		t.Fatalf("script should be removed after the run, got %v", err)
+	}
+
+	// The working dir persists: unchanged files are not captured again.
+	attached = nil
+	out, _ = tool.Execute(context.Background(), map[string]any{"language": "js", "code": "out.txt=hi"})
+	decodeToolJSON(t, out, &res)
+	if res.Language != "node" || len(res.Artifacts) != 1 || res.Artifacts[0].Name != "out.txt" {
+		t.Fatalf("unexpected second run %+v", res)
+	}
+	if runs[1].Dir != runs[0].Dir || runs[1].Image != cfg.Tools.CodeRun.NodeImage {
+		t.Fatalf("expected same session dir with node image, got %+v", runs[1])
+	}
+	if _, err := os.Stat(filepath.Join(runs[0].Dir, "data.csv")); err != nil {
+		t.Fatalf("session file missing: %v", err)
+	}
+
+	if err := tool.ResetSession("telegram:chat/1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(runs[0].Dir); !os.IsNotExist(err) {
+		t.Fatalf("reset should remove the working dir, got %v", err)
+	}
+}
+
+func TestCodeRunToolValidation(t *testing.T) {
+	cfg := config.DefaultConfig()
+	tool := NewCodeRunTool(cfg, func() string { return "" }, func(context.Context) string { return "s" }, nil)
+	tool.baseDir = func() (string, error) { return t.TempDir(), nil }
+	if out, _ := tool.Execute(context.Background(), map[string]any{"language": "ruby", "code": "puts 1"}); !strings.Contains(out, "python or node") {
+		t.Fatalf("expected language error, got %s", out)
+	}
+	if out, _ := tool.Execute(context.Background(), map[string]any{"code": " "}); !strings.Contains(out, "code is required") {
+		t.Fatalf("expected code error, got %s", out)
+	}
+	if ToolTier(tool) != TierWrite {
+		t.Fatalf("expected write tier, got %d", ToolTier(tool))
+	}
+	cfg.Skills.RuntimeIsolation = "host"
+	if ToolTier(tool) != TierHighRisk {
+		t.Fatalf("expected high-risk tier for host isolation, got %d", ToolTier(tool))
+	}
+}
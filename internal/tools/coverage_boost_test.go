@@ -4,6 +4,8 @@ import (
 	"context"
 	"strings"
 	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/config"
 )
 
 type fakeTieredTool struct{}
@@ -48,6 +50,22 @@ func TestToolTierAndDefaults(t *testing.T) {
 	}
 }
 
+func TestParallelSafe(t *testing.T) {
+	if !ParallelSafe(NewReadFileTool(), nil) {
+		t.Fatal("tier 0 tool should be parallel-safe")
+	}
+	if ParallelSafe(&fakeTieredTool{}, nil) {
+		t.Fatal("tier 2 tool should not be parallel-safe")
+	}
+	if ParallelSafe(&fakeUntieredTool{}, nil) {
+		t.Fatal("tool without a declared tier should not be parallel-safe")
+	}
+	h := NewHTTPRequestTool(config.HTTPToolConfig{})
+	if !ParallelSafe(h, map[string]any{"method": "GET"}) || ParallelSafe(h, map[string]any{"method": "POST"}) {
+		t.Fatal("http_request should be parallel-safe only for reads")
+	}
+}
+
 func TestRegistryExecute_NotFound(t *testing.T) {
 	r := NewRegistry()
 	_, err := r.Execute(context.Background(), "missing", nil)
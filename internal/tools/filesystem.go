@@ -1,21 +1,40 @@
 package tools
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/config"
 )
 
 // ReadFileTool reads the contents of a file.
-type ReadFileTool struct{}
+type ReadFileTool struct {
+	policy *ReadPolicy
+}
 
 func (t *ReadFileTool) Name() string { return "read_file" }
 func (t *ReadFileTool) Tier() int    { return TierReadOnly }
 
+// CallTier raises reads of sensitive-looking files, such as private keys
+// and .env files, to tier 2 so they need approval.
+func (t *ReadFileTool) CallTier(params map[string]any) int {
+	if path := GetString(params, "path", ""); path != "" && t.policy.Sensitive(path) {
+		return TierHighRisk
+	}
+	return TierReadOnly
+}
+
+// CheckCall refuses reads the read policy blocks.
+func (t *ReadFileTool) CheckCall(params map[string]any) error {
+	return t.policy.Check(GetString(params, "path", ""))
+}
+
 func (t *ReadFileTool) Description() string {
-	return "Read the contents of a file at the specified path."
+	return "Read the contents of a file at the specified path. Pass offset and/or limit to read a line range; ranged output is prefixed with line numbers."
 }
 
 func (t *ReadFileTool) Parameters() map[string]any {
@@ -26,6 +45,14 @@ func (t *ReadFileTool) Parameters() map[string]any {
 				"type":        "string",
 				"description": "The path to the file to read",
 			},
+			"offset": map[string]any{
+				"type":        "integer",
+				"description": "First line to read (1-based)",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "Maximum number of lines to read",
+			},
 		},
 		"required": []string{"path"},
 	}
@@ -42,6 +69,9 @@ func (t *ReadFileTool) Execute(ctx context.Context, params map[string]any) (stri
 		home, _ := os.UserHomeDir()
 		path = filepath.Join(home, path[1:])
 	}
+	if err := t.policy.Check(path); err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
 
 	content, err := os.ReadFile(path)
 	if err != nil {
@@ -53,8 +83,26 @@ func (t *ReadFileTool) Execute(ctx context.Context, params map[string]any) (stri
 		}
 		return fmt.Sprintf("Error reading file: %v", err), nil
 	}
+	if isBinary(content) {
+		return fmt.Sprintf("Error: %s is a binary file (%d bytes)", path, len(content)), nil
+	}
 
-	return string(content), nil
+	offset := GetInt(params, "offset", 0)
+	limit := GetInt(params, "limit", 0)
+	if offset <= 0 && limit <= 0 {
+		return string(content), nil
+	}
+	if offset < 1 {
+		offset = 1
+	}
+	out, total := numberLines(string(content), offset, limit)
+	if out == "" {
+		return fmt.Sprintf("Error: offset %d is past the end of the file (%d lines)", offset, total), nil
+	}
+	if last := offset + limit - 1; limit > 0 && last < total {
+		out += fmt.Sprintf("... (%d more lines; continue with offset=%d)\n", total-last, last+1)
+	}
+	return out, nil
 }
 
 // WriteFileTool writes content to a file.
@@ -102,6 +150,9 @@ func (t *WriteFileTool) Execute(ctx context.Context, params map[string]any) (str
 	if root != "" && !isWithin(root, path) {
 		return "Error: path outside work repo.", nil
 	}
+	if inGitDir(path) {
+		return "Error: writing git metadata (.git) is not allowed.", nil
+	}
 
 	// Create parent directories
 	dir := filepath.Dir(path)
@@ -172,6 +223,9 @@ func (t *EditFileTool) Execute(ctx context.Context, params map[string]any) (stri
 	if root != "" && !isWithin(root, path) {
 		return "Error: path outside work repo.", nil
 	}
+	if inGitDir(path) {
+		return "Error: writing git metadata (.git) is not allowed.", nil
+	}
 
 	content, err := os.ReadFile(path)
 	if err != nil {
@@ -196,11 +250,18 @@ func (t *EditFileTool) Execute(ctx context.Context, params map[string]any) (stri
 }
 
 // ListDirTool lists directory contents.
-type ListDirTool struct{}
+type ListDirTool struct {
+	policy *ReadPolicy
+}
 
 func (t *ListDirTool) Name() string { return "list_dir" }
 func (t *ListDirTool) Tier() int    { return TierReadOnly }
 
+// CheckCall refuses listings the read policy blocks.
+func (t *ListDirTool) CheckCall(params map[string]any) error {
+	return t.policy.Check(GetString(params, "path", "."))
+}
+
 func (t *ListDirTool) Description() string {
 	return "List the contents of a directory."
 }
@@ -221,6 +282,9 @@ func (t *ListDirTool) Parameters() map[string]any {
 func (t *ListDirTool) Execute(ctx context.Context, params map[string]any) (string, error) {
 	path := GetString(params, "path", ".")
 	path = expandPath(path)
+	if err := t.policy.Check(path); err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
 
 	entries, err := os.ReadDir(path)
 	if err != nil {
@@ -250,8 +314,18 @@ func (t *ListDirTool) Execute(ctx context.Context, params map[string]any) (strin
 	return result.String(), nil
 }
 
-// NewReadFileTool creates a new ReadFileTool.
-func NewReadFileTool() *ReadFileTool { return &ReadFileTool{} }
+// NewReadFileTool creates a ReadFileTool that denies KafClaw's secret
+// stores.
+func NewReadFileTool() *ReadFileTool { return NewReadFileToolWithPolicy(nil) }
+
+// NewReadFileToolWithPolicy creates a ReadFileTool with a read policy. A nil
+// policy only denies KafClaw's secret stores.
+func NewReadFileToolWithPolicy(policy *ReadPolicy) *ReadFileTool {
+	if policy == nil {
+		policy = NewReadPolicy(config.ReadToolConfig{}, nil)
+	}
+	return &ReadFileTool{policy: policy}
+}
 
 // NewWriteFileTool creates a new WriteFileTool.
 func NewWriteFileTool(workRepoGetter func() string) *WriteFileTool {
@@ -269,8 +343,17 @@ func NewEditFileTool(workRepoGetter func() string) *EditFileTool {
 	return &EditFileTool{workRepoRoot: func() string { return normalizeRoot(workRepoGetter()) }}
 }
 
-// NewListDirTool creates a new ListDirTool.
-func NewListDirTool() *ListDirTool { return &ListDirTool{} }
+// NewListDirTool creates a ListDirTool that denies KafClaw's secret stores.
+func NewListDirTool() *ListDirTool { return NewListDirToolWithPolicy(nil) }
+
+// NewListDirToolWithPolicy creates a ListDirTool with a read policy. A nil
+// policy only denies KafClaw's secret stores.
+func NewListDirToolWithPolicy(policy *ReadPolicy) *ListDirTool {
+	if policy == nil {
+		policy = NewReadPolicy(config.ReadToolConfig{}, nil)
+	}
+	return &ListDirTool{policy: policy}
+}
 
 // ResolvePathTool resolves a default path inside the work repo.
 type ResolvePathTool struct {
@@ -354,3 +437,47 @@ func isWithin(root, path string) bool {
 	}
 	return !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && rel != ".."
 }
+
+// inGitDir reports whether path lies in a .git directory, also through
+// symlinked parents. Hooks and config there run on the host with the git
+// tools, so the write tools must not create them.
+func inGitDir(path string) bool {
+	if hasGitComponent(path) {
+		return true
+	}
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return hasGitComponent(resolved)
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return false
+		}
+	}
+}
+
+func hasGitComponent(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if strings.EqualFold(part, ".git") {
+			return true
+		}
+	}
+	return false
+}
+
+// numberLines renders up to limit lines starting at line from (1-based; 0
+// means no limit) with line numbers, and returns the file's line count.
+func numberLines(content string, from, limit int) (string, int) {
+	var out strings.Builder
+	sc := bufio.NewScanner(strings.NewReader(content))
+	sc.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	n, total := 0, 0
+	for sc.Scan() {
+		n++
+		total = n
+		if n < from || (limit > 0 && n >= from+limit) {
+			continue
+		}
+		fmt.Fprintf(&out, "%6d\t%s\n", n, sc.Text())
+	}
+	return out.String(), total
+}
@@ -0,0 +1,813 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	gitTimeout       = 60 * time.Second
+	gitMaxDiffChars  = 100_000
+	gitDefaultLog    = 20
+	gitMaxLog        = 200
+	gitMaxBlameLines = 400
+)
+
+// GitCommitRecord describes a commit created by the git_commit tool.
+type GitCommitRecord struct {
+	Repo    string   `json:"repo"`
+	SHA     string   `json:"sha"`
+	Branch  string   `json:"branch"`
+	Message string   `json:"message"`
+	Files   []string `json:"files"`
+}
+
+// gitRepo runs git commands inside the work repo.
+type gitRepo struct {
+	root func() string
+}
+
+func (g gitRepo) dir() (string, error) {
+	if g.root == nil {
+		return "", fmt.Errorf("work repo not configured")
+	}
+	dir := strings.TrimSpace(g.root())
+	if dir == "" {
+		return "", fmt.Errorf("work repo not configured")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("work repo not available: %w", err)
+	}
+	return dir, nil
+}
+
+// gitSafeConfig keeps git from running repo-controlled programs: hooks and
+// the fsmonitor are configured inside the repo, which the write tools can
+// change without an approval.
+var gitSafeConfig = []string{"-c", "core.hooksPath=/dev/null", "-c", "core.fsmonitor=false"}
+
+func (g gitRepo) run(ctx context.Context, args ...string) (string, error) {
+	return g.exec(ctx, "git", args[0], append(append([]string{}, gitSafeConfig...), args...))
+}
+
+func (g gitRepo) runBin(ctx context.Context, bin string, args ...string) (string, error) {
+	return g.exec(ctx, bin, args[0], args)
+}
+
+// exec runs bin in the work repo; sub names the command in errors.
+func (g gitRepo) exec(ctx context.Context, bin, sub string, args []string) (string, error) {
+	dir, err := g.dir()
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Dir = dir
+	// Never block on credential or editor prompts.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_EDITOR=true", "GH_PROMPT_DISABLED=1")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("%s %s failed: %s", bin, sub, msg)
+	}
+	return stdout.String(), nil
+}
+
+func (g gitRepo) currentBranch(ctx context.Context) string {
+	out, err := g.run(ctx, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// validRef rejects values git would parse as options.
+func validRef(ref string) bool {
+	return ref != "" && !strings.HasPrefix(ref, "-") && !strings.ContainsAny(ref, " \t\n")
+}
+
+func jsonResult(v any) (string, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func nonEmptyLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// GitStatusTool reports the branch and changed files of the work repo.
+type GitStatusTool struct{ repo gitRepo }
+
+func NewGitStatusTool(workRepoRoot func() string) *GitStatusTool {
+	return &GitStatusTool{repo: gitRepo{root: workRepoRoot}}
+}
+
+func (t *GitStatusTool) Name() string { return "git_status" }
+func (t *GitStatusTool) Tier() int    { return TierReadOnly }
+
+func (t *GitStatusTool) Description() string {
+	return "Show the current branch, upstream tracking and changed files of the work repo."
+}
+
+func (t *GitStatusTool) Parameters() map[string]any {
+	return map[string]any{"type": "object", "properties": map[string]any{}}
+}
+
+type gitStatusFile struct {
+	Path     string `json:"path"`
+	OrigPath string `json:"origPath,omitempty"`
+	Index    string `json:"index"`
+	Worktree string `json:"worktree"`
+}
+
+type gitStatus struct {
+	Branch   string          `json:"branch"`
+	Upstream string          `json:"upstream,omitempty"`
+	Ahead    int             `json:"ahead"`
+	Behind   int             `json:"behind"`
+	Clean    bool            `json:"clean"`
+	Files    []gitStatusFile `json:"files"`
+}
+
+func (t *GitStatusTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	out, err := t.repo.run(ctx, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	return jsonResult(parseGitStatus(out))
+}
+
+// parseGitStatus parses `git status --porcelain=v2 --branch` output.
+func parseGitStatus(out string) gitStatus {
+	st := gitStatus{Files: []gitStatusFile{}}
+	for _, line := range nonEmptyLines(out) {
+		switch {
+		case strings.HasPrefix(line, "# branch.head "):
+			st.Branch = strings.TrimPrefix(line, "# branch.head ")
+		case strings.HasPrefix(line, "# branch.upstream "):
+			st.Upstream = strings.TrimPrefix(line, "# branch.upstream ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			fields := strings.Fields(strings.TrimPrefix(line, "# branch.ab "))
+			if len(fields) == 2 {
+				st.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[0], "+"))
+				st.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[1], "-"))
+			}
+		case strings.HasPrefix(line, "1 "):
+			if parts := strings.SplitN(line, " ", 9); len(parts) == 9 {
+				st.Files = append(st.Files, newGitStatusFile(parts[1], parts[8], ""))
+			}
+		case strings.HasPrefix(line, "2 "):
+			if parts := strings.SplitN(line, " ", 10); len(parts) == 10 {
+				path, orig, _ := strings.Cut(parts[9], "\t")
+				st.Files = append(st.Files, newGitStatusFile(parts[1], path, orig))
+			}
+		case strings.HasPrefix(line, "u "):
+			if parts := strings.SplitN(line, " ", 11); len(parts) == 11 {
+				st.Files = append(st.Files, newGitStatusFile("UU", parts[10], ""))
+			}
+		case strings.HasPrefix(line, "? "):
+			st.Files = append(st.Files, gitStatusFile{Path: strings.TrimPrefix(line, "? "), Index: "untracked", Worktree: "untracked"})
+		}
+	}
+	st.Clean = len(st.Files) == 0
+	return st
+}
+
+func newGitStatusFile(xy, path, orig string) gitStatusFile {
+	f := gitStatusFile{Path: path, OrigPath: orig, Index: "unmodified", Worktree: "unmodified"}
+	if len(xy) == 2 {
+		f.Index = gitStatusCode(xy[0])
+		f.Worktree = gitStatusCode(xy[1])
+	}
+	return f
+}
+
+func gitStatusCode(c byte) string {
+	switch c {
+	case 'M':
+		return "modified"
+	case 'T':
+		return "type_changed"
+	case 'A':
+		return "added"
+	case 'D':
+		return "deleted"
+	case 'R':
+		return "renamed"
+	case 'C':
+		return "copied"
+	case 'U':
+		return "unmerged"
+	default:
+		return "unmodified"
+	}
+}
+
+// GitDiffTool shows changes in the work repo.
+type GitDiffTool struct{ repo gitRepo }
+
+func NewGitDiffTool(workRepoRoot func() string) *GitDiffTool {
+	return &GitDiffTool{repo: gitRepo{root: workRepoRoot}}
+}
+
+func (t *GitDiffTool) Name() string { return "git_diff" }
+func (t *GitDiffTool) Tier() int    { return TierReadOnly }
+
+func (t *GitDiffTool) Description() string {
+	return "Show the diff of the work repo (unstaged by default, staged with staged=true, or against a ref) with per-file line counts."
+}
+
+func (t *GitDiffTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Optional file or directory to limit the diff to",
+			},
+			"staged": map[string]any{
+				"type":        "boolean",
+				"description": "Diff the index instead of the working tree",
+			},
+			"ref": map[string]any{
+				"type":        "string",
+				"description": "Optional commit, branch or range to diff against (e.g. main, HEAD~3, main...HEAD)",
+			},
+		},
+	}
+}
+
+type gitDiffFile struct {
+	Path      string `json:"path"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Binary    bool   `json:"binary,omitempty"`
+}
+
+func (t *GitDiffTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	args := []string{"diff"}
+	if GetBool(params, "staged", false) {
+		args = append(args, "--cached")
+	}
+	if ref := strings.TrimSpace(GetString(params, "ref", "")); ref != "" {
+		if !validRef(ref) {
+			return "Error: invalid ref", nil
+		}
+		args = append(args, ref)
+	}
+	var pathArgs []string
+	if path := strings.TrimSpace(GetString(params, "path", "")); path != "" {
+		pathArgs = []string{"--", path}
+	}
+
+	numstat, err := t.repo.run(ctx, append(append(append([]string{}, args...), "--numstat"), pathArgs...)...)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	diff, err := t.repo.run(ctx, append(args, pathArgs...)...)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	truncated := false
+	if len(diff) > gitMaxDiffChars {
+		diff = diff[:gitMaxDiffChars]
+		truncated = true
+	}
+	return jsonResult(map[string]any{
+		"files":     parseGitNumstat(numstat),
+		"diff":      diff,
+		"truncated": truncated,
+	})
+}
+
+func parseGitNumstat(out string) []gitDiffFile {
+	files := []gitDiffFile{}
+	for _, line := range nonEmptyLines(out) {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		f := gitDiffFile{Path: parts[2]}
+		if parts[0] == "-" && parts[1] == "-" {
+			f.Binary = true
+		} else {
+			f.Additions, _ = strconv.Atoi(parts[0])
+			f.Deletions, _ = strconv.Atoi(parts[1])
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// GitLogTool lists recent commits.
+type GitLogTool struct{ repo gitRepo }
+
+func NewGitLogTool(workRepoRoot func() string) *GitLogTool {
+	return &GitLogTool{repo: gitRepo{root: workRepoRoot}}
+}
+
+func (t *GitLogTool) Name() string { return "git_log" }
+func (t *GitLogTool) Tier() int    { return TierReadOnly }
+
+func (t *GitLogTool) Description() string {
+	return "List recent commits of the work repo, optionally for a ref or path."
+}
+
+func (t *GitLogTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "Maximum number of commits (default 20, max 200)",
+			},
+			"ref": map[string]any{
+				"type":        "string",
+				"description": "Optional branch, commit or range (default HEAD)",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Optional file or directory to limit history to",
+			},
+		},
+	}
+}
+
+type gitLogEntry struct {
+	SHA     string `json:"sha"`
+	Author  string `json:"author"`
+	Email   string `json:"email"`
+	Date    string `json:"date"`
+	Subject string `json:"subject"`
+}
+
+func (t *GitLogTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	limit := GetInt(params, "limit", gitDefaultLog)
+	if limit < 1 {
+		limit = gitDefaultLog
+	}
+	if limit > gitMaxLog {
+		limit = gitMaxLog
+	}
+	args := []string{"log", "-n", strconv.Itoa(limit), "--format=%H%x1f%an%x1f%ae%x1f%aI%x1f%s%x1e"}
+	if ref := strings.TrimSpace(GetString(params, "ref", "")); ref != "" {
+		if !validRef(ref) {
+			return "Error: invalid ref", nil
+		}
+		args = append(args, ref)
+	}
+	if path := strings.TrimSpace(GetString(params, "path", "")); path != "" {
+		args = append(args, "--", path)
+	}
+	out, err := t.repo.run(ctx, args...)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	return jsonResult(map[string]any{"commits": parseGitLog(out)})
+}
+
+func parseGitLog(out string) []gitLogEntry {
+	commits := []gitLogEntry{}
+	for _, rec := range strings.Split(out, "\x1e") {
+		rec = strings.Trim(rec, "\n")
+		fields := strings.Split(rec, "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		commits = append(commits, gitLogEntry{SHA: fields[0], Author: fields[1], Email: fields[2], Date: fields[3], Subject: fields[4]})
+	}
+	return commits
+}
+
+// GitBlameTool shows which commit last changed each line of a file.
+type GitBlameTool struct{ repo gitRepo }
+
+func NewGitBlameTool(workRepoRoot func() string) *GitBlameTool {
+	return &GitBlameTool{repo: gitRepo{root: workRepoRoot}}
+}
+
+func (t *GitBlameTool) Name() string { return "git_blame" }
+func (t *GitBlameTool) Tier() int    { return TierReadOnly }
+
+func (t *GitBlameTool) Description() string {
+	return "Show the commit, author and summary that last changed each line of a file in the work repo."
+}
+
+func (t *GitBlameTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "File path relative to the work repo",
+			},
+			"startLine": map[string]any{
+				"type":        "integer",
+				"description": "First line (1-based, default 1)",
+			},
+			"endLine": map[string]any{
+				"type":        "integer",
+				"description": "Last line (default startLine+399)",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type gitBlameLine struct {
+	Line    int    `json:"line"`
+	SHA     string `json:"sha"`
+	Author  string `json:"author"`
+	Date    string `json:"date"`
+	Summary string `json:"summary"`
+	Content string `json:"content"`
+}
+
+func (t *GitBlameTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	path := strings.TrimSpace(GetString(params, "path", ""))
+	if path == "" {
+		return "Error: path is required", nil
+	}
+	start := GetInt(params, "startLine", 1)
+	if start < 1 {
+		start = 1
+	}
+	end := GetInt(params, "endLine", start+gitMaxBlameLines-1)
+	if end < start {
+		return "Error: endLine must not be before startLine", nil
+	}
+	if end-start+1 > gitMaxBlameLines {
+		end = start + gitMaxBlameLines - 1
+	}
+	out, err := t.repo.run(ctx, "blame", "--line-porcelain", "-L", fmt.Sprintf("%d,%d", start, end), "--", path)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	return jsonResult(map[string]any{"path": path, "lines": parseGitBlame(out)})
+}
+
+// parseGitBlame parses `git blame --line-porcelain` output, where every line
+// carries its full commit header.
+func parseGitBlame(out string) []gitBlameLine {
+	lines := []gitBlameLine{}
+	var cur gitBlameLine
+	header := true
+	for _, line := range strings.Split(out, "\n") {
+		if header {
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+			cur = gitBlameLine{SHA: fields[0]}
+			cur.Line, _ = strconv.Atoi(fields[2])
+			header = false
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			cur.Content = strings.TrimPrefix(line, "\t")
+			lines = append(lines, cur)
+			header = true
+		case strings.HasPrefix(line, "author "):
+			cur.Author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			if sec, err := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64); err == nil {
+				cur.Date = time.Unix(sec, 0).UTC().Format(time.RFC3339)
+			}
+		case strings.HasPrefix(line, "summary "):
+			cur.Summary = strings.TrimPrefix(line, "summary ")
+		}
+	}
+	return lines
+}
+
+// GitBranchTool lists, creates and switches branches.
+type GitBranchTool struct{ repo gitRepo }
+
+func NewGitBranchTool(workRepoRoot func() string) *GitBranchTool {
+	return &GitBranchTool{repo: gitRepo{root: workRepoRoot}}
+}
+
+func (t *GitBranchTool) Name() string { return "git_branch" }
+func (t *GitBranchTool) Tier() int    { return TierWrite }
+
+func (t *GitBranchTool) Description() string {
+	return "List local branches, create a branch, or switch to a branch in the work repo."
+}
+
+func (t *GitBranchTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"description": "list (default), create or switch",
+				"enum":        []string{"list", "create", "switch"},
+			},
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Branch name for create/switch",
+			},
+			"from": map[string]any{
+				"type":        "string",
+				"description": "Start point for create (default HEAD)",
+			},
+			"switch": map[string]any{
+				"type":        "boolean",
+				"description": "Switch to the branch after create (default true)",
+			},
+		},
+	}
+}
+
+type gitBranch struct {
+	Name     string `json:"name"`
+	Current  bool   `json:"current"`
+	Upstream string `json:"upstream,omitempty"`
+}
+
+func (t *GitBranchTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	action := strings.ToLower(strings.TrimSpace(GetString(params, "action", "list")))
+	name := strings.TrimSpace(GetString(params, "name", ""))
+	switch action {
+	case "", "list":
+		out, err := t.repo.run(ctx, "branch", "--format=%(refname:short)%1f%(HEAD)%1f%(upstream:short)")
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err), nil
+		}
+		branches := []gitBranch{}
+		for _, line := range nonEmptyLines(out) {
+			fields := strings.Split(line, "\x1f")
+			if len(fields) != 3 {
+				continue
+			}
+			branches = append(branches, gitBranch{Name: fields[0], Current: fields[1] == "*", Upstream: fields[2]})
+		}
+		return jsonResult(map[string]any{"branches": branches})
+	case "create", "switch":
+		if !validRef(name) {
+			return "Error: valid branch name is required", nil
+		}
+		if _, err := t.repo.run(ctx, "check-ref-format", "--branch", name); err != nil {
+			return fmt.Sprintf("Error: invalid branch name %q", name), nil
+		}
+		var args []string
+		if action == "create" {
+			from := strings.TrimSpace(GetString(params, "from", ""))
+			if from != "" && !validRef(from) {
+				return "Error: invalid start point", nil
+			}
+			if GetBool(params, "switch", true) {
+				args = []string{"switch", "-c", name}
+			} else {
+				args = []string{"branch", name}
+			}
+			if from != "" {
+				args = append(args, from)
+			}
+		} else {
+			args = []string{"switch", name}
+		}
+		if _, err := t.repo.run(ctx, args...); err != nil {
+			return fmt.Sprintf("Error: %v", err), nil
+		}
+		return jsonResult(map[string]any{"action": action, "branch": name, "current": t.repo.currentBranch(ctx)})
+	default:
+		return fmt.Sprintf("Error: unknown action %q", action), nil
+	}
+}
+
+// GitCommitTool stages and commits changes in the work repo.
+type GitCommitTool struct {
+	repo     gitRepo
+	onCommit func(context.Context, GitCommitRecord)
+}
+
+// NewGitCommitTool creates the commit tool. onCommit, if set, is called
+// after every successful commit so it can be linked to the current trace.
+func NewGitCommitTool(workRepoRoot func() string, onCommit func(context.Context, GitCommitRecord)) *GitCommitTool {
+	return &GitCommitTool{repo: gitRepo{root: workRepoRoot}, onCommit: onCommit}
+}
+
+func (t *GitCommitTool) Name() string { return "git_commit" }
+func (t *GitCommitTool) Tier() int    { return TierWrite }
+
+func (t *GitCommitTool) Description() string {
+	return "Stage and commit changes in the work repo. Stages all changes unless paths are given."
+}
+
+func (t *GitCommitTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{
+				"type":        "string",
+				"description": "Commit message",
+			},
+			"paths": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Optional paths to stage; all changes are staged when omitted",
+			},
+		},
+		"required": []string{"message"},
+	}
+}
+
+func (t *GitCommitTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	message := strings.TrimSpace(GetString(params, "message", ""))
+	if message == "" {
+		return "Error: message is required", nil
+	}
+	addArgs := []string{"add", "-A"}
+	if raw, ok := params["paths"].([]any); ok && len(raw) > 0 {
+		addArgs = append(addArgs, "--")
+		for _, p := range raw {
+			if s, ok := p.(string); ok && strings.TrimSpace(s) != "" {
+				addArgs = append(addArgs, strings.TrimSpace(s))
+			}
+		}
+	}
+	if _, err := t.repo.run(ctx, addArgs...); err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	staged, err := t.repo.run(ctx, "diff", "--cached", "--name-only")
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	files := nonEmptyLines(staged)
+	if len(files) == 0 {
+		return "Error: nothing to commit", nil
+	}
+	if _, err := t.repo.run(ctx, "commit", "--no-verify", "-m", message); err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	sha, err := t.repo.run(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	dir, _ := t.repo.dir()
+	rec := GitCommitRecord{
+		Repo:    dir,
+		SHA:     strings.TrimSpace(sha),
+		Branch:  t.repo.currentBranch(ctx),
+		Message: message,
+		Files:   files,
+	}
+	if t.onCommit != nil {
+		t.onCommit(ctx, rec)
+	}
+	return jsonResult(rec)
+}
+
+// GitPushTool pushes the current branch to a remote.
+type GitPushTool struct{ repo gitRepo }
+
+func NewGitPushTool(workRepoRoot func() string) *GitPushTool {
+	return &GitPushTool{repo: gitRepo{root: workRepoRoot}}
+}
+
+func (t *GitPushTool) Name() string { return "git_push" }
+func (t *GitPushTool) Tier() int    { return TierHighRisk }
+
+func (t *GitPushTool) Description() string {
+	return "Push a branch of the work repo to a remote (never forced). Requires approval."
+}
+
+func (t *GitPushTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"remote": map[string]any{
+				"type":        "string",
+				"description": "Remote name (default origin)",
+			},
+			"branch": map[string]any{
+				"type":        "string",
+				"description": "Branch to push (default current branch)",
+			},
+			"setUpstream": map[string]any{
+				"type":        "boolean",
+				"description": "Set the remote branch as upstream",
+			},
+		},
+	}
+}
+
+func (t *GitPushTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	remote := strings.TrimSpace(GetString(params, "remote", "origin"))
+	branch := strings.TrimSpace(GetString(params, "branch", ""))
+	if branch == "" {
+		branch = t.repo.currentBranch(ctx)
+	}
+	if !validRef(remote) || !validRef(branch) || branch == "HEAD" {
+		return "Error: valid remote and branch are required", nil
+	}
+	args := []string{"push"}
+	if GetBool(params, "setUpstream", false) {
+		args = append(args, "--set-upstream")
+	}
+	args = append(args, remote, branch)
+	if _, err := t.repo.run(ctx, args...); err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	sha, _ := t.repo.run(ctx, "rev-parse", branch)
+	return jsonResult(map[string]any{"remote": remote, "branch": branch, "sha": strings.TrimSpace(sha)})
+}
+
+// GitPRTool opens a pull request with the GitHub CLI.
+type GitPRTool struct{ repo gitRepo }
+
+func NewGitPRTool(workRepoRoot func() string) *GitPRTool {
+	return &GitPRTool{repo: gitRepo{root: workRepoRoot}}
+}
+
+func (t *GitPRTool) Name() string { return "git_pr" }
+func (t *GitPRTool) Tier() int    { return TierHighRisk }
+
+func (t *GitPRTool) Description() string {
+	return "Open a pull request for the work repo using the GitHub CLI (gh). Requires approval."
+}
+
+func (t *GitPRTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"title": map[string]any{
+				"type":        "string",
+				"description": "Pull request title",
+			},
+			"body": map[string]any{
+				"type":        "string",
+				"description": "Pull request description",
+			},
+			"base": map[string]any{
+				"type":        "string",
+				"description": "Base branch (default repository default branch)",
+			},
+			"head": map[string]any{
+				"type":        "string",
+				"description": "Head branch (default current branch)",
+			},
+			"draft": map[string]any{
+				"type":        "boolean",
+				"description": "Open as draft",
+			},
+		},
+		"required": []string{"title"},
+	}
+}
+
+func (t *GitPRTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	title := strings.TrimSpace(GetString(params, "title", ""))
+	if title == "" {
+		return "Error: title is required", nil
+	}
+	args := []string{"pr", "create", "--title", title, "--body", GetString(params, "body", "")}
+	for _, key := range []string{"base", "head"} {
+		if v := strings.TrimSpace(GetString(params, key, "")); v != "" {
+			if !validRef(v) {
+				return fmt.Sprintf("Error: invalid %s branch", key), nil
+			}
+			args = append(args, "--"+key, v)
+		}
+	}
+	if GetBool(params, "draft", false) {
+		args = append(args, "--draft")
+	}
+	out, err := t.repo.runBin(ctx, "gh", args...)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	lines := nonEmptyLines(out)
+	url := ""
+	if len(lines) > 0 {
+		url = strings.TrimSpace(lines[len(lines)-1])
+	}
+	return jsonResult(map[string]any{"url": url, "title": title})
+}
@@ -0,0 +1,238 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func initGitTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.name", "Test User"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	return dir
+}
+
+func decodeToolJSON(t *testing.T, out string, v any) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(out), v); err != nil {
+		t.Fatalf("expected JSON result, got %q: %v", out, err)
+	}
+}
+
+func TestGitTools_CommitFlow(t *testing.T) {
+	dir := initGitTestRepo(t)
+	repo := func() string { return dir }
+	ctx := context.Background()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _ := NewGitStatusTool(repo).Execute(ctx, nil)
+	var st gitStatus
+	decodeToolJSON(t, out, &st)
+	if st.Branch != "main" || st.Clean || len(st.Files) != 1 || st.Files[0].Path != "a.txt" || st.Files[0].Worktree != "untracked" {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+
+	var recorded []GitCommitRecord
+	commit := NewGitCommitTool(repo, func(_ context.Context, rec GitCommitRecord) {
+		recorded = append(recorded, rec)
+	})
+	out, _ = commit.Execute(ctx, map[string]any{"message": "add a"})
+	var rec GitCommitRecord
+	decodeToolJSON(t, out, &rec)
+	if len(rec.SHA) != 40 || rec.Branch != "main" || len(rec.Files) != 1 || rec.Files[0] != "a.txt" {
+		t.Fatalf("unexpected commit record: %+v", rec)
+	}
+	if len(recorded) != 1 || recorded[0].SHA != rec.SHA {
+		t.Fatalf("expected onCommit callback with %s, got %+v", rec.SHA, recorded)
+	}
+	if out, _ := commit.Execute(ctx, map[string]any{"message": "empty"}); out != "Error: nothing to commit" {
+		t.Fatalf("expected nothing to commit, got %q", out)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n2\nthree\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, _ = NewGitDiffTool(repo).Execute(ctx, map[string]any{})
+	var diff struct {
+		Files []gitDiffFile `json:"files"`
+		Diff  string        `json:"diff"`
+	}
+	decodeToolJSON(t, out, &diff)
+	if len(diff.Files) != 1 || diff.Files[0].Additions != 2 || diff.Files[0].Deletions != 1 || !strings.Contains(diff.Diff, "+three") {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+
+	out, _ = NewGitLogTool(repo).Execute(ctx, map[string]any{"limit": float64(5)})
+	var log struct {
+		Commits []gitLogEntry `json:"commits"`
+	}
+	decodeToolJSON(t, out, &log)
+	if len(log.Commits) != 1 || log.Commits[0].SHA != rec.SHA || log.Commits[0].Subject != "add a" || log.Commits[0].Author != "Test User" {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+
+	out, _ = NewGitBlameTool(repo).Execute(ctx, map[string]any{"path": "a.txt", "startLine": float64(1), "endLine": float64(1)})
+	var blame struct {
+		Lines []gitBlameLine `json:"lines"`
+	}
+	decodeToolJSON(t, out, &blame)
+	if len(blame.Lines) != 1 || blame.Lines[0].Line != 1 || blame.Lines[0].Content != "one" || blame.Lines[0].SHA != rec.SHA {
+		t.Fatalf("unexpected blame: %+v", blame)
+	}
+}
+
+func TestGitTools_DoNotRunRepoHooks(t *testing.T) {
+	dir := initGitTestRepo(t)
+	repo := func() string { return dir }
+	ctx := context.Background()
+	marker := filepath.Join(t.TempDir(), "ran")
+	script := "#!/bin/sh\ntouch " + marker + "\n"
+	for _, hook := range []string{"pre-commit", "commit-msg", "post-commit"} {
+		if err := os.WriteFile(filepath.Join(dir, ".git", "hooks", hook), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fsmonitor := filepath.Join(dir, "fsmonitor.sh")
+	if err := os.WriteFile(fsmonitor, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("git", "config", "core.fsmonitor", fsmonitor)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git config: %v: %s", err, out)
+	}
+
+	if out, _ := NewGitStatusTool(repo).Execute(ctx, nil); strings.HasPrefix(out, "Error") {
+		t.Fatalf("status: %s", out)
+	}
+	out, _ := NewGitCommitTool(repo, nil).Execute(ctx, map[string]any{"message": "add files"})
+	var rec GitCommitRecord
+	decodeToolJSON(t, out, &rec)
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatalf("a repo hook or fsmonitor ran on the host")
+	}
+}
+
+func TestWriteToolsRefuseGitMetadata(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".git", "hooks"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, ".git"), filepath.Join(dir, "meta")); err != nil {
+		t.Fatal(err)
+	}
+	repo := func() string { return dir }
+	ctx := context.Background()
+	for _, path := range []string{
+		filepath.Join(dir, ".git", "hooks", "pre-commit"),
+		filepath.Join(dir, ".GIT", "config"),
+		filepath.Join(dir, "meta", "hooks", "pre-commit"),
+	} {
+		out, _ := NewWriteFileTool(repo).Execute(ctx, map[string]any{"path": path, "content": "#!/bin/sh\n"})
+		if !strings.Contains(out, "git metadata") {
+			t.Errorf("write_file %s: %s", path, out)
+		}
+	}
+	patch := "--- /dev/null\n+++ b/.git/hooks/post-commit\n@@ -0,0 +1 @@\n+#!/bin/sh\n"
+	if out, _ := NewApplyPatchTool(repo).Execute(ctx, map[string]any{"patch": patch}); !strings.Contains(out, "git metadata") {
+		t.Errorf("apply_patch: %s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git", "hooks", "post-commit")); err == nil {
+		t.Fatalf("apply_patch wrote a hook")
+	}
+}
+
+func TestGitBranchTool(t *testing.T) {
+	dir := initGitTestRepo(t)
+	repo := func() string { return dir }
+	ctx := context.Background()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := NewGitCommitTool(repo, nil).Execute(ctx, map[string]any{"message": "init"}); strings.HasPrefix(out, "Error") {
+		t.Fatal(out)
+	}
+
+	tool := NewGitBranchTool(repo)
+	out, _ := tool.Execute(ctx, map[string]any{"action": "create", "name": "feature/x"})
+	if !strings.Contains(out, `"current":"feature/x"`) {
+		t.Fatalf("expected switch to new branch, got %q", out)
+	}
+	out, _ = tool.Execute(ctx, map[string]any{})
+	var list struct {
+		Branches []gitBranch `json:"branches"`
+	}
+	decodeToolJSON(t, out, &list)
+	if len(list.Branches) != 2 {
+		t.Fatalf("expected two branches, got %+v", list)
+	}
+	for _, b := range list.Branches {
+		if b.Current != (b.Name == "feature/x") {
+			t.Fatalf("unexpected current flag: %+v", list)
+		}
+	}
+	for _, name := range []string{"--force", "bad..name"} {
+		if out, _ := tool.Execute(ctx, map[string]any{"action": "switch", "name": name}); !strings.HasPrefix(out, "Error") {
+			t.Fatalf("expected rejection of %q, got %q", name, out)
+		}
+	}
+}
+
+func TestGitTools_Tiers(t *testing.T) {
+	repo := func() string { return "" }
+	cases := map[Tool]int{
+		NewGitStatusTool(repo):      TierReadOnly,
+		NewGitDiffTool(repo):        TierReadOnly,
+		NewGitLogTool(repo):         TierReadOnly,
+		NewGitBlameTool(repo):       TierReadOnly,
+		NewGitBranchTool(repo):      TierWrite,
+		NewGitCommitTool(repo, nil): TierWrite,
+		NewGitPushTool(repo):        TierHighRisk,
+		NewGitPRTool(repo):          TierHighRisk,
+	}
+	for tool, want := range cases {
+		if got := ToolTier(tool); got != want {
+			t.Fatalf("%s: expected tier %d, got %d", tool.Name(), want, got)
+		}
+	}
+	if out, _ := NewGitStatusTool(repo).Execute(context.Background(), nil); out != "Error: work repo not configured" {
+		t.Fatalf("expected missing repo error, got %q", out)
+	}
+}
+
+func TestParseGitStatusRenamedAndAhead(t *testing.T) {
+	out := "# branch.oid abc\n# branch.head dev\n# branch.upstream origin/dev\n# branch.ab +2 -1\n" +
+		"2 R. N... 100644 100644 100644 aaa bbb R100 new name.go\told.go\n" +
+		"1 .M N... 100644 100644 100644 aaa aaa main.go\n"
+	st := parseGitStatus(out)
+	if st.Branch != "dev" || st.Upstream != "origin/dev" || st.Ahead != 2 || st.Behind != 1 {
+		t.Fatalf("unexpected branch info: %+v", st)
+	}
+	if len(st.Files) != 2 || st.Files[0].Path != "new name.go" || st.Files[0].OrigPath != "old.go" || st.Files[0].Index != "renamed" {
+		t.Fatalf("unexpected rename entry: %+v", st.Files)
+	}
+	if st.Files[1].Index != "unmodified" || st.Files[1].Worktree != "modified" {
+		t.Fatalf("unexpected modify entry: %+v", st.Files[1])
+	}
+}
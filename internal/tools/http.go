@@ -0,0 +1,376 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/provider/middleware"
+	"github.com/KafClaw/KafClaw/internal/skills"
+)
+
+const (
+	httpDefaultMaxResponseBytes = 256 * 1024
+	httpDefaultTimeout          = 30 * time.Second
+	httpMaxRedirects            = 5
+	httpRedactedCredential      = "[REDACTED:CREDENTIAL]"
+)
+
+// httpSecretTypes are the detector patterns applied to responses; PII is
+// left alone because API results legitimately contain it.
+var httpSecretTypes = []string{"api_key", "bearer_token", "private_key", "password_literal"}
+
+// HTTPRequestTool calls HTTP APIs on allow-listed hosts. Credentials are
+// referenced by name and injected from the tomb or the OAuth store; their
+// values never reach the model, and secrets echoed back are redacted.
+type HTTPRequestTool struct {
+	cfg      config.HTTPToolConfig
+	detector *middleware.Detector
+	secret   func(name string) (string, error)
+	oauth    func(provider, profile string) (string, error)
+}
+
+// NewHTTPRequestTool creates http_request with the tools.http settings.
+func NewHTTPRequestTool(cfg config.HTTPToolConfig) *HTTPRequestTool {
+	return &HTTPRequestTool{
+		cfg:      cfg,
+		detector: middleware.NewDetector(nil, httpSecretTypes, nil),
+		secret:   lookupTombSecret,
+		oauth:    lookupOAuthToken,
+	}
+}
+
+func (t *HTTPRequestTool) Name() string { return "http_request" }
+
+// Tier is the highest tier a call can have; see CallTier.
+func (t *HTTPRequestTool) Tier() int { return TierHighRisk }
+
+// CallTier makes reads tier 0 and mutating methods tier 2.
+func (t *HTTPRequestTool) CallTier(params map[string]any) int {
+	switch httpMethod(params) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return TierReadOnly
+	default:
+		return TierHighRisk
+	}
+}
+
+func (t *HTTPRequestTool) Description() string {
+	return "Make an HTTP request to an allow-listed API host. Reference stored credentials by name with `credential`; never put tokens in headers or URLs. GET/HEAD are read-only, other methods need approval."
+}
+
+func (t *HTTPRequestTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"method": map[string]any{
+				"type":        "string",
+				"enum":        []string{"GET", "HEAD", "OPTIONS", "POST", "PUT", "PATCH", "DELETE"},
+				"description": "HTTP method (default GET)",
+			},
+			"url": map[string]any{
+				"type":        "string",
+				"description": "Absolute URL; the host must be in tools.http.allowedHosts",
+			},
+			"headers": map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"type": "string"},
+				"description":          "Extra request headers",
+			},
+			"body": map[string]any{
+				"type":        "string",
+				"description": "Raw request body",
+			},
+			"json": map[string]any{
+				"description": "Request body encoded as JSON (sets Content-Type: application/json)",
+			},
+			"credential": map[string]any{
+				"type":        "string",
+				"description": "Name of a configured credential (tools.http.credentials) to authenticate with",
+			},
+		},
+		"required": []string{"url"},
+	}
+}
+
+// httpResult is the JSON returned to the model.
+type httpResult struct {
+	Status    int               `json:"status"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      string            `json:"body,omitempty"`
+	Bytes     int               `json:"bytes"`
+	Truncated bool              `json:"truncated,omitempty"`
+	Binary    bool              `json:"binary,omitempty"`
+}
+
+// httpAuth is a resolved credential for one request.
+type httpAuth struct {
+	header string
+	value  string
+	secret string
+	hosts  []string
+}
+
+func (t *HTTPRequestTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	method := httpMethod(params)
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return fmt.Sprintf("Error: unsupported method %s", method), nil
+	}
+	target, err := url.Parse(strings.TrimSpace(GetString(params, "url", "")))
+	if err != nil || target.Host == "" {
+		return "Error: url must be absolute (https://host/path)", nil
+	}
+	if err := t.checkURL(target); err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+
+	var body io.Reader
+	contentType := ""
+	if v, ok := params["json"]; ok && v != nil {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("Error: encode json body: %v", err), nil
+		}
+		body = bytes.NewReader(data)
+		contentType = "application/json"
+	} else if raw := GetString(params, "body", ""); raw != "" {
+		body = strings.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	if headers, ok := params["headers"].(map[string]any); ok {
+		for k, v := range headers {
+			if s, ok := v.(string); ok {
+				req.Header.Set(k, s)
+			}
+		}
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	var auth *httpAuth
+	if name := strings.TrimSpace(GetString(params, "credential", "")); name != "" {
+		auth, err = t.resolveCredential(name, target.Hostname())
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err), nil
+		}
+		req.Header.Set(auth.header, auth.value)
+	}
+
+	resp, err := t.client(auth).Do(req)
+	if err != nil {
+		return fmt.Sprintf("Error: %s", t.redact(err.Error(), auth)), nil
+	}
+	defer resp.Body.Close()
+
+	limit := t.cfg.MaxResponseBytes
+	if limit <= 0 {
+		limit = httpDefaultMaxResponseBytes
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return fmt.Sprintf("Error: read response: %v", err), nil
+	}
+	result := httpResult{
+		Status:  resp.StatusCode,
+		URL:     t.redact(resp.Request.URL.String(), auth),
+		Headers: t.responseHeaders(resp.Header, auth),
+	}
+	if len(data) > limit {
+		data = data[:limit]
+		result.Truncated = true
+	}
+	result.Bytes = len(data)
+	if isBinary(data) {
+		result.Binary = true
+	} else {
+		result.Body = t.redact(string(data), auth)
+	}
+	return jsonResult(result)
+}
+
+func httpMethod(params map[string]any) string {
+	method := strings.ToUpper(strings.TrimSpace(GetString(params, "method", "")))
+	if method == "" {
+		return http.MethodGet
+	}
+	return method
+}
+
+// checkURL enforces the scheme and host allowlist.
+func (t *HTTPRequestTool) checkURL(u *url.URL) error {
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !t.cfg.AllowHTTP {
+			return errors.New("plain http is disabled (tools.http.allowHttp)")
+		}
+	default:
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if u.User != nil {
+		return errors.New("credentials in the URL are not allowed; use credential")
+	}
+	if !matchHost(t.cfg.AllowedHosts, u.Hostname()) {
+		return fmt.Errorf("host %s is not in tools.http.allowedHosts", u.Hostname())
+	}
+	return nil
+}
+
+func (t *HTTPRequestTool) resolveCredential(name, host string) (*httpAuth, error) {
+	cred, ok := t.cfg.Credentials[name]
+	if !ok {
+		names := make([]string, 0, len(t.cfg.Credentials))
+		for n := range t.cfg.Credentials {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown credential %q (configured: %s)", name, strings.Join(names, ", "))
+	}
+	hosts := cred.Hosts
+	if len(hosts) == 0 {
+		hosts = t.cfg.AllowedHosts
+	}
+	if !matchHost(hosts, host) {
+		return nil, fmt.Errorf("credential %q may not be sent to %s", name, host)
+	}
+
+	var secret string
+	var err error
+	switch {
+	case cred.Secret != "" && cred.OAuthProvider == "":
+		secret, err = t.secret(cred.Secret)
+	case cred.OAuthProvider != "" && cred.Secret == "":
+		profile := cred.OAuthProfile
+		if profile == "" {
+			profile = "default"
+		}
+		secret, err = t.oauth(cred.OAuthProvider, profile)
+	default:
+		return nil, fmt.Errorf("credential %q must set exactly one of secret or oauthProvider", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("credential %q: %v", name, err)
+	}
+	if strings.TrimSpace(secret) == "" {
+		return nil, fmt.Errorf("credential %q is empty", name)
+	}
+
+	header := cred.Header
+	if header == "" {
+		header = "Authorization"
+	}
+	scheme := cred.Scheme
+	if scheme == "" && strings.EqualFold(header, "Authorization") {
+		scheme = "Bearer"
+	}
+	value := secret
+	if scheme != "" {
+		value = scheme + " " + secret
+	}
+	return &httpAuth{header: header, value: value, secret: secret, hosts: hosts}, nil
+}
+
+// client follows redirects only to allowed hosts and drops the credential
+// when a redirect leaves the credential's hosts.
+func (t *HTTPRequestTool) client(auth *httpAuth) *http.Client {
+	timeout := time.Duration(t.cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = httpDefaultTimeout
+	}
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= httpMaxRedirects {
+				return errors.New("too many redirects")
+			}
+			if err := t.checkURL(req.URL); err != nil {
+				return fmt.Errorf("redirect blocked: %w", err)
+			}
+			if auth != nil && !matchHost(auth.hosts, req.URL.Hostname()) {
+				req.Header.Del(auth.header)
+			}
+			return nil
+		},
+	}
+}
+
+func (t *HTTPRequestTool) responseHeaders(h http.Header, auth *httpAuth) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if strings.EqualFold(k, "Set-Cookie") {
+			continue
+		}
+		out[k] = t.redact(strings.Join(v, ", "), auth)
+	}
+	return out
+}
+
+// redact removes the injected credential and anything the secret detector
+// recognizes.
+func (t *HTTPRequestTool) redact(s string, auth *httpAuth) string {
+	if auth != nil {
+		s = strings.ReplaceAll(s, auth.value, httpRedactedCredential)
+		s = strings.ReplaceAll(s, auth.secret, httpRedactedCredential)
+	}
+	return t.detector.Redact(s)
+}
+
+// matchHost reports whether host matches one of the patterns: an exact
+// name, "*.domain" for its subdomains, or "*" for any host.
+func matchHost(patterns []string, host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return false
+	}
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		switch {
+		case p == "*":
+			return true
+		case strings.HasPrefix(p, "*."):
+			if strings.HasSuffix(host, p[1:]) {
+				return true
+			}
+		case p == host:
+			return true
+		}
+	}
+	return false
+}
+
+func lookupTombSecret(name string) (string, error) {
+	kv, err := skills.LoadEnvSecretsFromLocalTomb()
+	if err != nil {
+		return "", err
+	}
+	v, ok := kv[name]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found in tomb", name)
+	}
+	return v, nil
+}
+
+func lookupOAuthToken(provider, profile string) (string, error) {
+	token, err := skills.GetOAuthAccessToken(skills.OAuthProvider(provider), profile)
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
@@ -0,0 +1,320 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/knowledge"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// knowledgeFact is a fact as returned to the model.
+type knowledgeFact struct {
+	FactID    string `json:"factId"`
+	Group     string `json:"group"`
+	Subject   string `json:"subject"`
+	Predicate string `json:"predicate"`
+	Object    string `json:"object"`
+	Version   int    `json:"version"`
+	Source    string `json:"source,omitempty"`
+	UpdatedAt string `json:"updatedAt,omitempty"`
+}
+
+// KnowledgeQueryTool searches the facts agreed by the knowledge group.
+type KnowledgeQueryTool struct {
+	timeline *timeline.TimelineService
+	group    string
+}
+
+// NewKnowledgeQueryTool creates the knowledge_query tool. group is the
+// default knowledge group.
+func NewKnowledgeQueryTool(tl *timeline.TimelineService, group string) *KnowledgeQueryTool {
+	return &KnowledgeQueryTool{timeline: tl, group: strings.TrimSpace(group)}
+}
+
+func (t *KnowledgeQueryTool) Name() string { return "knowledge_query" }
+func (t *KnowledgeQueryTool) Tier() int    { return TierReadOnly }
+
+func (t *KnowledgeQueryTool) Description() string {
+	return "Query the facts agreed by the agent group (subject / predicate / object, e.g. \"payments-api\" \"owned_by\" \"team-billing\"). " +
+		"Filter by exact subject or predicate, or by text found anywhere in a fact."
+}
+
+func (t *KnowledgeQueryTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"subject": map[string]any{
+				"type":        "string",
+				"description": "Exact subject (case-insensitive)",
+			},
+			"predicate": map[string]any{
+				"type":        "string",
+				"description": "Exact predicate (case-insensitive)",
+			},
+			"text": map[string]any{
+				"type":        "string",
+				"description": "Text to find in subject, predicate or object",
+			},
+			"group": map[string]any{
+				"type":        "string",
+				"description": "Knowledge group (default: the configured group)",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "Maximum facts to return (default 20)",
+			},
+		},
+	}
+}
+
+func (t *KnowledgeQueryTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	group := strings.TrimSpace(GetString(params, "group", ""))
+	if group == "" {
+		group = t.group
+	}
+	limit := GetInt(params, "limit", 20)
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	facts, err := t.timeline.SearchKnowledgeFacts(timeline.KnowledgeFactQuery{
+		Group:     group,
+		Subject:   GetString(params, "subject", ""),
+		Predicate: GetString(params, "predicate", ""),
+		Text:      GetString(params, "text", ""),
+		Limit:     limit,
+	})
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	out := make([]knowledgeFact, 0, len(facts))
+	for _, f := range facts {
+		out = append(out, describeKnowledgeFact(f))
+	}
+	return jsonResult(map[string]any{"group": group, "count": len(out), "facts": out})
+}
+
+func describeKnowledgeFact(f timeline.KnowledgeFactRecord) knowledgeFact {
+	out := knowledgeFact{
+		FactID:    f.FactID,
+		Group:     f.GroupName,
+		Subject:   f.Subject,
+		Predicate: f.Predicate,
+		Object:    f.Object,
+		Version:   f.Version,
+		Source:    f.Source,
+	}
+	if !f.UpdatedAt.IsZero() {
+		out.UpdatedAt = f.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	return out
+}
+
+// KnowledgeProposeOptions configures the knowledge_propose tool.
+type KnowledgeProposeOptions struct {
+	// Group is the knowledge group facts are proposed to.
+	Group string
+	// Governance sends facts through proposal and vote; otherwise they are
+	// applied directly.
+	Governance bool
+	ClawID     string
+	InstanceID string
+	// Publish, when set, shares the proposal or fact envelope with the
+	// group.
+	Publish func(env knowledge.Envelope) error
+}
+
+// KnowledgeProposeTool proposes a new or updated fact to the group.
+type KnowledgeProposeTool struct {
+	timeline *timeline.TimelineService
+	opts     KnowledgeProposeOptions
+}
+
+// NewKnowledgeProposeTool creates the knowledge_propose tool.
+func NewKnowledgeProposeTool(tl *timeline.TimelineService, opts KnowledgeProposeOptions) *KnowledgeProposeTool {
+	opts.Group = strings.TrimSpace(opts.Group)
+	return &KnowledgeProposeTool{timeline: tl, opts: opts}
+}
+
+func (t *KnowledgeProposeTool) Name() string { return "knowledge_propose" }
+func (t *KnowledgeProposeTool) Tier() int    { return TierWrite }
+
+func (t *KnowledgeProposeTool) Description() string {
+	if t.opts.Governance {
+		return "Propose a fact (subject / predicate / object) to the agent group. The group votes on it; once approved it replaces the current value of subject+predicate. Only propose facts that are stable and useful to other agents."
+	}
+	return "Record a fact (subject / predicate / object) in the agent group's shared knowledge. It replaces the current value of subject+predicate. Only record facts that are stable and useful to other agents."
+}
+
+func (t *KnowledgeProposeTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"subject": map[string]any{
+				"type":        "string",
+				"description": "What the fact is about, e.g. \"payments-api\"",
+			},
+			"predicate": map[string]any{
+				"type":        "string",
+				"description": "The property, e.g. \"owned_by\"",
+			},
+			"object": map[string]any{
+				"type":        "string",
+				"description": "The value, e.g. \"team-billing\"",
+			},
+			"title": map[string]any{
+				"type":        "string",
+				"description": "Short proposal title (optional)",
+			},
+			"tags": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Tags, e.g. [\"ops\"]",
+			},
+		},
+		"required": []string{"subject", "predicate", "object"},
+	}
+}
+
+func (t *KnowledgeProposeTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	fact := knowledge.ProposedFact{
+		Subject:   strings.TrimSpace(GetString(params, "subject", "")),
+		Predicate: strings.TrimSpace(GetString(params, "predicate", "")),
+		Object:    strings.TrimSpace(GetString(params, "object", "")),
+	}
+	if err := fact.Validate(); err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	if t.opts.Group == "" {
+		return "Error: knowledge group is not configured", nil
+	}
+	if t.opts.ClawID == "" || t.opts.InstanceID == "" {
+		return "Error: node.clawId and node.instanceId must be configured", nil
+	}
+	tags := knowledgeTags(params["tags"])
+	if t.opts.Governance {
+		return t.propose(fact, strings.TrimSpace(GetString(params, "title", "")), tags)
+	}
+	return t.record(fact, tags)
+}
+
+// propose stores a pending proposal carrying the fact.
+func (t *KnowledgeProposeTool) propose(fact knowledge.ProposedFact, title string, tags []string) (string, error) {
+	proposalID := "kp-" + newKnowledgeID()
+	tagsJSON, _ := json.Marshal(tags)
+	factJSON, _ := json.Marshal(fact)
+	if err := t.timeline.CreateKnowledgeProposal(&timeline.KnowledgeProposalRecord{
+		ProposalID:         proposalID,
+		GroupName:          t.opts.Group,
+		Title:              title,
+		Statement:          fact.Statement(),
+		Tags:               string(tagsJSON),
+		ProposerClawID:     t.opts.ClawID,
+		ProposerInstanceID: t.opts.InstanceID,
+		Status:             knowledge.VoteStatusPending,
+		Fact:               string(factJSON),
+	}); err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	published, publishErr := t.publish(knowledge.TypeProposal, "knowledge:proposal:"+proposalID, knowledge.ProposalPayload{
+		ProposalID: proposalID,
+		Group:      t.opts.Group,
+		Title:      title,
+		Statement:  fact.Statement(),
+		Tags:       tags,
+		Fact:       &fact,
+	})
+	out := map[string]any{
+		"status":     knowledge.VoteStatusPending,
+		"proposalId": proposalID,
+		"factId":     knowledge.FactID(t.opts.Group, fact.Subject, fact.Predicate),
+		"published":  published,
+	}
+	if publishErr != "" {
+		out["publishError"] = publishErr
+	}
+	return jsonResult(out)
+}
+
+// record applies the fact directly (governance off).
+func (t *KnowledgeProposeTool) record(fact knowledge.ProposedFact, tags []string) (string, error) {
+	p, err := knowledge.NextFact(t.timeline, t.opts.Group, fact, "agent:"+t.opts.ClawID)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	p.Tags = tags
+	result, err := knowledge.ApplyFact(t.timeline, p)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	out := map[string]any{
+		"status":  result.Status,
+		"factId":  p.FactID,
+		"version": p.Version,
+	}
+	if result.Status != knowledge.FactApplyAccepted {
+		out["reason"] = result.Reason
+		return jsonResult(out)
+	}
+	p.PublishedAt = time.Now().UTC().Format(time.RFC3339)
+	published, publishErr := t.publish(knowledge.TypeFact, fmt.Sprintf("knowledge:fact:%s:v%d", p.FactID, p.Version), p)
+	out["published"] = published
+	if publishErr != "" {
+		out["publishError"] = publishErr
+	}
+	return jsonResult(out)
+}
+
+// publish shares an envelope with the group. The local write stands when
+// publishing fails; the error is reported to the model.
+func (t *KnowledgeProposeTool) publish(typ, idempotencyKey string, payload any) (bool, string) {
+	if t.opts.Publish == nil {
+		return false, ""
+	}
+	err := t.opts.Publish(knowledge.Envelope{
+		SchemaVersion:  knowledge.CurrentSchemaVersion,
+		Type:           typ,
+		TraceID:        "knowledge-" + newKnowledgeID(),
+		Timestamp:      time.Now(),
+		IdempotencyKey: idempotencyKey,
+		ClawID:         t.opts.ClawID,
+		InstanceID:     t.opts.InstanceID,
+		Payload:        payload,
+	})
+	if err != nil {
+		return false, err.Error()
+	}
+	return true, ""
+}
+
+func knowledgeTags(raw any) []string {
+	out := []string{}
+	switch v := raw.(type) {
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+	case string:
+		for _, s := range strings.Split(v, ",") {
+			if strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+	}
+	return out
+}
+
+func newKnowledgeID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err == nil {
+		return hex.EncodeToString(b[:])
+	}
+	return fmt.Sprintf("%d", time.Now().UnixNano())
+}
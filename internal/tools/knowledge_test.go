@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/knowledge"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func newKnowledgeTimeline(t *testing.T) *timeline.TimelineService {
+	t.Helper()
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	t.Cleanup(func() { _ = tl.Close() })
+	return tl
+}
+
+func TestKnowledgeProposeDirectThenQuery(t *testing.T) {
+	tl := newKnowledgeTimeline(t)
+	var published []knowledge.Envelope
+	propose := NewKnowledgeProposeTool(tl, KnowledgeProposeOptions{
+		Group: "g1", ClawID: "claw-a", InstanceID: "inst-a",
+		Publish: func(env knowledge.Envelope) error {
+			published = append(published, env)
+			return nil
+		},
+	})
+	query := NewKnowledgeQueryTool(tl, "g1")
+	ctx := context.Background()
+
+	if ToolCallTier(propose, nil) != TierWrite || ToolCallTier(query, nil) != TierReadOnly {
+		t.Fatal("unexpected tool tiers")
+	}
+	if out, _ := propose.Execute(ctx, map[string]any{"subject": "payments-api", "predicate": "owned_by"}); !strings.HasPrefix(out, "Error:") {
+		t.Fatalf("expected missing object error, got %q", out)
+	}
+
+	var res struct {
+		Status    string `json:"status"`
+		FactID    string `json:"factId"`
+		Version   int    `json:"version"`
+		Published bool   `json:"published"`
+	}
+	out, _ := propose.Execute(ctx, map[string]any{"subject": "payments-api", "predicate": "owned_by", "object": "team-a", "tags": []any{"ops"}})
+	decodeToolJSON(t, out, &res)
+	if res.Status != knowledge.FactApplyAccepted || res.Version != 1 || !res.Published {
+		t.Fatalf("unexpected first result %s", out)
+	}
+	out, _ = propose.Execute(ctx, map[string]any{"subject": "Payments-API", "predicate": "owned_by", "object": "team-billing"})
+	decodeToolJSON(t, out, &res)
+	if res.Version != 2 || res.FactID != knowledge.FactID("g1", "payments-api", "owned_by") {
+		t.Fatalf("expected v2 of the same fact, got %s", out)
+	}
+	if len(published) != 2 || published[1].Type != knowledge.TypeFact {
+		t.Fatalf("expected two published facts, got %+v", published)
+	}
+	_, _ = propose.Execute(ctx, map[string]any{"subject": "search", "predicate": "owned_by", "object": "team-discovery"})
+
+	var found struct {
+		Count int             `json:"count"`
+		Facts []knowledgeFact `json:"facts"`
+	}
+	out, _ = query.Execute(ctx, map[string]any{"subject": "payments-api"})
+	decodeToolJSON(t, out, &found)
+	if found.Count != 1 || found.Facts[0].Object != "team-billing" || found.Facts[0].Version != 2 {
+		t.Fatalf("unexpected subject query result %s", out)
+	}
+	out, _ = query.Execute(ctx, map[string]any{"predicate": "owned_by", "text": "discovery"})
+	decodeToolJSON(t, out, &found)
+	if found.Count != 1 || found.Facts[0].Subject != "search" {
+		t.Fatalf("unexpected text query result %s", out)
+	}
+	out, _ = query.Execute(ctx, map[string]any{"group": "other"})
+	decodeToolJSON(t, out, &found)
+	if found.Count != 0 {
+		t.Fatalf("expected no facts in other group, got %s", out)
+	}
+}
+
+func TestKnowledgeProposeWithGovernanceCreatesProposal(t *testing.T) {
+	tl := newKnowledgeTimeline(t)
+	propose := NewKnowledgeProposeTool(tl, KnowledgeProposeOptions{
+		Group: "g1", Governance: true, ClawID: "claw-a", InstanceID: "inst-a",
+		Publish: func(env knowledge.Envelope) error { return errors.New("proxy down") },
+	})
+
+	out, _ := propose.Execute(context.Background(), map[string]any{"subject": "payments-api", "predicate": "owned_by", "object": "team-billing", "title": "Ownership"})
+	var res struct {
+		Status       string `json:"status"`
+		ProposalID   string `json:"proposalId"`
+		FactID       string `json:"factId"`
+		Published    bool   `json:"published"`
+		PublishError string `json:"publishError"`
+	}
+	decodeToolJSON(t, out, &res)
+	if res.Status != knowledge.VoteStatusPending || res.Published || res.PublishError != "proxy down" {
+		t.Fatalf("unexpected result %s", out)
+	}
+	prop, err := tl.GetKnowledgeProposal(res.ProposalID)
+	if err != nil || prop == nil || prop.Status != "pending" || prop.Title != "Ownership" || !strings.Contains(prop.Fact, "team-billing") {
+		t.Fatalf("unexpected proposal %+v %v", prop, err)
+	}
+	if fact, _ := tl.GetKnowledgeFactLatest(res.FactID); fact != nil {
+		t.Fatalf("fact must wait for the vote, got %+v", fact)
+	}
+
+	if err := tl.UpdateKnowledgeProposalDecision(res.ProposalID, knowledge.VoteStatusApproved, 2, 0, ""); err != nil {
+		t.Fatalf("decide: %v", err)
+	}
+	if _, err := knowledge.ApplyApprovedProposal(tl, res.ProposalID); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if fact, _ := tl.GetKnowledgeFactLatest(res.FactID); fact == nil || fact.Object != "team-billing" {
+		t.Fatalf("expected approved fact, got %+v", fact)
+	}
+}
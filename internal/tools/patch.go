@@ -0,0 +1,535 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ApplyPatchTool applies a unified diff to files in the work repo. Either
+// every hunk applies and all files are written, or nothing changes.
+type ApplyPatchTool struct {
+	workRepoRoot func() string
+}
+
+// NewApplyPatchTool creates an ApplyPatchTool restricted to the work repo.
+func NewApplyPatchTool(workRepoGetter func() string) *ApplyPatchTool {
+	if workRepoGetter == nil {
+		workRepoGetter = func() string { return "" }
+	}
+	return &ApplyPatchTool{workRepoRoot: func() string { return normalizeRoot(workRepoGetter()) }}
+}
+
+func (t *ApplyPatchTool) Name() string { return "apply_patch" }
+func (t *ApplyPatchTool) Tier() int    { return TierWrite }
+
+func (t *ApplyPatchTool) Description() string {
+	return "Apply a unified diff (as produced by git diff or diff -u) to files in the work repo. Supports multiple files and hunks, new files (--- /dev/null) and deletions (+++ /dev/null). The patch is applied atomically: on any conflict nothing is written and every failing hunk is reported."
+}
+
+func (t *ApplyPatchTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"patch": map[string]any{
+				"type":        "string",
+				"description": "Unified diff text; paths are relative to the work repo (a/ and b/ prefixes are stripped)",
+			},
+			"check": map[string]any{
+				"type":        "boolean",
+				"description": "Only verify that the patch applies, without writing",
+			},
+		},
+		"required": []string{"patch"},
+	}
+}
+
+type patchLine struct {
+	op   byte // ' ', '-' or '+'
+	text string
+}
+
+type patchHunk struct {
+	header   string
+	oldStart int
+	lines    []patchLine
+	noNLOld  bool
+	noNLNew  bool
+}
+
+type filePatch struct {
+	oldPath string
+	newPath string
+	hunks   []patchHunk
+}
+
+func (fp filePatch) isNew() bool    { return fp.oldPath == "" }
+func (fp filePatch) isDelete() bool { return fp.newPath == "" }
+
+func (fp filePatch) displayPath() string {
+	if fp.newPath != "" {
+		return fp.newPath
+	}
+	return fp.oldPath
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// parsePatch parses unified diff text. Hunk line counts in headers are not
+// trusted; a hunk ends at the next header or file marker.
+func parsePatch(text string) ([]filePatch, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var patches []filePatch
+	var cur *filePatch
+	var hunk *patchHunk
+	flushHunk := func() {
+		if hunk == nil || cur == nil {
+			return
+		}
+		// Blank lines at the end of a hunk are usually separators, not
+		// context lines with the leading space stripped.
+		for len(hunk.lines) > 0 {
+			last := hunk.lines[len(hunk.lines)-1]
+			if last.op != ' ' || last.text != "" {
+				break
+			}
+			hunk.lines = hunk.lines[:len(hunk.lines)-1]
+		}
+		cur.hunks = append(cur.hunks, *hunk)
+		hunk = nil
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			flushHunk()
+			patches = append(patches, filePatch{
+				oldPath: patchPath(line[4:]),
+				newPath: patchPath(lines[i+1][4:]),
+			})
+			cur = &patches[len(patches)-1]
+			i++
+		case strings.HasPrefix(line, "@@"):
+			flushHunk()
+			if cur == nil {
+				return nil, fmt.Errorf("hunk %q before file header (--- / +++)", line)
+			}
+			m := hunkHeaderRe.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("%s: malformed hunk header %q", cur.displayPath(), line)
+			}
+			start, _ := strconv.Atoi(m[1])
+			hunk = &patchHunk{header: m[0], oldStart: start}
+		case hunk != nil && strings.HasPrefix(line, `\`):
+			if n := len(hunk.lines); n > 0 {
+				switch hunk.lines[n-1].op {
+				case '-':
+					hunk.noNLOld = true
+				case '+':
+					hunk.noNLNew = true
+				default:
+					hunk.noNLOld, hunk.noNLNew = true, true
+				}
+			}
+		case hunk != nil && line == "":
+			hunk.lines = append(hunk.lines, patchLine{op: ' ', text: ""})
+		case hunk != nil && (line[0] == ' ' || line[0] == '-' || line[0] == '+'):
+			hunk.lines = append(hunk.lines, patchLine{op: line[0], text: line[1:]})
+		default:
+			// diff --git, index, mode lines and free text between files.
+			flushHunk()
+		}
+	}
+	flushHunk()
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no file headers (--- / +++) found in patch")
+	}
+	for _, fp := range patches {
+		if fp.oldPath == "" && fp.newPath == "" {
+			return nil, fmt.Errorf("patch has a file with neither old nor new path")
+		}
+		if len(fp.hunks) == 0 {
+			return nil, fmt.Errorf("%s: no hunks", fp.displayPath())
+		}
+	}
+	return patches, nil
+}
+
+func patchPath(raw string) string {
+	p, _, _ := strings.Cut(raw, "\t")
+	p = strings.TrimSpace(p)
+	if p == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "b/") {
+		p = p[2:]
+	}
+	return p
+}
+
+// applyHunks applies hunks to content and returns the new content or one
+// conflict message per failing hunk.
+func applyHunks(path, content string, hunks []patchHunk) (string, []string) {
+	lines, hadNL := splitContentLines(content)
+	if content == "" {
+		hadNL = true // new files end with a newline unless the patch says otherwise
+	}
+	var conflicts []string
+	out := make([]string, 0, len(lines))
+	pos := 0    // next unconsumed line in lines
+	offset := 0 // drift between header line numbers and actual positions
+	touchedEOF := false
+	for i, h := range hunks {
+		var oldLines, newLines []string
+		for _, l := range h.lines {
+			if l.op != '+' {
+				oldLines = append(oldLines, l.text)
+			}
+			if l.op != '-' {
+				newLines = append(newLines, l.text)
+			}
+		}
+		want := h.oldStart - 1 + offset
+		if len(oldLines) == 0 && h.oldStart > 0 {
+			want = h.oldStart + offset // pure insertion after line oldStart
+		}
+		at := findHunk(lines, oldLines, want, pos)
+		if at < 0 {
+			conflicts = append(conflicts, hunkConflict(path, i, h, lines, oldLines, want))
+			continue
+		}
+		out = append(out, lines[pos:at]...)
+		out = append(out, newLines...)
+		pos = at + len(oldLines)
+		offset = at - (h.oldStart - 1)
+		if len(oldLines) == 0 && h.oldStart > 0 {
+			offset--
+		}
+		if pos == len(lines) {
+			touchedEOF = true
+		}
+		if touchedEOF {
+			if h.noNLNew {
+				hadNL = false
+			} else if h.noNLOld {
+				hadNL = true
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return "", conflicts
+	}
+	out = append(out, lines[pos:]...)
+	if len(out) == 0 {
+		return "", nil
+	}
+	result := strings.Join(out, "\n")
+	if hadNL {
+		result += "\n"
+	}
+	return result, nil
+}
+
+// findHunk locates oldLines in lines at or after from, preferring the
+// position closest to want. Exact matches win over matches that only
+// differ in trailing whitespace.
+func findHunk(lines, oldLines []string, want, from int) int {
+	if len(oldLines) == 0 {
+		if want < from {
+			want = from
+		}
+		if want > len(lines) {
+			want = len(lines)
+		}
+		return want
+	}
+	for _, loose := range []bool{false, true} {
+		for d := 0; d <= len(lines); d++ {
+			for _, at := range []int{want - d, want + d} {
+				if at < from || at+len(oldLines) > len(lines) {
+					continue
+				}
+				if linesMatch(lines[at:at+len(oldLines)], oldLines, loose) {
+					return at
+				}
+			}
+		}
+	}
+	return -1
+}
+
+func linesMatch(have, want []string, loose bool) bool {
+	for i := range want {
+		a, b := have[i], want[i]
+		if loose {
+			a, b = strings.TrimRight(a, " \t"), strings.TrimRight(b, " \t")
+		}
+		if a != b {
+			return false
+		}
+	}
+	return true
+}
+
+func hunkConflict(path string, idx int, h patchHunk, lines, oldLines []string, want int) string {
+	msg := fmt.Sprintf("%s: hunk #%d (%s) does not apply", path, idx+1, h.header)
+	if want < 0 {
+		want = 0
+	}
+	for i, expected := range oldLines {
+		at := want + i
+		if at >= len(lines) {
+			return msg + fmt.Sprintf(": expected %q at line %d but the file has only %d lines", expected, at+1, len(lines))
+		}
+		if lines[at] != expected {
+			return msg + fmt.Sprintf(": line %d is %q, expected %q", at+1, lines[at], expected)
+		}
+	}
+	return msg + ": context overlaps an earlier hunk"
+}
+
+func splitContentLines(content string) ([]string, bool) {
+	if content == "" {
+		return nil, false
+	}
+	hadNL := strings.HasSuffix(content, "\n")
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	return lines, hadNL
+}
+
+// patchResult is the planned outcome for one file.
+type patchResult struct {
+	path    string // absolute target path
+	oldPath string // absolute source path when renamed or deleted
+	content string
+	delete  bool
+	summary string
+}
+
+func (t *ApplyPatchTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	text := GetString(params, "patch", "")
+	if strings.TrimSpace(text) == "" {
+		return "Error: patch is required", nil
+	}
+	patches, err := parsePatch(text)
+	if err != nil {
+		return fmt.Sprintf("Error: invalid patch: %v", err), nil
+	}
+	root := ""
+	if t.workRepoRoot != nil {
+		root = t.workRepoRoot()
+	}
+
+	var results []patchResult
+	var conflicts []string
+	for _, fp := range patches {
+		res, errs := t.plan(root, fp)
+		if len(errs) > 0 {
+			conflicts = append(conflicts, errs...)
+			continue
+		}
+		results = append(results, res)
+	}
+	if len(conflicts) > 0 {
+		return "Error: patch does not apply; no files were changed.\n" + strings.Join(conflicts, "\n"), nil
+	}
+	var summary strings.Builder
+	if GetBool(params, "check", false) {
+		summary.WriteString("Patch applies cleanly:\n")
+	} else {
+		if err := writePatchResults(results); err != nil {
+			return fmt.Sprintf("Error: %v; no files were changed", err), nil
+		}
+		summary.WriteString("Applied patch:\n")
+	}
+	for _, r := range results {
+		summary.WriteString(r.summary + "\n")
+	}
+	return summary.String(), nil
+}
+
+func (t *ApplyPatchTool) resolve(root, rel string) (string, error) {
+	p := rel
+	if root != "" && !filepath.IsAbs(p) {
+		p = filepath.Join(root, p)
+	}
+	p = expandPath(p)
+	if root != "" && !isWithin(root, p) {
+		return "", fmt.Errorf("%s: path outside work repo", rel)
+	}
+	if inGitDir(p) {
+		return "", fmt.Errorf("%s: writing git metadata (.git) is not allowed", rel)
+	}
+	return p, nil
+}
+
+func (t *ApplyPatchTool) plan(root string, fp filePatch) (patchResult, []string) {
+	name := fp.displayPath()
+	var res patchResult
+	var err error
+	if fp.newPath != "" {
+		if res.path, err = t.resolve(root, fp.newPath); err != nil {
+			return res, []string{err.Error()}
+		}
+	}
+	if fp.oldPath != "" {
+		if res.oldPath, err = t.resolve(root, fp.oldPath); err != nil {
+			return res, []string{err.Error()}
+		}
+	}
+
+	current := ""
+	if !fp.isNew() {
+		data, err := os.ReadFile(res.oldPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return res, []string{fmt.Sprintf("%s: file not found", fp.oldPath)}
+			}
+			return res, []string{fmt.Sprintf("%s: %v", fp.oldPath, err)}
+		}
+		if isBinary(data) {
+			return res, []string{fmt.Sprintf("%s: binary file", fp.oldPath)}
+		}
+		current = string(data)
+	} else if _, err := os.Stat(res.path); err == nil {
+		return res, []string{fmt.Sprintf("%s: file already exists", fp.newPath)}
+	}
+
+	updated, conflicts := applyHunks(name, current, fp.hunks)
+	if len(conflicts) > 0 {
+		return res, conflicts
+	}
+	added, removed := 0, 0
+	for _, h := range fp.hunks {
+		for _, l := range h.lines {
+			switch l.op {
+			case '+':
+				added++
+			case '-':
+				removed++
+			}
+		}
+	}
+	switch {
+	case fp.isDelete():
+		if updated != "" {
+			return res, []string{fmt.Sprintf("%s: deletion patch does not remove the whole file", fp.oldPath)}
+		}
+		res.delete = true
+		res.path = res.oldPath
+		res.oldPath = ""
+		res.summary = fmt.Sprintf("D %s (-%d)", fp.oldPath, removed)
+	case fp.isNew():
+		res.content = updated
+		res.summary = fmt.Sprintf("A %s (+%d)", fp.newPath, added)
+	case res.oldPath != res.path:
+		res.content = updated
+		res.summary = fmt.Sprintf("R %s -> %s (+%d -%d)", fp.oldPath, fp.newPath, added, removed)
+	default:
+		res.content = updated
+		res.oldPath = ""
+		res.summary = fmt.Sprintf("M %s (+%d -%d)", fp.newPath, added, removed)
+	}
+	return res, nil
+}
+
+// writePatchResults writes all planned files and rolls every change back
+// if any step fails.
+func writePatchResults(results []patchResult) error {
+	type backup struct {
+		path    string
+		data    []byte
+		mode    os.FileMode
+		existed bool
+	}
+	var backups []backup
+	saved := map[string]bool{}
+	save := func(p string) {
+		if saved[p] {
+			return
+		}
+		saved[p] = true
+		b := backup{path: p}
+		if info, err := os.Stat(p); err == nil {
+			b.existed = true
+			b.mode = info.Mode().Perm()
+			b.data, _ = os.ReadFile(p)
+		}
+		backups = append(backups, b)
+	}
+	rollback := func() {
+		for i := len(backups) - 1; i >= 0; i-- {
+			b := backups[i]
+			if b.existed {
+				_ = os.WriteFile(b.path, b.data, b.mode)
+			} else {
+				_ = os.Remove(b.path)
+			}
+		}
+	}
+
+	for _, r := range results {
+		save(r.path)
+		if r.oldPath != "" {
+			save(r.oldPath)
+		}
+		if r.delete {
+			if err := os.Remove(r.path); err != nil {
+				rollback()
+				return fmt.Errorf("delete %s: %w", r.path, err)
+			}
+			continue
+		}
+		if err := writeFileAtomic(r.path, []byte(r.content)); err != nil {
+			rollback()
+			return err
+		}
+		if r.oldPath != "" {
+			if err := os.Remove(r.oldPath); err != nil {
+				rollback()
+				return fmt.Errorf("remove %s: %w", r.oldPath, err)
+			}
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic replaces path via a temp file in the same directory,
+// keeping the existing file mode.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create directory %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".patch-*")
+	if err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmpName, mode); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestApplyPatchTool_MultiFile(t *testing.T) {
+	repo := t.TempDir()
+	writeTree(t, repo, map[string]string{
+		"a.txt":    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+		"old.txt":  "keep\n",
+		"gone.txt": "bye\n",
+	})
+	patch := `diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@
+ 1
+-2
++two
+ 3
+@@ -8,3 +8,4 @@
+ 8
+ 9
++9.5
+ 10
+--- /dev/null
++++ b/dir/new.txt
+@@ -0,0 +1,2 @@
++hello
++world
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`
+	tool := NewApplyPatchTool(func() string { return repo })
+	out, _ := tool.Execute(context.Background(), map[string]any{"patch": patch})
+	if !strings.HasPrefix(out, "Applied patch:") || !strings.Contains(out, "M a.txt (+2 -1)") || !strings.Contains(out, "A dir/new.txt (+2)") || !strings.Contains(out, "D gone.txt (-1)") {
+		t.Fatalf("unexpected result: %q", out)
+	}
+	if got := readTestFile(t, filepath.Join(repo, "a.txt")); got != "1\ntwo\n3\n4\n5\n6\n7\n8\n9\n9.5\n10\n" {
+		t.Fatalf("unexpected a.txt: %q", got)
+	}
+	if got := readTestFile(t, filepath.Join(repo, "dir", "new.txt")); got != "hello\nworld\n" {
+		t.Fatalf("unexpected new.txt: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "gone.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected gone.txt deleted, err=%v", err)
+	}
+}
+
+func TestApplyPatchTool_OffsetAndNoNewline(t *testing.T) {
+	repo := t.TempDir()
+	writeTree(t, repo, map[string]string{"f.txt": "x\ny\na\nb\nc"})
+	// Header line numbers are off by two; the hunk is found by context.
+	patch := "--- a/f.txt\n+++ b/f.txt\n@@ -1,3 +1,3 @@\n a\n b\n-c\n\\ No newline at end of file\n+C\n"
+	out, _ := NewApplyPatchTool(func() string { return repo }).Execute(context.Background(), map[string]any{"patch": patch})
+	if !strings.HasPrefix(out, "Applied patch:") {
+		t.Fatalf("unexpected result: %q", out)
+	}
+	if got := readTestFile(t, filepath.Join(repo, "f.txt")); got != "x\ny\na\nb\nC\n" {
+		t.Fatalf("unexpected content: %q", got)
+	}
+}
+
+func TestApplyPatchTool_ConflictIsAtomic(t *testing.T) {
+	repo := t.TempDir()
+	writeTree(t, repo, map[string]string{
+		"a.txt": "alpha\nbeta\n",
+		"b.txt": "gamma\ndelta\n",
+	})
+	patch := `--- a/a.txt
++++ b/a.txt
+@@ -1,2 +1,2 @@
+ alpha
+-beta
++BETA
+--- a/b.txt
++++ b/b.txt
+@@ -1,2 +1,2 @@
+ gamma
+-epsilon
++EPSILON
+`
+	tool := NewApplyPatchTool(func() string { return repo })
+	out, _ := tool.Execute(context.Background(), map[string]any{"patch": patch})
+	if !strings.Contains(out, "no files were changed") || !strings.Contains(out, `b.txt: hunk #1 (@@ -1,2 +1,2 @@) does not apply: line 2 is "delta", expected "epsilon"`) {
+		t.Fatalf("unexpected conflict report: %q", out)
+	}
+	if got := readTestFile(t, filepath.Join(repo, "a.txt")); got != "alpha\nbeta\n" {
+		t.Fatalf("a.txt must be unchanged, got %q", got)
+	}
+
+	check := "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n alpha\n-beta\n+BETA\n"
+	out, _ = tool.Execute(context.Background(), map[string]any{"patch": check, "check": true})
+	if !strings.HasPrefix(out, "Patch applies cleanly:") {
+		t.Fatalf("unexpected check result: %q", out)
+	}
+	if got := readTestFile(t, filepath.Join(repo, "a.txt")); got != "alpha\nbeta\n" {
+		t.Fatalf("check must not write, got %q", got)
+	}
+}
+
+func TestApplyPatchTool_WorkRepoRestriction(t *testing.T) {
+	repo := t.TempDir()
+	patch := "--- /dev/null\n+++ b/../escape.txt\n@@ -0,0 +1 @@\n+x\n"
+	out, _ := NewApplyPatchTool(func() string { return repo }).Execute(context.Background(), map[string]any{"patch": patch})
+	if !strings.Contains(out, "path outside work repo") {
+		t.Fatalf("expected work repo restriction, got %q", out)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(repo), "escape.txt")); !os.IsNotExist(err) {
+		t.Fatalf("file outside repo must not be created")
+	}
+	if out, _ := NewApplyPatchTool(nil).Execute(context.Background(), map[string]any{"patch": "just text"}); !strings.Contains(out, "invalid patch") {
+		t.Fatalf("expected invalid patch error, got %q", out)
+	}
+}
@@ -0,0 +1,644 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	processBufferBytes    = 1 << 20
+	processMaxReadBytes   = 32 << 10
+	processMaxPerSession  = 8
+	processKeepFinished   = 32
+	processKillGrace      = 3 * time.Second
+	processWaitDelay      = 2 * time.Second
+	processDefaultSession = "cli:default"
+)
+
+// Background process states.
+const (
+	ProcessStatusRunning = "running"
+	ProcessStatusExited  = "exited"
+	ProcessStatusKilled  = "killed"
+	ProcessStatusFailed  = "failed"
+)
+
+// ProcessInfo describes a background process started by the agent.
+type ProcessInfo struct {
+	ID          string     `json:"id"`
+	SessionKey  string     `json:"session"`
+	Name        string     `json:"name,omitempty"`
+	Command     string     `json:"command"`
+	WorkDir     string     `json:"workDir,omitempty"`
+	PID         int        `json:"pid"`
+	Status      string     `json:"status"`
+	ExitCode    *int       `json:"exitCode,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	EndedAt     *time.Time `json:"endedAt,omitempty"`
+	OutputBytes int64      `json:"outputBytes"`
+}
+
+// ProcessManager owns the background processes of one agent loop. Each
+// process belongs to the session that started it and is killed when that
+// session ends or the loop stops.
+type ProcessManager struct {
+	mu       sync.Mutex
+	procs    map[string]*managedProcess
+	starting map[string]int // slots reserved by starts still spawning, per session
+	seq      int
+}
+
+// NewProcessManager creates an empty process manager.
+func NewProcessManager() *ProcessManager {
+	return &ProcessManager{procs: make(map[string]*managedProcess), starting: make(map[string]int)}
+}
+
+type managedProcess struct {
+	mu       sync.Mutex
+	info     ProcessInfo
+	buf      []byte
+	base     int64 // output offset of buf[0]
+	readPos  int64 // next offset for reads without an explicit offset
+	stdin    io.WriteCloser
+	cmd      *exec.Cmd
+	cancel   context.CancelFunc
+	killed   bool
+	done     chan struct{}
+	finished time.Time
+}
+
+// Write appends output, dropping the oldest bytes beyond the buffer size.
+func (p *managedProcess) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.buf = append(p.buf, b...)
+	if over := len(p.buf) - processBufferBytes; over > 0 {
+		p.buf = append(p.buf[:0], p.buf[over:]...)
+		p.base += int64(over)
+	}
+	p.info.OutputBytes += int64(len(b))
+	return len(b), nil
+}
+
+func (p *managedProcess) snapshot() ProcessInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.info
+}
+
+// start launches cmd, built by the caller with a context that cancel ends.
+func (m *ProcessManager) start(sessionKey, name, command, workDir string, cmd *exec.Cmd, cancel context.CancelFunc, release func()) (ProcessInfo, error) {
+	sessionKey = normalizeProcessSession(sessionKey)
+	m.mu.Lock()
+	running := m.starting[sessionKey]
+	for _, p := range m.procs {
+		if p.info.SessionKey == sessionKey && p.snapshot().Status == ProcessStatusRunning {
+			running++
+		}
+	}
+	if running >= processMaxPerSession {
+		m.mu.Unlock()
+		cancel()
+		release()
+		return ProcessInfo{}, fmt.Errorf("session already has %d running processes; kill one first", running)
+	}
+	// Reserve the slot before spawning so concurrent starts cannot exceed
+	// the cap; it becomes a registered process or is released on failure.
+	m.starting[sessionKey]++
+	m.seq++
+	id := fmt.Sprintf("proc-%d", m.seq)
+	m.pruneLocked()
+	m.mu.Unlock()
+	fail := func(err error) (ProcessInfo, error) {
+		m.mu.Lock()
+		m.unreserveLocked(sessionKey)
+		m.mu.Unlock()
+		cancel()
+		release()
+		return ProcessInfo{}, err
+	}
+
+	p := &managedProcess{
+		info: ProcessInfo{
+			ID:         id,
+			SessionKey: sessionKey,
+			Name:       name,
+			Command:    command,
+			WorkDir:    workDir,
+			Status:     ProcessStatusRunning,
+			StartedAt:  time.Now(),
+		},
+		cmd:    cmd,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	cmd.Stdout = p
+	cmd.Stderr = p
+	cmd.WaitDelay = processWaitDelay
+	setProcessGroup(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fail(err)
+	}
+	p.stdin = stdin
+	if err := cmd.Start(); err != nil {
+		return fail(err)
+	}
+	p.info.PID = cmd.Process.Pid
+
+	m.mu.Lock()
+	m.unreserveLocked(sessionKey)
+	m.procs[id] = p
+	m.mu.Unlock()
+
+	go func() {
+		err := cmd.Wait()
+		release()
+		cancel()
+		now := time.Now()
+		p.mu.Lock()
+		p.info.EndedAt = &now
+		p.finished = now
+		code := cmd.ProcessState.ExitCode()
+		p.info.ExitCode = &code
+		switch {
+		case p.killed:
+			p.info.Status = ProcessStatusKilled
+		case err != nil && code < 0:
+			p.info.Status = ProcessStatusFailed
+			p.info.Error = err.Error()
+		default:
+			p.info.Status = ProcessStatusExited
+		}
+		p.mu.Unlock()
+		close(p.done)
+	}()
+	return p.snapshot(), nil
+}
+
+func (m *ProcessManager) unreserveLocked(sessionKey string) {
+	if m.starting[sessionKey]--; m.starting[sessionKey] <= 0 {
+		delete(m.starting, sessionKey)
+	}
+}
+
+// pruneLocked forgets the oldest finished processes beyond the retention cap.
+func (m *ProcessManager) pruneLocked() {
+	var finished []*managedProcess
+	for _, p := range m.procs {
+		if p.snapshot().Status != ProcessStatusRunning {
+			finished = append(finished, p)
+		}
+	}
+	if len(finished) <= processKeepFinished {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].finished.Before(finished[j].finished) })
+	for _, p := range finished[:len(finished)-processKeepFinished] {
+		delete(m.procs, p.info.ID)
+	}
+}
+
+func (m *ProcessManager) get(sessionKey, id string) (*managedProcess, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.procs[strings.TrimSpace(id)]
+	if !ok || (sessionKey != "" && p.info.SessionKey != normalizeProcessSession(sessionKey)) {
+		return nil, fmt.Errorf("process not found: %s", id)
+	}
+	return p, nil
+}
+
+// ProcessOutput is one incremental read of a process's combined output.
+type ProcessOutput struct {
+	ID         string `json:"id"`
+	Status     string `json:"status"`
+	ExitCode   *int   `json:"exitCode,omitempty"`
+	Output     string `json:"output"`
+	Offset     int64  `json:"offset"`
+	NextOffset int64  `json:"nextOffset"`
+	Dropped    int64  `json:"dropped,omitempty"`
+	More       bool   `json:"more"`
+}
+
+// Output reads output from offset; a negative offset continues after the
+// previous read.
+func (m *ProcessManager) Output(sessionKey, id string, offset int64, maxBytes int) (ProcessOutput, error) {
+	p, err := m.get(sessionKey, id)
+	if err != nil {
+		return ProcessOutput{}, err
+	}
+	if maxBytes <= 0 || maxBytes > processMaxReadBytes {
+		maxBytes = processMaxReadBytes
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if offset < 0 {
+		offset = p.readPos
+	}
+	out := ProcessOutput{ID: p.info.ID, Status: p.info.Status, ExitCode: p.info.ExitCode}
+	if offset < p.base {
+		out.Dropped = p.base - offset
+		offset = p.base
+	}
+	end := p.base + int64(len(p.buf))
+	if offset > end {
+		offset = end
+	}
+	stop := min(end, offset+int64(maxBytes))
+	out.Offset = offset
+	out.Output = string(p.buf[offset-p.base : stop-p.base])
+	out.NextOffset = stop
+	out.More = stop < end
+	p.readPos = stop
+	return out, nil
+}
+
+// Write sends input to the process's stdin, closing it afterwards when
+// closeStdin is set.
+func (m *ProcessManager) Write(sessionKey, id, input string, closeStdin bool) error {
+	p, err := m.get(sessionKey, id)
+	if err != nil {
+		return err
+	}
+	if p.snapshot().Status != ProcessStatusRunning {
+		return fmt.Errorf("process %s is not running", id)
+	}
+	if input != "" {
+		if _, err := io.WriteString(p.stdin, input); err != nil {
+			return fmt.Errorf("write stdin: %w", err)
+		}
+	}
+	if closeStdin {
+		return p.stdin.Close()
+	}
+	return nil
+}
+
+// Kill terminates a process (and its process group), escalating to SIGKILL
+// after a grace period.
+func (m *ProcessManager) Kill(sessionKey, id string) (ProcessInfo, error) {
+	p, err := m.get(sessionKey, id)
+	if err != nil {
+		return ProcessInfo{}, err
+	}
+	m.kill(p)
+	return p.snapshot(), nil
+}
+
+func (m *ProcessManager) kill(p *managedProcess) {
+	p.mu.Lock()
+	if p.info.Status != ProcessStatusRunning {
+		p.mu.Unlock()
+		return
+	}
+	p.killed = true
+	p.mu.Unlock()
+	terminateProcess(p.cmd, false)
+	select {
+	case <-p.done:
+	case <-time.After(processKillGrace):
+		terminateProcess(p.cmd, true)
+		p.cancel()
+		<-p.done
+	}
+}
+
+// List returns the processes of a session, or of all sessions when
+// sessionKey is empty, newest first.
+func (m *ProcessManager) List(sessionKey string) []ProcessInfo {
+	m.mu.Lock()
+	procs := make([]*managedProcess, 0, len(m.procs))
+	for _, p := range m.procs {
+		if sessionKey == "" || p.info.SessionKey == normalizeProcessSession(sessionKey) {
+			procs = append(procs, p)
+		}
+	}
+	m.mu.Unlock()
+	out := make([]ProcessInfo, 0, len(procs))
+	for _, p := range procs {
+		out = append(out, p.snapshot())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.After(out[j].StartedAt) })
+	return out
+}
+
+// KillSession terminates every running process of a session.
+func (m *ProcessManager) KillSession(sessionKey string) {
+	m.killWhere(func(p *managedProcess) bool { return p.info.SessionKey == normalizeProcessSession(sessionKey) })
+}
+
+// KillAll terminates every running process.
+func (m *ProcessManager) KillAll() {
+	m.killWhere(func(*managedProcess) bool { return true })
+}
+
+func (m *ProcessManager) killWhere(match func(*managedProcess) bool) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	var targets []*managedProcess
+	for _, p := range m.procs {
+		if match(p) {
+			targets = append(targets, p)
+		}
+	}
+	m.mu.Unlock()
+	var wg sync.WaitGroup
+	for _, p := range targets {
+		wg.Add(1)
+		go func(p *managedProcess) {
+			defer wg.Done()
+			m.kill(p)
+		}(p)
+	}
+	wg.Wait()
+}
+
+func normalizeProcessSession(key string) string {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return processDefaultSession
+	}
+	return key
+}
+
+// processTool holds what all process tools share.
+type processTool struct {
+	manager    *ProcessManager
+	sessionKey func(context.Context) string
+}
+
+func (t processTool) session(ctx context.Context) string {
+	if t.sessionKey == nil {
+		return processDefaultSession
+	}
+	return normalizeProcessSession(t.sessionKey(ctx))
+}
+
+// ProcessStartTool starts a background command under the exec tool's
+// guards and isolation.
+type ProcessStartTool struct {
+	processTool
+	exec *ExecTool
+}
+
+// NewProcessStartTool creates process_start. Commands pass the same checks
+// and sandbox as exec.
+func NewProcessStartTool(manager *ProcessManager, execTool *ExecTool, sessionKey func(context.Context) string) *ProcessStartTool {
+	return &ProcessStartTool{processTool: processTool{manager: manager, sessionKey: sessionKey}, exec: execTool}
+}
+
+func (t *ProcessStartTool) Name() string { return "process_start" }
+func (t *ProcessStartTool) Tier() int    { return TierHighRisk }
+
+func (t *ProcessStartTool) Description() string {
+	return "Start a long-running shell command in the background (dev server, log tail, long build) and return a process handle. Read output with process_output."
+}
+
+func (t *ProcessStartTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"command": map[string]any{
+				"type":        "string",
+				"description": "The shell command to run",
+			},
+			"working_dir": map[string]any{
+				"type":        "string",
+				"description": "Optional working directory (default: work repo)",
+			},
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Optional label shown in process_list",
+			},
+		},
+		"required": []string{"command"},
+	}
+}
+
+func (t *ProcessStartTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	command := GetString(params, "command", "")
+	if strings.TrimSpace(command) == "" {
+		return "Error: command is required", nil
+	}
+	workingDir := GetString(params, "working_dir", t.exec.defaultWorkDir())
+	if err := t.exec.guardCommand(command, workingDir); err != nil {
+		return err.Error(), nil
+	}
+	// The process outlives this tool call; it ends on kill or session end.
+	procCtx, cancel := context.WithCancel(context.Background())
+	cmd, release, err := t.exec.command(procCtx, command, workingDir)
+	if err != nil {
+		cancel()
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	info, err := t.manager.start(t.session(ctx), strings.TrimSpace(GetString(params, "name", "")), command, workingDir, cmd, cancel, release)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	return jsonResult(info)
+}
+
+// ProcessOutputTool reads a background process's output incrementally.
+type ProcessOutputTool struct{ processTool }
+
+func NewProcessOutputTool(manager *ProcessManager, sessionKey func(context.Context) string) *ProcessOutputTool {
+	return &ProcessOutputTool{processTool{manager: manager, sessionKey: sessionKey}}
+}
+
+func (t *ProcessOutputTool) Name() string { return "process_output" }
+func (t *ProcessOutputTool) Tier() int    { return TierReadOnly }
+
+func (t *ProcessOutputTool) Description() string {
+	return "Read combined stdout/stderr of a background process. Without offset, continues after the previous read; pass nextOffset from an earlier result to re-read from there. Optionally waits for new output."
+}
+
+func (t *ProcessOutputTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id": map[string]any{
+				"type":        "string",
+				"description": "Process handle from process_start",
+			},
+			"offset": map[string]any{
+				"type":        "integer",
+				"description": "Byte offset to read from (default: after the previous read)",
+			},
+			"max_bytes": map[string]any{
+				"type":        "integer",
+				"description": "Maximum bytes to return (default and max 32768)",
+			},
+			"wait_seconds": map[string]any{
+				"type":        "integer",
+				"description": "Wait up to this many seconds (max 30) for new output or exit",
+			},
+		},
+		"required": []string{"id"},
+	}
+}
+
+func (t *ProcessOutputTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	id := GetString(params, "id", "")
+	offset := int64(-1)
+	if _, ok := params["offset"]; ok {
+		offset = int64(max(GetInt(params, "offset", 0), 0))
+	}
+	maxBytes := GetInt(params, "max_bytes", processMaxReadBytes)
+	if wait := min(GetInt(params, "wait_seconds", 0), 30); wait > 0 {
+		t.waitForOutput(ctx, id, offset, time.Duration(wait)*time.Second)
+	}
+	out, err := t.manager.Output(t.session(ctx), id, offset, maxBytes)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	return jsonResult(out)
+}
+
+// waitForOutput returns once the process has output past offset, has
+// exited, or the timeout passed.
+func (t *ProcessOutputTool) waitForOutput(ctx context.Context, id string, offset int64, timeout time.Duration) {
+	p, err := t.manager.get(t.session(ctx), id)
+	if err != nil {
+		return
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	tick := time.NewTicker(100 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		p.mu.Lock()
+		from := offset
+		if from < 0 {
+			from = p.readPos
+		}
+		ready := p.base+int64(len(p.buf)) > from
+		p.mu.Unlock()
+		if ready {
+			return
+		}
+		select {
+		case <-p.done:
+			return
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// ProcessWriteTool writes to a background process's stdin.
+type ProcessWriteTool struct{ processTool }
+
+func NewProcessWriteTool(manager *ProcessManager, sessionKey func(context.Context) string) *ProcessWriteTool {
+	return &ProcessWriteTool{processTool{manager: manager, sessionKey: sessionKey}}
+}
+
+func (t *ProcessWriteTool) Name() string { return "process_write" }
+func (t *ProcessWriteTool) Tier() int    { return TierHighRisk }
+
+func (t *ProcessWriteTool) Description() string {
+	return "Write input to the stdin of a background process. Include a trailing newline to submit a line."
+}
+
+func (t *ProcessWriteTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id": map[string]any{
+				"type":        "string",
+				"description": "Process handle from process_start",
+			},
+			"input": map[string]any{
+				"type":        "string",
+				"description": "Text to write to stdin",
+			},
+			"close": map[string]any{
+				"type":        "boolean",
+				"description": "Close stdin after writing (sends EOF)",
+			},
+		},
+		"required": []string{"id"},
+	}
+}
+
+func (t *ProcessWriteTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	id := GetString(params, "id", "")
+	input := GetString(params, "input", "")
+	closeStdin := GetBool(params, "close", false)
+	if input == "" && !closeStdin {
+		return "Error: input or close is required", nil
+	}
+	if err := t.manager.Write(t.session(ctx), id, input, closeStdin); err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	return fmt.Sprintf("Wrote %d bytes to %s", len(input), id), nil
+}
+
+// ProcessKillTool terminates a background process.
+type ProcessKillTool struct{ processTool }
+
+func NewProcessKillTool(manager *ProcessManager, sessionKey func(context.Context) string) *ProcessKillTool {
+	return &ProcessKillTool{processTool{manager: manager, sessionKey: sessionKey}}
+}
+
+func (t *ProcessKillTool) Name() string { return "process_kill" }
+func (t *ProcessKillTool) Tier() int    { return TierWrite }
+
+func (t *ProcessKillTool) Description() string {
+	return "Terminate a background process and its children."
+}
+
+func (t *ProcessKillTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id": map[string]any{
+				"type":        "string",
+				"description": "Process handle from process_start",
+			},
+		},
+		"required": []string{"id"},
+	}
+}
+
+func (t *ProcessKillTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	info, err := t.manager.Kill(t.session(ctx), GetString(params, "id", ""))
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	return jsonResult(info)
+}
+
+// ProcessListTool lists the session's background processes.
+type ProcessListTool struct{ processTool }
+
+func NewProcessListTool(manager *ProcessManager, sessionKey func(context.Context) string) *ProcessListTool {
+	return &ProcessListTool{processTool{manager: manager, sessionKey: sessionKey}}
+}
+
+func (t *ProcessListTool) Name() string { return "process_list" }
+func (t *ProcessListTool) Tier() int    { return TierReadOnly }
+
+func (t *ProcessListTool) Description() string {
+	return "List background processes started in this session with their status."
+}
+
+func (t *ProcessListTool) Parameters() map[string]any {
+	return map[string]any{"type": "object", "properties": map[string]any{}}
+}
+
+func (t *ProcessListTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	return jsonResult(map[string]any{"processes": t.manager.List(t.session(ctx))})
+}
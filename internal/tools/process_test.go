@@ -0,0 +1,195 @@
+//go:build !windows
+
+package tools
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newProcessTestTools(t *testing.T, session *string) (*ProcessManager, *ProcessStartTool) {
+	t.Helper()
+	dir := t.TempDir()
+	mgr := NewProcessManager()
+	t.Cleanup(mgr.KillAll)
+	execTool := NewExecTool(0, false, dir, func() string { return dir })
+	execTool.StrictAllowList = false
+	return mgr, NewProcessStartTool(mgr, execTool, func(context.Context) string { return *session })
+}
+
+func startTestProcess(t *testing.T, start *ProcessStartTool, command string) ProcessInfo {
+	t.Helper()
+	out, _ := start.Execute(context.Background(), map[string]any{"command": command})
+	var info ProcessInfo
+	decodeToolJSON(t, out, &info)
+	if info.ID == "" || info.PID == 0 || info.Status != ProcessStatusRunning {
+		t.Fatalf("unexpected start result: %+v", info)
+	}
+	return info
+}
+
+func TestProcessTools_OutputWriteAndExit(t *testing.T) {
+	session := "telegram:42"
+	mgr, start := newProcessTestTools(t, &session)
+	sessionKey := func(context.Context) string { return session }
+	ctx := context.Background()
+
+	info := startTestProcess(t, start, "echo ready; while read line; do echo \"got $line\"; done; echo bye")
+	read := NewProcessOutputTool(mgr, sessionKey)
+
+	var out ProcessOutput
+	res, _ := read.Execute(ctx, map[string]any{"id": info.ID, "wait_seconds": 5})
+	decodeToolJSON(t, res, &out)
+	if out.Output != "ready\n" || out.Offset != 0 || out.NextOffset != 6 {
+		t.Fatalf("unexpected first read: %+v", out)
+	}
+
+	write := NewProcessWriteTool(mgr, sessionKey)
+	if res, _ := write.Execute(ctx, map[string]any{"id": info.ID, "input": "ping\n", "close": true}); strings.HasPrefix(res, "Error") {
+		t.Fatalf("write failed: %s", res)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		res, _ = read.Execute(ctx, map[string]any{"id": info.ID, "offset": 0, "wait_seconds": 1})
+		decodeToolJSON(t, res, &out)
+		if out.Status == ProcessStatusExited || time.Now().After(deadline) {
+			break
+		}
+	}
+	if out.Status != ProcessStatusExited || out.ExitCode == nil || *out.ExitCode != 0 {
+		t.Fatalf("expected clean exit, got %+v", out)
+	}
+	if out.Output != "ready\ngot ping\nbye\n" {
+		t.Fatalf("unexpected full output: %q", out.Output)
+	}
+
+	// A read without offset continues after the previous one.
+	res, _ = read.Execute(ctx, map[string]any{"id": info.ID})
+	decodeToolJSON(t, res, &out)
+	if out.Output != "" || out.NextOffset != int64(len("ready\ngot ping\nbye\n")) {
+		t.Fatalf("expected empty incremental read, got %+v", out)
+	}
+}
+
+func TestProcessTools_KillAndSessionScope(t *testing.T) {
+	session := "slack:a"
+	mgr, start := newProcessTestTools(t, &session)
+	ctx := context.Background()
+	info := startTestProcess(t, start, "sleep 60")
+
+	session = "slack:b"
+	other := func(context.Context) string { return session }
+	if res, _ := NewProcessKillTool(mgr, other).Execute(ctx, map[string]any{"id": info.ID}); !strings.Contains(res, "process not found") {
+		t.Fatalf("expected other session to be denied, got %s", res)
+	}
+	res, _ := NewProcessListTool(mgr, other).Execute(ctx, nil)
+	if strings.Contains(res, info.ID) {
+		t.Fatalf("other session should not list %s: %s", info.ID, res)
+	}
+
+	session = "slack:a"
+	res, _ = NewProcessKillTool(mgr, other).Execute(ctx, map[string]any{"id": info.ID})
+	var killed ProcessInfo
+	decodeToolJSON(t, res, &killed)
+	if killed.Status != ProcessStatusKilled || killed.EndedAt == nil {
+		t.Fatalf("expected killed process, got %+v", killed)
+	}
+}
+
+func TestProcessManager_KillSession(t *testing.T) {
+	session := "cli:default"
+	mgr, start := newProcessTestTools(t, &session)
+	a := startTestProcess(t, start, "sleep 60 & wait")
+	session = "web:x"
+	b := startTestProcess(t, start, "sleep 60")
+
+	mgr.KillSession("")
+	for _, p := range mgr.List("") {
+		switch p.ID {
+		case a.ID:
+			if p.Status != ProcessStatusKilled {
+				t.Fatalf("expected %s killed, got %s", a.ID, p.Status)
+			}
+		case b.ID:
+			if p.Status != ProcessStatusRunning {
+				t.Fatalf("expected %s still running, got %s", b.ID, p.Status)
+			}
+		}
+	}
+	mgr.KillAll()
+	if got := mgr.List("web:x"); len(got) != 1 || got[0].Status != ProcessStatusKilled {
+		t.Fatalf("expected web:x process killed, got %+v", got)
+	}
+}
+
+func TestProcessManager_ConcurrentStartsRespectSessionCap(t *testing.T) {
+	mgr := NewProcessManager()
+	t.Cleanup(mgr.KillAll)
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		started int
+	)
+	for i := 0; i < processMaxPerSession*3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithCancel(context.Background())
+			cmd := exec.CommandContext(ctx, "sleep", "60")
+			if _, err := mgr.start("cli:race", "", "sleep 60", "", cmd, cancel, func() {}); err == nil {
+				mu.Lock()
+				started++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if started != processMaxPerSession {
+		t.Fatalf("started %d processes, want %d", started, processMaxPerSession)
+	}
+
+	// A failed spawn gives its reserved slot back.
+	mgr.KillAll()
+	ctx, cancel := context.WithCancel(context.Background())
+	bad := exec.CommandContext(ctx, "/nonexistent/binary")
+	if _, err := mgr.start("cli:race", "", "bad", "", bad, cancel, func() {}); err == nil {
+		t.Fatal("expected start of a missing binary to fail")
+	}
+	if n := mgr.starting["cli:race"]; n != 0 {
+		t.Fatalf("expected no reserved slots after failure, got %d", n)
+	}
+}
+
+func TestProcessStart_UsesExecGuards(t *testing.T) {
+	session := ""
+	mgr, start := newProcessTestTools(t, &session)
+	res, _ := start.Execute(context.Background(), map[string]any{"command": "rm -rf /"})
+	if res != blockedAttackMessage {
+		t.Fatalf("expected guard to block, got %q", res)
+	}
+	if len(mgr.List("")) != 0 {
+		t.Fatal("blocked command must not start a process")
+	}
+}
+
+func TestProcessOutput_ReportsDroppedBytes(t *testing.T) {
+	p := &managedProcess{}
+	chunk := strings.Repeat("x", processBufferBytes/2)
+	for i := 0; i < 3; i++ {
+		_, _ = p.Write([]byte(chunk))
+	}
+	mgr := NewProcessManager()
+	p.info = ProcessInfo{ID: "proc-1", SessionKey: processDefaultSession}
+	mgr.procs["proc-1"] = p
+	out, err := mgr.Output("", "proc-1", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Dropped != int64(len(chunk)) || out.Offset != int64(len(chunk)) || !out.More || len(out.Output) != 10 {
+		t.Fatalf("unexpected read: %+v", out)
+	}
+}
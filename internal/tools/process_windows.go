@@ -0,0 +1,14 @@
+//go:build windows
+
+package tools
+
+import "os/exec"
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcess kills the process; Windows has no graceful signal.
+func terminateProcess(cmd *exec.Cmd, force bool) {
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
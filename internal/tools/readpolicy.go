@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/secrets"
+	"github.com/KafClaw/KafClaw/internal/skills"
+)
+
+// sensitiveFileNames are file names that usually hold credentials.
+var sensitiveFileNames = map[string]bool{
+	".netrc": true, ".pgpass": true, ".git-credentials": true, ".npmrc": true, ".pypirc": true,
+	"credentials": true, "credentials.json": true, "secrets.json": true, "secrets.yaml": true,
+	"id_rsa": true, "id_dsa": true, "id_ecdsa": true, "id_ed25519": true, "shadow": true,
+}
+
+// sensitiveExts are extensions of key, keystore and password-vault files.
+var sensitiveExts = map[string]bool{
+	".pem": true, ".key": true, ".p12": true, ".pfx": true, ".jks": true, ".keystore": true,
+	".kdbx": true, ".gpg": true, ".asc": true, ".ovpn": true,
+}
+
+// sensitiveDirs are directories whose files are all treated as sensitive.
+var sensitiveDirs = map[string]bool{".ssh": true, ".gnupg": true, ".aws": true, ".kube": true, ".docker": true}
+
+// ReadPolicy decides which paths read_file, list_dir, grep and glob may read. KafClaw's
+// secret stores and configured deny paths are refused; when allow roots are
+// configured, everything outside them is refused as well. Paths are checked
+// after resolving symlinks.
+type ReadPolicy struct {
+	cfg   config.ReadToolConfig
+	roots func() []string
+}
+
+// NewReadPolicy creates a read policy. roots returns directories that are
+// always allowed, such as the workspace and the work repo.
+func NewReadPolicy(cfg config.ReadToolConfig, roots func() []string) *ReadPolicy {
+	return &ReadPolicy{cfg: cfg, roots: roots}
+}
+
+// Check returns an error when path must not be read.
+func (p *ReadPolicy) Check(path string) error {
+	return p.checker()(path)
+}
+
+// checker returns Check with the denied paths and allow roots resolved once,
+// for callers that check many files.
+func (p *ReadPolicy) checker() func(path string) error {
+	denied := p.deniedPaths()
+	var roots []string
+	if len(p.cfg.AllowRoots) > 0 {
+		candidates := append([]string{}, p.cfg.AllowRoots...)
+		if p.roots != nil {
+			candidates = append(candidates, p.roots()...)
+		}
+		for _, root := range candidates {
+			if strings.TrimSpace(root) != "" {
+				roots = append(roots, resolveReal(expandPath(root)))
+			}
+		}
+	}
+	return func(path string) error {
+		real := resolveReal(path)
+		// SQLite keeps journal files next to the session stores.
+		db := real
+		for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+			db = strings.TrimSuffix(db, suffix)
+		}
+		for _, d := range denied {
+			if isWithin(d, real) || isWithin(d, db) {
+				return fmt.Errorf("read of %s is blocked: it holds KafClaw secrets or is in tools.read.denyPaths", path)
+			}
+		}
+		if len(p.cfg.AllowRoots) == 0 {
+			return nil
+		}
+		for _, root := range roots {
+			if isWithin(root, real) {
+				return nil
+			}
+		}
+		return fmt.Errorf("read of %s is blocked: outside tools.read.allowRoots", path)
+	}
+}
+
+// Sensitive reports whether path looks like it holds credentials, such as
+// private keys, .env files or cloud credentials.
+func (p *ReadPolicy) Sensitive(path string) bool {
+	real := resolveReal(path)
+	for _, candidate := range []string{expandPath(path), real} {
+		base := strings.ToLower(filepath.Base(candidate))
+		if sensitiveFileNames[base] || sensitiveExts[filepath.Ext(base)] {
+			return true
+		}
+		if base == ".env" || strings.HasPrefix(base, ".env.") {
+			return true
+		}
+		for _, part := range strings.Split(filepath.ToSlash(filepath.Dir(candidate)), "/") {
+			if sensitiveDirs[part] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deniedPaths lists KafClaw's secret locations: the config file and .env,
+// the WhatsApp session store, the timeline, the local tomb and the skills
+// auth dir holding master.key and OAuth tokens.
+func (p *ReadPolicy) deniedPaths() []string {
+	var out []string
+	add := func(path string) {
+		if strings.TrimSpace(path) != "" {
+			out = append(out, resolveReal(expandPath(path)))
+		}
+	}
+	if cfgPath, err := config.ConfigPath(); err == nil {
+		dir := filepath.Dir(cfgPath)
+		add(cfgPath)
+		add(filepath.Join(dir, ".env"))
+		add(filepath.Join(dir, "whatsapp.db"))
+		add(filepath.Join(dir, "timeline.db"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		add(filepath.Join(home, config.ConfigDir, ".env"))
+		add(filepath.Join(home, config.ConfigDir, "whatsapp.db"))
+		add(filepath.Join(home, config.ConfigDir, "timeline.db"))
+	}
+	if tomb, err := secrets.ResolveLocalTombPath(); err == nil {
+		add(tomb)
+	}
+	if dirs, err := skills.ResolveStateDirs(); err == nil {
+		add(filepath.Join(dirs.ToolsDir, "auth"))
+	}
+	for _, path := range p.cfg.DenyPaths {
+		add(path)
+	}
+	return out
+}
+
+// resolveReal returns the absolute path with symlinks resolved. For paths
+// that do not exist yet the parent directory is resolved instead.
+func resolveReal(path string) string {
+	path = expandPath(path)
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		return filepath.Join(dir, filepath.Base(path))
+	}
+	return path
+}
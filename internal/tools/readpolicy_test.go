@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+// setupReadPolicyHome points KafClaw's state at a temp home and creates its
+// secret files.
+func setupReadPolicyHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("KAFCLAW_HOME", home)
+	t.Setenv("KAFCLAW_CONFIG", "")
+	t.Setenv("MIKROBOT_CONFIG", "")
+	t.Setenv("KAFCLAW_OAUTH_TOMB_FILE", "")
+	for _, rel := range []string{
+		".kafclaw/config.json",
+		".kafclaw/skills/tools/auth/master.key",
+		".config/kafclaw/tomb.rr",
+	} {
+		path := filepath.Join(home, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("secret"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return home
+}
+
+func TestReadPolicyDeniesSecretStores(t *testing.T) {
+	home := setupReadPolicyHome(t)
+	read := NewReadFileTool()
+	list := NewListDirTool()
+
+	for _, rel := range []string{".kafclaw/config.json", ".kafclaw/skills/tools/auth/master.key", ".config/kafclaw/tomb.rr", ".kafclaw/whatsapp.db-wal"} {
+		path := filepath.Join(home, rel)
+		if err := read.CheckCall(map[string]any{"path": path}); err == nil {
+			t.Errorf("expected %s to be blocked", rel)
+		}
+	}
+	out, _ := read.Execute(context.Background(), map[string]any{"path": filepath.Join(home, ".kafclaw", "config.json")})
+	if !strings.Contains(out, "is blocked") {
+		t.Fatalf("expected blocked read, got %q", out)
+	}
+	out, _ = list.Execute(context.Background(), map[string]any{"path": filepath.Join(home, ".kafclaw", "skills", "tools", "auth")})
+	if !strings.Contains(out, "is blocked") {
+		t.Fatalf("expected blocked listing, got %q", out)
+	}
+	if err := list.CheckCall(map[string]any{"path": filepath.Join(home, ".kafclaw")}); err != nil {
+		t.Fatalf("listing the config dir itself should be allowed: %v", err)
+	}
+
+	// A symlink into a secret store is resolved before checking.
+	link := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.Symlink(filepath.Join(home, ".config", "kafclaw", "tomb.rr"), link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	out, _ = read.Execute(context.Background(), map[string]any{"path": link})
+	if !strings.Contains(out, "is blocked") {
+		t.Fatalf("expected symlinked secret to be blocked, got %q", out)
+	}
+}
+
+func TestReadPolicyAllowRootsAndDenyPaths(t *testing.T) {
+	setupReadPolicyHome(t)
+	allowed := t.TempDir()
+	repo := t.TempDir()
+	outside := t.TempDir()
+	for _, dir := range []string{allowed, repo, outside} {
+		if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	policy := NewReadPolicy(config.ReadToolConfig{
+		AllowRoots: []string{allowed},
+		DenyPaths:  []string{filepath.Join(allowed, "private")},
+	}, func() []string { return []string{repo} })
+	read := NewReadFileToolWithPolicy(policy)
+
+	for _, path := range []string{filepath.Join(allowed, "a.txt"), filepath.Join(repo, "a.txt")} {
+		if out, _ := read.Execute(context.Background(), map[string]any{"path": path}); out != "hello" {
+			t.Fatalf("expected read of %s, got %q", path, out)
+		}
+	}
+	if out, _ := read.Execute(context.Background(), map[string]any{"path": filepath.Join(outside, "a.txt")}); !strings.Contains(out, "outside tools.read.allowRoots") {
+		t.Fatalf("expected allowRoots block, got %q", out)
+	}
+	if err := read.CheckCall(map[string]any{"path": filepath.Join(allowed, "private", "x.txt")}); err == nil {
+		t.Fatal("expected deny path to win over allow roots")
+	}
+
+	// Escaping the allow root through a symlink is refused.
+	link := filepath.Join(allowed, "escape.txt")
+	if err := os.Symlink(filepath.Join(outside, "a.txt"), link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := read.CheckCall(map[string]any{"path": link}); err == nil {
+		t.Fatal("expected symlink escape to be blocked")
+	}
+}
+
+func TestSearchToolsApplyReadPolicy(t *testing.T) {
+	setupReadPolicyHome(t)
+	allowed := t.TempDir()
+	outside := t.TempDir()
+	writeTree(t, allowed, map[string]string{
+		"a.txt":              "needle\n",
+		"private/secret.txt": "needle\n",
+		".env":               "TOKEN=needle\n",
+	})
+	writeTree(t, outside, map[string]string{"b.txt": "needle\n"})
+	policy := NewReadPolicy(config.ReadToolConfig{
+		AllowRoots: []string{allowed},
+		DenyPaths:  []string{filepath.Join(allowed, "private")},
+	}, nil)
+	// No work repo: the policy alone limits what the tools open.
+	noRepo := func() string { return "" }
+	grep := NewGrepToolWithPolicy(noRepo, policy)
+	glob := NewGlobToolWithPolicy(noRepo, policy)
+	ctx := context.Background()
+
+	out, _ := grep.Execute(ctx, map[string]any{"pattern": "needle", "path": allowed})
+	if !strings.Contains(out, "a.txt:1:needle") || strings.Contains(out, "secret.txt") || strings.Contains(out, ".env") {
+		t.Fatalf("expected only a.txt to be searched, got %q", out)
+	}
+	out, _ = glob.Execute(ctx, map[string]any{"pattern": "**", "path": allowed})
+	if !strings.Contains(out, "a.txt") || !strings.Contains(out, ".env") || strings.Contains(out, "secret.txt") {
+		t.Fatalf("expected denied files left out of glob, got %q", out)
+	}
+	for name, tool := range map[string]Tool{"grep": grep, "glob": glob} {
+		out, _ := tool.Execute(ctx, map[string]any{"pattern": "needle", "path": outside})
+		if !strings.Contains(out, "outside tools.read.allowRoots") {
+			t.Fatalf("%s: expected allowRoots block, got %q", name, out)
+		}
+	}
+}
+
+func TestReadFileSensitiveCallTier(t *testing.T) {
+	read := NewReadFileTool()
+	dir := t.TempDir()
+	for _, path := range []string{
+		filepath.Join(dir, ".env"),
+		filepath.Join(dir, ".env.production"),
+		filepath.Join(dir, ".ssh", "config"),
+		filepath.Join(dir, "server.pem"),
+		filepath.Join(dir, "id_ed25519"),
+		"~/.aws/credentials",
+	} {
+		if tier := ToolCallTier(read, map[string]any{"path": path}); tier != TierHighRisk {
+			t.Errorf("%s: tier = %d, want %d", path, tier, TierHighRisk)
+		}
+	}
+	for _, path := range []string{filepath.Join(dir, "README.md"), filepath.Join(dir, "env.go")} {
+		if tier := ToolCallTier(read, map[string]any{"path": path}); tier != TierReadOnly {
+			t.Errorf("%s: tier = %d, want %d", path, tier, TierReadOnly)
+		}
+	}
+}
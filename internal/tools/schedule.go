@@ -0,0 +1,204 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/scheduler"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// ScheduleTool lets the agent create reminders and recurring jobs. Jobs run
+// in the conversation that created them and reply there.
+type ScheduleTool struct {
+	timeline *timeline.TimelineService
+	location *time.Location
+	target   func(context.Context) scheduler.Target
+	now      func() time.Time
+}
+
+// NewScheduleTool creates the schedule tool. loc is the default timezone of
+// schedules; target returns the current conversation.
+func NewScheduleTool(tl *timeline.TimelineService, loc *time.Location, target func(context.Context) scheduler.Target) *ScheduleTool {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &ScheduleTool{timeline: tl, location: loc, target: target, now: time.Now}
+}
+
+func (t *ScheduleTool) Name() string { return "schedule" }
+func (t *ScheduleTool) Tier() int    { return TierWrite }
+
+// CallTier is 0 for list and runs.
+func (t *ScheduleTool) CallTier(params map[string]any) int {
+	switch scheduleAction(params) {
+	case "list", "runs":
+		return TierReadOnly
+	}
+	return TierWrite
+}
+
+func (t *ScheduleTool) Description() string {
+	return fmt.Sprintf("Schedule reminders and recurring tasks for this chat. When a job is due, you get its message as a new request here and your reply goes to the user. "+
+		"when examples: \"in 2 hours\", \"tomorrow 9:00\", \"at 2026-03-01 14:30\", \"every weekday 9:00 Europe/Berlin\", \"every 30m\", \"cron 0 8 * * 1\". Default timezone: %s.", t.location)
+}
+
+func (t *ScheduleTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"description": "Action (default list)",
+				"enum":        []string{"add", "list", "remove", "pause", "resume", "runs"},
+			},
+			"when": map[string]any{
+				"type":        "string",
+				"description": "Schedule for action=add",
+			},
+			"message": map[string]any{
+				"type":        "string",
+				"description": "What to do when the job is due, e.g. \"Remind me to call Anna\" (action=add)",
+			},
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Job name; optional for add, required for remove, pause, resume and runs",
+			},
+			"misfire": map[string]any{
+				"type":        "string",
+				"enum":        []string{scheduler.MisfireRunOnce, scheduler.MisfireSkip, scheduler.MisfireRunAll},
+				"description": "Runs missed while offline: run once (default), skip, or run all",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "Runs to return for action=runs (default 10)",
+			},
+		},
+	}
+}
+
+// scheduleJob is a job as returned to the model.
+type scheduleJob struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	Message  string `json:"message"`
+	Status   string `json:"status"`
+	NextRun  string `json:"nextRun,omitempty"`
+	LastRun  string `json:"lastRun,omitempty"`
+	Runs     int    `json:"runs"`
+	Channel  string `json:"channel,omitempty"`
+}
+
+func (t *ScheduleTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	name := GetString(params, "name", "")
+	switch action := scheduleAction(params); action {
+	case "add":
+		job, err := scheduler.AddJob(t.timeline, scheduler.JobSpec{
+			Name:      name,
+			When:      GetString(params, "when", ""),
+			Content:   GetString(params, "message", ""),
+			Target:    t.target(ctx),
+			Misfire:   GetString(params, "misfire", ""),
+			CreatedBy: "agent",
+		}, t.now(), t.location)
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err), nil
+		}
+		return jsonResult(t.describe(*job))
+	case "list":
+		jobs, err := scheduler.ListJobs(t.timeline)
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err), nil
+		}
+		// Only jobs of this conversation are listed.
+		current := t.target(ctx)
+		out := []scheduleJob{}
+		for _, j := range jobs {
+			if j.Channel == current.Channel && j.ChatID == current.ChatID {
+				out = append(out, t.describe(j))
+			}
+		}
+		return jsonResult(map[string]any{"jobs": out})
+	case "remove", "pause", "resume", "runs":
+		if strings.TrimSpace(name) == "" {
+			return fmt.Sprintf("Error: name is required for %s", action), nil
+		}
+		if err := t.checkOwner(ctx, name); err != nil {
+			return fmt.Sprintf("Error: %v", err), nil
+		}
+		return t.manage(action, name, GetInt(params, "limit", 10))
+	default:
+		return fmt.Sprintf("Error: unknown action %q", action), nil
+	}
+}
+
+func (t *ScheduleTool) manage(action, name string, limit int) (string, error) {
+	var err error
+	switch action {
+	case "remove":
+		err = scheduler.RemoveJob(t.timeline, name)
+	case "pause":
+		err = scheduler.PauseJob(t.timeline, name)
+	case "resume":
+		_, err = scheduler.ResumeJob(t.timeline, name, t.now())
+	case "runs":
+		runs, err := t.timeline.ListScheduledJobRuns(strings.ToLower(strings.TrimSpace(name)), limit)
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err), nil
+		}
+		return jsonResult(map[string]any{"name": name, "runs": runs})
+	}
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	if action == "remove" {
+		return jsonResult(map[string]any{"name": name, "removed": true})
+	}
+	job, err := t.timeline.GetScheduledJob(strings.ToLower(strings.TrimSpace(name)))
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	return jsonResult(t.describe(*job))
+}
+
+// checkOwner refuses jobs of other conversations.
+func (t *ScheduleTool) checkOwner(ctx context.Context, name string) error {
+	job, err := t.timeline.GetScheduledJob(strings.ToLower(strings.TrimSpace(name)))
+	current := t.target(ctx)
+	if err != nil || job.Kind == "" || job.Channel != current.Channel || job.ChatID != current.ChatID {
+		return fmt.Errorf("job %q not found in this chat", name)
+	}
+	return nil
+}
+
+func (t *ScheduleTool) describe(j timeline.ScheduledJobRecord) scheduleJob {
+	out := scheduleJob{
+		Name:     j.JobName,
+		Schedule: scheduler.DescribeSchedule(j),
+		Message:  j.Content,
+		Status:   scheduler.JobStatus(j),
+		Runs:     j.RunCount,
+		Channel:  j.Channel,
+	}
+	loc := t.location
+	if l, err := scheduler.Location(j.Timezone); err == nil {
+		loc = l
+	}
+	if j.NextRunAt != nil {
+		out.NextRun = j.NextRunAt.In(loc).Format(time.RFC3339)
+	}
+	if !j.LastRunAt.IsZero() {
+		out.LastRun = j.LastRunAt.In(loc).Format(time.RFC3339)
+	}
+	return out
+}
+
+func scheduleAction(params map[string]any) string {
+	action := strings.ToLower(strings.TrimSpace(GetString(params, "action", "list")))
+	if action == "" {
+		return "list"
+	}
+	return action
+}
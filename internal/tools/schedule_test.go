@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/scheduler"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestScheduleToolManagesJobsOfCurrentChat(t *testing.T) {
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	defer tl.Close()
+
+	target := scheduler.Target{Channel: "telegram", ChatID: "42", ThreadID: "7", SenderID: "alice", MessageType: "external"}
+	tool := NewScheduleTool(tl, time.UTC, func(context.Context) scheduler.Target { return target })
+	tool.now = func() time.Time { return time.Date(2026, 3, 6, 10, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	out, _ := tool.Execute(ctx, map[string]any{"action": "add", "name": "standup", "when": "every weekday 9:00", "message": "Summarize open PRs"})
+	var job scheduleJob
+	decodeToolJSON(t, out, &job)
+	if job.Name != "standup" || job.Status != "active" || job.NextRun != "2026-03-09T09:00:00Z" {
+		t.Fatalf("unexpected job %+v", job)
+	}
+	stored, _ := tl.GetScheduledJob("standup")
+	if stored.Channel != "telegram" || stored.ChatID != "42" || stored.ThreadID != "7" || stored.CreatedBy != "agent" {
+		t.Fatalf("job not bound to the current chat: %+v", stored)
+	}
+	if out, _ := tool.Execute(ctx, map[string]any{"action": "add", "when": "yesterday-ish", "message": "x"}); !strings.HasPrefix(out, "Error:") {
+		t.Fatalf("expected parse error, got %q", out)
+	}
+
+	out, _ = tool.Execute(ctx, map[string]any{"action": "pause", "name": "standup"})
+	decodeToolJSON(t, out, &job)
+	if job.Status != "paused" {
+		t.Fatalf("expected paused job, got %+v", job)
+	}
+
+	// Another chat neither sees nor controls the job.
+	other := NewScheduleTool(tl, time.UTC, func(context.Context) scheduler.Target { return scheduler.Target{Channel: "telegram", ChatID: "99"} })
+	var listed struct {
+		Jobs []scheduleJob `json:"jobs"`
+	}
+	out, _ = other.Execute(ctx, map[string]any{"action": "list"})
+	decodeToolJSON(t, out, &listed)
+	if len(listed.Jobs) != 0 {
+		t.Fatalf("other chat sees jobs: %+v", listed.Jobs)
+	}
+	if out, _ := other.Execute(ctx, map[string]any{"action": "remove", "name": "standup"}); !strings.Contains(out, "not found in this chat") {
+		t.Fatalf("expected ownership error, got %q", out)
+	}
+
+	out, _ = tool.Execute(ctx, map[string]any{})
+	decodeToolJSON(t, out, &listed)
+	if len(listed.Jobs) != 1 || listed.Jobs[0].Name != "standup" {
+		t.Fatalf("expected own job in list, got %+v", listed.Jobs)
+	}
+	if out, _ := tool.Execute(ctx, map[string]any{"action": "remove", "name": "standup"}); !strings.Contains(out, `"removed":true`) {
+		t.Fatalf("expected removal, got %q", out)
+	}
+
+	if ToolCallTier(tool, map[string]any{"action": "list"}) != TierReadOnly || ToolCallTier(tool, map[string]any{"action": "add"}) != TierWrite {
+		t.Fatal("unexpected call tiers")
+	}
+}
@@ -0,0 +1,558 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+const (
+	grepDefaultMaxResults = 100
+	grepMaxResults        = 1000
+	grepMaxContext        = 10
+	grepMaxFileBytes      = 5 << 20
+	grepMaxLineChars      = 500
+	globDefaultMaxResults = 200
+	globMaxResults        = 2000
+	binarySniffBytes      = 8000
+)
+
+// searchScope resolves the directory a search tool walks. With a work repo
+// configured the search stays inside it, like writes do. Files the read
+// policy blocks are never opened.
+type searchScope struct {
+	workRepoRoot func() string
+	policy       *ReadPolicy
+}
+
+func newSearchScope(workRepoGetter func() string, policy *ReadPolicy) searchScope {
+	if policy == nil {
+		policy = NewReadPolicy(config.ReadToolConfig{}, nil)
+	}
+	return searchScope{workRepoRoot: workRepoGetter, policy: policy}
+}
+
+// resolve returns the absolute start path and the root that results are
+// reported relative to.
+func (s searchScope) resolve(p string) (start, root string, err error) {
+	if s.workRepoRoot != nil {
+		root = normalizeRoot(s.workRepoRoot())
+	}
+	if strings.TrimSpace(p) == "" {
+		if root == "" {
+			return "", "", fmt.Errorf("path is required when no work repo is configured")
+		}
+		return root, root, nil
+	}
+	if root != "" && !filepath.IsAbs(p) && !strings.HasPrefix(p, "~") {
+		p = filepath.Join(root, p)
+	}
+	p = expandPath(p)
+	if root != "" && !isWithin(root, p) {
+		return "", "", fmt.Errorf("path outside work repo")
+	}
+	if err := s.policy.Check(p); err != nil {
+		return "", "", err
+	}
+	if root == "" {
+		root = p
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			root = filepath.Dir(p)
+		}
+	}
+	return p, root, nil
+}
+
+// walk is walkFiles without the files the read policy blocks.
+func (s searchScope) walk(ctx context.Context, root, start string, fn func(abs, rel string) bool) error {
+	check := s.policy.checker()
+	return walkFiles(ctx, root, start, func(abs, rel string) bool {
+		if check(abs) != nil {
+			return true
+		}
+		return fn(abs, rel)
+	})
+}
+
+// walkFiles calls fn for every regular file below start that is not
+// excluded by .gitignore rules between root and the file. .git is skipped.
+func walkFiles(ctx context.Context, root, start string, fn func(abs, rel string) bool) error {
+	info, err := os.Stat(start)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		rel, _ := filepath.Rel(root, start)
+		fn(start, filepath.ToSlash(rel))
+		return nil
+	}
+	ignore := &ignoreMatcher{}
+	// Rules from directories above the start path still apply.
+	if rel, err := filepath.Rel(root, start); err == nil && rel != "." {
+		dir := ""
+		ignore.load(root, dir)
+		for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+			dir = path.Join(dir, part)
+			ignore.load(root, dir)
+		}
+	}
+	stop := fmt.Errorf("stop")
+	err = filepath.WalkDir(start, func(abs string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rel, relErr := filepath.Rel(root, abs)
+		if relErr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if abs != start && ignore.match(rel, true) {
+				return filepath.SkipDir
+			}
+			if rel == "." {
+				rel = ""
+			}
+			ignore.load(root, rel)
+			return nil
+		}
+		if !d.Type().IsRegular() || ignore.match(rel, false) {
+			return nil
+		}
+		if !fn(abs, rel) {
+			return stop
+		}
+		return nil
+	})
+	if err == stop {
+		return nil
+	}
+	return err
+}
+
+// ignoreMatcher implements the commonly used subset of .gitignore:
+// comments, negation, directory-only rules, anchored rules and "**".
+type ignoreMatcher struct {
+	rules  []ignoreRule
+	loaded map[string]bool
+}
+
+type ignoreRule struct {
+	base     string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func (m *ignoreMatcher) load(root, relDir string) {
+	if m.loaded == nil {
+		m.loaded = map[string]bool{}
+	}
+	if m.loaded[relDir] {
+		return
+	}
+	m.loaded[relDir] = true
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(relDir), ".gitignore"))
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rule, ok := parseIgnoreRule(relDir, line); ok {
+			m.rules = append(m.rules, rule)
+		}
+	}
+}
+
+func parseIgnoreRule(base, line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	line = strings.TrimRight(line, " ")
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	rule.pattern = line
+	return rule, true
+}
+
+// match reports whether rel (slash separated, relative to the walk root)
+// is ignored. The last matching rule wins.
+func (m *ignoreMatcher) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		sub := rel
+		if r.base != "" {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			sub = strings.TrimPrefix(rel, r.base+"/")
+		}
+		if r.dirOnly && !isDir {
+			continue
+		}
+		var ok bool
+		if r.anchored {
+			ok = matchGlob(r.pattern, sub)
+		} else {
+			ok = matchGlob(r.pattern, path.Base(sub))
+		}
+		if ok {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// matchGlob matches a slash separated path against a pattern where "**"
+// spans any number of directories and other segments use path.Match.
+func matchGlob(pattern, name string) bool {
+	return matchGlobParts(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchGlobParts(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			rest := pat[1:]
+			if len(rest) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchGlobParts(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pat[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchFileGlob applies a user filter: patterns without a slash match the
+// file name at any depth, others match the whole relative path.
+func matchFileGlob(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		return matchGlob(pattern, path.Base(rel))
+	}
+	return matchGlob(strings.TrimPrefix(pattern, "/"), rel)
+}
+
+// isBinary reports whether data looks like a binary file.
+func isBinary(data []byte) bool {
+	if len(data) > binarySniffBytes {
+		data = data[:binarySniffBytes]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// GrepTool searches file contents with a regular expression.
+type GrepTool struct {
+	scope searchScope
+}
+
+// NewGrepTool creates a GrepTool restricted to the work repo that skips
+// KafClaw's secret stores.
+func NewGrepTool(workRepoGetter func() string) *GrepTool {
+	return NewGrepToolWithPolicy(workRepoGetter, nil)
+}
+
+// NewGrepToolWithPolicy creates a GrepTool with a read policy. A nil policy
+// only denies KafClaw's secret stores.
+func NewGrepToolWithPolicy(workRepoGetter func() string, policy *ReadPolicy) *GrepTool {
+	return &GrepTool{scope: newSearchScope(workRepoGetter, policy)}
+}
+
+func (t *GrepTool) Name() string { return "grep" }
+func (t *GrepTool) Tier() int    { return TierReadOnly }
+
+func (t *GrepTool) Description() string {
+	return "Search file contents in the work repo with a regular expression (RE2 syntax). Skips .gitignore'd, binary and credential files. Output lines are path:line:text, context lines use path-line-text."
+}
+
+func (t *GrepTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"pattern": map[string]any{
+				"type":        "string",
+				"description": "Regular expression to search for",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "File or directory to search, relative to the work repo (default: work repo root)",
+			},
+			"glob": map[string]any{
+				"type":        "string",
+				"description": "Only search files matching this glob (e.g. *.go, internal/**/*.ts)",
+			},
+			"ignore_case": map[string]any{
+				"type":        "boolean",
+				"description": "Case-insensitive match",
+			},
+			"context": map[string]any{
+				"type":        "integer",
+				"description": "Lines of context before and after each match (max 10)",
+			},
+			"files_only": map[string]any{
+				"type":        "boolean",
+				"description": "Only list files that contain a match",
+			},
+			"max_results": map[string]any{
+				"type":        "integer",
+				"description": "Maximum matches (or files with files_only) to return (default 100, max 1000)",
+			},
+		},
+		"required": []string{"pattern"},
+	}
+}
+
+func (t *GrepTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	pattern := GetString(params, "pattern", "")
+	if pattern == "" {
+		return "Error: pattern is required", nil
+	}
+	if GetBool(params, "ignore_case", false) {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Sprintf("Error: invalid pattern: %v", err), nil
+	}
+	start, root, err := t.scope.resolve(GetString(params, "path", ""))
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	glob := strings.TrimSpace(GetString(params, "glob", ""))
+	contextLines := min(max(GetInt(params, "context", 0), 0), grepMaxContext)
+	filesOnly := GetBool(params, "files_only", false)
+	maxResults := GetInt(params, "max_results", grepDefaultMaxResults)
+	if maxResults < 1 {
+		maxResults = grepDefaultMaxResults
+	}
+	maxResults = min(maxResults, grepMaxResults)
+
+	var out strings.Builder
+	count := 0
+	truncated := false
+	err = t.scope.walk(ctx, root, start, func(abs, rel string) bool {
+		if glob != "" && !matchFileGlob(glob, rel) {
+			return true
+		}
+		// Credential files need approval through read_file.
+		if t.scope.policy.Sensitive(abs) {
+			return true
+		}
+		info, err := os.Stat(abs)
+		if err != nil || info.Size() > grepMaxFileBytes {
+			return true
+		}
+		data, err := os.ReadFile(abs)
+		if err != nil || isBinary(data) {
+			return true
+		}
+		lines := strings.Split(string(data), "\n")
+		var hits []int
+		for i, line := range lines {
+			if re.MatchString(line) {
+				hits = append(hits, i)
+			}
+		}
+		if len(hits) == 0 {
+			return true
+		}
+		if filesOnly {
+			out.WriteString(rel + "\n")
+			count++
+			if count >= maxResults {
+				truncated = true
+				return false
+			}
+			return true
+		}
+		if remaining := maxResults - count; len(hits) > remaining {
+			hits = hits[:remaining]
+			truncated = true
+		}
+		writeGrepHits(&out, rel, lines, hits, contextLines)
+		count += len(hits)
+		return !truncated
+	})
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	if count == 0 {
+		return "No matches found.", nil
+	}
+	if truncated {
+		fmt.Fprintf(&out, "(results truncated at %d; narrow the pattern, path or glob)\n", maxResults)
+	}
+	return out.String(), nil
+}
+
+func writeGrepHits(out *strings.Builder, rel string, lines []string, hits []int, contextLines int) {
+	isHit := make(map[int]bool, len(hits))
+	for _, h := range hits {
+		isHit[h] = true
+	}
+	last := -1
+	for _, h := range hits {
+		from := max(h-contextLines, last+1)
+		to := min(h+contextLines, len(lines)-1)
+		if contextLines > 0 && last >= 0 && from > last+1 {
+			out.WriteString("--\n")
+		}
+		for i := from; i <= to; i++ {
+			if i <= last {
+				continue
+			}
+			sep := "-"
+			if isHit[i] {
+				sep = ":"
+			}
+			line := lines[i]
+			if len(line) > grepMaxLineChars {
+				line = line[:grepMaxLineChars] + "..."
+			}
+			fmt.Fprintf(out, "%s%s%d%s%s\n", rel, sep, i+1, sep, line)
+			last = i
+		}
+	}
+}
+
+// GlobTool lists files matching a glob pattern.
+type GlobTool struct {
+	scope searchScope
+}
+
+// NewGlobTool creates a GlobTool restricted to the work repo that skips
+// KafClaw's secret stores.
+func NewGlobTool(workRepoGetter func() string) *GlobTool {
+	return NewGlobToolWithPolicy(workRepoGetter, nil)
+}
+
+// NewGlobToolWithPolicy creates a GlobTool with a read policy. A nil policy
+// only denies KafClaw's secret stores.
+func NewGlobToolWithPolicy(workRepoGetter func() string, policy *ReadPolicy) *GlobTool {
+	return &GlobTool{scope: newSearchScope(workRepoGetter, policy)}
+}
+
+func (t *GlobTool) Name() string { return "glob" }
+func (t *GlobTool) Tier() int    { return TierReadOnly }
+
+func (t *GlobTool) Description() string {
+	return "List files in the work repo matching a glob pattern (e.g. **/*.go, cmd/*/main.go). Patterns without a slash match file names at any depth. Skips .gitignore'd files."
+}
+
+func (t *GlobTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"pattern": map[string]any{
+				"type":        "string",
+				"description": "Glob pattern; ** matches any number of directories",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Directory to search, relative to the work repo (default: work repo root)",
+			},
+			"max_results": map[string]any{
+				"type":        "integer",
+				"description": "Maximum files to return (default 200, max 2000)",
+			},
+		},
+		"required": []string{"pattern"},
+	}
+}
+
+func (t *GlobTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	pattern := strings.TrimSpace(GetString(params, "pattern", ""))
+	if pattern == "" {
+		return "Error: pattern is required", nil
+	}
+	if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+		return fmt.Sprintf("Error: invalid pattern: %v", err), nil
+	}
+	start, root, err := t.scope.resolve(GetString(params, "path", ""))
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	maxResults := GetInt(params, "max_results", globDefaultMaxResults)
+	if maxResults < 1 {
+		maxResults = globDefaultMaxResults
+	}
+	maxResults = min(maxResults, globMaxResults)
+
+	// Patterns are relative to the search path, results to the root.
+	prefix, _ := filepath.Rel(root, start)
+	prefix = filepath.ToSlash(prefix)
+	var files []string
+	truncated := false
+	err = t.scope.walk(ctx, root, start, func(abs, rel string) bool {
+		sub := rel
+		if prefix != "." && prefix != "" {
+			sub = strings.TrimPrefix(rel, prefix+"/")
+		}
+		if !matchFileGlob(pattern, sub) {
+			return true
+		}
+		if len(files) >= maxResults {
+			truncated = true
+			return false
+		}
+		files = append(files, rel)
+		return true
+	})
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	if len(files) == 0 {
+		return "No files found.", nil
+	}
+	sort.Strings(files)
+	var out strings.Builder
+	for _, f := range files {
+		out.WriteString(f + "\n")
+	}
+	if truncated {
+		fmt.Fprintf(&out, "(results truncated at %d; narrow the pattern or path)\n", maxResults)
+	}
+	return out.String(), nil
+}
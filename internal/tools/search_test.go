@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGrepTool_GitignoreGlobAndContext(t *testing.T) {
+	repo := t.TempDir()
+	writeTree(t, repo, map[string]string{
+		".gitignore":       "build/\n*.log\n!keep.log\n",
+		"main.go":          "package main\n\nfunc main() {\n\tTODO()\n}\n",
+		"pkg/util.go":      "package pkg\n// TODO: tidy\n",
+		"pkg/util_test.go": "package pkg\n// TODO in test\n",
+		"pkg/.gitignore":   "generated.go\n",
+		"pkg/generated.go": "// TODO generated\n",
+		"build/out.go":     "// TODO build\n",
+		"debug.log":        "TODO log\n",
+		"keep.log":         "TODO kept\n",
+		"bin.dat":          "TODO\x00binary",
+		".git/config":      "TODO git\n",
+		"docs/readme.md":   "nothing here\n",
+	})
+	tool := NewGrepTool(func() string { return repo })
+	ctx := context.Background()
+
+	out, _ := tool.Execute(ctx, map[string]any{"pattern": "TODO", "files_only": true})
+	got := strings.Fields(out)
+	want := []string{"keep.log", "main.go", "pkg/util.go", "pkg/util_test.go"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	out, _ = tool.Execute(ctx, map[string]any{"pattern": "todo", "ignore_case": true, "glob": "*.go", "path": "pkg"})
+	if !strings.Contains(out, "pkg/util.go:2:// TODO: tidy") || strings.Contains(out, "generated") {
+		t.Fatalf("unexpected filtered output: %q", out)
+	}
+
+	out, _ = tool.Execute(ctx, map[string]any{"pattern": `TODO\(\)`, "path": "main.go", "context": float64(1)})
+	if out != "main.go-3-func main() {\nmain.go:4:\tTODO()\nmain.go-5-}\n" {
+		t.Fatalf("unexpected context output: %q", out)
+	}
+
+	out, _ = tool.Execute(ctx, map[string]any{"pattern": "TODO", "max_results": float64(1)})
+	if !strings.Contains(out, "truncated at 1") {
+		t.Fatalf("expected truncation note, got %q", out)
+	}
+
+	if out, _ := tool.Execute(ctx, map[string]any{"pattern": "x", "path": "/etc"}); !strings.Contains(out, "outside work repo") {
+		t.Fatalf("expected work repo restriction, got %q", out)
+	}
+	if out, _ := tool.Execute(ctx, map[string]any{"pattern": "("}); !strings.Contains(out, "invalid pattern") {
+		t.Fatalf("expected invalid pattern error, got %q", out)
+	}
+}
+
+func TestGlobTool(t *testing.T) {
+	repo := t.TempDir()
+	writeTree(t, repo, map[string]string{
+		".gitignore":           "vendor/\n",
+		"cmd/app/main.go":      "",
+		"internal/a/a.go":      "",
+		"internal/a/a_test.go": "",
+		"internal/b/deep/b.go": "",
+		"vendor/x/x.go":        "",
+		"README.md":            "",
+	})
+	tool := NewGlobTool(func() string { return repo })
+	ctx := context.Background()
+
+	out, _ := tool.Execute(ctx, map[string]any{"pattern": "internal/**/*.go"})
+	if out != "internal/a/a.go\ninternal/a/a_test.go\ninternal/b/deep/b.go\n" {
+		t.Fatalf("unexpected ** output: %q", out)
+	}
+	out, _ = tool.Execute(ctx, map[string]any{"pattern": "*_test.go"})
+	if out != "internal/a/a_test.go\n" {
+		t.Fatalf("unexpected basename output: %q", out)
+	}
+	out, _ = tool.Execute(ctx, map[string]any{"pattern": "*/main.go", "path": "cmd"})
+	if out != "cmd/app/main.go\n" {
+		t.Fatalf("unexpected relative output: %q", out)
+	}
+	if out, _ := tool.Execute(ctx, map[string]any{"pattern": "x.go"}); out != "No files found." {
+		t.Fatalf("expected vendor to be ignored, got %q", out)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"**/*.go", "a.go", true},
+		{"**/*.go", "a/b/c.go", true},
+		{"a/**", "a/b/c", true},
+		{"a/**/c", "a/c", true},
+		{"a/*/c", "a/b/x/c", false},
+		{"*.go", "a/b.go", false},
+	}
+	for _, c := range cases {
+		if got := matchGlob(c.pattern, c.name); got != c.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", c.pattern, c.name, got, c.want)
+		}
+	}
+}
+
+func TestReadFileTool_RangeAndBinary(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"f.txt":   "one\ntwo\nthree\nfour\n",
+		"bin.dat": "ab\x00cd",
+	})
+	tool := NewReadFileTool()
+	ctx := context.Background()
+
+	out, _ := tool.Execute(ctx, map[string]any{"path": filepath.Join(dir, "f.txt"), "offset": float64(2), "limit": float64(2)})
+	if out != "     2\ttwo\n     3\tthree\n... (1 more lines; continue with offset=4)\n" {
+		t.Fatalf("unexpected ranged output: %q", out)
+	}
+	out, _ = tool.Execute(ctx, map[string]any{"path": filepath.Join(dir, "f.txt"), "offset": float64(4)})
+	if out != "     4\tfour\n" {
+		t.Fatalf("unexpected tail output: %q", out)
+	}
+	if out, _ := tool.Execute(ctx, map[string]any{"path": filepath.Join(dir, "f.txt"), "offset": float64(9)}); !strings.Contains(out, "past the end") {
+		t.Fatalf("expected past-end error, got %q", out)
+	}
+	if out, _ := tool.Execute(ctx, map[string]any{"path": filepath.Join(dir, "bin.dat")}); !strings.Contains(out, "binary file") {
+		t.Fatalf("expected binary detection, got %q", out)
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/KafClaw/KafClaw/internal/sandbox"
 )
 
 // DenyPatterns contains regex patterns for dangerous commands.
@@ -74,6 +77,14 @@ type ExecTool struct {
 	pathRegexes         []*regexp.Regexp
 	allowRegexes        []*regexp.Regexp
 	StrictAllowList     bool
+	// Isolation selects "native" to run commands in the Linux sandbox
+	// (writable: work repo and a private /tmp); anything else runs on the host.
+	Isolation string
+	// AllowNetwork keeps network access inside the native sandbox.
+	AllowNetwork bool
+	// StrictSandbox refuses to run when the native sandbox lacks Landlock,
+	// seccomp or cgroup limits.
+	StrictSandbox bool
 }
 
 // NewExecTool creates a new ExecTool.
@@ -160,16 +171,17 @@ func (t *ExecTool) Execute(ctx context.Context, params map[string]any) (string,
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "sh", "-c", command)
-	if workingDir != "" {
-		cmd.Dir = workingDir
+	cmd, release, err := t.command(ctx, command, workingDir)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
 	}
+	defer release()
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 
 	// Build result
 	var result strings.Builder
@@ -203,6 +215,50 @@ func (t *ExecTool) Execute(ctx context.Context, params map[string]any) (string,
 	return result.String(), nil
 }
 
+// command builds the shell invocation, sandboxed when native isolation is
+// selected.
+func (t *ExecTool) command(ctx context.Context, command, workingDir string) (*exec.Cmd, func(), error) {
+	if t.Isolation != "native" {
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		if workingDir != "" {
+			cmd.Dir = workingDir
+		}
+		return cmd, func() {}, nil
+	}
+	var writable []string
+	if t.workRepoGetter != nil {
+		if repo := t.workRepoGetter(); repo != "" {
+			writable = append(writable, repo)
+		}
+	}
+	return sandbox.Command(ctx, sandbox.Policy{
+		WritableDirs: writable,
+		WorkDir:      workingDir,
+		Env:          sandboxEnv(workingDir),
+		Network:      t.AllowNetwork,
+		Strict:       t.StrictSandbox,
+	}, []string{"sh", "-c", command})
+}
+
+// sandboxEnv is the environment of sandboxed commands. The host environment
+// is not passed on, so credentials in it stay outside the sandbox.
+func sandboxEnv(home string) []string {
+	path := os.Getenv("PATH")
+	if strings.TrimSpace(path) == "" {
+		path = "/usr/local/bin:/usr/bin:/bin"
+	}
+	if home == "" {
+		home = "/tmp"
+	}
+	return []string{
+		"PATH=" + path,
+		"HOME=" + home,
+		"TMPDIR=/tmp",
+		"LANG=C.UTF-8",
+		"LC_ALL=C.UTF-8",
+	}
+}
+
 func (t *ExecTool) guardCommand(command, workingDir string) error {
 	normalized := strings.ToLower(command)
 
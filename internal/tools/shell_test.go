@@ -2,11 +2,20 @@ package tools
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/KafClaw/KafClaw/internal/sandbox"
 )
 
+func TestMain(m *testing.M) {
+	sandbox.Init()
+	os.Exit(m.Run())
+}
+
 func TestExecTool_Basic(t *testing.T) {
 	tool := NewExecTool(5*time.Second, false, "", nil)
 	tool.StrictAllowList = false
@@ -116,3 +125,49 @@ func TestExecTool_ExitCode(t *testing.T) {
 		t.Errorf("expected 'Exit code: 42' in output, got '%s'", result)
 	}
 }
+
+func TestExecTool_NativeIsolationConfinesWrites(t *testing.T) {
+	if err := sandbox.Preflight(); err != nil {
+		t.Skipf("native sandbox unavailable: %v", err)
+	}
+	repo := t.TempDir()
+	outside := t.TempDir()
+	tool := NewExecTool(10*time.Second, false, repo, func() string { return repo })
+	tool.StrictAllowList = false
+	tool.Isolation = "native"
+
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"command": "echo ok > result.txt && echo leaked > " + filepath.Join(outside, "leak.txt"),
+	})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(repo, "result.txt")); err != nil || string(data) != "ok\n" {
+		t.Fatalf("expected write inside work repo, got %q, %v (result %q)", data, err, result)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "leak.txt")); err == nil {
+		t.Fatalf("write outside work repo succeeded: %q", result)
+	}
+	if !strings.Contains(result, "Exit code") {
+		t.Errorf("expected failing redirect to be reported, got %q", result)
+	}
+}
+
+func TestExecTool_NativeIsolationUsesMinimalEnv(t *testing.T) {
+	if err := sandbox.Preflight(); err != nil {
+		t.Skipf("native sandbox unavailable: %v", err)
+	}
+	t.Setenv("KAFCLAW_TEST_SECRET", "leak")
+	repo := t.TempDir()
+	tool := NewExecTool(10*time.Second, false, repo, func() string { return repo })
+	tool.StrictAllowList = false
+	tool.Isolation = "native"
+
+	result, err := tool.Execute(context.Background(), map[string]any{"command": "env"})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if strings.Contains(result, "KAFCLAW_TEST_SECRET") || !strings.Contains(result, "HOME="+repo) {
+		t.Fatalf("expected only the minimal environment, got %q", result)
+	}
+}
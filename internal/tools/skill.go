@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/skills"
+)
+
+// SkillTool exposes a tool declared in a skill's SKILL-TOOLS.json. Commands
+// run through skills.ExecuteSkillCommand with the skill's isolation and
+// SKILL-POLICY.json limits; HTTP targets go through http_request after the
+// skill's network and link policy checks.
+type SkillTool struct {
+	def  skills.SkillTool
+	cfg  *config.Config
+	http *HTTPRequestTool
+	run  func(cfg *config.Config, skill string, command []string) (*skills.SkillExecResult, error)
+}
+
+// NewSkillTool creates the tool for one manifest entry.
+func NewSkillTool(cfg *config.Config, def skills.SkillTool) *SkillTool {
+	return &SkillTool{
+		def:  def,
+		cfg:  cfg,
+		http: NewHTTPRequestTool(cfg.Tools.HTTP),
+		run:  skills.ExecuteSkillCommand,
+	}
+}
+
+func (t *SkillTool) Name() string { return t.def.Spec.Name }
+func (t *SkillTool) Tier() int    { return t.def.Tier }
+
+// Skill returns the name of the skill declaring the tool.
+func (t *SkillTool) Skill() string { return t.def.Skill }
+
+func (t *SkillTool) Description() string {
+	return fmt.Sprintf("%s (skill: %s)", strings.TrimSpace(t.def.Spec.Description), t.def.Skill)
+}
+
+func (t *SkillTool) Parameters() map[string]any {
+	if t.def.Spec.Parameters != nil {
+		return t.def.Spec.Parameters
+	}
+	return map[string]any{"type": "object", "properties": map[string]any{}}
+}
+
+// skillCommandResult is the JSON returned for command tools.
+type skillCommandResult struct {
+	ExitCode  int    `json:"exitCode"`
+	Stdout    string `json:"stdout,omitempty"`
+	Stderr    string `json:"stderr,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (t *SkillTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	if missing := t.def.MissingRequired(params); len(missing) > 0 {
+		return fmt.Sprintf("Error: missing required parameters: %s", strings.Join(missing, ", ")), nil
+	}
+	if t.def.Spec.HTTP != nil {
+		return t.executeHTTP(ctx, params)
+	}
+
+	argv, err := t.def.RenderCommand(params)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	res, err := t.run(t.cfg, t.def.Skill, argv)
+	if res == nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	out := skillCommandResult{
+		ExitCode:  res.ExitCode,
+		Stdout:    res.Stdout,
+		Stderr:    res.Stderr,
+		Truncated: res.OutputTruncated,
+	}
+	if err != nil {
+		out.Error = err.Error()
+	}
+	return jsonResult(out)
+}
+
+func (t *SkillTool) executeHTTP(ctx context.Context, params map[string]any) (string, error) {
+	target, used := t.def.RenderURL(params)
+	timeout, err := skills.CheckSkillHTTPTarget(t.cfg, t.def.Skill, target)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req := map[string]any{"method": t.def.Method(), "url": target}
+	if cred := t.def.Spec.HTTP.Credential; cred != "" {
+		req["credential"] = cred
+	}
+	if t.def.Spec.HTTP.JSONBody {
+		body := map[string]any{}
+		for k, v := range params {
+			if !used[k] {
+				body[k] = v
+			}
+		}
+		req["json"] = body
+	}
+	return t.http.Execute(ctx, req)
+}
@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/skills"
+)
+
+// installTestSkill creates an enabled installed skill with the given policy
+// in a private KAFCLAW_HOME.
+func installTestSkill(t *testing.T, policy string) (*config.Config, string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("KAFCLAW_HOME", home)
+	t.Setenv("KAFCLAW_CONFIG", filepath.Join(home, ".kafclaw", "config.json"))
+	dirs, err := skills.EnsureStateDirs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := "tool-skill"
+	root := filepath.Join(dirs.Installed, name)
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "SKILL-POLICY.json"), []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Skills.Enabled = true
+	cfg.Skills.Entries = map[string]config.SkillEntryConfig{name: {Enabled: true}}
+	return cfg, name
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestSkillToolCommand(t *testing.T) {
+	def := skills.SkillTool{
+		Skill: "tool-skill",
+		Tier:  TierReadOnly,
+		Spec: skills.SkillToolSpec{
+			Name:        "count",
+			Description: "Count things",
+			Tier:        intPtr(0),
+			Parameters: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"text": map[string]any{"type": "string"}},
+				"required":   []any{"text"},
+			},
+			Command: []string{"wc", "-w", "{{text}}"},
+		},
+	}
+	tool := NewSkillTool(config.DefaultConfig(), def)
+	var gotSkill string
+	var gotArgv []string
+	tool.run = func(_ *config.Config, skill string, command []string) (*skills.SkillExecResult, error) {
+		gotSkill, gotArgv = skill, command
+		return &skills.SkillExecResult{ExitCode: 0, Stdout: "3\n"}, nil
+	}
+
+	if tool.Name() != "count" || ToolTier(tool) != TierReadOnly || !strings.Contains(tool.Description(), "skill: tool-skill") {
+		t.Fatalf("unexpected metadata: %s %d %s", tool.Name(), ToolTier(tool), tool.Description())
+	}
+	out, _ := tool.Execute(context.Background(), map[string]any{})
+	if !strings.Contains(out, "missing required parameters: text") {
+		t.Fatalf("expected missing parameter error, got %s", out)
+	}
+
+	out, _ = tool.Execute(context.Background(), map[string]any{"text": "a; b"})
+	var res skillCommandResult
+	decodeToolJSON(t, out, &res)
+	if gotSkill != "tool-skill" || !reflect.DeepEqual(gotArgv, []string{"wc", "-w", "a; b"}) {
+		t.Fatalf("unexpected call %s %q", gotSkill, gotArgv)
+	}
+	if res.Stdout != "3\n" || res.ExitCode != 0 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
+
+func TestSkillToolHTTP(t *testing.T) {
+	var gotPath, gotQuery, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.EscapedPath(), r.URL.RawQuery
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+	host := strings.Split(strings.TrimPrefix(srv.URL, "http://"), ":")[0]
+
+	def := skills.SkillTool{
+		Skill: "tool-skill",
+		Tier:  TierHighRisk,
+		Spec: skills.SkillToolSpec{
+			Name:        "create_item",
+			Description: "Create an item",
+			Tier:        intPtr(2),
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"list": map[string]any{"type": "string"},
+					"name": map[string]any{"type": "string"},
+				},
+			},
+			HTTP: &skills.SkillToolHTTP{Method: "POST", URL: srv.URL + "/lists/{{list}}/items", JSONBody: true},
+		},
+	}
+
+	cfg, _ := installTestSkill(t, `{"version":"1"}`)
+	cfg.Tools.HTTP = config.HTTPToolConfig{AllowedHosts: []string{host}, AllowHTTP: true}
+	cfg.Skills.LinkPolicy = config.SkillLinkPolicyConfig{Mode: "open", AllowHTTP: true}
+	out, _ := NewSkillTool(cfg, def).Execute(context.Background(), map[string]any{"list": "a/b", "name": "milk"})
+	if !strings.Contains(out, "execution.network") {
+		t.Fatalf("expected network policy error, got %s", out)
+	}
+
+	cfg, _ = installTestSkill(t, `{"version":"1","execution":{"network":true}}`)
+	cfg.Tools.HTTP = config.HTTPToolConfig{AllowedHosts: []string{host}, AllowHTTP: true}
+	cfg.Skills.LinkPolicy = config.SkillLinkPolicyConfig{Mode: "open", AllowHTTP: true}
+	out, _ = NewSkillTool(cfg, def).Execute(context.Background(), map[string]any{"list": "a/b", "name": "milk"})
+	var res httpResult
+	decodeToolJSON(t, out, &res)
+	if res.Status != 200 || gotPath != "/lists/a%2Fb/items" || gotQuery != "" {
+		t.Fatalf("unexpected request %s?%s -> %+v", gotPath, gotQuery, res)
+	}
+	var body map[string]any
+	if err := json.Unmarshal([]byte(gotBody), &body); err != nil || !reflect.DeepEqual(body, map[string]any{"name": "milk"}) {
+		t.Fatalf("unexpected body %q", gotBody)
+	}
+
+	cfg.Tools.HTTP.AllowedHosts = []string{"other.example"}
+	out, _ = NewSkillTool(cfg, def).Execute(context.Background(), map[string]any{"list": "x"})
+	if !strings.Contains(out, "allowedHosts") {
+		t.Fatalf("expected tools.http allowlist to apply, got %s", out)
+	}
+}
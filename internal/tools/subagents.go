@@ -173,15 +173,15 @@ type SubagentsRequest struct {
 }
 
 type SubagentsTool struct {
-	listRuns func() []SubagentRunView
-	killRun  func(runID string) (bool, error)
-	steerRun func(runID, input string) (SpawnResult, error)
+	listRuns func(context.Context) []SubagentRunView
+	killRun  func(ctx context.Context, runID string) (bool, error)
+	steerRun func(ctx context.Context, runID, input string) (SpawnResult, error)
 }
 
 func NewSubagentsTool(
-	listFn func() []SubagentRunView,
-	killFn func(runID string) (bool, error),
-	steerFn func(runID, input string) (SpawnResult, error),
+	listFn func(context.Context) []SubagentRunView,
+	killFn func(ctx context.Context, runID string) (bool, error),
+	steerFn func(ctx context.Context, runID, input string) (SpawnResult, error),
 ) *SubagentsTool {
 	return &SubagentsTool{
 		listRuns: listFn,
@@ -221,7 +221,7 @@ func (t *SubagentsTool) Parameters() map[string]any {
 	}
 }
 
-func (t *SubagentsTool) Execute(_ context.Context, params map[string]any) (string, error) {
+func (t *SubagentsTool) Execute(ctx context.Context, params map[string]any) (string, error) {
 	action := strings.TrimSpace(GetString(params, "action", "list"))
 	if action == "" {
 		action = "list"
@@ -238,7 +238,7 @@ func (t *SubagentsTool) Execute(_ context.Context, params map[string]any) (strin
 		if t.listRuns == nil {
 			return "", fmt.Errorf("subagents list unavailable")
 		}
-		runs := t.listRuns()
+		runs := t.listRuns(ctx)
 		body := map[string]any{
 			"status": "ok",
 			"action": "list",
@@ -257,12 +257,12 @@ func (t *SubagentsTool) Execute(_ context.Context, params map[string]any) (strin
 		if target == "" {
 			return "", fmt.Errorf("target is required for kill")
 		}
-		runs := t.listRunsOrNil()
+		runs := t.listRunsOrNil(ctx)
 		resolved, err := resolveSubagentTarget(runs, target, recentMinutes)
 		if err != nil {
 			return "", err
 		}
-		killed, err := t.killRun(resolved.RunID)
+		killed, err := t.killRun(ctx, resolved.RunID)
 		if err != nil {
 			return "", err
 		}
@@ -282,7 +282,7 @@ func (t *SubagentsTool) Execute(_ context.Context, params map[string]any) (strin
 		if t.killRun == nil {
 			return "", fmt.Errorf("subagents kill unavailable")
 		}
-		runs := t.listRunsOrNil()
+		runs := t.listRunsOrNil(ctx)
 		killed := 0
 		attempted := 0
 		for _, run := range runs {
@@ -290,7 +290,7 @@ func (t *SubagentsTool) Execute(_ context.Context, params map[string]any) (strin
 				continue
 			}
 			attempted++
-			ok, err := t.killRun(run.RunID)
+			ok, err := t.killRun(ctx, run.RunID)
 			if err != nil {
 				return "", err
 			}
@@ -321,12 +321,12 @@ func (t *SubagentsTool) Execute(_ context.Context, params map[string]any) (strin
 		if input == "" {
 			return "", fmt.Errorf("input is required for steer")
 		}
-		runs := t.listRunsOrNil()
+		runs := t.listRunsOrNil(ctx)
 		resolved, err := resolveSubagentTarget(runs, target, recentMinutes)
 		if err != nil {
 			return "", err
 		}
-		res, err := t.steerRun(resolved.RunID, input)
+		res, err := t.steerRun(ctx, resolved.RunID, input)
 		if err != nil {
 			return "", err
 		}
@@ -349,11 +349,11 @@ func (t *SubagentsTool) Execute(_ context.Context, params map[string]any) (strin
 	}
 }
 
-func (t *SubagentsTool) listRunsOrNil() []SubagentRunView {
+func (t *SubagentsTool) listRunsOrNil(ctx context.Context) []SubagentRunView {
 	if t.listRuns == nil {
 		return nil
 	}
-	return t.listRuns()
+	return t.listRuns(ctx)
 }
 
 func resolveSubagentTarget(runs []SubagentRunView, target string, recentMinutes int) (SubagentRunView, error) {
@@ -465,10 +465,10 @@ func atoi(s string) int {
 }
 
 type AgentsListTool struct {
-	discover func() AgentDiscovery
+	discover func(context.Context) AgentDiscovery
 }
 
-func NewAgentsListTool(discoverFn func() AgentDiscovery) *AgentsListTool {
+func NewAgentsListTool(discoverFn func(context.Context) AgentDiscovery) *AgentsListTool {
 	return &AgentsListTool{discover: discoverFn}
 }
 
@@ -485,14 +485,14 @@ func (t *AgentsListTool) Parameters() map[string]any {
 	}
 }
 
-func (t *AgentsListTool) Execute(_ context.Context, _ map[string]any) (string, error) {
+func (t *AgentsListTool) Execute(ctx context.Context, _ map[string]any) (string, error) {
 	if t.discover == nil {
 		return "", fmt.Errorf("agents_list unavailable")
 	}
 	body := map[string]any{
 		"status": "ok",
 		"action": "agents_list",
-		"agents": t.discover(),
+		"agents": t.discover(ctx),
 	}
 	out, err := json.Marshal(body)
 	if err != nil {
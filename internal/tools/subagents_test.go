@@ -114,7 +114,7 @@ func TestSessionsSpawnTool_Execute_Validation(t *testing.T) {
 func TestSubagentsTool_ListAndKill(t *testing.T) {
 	killedID := ""
 	tool := NewSubagentsTool(
-		func() []SubagentRunView {
+		func(context.Context) []SubagentRunView {
 			return []SubagentRunView{
 				{
 					RunID:           "run-1",
@@ -126,7 +126,7 @@ func TestSubagentsTool_ListAndKill(t *testing.T) {
 				},
 			}
 		},
-		func(runID string) (bool, error) {
+		func(_ context.Context, runID string) (bool, error) {
 			killedID = runID
 			return true, nil
 		},
@@ -165,7 +165,7 @@ func TestSubagentsTool_ListAndKill(t *testing.T) {
 }
 
 func TestSubagentsTool_UnsupportedAction(t *testing.T) {
-	tool := NewSubagentsTool(func() []SubagentRunView { return nil }, nil, nil)
+	tool := NewSubagentsTool(func(context.Context) []SubagentRunView { return nil }, nil, nil)
 	if _, err := tool.Execute(context.Background(), map[string]any{"action": "unknown"}); err == nil {
 		t.Fatal("expected unsupported action error")
 	}
@@ -193,7 +193,7 @@ func TestSubagentsTool_MetadataAndValidation(t *testing.T) {
 		t.Fatal("expected kill unavailable error")
 	}
 
-	tool = NewSubagentsTool(func() []SubagentRunView { return nil }, func(_ string) (bool, error) {
+	tool = NewSubagentsTool(func(context.Context) []SubagentRunView { return nil }, func(context.Context, string) (bool, error) {
 		return true, nil
 	}, nil)
 	if _, err := tool.Execute(context.Background(), map[string]any{"action": "kill"}); err == nil {
@@ -203,7 +203,7 @@ func TestSubagentsTool_MetadataAndValidation(t *testing.T) {
 
 func TestSubagentsTool_Steer(t *testing.T) {
 	tool := NewSubagentsTool(
-		func() []SubagentRunView {
+		func(context.Context) []SubagentRunView {
 			return []SubagentRunView{
 				{
 					RunID:     "run-1",
@@ -212,8 +212,8 @@ func TestSubagentsTool_Steer(t *testing.T) {
 				},
 			}
 		},
-		func(_ string) (bool, error) { return true, nil },
-		func(runID, input string) (SpawnResult, error) {
+		func(context.Context, string) (bool, error) { return true, nil },
+		func(_ context.Context, runID, input string) (SpawnResult, error) {
 			if runID != "run-1" {
 				t.Fatalf("unexpected runID: %s", runID)
 			}
@@ -247,8 +247,8 @@ func TestSubagentsTool_Steer(t *testing.T) {
 
 func TestSubagentsTool_SteerValidationAndUnavailable(t *testing.T) {
 	tool := NewSubagentsTool(
-		func() []SubagentRunView { return nil },
-		func(_ string) (bool, error) { return true, nil },
+		func(context.Context) []SubagentRunView { return nil },
+		func(context.Context, string) (bool, error) { return true, nil },
 		nil,
 	)
 	if _, err := tool.Execute(context.Background(), map[string]any{"action": "steer", "target": "run-1", "input": "x"}); err == nil {
@@ -256,9 +256,9 @@ func TestSubagentsTool_SteerValidationAndUnavailable(t *testing.T) {
 	}
 
 	tool = NewSubagentsTool(
-		func() []SubagentRunView { return nil },
-		func(_ string) (bool, error) { return true, nil },
-		func(_, _ string) (SpawnResult, error) { return SpawnResult{}, nil },
+		func(context.Context) []SubagentRunView { return nil },
+		func(context.Context, string) (bool, error) { return true, nil },
+		func(context.Context, string, string) (SpawnResult, error) { return SpawnResult{}, nil },
 	)
 	if _, err := tool.Execute(context.Background(), map[string]any{"action": "steer", "input": "x"}); err == nil {
 		t.Fatal("expected target required validation error")
@@ -273,13 +273,13 @@ func TestSubagentsTool_KillAll(t *testing.T) {
 	now := time.Now()
 	ended := now
 	tool := NewSubagentsTool(
-		func() []SubagentRunView {
+		func(context.Context) []SubagentRunView {
 			return []SubagentRunView{
 				{RunID: "run-1", CreatedAt: now.Add(-time.Minute)},
 				{RunID: "run-2", CreatedAt: now, EndedAt: &ended},
 			}
 		},
-		func(runID string) (bool, error) {
+		func(_ context.Context, runID string) (bool, error) {
 			killed = append(killed, runID)
 			return true, nil
 		},
@@ -342,7 +342,7 @@ func TestResolveSubagentTarget(t *testing.T) {
 }
 
 func TestAgentsListTool(t *testing.T) {
-	tool := NewAgentsListTool(func() AgentDiscovery {
+	tool := NewAgentsListTool(func(context.Context) AgentDiscovery {
 		return AgentDiscovery{
 			CurrentAgentID:   "agent-main",
 			AllowAgents:      []string{"agent-main", "agent-research"},
@@ -45,6 +45,57 @@ func ToolTier(t Tool) int {
 	return TierReadOnly
 }
 
+// CallTieredTool is an optional interface for tools whose risk depends on
+// the arguments of a call, such as the HTTP method of a request.
+type CallTieredTool interface {
+	TieredTool
+	CallTier(params map[string]any) int
+}
+
+// ToolCallTier returns the risk tier of one call. Tools implementing
+// CallTieredTool decide per call; others fall back to ToolTier.
+func ToolCallTier(t Tool, params map[string]any) int {
+	if ct, ok := t.(CallTieredTool); ok {
+		return ct.CallTier(params)
+	}
+	return ToolTier(t)
+}
+
+// GuardedTool is an optional interface for tools that refuse some calls
+// regardless of tier and approval, such as reads of secret files.
+type GuardedTool interface {
+	CheckCall(params map[string]any) error
+}
+
+// CheckToolCall returns the reason a call is refused, or nil. Tools not
+// implementing GuardedTool accept every call.
+func CheckToolCall(t Tool, params map[string]any) error {
+	if gt, ok := t.(GuardedTool); ok {
+		return gt.CheckCall(params)
+	}
+	return nil
+}
+
+// ParallelTool is an optional interface for tools that decide per call
+// whether they may run concurrently with other calls of the same response.
+type ParallelTool interface {
+	ParallelSafe(params map[string]any) bool
+}
+
+// ParallelSafe reports whether a call may run concurrently with other
+// parallel-safe calls. Tools implementing ParallelTool decide per call;
+// otherwise calls of tools declaring tier 0 are parallel-safe. Tools without
+// a declared tier always run alone.
+func ParallelSafe(t Tool, params map[string]any) bool {
+	if pt, ok := t.(ParallelTool); ok {
+		return pt.ParallelSafe(params)
+	}
+	if _, ok := t.(TieredTool); !ok {
+		return false
+	}
+	return ToolCallTier(t, params) == TierReadOnly
+}
+
 // DefaultToolNames returns the names of tools that are registered by default
 // in the agent loop. Used for identity announcements when a full registry is
 // not available (e.g. group manager startup).
@@ -58,6 +109,7 @@ func DefaultToolNames() []string {
 // Registry manages tool registration and execution.
 type Registry struct {
 	tools map[string]Tool
+	cache *ResultCache
 }
 
 // NewRegistry creates a new tool registry.
@@ -103,12 +155,25 @@ func (r *Registry) Definitions() []map[string]any {
 	return result
 }
 
-// Execute runs a tool by name with the given parameters.
+// SetCache enables result caching for calls made with a CacheCall in their
+// context. A nil cache disables it.
+func (r *Registry) SetCache(cache *ResultCache) {
+	r.cache = cache
+}
+
+// Execute runs a tool by name with the given parameters. With a cache set,
+// cacheable results are reused within the call's scope and writes
+// invalidate the entries they affect.
 func (r *Registry) Execute(ctx context.Context, name string, params map[string]any) (string, error) {
 	tool, ok := r.tools[name]
 	if !ok {
 		return "", fmt.Errorf("tool not found: %s", name)
 	}
+	if r.cache != nil {
+		if call := cacheCallFrom(ctx); call != nil {
+			return r.cache.execute(ctx, tool, call, params)
+		}
+	}
 	return tool.Execute(ctx, params)
 }
 
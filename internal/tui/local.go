@@ -0,0 +1,195 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/agent"
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/session"
+	"github.com/KafClaw/KafClaw/internal/tools"
+)
+
+// maxLocalTraces bounds the in-memory trace log kept for /trace.
+const maxLocalTraces = 20
+
+// LocalBackend runs turns on an in-process agent loop.
+//
+// Build the loop with LoopOptions.ToolProgress set to the backend's
+// OnToolProgress so tool calls stream into the UI. Approval prompts are
+// picked up from the loop's outbound messages on the session's channel.
+type LocalBackend struct {
+	loop *agent.Loop
+	bus  *bus.MessageBus
+
+	mu         sync.Mutex
+	events     chan<- Event
+	subscribed map[string]bool
+	traces     map[string][]Span
+	traceOrder []string
+}
+
+// NewLocalBackend creates a backend for a loop publishing on msgBus. The
+// caller must run msgBus.DispatchOutbound so approval prompts reach the UI.
+func NewLocalBackend(msgBus *bus.MessageBus) *LocalBackend {
+	return &LocalBackend{
+		bus:        msgBus,
+		subscribed: map[string]bool{},
+		traces:     map[string][]Span{},
+	}
+}
+
+// SetLoop attaches the loop. It is separate from the constructor because the
+// loop needs OnToolProgress at construction time.
+func (b *LocalBackend) SetLoop(loop *agent.Loop) { b.loop = loop }
+
+func (b *LocalBackend) Name() string { return "local" }
+
+// OnToolProgress is the agent.LoopOptions.ToolProgress hook.
+func (b *LocalBackend) OnToolProgress(p agent.ToolProgress) {
+	ev := Event{Kind: EventToolStart, TraceID: p.TraceID, Tool: p.Tool, Args: argsPreview(p.Arguments)}
+	if p.Done {
+		ev.Kind = EventToolDone
+		ev.Duration = p.Duration
+		ev.Err = p.Err
+		output := fmt.Sprintf("result_len=%d", p.ResultLen)
+		if p.Err != "" {
+			output = "error: " + p.Err
+		}
+		b.recordSpan(p.TraceID, Span{
+			Type:     "TOOL",
+			Title:    p.Tool,
+			Time:     time.Now().Format("15:04:05"),
+			Duration: fmt.Sprintf("%dms", p.Duration.Milliseconds()),
+			Output:   output,
+		})
+	}
+	b.emit(ev)
+}
+
+func (b *LocalBackend) onOutbound(msg *bus.OutboundMessage) {
+	if msg.Action != "approval_request" {
+		return
+	}
+	id, _ := msg.ActionParams["approval_id"].(string)
+	if id == "" {
+		return
+	}
+	tool, _ := msg.ActionParams["tool"].(string)
+	tier, _ := msg.ActionParams["tier"].(int)
+	args := ""
+	for _, line := range strings.Split(msg.Content, "\n") {
+		if strings.HasPrefix(line, "Args: ") {
+			args = strings.TrimPrefix(line, "Args: ")
+		}
+	}
+	b.emit(Event{Kind: EventApproval, TraceID: msg.TraceID, Tool: tool, Tier: tier, Args: args, ApprovalID: id})
+}
+
+func (b *LocalBackend) emit(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.events == nil {
+		return
+	}
+	select {
+	case b.events <- ev:
+	default:
+	}
+}
+
+func (b *LocalBackend) recordSpan(traceID string, span Span) {
+	if traceID == "" {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.traces[traceID]; !ok {
+		b.traceOrder = append(b.traceOrder, traceID)
+		if len(b.traceOrder) > maxLocalTraces {
+			delete(b.traces, b.traceOrder[0])
+			b.traceOrder = b.traceOrder[1:]
+		}
+	}
+	b.traces[traceID] = append(b.traces[traceID], span)
+}
+
+func (b *LocalBackend) Chat(ctx context.Context, sessionKey, traceID, text string, events chan<- Event) (string, error) {
+	channel, _, _ := strings.Cut(sessionKey, ":")
+	b.mu.Lock()
+	if !b.subscribed[channel] {
+		b.subscribed[channel] = true
+		b.bus.Subscribe(channel, b.onOutbound)
+	}
+	b.events = events
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		b.events = nil
+		b.mu.Unlock()
+	}()
+
+	started := time.Now()
+	b.recordSpan(traceID, Span{Type: "INBOUND", Title: sessionKey, Time: started.Format("15:04:05"), Output: truncate(text, 200)})
+	reply, err := b.loop.ProcessDirectWithTrace(ctx, text, sessionKey, traceID)
+	out := Span{Type: "OUTBOUND", Title: "reply", Time: time.Now().Format("15:04:05"), Duration: fmt.Sprintf("%dms", time.Since(started).Milliseconds()), Output: truncate(reply, 200)}
+	if err != nil {
+		out.Output = "error: " + err.Error()
+	}
+	b.recordSpan(traceID, out)
+	return reply, err
+}
+
+func (b *LocalBackend) RespondApproval(_ context.Context, approvalID string, approved bool) error {
+	return b.loop.RespondApproval(approvalID, approved)
+}
+
+func (b *LocalBackend) Sessions(context.Context) ([]session.SessionInfo, error) {
+	return b.loop.Sessions().List(), nil
+}
+
+func (b *LocalBackend) History(_ context.Context, sessionKey string, limit int) ([]session.Message, error) {
+	sess, ok := b.loop.Sessions().Get(sessionKey)
+	if !ok {
+		return nil, nil
+	}
+	return sess.GetHistory(limit), nil
+}
+
+func (b *LocalBackend) Memory(context.Context) (MemoryStatus, error) {
+	return MemoryStatus{}, fmt.Errorf("memory: %w in local mode (attach to a gateway with --remote)", ErrUnsupported)
+}
+
+func (b *LocalBackend) Model(context.Context) (string, error) {
+	return b.loop.Model(), nil
+}
+
+func (b *LocalBackend) SetModel(_ context.Context, model string) error {
+	b.loop.SetModel(model)
+	return nil
+}
+
+func (b *LocalBackend) Subagents(_ context.Context, sessionKey string) ([]tools.SubagentRunView, error) {
+	return b.loop.Subagents(sessionKey), nil
+}
+
+func (b *LocalBackend) Trace(_ context.Context, traceID string) ([]Span, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Span(nil), b.traces[traceID]...), nil
+}
+
+func argsPreview(args map[string]any) string {
+	if len(args) == 0 {
+		return ""
+	}
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var (
+	mdHeading = color.New(color.FgCyan, color.Bold).SprintFunc()
+	mdBold    = color.New(color.Bold).SprintFunc()
+	mdItalic  = color.New(color.Italic).SprintFunc()
+	mdCode    = color.New(color.FgYellow).SprintFunc()
+	mdFaint   = color.New(color.Faint).SprintFunc()
+	mdLink    = color.New(color.Underline).SprintFunc()
+
+	mdInlineCode = regexp.MustCompile("`([^`]+)`")
+	mdBoldRe     = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdItalicRe   = regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*]*)\*`)
+	mdLinkRe     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdOrdered    = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+)
+
+// RenderMarkdown renders the subset of Markdown that model replies use
+// (headings, lists, quotes, fenced code, inline emphasis, code and links)
+// for a terminal. Styling is dropped when color output is disabled.
+func RenderMarkdown(text string) string {
+	var out []string
+	inFence := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			if inFence {
+				if lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```")); lang != "" {
+					out = append(out, mdFaint("  ┌ "+lang))
+				} else {
+					out = append(out, mdFaint("  ┌"))
+				}
+			} else {
+				out = append(out, mdFaint("  └"))
+			}
+			continue
+		}
+		if inFence {
+			out = append(out, mdFaint("  │ ")+mdCode(line))
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			title := strings.TrimSpace(trimmed[level:])
+			if level == 1 {
+				title = strings.ToUpper(title)
+			}
+			out = append(out, mdHeading(renderInline(title)))
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") || strings.HasPrefix(trimmed, "+ "):
+			out = append(out, indent+"  • "+renderInline(strings.TrimSpace(trimmed[2:])))
+		case mdOrdered.MatchString(line):
+			m := mdOrdered.FindStringSubmatch(line)
+			out = append(out, m[1]+"  "+m[2]+". "+renderInline(m[3]))
+		case strings.HasPrefix(trimmed, ">"):
+			out = append(out, mdFaint("  ▌ ")+renderInline(strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))))
+		case trimmed == "---" || trimmed == "***" || trimmed == "___":
+			out = append(out, mdFaint(strings.Repeat("─", 40)))
+		default:
+			out = append(out, renderInline(line))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// renderInline styles code spans first so emphasis markers inside them are
+// left alone.
+func renderInline(s string) string {
+	parts := mdInlineCode.Split(s, -1)
+	codes := mdInlineCode.FindAllStringSubmatch(s, -1)
+	var b strings.Builder
+	for i, part := range parts {
+		part = mdLinkRe.ReplaceAllStringFunc(part, func(m string) string {
+			sub := mdLinkRe.FindStringSubmatch(m)
+			if sub[1] == sub[2] {
+				return mdLink(sub[2])
+			}
+			return sub[1] + " (" + mdLink(sub[2]) + ")"
+		})
+		part = mdBoldRe.ReplaceAllStringFunc(part, func(m string) string {
+			sub := mdBoldRe.FindStringSubmatch(m)
+			return mdBold(sub[1] + sub[2])
+		})
+		part = mdItalicRe.ReplaceAllStringFunc(part, func(m string) string {
+			sub := mdItalicRe.FindStringSubmatch(m)
+			return sub[1] + mdItalic(sub[2])
+		})
+		b.WriteString(part)
+		if i < len(codes) {
+			b.WriteString(mdCode(codes[i][1]))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,179 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/gatewayapi"
+	"github.com/KafClaw/KafClaw/internal/gatewayclient"
+	"github.com/KafClaw/KafClaw/internal/session"
+	"github.com/KafClaw/KafClaw/internal/tools"
+)
+
+// RemoteBackend attaches to a running gateway through the generated
+// gateway client. Turns go through the API server's /chat endpoint;
+// progress, approvals and the slash commands use the dashboard API.
+type RemoteBackend struct {
+	// APIURL is the gateway API server (gateway.port), e.g. http://host:18790.
+	APIURL string
+	// DashboardURL is the dashboard server (gateway.dashboardPort).
+	DashboardURL string
+	// Token is sent as a Bearer token when the gateway requires auth.
+	Token string
+	// PollInterval controls how often progress is polled during a turn.
+	PollInterval time.Duration
+
+	client *gatewayclient.Client
+}
+
+// NewRemoteBackend creates a backend for the gateway at the given URLs.
+func NewRemoteBackend(apiURL, dashboardURL, token string) *RemoteBackend {
+	client := gatewayclient.New(apiURL, dashboardURL, token)
+	return &RemoteBackend{
+		APIURL:       client.APIURL,
+		DashboardURL: client.DashboardURL,
+		Token:        client.Token,
+		PollInterval: 750 * time.Millisecond,
+		client:       client,
+	}
+}
+
+func (b *RemoteBackend) Name() string { return "gateway " + b.APIURL }
+
+func (b *RemoteBackend) Chat(ctx context.Context, sessionKey, traceID, text string, events chan<- Event) (string, error) {
+	type result struct {
+		reply string
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		reply, err := b.client.Chat(ctx, gatewayapi.ChatRequest{Message: text, Session: sessionKey, Trace: traceID})
+		done <- result{reply: reply, err: err}
+	}()
+
+	seenSpans := map[string]bool{}
+	seenApprovals := map[string]bool{}
+	ticker := time.NewTicker(b.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case res := <-done:
+			b.pollProgress(ctx, traceID, seenSpans, seenApprovals, events)
+			return res.reply, res.err
+		case <-ticker.C:
+			b.pollProgress(ctx, traceID, seenSpans, seenApprovals, events)
+		}
+	}
+}
+
+// pollProgress turns new TOOL spans and pending approvals of the trace into
+// events. Poll errors are ignored; the turn result is authoritative.
+func (b *RemoteBackend) pollProgress(ctx context.Context, traceID string, seenSpans, seenApprovals map[string]bool, events chan<- Event) {
+	if trace, err := b.client.GetTrace(ctx, gatewayapi.TraceRequest{ID: traceID}); err == nil {
+		for _, s := range trace.Spans {
+			if s.Type != "TOOL" || seenSpans[s.ID] {
+				continue
+			}
+			seenSpans[s.ID] = true
+			ev := Event{Kind: EventToolDone, TraceID: traceID}
+			ev.Tool, _ = s.Metadata["tool_name"].(string)
+			if args, ok := s.Metadata["arguments"].(map[string]any); ok {
+				ev.Args = argsPreview(args)
+			}
+			ev.Err, _ = s.Metadata["error"].(string)
+			ev.Duration, _ = time.ParseDuration(s.Duration)
+			events <- ev
+		}
+	}
+
+	if approvals, err := b.client.ListPendingApprovals(ctx); err == nil {
+		for _, a := range approvals {
+			if a.TraceID != traceID || seenApprovals[a.ApprovalID] {
+				continue
+			}
+			seenApprovals[a.ApprovalID] = true
+			events <- Event{Kind: EventApproval, TraceID: traceID, Tool: a.Tool, Tier: a.Tier, Args: a.Arguments, ApprovalID: a.ApprovalID}
+		}
+	}
+}
+
+func (b *RemoteBackend) RespondApproval(ctx context.Context, approvalID string, approved bool) error {
+	_, err := b.client.RespondApproval(ctx, gatewayapi.ApprovalDecision{ID: approvalID, Approved: approved})
+	return err
+}
+
+func (b *RemoteBackend) Sessions(ctx context.Context) ([]session.SessionInfo, error) {
+	list, err := b.client.ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]session.SessionInfo, 0, len(list))
+	for _, s := range list {
+		out = append(out, session.SessionInfo{Key: s.Key, CreatedAt: s.CreatedAt, UpdatedAt: s.UpdatedAt})
+	}
+	return out, nil
+}
+
+func (b *RemoteBackend) History(ctx context.Context, sessionKey string, limit int) ([]session.Message, error) {
+	resp, err := b.client.GetSessionHistory(ctx, gatewayapi.SessionHistoryRequest{Key: sessionKey, Limit: limit})
+	var apiErr *gatewayclient.Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		// A session that has no messages yet.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.Messages, nil
+}
+
+func (b *RemoteBackend) Memory(ctx context.Context) (MemoryStatus, error) {
+	resp, err := b.client.GetMemoryStatus(ctx)
+	if err != nil {
+		return MemoryStatus{}, err
+	}
+	st := MemoryStatus{
+		Layers:         map[string]int{},
+		TotalChunks:    resp.Totals.TotalChunks,
+		WorkingEntries: resp.WorkingMemory.Entries,
+		WorkingPreview: resp.WorkingMemory.Preview,
+	}
+	for _, l := range resp.Layers {
+		st.Layers[l.Name] = l.ChunkCount
+	}
+	for _, o := range resp.Observations {
+		st.Observations = append(st.Observations, o.Content)
+	}
+	return st, nil
+}
+
+func (b *RemoteBackend) Model(ctx context.Context) (string, error) {
+	resp, err := b.client.GetStatus(ctx)
+	if err != nil {
+		return "", err
+	}
+	return resp.Model, nil
+}
+
+func (b *RemoteBackend) SetModel(context.Context, string) error {
+	return fmt.Errorf("changing the model: %w when attached to a gateway", ErrUnsupported)
+}
+
+func (b *RemoteBackend) Subagents(ctx context.Context, sessionKey string) ([]tools.SubagentRunView, error) {
+	return b.client.ListSubagents(ctx, gatewayapi.SessionRequest{Session: sessionKey})
+}
+
+func (b *RemoteBackend) Trace(ctx context.Context, traceID string) ([]Span, error) {
+	trace, err := b.client.GetTrace(ctx, gatewayapi.TraceRequest{ID: traceID})
+	if err != nil {
+		return nil, err
+	}
+	spans := make([]Span, 0, len(trace.Spans))
+	for _, s := range trace.Spans {
+		spans = append(spans, Span{Type: s.Type, Title: s.Title, Time: s.Time, Duration: s.Duration, Output: s.Output})
+	}
+	return spans, nil
+}
@@ -0,0 +1,161 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRemoteBackendChatStreamsProgressAndApprovals(t *testing.T) {
+	var mu sync.Mutex
+	var traceID string
+	approved := make(chan bool, 1)
+	answered := false
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/chat", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		traceID = r.URL.Query().Get("trace")
+		mu.Unlock()
+		if r.URL.Query().Get("session") != "cli:remote" {
+			http.Error(w, "bad session", http.StatusBadRequest)
+			return
+		}
+		select {
+		case ok := <-approved:
+			if ok {
+				w.Write([]byte("ran it"))
+				return
+			}
+			w.Write([]byte("skipped"))
+		case <-time.After(3 * time.Second):
+			http.Error(w, "approval never arrived", http.StatusGatewayTimeout)
+		}
+	})
+	mux.HandleFunc("/api/v1/trace/", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"spans": []map[string]any{
+			{"id": "s1", "type": "LLM", "title": "LLM"},
+			{"id": "s2", "type": "TOOL", "title": "TOOL", "duration": "40ms", "metadata": map[string]any{"tool_name": "exec", "arguments": map[string]any{"command": "ls"}}},
+		}})
+	})
+	mux.HandleFunc("/api/v1/approvals/pending", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		out := []map[string]any{{"approval_id": "other", "trace_id": "someone-else", "tool": "exec"}}
+		if traceID != "" && !answered {
+			out = append(out, map[string]any{"approval_id": "ap9", "trace_id": traceID, "tool": "write_file", "tier": 2, "arguments": `{"path":"x"}`})
+		}
+		json.NewEncoder(w).Encode(out)
+	})
+	mux.HandleFunc("/api/v1/approvals/", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Approved bool `json:"approved"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if !strings.HasSuffix(r.URL.Path, "/ap9") {
+			http.Error(w, "unknown", http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		answered = true
+		mu.Unlock()
+		approved <- body.Approved
+	})
+	mux.HandleFunc("/api/v1/status", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"model": "remote-model"})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	b := NewRemoteBackend(srv.URL, srv.URL, "tok")
+	b.PollInterval = 10 * time.Millisecond
+
+	events := make(chan Event, 16)
+	var got []Event
+	stop := make(chan struct{})
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for {
+			select {
+			case ev := <-events:
+				got = append(got, ev)
+				if ev.Kind == EventApproval {
+					if err := b.RespondApproval(context.Background(), ev.ApprovalID, true); err != nil {
+						t.Errorf("respond: %v", err)
+					}
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	reply, err := b.Chat(context.Background(), "cli:remote", "trace-abc", "do it", events)
+	close(stop)
+	<-collected
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if reply != "ran it" {
+		t.Fatalf("unexpected reply %q", reply)
+	}
+
+	var tool, approval int
+	for _, ev := range got {
+		switch ev.Kind {
+		case EventToolDone:
+			tool++
+			if ev.Tool != "exec" || ev.Duration != 40*time.Millisecond || ev.Args != `{"command":"ls"}` {
+				t.Fatalf("unexpected tool event %+v", ev)
+			}
+		case EventApproval:
+			approval++
+			if ev.ApprovalID != "ap9" || ev.Tool != "write_file" || ev.Tier != 2 {
+				t.Fatalf("unexpected approval event %+v", ev)
+			}
+		}
+	}
+	if tool != 1 || approval != 1 {
+		t.Fatalf("expected one deduplicated tool and approval event, got %+v", got)
+	}
+
+	model, err := b.Model(context.Background())
+	if err != nil || model != "remote-model" {
+		t.Fatalf("model = %q, %v", model, err)
+	}
+	if err := b.SetModel(context.Background(), "x"); err == nil {
+		t.Fatalf("expected SetModel to be unsupported remotely")
+	}
+}
+
+func TestRemoteBackendReportsGatewayErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	b := NewRemoteBackend(srv.URL, srv.URL, "")
+	if _, err := b.Sessions(context.Background()); err == nil || !strings.Contains(err.Error(), "status: 401") {
+		t.Fatalf("expected status error, got %v", err)
+	}
+}
+
+func TestRemoteBackendHistoryOfUnknownSessionIsEmpty(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"session not found"}`, http.StatusNotFound)
+	}))
+	defer srv.Close()
+	b := NewRemoteBackend(srv.URL, srv.URL, "")
+	history, err := b.History(context.Background(), "cli:new", 10)
+	if err != nil || len(history) != 0 {
+		t.Fatalf("expected empty history, got %v (err=%v)", history, err)
+	}
+}
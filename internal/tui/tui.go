@@ -0,0 +1,478 @@
+// Package tui implements the interactive terminal chat behind `kafclaw agent`.
+//
+// The UI is line based: replies are rendered into the terminal's own
+// scrollback, tool calls stream in while the agent works, and approval
+// prompts are answered inline. The same UI drives an in-process agent loop
+// (LocalBackend) or a running gateway over its HTTP API (RemoteBackend).
+package tui
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/session"
+	"github.com/KafClaw/KafClaw/internal/tools"
+	"github.com/fatih/color"
+)
+
+// EventKind identifies a progress event emitted while a chat turn runs.
+type EventKind string
+
+const (
+	EventToolStart EventKind = "tool_start"
+	EventToolDone  EventKind = "tool_done"
+	EventApproval  EventKind = "approval"
+)
+
+// Event is one progress update for the running turn.
+type Event struct {
+	Kind       EventKind
+	TraceID    string
+	Tool       string
+	Args       string
+	Duration   time.Duration
+	Err        string
+	ApprovalID string
+	Tier       int
+}
+
+// Span is one step of a trace as shown by /trace.
+type Span struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Time     string `json:"time"`
+	Duration string `json:"duration"`
+	Output   string `json:"output"`
+}
+
+// MemoryStatus summarises the memory system for /memory.
+type MemoryStatus struct {
+	Layers         map[string]int
+	TotalChunks    int
+	WorkingEntries int
+	WorkingPreview string
+	Observations   []string
+}
+
+// ErrUnsupported is returned by backends for commands they cannot serve.
+var ErrUnsupported = errors.New("not supported by this backend")
+
+// Backend is what the UI talks to: an in-process loop or a remote gateway.
+type Backend interface {
+	// Name describes the backend in the banner ("local", "gateway http://...").
+	Name() string
+	// Chat runs one turn and returns the reply. Progress events are sent on
+	// events until Chat returns; no events are sent afterwards.
+	Chat(ctx context.Context, sessionKey, traceID, text string, events chan<- Event) (string, error)
+	RespondApproval(ctx context.Context, approvalID string, approved bool) error
+	Sessions(ctx context.Context) ([]session.SessionInfo, error)
+	History(ctx context.Context, sessionKey string, limit int) ([]session.Message, error)
+	Memory(ctx context.Context) (MemoryStatus, error)
+	Model(ctx context.Context) (string, error)
+	SetModel(ctx context.Context, model string) error
+	Subagents(ctx context.Context, sessionKey string) ([]tools.SubagentRunView, error)
+	Trace(ctx context.Context, traceID string) ([]Span, error)
+}
+
+var (
+	styleUser   = color.New(color.FgGreen, color.Bold).SprintFunc()
+	styleAgent  = color.New(color.FgCyan, color.Bold).SprintFunc()
+	styleTool   = color.New(color.FgMagenta).SprintFunc()
+	styleWarn   = color.New(color.FgYellow, color.Bold).SprintFunc()
+	styleError  = color.New(color.FgRed).SprintFunc()
+	styleMuted  = color.New(color.Faint).SprintFunc()
+	styleHeader = color.New(color.Bold).SprintFunc()
+)
+
+// App is one interactive terminal session.
+type App struct {
+	backend   Backend
+	in        io.Reader
+	out       io.Writer
+	session   string
+	lastTrace string
+	newTrace  func() string
+}
+
+// New creates an App reading commands from in and rendering to out.
+func New(backend Backend, in io.Reader, out io.Writer, sessionKey string) *App {
+	if strings.TrimSpace(sessionKey) == "" {
+		sessionKey = "cli:default"
+	}
+	return &App{
+		backend: backend,
+		in:      in,
+		out:     out,
+		session: sessionKey,
+		newTrace: func() string {
+			return "tui-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+		},
+	}
+}
+
+// Session returns the active session key.
+func (a *App) Session() string { return a.session }
+
+// Run reads input until EOF, /quit or ctx cancellation.
+func (a *App) Run(ctx context.Context) error {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(a.in)
+		sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for sc.Scan() {
+			select {
+			case lines <- sc.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	a.printf("%s  %s\n", styleHeader("KafClaw chat"), styleMuted("("+a.backend.Name()+", session "+a.session+", /help for commands)"))
+	for {
+		a.printf("%s ", styleUser("›"))
+		var line string
+		select {
+		case <-ctx.Done():
+			return nil
+		case l, ok := <-lines:
+			if !ok {
+				a.printf("\n")
+				return nil
+			}
+			line = strings.TrimSpace(l)
+		}
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "/") {
+			if a.command(ctx, line) {
+				return nil
+			}
+			continue
+		}
+		a.chat(ctx, line, lines)
+	}
+}
+
+type chatResult struct {
+	reply string
+	err   error
+}
+
+// chat runs one turn, rendering progress and answering approval prompts from
+// input lines that arrive while the turn is in flight.
+func (a *App) chat(ctx context.Context, text string, lines <-chan string) {
+	traceID := a.newTrace()
+	events := make(chan Event, 64)
+	done := make(chan chatResult, 1)
+	turnCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		reply, err := a.backend.Chat(turnCtx, a.session, traceID, text, events)
+		done <- chatResult{reply: reply, err: err}
+	}()
+
+	var pending []Event
+	for {
+		select {
+		case ev := <-events:
+			if ev.Kind == EventApproval {
+				pending = append(pending, ev)
+			}
+			a.renderEvent(ev, len(pending))
+		case line, ok := <-lines:
+			if !ok {
+				lines = nil
+				continue
+			}
+			line = strings.TrimSpace(line)
+			if line == "/cancel" {
+				cancel()
+				continue
+			}
+			if len(pending) == 0 {
+				if line != "" {
+					a.printf("%s\n", styleMuted("(agent is working; /cancel to abort)"))
+				}
+				continue
+			}
+			approved, ok := parseApprovalAnswer(line)
+			if !ok {
+				a.printf("%s\n", styleMuted("answer y (approve) or n (deny)"))
+				continue
+			}
+			ev := pending[0]
+			pending = pending[1:]
+			if err := a.backend.RespondApproval(ctx, ev.ApprovalID, approved); err != nil {
+				a.printf("%s\n", styleError("approval failed: "+err.Error()))
+			} else if approved {
+				a.printf("%s\n", styleMuted("approved "+ev.ApprovalID))
+			} else {
+				a.printf("%s\n", styleMuted("denied "+ev.ApprovalID))
+			}
+			if len(pending) > 0 {
+				a.renderEvent(pending[0], len(pending))
+			}
+		case res := <-done:
+		drain:
+			for {
+				select {
+				case ev := <-events:
+					a.renderEvent(ev, 0)
+				default:
+					break drain
+				}
+			}
+			a.lastTrace = traceID
+			if res.err != nil {
+				a.printf("%s\n", styleError("error: "+res.err.Error()))
+				return
+			}
+			a.printf("%s\n%s\n\n", styleAgent("kafclaw"), RenderMarkdown(strings.TrimSpace(res.reply)))
+			return
+		}
+	}
+}
+
+func (a *App) renderEvent(ev Event, pending int) {
+	switch ev.Kind {
+	case EventToolStart:
+		a.printf("  %s %s %s\n", styleTool("⚙"), ev.Tool, styleMuted(truncate(ev.Args, 80)))
+	case EventToolDone:
+		status := styleMuted(fmt.Sprintf("done in %s", ev.Duration.Round(time.Millisecond)))
+		if ev.Err != "" {
+			status = styleError("failed: " + truncate(ev.Err, 80))
+		}
+		a.printf("  %s %s %s\n", styleTool("✓"), ev.Tool, status)
+	case EventApproval:
+		a.printf("%s %s (tier %d) %s\n", styleWarn("approval required:"), ev.Tool, ev.Tier, styleMuted(truncate(ev.Args, 120)))
+		suffix := ""
+		if pending > 1 {
+			suffix = fmt.Sprintf(" (%d pending)", pending)
+		}
+		a.printf("  approve %s? [y/n]%s ", ev.ApprovalID, suffix)
+	}
+}
+
+func parseApprovalAnswer(s string) (approved, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "y", "yes", "approve", "a", "ok":
+		return true, true
+	case "n", "no", "deny", "d":
+		return false, true
+	}
+	return false, false
+}
+
+// command handles a slash command. It returns true when the UI should exit.
+func (a *App) command(ctx context.Context, line string) bool {
+	fields := strings.Fields(line)
+	name, args := strings.ToLower(fields[0]), fields[1:]
+	switch name {
+	case "/quit", "/exit", "/q":
+		return true
+	case "/help", "/?":
+		a.printf("%s\n", strings.Join([]string{
+			"/sessions             list sessions",
+			"/session <key>        switch session (shows recent history)",
+			"/new [name]           start a fresh session",
+			"/memory               memory system status",
+			"/model [name]         show or change the model",
+			"/subagents            subagent runs for this session",
+			"/trace [trace-id]     steps of the last (or given) turn",
+			"/cancel               abort the running turn",
+			"/quit                 exit",
+		}, "\n"))
+	case "/sessions":
+		a.cmdSessions(ctx)
+	case "/session":
+		if len(args) == 0 {
+			a.printf("current session: %s\n", a.session)
+			return false
+		}
+		a.switchSession(ctx, args[0])
+	case "/new":
+		key := "cli:" + strconv.FormatInt(time.Now().Unix(), 10)
+		if len(args) > 0 {
+			key = args[0]
+			if !strings.Contains(key, ":") {
+				key = "cli:" + key
+			}
+		}
+		a.session = key
+		a.printf("%s\n", styleMuted("new session "+key))
+	case "/memory":
+		a.cmdMemory(ctx)
+	case "/model":
+		a.cmdModel(ctx, args)
+	case "/subagents":
+		a.cmdSubagents(ctx)
+	case "/trace":
+		id := a.lastTrace
+		if len(args) > 0 {
+			id = args[0]
+		}
+		a.cmdTrace(ctx, id)
+	case "/cancel":
+		a.printf("%s\n", styleMuted("nothing to cancel"))
+	default:
+		a.printf("%s\n", styleError("unknown command "+name+" (try /help)"))
+	}
+	return false
+}
+
+func (a *App) cmdSessions(ctx context.Context) {
+	list, err := a.backend.Sessions(ctx)
+	if err != nil {
+		a.printErr(err)
+		return
+	}
+	if len(list) == 0 {
+		a.printf("%s\n", styleMuted("no sessions yet"))
+		return
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].UpdatedAt.After(list[j].UpdatedAt) })
+	for _, s := range list {
+		marker := " "
+		if s.Key == a.session {
+			marker = "*"
+		}
+		updated := "-"
+		if !s.UpdatedAt.IsZero() {
+			updated = s.UpdatedAt.Local().Format("2006-01-02 15:04")
+		}
+		a.printf("%s %-40s %s\n", marker, s.Key, styleMuted(updated))
+	}
+}
+
+func (a *App) switchSession(ctx context.Context, key string) {
+	a.session = key
+	a.printf("%s\n", styleMuted("switched to "+key))
+	history, err := a.backend.History(ctx, key, 6)
+	if err != nil {
+		if !errors.Is(err, ErrUnsupported) {
+			a.printErr(err)
+		}
+		return
+	}
+	for _, m := range history {
+		who := styleUser("you")
+		if m.Role == "assistant" {
+			who = styleAgent("kafclaw")
+		}
+		a.printf("%s %s\n", who, truncate(strings.TrimSpace(m.Content), 200))
+	}
+}
+
+func (a *App) cmdMemory(ctx context.Context) {
+	st, err := a.backend.Memory(ctx)
+	if err != nil {
+		a.printErr(err)
+		return
+	}
+	a.printf("%s %d chunks\n", styleHeader("memory:"), st.TotalChunks)
+	names := make([]string, 0, len(st.Layers))
+	for name := range st.Layers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		a.printf("  %-14s %d\n", name, st.Layers[name])
+	}
+	a.printf("  %-14s %d\n", "working", st.WorkingEntries)
+	if st.WorkingPreview != "" {
+		a.printf("%s\n", styleMuted("  "+truncate(st.WorkingPreview, 200)))
+	}
+	for i, obs := range st.Observations {
+		if i == 5 {
+			break
+		}
+		a.printf("  %s %s\n", styleMuted("obs"), truncate(obs, 120))
+	}
+}
+
+func (a *App) cmdModel(ctx context.Context, args []string) {
+	if len(args) > 0 {
+		if err := a.backend.SetModel(ctx, args[0]); err != nil {
+			a.printErr(err)
+			return
+		}
+	}
+	model, err := a.backend.Model(ctx)
+	if err != nil {
+		a.printErr(err)
+		return
+	}
+	a.printf("model: %s\n", model)
+}
+
+func (a *App) cmdSubagents(ctx context.Context) {
+	runs, err := a.backend.Subagents(ctx, a.session)
+	if err != nil {
+		a.printErr(err)
+		return
+	}
+	if len(runs) == 0 {
+		a.printf("%s\n", styleMuted("no subagent runs"))
+		return
+	}
+	for _, r := range runs {
+		label := r.Label
+		if label == "" {
+			label = truncate(r.Task, 60)
+		}
+		a.printf("  %-10s %-12s %s\n", r.RunID, r.Status, label)
+		if r.Error != "" {
+			a.printf("  %s\n", styleError(truncate(r.Error, 120)))
+		}
+	}
+}
+
+func (a *App) cmdTrace(ctx context.Context, traceID string) {
+	if traceID == "" {
+		a.printf("%s\n", styleMuted("no turn yet; pass a trace id"))
+		return
+	}
+	spans, err := a.backend.Trace(ctx, traceID)
+	if err != nil {
+		a.printErr(err)
+		return
+	}
+	a.printf("%s %s\n", styleHeader("trace"), traceID)
+	if len(spans) == 0 {
+		a.printf("%s\n", styleMuted("  no spans recorded"))
+	}
+	for _, s := range spans {
+		a.printf("  %s %-8s %-8s %s %s\n", styleMuted(s.Time), s.Type, s.Duration, s.Title, styleMuted(truncate(s.Output, 80)))
+	}
+}
+
+func (a *App) printErr(err error) {
+	if errors.Is(err, ErrUnsupported) {
+		a.printf("%s\n", styleMuted(err.Error()))
+		return
+	}
+	a.printf("%s\n", styleError("error: "+err.Error()))
+}
+
+func (a *App) printf(format string, args ...any) {
+	fmt.Fprintf(a.out, format, args...)
+}
+
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
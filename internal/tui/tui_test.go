@@ -0,0 +1,194 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/session"
+	"github.com/KafClaw/KafClaw/internal/tools"
+)
+
+type fakeBackend struct {
+	mu        sync.Mutex
+	model     string
+	sessions  []session.SessionInfo
+	history   map[string][]session.Message
+	responses map[string]bool
+	chats     []string
+	approvals chan bool
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		model:     "test-model",
+		history:   map[string][]session.Message{},
+		responses: map[string]bool{},
+		approvals: make(chan bool, 1),
+	}
+}
+
+func (f *fakeBackend) Name() string { return "fake" }
+
+// Chat emits a tool call and, for texts containing "approve", an approval
+// that must be answered before the reply is returned.
+func (f *fakeBackend) Chat(ctx context.Context, sessionKey, traceID, text string, events chan<- Event) (string, error) {
+	f.mu.Lock()
+	f.chats = append(f.chats, sessionKey+"|"+text)
+	f.mu.Unlock()
+	events <- Event{Kind: EventToolStart, TraceID: traceID, Tool: "exec", Args: `{"command":"ls"}`}
+	if strings.Contains(text, "approve") {
+		events <- Event{Kind: EventApproval, TraceID: traceID, Tool: "exec", Tier: 2, ApprovalID: "ap1"}
+		select {
+		case ok := <-f.approvals:
+			if !ok {
+				return "denied it", nil
+			}
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	events <- Event{Kind: EventToolDone, TraceID: traceID, Tool: "exec", Duration: 12 * time.Millisecond}
+	return "# Done\n\n- item **one**", nil
+}
+
+func (f *fakeBackend) RespondApproval(_ context.Context, id string, approved bool) error {
+	f.mu.Lock()
+	f.responses[id] = approved
+	f.mu.Unlock()
+	f.approvals <- approved
+	return nil
+}
+
+func (f *fakeBackend) Sessions(context.Context) ([]session.SessionInfo, error) {
+	return f.sessions, nil
+}
+
+func (f *fakeBackend) History(_ context.Context, key string, _ int) ([]session.Message, error) {
+	return f.history[key], nil
+}
+
+func (f *fakeBackend) Memory(context.Context) (MemoryStatus, error) {
+	return MemoryStatus{Layers: map[string]int{"conversation": 3}, TotalChunks: 3}, nil
+}
+
+func (f *fakeBackend) Model(context.Context) (string, error) { return f.model, nil }
+
+func (f *fakeBackend) SetModel(_ context.Context, m string) error {
+	f.model = m
+	return nil
+}
+
+func (f *fakeBackend) Subagents(context.Context, string) ([]tools.SubagentRunView, error) {
+	return []tools.SubagentRunView{{RunID: "run-1", Status: "running", Task: "research"}}, nil
+}
+
+func (f *fakeBackend) Trace(_ context.Context, id string) ([]Span, error) {
+	return []Span{{Type: "TOOL", Title: "exec", Output: id}}, nil
+}
+
+// runApp feeds input lines one at a time, waiting for the output to contain
+// each step's marker before sending its line.
+func runApp(t *testing.T, backend Backend, steps []struct{ waitFor, line string }) string {
+	t.Helper()
+	pr, pw := io.Pipe()
+	out := &syncBuffer{}
+	app := New(backend, pr, out, "cli:default")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- app.Run(ctx) }()
+	for _, step := range steps {
+		deadline := time.Now().Add(3 * time.Second)
+		for !strings.Contains(out.String(), step.waitFor) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %q; output:\n%s", step.waitFor, out.String())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		if _, err := io.WriteString(pw, step.line+"\n"); err != nil {
+			t.Fatalf("write input: %v", err)
+		}
+	}
+	pw.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	return out.String()
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestAppChatRendersProgressAndInlineApproval(t *testing.T) {
+	fb := newFakeBackend()
+	out := runApp(t, fb, []struct{ waitFor, line string }{
+		{"KafClaw chat", "please approve this"},
+		{"approve ap1? [y/n]", "maybe"},
+		{"answer y (approve) or n (deny)", "y"},
+		{"item one", "/trace"},
+		{"trace tui-", "/quit"},
+	})
+	if !fb.responses["ap1"] {
+		t.Fatalf("expected approval to be granted, got %+v", fb.responses)
+	}
+	for _, want := range []string{"⚙ exec", "approval required: exec (tier 2)", "approved ap1", "✓ exec done in 12ms", "DONE", "• item one"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestAppSlashCommands(t *testing.T) {
+	fb := newFakeBackend()
+	fb.sessions = []session.SessionInfo{
+		{Key: "cli:default", UpdatedAt: time.Now()},
+		{Key: "cli:work", UpdatedAt: time.Now().Add(-time.Hour)},
+	}
+	fb.history["cli:work"] = []session.Message{{Role: "user", Content: "earlier question"}, {Role: "assistant", Content: "earlier answer"}}
+
+	out := runApp(t, fb, []struct{ waitFor, line string }{
+		{"KafClaw chat", "/sessions"},
+		{"cli:work", "/session cli:work"},
+		{"earlier answer", "hello"},
+		{"item one", "/model other-model"},
+		{"model: other-model", "/memory"},
+		{"conversation", "/subagents"},
+		{"run-1", "/bogus"},
+		{"unknown command /bogus", "/quit"},
+	})
+	if len(fb.chats) != 1 || fb.chats[0] != "cli:work|hello" {
+		t.Fatalf("expected chat in switched session, got %v", fb.chats)
+	}
+	if !strings.Contains(out, "* cli:default") {
+		t.Fatalf("expected active session marker:\n%s", out)
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	in := "## Plan\n1. first `code` step\n* [docs](https://example.org)\n> quoted\n```go\nx := **1**\n```\nplain *emph*"
+	got := RenderMarkdown(in)
+	for _, want := range []string{"Plan", "  1. first code step", "  • docs (https://example.org)", "▌ quoted", "┌ go", "│ x := **1**", "└", "plain emph"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("rendered markdown missing %q:\n%s", want, got)
+		}
+	}
+}
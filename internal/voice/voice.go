@@ -0,0 +1,319 @@
+// Package voice transcribes inbound audio and synthesizes voice replies
+// independently of the channel that carried the message.
+package voice
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+// ErrReplyTooLong is returned by Synthesize for replies above MaxReplyChars.
+var ErrReplyTooLong = errors.New("reply too long for voice synthesis")
+
+// Transcriber converts audio to text.
+type Transcriber interface {
+	Transcribe(ctx context.Context, req *provider.AudioRequest) (*provider.AudioResponse, error)
+}
+
+// Speaker converts text to audio.
+type Speaker interface {
+	Speak(ctx context.Context, req *provider.TTSRequest) (*provider.TTSResponse, error)
+}
+
+// Store persists transcripts and per-chat reply preferences. It is
+// implemented by timeline.TimelineService.
+type Store interface {
+	GetVoiceTranscript(audioSHA256 string) (string, bool, error)
+	SaveVoiceTranscript(audioSHA256, transcript, source string) error
+	GetVoiceReplyMode(channel, chatID string) (string, bool, error)
+	SetVoiceReplyMode(channel, chatID, mode string) error
+}
+
+// Converter converts an audio file to another container format and returns
+// the path of the converted file.
+type Converter interface {
+	Convert(ctx context.Context, src, format string) (string, error)
+}
+
+// audioExts lists extensions treated as audio media.
+var audioExts = map[string]bool{
+	".ogg": true, ".oga": true, ".opus": true, ".mp3": true, ".m4a": true,
+	".wav": true, ".webm": true, ".flac": true, ".aac": true, ".amr": true, ".mpga": true,
+}
+
+// transcribableExts are accepted by Whisper without conversion.
+var transcribableExts = map[string]bool{
+	".ogg": true, ".oga": true, ".mp3": true, ".m4a": true, ".wav": true,
+	".webm": true, ".flac": true, ".mpga": true,
+}
+
+// channelAudioFormats maps channels to the format their clients play inline.
+var channelAudioFormats = map[string]string{
+	"whatsapp": "ogg", // Opus voice note (PTT)
+	"slack":    "mp3",
+}
+
+// IsAudio reports whether a media path or URL looks like an audio file.
+func IsAudio(media string) bool {
+	u := strings.TrimSpace(media)
+	if i := strings.IndexAny(u, "?#"); i >= 0 {
+		u = u[:i]
+	}
+	return audioExts[strings.ToLower(filepath.Ext(u))]
+}
+
+// ChannelAudioFormat returns the preferred voice reply format for a channel,
+// or "" when the synthesized format is used as-is.
+func ChannelAudioFormat(channel string) string {
+	return channelAudioFormats[strings.ToLower(strings.TrimSpace(channel))]
+}
+
+// SupportsReplies reports whether voice replies can be attached on a channel.
+func SupportsReplies(channel string) bool {
+	return ChannelAudioFormat(channel) != ""
+}
+
+// Pipeline transcribes inbound audio (local Whisper first, provider
+// fallback, cached by content hash) and synthesizes voice replies.
+type Pipeline struct {
+	cfg      config.VoiceConfig
+	local    Transcriber
+	fallback Transcriber
+	speaker  Speaker
+	store    Store
+	convert  Converter
+	mediaDir string
+}
+
+// NewPipeline creates a voice pipeline. local may be nil when no local
+// Whisper is configured; store may be nil to disable caching and per-chat
+// preferences. Synthesized audio is written below mediaDir.
+func NewPipeline(cfg config.VoiceConfig, local, fallback Transcriber, speaker Speaker, store Store, mediaDir string) *Pipeline {
+	return &Pipeline{
+		cfg:      cfg,
+		local:    local,
+		fallback: fallback,
+		speaker:  speaker,
+		store:    store,
+		convert:  FFmpegConverter{Path: cfg.FFmpegPath},
+		mediaDir: mediaDir,
+	}
+}
+
+// Transcribe returns the transcript of a local audio file.
+func (p *Pipeline) Transcribe(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read audio: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if p.store != nil {
+		if text, ok, err := p.store.GetVoiceTranscript(hash); err == nil && ok {
+			return text, nil
+		}
+	}
+
+	input := path
+	if !transcribableExts[strings.ToLower(filepath.Ext(path))] {
+		converted, err := p.convert.Convert(ctx, path, "wav")
+		if err != nil {
+			return "", fmt.Errorf("convert audio for transcription: %w", err)
+		}
+		defer os.Remove(converted)
+		input = converted
+	}
+
+	var text, source string
+	var errs []error
+	if p.local != nil {
+		if resp, err := p.local.Transcribe(ctx, &provider.AudioRequest{FilePath: input}); err == nil {
+			text, source = resp.Text, "local-whisper"
+		} else {
+			slog.Warn("Local transcription failed, falling back to provider", "error", err)
+			errs = append(errs, err)
+		}
+	}
+	if source == "" && p.fallback != nil {
+		if resp, err := p.fallback.Transcribe(ctx, &provider.AudioRequest{FilePath: input}); err == nil {
+			text, source = resp.Text, "provider"
+		} else {
+			errs = append(errs, err)
+		}
+	}
+	if source == "" {
+		if len(errs) == 0 {
+			return "", fmt.Errorf("no transcriber configured")
+		}
+		return "", fmt.Errorf("transcription failed: %w", errors.Join(errs...))
+	}
+	text = strings.TrimSpace(text)
+	if p.store != nil {
+		if err := p.store.SaveVoiceTranscript(hash, text, source); err != nil {
+			slog.Warn("Transcript cache write failed", "error", err)
+		}
+	}
+	return text, nil
+}
+
+// ReplyMode returns the effective voice reply mode for a chat: the per-chat
+// preference if set, else the configured default.
+func (p *Pipeline) ReplyMode(channel, chatID string) string {
+	if p.store != nil {
+		if mode, ok, err := p.store.GetVoiceReplyMode(channel, chatID); err == nil && ok {
+			return mode
+		}
+	}
+	switch p.cfg.ReplyMode {
+	case config.VoiceReplyInbound, config.VoiceReplyAlways:
+		return p.cfg.ReplyMode
+	default:
+		return config.VoiceReplyOff
+	}
+}
+
+// SetReplyMode stores a per-chat reply mode; "" restores the default.
+func (p *Pipeline) SetReplyMode(channel, chatID, mode string) error {
+	switch mode {
+	case "", config.VoiceReplyOff, config.VoiceReplyInbound, config.VoiceReplyAlways:
+	default:
+		return fmt.Errorf("unknown voice reply mode %q", mode)
+	}
+	if p.store == nil {
+		return fmt.Errorf("voice preferences unavailable")
+	}
+	return p.store.SetVoiceReplyMode(channel, chatID, mode)
+}
+
+// ShouldReply reports whether a reply in this chat gets a voice attachment.
+func (p *Pipeline) ShouldReply(channel, chatID string, inboundVoice bool) bool {
+	switch p.ReplyMode(channel, chatID) {
+	case config.VoiceReplyAlways:
+		return true
+	case config.VoiceReplyInbound:
+		return inboundVoice
+	default:
+		return false
+	}
+}
+
+// Synthesize speaks text and returns the path of an audio file in the
+// channel's preferred format. When conversion fails, the synthesized
+// format is returned unchanged.
+func (p *Pipeline) Synthesize(ctx context.Context, channel, text string) (string, error) {
+	spoken := SpeakableText(text)
+	if spoken == "" {
+		return "", fmt.Errorf("nothing to speak")
+	}
+	if p.cfg.MaxReplyChars > 0 && len(spoken) > p.cfg.MaxReplyChars {
+		return "", ErrReplyTooLong
+	}
+	if p.speaker == nil {
+		return "", fmt.Errorf("no speech synthesizer configured")
+	}
+	resp, err := p.speaker.Speak(ctx, &provider.TTSRequest{Text: spoken, Voice: p.cfg.TTSVoice})
+	if err != nil {
+		return "", fmt.Errorf("speak: %w", err)
+	}
+	if len(resp.AudioData) == 0 {
+		return "", fmt.Errorf("speak: empty audio")
+	}
+
+	dir := filepath.Join(p.mediaDir, "tts")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(resp.AudioData)
+	path := filepath.Join(dir, hex.EncodeToString(sum[:8])+"."+synthesizedExt(resp.Format))
+	if err := os.WriteFile(path, resp.AudioData, 0o644); err != nil {
+		return "", err
+	}
+
+	want := ChannelAudioFormat(channel)
+	if want == "" || strings.TrimPrefix(filepath.Ext(path), ".") == want {
+		return path, nil
+	}
+	converted, err := p.convert.Convert(ctx, path, want)
+	if err != nil {
+		slog.Warn("Voice reply conversion failed, sending synthesized format", "channel", channel, "format", want, "error", err)
+		return path, nil
+	}
+	_ = os.Remove(path)
+	return converted, nil
+}
+
+// synthesizedExt maps a TTS response format to a file extension. OpenAI's
+// "opus" output is Ogg-contained.
+func synthesizedExt(format string) string {
+	switch f := strings.ToLower(strings.TrimSpace(format)); f {
+	case "", "opus", "ogg":
+		return "ogg"
+	default:
+		return f
+	}
+}
+
+var (
+	mdFence  = regexp.MustCompile("(?s)```.*?```")
+	mdLink   = regexp.MustCompile(`\[([^\]]+)\]\([^)]+\)`)
+	mdMarker = regexp.MustCompile("[*_`#>]+")
+	spaces   = regexp.MustCompile(`[ \t]+`)
+)
+
+// SpeakableText strips markdown so a reply reads naturally when spoken.
+// Code blocks are dropped.
+func SpeakableText(text string) string {
+	text = mdFence.ReplaceAllString(text, "")
+	text = mdLink.ReplaceAllString(text, "$1")
+	text = mdMarker.ReplaceAllString(text, "")
+	lines := strings.Split(text, "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		line = strings.TrimSpace(spaces.ReplaceAllString(line, " "))
+		line = strings.TrimPrefix(line, "- ")
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// FFmpegConverter converts audio with the ffmpeg binary.
+type FFmpegConverter struct {
+	Path string
+}
+
+// Convert writes src as format next to it and returns the new path.
+func (c FFmpegConverter) Convert(ctx context.Context, src, format string) (string, error) {
+	bin := strings.TrimSpace(c.Path)
+	if bin == "" {
+		bin = "ffmpeg"
+	}
+	dst := strings.TrimSuffix(src, filepath.Ext(src)) + ".conv." + format
+	args := []string{"-y", "-loglevel", "error", "-i", src}
+	switch format {
+	case "wav":
+		args = append(args, "-ac", "1", "-ar", "16000")
+	case "ogg":
+		args = append(args, "-c:a", "libopus", "-b:a", "32k")
+	case "mp3":
+		args = append(args, "-c:a", "libmp3lame", "-q:a", "4")
+	}
+	args = append(args, dst)
+	if out, err := exec.CommandContext(ctx, bin, args...).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("ffmpeg: %w (output: %s)", err, strings.TrimSpace(string(out)))
+	}
+	return dst, nil
+}
@@ -0,0 +1,182 @@
+package voice
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+type fakeTranscriber struct {
+	text  string
+	err   error
+	calls []string
+}
+
+func (f *fakeTranscriber) Transcribe(_ context.Context, req *provider.AudioRequest) (*provider.AudioResponse, error) {
+	f.calls = append(f.calls, req.FilePath)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &provider.AudioResponse{Text: f.text}, nil
+}
+
+type fakeSpeaker struct{ texts []string }
+
+func (f *fakeSpeaker) Speak(_ context.Context, req *provider.TTSRequest) (*provider.TTSResponse, error) {
+	f.texts = append(f.texts, req.Text)
+	return &provider.TTSResponse{AudioData: []byte("OggS" + req.Text), Format: "opus"}, nil
+}
+
+type fakeConverter struct {
+	err   error
+	calls []string
+}
+
+func (f *fakeConverter) Convert(_ context.Context, src, format string) (string, error) {
+	f.calls = append(f.calls, filepath.Ext(src)+"->"+format)
+	if f.err != nil {
+		return "", f.err
+	}
+	dst := strings.TrimSuffix(src, filepath.Ext(src)) + ".conv." + format
+	return dst, os.WriteFile(dst, []byte("converted"), 0o644)
+}
+
+func newTestStore(t *testing.T) *timeline.TimelineService {
+	t.Helper()
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("new timeline: %v", err)
+	}
+	t.Cleanup(func() { tl.Close() })
+	return tl
+}
+
+func TestTranscribeLocalFirstWithFallbackAndCache(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "note.ogg")
+	if err := os.WriteFile(audio, []byte("voice-bytes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	local := &fakeTranscriber{err: errors.New("whisper not installed")}
+	fallback := &fakeTranscriber{text: "  hello there "}
+	p := NewPipeline(config.VoiceConfig{}, local, fallback, nil, newTestStore(t), dir)
+
+	got, err := p.Transcribe(context.Background(), audio)
+	if err != nil || got != "hello there" {
+		t.Fatalf("transcribe = %q, %v", got, err)
+	}
+	if len(local.calls) != 1 || len(fallback.calls) != 1 {
+		t.Fatalf("expected local then fallback, got %d/%d", len(local.calls), len(fallback.calls))
+	}
+
+	// Same bytes under another name hit the timeline cache.
+	copyPath := filepath.Join(dir, "forwarded.ogg")
+	_ = os.WriteFile(copyPath, []byte("voice-bytes"), 0o644)
+	got, err = p.Transcribe(context.Background(), copyPath)
+	if err != nil || got != "hello there" {
+		t.Fatalf("cached transcribe = %q, %v", got, err)
+	}
+	if len(local.calls) != 1 || len(fallback.calls) != 1 {
+		t.Fatal("expected cached transcript to skip transcribers")
+	}
+}
+
+func TestTranscribeConvertsUnsupportedFormats(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "note.amr")
+	_ = os.WriteFile(audio, []byte("amr-bytes"), 0o644)
+	local := &fakeTranscriber{text: "converted speech"}
+	conv := &fakeConverter{}
+	p := NewPipeline(config.VoiceConfig{}, local, nil, nil, nil, dir)
+	p.convert = conv
+
+	got, err := p.Transcribe(context.Background(), audio)
+	if err != nil || got != "converted speech" {
+		t.Fatalf("transcribe = %q, %v", got, err)
+	}
+	if len(conv.calls) != 1 || conv.calls[0] != ".amr->wav" || !strings.HasSuffix(local.calls[0], ".conv.wav") {
+		t.Fatalf("expected wav conversion, conv=%v local=%v", conv.calls, local.calls)
+	}
+	if _, err := os.Stat(local.calls[0]); !os.IsNotExist(err) {
+		t.Fatal("expected converted temp file to be removed")
+	}
+
+	p.local = nil
+	if _, err := p.Transcribe(context.Background(), filepath.Join(dir, "missing.ogg")); err == nil {
+		t.Fatal("expected missing file error")
+	}
+}
+
+func TestReplyModeAndSynthesize(t *testing.T) {
+	dir := t.TempDir()
+	speaker := &fakeSpeaker{}
+	conv := &fakeConverter{}
+	p := NewPipeline(config.VoiceConfig{ReplyMode: config.VoiceReplyInbound, MaxReplyChars: 40}, nil, nil, speaker, newTestStore(t), dir)
+	p.convert = conv
+
+	if !p.ShouldReply("whatsapp", "chat1", true) || p.ShouldReply("whatsapp", "chat1", false) {
+		t.Fatal("inbound mode should only voice replies to voice notes")
+	}
+	if err := p.SetReplyMode("whatsapp", "chat1", config.VoiceReplyAlways); err != nil {
+		t.Fatalf("set mode: %v", err)
+	}
+	if !p.ShouldReply("whatsapp", "chat1", false) || p.ShouldReply("whatsapp", "chat2", false) {
+		t.Fatal("per-chat preference should only apply to its chat")
+	}
+	if err := p.SetReplyMode("whatsapp", "chat1", "loud"); err == nil {
+		t.Fatal("expected unknown mode to be rejected")
+	}
+	_ = p.SetReplyMode("whatsapp", "chat1", "")
+	if p.ReplyMode("whatsapp", "chat1") != config.VoiceReplyInbound {
+		t.Fatal("clearing the preference should restore the default")
+	}
+
+	wa, err := p.Synthesize(context.Background(), "whatsapp", "## Done\n- **all** set, see [docs](https://x.y)")
+	if err != nil {
+		t.Fatalf("synthesize whatsapp: %v", err)
+	}
+	if filepath.Ext(wa) != ".ogg" || len(conv.calls) != 0 {
+		t.Fatalf("whatsapp should keep synthesized ogg, got %s conv=%v", wa, conv.calls)
+	}
+	if speaker.texts[0] != "Done\nall set, see docs" {
+		t.Fatalf("markdown not stripped: %q", speaker.texts[0])
+	}
+
+	slack, err := p.Synthesize(context.Background(), "slack", "short answer")
+	if err != nil || !strings.HasSuffix(slack, ".conv.mp3") {
+		t.Fatalf("synthesize slack = %q, %v", slack, err)
+	}
+
+	conv.err = errors.New("ffmpeg missing")
+	fallback, err := p.Synthesize(context.Background(), "slack", "another answer")
+	if err != nil || filepath.Ext(fallback) != ".ogg" {
+		t.Fatalf("expected synthesized format on conversion failure, got %q, %v", fallback, err)
+	}
+
+	if _, err := p.Synthesize(context.Background(), "whatsapp", strings.Repeat("long ", 20)); !errors.Is(err, ErrReplyTooLong) {
+		t.Fatalf("expected ErrReplyTooLong, got %v", err)
+	}
+}
+
+func TestIsAudioAndChannelFormats(t *testing.T) {
+	for media, want := range map[string]bool{
+		"/tmp/a.OGG":                    true,
+		"https://x.test/v.m4a?sig=1":    true,
+		"/tmp/photo.jpg":                false,
+		"https://x.test/doc.pdf#page=2": false,
+	} {
+		if IsAudio(media) != want {
+			t.Errorf("IsAudio(%q) != %v", media, want)
+		}
+	}
+	if !SupportsReplies("WhatsApp") || !SupportsReplies("slack") || SupportsReplies("matrix") {
+		t.Fatal("unexpected channel voice support")
+	}
+}